@@ -0,0 +1,126 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/docker"
+)
+
+// defaultAllowlistMaxManifestBytes is the maximum size of manifests that can
+// be inspected by MediaTypeAllowlistTarget.
+const defaultAllowlistMaxManifestBytes int64 = 4 * 1024 * 1024 // 4 MiB
+
+// MediaTypeAllowlistTarget represents a Target that only accepts pushing
+// content of allowed media types.
+type MediaTypeAllowlistTarget struct {
+	Target                     // underlying target
+	AllowedMediaTypes []string // media types allowed to be pushed
+}
+
+// AllowMediaTypes returns a target that only accepts pushing content of the
+// given media types.
+func AllowMediaTypes(target Target, mediaTypes ...string) *MediaTypeAllowlistTarget {
+	return &MediaTypeAllowlistTarget{
+		Target:            target,
+		AllowedMediaTypes: mediaTypes,
+	}
+}
+
+// Push pushes the content, matching the expected descriptor.
+// The media type of the expected descriptor must be allowed. If the content is
+// a manifest, the media type parsed from the content must be allowed as well.
+// A Docker schema 1 manifest is parsed as
+// "application/vnd.docker.distribution.manifest.v1+json", or as
+// "application/vnd.docker.distribution.manifest.v1+prettyjws" if signed.
+func (t *MediaTypeAllowlistTarget) Push(ctx context.Context, expected ocispec.Descriptor, r io.Reader) error {
+	if err := t.checkMediaType(expected.MediaType); err != nil {
+		return fmt.Errorf("%s: %w", expected.Digest, err)
+	}
+	if !isManifestMediaType(expected.MediaType) {
+		return t.Target.Push(ctx, expected, r)
+	}
+
+	if expected.Size > defaultAllowlistMaxManifestBytes {
+		return fmt.Errorf(
+			"%s: manifest size %v exceeds limit %v: %w",
+			expected.Digest,
+			expected.Size,
+			defaultAllowlistMaxManifestBytes,
+			errdef.ErrSizeExceedsLimit)
+	}
+	manifestJSON, err := content.ReadAll(r, expected)
+	if err != nil {
+		return err
+	}
+	mediaType, err := parseManifestMediaType(manifestJSON)
+	if err != nil {
+		return fmt.Errorf("%s: failed to parse manifest: %w", expected.Digest, err)
+	}
+	if mediaType != "" {
+		if err := t.checkMediaType(mediaType); err != nil {
+			return fmt.Errorf("%s: manifest content: %w", expected.Digest, err)
+		}
+	}
+	return t.Target.Push(ctx, expected, bytes.NewReader(manifestJSON))
+}
+
+// checkMediaType returns an error if mediaType is not allowed.
+func (t *MediaTypeAllowlistTarget) checkMediaType(mediaType string) error {
+	if slices.Contains(t.AllowedMediaTypes, mediaType) {
+		return nil
+	}
+	return fmt.Errorf("media type %q is not allowed: %w", mediaType, errdef.ErrUnsupported)
+}
+
+// isManifestMediaType checks if mediaType is a manifest media type, including
+// the deprecated Docker schema 1 media types.
+func isManifestMediaType(mediaType string) bool {
+	return isSchema1MediaType(mediaType) || descriptor.IsManifest(ocispec.Descriptor{MediaType: mediaType})
+}
+
+// parseManifestMediaType returns the media type declared by manifestJSON.
+// An empty string is returned if no media type can be determined.
+func parseManifestMediaType(manifestJSON []byte) (string, error) {
+	var manifest struct {
+		SchemaVersion int              `json:"schemaVersion"`
+		MediaType     string           `json:"mediaType"`
+		Signatures    *json.RawMessage `json:"signatures"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return "", err
+	}
+	if manifest.MediaType != "" {
+		return manifest.MediaType, nil
+	}
+	if manifest.SchemaVersion == 1 {
+		if manifest.Signatures != nil {
+			return docker.MediaTypeManifestSchema1Signed, nil
+		}
+		return docker.MediaTypeManifestSchema1, nil
+	}
+	return "", nil
+}
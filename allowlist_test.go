@@ -0,0 +1,113 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/docker"
+)
+
+func TestMediaTypeAllowlistTarget_Push(t *testing.T) {
+	layer := []byte("foo")
+	layerDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, layer)
+	configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, []byte("{}"))
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema1JSON := []byte(`{"schemaVersion":1,"name":"test","tag":"latest","fsLayers":[],"history":[]}`)
+	signedSchema1JSON := []byte(`{"schemaVersion":1,"name":"test","tag":"latest","fsLayers":[],"history":[],"signatures":[]}`)
+
+	tests := []struct {
+		name    string
+		desc    ocispec.Descriptor
+		content []byte
+		wantErr error
+	}{
+		{
+			name:    "allowed layer",
+			desc:    layerDesc,
+			content: layer,
+		},
+		{
+			name:    "allowed OCI manifest",
+			desc:    content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON),
+			content: manifestJSON,
+		},
+		{
+			name:    "disallowed layer",
+			desc:    content.NewDescriptorFromBytes(docker.MediaTypeForeignLayer, layer),
+			content: layer,
+			wantErr: errdef.ErrUnsupported,
+		},
+		{
+			name:    "disallowed schema 1 manifest",
+			desc:    content.NewDescriptorFromBytes(docker.MediaTypeManifestSchema1, schema1JSON),
+			content: schema1JSON,
+			wantErr: errdef.ErrUnsupported,
+		},
+		{
+			name:    "schema 1 manifest disguised as OCI manifest",
+			desc:    content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, schema1JSON),
+			content: schema1JSON,
+			wantErr: errdef.ErrUnsupported,
+		},
+		{
+			name:    "signed schema 1 manifest disguised as OCI manifest",
+			desc:    content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, signedSchema1JSON),
+			content: signedSchema1JSON,
+			wantErr: errdef.ErrUnsupported,
+		},
+		{
+			name:    "mismatched manifest content",
+			desc:    content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON),
+			content: bytes.Repeat([]byte("x"), len(manifestJSON)),
+			wantErr: content.ErrMismatchedDigest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.New()
+			target := AllowMediaTypes(store, ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageLayer)
+
+			err := target.Push(ctx, tt.desc, bytes.NewReader(tt.content))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MediaTypeAllowlistTarget.Push() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			exists, err := store.Exists(ctx, tt.desc)
+			if err != nil {
+				t.Fatalf("Store.Exists() error = %v", err)
+			}
+			if want := tt.wantErr == nil; exists != want {
+				t.Errorf("Store.Exists() = %v, want %v", exists, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/cas"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
+)
+
+// CompareReport records the differences found by [Compare] between the graph
+// in the source and the destination.
+type CompareReport struct {
+	// Root is the root node resolved from the source.
+	Root ocispec.Descriptor
+	// Present lists the nodes of the source graph that exist in the
+	// destination.
+	Present []ocispec.Descriptor
+	// Absent lists the nodes of the source graph that do not exist in the
+	// destination.
+	Absent []ocispec.Descriptor
+	// Mismatched lists the descriptors the reference resolves to in the
+	// destination, if they do not match the root resolved from the source.
+	// A reference not found in the destination is not reported as
+	// mismatched, but the root is reported as absent if it does not exist.
+	Mismatched []ocispec.Descriptor
+}
+
+// InSync returns true if the destination has the same graph as the source
+// under the compared reference.
+func (r *CompareReport) InSync() bool {
+	return len(r.Absent) == 0 && len(r.Mismatched) == 0
+}
+
+// Compare compares the rooted directed acyclic graph (DAG) identified by the
+// reference in the source Target against the destination Target, without
+// copying any content.
+// The graph is walked in the source, and each node, except foreign layers, is
+// checked for existence in the destination. The root resolved from the
+// reference in the destination is also compared with the one resolved from the
+// source.
+func Compare(ctx context.Context, src ReadOnlyTarget, dst ReadOnlyTarget, ref string) (*CompareReport, error) {
+	if src == nil {
+		return nil, errors.New("nil source target")
+	}
+	if dst == nil {
+		return nil, errors.New("nil destination target")
+	}
+
+	proxy := cas.NewProxyWithLimit(src, cas.NewMemory(), defaultCopyMaxMetadataBytes)
+	root, err := resolveRoot(ctx, src, ref, proxy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	report := &CompareReport{
+		Root: root,
+	}
+
+	dstRoot, err := dst.Resolve(ctx, ref)
+	switch {
+	case err == nil:
+		if !content.Equal(dstRoot, root) {
+			report.Mismatched = append(report.Mismatched, dstRoot)
+		}
+	case errors.Is(err, errdef.ErrNotFound):
+		// the root is reported as absent if it does not exist
+	default:
+		return nil, fmt.Errorf("failed to resolve %s in the destination: %w", ref, err)
+	}
+
+	visited := set.New[descriptor.Descriptor]()
+	nodes := []ocispec.Descriptor{root}
+	for len(nodes) > 0 {
+		desc := nodes[len(nodes)-1]
+		nodes = nodes[:len(nodes)-1]
+		key := descriptor.FromOCI(desc)
+		if visited.Contains(key) {
+			continue
+		}
+		visited.Add(key)
+
+		exists, err := dst.Exists(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			report.Present = append(report.Present, desc)
+		} else {
+			report.Absent = append(report.Absent, desc)
+		}
+
+		successors, err := content.Successors(ctx, proxy, desc)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, removeForeignLayers(successors)...)
+	}
+	return report, nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+func TestCompare(t *testing.T) {
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1:3]...)                  // Blob 3
+	generateManifest(descs[0], descs[1])                       // Blob 4
+
+	ctx := context.Background()
+	push := func(s *memory.Store, indices ...int) {
+		for _, i := range indices {
+			if err := s.Push(ctx, descs[i], bytes.NewReader(blobs[i])); err != nil {
+				t.Fatalf("failed to push test content: %d: %v", i, err)
+			}
+		}
+	}
+	ref := "foobar"
+	src := memory.New()
+	push(src, 0, 1, 2, 3)
+	if err := src.Tag(ctx, descs[3], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// in sync
+	dst := memory.New()
+	push(dst, 0, 1, 2, 3)
+	if err := dst.Tag(ctx, descs[3], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+	report, err := oras.Compare(ctx, src, dst, ref)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if !report.InSync() {
+		t.Errorf("Compare() = %+v, want in sync", report)
+	}
+	if !reflect.DeepEqual(report.Root, descs[3]) {
+		t.Errorf("CompareReport.Root = %v, want %v", report.Root, descs[3])
+	}
+	if got := len(report.Present); got != 4 {
+		t.Errorf("CompareReport.Present = %v, want 4 descriptors", report.Present)
+	}
+
+	// missing layer
+	dst = memory.New()
+	push(dst, 0, 1, 3)
+	if err := dst.Tag(ctx, descs[3], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+	report, err = oras.Compare(ctx, src, dst, ref)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if report.InSync() {
+		t.Error("CompareReport.InSync() = true, want false")
+	}
+	if want := []ocispec.Descriptor{descs[2]}; !reflect.DeepEqual(report.Absent, want) {
+		t.Errorf("CompareReport.Absent = %v, want %v", report.Absent, want)
+	}
+	if len(report.Mismatched) != 0 {
+		t.Errorf("CompareReport.Mismatched = %v, want none", report.Mismatched)
+	}
+	if got := len(report.Present); got != 3 {
+		t.Errorf("CompareReport.Present = %v, want 3 descriptors", report.Present)
+	}
+
+	// mismatched reference
+	dst = memory.New()
+	push(dst, 0, 1, 2, 3, 4)
+	if err := dst.Tag(ctx, descs[4], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+	report, err = oras.Compare(ctx, src, dst, ref)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{descs[4]}; !reflect.DeepEqual(report.Mismatched, want) {
+		t.Errorf("CompareReport.Mismatched = %v, want %v", report.Mismatched, want)
+	}
+	if len(report.Absent) != 0 {
+		t.Errorf("CompareReport.Absent = %v, want none", report.Absent)
+	}
+
+	// empty destination
+	report, err = oras.Compare(ctx, src, memory.New(), ref)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if got := len(report.Absent); got != 4 {
+		t.Errorf("CompareReport.Absent = %v, want 4 descriptors", report.Absent)
+	}
+	if len(report.Mismatched) != 0 {
+		t.Errorf("CompareReport.Mismatched = %v, want none", report.Mismatched)
+	}
+
+	// reference not found in the source
+	if _, err := oras.Compare(ctx, src, dst, "unknown"); err == nil {
+		t.Error("Compare() error = nil, wantErr true")
+	}
+}
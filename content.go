@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
@@ -195,6 +196,27 @@ func Resolve(ctx context.Context, target ReadOnlyTarget, reference string, opts
 	return resolve(ctx, target, nil, reference, opts)
 }
 
+// HostPlatform returns the platform of the running host, which is derived from
+// runtime.GOOS and runtime.GOARCH.
+func HostPlatform() *ocispec.Platform {
+	return &ocispec.Platform{
+		OS:           runtime.GOOS,
+		Architecture: runtime.GOARCH,
+	}
+}
+
+// ResolvePlatform resolves a descriptor with provided reference from the
+// target, selecting the manifest matching the target platform if the reference
+// points to a manifest list.
+// If opts.TargetPlatform is nil, the platform of the running host returned by
+// [HostPlatform] is used.
+func ResolvePlatform(ctx context.Context, target ReadOnlyTarget, reference string, opts ResolveOptions) (ocispec.Descriptor, error) {
+	if opts.TargetPlatform == nil {
+		opts.TargetPlatform = HostPlatform()
+	}
+	return resolve(ctx, target, nil, reference, opts)
+}
+
 // resolve resolves a descriptor with provided reference from the target, with
 // specified caching.
 func resolve(ctx context.Context, target ReadOnlyTarget, proxy *cas.Proxy, reference string, opts ResolveOptions) (ocispec.Descriptor, error) {
@@ -0,0 +1,95 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/internal/copyutil"
+)
+
+// CopyNodeStatus describes how the content is made available in the
+// destination by CopyNode.
+type CopyNodeStatus int
+
+const (
+	// CopyNodeExists indicates that the content already exists in the
+	// destination, and nothing is transferred.
+	CopyNodeExists CopyNodeStatus = iota
+	// CopyNodeMounted indicates that the content is mounted from one of the
+	// source repositories.
+	CopyNodeMounted
+	// CopyNodeCopied indicates that the content is fetched from the source
+	// and pushed to the destination.
+	CopyNodeCopied
+)
+
+// String returns the name of the status.
+func (s CopyNodeStatus) String() string {
+	switch s {
+	case CopyNodeExists:
+		return "exists"
+	case CopyNodeMounted:
+		return "mounted"
+	case CopyNodeCopied:
+		return "copied"
+	default:
+		return "unknown"
+	}
+}
+
+// CopyNodeOptions contains parameters for CopyNode.
+type CopyNodeOptions struct {
+	// MountFrom returns the candidate repositories that desc may be mounted
+	// from. The candidates are tried in order, and the content is fetched
+	// from the source if none of them can be mounted.
+	// Mounting is only attempted for blobs and only if dst supports
+	// cross-repository blob mounts, such as registry.Mounter.
+	MountFrom func(ctx context.Context, desc ocispec.Descriptor) ([]string, error)
+}
+
+// CopyNode copies a single content described by desc from src to dst, without
+// traversing its successors. The content is skipped if it already exists in
+// dst, otherwise it is mounted if opts.MountFrom is set and dst supports
+// mounting, or it is fetched from src and pushed to dst.
+// The returned status tells how the content got into dst.
+func CopyNode(ctx context.Context, src ReadOnlyStorage, dst Storage, desc ocispec.Descriptor, opts CopyNodeOptions) (CopyNodeStatus, error) {
+	exists, err := dst.Exists(ctx, desc)
+	if err != nil {
+		return 0, err
+	}
+	if exists {
+		return CopyNodeExists, nil
+	}
+
+	fetch := func(ctx context.Context) (io.ReadCloser, error) {
+		return src.Fetch(ctx, desc)
+	}
+	status, err := copyutil.CopyNode(ctx, dst, desc, opts.MountFrom, fetch)
+	if err != nil {
+		return 0, err
+	}
+	switch status {
+	case copyutil.NodeExists:
+		return CopyNodeExists, nil
+	case copyutil.NodeMounted:
+		return CopyNodeMounted, nil
+	default:
+		return CopyNodeCopied, nil
+	}
+}
@@ -0,0 +1,166 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// mountableStorage is a storage supporting cross-repository blob mounts from
+// the repositories in repos, and records the attempted repositories.
+type mountableStorage struct {
+	*memory.Store
+	repos     map[string]content.ReadOnlyStorage
+	mountFrom []string
+}
+
+func (s *mountableStorage) Mount(ctx context.Context, desc ocispec.Descriptor, fromRepo string, getContent func() (io.ReadCloser, error)) error {
+	s.mountFrom = append(s.mountFrom, fromRepo)
+	if repo, ok := s.repos[fromRepo]; ok {
+		if exists, err := repo.Exists(ctx, desc); err != nil {
+			return err
+		} else if exists {
+			rc, err := repo.Fetch(ctx, desc)
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			return s.Push(ctx, desc, rc)
+		}
+	}
+	rc, err := getContent()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return s.Push(ctx, desc, rc)
+}
+
+func TestCopyNode(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("hello world")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+	src := memory.New()
+	if err := src.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	mountable := memory.New()
+	if err := mountable.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+
+	tests := []struct {
+		name          string
+		mountFrom     []string
+		wantStatus    content.CopyNodeStatus
+		wantMountFrom []string
+	}{
+		{
+			name:       "fetch-push without mount candidates",
+			wantStatus: content.CopyNodeCopied,
+		},
+		{
+			name:          "mount from the first candidate",
+			mountFrom:     []string{"mountable", "unknown"},
+			wantStatus:    content.CopyNodeMounted,
+			wantMountFrom: []string{"mountable"},
+		},
+		{
+			name:          "mount from the second candidate",
+			mountFrom:     []string{"unknown", "mountable"},
+			wantStatus:    content.CopyNodeMounted,
+			wantMountFrom: []string{"unknown", "mountable"},
+		},
+		{
+			name:          "fall back to fetch-push when mount fails",
+			mountFrom:     []string{"unknown", "another-unknown"},
+			wantStatus:    content.CopyNodeCopied,
+			wantMountFrom: []string{"unknown", "another-unknown"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := &mountableStorage{
+				Store: memory.New(),
+				repos: map[string]content.ReadOnlyStorage{"mountable": mountable},
+			}
+			var opts content.CopyNodeOptions
+			if tt.mountFrom != nil {
+				opts.MountFrom = func(ctx context.Context, d ocispec.Descriptor) ([]string, error) {
+					return tt.mountFrom, nil
+				}
+			}
+			status, err := content.CopyNode(ctx, src, dst, desc, opts)
+			if err != nil {
+				t.Fatal("CopyNode() error =", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("CopyNode() = %v, want %v", status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(dst.mountFrom, tt.wantMountFrom) {
+				t.Errorf("mounted from %v, want %v", dst.mountFrom, tt.wantMountFrom)
+			}
+			got, err := content.FetchAll(ctx, dst, desc)
+			if err != nil {
+				t.Fatal("FetchAll() error =", err)
+			}
+			if !bytes.Equal(got, blob) {
+				t.Errorf("FetchAll() = %v, want %v", got, blob)
+			}
+
+			// copy again
+			status, err = content.CopyNode(ctx, src, dst, desc, opts)
+			if err != nil {
+				t.Fatal("CopyNode() error =", err)
+			}
+			if status != content.CopyNodeExists {
+				t.Errorf("CopyNode() = %v, want %v", status, content.CopyNodeExists)
+			}
+		})
+	}
+}
+
+func TestCopyNode_Error(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("hello world")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+	dst := &mountableStorage{Store: memory.New()}
+
+	// content missing in the source
+	if _, err := content.CopyNode(ctx, memory.New(), dst, desc, content.CopyNodeOptions{}); err == nil {
+		t.Error("CopyNode() error = nil, wantErr true")
+	}
+
+	// MountFrom fails
+	errMountFrom := errors.New("mount from error")
+	opts := content.CopyNodeOptions{
+		MountFrom: func(ctx context.Context, d ocispec.Descriptor) ([]string, error) {
+			return nil, errMountFrom
+		},
+	}
+	if _, err := content.CopyNode(ctx, memory.New(), dst, desc, opts); !errors.Is(err, errMountFrom) {
+		t.Errorf("CopyNode() error = %v, want %v", err, errMountFrom)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/docker"
+)
+
+// defaultExtractMaxMetadataBytes is the maximum size of the manifest read by
+// Extract.
+const defaultExtractMaxMetadataBytes int64 = 4 * 1024 * 1024 // 4 MiB
+
+// ExtractOptions contains parameters for [Extract].
+type ExtractOptions struct {
+	// DisableOverwrite controls if existing files in the directory can be
+	// overwritten. When specified, extracting a layer to an existing path
+	// fails with [ErrOverwriteDisallowed].
+	// Default value: false.
+	DisableOverwrite bool
+	// IgnoreNoName controls if layers without the
+	// `org.opencontainers.image.title` annotation are skipped. Otherwise,
+	// extracting such a layer fails with [ErrMissingName].
+	// Default value: false.
+	IgnoreNoName bool
+}
+
+// Extract materializes the layers of the manifest described by desc, fetched
+// from src, to the directory dir, in the same way as they are pulled to a
+// [Store]:
+//   - a layer is saved as a file named by its `org.opencontainers.image.title`
+//     annotation.
+//   - a layer annotated with [AnnotationUnpack] is extracted as a
+//     directory named by its title annotation. The layer must be a tarball,
+//     either uncompressed or compressed by gzip or zstd, which is detected by
+//     the magic bytes of the layer regardless of its media type, so that the
+//     directories packed by [Store.Add] under custom media types are also
+//     supported. See also [UnpackSniffMagic].
+//
+// The layers are verified against their digests, and writing files outside
+// dir, by either the layer titles or the entries of the tarballs, is
+// rejected.
+func Extract(ctx context.Context, src content.ReadOnlyStorage, desc ocispec.Descriptor, dir string, opts ExtractOptions) (err error) {
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest, docker.MediaTypeManifest:
+	default:
+		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrUnsupported)
+	}
+	if desc.Size > defaultExtractMaxMetadataBytes {
+		return fmt.Errorf(
+			"content size %v exceeds MaxMetadataBytes %v: %w",
+			desc.Size,
+			defaultExtractMaxMetadataBytes,
+			errdef.ErrSizeExceedsLimit)
+	}
+	manifestJSON, err := content.FetchAll(ctx, src, desc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return fmt.Errorf("failed to decode manifest: %w", err)
+	}
+
+	store, err := New(dir)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := store.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	store.DisableOverwrite = opts.DisableOverwrite
+	store.UnpackCompressionMode = UnpackSniffMagic
+
+	for i, layer := range manifest.Layers {
+		name := layer.Annotations[ocispec.AnnotationTitle]
+		if name == "" {
+			if opts.IgnoreNoName {
+				continue
+			}
+			return fmt.Errorf("layer %d: %s: %w", i, layer.Digest, ErrMissingName)
+		}
+		if err := extractLayer(ctx, src, store, layer); err != nil {
+			return fmt.Errorf("layer %d: %s: %w", i, name, err)
+		}
+	}
+	return nil
+}
+
+// extractLayer copies the layer described by desc from src to store.
+func extractLayer(ctx context.Context, src content.ReadOnlyStorage, store *Store, desc ocispec.Descriptor) error {
+	rc, err := src.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return store.Push(ctx, desc, rc)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// tarball creates an uncompressed tarball of the entries in turn, where the
+// names ending with "/" are directories and the others are regular files with
+// the content followed.
+func tarball(t *testing.T, entries ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i < len(entries); i++ {
+		name := entries[i]
+		if strings.HasSuffix(name, "/") {
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     name,
+				Mode:     0755,
+			}); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		i++
+		data := entries[i]
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(data)),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// tarGzip creates a gzip compressed tarball of the entries.
+// See also: tarball.
+func tarGzip(t *testing.T, entries ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(tarball(t, entries...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// tarZstd creates a zstd compressed tarball of the entries.
+// See also: tarball.
+func tarZstd(t *testing.T, entries ...string) []byte {
+	t.Helper()
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zw.Close()
+	return zw.EncodeAll(tarball(t, entries...), nil)
+}
+
+// pushExtractTestManifest pushes a manifest with the given layers to a memory
+// store.
+func pushExtractTestManifest(t *testing.T, layers []ocispec.Descriptor, blobs [][]byte) (*memory.Store, ocispec.Descriptor) {
+	t.Helper()
+	ctx := context.Background()
+	s := memory.New()
+	for i, layer := range layers {
+		if err := s.Push(ctx, layer, bytes.NewReader(blobs[i])); err != nil {
+			t.Fatal("Store.Push() error =", err)
+		}
+	}
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    layers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	if err := s.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	return s, desc
+}
+
+// newExtractTestLayer creates a layer descriptor for blob with the given
+// title, which is unpacked if unpack is true.
+func newExtractTestLayer(mediaType, title string, unpack bool, blob []byte) ocispec.Descriptor {
+	desc := content.NewDescriptorFromBytes(mediaType, blob)
+	desc.Annotations = map[string]string{
+		ocispec.AnnotationTitle: title,
+	}
+	if unpack {
+		desc.Annotations[AnnotationUnpack] = "true"
+	}
+	return desc
+}
+
+func TestExtract(t *testing.T) {
+	fileBlob := []byte("hello world")
+	dirBlob := tarGzip(t,
+		"dir/",
+		"dir/foo.txt", "foo",
+		"dir/sub/",
+		"dir/sub/bar.txt", "bar",
+	)
+	tarBlob := tarball(t, "tar/", "tar/foo.txt", "foo")
+	zstdBlob := tarZstd(t, "zstd/", "zstd/foo.txt", "foo")
+	// directories packed by Store.Add under a custom media type are gzipped
+	customBlob := tarGzip(t, "custom/", "custom/foo.txt", "foo")
+	layers := []ocispec.Descriptor{
+		newExtractTestLayer("test/file", "hello.txt", false, fileBlob),
+		newExtractTestLayer(ocispec.MediaTypeImageLayerGzip, "dir", true, dirBlob),
+		newExtractTestLayer(ocispec.MediaTypeImageLayer, "tar", true, tarBlob),
+		newExtractTestLayer(ocispec.MediaTypeImageLayerZstd, "zstd", true, zstdBlob),
+		newExtractTestLayer("test/dir", "custom", true, customBlob),
+	}
+	src, desc := pushExtractTestManifest(t, layers, [][]byte{fileBlob, dirBlob, tarBlob, zstdBlob, customBlob})
+
+	dir := t.TempDir()
+	if err := Extract(context.Background(), src, desc, dir, ExtractOptions{}); err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	want := map[string]string{
+		"hello.txt":       "hello world",
+		"dir/foo.txt":     "foo",
+		"dir/sub/bar.txt": "bar",
+		"tar/foo.txt":     "foo",
+		"zstd/foo.txt":    "foo",
+		"custom/foo.txt":  "foo",
+	}
+	for name, data := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("failed to read %s: %v", name, err)
+			continue
+		}
+		if string(got) != data {
+			t.Errorf("content of %s = %s, want %s", name, got, data)
+		}
+	}
+
+	// overwriting can be disallowed
+	err := Extract(context.Background(), src, desc, dir, ExtractOptions{DisableOverwrite: true})
+	if !errors.Is(err, ErrOverwriteDisallowed) {
+		t.Errorf("Extract() error = %v, want %v", err, ErrOverwriteDisallowed)
+	}
+}
+
+func TestExtract_InvalidLayers(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("hello world")
+	layer := newExtractTestLayer("test/file", "../evil.txt", false, blob)
+	src, desc := pushExtractTestManifest(t, []ocispec.Descriptor{layer}, [][]byte{blob})
+	dir := t.TempDir()
+	if err := Extract(ctx, src, desc, filepath.Join(dir, "out"), ExtractOptions{}); !errors.Is(err, ErrPathTraversalDisallowed) {
+		t.Errorf("Extract() error = %v, want %v", err, ErrPathTraversalDisallowed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("evil.txt is written outside of the directory")
+	}
+
+	// path traversal in tarball entries
+	tarBlob := tarGzip(t, "dir/", "dir/../../evil.txt", "evil")
+	layer = newExtractTestLayer(ocispec.MediaTypeImageLayerGzip, "dir", true, tarBlob)
+	src, desc = pushExtractTestManifest(t, []ocispec.Descriptor{layer}, [][]byte{tarBlob})
+	if err := Extract(ctx, src, desc, filepath.Join(dir, "out"), ExtractOptions{}); !errors.Is(err, ErrPathTraversalDisallowed) {
+		t.Errorf("Extract() error = %v, want %v", err, ErrPathTraversalDisallowed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("evil.txt is written outside of the directory")
+	}
+
+	// missing name
+	layer = content.NewDescriptorFromBytes("test/file", blob)
+	src, desc = pushExtractTestManifest(t, []ocispec.Descriptor{layer}, [][]byte{blob})
+	if err := Extract(ctx, src, desc, t.TempDir(), ExtractOptions{}); !errors.Is(err, ErrMissingName) {
+		t.Errorf("Extract() error = %v, want %v", err, ErrMissingName)
+	}
+	if err := Extract(ctx, src, desc, t.TempDir(), ExtractOptions{IgnoreNoName: true}); err != nil {
+		t.Errorf("Extract(IgnoreNoName) error = %v", err)
+	}
+
+	// unsupported manifest
+	if err := Extract(ctx, src, layer, t.TempDir(), ExtractOptions{}); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Extract() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+}
@@ -36,6 +36,28 @@ import (
 	"oras.land/oras-go/v2/internal/resolver"
 )
 
+// UnpackCompressionMode specifies how the compression format of the content to
+// be unpacked is determined.
+type UnpackCompressionMode int
+
+const (
+	// UnpackAssumeGzip decompresses the content to be unpacked as gzip
+	// regardless of its media type.
+	UnpackAssumeGzip UnpackCompressionMode = iota
+	// UnpackTrustMediaType strictly determines the compression format by the
+	// media type: the content is decompressed by the decompressor registered
+	// for the suffix of the media type, such as "+gzip" or "+zstd", and is
+	// extracted as an uncompressed tarball if the media type has no suffix.
+	UnpackTrustMediaType
+	// UnpackSniffMagic determines the compression format by the magic bytes
+	// of the content regardless of the media type, which is robust against
+	// mislabeled content. Only gzip and zstd can be detected.
+	UnpackSniffMagic
+)
+
+// Decompressor returns a reader decompressing the content read from r.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
 // bufPool is a pool of byte buffers that can be reused for copying content
 // between files.
 var bufPool = sync.Pool{
@@ -108,10 +130,44 @@ type Store struct {
 	// value overrides the [AnnotationUnpack].
 	// Default value: false.
 	SkipUnpack bool
+	// UnpackCompressionMode specifies how the compression format of the
+	// content to be unpacked is determined. The digest of the content is
+	// always verified on the raw bytes before decompression.
+	// Default value: UnpackAssumeGzip.
+	UnpackCompressionMode UnpackCompressionMode
+	// Decompressors maps the compression formats, named by the media type
+	// suffixes such as "gzip" for "+gzip", to the decompressors used for
+	// unpacking, which can be used to register custom codecs. The registered
+	// decompressors override the built-in ones for "gzip" and "zstd".
+	// Content in a compression format without a decompressor is not unpacked
+	// but saved as is to the file named by [ocispec.AnnotationTitle].
+	// Default value: nil.
+	Decompressors map[string]Decompressor
+	// TempDir specifies the directory for the temporary files created by the
+	// file store, such as the tarballs of the added or pushed directories.
+	// Each temporary file is named with a random suffix, so that the directory
+	// can be shared by multiple stores and processes without collisions. The
+	// temporary files are removed on Close.
+	// If empty, the default directory returned by [os.TempDir] is used.
+	// Default value: "".
+	TempDir string
+	// DedupeByHardlink controls if a pushed file is created as a hard link
+	// to an existing file previously pushed to the file store with the same
+	// digest, instead of writing the content again, which saves the disk
+	// space for artifacts sharing identical files. The existing file is
+	// verified against the digest before linking, and the files added by
+	// [Store.Add] are never linked to. If a hard link cannot be created, such
+	// as across devices, the content is written as usual.
+	// When specified, an existing file at the path to write is removed
+	// instead of being overwritten in place, so that the files sharing its
+	// content via hard links are not affected.
+	// Default value: false.
+	DedupeByHardlink bool
 
 	workingDir   string   // the working directory of the file store
 	closed       int32    // if the store is closed - 0: false, 1: true.
 	digestToPath sync.Map // map[digest.Digest]string
+	pushedFiles  sync.Map // map[digest.Digest]string, files written by pushFile
 	nameToStatus sync.Map // map[string]*nameStatus
 	tmpFiles     sync.Map // map[string]bool
 
@@ -471,20 +527,86 @@ func (s *Store) pushFile(target string, expected ocispec.Descriptor, content io.
 		return fmt.Errorf("failed to ensure directories of the target path: %w", err)
 	}
 
+	if s.DedupeByHardlink {
+		linked, err := s.pushLink(target, expected)
+		if err != nil {
+			return err
+		}
+		if linked {
+			s.pushedFiles.Store(expected.Digest, target)
+			return nil
+		}
+	}
+
 	fp, err := os.Create(target)
 	if err != nil {
 		return fmt.Errorf("failed to create file %s: %w", target, err)
 	}
 
-	return s.saveFile(fp, expected, content)
+	if err := s.saveFile(fp, expected, content); err != nil {
+		return err
+	}
+	s.pushedFiles.Store(expected.Digest, target)
+	return nil
 }
 
-// pushDir saves content matching the descriptor to the target directory.
-func (s *Store) pushDir(name, target string, expected ocispec.Descriptor, content io.Reader) (err error) {
-	if err := ensureDir(target); err != nil {
-		return fmt.Errorf("failed to ensure directories of the target path: %w", err)
+// pushLink creates the target path as a hard link to an existing file with the
+// digest of the descriptor, and returns true if the link is created.
+// Only the files previously written by pushFile are linked to, and their
+// content is verified against the descriptor before linking, so that neither
+// the files added by the caller nor the files modified since written are
+// shared.
+// The existing file at the target path is removed in any case, which may share
+// its content with other files.
+func (s *Store) pushLink(target string, expected ocispec.Descriptor) (bool, error) {
+	var source string
+	if v, ok := s.pushedFiles.Load(expected.Digest); ok {
+		source = v.(string)
+		if filepath.Clean(source) == filepath.Clean(target) {
+			if verifyFile(source, expected) {
+				// the content is already in place
+				return true, nil
+			}
+			source = ""
+		}
+	}
+	if fi, err := os.Lstat(target); err == nil && !fi.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return false, fmt.Errorf("failed to remove file %s: %w", target, err)
+		}
+	}
+	if source == "" || !verifyFile(source, expected) {
+		// the existing file is gone or modified
+		return false, nil
 	}
+	if err := os.Link(source, target); err != nil {
+		// hard links are not supported, fall back to writing the content
+		return false, nil
+	}
+	return true, nil
+}
 
+// verifyFile returns true if the file at path is a regular file matching the
+// size and the digest of the descriptor.
+func verifyFile(path string, expected ocispec.Descriptor) bool {
+	fp, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer fp.Close()
+	fi, err := fp.Stat()
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() != expected.Size {
+		return false
+	}
+	buf := bufPool.Get().(*[]byte)
+	defer bufPool.Put(buf)
+	return ioutil.CopyBuffer(io.Discard, fp, *buf, expected) == nil
+}
+
+// pushDir saves content matching the descriptor to the target directory.
+// If the content is compressed in an unknown format, it is saved as a file to
+// the target path instead.
+func (s *Store) pushDir(name, target string, expected ocispec.Descriptor, content io.Reader) (err error) {
 	gz, err := s.tempFile()
 	if err != nil {
 		return err
@@ -496,15 +618,52 @@ func (s *Store) pushDir(name, target string, expected ocispec.Descriptor, conten
 		return fmt.Errorf("failed to save gzip to %s: %w", gzPath, err)
 	}
 
+	var comp compression
+	switch s.UnpackCompressionMode {
+	case UnpackTrustMediaType:
+		comp = compressionFromMediaType(expected.MediaType)
+	case UnpackSniffMagic:
+		if comp, err = sniffCompression(gzPath); err != nil {
+			return fmt.Errorf("failed to detect compression of %s: %w", gzPath, err)
+		}
+	default:
+		comp = compressionGzip
+	}
+	var decompress Decompressor
+	if comp != compressionNone {
+		var ok bool
+		if decompress, ok = s.decompressor(comp); !ok {
+			// fall through to the raw content
+			fp, err := os.Open(gzPath)
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", gzPath, err)
+			}
+			defer fp.Close()
+			return s.pushFile(target, expected, fp)
+		}
+	}
+
+	if err := ensureDir(target); err != nil {
+		return fmt.Errorf("failed to ensure directories of the target path: %w", err)
+	}
 	checksum := expected.Annotations[AnnotationDigest]
 	buf := bufPool.Get().(*[]byte)
 	defer bufPool.Put(buf)
-	if err := extractTarGzip(target, name, gzPath, checksum, *buf); err != nil {
+	if err := extractTarball(target, name, gzPath, checksum, decompress, *buf); err != nil {
 		return fmt.Errorf("failed to extract tar to %s: %w", target, err)
 	}
 	return nil
 }
 
+// decompressor returns the decompressor for the compression format comp.
+func (s *Store) decompressor(comp compression) (Decompressor, bool) {
+	if decompress, ok := s.Decompressors[string(comp)]; ok && decompress != nil {
+		return decompress, true
+	}
+	decompress, ok := defaultDecompressors[string(comp)]
+	return decompress, ok
+}
+
 // descriptorFromDir generates descriptor from the given directory.
 func (s *Store) descriptorFromDir(ctx context.Context, name, mediaType, dir string) (desc ocispec.Descriptor, err error) {
 	// make a temp file to store the gzip
@@ -649,10 +808,10 @@ func (s *Store) nameExists(name string) bool {
 	return status.exists
 }
 
-// tempFile creates a temp file with the file name format "oras_file_randomString",
-// and returns the pointer to the temp file.
+// tempFile creates a temp file under s.TempDir with the file name format
+// "oras_file_randomString", and returns the pointer to the temp file.
 func (s *Store) tempFile() (*os.File, error) {
-	tmp, err := os.CreateTemp("", "oras_file_*")
+	tmp, err := os.CreateTemp(s.TempDir, "oras_file_*")
 	if err != nil {
 		return nil, err
 	}
@@ -17,6 +17,7 @@ package file
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	_ "crypto/sha256"
 	"encoding/json"
@@ -26,10 +27,13 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"testing"
+	"testing/iotest"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/errgroup"
@@ -42,6 +46,9 @@ import (
 	"oras.land/oras-go/v2/internal/spec"
 )
 
+// errAny is a placeholder for any error expected in tests.
+var errAny = errors.New("any error")
+
 // storageTracker tracks storage API counts.
 type storageTracker struct {
 	content.Storage
@@ -717,6 +724,221 @@ func TestStore_Dir_Push_SkipUnpack(t *testing.T) {
 	}
 }
 
+func TestStore_Dir_Push_UnpackCompressionMode(t *testing.T) {
+	dirName := "testdir"
+	fileName := "test.txt"
+	wantContent := []byte("hello world")
+	tarData := createTar(t, []tarEntry{
+		{name: dirName + "/", mode: os.ModeDir | 0777},
+		{name: dirName + "/" + fileName, content: string(wantContent), mode: 0666},
+	})
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(tarData); err != nil {
+		t.Fatal("failed to write gzip:", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal("failed to close gzip writer:", err)
+	}
+	gzData := buf.Bytes()
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal("failed to create zstd writer:", err)
+	}
+	zstdData := zw.EncodeAll(tarData, nil)
+	if err := zw.Close(); err != nil {
+		t.Fatal("failed to close zstd writer:", err)
+	}
+
+	tests := []struct {
+		name      string
+		mode      UnpackCompressionMode
+		mediaType string
+		blob      []byte
+		wantErr   error // nil for success, errAny for any error
+	}{
+		{"sniff: gzip labeled as tar", UnpackSniffMagic, ocispec.MediaTypeImageLayer, gzData, nil},
+		{"sniff: tar labeled as gzip", UnpackSniffMagic, ocispec.MediaTypeImageLayerGzip, tarData, nil},
+		{"sniff: zstd labeled as tar", UnpackSniffMagic, ocispec.MediaTypeImageLayer, zstdData, nil},
+		{"trust media type: gzip labeled as tar", UnpackTrustMediaType, ocispec.MediaTypeImageLayer, gzData, errAny},
+		{"trust media type: tar", UnpackTrustMediaType, ocispec.MediaTypeImageLayer, tarData, nil},
+		{"trust media type: gzip", UnpackTrustMediaType, ocispec.MediaTypeImageLayerGzip, gzData, nil},
+		{"trust media type: zstd", UnpackTrustMediaType, ocispec.MediaTypeImageLayerZstd, zstdData, nil},
+		{"trust media type: zstd labeled as gzip", UnpackTrustMediaType, ocispec.MediaTypeImageLayerGzip, zstdData, errAny},
+		{"assume gzip: gzip labeled as tar", UnpackAssumeGzip, ocispec.MediaTypeImageLayer, gzData, nil},
+		{"assume gzip: tar", UnpackAssumeGzip, ocispec.MediaTypeImageLayer, tarData, errAny},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(t.TempDir())
+			if err != nil {
+				t.Fatal("Store.New() error =", err)
+			}
+			defer s.Close()
+			s.UnpackCompressionMode = tt.mode
+			ctx := context.Background()
+
+			desc := ocispec.Descriptor{
+				MediaType: tt.mediaType,
+				Digest:    digest.FromBytes(tt.blob),
+				Size:      int64(len(tt.blob)),
+				Annotations: map[string]string{
+					ocispec.AnnotationTitle: dirName,
+					AnnotationUnpack:        "true",
+				},
+			}
+			err = s.Push(ctx, desc, bytes.NewReader(tt.blob))
+			if tt.wantErr != nil {
+				if err == nil {
+					t.Fatal("Store.Push() error = nil, wantErr true")
+				}
+				if tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Store.Push() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal("Store.Push() error =", err)
+			}
+
+			// the raw bytes can be fetched
+			got, err := content.FetchAll(ctx, s, desc)
+			if err != nil {
+				t.Fatal("Store.Fetch() error =", err)
+			}
+			if !bytes.Equal(got, tt.blob) {
+				t.Errorf("Store.Fetch() = %v, want %v", got, tt.blob)
+			}
+			// the file is extracted
+			path := filepath.Join(s.workingDir, dirName, fileName)
+			fc, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read file %s: %v", path, err)
+			}
+			if !bytes.Equal(fc, wantContent) {
+				t.Errorf("file content = %v, want %v", fc, wantContent)
+			}
+		})
+	}
+
+	// the digest is verified on the raw bytes
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal("Store.New() error =", err)
+	}
+	defer s.Close()
+	s.UnpackCompressionMode = UnpackSniffMagic
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageLayer,
+		Digest:    digest.FromBytes(tarData),
+		Size:      int64(len(gzData)),
+		Annotations: map[string]string{
+			ocispec.AnnotationTitle: dirName,
+			AnnotationUnpack:        "true",
+		},
+	}
+	if err := s.Push(context.Background(), desc, bytes.NewReader(gzData)); !errors.Is(err, content.ErrMismatchedDigest) {
+		t.Errorf("Store.Push() error = %v, want %v", err, content.ErrMismatchedDigest)
+	}
+}
+
+func TestStore_Dir_Push_Decompressors(t *testing.T) {
+	dirName := "testdir"
+	fileName := "test.txt"
+	wantContent := []byte("hello world")
+	tarData := createTar(t, []tarEntry{
+		{name: dirName + "/", mode: os.ModeDir | 0777},
+		{name: dirName + "/" + fileName, content: string(wantContent), mode: 0666},
+	})
+	// a custom codec reversing the bytes
+	reverse := func(b []byte) []byte {
+		r := slices.Clone(b)
+		slices.Reverse(r)
+		return r
+	}
+	reversedData := reverse(tarData)
+	mediaType := "application/vnd.oci.image.layer.v1.tar+reversed"
+
+	tests := []struct {
+		name          string
+		decompressors map[string]Decompressor
+		wantExtracted bool
+	}{
+		{
+			name: "custom codec",
+			decompressors: map[string]Decompressor{
+				"reversed": func(r io.Reader) (io.ReadCloser, error) {
+					b, err := io.ReadAll(r)
+					if err != nil {
+						return nil, err
+					}
+					return io.NopCloser(bytes.NewReader(reverse(b))), nil
+				},
+			},
+			wantExtracted: true,
+		},
+		{
+			name:          "unknown codec",
+			wantExtracted: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(t.TempDir())
+			if err != nil {
+				t.Fatal("Store.New() error =", err)
+			}
+			defer s.Close()
+			s.UnpackCompressionMode = UnpackTrustMediaType
+			s.Decompressors = tt.decompressors
+			ctx := context.Background()
+
+			desc := ocispec.Descriptor{
+				MediaType: mediaType,
+				Digest:    digest.FromBytes(reversedData),
+				Size:      int64(len(reversedData)),
+				Annotations: map[string]string{
+					ocispec.AnnotationTitle: dirName,
+					AnnotationUnpack:        "true",
+				},
+			}
+			if err := s.Push(ctx, desc, bytes.NewReader(reversedData)); err != nil {
+				t.Fatal("Store.Push() error =", err)
+			}
+
+			// the raw bytes can be fetched
+			got, err := content.FetchAll(ctx, s, desc)
+			if err != nil {
+				t.Fatal("Store.Fetch() error =", err)
+			}
+			if !bytes.Equal(got, reversedData) {
+				t.Errorf("Store.Fetch() = %v, want %v", got, reversedData)
+			}
+
+			if tt.wantExtracted {
+				path := filepath.Join(s.workingDir, dirName, fileName)
+				fc, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatalf("failed to read file %s: %v", path, err)
+				}
+				if !bytes.Equal(fc, wantContent) {
+					t.Errorf("file content = %v, want %v", fc, wantContent)
+				}
+				return
+			}
+			// the raw blob is saved as a file
+			path := filepath.Join(s.workingDir, dirName)
+			fc, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read file %s: %v", path, err)
+			}
+			if !bytes.Equal(fc, reversedData) {
+				t.Errorf("file content = %v, want %v", fc, reversedData)
+			}
+		})
+	}
+}
+
 func TestStore_Push_NoName(t *testing.T) {
 	content := []byte("hello world")
 	desc := ocispec.Descriptor{
@@ -998,6 +1220,90 @@ func TestStore_Dir_Add(t *testing.T) {
 		t.Errorf("Store.Fetch() = %v, want %v", got, gotgz)
 	}
 }
+func TestStore_Dir_SharedTempDir(t *testing.T) {
+	// prepare the source directory
+	srcDir := t.TempDir()
+	dirName := "testdir"
+	dirPath := filepath.Join(srcDir, dirName)
+	if err := os.MkdirAll(dirPath, 0777); err != nil {
+		t.Fatal("error calling Mkdir(), error =", err)
+	}
+	if err := os.WriteFile(filepath.Join(dirPath, "test.txt"), []byte("hello world"), 0444); err != nil {
+		t.Fatal("error calling WriteFile(), error =", err)
+	}
+	ctx := context.Background()
+	src, err := New(srcDir)
+	if err != nil {
+		t.Fatal("Store.New() error =", err)
+	}
+	defer src.Close()
+	desc, err := src.Add(ctx, dirName, "", dirPath)
+	if err != nil {
+		t.Fatal("Store.Add() error =", err)
+	}
+	manifestDesc, err := oras.PackManifest(ctx, src, oras.PackManifestVersion1_1, "test/artifact", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{desc},
+	})
+	if err != nil {
+		t.Fatal("oras.PackManifest() error =", err)
+	}
+
+	// copy concurrently to stores sharing the temp dir
+	sharedTempDir := t.TempDir()
+	const n = 4
+	dsts := make([]*Store, n)
+	for i := range dsts {
+		if dsts[i], err = New(t.TempDir()); err != nil {
+			t.Fatal("Store.New() error =", err)
+		}
+		dsts[i].TempDir = sharedTempDir
+	}
+	eg, egCtx := errgroup.WithContext(ctx)
+	for _, dst := range dsts {
+		eg.Go(func() error {
+			// both the pushed directory and the added one spill to TempDir
+			if _, err := dst.Add(egCtx, dirName+"-added", "", dirPath); err != nil {
+				return err
+			}
+			return oras.CopyGraph(egCtx, src, dst, manifestDesc, oras.DefaultCopyGraphOptions)
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatal("failed to copy concurrently:", err)
+	}
+
+	entries, err := os.ReadDir(sharedTempDir)
+	if err != nil {
+		t.Fatal("os.ReadDir() error =", err)
+	}
+	if want := 2 * n; len(entries) != want {
+		t.Errorf("number of temp files = %d, want %d", len(entries), want)
+	}
+	for _, dst := range dsts {
+		got, err := os.ReadFile(filepath.Join(dst.workingDir, dirName, "test.txt"))
+		if err != nil {
+			t.Fatal("os.ReadFile() error =", err)
+		}
+		if want := []byte("hello world"); !bytes.Equal(got, want) {
+			t.Errorf("extracted file = %s, want %s", got, want)
+		}
+	}
+
+	// temp files are cleaned up on close
+	for _, dst := range dsts {
+		if err := dst.Close(); err != nil {
+			t.Fatal("Store.Close() error =", err)
+		}
+	}
+	entries, err = os.ReadDir(sharedTempDir)
+	if err != nil {
+		t.Fatal("os.ReadDir() error =", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("number of temp files after close = %d, want 0", len(entries))
+	}
+}
+
 func TestStore_File_SameContent_DuplicateName(t *testing.T) {
 	content := []byte("hello world")
 	name := "test.txt"
@@ -1584,6 +1890,181 @@ func TestStore_File_Push_RestoreDuplicates(t *testing.T) {
 	}
 }
 
+func TestStore_File_Push_DedupeByHardlink(t *testing.T) {
+	mediaType := "test"
+	content := []byte("hello world")
+	newDesc := func(name string) ocispec.Descriptor {
+		return ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(content),
+			Size:      int64(len(content)),
+			Annotations: map[string]string{
+				ocispec.AnnotationTitle: name,
+			},
+		}
+	}
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("Store.New() error =", err)
+	}
+	defer s.Close()
+	s.DedupeByHardlink = true
+	ctx := context.Background()
+
+	if err := s.Push(ctx, newDesc("blob1"), bytes.NewReader(content)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	// the content is not read for the linked file
+	if err := s.Push(ctx, newDesc("blob2"), iotest.ErrReader(errors.New("should not be read"))); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	fi1, err := os.Stat(filepath.Join(tempDir, "blob1"))
+	if err != nil {
+		t.Fatal("os.Stat() error =", err)
+	}
+	fi2, err := os.Stat(filepath.Join(tempDir, "blob2"))
+	if err != nil {
+		t.Fatal("os.Stat() error =", err)
+	}
+	if !os.SameFile(fi1, fi2) {
+		t.Error("blob2 is not a hard link to blob1")
+	}
+
+	// overwriting a linked file does not affect the other one
+	s2, err := New(tempDir)
+	if err != nil {
+		t.Fatal("Store.New() error =", err)
+	}
+	defer s2.Close()
+	s2.DedupeByHardlink = true
+	newContent := []byte("foobar")
+	newBlob2 := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(newContent),
+		Size:      int64(len(newContent)),
+		Annotations: map[string]string{
+			ocispec.AnnotationTitle: "blob2",
+		},
+	}
+	if err := s2.Push(ctx, newBlob2, bytes.NewReader(newContent)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	for name, want := range map[string][]byte{"blob1": content, "blob2": newContent} {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatal("os.ReadFile() error =", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("content of %s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestStore_File_Push_DedupeByHardlink_Sources(t *testing.T) {
+	mediaType := "test"
+	content := []byte("hello world")
+	newDesc := func(name string) ocispec.Descriptor {
+		return ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(content),
+			Size:      int64(len(content)),
+			Annotations: map[string]string{
+				ocispec.AnnotationTitle: name,
+			},
+		}
+	}
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("Store.New() error =", err)
+	}
+	defer s.Close()
+	s.DedupeByHardlink = true
+	ctx := context.Background()
+
+	// the files added by the caller are not linked to
+	added := filepath.Join(tempDir, "added")
+	if err := os.WriteFile(added, content, 0444); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	if _, err := s.Add(ctx, "added", mediaType, added); err != nil {
+		t.Fatal("Store.Add() error =", err)
+	}
+	if err := s.Push(ctx, newDesc("blob1"), bytes.NewReader(content)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	fiAdded, err := os.Stat(added)
+	if err != nil {
+		t.Fatal("os.Stat() error =", err)
+	}
+	fi1, err := os.Stat(filepath.Join(tempDir, "blob1"))
+	if err != nil {
+		t.Fatal("os.Stat() error =", err)
+	}
+	if os.SameFile(fiAdded, fi1) {
+		t.Error("blob1 is a hard link to the added file")
+	}
+
+	// the files modified since pushed are not linked to
+	modified := []byte("HELLO WORLD")
+	if err := os.WriteFile(filepath.Join(tempDir, "blob1"), modified, 0644); err != nil {
+		t.Fatal("os.WriteFile() error =", err)
+	}
+	if err := s.Push(ctx, newDesc("blob2"), bytes.NewReader(content)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	for name, want := range map[string][]byte{"blob1": modified, "blob2": content} {
+		got, err := os.ReadFile(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatal("os.ReadFile() error =", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("content of %s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestStore_File_Push_DedupeByHardlink_Concurrent(t *testing.T) {
+	content := []byte("hello world")
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("Store.New() error =", err)
+	}
+	defer s.Close()
+	s.DedupeByHardlink = true
+	ctx := context.Background()
+
+	concurrency := 16
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i := 0; i < concurrency; i++ {
+		desc := ocispec.Descriptor{
+			MediaType: "test",
+			Digest:    digest.FromBytes(content),
+			Size:      int64(len(content)),
+			Annotations: map[string]string{
+				ocispec.AnnotationTitle: fmt.Sprintf("blob%d", i),
+			},
+		}
+		eg.Go(func() error {
+			return s.Push(egCtx, desc, bytes.NewReader(content))
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	for i := 0; i < concurrency; i++ {
+		got, err := os.ReadFile(filepath.Join(tempDir, fmt.Sprintf("blob%d", i)))
+		if err != nil {
+			t.Fatal("os.ReadFile() error =", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("content of blob%d = %s, want %s", i, got, content)
+		}
+	}
+}
+
 func TestStore_File_Push_RestoreDuplicates_NotFound(t *testing.T) {
 	mediaType := "test"
 	content := []byte("hello world")
@@ -17,6 +17,7 @@ package file
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
@@ -28,6 +29,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
 	"github.com/opencontainers/go-digest"
 )
 
@@ -111,32 +113,110 @@ func tarDirectory(ctx context.Context, root, prefix string, w io.Writer, removeT
 	})
 }
 
-// extractTarGzip decompresses the gzip
-// and extracts tar file to a directory specified by the `dir` parameter.
-func extractTarGzip(dirPath, dirName, gzPath, checksum string, buf []byte) (err error) {
-	fp, err := os.Open(gzPath)
-	if err != nil {
-		return err
+// compression is the compression format of a tarball, named by the suffix in
+// its media type, such as "gzip" for "+gzip". An empty string indicates an
+// uncompressed tarball.
+type compression string
+
+const (
+	compressionNone compression = ""
+	compressionGzip compression = "gzip"
+	compressionZstd compression = "zstd"
+)
+
+var (
+	// gzipMagic is the magic bytes of gzip.
+	// Reference: https://www.rfc-editor.org/rfc/rfc1952#page-6
+	gzipMagic = []byte{0x1f, 0x8b}
+	// zstdMagic is the magic bytes of zstd.
+	// Reference: https://www.rfc-editor.org/rfc/rfc8878#section-3.1.1
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// defaultDecompressors contains the built-in decompressors.
+var defaultDecompressors = map[string]Decompressor{
+	string(compressionGzip): func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	string(compressionZstd): func(r io.Reader) (io.ReadCloser, error) {
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	},
+}
+
+// compressionFromMediaType determines the compression format of a tarball by
+// the suffix of its media type, where the legacy Docker media types use "."
+// instead of "+" for the suffix.
+func compressionFromMediaType(mediaType string) compression {
+	if _, suffix, ok := strings.Cut(mediaType, "+"); ok {
+		return compression(suffix)
 	}
-	defer func() {
-		closeErr := fp.Close()
-		if err == nil {
-			err = closeErr
+	for _, comp := range []compression{compressionGzip, compressionZstd} {
+		if strings.HasSuffix(mediaType, "."+string(comp)) {
+			return comp
 		}
-	}()
+	}
+	return compressionNone
+}
+
+// sniffCompression determines the compression format of the tarball at path
+// by its magic bytes.
+func sniffCompression(path string) (compression, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return compressionNone, err
+	}
+	defer fp.Close()
+
+	header := make([]byte, len(zstdMagic))
+	n, err := io.ReadFull(fp, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return compressionNone, err
+	}
+	header = header[:n]
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return compressionGzip, nil
+	case bytes.HasPrefix(header, zstdMagic):
+		return compressionZstd, nil
+	default:
+		return compressionNone, nil
+	}
+}
 
-	gzr, err := gzip.NewReader(fp)
+// extractTarball decompresses the tarball at path with decompress, or reads
+// it as is if decompress is nil, and extracts it to a directory specified by
+// the `dir` parameter.
+func extractTarball(dirPath, dirName, path, checksum string, decompress Decompressor, buf []byte) (err error) {
+	fp, err := os.Open(path)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		closeErr := gzr.Close()
+		closeErr := fp.Close()
 		if err == nil {
 			err = closeErr
 		}
 	}()
 
-	var r io.Reader = gzr
+	var r io.Reader = fp
+	if decompress != nil {
+		rc, err := decompress(fp)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			closeErr := rc.Close()
+			if err == nil {
+				err = closeErr
+			}
+		}()
+		r = rc
+	}
+
 	var verifier digest.Verifier
 	if checksum != "" {
 		if digest, err := digest.Parse(checksum); err == nil {
@@ -233,7 +313,7 @@ func resolveRelToBase(baseAbs, baseRel, target string) (string, error) {
 	}
 	cleanPath := filepath.ToSlash(filepath.Clean(path))
 	if cleanPath == ".." || strings.HasPrefix(cleanPath, "../") {
-		return "", fmt.Errorf("%q is outside of %q", target, baseRel)
+		return "", fmt.Errorf("%q is outside of %q: %w", target, baseRel, ErrPathTraversalDisallowed)
 	}
 
 	// No symbolic link allowed in the relative path
@@ -236,9 +236,9 @@ func Test_ensureLinkPath(t *testing.T) {
 	}
 }
 
-func Test_extractTarGzip_Error(t *testing.T) {
+func Test_extractTarball_Error(t *testing.T) {
 	t.Run("Non-existing file", func(t *testing.T) {
-		err := extractTarGzip("", "", "non-existing-file", "", nil)
+		err := extractTarball("", "", "non-existing-file", "", defaultDecompressors[string(compressionGzip)], nil)
 		if err == nil {
 			t.Fatal("expected error, got nil")
 		}
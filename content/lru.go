@@ -0,0 +1,183 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/descriptor"
+)
+
+// LRU is a memory based storage with a byte budget, which evicts the least
+// recently used content once the total size of the stored content exceeds the
+// budget. LRU is suitable as a pull-through cache of a long-running process.
+//
+// Content is considered used on Push and Fetch. Content being read by an open
+// reader returned by Fetch is never evicted, and other content is evicted in
+// its place, which may include the content just pushed. Evicted content no
+// longer exists in the storage, and has to be pushed again.
+//
+// LRU is safe for concurrent use.
+type LRU struct {
+	maxBytes int64
+
+	lock    sync.Mutex
+	size    int64
+	order   *list.List                              // of *lruEntry, the most recently used first
+	entries map[descriptor.Descriptor]*list.Element // of *lruEntry
+}
+
+// lruEntry is the content stored in LRU.
+type lruEntry struct {
+	key     descriptor.Descriptor
+	content []byte
+	readers int // number of open readers
+}
+
+// NewLRU creates a new LRU storage with the byte budget maxBytes.
+func NewLRU(maxBytes int64) *LRU {
+	return &LRU{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		entries:  make(map[descriptor.Descriptor]*list.Element),
+	}
+}
+
+// Fetch fetches the content identified by the descriptor, and marks the
+// content as most recently used. The content is not evicted until the returned
+// reader is closed.
+func (l *LRU) Fetch(_ context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	key := descriptor.FromOCI(target)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	elem, ok := l.entries[key]
+	if !ok {
+		return nil, fmt.Errorf("%s: %s: %w", key.Digest, key.MediaType, errdef.ErrNotFound)
+	}
+	l.order.MoveToFront(elem)
+	entry := elem.Value.(*lruEntry)
+	entry.readers++
+	return &lruReader{
+		Reader: bytes.NewReader(entry.content),
+		lru:    l,
+		entry:  entry,
+	}, nil
+}
+
+// Push pushes the content, matching the expected descriptor, and marks the
+// content as most recently used. Content larger than the byte budget is
+// rejected with an error wrapping errdef.ErrSizeExceedsLimit.
+func (l *LRU) Push(_ context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	key := descriptor.FromOCI(expected)
+	if expected.Size > l.maxBytes {
+		return fmt.Errorf(
+			"content size %v exceeds the byte budget %v: %w",
+			expected.Size,
+			l.maxBytes,
+			errdef.ErrSizeExceedsLimit)
+	}
+
+	// check if the content exists in advance to avoid reading from the content.
+	l.lock.Lock()
+	_, exists := l.entries[key]
+	l.lock.Unlock()
+	if exists {
+		return fmt.Errorf("%s: %s: %w", key.Digest, key.MediaType, errdef.ErrAlreadyExists)
+	}
+
+	// read and try to store the content.
+	value, err := ReadAll(content, expected)
+	if err != nil {
+		return err
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, exists := l.entries[key]; exists {
+		return fmt.Errorf("%s: %s: %w", key.Digest, key.MediaType, errdef.ErrAlreadyExists)
+	}
+	l.entries[key] = l.order.PushFront(&lruEntry{
+		key:     key,
+		content: value,
+	})
+	l.size += int64(len(value))
+	l.evict()
+	return nil
+}
+
+// Exists returns true if the described content exists, without marking the
+// content as used.
+func (l *LRU) Exists(_ context.Context, target ocispec.Descriptor) (bool, error) {
+	key := descriptor.FromOCI(target)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	_, exists := l.entries[key]
+	return exists, nil
+}
+
+// Size returns the total size of the content currently stored.
+func (l *LRU) Size() int64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.size
+}
+
+// evict evicts the least recently used content not being read, until the
+// total size is within the byte budget.
+// evict must be called with l.lock held.
+func (l *LRU) evict() {
+	for elem := l.order.Back(); elem != nil && l.size > l.maxBytes; {
+		prev := elem.Prev()
+		if entry := elem.Value.(*lruEntry); entry.readers == 0 {
+			l.order.Remove(elem)
+			delete(l.entries, entry.key)
+			l.size -= int64(len(entry.content))
+		}
+		elem = prev
+	}
+}
+
+// lruReader reads the content stored in LRU, and releases the content on
+// close.
+type lruReader struct {
+	*bytes.Reader
+	lru    *LRU
+	entry  *lruEntry
+	closed bool
+}
+
+// Close releases the content so that it can be evicted, and evicts the least
+// recently used content if the byte budget has been exceeded while the content
+// was being read.
+func (r *lruReader) Close() error {
+	r.lru.lock.Lock()
+	defer r.lru.lock.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.entry.readers--
+	r.lru.evict()
+	return nil
+}
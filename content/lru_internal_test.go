@@ -0,0 +1,60 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestLRU_CloseEvicts(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("aaaa")
+	desc := NewDescriptorFromBytes("test", blob)
+	l := NewLRU(8)
+	if err := l.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatal("LRU.Push() error =", err)
+	}
+	rc, err := l.Fetch(ctx, desc)
+	if err != nil {
+		t.Fatal("LRU.Fetch() error =", err)
+	}
+
+	// exceed the byte budget while the content is being read
+	l.lock.Lock()
+	l.maxBytes = 2
+	l.evict()
+	l.lock.Unlock()
+	if got := l.Size(); got != 4 {
+		t.Fatalf("LRU.Size() = %v, want 4", got)
+	}
+
+	// the released content is evicted on close
+	if err := rc.Close(); err != nil {
+		t.Fatal("LRU.Fetch().Close() error =", err)
+	}
+	exists, err := l.Exists(ctx, desc)
+	if err != nil {
+		t.Fatal("LRU.Exists() error =", err)
+	}
+	if exists {
+		t.Errorf("LRU.Exists() = %v, want %v", exists, false)
+	}
+	if got := l.Size(); got != 0 {
+		t.Errorf("LRU.Size() = %v, want 0", got)
+	}
+}
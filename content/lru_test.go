@@ -0,0 +1,186 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+func TestLRU_Eviction(t *testing.T) {
+	ctx := context.Background()
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	for _, s := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+		blobs = append(blobs, []byte(s))
+		descs = append(descs, content.NewDescriptorFromBytes("test", []byte(s)))
+	}
+	exists := func(s *content.LRU, i int) bool {
+		ok, err := s.Exists(ctx, descs[i])
+		if err != nil {
+			t.Fatal("LRU.Exists() error =", err)
+		}
+		return ok
+	}
+
+	s := content.NewLRU(8) // fits 2 blobs
+	for i := 0; i < 2; i++ {
+		if err := s.Push(ctx, descs[i], bytes.NewReader(blobs[i])); err != nil {
+			t.Fatal("LRU.Push() error =", err)
+		}
+	}
+	if err := s.Push(ctx, descs[0], bytes.NewReader(blobs[0])); !errors.Is(err, errdef.ErrAlreadyExists) {
+		t.Errorf("LRU.Push() error = %v, wantErr %v", err, errdef.ErrAlreadyExists)
+	}
+
+	// fetching blob 0 makes blob 1 the least recently used
+	got, err := content.FetchAll(ctx, s, descs[0])
+	if err != nil {
+		t.Fatal("LRU.Fetch() error =", err)
+	}
+	if !bytes.Equal(got, blobs[0]) {
+		t.Errorf("LRU.Fetch() = %v, want %v", got, blobs[0])
+	}
+	if err := s.Push(ctx, descs[2], bytes.NewReader(blobs[2])); err != nil {
+		t.Fatal("LRU.Push() error =", err)
+	}
+	if !exists(s, 0) || exists(s, 1) || !exists(s, 2) {
+		t.Errorf("LRU.Exists() = [%v %v %v], want [true false true]", exists(s, 0), exists(s, 1), exists(s, 2))
+	}
+	if _, err := s.Fetch(ctx, descs[1]); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("LRU.Fetch() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+	if got := s.Size(); got != 8 {
+		t.Errorf("LRU.Size() = %v, want 8", got)
+	}
+
+	// content being read is not evicted
+	rc, err := s.Fetch(ctx, descs[2])
+	if err != nil {
+		t.Fatal("LRU.Fetch() error =", err)
+	}
+	if _, err := content.FetchAll(ctx, s, descs[0]); err != nil {
+		t.Fatal("LRU.Fetch() error =", err)
+	}
+	if err := s.Push(ctx, descs[3], bytes.NewReader(blobs[3])); err != nil {
+		t.Fatal("LRU.Push() error =", err)
+	}
+	if exists(s, 0) || !exists(s, 2) || !exists(s, 3) {
+		t.Errorf("LRU.Exists() = [%v %v %v], want [false true true]", exists(s, 0), exists(s, 2), exists(s, 3))
+	}
+	if err := s.Push(ctx, descs[1], bytes.NewReader(blobs[1])); err != nil {
+		t.Fatal("LRU.Push() error =", err)
+	}
+	// blob 2 is the least recently used but still pinned by the open reader
+	if !exists(s, 2) || exists(s, 3) || !exists(s, 1) {
+		t.Errorf("LRU.Exists() = [%v %v %v], want [true false true]", exists(s, 2), exists(s, 3), exists(s, 1))
+	}
+	got, err = io.ReadAll(rc)
+	if err != nil {
+		t.Fatal("LRU.Fetch().Read() error =", err)
+	}
+	if !bytes.Equal(got, blobs[2]) {
+		t.Errorf("LRU.Fetch() = %v, want %v", got, blobs[2])
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Fatal("LRU.Fetch().Close() error =", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatal("LRU.Fetch().Close() error =", err)
+	}
+
+	// the released content is evicted in turn
+	if err := s.Push(ctx, descs[0], bytes.NewReader(blobs[0])); err != nil {
+		t.Fatal("LRU.Push() error =", err)
+	}
+	if exists(s, 2) || !exists(s, 1) || !exists(s, 0) {
+		t.Errorf("LRU.Exists() = [%v %v %v], want [false true true]", exists(s, 2), exists(s, 1), exists(s, 0))
+	}
+	if got := s.Size(); got != 8 {
+		t.Errorf("LRU.Size() = %v, want 8", got)
+	}
+
+	// pushed content is evicted if all other content is being read
+	rc0, err := s.Fetch(ctx, descs[0])
+	if err != nil {
+		t.Fatal("LRU.Fetch() error =", err)
+	}
+	defer rc0.Close()
+	rc1, err := s.Fetch(ctx, descs[1])
+	if err != nil {
+		t.Fatal("LRU.Fetch() error =", err)
+	}
+	defer rc1.Close()
+	if err := s.Push(ctx, descs[3], bytes.NewReader(blobs[3])); err != nil {
+		t.Fatal("LRU.Push() error =", err)
+	}
+	if exists(s, 3) || !exists(s, 1) || !exists(s, 0) {
+		t.Errorf("LRU.Exists() = [%v %v %v], want [false true true]", exists(s, 3), exists(s, 1), exists(s, 0))
+	}
+
+	// content larger than the budget is rejected
+	large := []byte("too large content")
+	err = s.Push(ctx, content.NewDescriptorFromBytes("test", large), bytes.NewReader(large))
+	if !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+		t.Errorf("LRU.Push() error = %v, wantErr %v", err, errdef.ErrSizeExceedsLimit)
+	}
+}
+
+func TestLRU_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s := content.NewLRU(64)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 32; j++ {
+				blob := []byte(fmt.Sprintf("blob-%02d", (i+j)%24))
+				desc := content.NewDescriptorFromBytes("test", blob)
+				if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+					t.Errorf("LRU.Push() error = %v", err)
+					return
+				}
+				got, err := content.FetchAll(ctx, s, desc)
+				if errors.Is(err, errdef.ErrNotFound) {
+					// evicted by others
+					continue
+				}
+				if err != nil {
+					t.Errorf("LRU.Fetch() error = %v", err)
+					return
+				}
+				if !bytes.Equal(got, blob) {
+					t.Errorf("LRU.Fetch() = %s, want %s", got, blob)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if got := s.Size(); got > 64 {
+		t.Errorf("LRU.Size() = %v, want no more than 64", got)
+	}
+}
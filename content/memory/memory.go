@@ -20,11 +20,15 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/cas"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/descriptor"
 	"oras.land/oras-go/v2/internal/graph"
 	"oras.land/oras-go/v2/internal/resolver"
 )
@@ -68,10 +72,47 @@ func (s *Store) Exists(ctx context.Context, target ocispec.Descriptor) (bool, er
 }
 
 // Resolve resolves a reference to a descriptor.
+// If the reference is a digest prefix in the form of
+// `<algorithm>:<encoded prefix>`, it is resolved to the content with the
+// unique digest starting with the prefix. Returns ErrAmbiguousReference if
+// more than one digest match.
 func (s *Store) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if alg, prefix, ok := descriptor.ParseDigestPrefix(reference); ok {
+		return s.resolveDigestPrefix(alg, prefix)
+	}
 	return s.resolver.Resolve(ctx, reference)
 }
 
+// resolveDigestPrefix resolves the content with the digest of the given
+// algorithm starting with the given encoded prefix.
+func (s *Store) resolveDigestPrefix(alg digest.Algorithm, prefix string) (ocispec.Descriptor, error) {
+	reference := alg.String() + ":" + prefix
+	storage, ok := s.storage.(*cas.Memory)
+	if !ok {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+	}
+	var match descriptor.Descriptor
+	matches := set.New[digest.Digest]()
+	for desc := range storage.Map() {
+		if desc.Digest.Algorithm() == alg && strings.HasPrefix(desc.Digest.Encoded(), prefix) {
+			match = desc
+			matches.Add(desc.Digest)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+	case 1:
+		return ocispec.Descriptor{
+			MediaType: match.MediaType,
+			Digest:    match.Digest,
+			Size:      match.Size,
+		}, nil
+	default:
+		return ocispec.Descriptor{}, fmt.Errorf("%s: matches %d digests: %w", reference, len(matches), errdef.ErrAmbiguousReference)
+	}
+}
+
 // Tag tags a descriptor with a reference string.
 // Returns ErrNotFound if the tagged content does not exist.
 func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
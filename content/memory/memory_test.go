@@ -187,6 +187,66 @@ func TestStoreTagNotFound(t *testing.T) {
 	}
 }
 
+func TestStoreResolveDigestPrefix(t *testing.T) {
+	s := New()
+	ctx := context.Background()
+
+	// push enough blobs so that at least two of them share the first
+	// character of the encoded digest
+	var descs []ocispec.Descriptor
+	byFirstChar := make(map[byte][]ocispec.Descriptor)
+	for i := 0; i < 17; i++ {
+		blob := []byte(fmt.Sprintf("blob %d", i))
+		desc := content.NewDescriptorFromBytes("test", blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("Store.Push() error = %v", err)
+		}
+		descs = append(descs, desc)
+		c := desc.Digest.Encoded()[0]
+		byFirstChar[c] = append(byFirstChar[c], desc)
+	}
+
+	// unique prefix
+	want := descs[0]
+	encoded := want.Digest.Encoded()
+	for _, prefix := range []string{encoded[:12], encoded[:len(encoded)-1]} {
+		got, err := s.Resolve(ctx, "sha256:"+prefix)
+		if err != nil {
+			t.Fatalf("Store.Resolve(%s) error = %v", prefix, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Store.Resolve(%s) = %v, want %v", prefix, got, want)
+		}
+	}
+
+	// ambiguous prefix
+	for c, matches := range byFirstChar {
+		if len(matches) < 2 {
+			continue
+		}
+		_, err := s.Resolve(ctx, "sha256:"+string(c))
+		if !errors.Is(err, errdef.ErrAmbiguousReference) {
+			t.Errorf("Store.Resolve(%c) error = %v, want %v", c, err, errdef.ErrAmbiguousReference)
+		}
+		break
+	}
+
+	// non-matching prefix
+	for _, c := range "0123456789abcdef" {
+		if _, ok := byFirstChar[byte(c)]; ok {
+			continue
+		}
+		_, err := s.Resolve(ctx, "sha256:"+string(c))
+		if !errors.Is(err, errdef.ErrNotFound) {
+			t.Errorf("Store.Resolve(%c) error = %v, want %v", c, err, errdef.ErrNotFound)
+		}
+	}
+	_, err := s.Resolve(ctx, "sha512:"+encoded[:12])
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+}
+
 func TestStoreTagUnknownContent(t *testing.T) {
 	content := []byte("hello world")
 	desc := ocispec.Descriptor{
@@ -0,0 +1,124 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// multiReader is a read-only storage consulting a list of stores in turn.
+type multiReader struct {
+	stores   []ReadOnlyStorage
+	populate bool
+}
+
+// MultiReader returns a read-only storage that is the logical concatenation of
+// the provided stores, which are consulted in order until one of them has the
+// requested content. This is suitable for layered caches ordered from the
+// fastest store to the slowest one.
+//
+// ErrNotFound is returned only if the content is found in none of the stores.
+// Any other error returned by a store is returned immediately, without
+// consulting the remaining stores.
+func MultiReader(stores ...ReadOnlyStorage) ReadOnlyStorage {
+	return &multiReader{
+		stores: stores,
+	}
+}
+
+// ReadThrough returns a read-only storage that is the same as [MultiReader],
+// except that the content fetched from a store is pushed to the earlier stores
+// implementing [Pusher] before it is returned, so that the subsequent fetches
+// hit the faster stores.
+//
+// Populating the earlier stores is best-effort: if a push fails, the content
+// is fetched again from the store where it is found.
+func ReadThrough(stores ...ReadOnlyStorage) ReadOnlyStorage {
+	return &multiReader{
+		stores:   stores,
+		populate: true,
+	}
+}
+
+// Fetch fetches the content identified by the descriptor from the first store
+// having the content.
+func (mr *multiReader) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	for i, s := range mr.stores {
+		rc, err := s.Fetch(ctx, target)
+		if err != nil {
+			if errors.Is(err, errdef.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if mr.populate && i > 0 {
+			return mr.populateFrom(ctx, i, target, rc)
+		}
+		return rc, nil
+	}
+	return nil, fmt.Errorf("%s: %s: %w", target.Digest, target.MediaType, errdef.ErrNotFound)
+}
+
+// Exists returns true if the described content exists in any of the stores.
+func (mr *multiReader) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	for _, s := range mr.stores {
+		exists, err := s.Exists(ctx, target)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// populateFrom pushes the content read from rc, which is fetched from the i-th
+// store, to the earlier stores implementing Pusher, and returns the content
+// fetched from the fastest store populated.
+func (mr *multiReader) populateFrom(ctx context.Context, i int, target ocispec.Descriptor, rc io.ReadCloser) (io.ReadCloser, error) {
+	source := mr.stores[i]
+	for j := i - 1; j >= 0; j-- {
+		pusher, ok := mr.stores[j].(Pusher)
+		if !ok {
+			continue
+		}
+		if rc == nil {
+			// the content is consumed by the previous push, read it from the
+			// store populated last
+			var err error
+			if rc, err = source.Fetch(ctx, target); err != nil {
+				return nil, err
+			}
+		}
+		err := pusher.Push(ctx, target, rc)
+		rc.Close()
+		rc = nil
+		if err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+			// fall back to the store having the content
+			break
+		}
+		source = mr.stores[j]
+	}
+	if rc != nil {
+		// no store is populated
+		return rc, nil
+	}
+	return source.Fetch(ctx, target)
+}
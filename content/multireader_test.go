@@ -0,0 +1,182 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package content_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// readOnlyStorage hides the Pusher of the underlying storage.
+type readOnlyStorage struct {
+	content.ReadOnlyStorage
+}
+
+// failingStorage fails all the requests with err.
+type failingStorage struct {
+	err error
+}
+
+func (s failingStorage) Fetch(context.Context, ocispec.Descriptor) (io.ReadCloser, error) {
+	return nil, s.err
+}
+
+func (s failingStorage) Exists(context.Context, ocispec.Descriptor) (bool, error) {
+	return false, s.err
+}
+
+func fetchString(t *testing.T, s content.ReadOnlyStorage, desc ocispec.Descriptor) string {
+	t.Helper()
+	got, err := content.FetchAll(context.Background(), s, desc)
+	if err != nil {
+		t.Fatalf("FetchAll() error = %v", err)
+	}
+	return string(got)
+}
+
+func TestMultiReader(t *testing.T) {
+	ctx := context.Background()
+	foo := []byte("foo")
+	fooDesc := content.NewDescriptorFromBytes("test", foo)
+	bar := []byte("bar")
+	barDesc := content.NewDescriptorFromBytes("test", bar)
+	missingDesc := content.NewDescriptorFromBytes("test", []byte("missing"))
+
+	first := memory.New()
+	if err := first.Push(ctx, fooDesc, bytes.NewReader(foo)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	second := memory.New()
+	if err := second.Push(ctx, barDesc, bytes.NewReader(bar)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	s := content.MultiReader(first, second)
+
+	if got := fetchString(t, s, fooDesc); got != "foo" {
+		t.Errorf("MultiReader.Fetch() = %s, want foo", got)
+	}
+	if got := fetchString(t, s, barDesc); got != "bar" {
+		t.Errorf("MultiReader.Fetch() = %s, want bar", got)
+	}
+	for _, desc := range []ocispec.Descriptor{fooDesc, barDesc} {
+		exists, err := s.Exists(ctx, desc)
+		if err != nil || !exists {
+			t.Errorf("MultiReader.Exists() = %v, %v, want true, nil", exists, err)
+		}
+	}
+	if _, err := s.Fetch(ctx, missingDesc); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("MultiReader.Fetch() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	exists, err := s.Exists(ctx, missingDesc)
+	if err != nil || exists {
+		t.Errorf("MultiReader.Exists() = %v, %v, want false, nil", exists, err)
+	}
+
+	// the content is not populated
+	exists, err = first.Exists(ctx, barDesc)
+	if err != nil || exists {
+		t.Errorf("Store.Exists() = %v, %v, want false, nil", exists, err)
+	}
+}
+
+func TestMultiReader_ErrorShortCircuits(t *testing.T) {
+	ctx := context.Background()
+	foo := []byte("foo")
+	fooDesc := content.NewDescriptorFromBytes("test", foo)
+	store := memory.New()
+	if err := store.Push(ctx, fooDesc, bytes.NewReader(foo)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	errBroken := errors.New("broken")
+	s := content.MultiReader(failingStorage{errBroken}, store)
+
+	if _, err := s.Fetch(ctx, fooDesc); !errors.Is(err, errBroken) {
+		t.Errorf("MultiReader.Fetch() error = %v, want %v", err, errBroken)
+	}
+	if _, err := s.Exists(ctx, fooDesc); !errors.Is(err, errBroken) {
+		t.Errorf("MultiReader.Exists() error = %v, want %v", err, errBroken)
+	}
+
+	// not found errors fall through
+	s = content.MultiReader(failingStorage{errdef.ErrNotFound}, store)
+	if got := fetchString(t, s, fooDesc); got != "foo" {
+		t.Errorf("MultiReader.Fetch() = %s, want foo", got)
+	}
+}
+
+func TestReadThrough(t *testing.T) {
+	ctx := context.Background()
+	foo := []byte("foo")
+	fooDesc := content.NewDescriptorFromBytes("test", foo)
+
+	fast := memory.New()
+	readOnly := readOnlyStorage{memory.New()}
+	slow := memory.New()
+	source := memory.New()
+	if err := source.Push(ctx, fooDesc, bytes.NewReader(foo)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	s := content.ReadThrough(fast, readOnly, slow, source)
+
+	if got := fetchString(t, s, fooDesc); got != "foo" {
+		t.Errorf("ReadThrough.Fetch() = %s, want foo", got)
+	}
+	for name, store := range map[string]content.ReadOnlyStorage{"fast": fast, "slow": slow} {
+		exists, err := store.Exists(ctx, fooDesc)
+		if err != nil || !exists {
+			t.Errorf("%s store: Exists() = %v, %v, want true, nil", name, exists, err)
+		}
+	}
+	exists, err := readOnly.Exists(ctx, fooDesc)
+	if err != nil || exists {
+		t.Errorf("read-only store: Exists() = %v, %v, want false, nil", exists, err)
+	}
+
+	// subsequent fetches hit the fast store
+	s = content.ReadThrough(fast, failingStorage{errors.New("should not be consulted")})
+	if got := fetchString(t, s, fooDesc); got != "foo" {
+		t.Errorf("ReadThrough.Fetch() = %s, want foo", got)
+	}
+}
+
+func TestReadThrough_PushFailure(t *testing.T) {
+	ctx := context.Background()
+	foo := []byte("foo")
+	fooDesc := content.NewDescriptorFromBytes("test", foo)
+	source := memory.New()
+	if err := source.Push(ctx, fooDesc, bytes.NewReader(foo)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+
+	// pushes to a limited storage fail for oversized content
+	cache := content.LimitStorage(memory.New(), 1)
+	s := content.ReadThrough(cache, source)
+	if got := fetchString(t, s, fooDesc); got != "foo" {
+		t.Errorf("ReadThrough.Fetch() = %s, want foo", got)
+	}
+	exists, err := cache.Exists(ctx, fooDesc)
+	if err != nil || exists {
+		t.Errorf("Exists() = %v, %v, want false, nil", exists, err)
+	}
+}
@@ -27,11 +27,14 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/container/set"
@@ -45,6 +48,11 @@ import (
 // Store implements `oras.Target`, and represents a content store
 // based on file system with the OCI-Image layout.
 // Reference: https://github.com/opencontainers/image-spec/blob/v1.1.0/image-layout.md
+//
+// Store is safe for concurrent use within a process. Push, Fetch, Tag and the
+// other operations may be invoked concurrently, where the blobs are written in
+// parallel and the updates to the index are serialized. Delete and GC wait
+// for the other operations to complete, and have exclusive access to Store.
 type Store struct {
 	// AutoSaveIndex controls if the OCI store will automatically save the index
 	// file when needed.
@@ -64,6 +72,35 @@ type Store struct {
 	//   - Default value: true.
 	AutoGC bool
 
+	// BufferSize specifies the size of the buffer used for streaming content
+	// to the file system on push.
+	// See also: Storage.BufferSize
+	BufferSize int
+
+	// OnThroughput reports the throughput, in bytes per second, achieved by
+	// each successful push, if set. OnThroughput may be invoked concurrently.
+	OnThroughput func(desc ocispec.Descriptor, bytesPerSecond float64)
+
+	// TagListPageSize specifies the maximum number of tags passed to each
+	// invocation of the callback of Tags(), consistent with the pagination of
+	// the tag list API of remote repositories.
+	// If less than or equal to zero, all tags are listed in a single page.
+	TagListPageSize int
+
+	// GCConcurrency limits the maximum number of garbage blobs removed
+	// concurrently by GC, which speeds up GC on file systems with high
+	// latency, such as network file systems.
+	// If less than or equal to zero, the garbage blobs are removed one at a
+	// time.
+	GCConcurrency int
+
+	// DigestAlgorithms restricts the digest algorithms of the content accepted
+	// by Push. Content digested with other algorithms is rejected with
+	// errdef.ErrUnsupported.
+	// If empty, all the algorithms known to the store are accepted.
+	// See also: content.DigestAlgorithmLimiter
+	DigestAlgorithms []digest.Algorithm
+
 	root        string
 	indexPath   string
 	index       *ocispec.Index
@@ -78,6 +115,33 @@ type Store struct {
 	sync sync.RWMutex
 	// indexLock ensures that only one go-routine is writing to the index.
 	indexLock sync.Mutex
+	// indexStamp identifies the version of the `index.json` file last loaded
+	// or written by the store, for detecting out-of-band changes.
+	indexStamp indexStamp
+	// indexChecked is the time when the `index.json` file was last checked
+	// for out-of-band changes.
+	indexChecked time.Time
+	// stampLock guards indexStamp and indexChecked.
+	stampLock sync.Mutex
+}
+
+// indexCheckInterval is the minimum interval between two consecutive checks of
+// the `index.json` file for out-of-band changes.
+var indexCheckInterval = time.Second
+
+// indexStamp identifies a version of the `index.json` file by its
+// modification time and size.
+type indexStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// newIndexStamp returns the indexStamp of the file described by fi.
+func newIndexStamp(fi os.FileInfo) indexStamp {
+	return indexStamp{
+		modTime: fi.ModTime(),
+		size:    fi.Size(),
+	}
 }
 
 // New creates a new OCI store with context.Background().
@@ -131,10 +195,17 @@ func (s *Store) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCl
 
 // Push pushes the content, matching the expected descriptor.
 func (s *Store) Push(ctx context.Context, expected ocispec.Descriptor, reader io.Reader) error {
+	if err := s.checkDigestAlgorithm(expected); err != nil {
+		return err
+	}
+
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
-	if err := s.storage.Push(ctx, expected, reader); err != nil {
+	if err := s.storage.push(expected, reader, ingestOptions{
+		bufferSize:   s.BufferSize,
+		onThroughput: s.OnThroughput,
+	}); err != nil {
 		return err
 	}
 	if err := s.graph.Index(ctx, s.storage, expected); err != nil {
@@ -147,6 +218,27 @@ func (s *Store) Push(ctx context.Context, expected ocispec.Descriptor, reader io
 	return nil
 }
 
+// AllowedDigestAlgorithms returns the digest algorithms accepted by Push.
+// An empty list indicates that all the algorithms known to the store are
+// accepted.
+// See also: Store.DigestAlgorithms
+func (s *Store) AllowedDigestAlgorithms() []digest.Algorithm {
+	return slices.Clone(s.DigestAlgorithms)
+}
+
+// checkDigestAlgorithm ensures that desc is digested with one of the allowed
+// digest algorithms.
+func (s *Store) checkDigestAlgorithm(desc ocispec.Descriptor) error {
+	if len(s.DigestAlgorithms) == 0 {
+		return nil
+	}
+	if alg := desc.Digest.Algorithm(); !slices.Contains(s.DigestAlgorithms, alg) {
+		return fmt.Errorf("%s: %s: digest algorithm %q is not allowed: %w",
+			desc.Digest, desc.MediaType, alg, errdef.ErrUnsupported)
+	}
+	return nil
+}
+
 // Exists returns true if the described content exists.
 func (s *Store) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
 	s.sync.RLock()
@@ -220,8 +312,10 @@ func (s *Store) delete(ctx context.Context, target ocispec.Descriptor) ([]ocispe
 	return danglings, nil
 }
 
-// Tag tags a descriptor with a reference string.
-// reference should be a valid tag (e.g. "latest").
+// Tag tags a descriptor with a reference string, which is saved as the
+// `org.opencontainers.image.ref.name` annotation in `index.json`.
+// reference is typically a valid tag (e.g. "latest"), but can be any non-empty
+// ref name allowed by OCI image layouts (e.g. "example.com/foo:v1").
 // Reference: https://github.com/opencontainers/image-spec/blob/v1.1.0/image-layout.md#indexjson-file
 func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
 	s.sync.RLock()
@@ -273,6 +367,16 @@ func (s *Store) Resolve(ctx context.Context, reference string) (ocispec.Descript
 		return ocispec.Descriptor{}, errdef.ErrMissingReference
 	}
 
+	// expand digest prefix, unless the reference is a ref name as ref names
+	// in OCI layouts are not restricted to the tag grammar.
+	if alg, prefix, ok := descriptor.ParseDigestPrefix(reference); ok && !isRefName(ctx, s.tagResolver, reference) {
+		dgst, err := resolveDigestPrefix(os.DirFS(s.root), alg, prefix)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		reference = dgst.String()
+	}
+
 	// attempt resolving manifest
 	desc, err := s.tagResolver.Resolve(ctx, reference)
 	if err != nil {
@@ -316,13 +420,77 @@ func (s *Store) Untag(ctx context.Context, reference string) error {
 // Predecessors returns the nodes directly pointing to the current node.
 // Predecessors returns nil without error if the node does not exists in the
 // store.
+//
+// The predecessors are looked up in an in-memory index, which is built from
+// the `index.json` file on opening the store, and updated on every Push. If
+// the `index.json` file is modified out-of-band, such as by another process,
+// the manifests listed in it are indexed lazily on a later query. To keep the
+// queries cheap, the file is checked for changes at most once per second, so
+// the changes made within the last second may not be visible yet.
+// Only the in-memory index of predecessors is refreshed: the tags and the
+// manifests listed by the store, such as the ones returned by Resolve and
+// Tags, are not reloaded from the modified `index.json` file, and are
+// overwritten by the store on its next write of the file.
 func (s *Store) Predecessors(ctx context.Context, node ocispec.Descriptor) ([]ocispec.Descriptor, error) {
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
+	if err := s.refreshGraph(ctx); err != nil {
+		return nil, err
+	}
 	return s.graph.Predecessors(ctx, node)
 }
 
+// refreshGraph indexes the manifests listed in the `index.json` file if it is
+// modified since last loaded or written by the store. The file is checked at
+// most once per indexCheckInterval.
+func (s *Store) refreshGraph(ctx context.Context) error {
+	s.stampLock.Lock()
+	defer s.stampLock.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.indexChecked) < indexCheckInterval {
+		return nil
+	}
+	s.indexChecked = now
+
+	fi, err := os.Stat(s.indexPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to stat index file: %w", err)
+	}
+	stamp := newIndexStamp(fi)
+	if stamp == s.indexStamp {
+		return nil
+	}
+
+	indexJSON, err := os.ReadFile(s.indexPath)
+	if err != nil {
+		return fmt.Errorf("failed to read index file: %w", err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return fmt.Errorf("failed to decode index file: %w", err)
+	}
+	for _, desc := range index.Manifests {
+		if err := s.graph.IndexAll(ctx, s.storage, descriptor.Plain(desc)); err != nil {
+			return err
+		}
+	}
+	s.indexStamp = stamp
+	return nil
+}
+
+// setIndexStamp records the indexStamp of the `index.json` file described by
+// fi.
+func (s *Store) setIndexStamp(fi os.FileInfo) {
+	s.stampLock.Lock()
+	defer s.stampLock.Unlock()
+	s.indexStamp = newIndexStamp(fi)
+}
+
 // Tags lists the tags presented in the `index.json` file of the OCI layout,
 // returned in ascending order.
 // If `last` is NOT empty, the entries in the response start after the tag
@@ -334,7 +502,7 @@ func (s *Store) Tags(ctx context.Context, last string, fn func(tags []string) er
 	s.sync.RLock()
 	defer s.sync.RUnlock()
 
-	return listTags(s.tagResolver, last, fn)
+	return listTags(s.tagResolver, last, s.TagListPageSize, fn)
 }
 
 // ensureOCILayoutFile ensures the `oci-layout` file.
@@ -385,11 +553,16 @@ func (s *Store) loadIndexFile(ctx context.Context) error {
 	}
 	defer indexFile.Close()
 
+	fi, err := indexFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat index file: %w", err)
+	}
 	var index ocispec.Index
 	if err := json.NewDecoder(indexFile).Decode(&index); err != nil {
 		return fmt.Errorf("failed to decode index file: %w", err)
 	}
 	s.index = &index
+	s.setIndexStamp(fi)
 	return loadIndex(ctx, s.index, s.storage, s.tagResolver, s.graph)
 }
 
@@ -445,7 +618,15 @@ func (s *Store) writeIndexFile() error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal index file: %w", err)
 	}
-	return os.WriteFile(s.indexPath, indexJSON, 0666)
+	if err := os.WriteFile(s.indexPath, indexJSON, 0666); err != nil {
+		return err
+	}
+	fi, err := os.Stat(s.indexPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat index file: %w", err)
+	}
+	s.setIndexStamp(fi)
+	return nil
 }
 
 // GC removes garbage from Store. Unsaved index will be lost. To prevent unexpected
@@ -453,6 +634,12 @@ func (s *Store) writeIndexFile() error {
 // The garbage to be cleaned are:
 //   - unreferenced (dangling) blobs in Store which have no predecessors
 //   - garbage blobs in the storage whose metadata is not stored in Store
+//
+// GC is never invoked implicitly. In particular, Delete only removes the
+// content being deleted and, if AutoGC is set, the dangling content caused by
+// that delete. Blobs referenced by any manifest remaining in the index are
+// retained. GC has exclusive access to Store, and is therefore safe against
+// concurrent Push.
 func (s *Store) GC(ctx context.Context) error {
 	s.sync.Lock()
 	defer s.sync.Unlock()
@@ -465,6 +652,21 @@ func (s *Store) GC(ctx context.Context) error {
 	reachableNodes := s.graph.DigestSet()
 
 	// clean up garbage blobs in the storage
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(max(s.GCConcurrency, 1))
+	if err := s.gcBlobs(egCtx, eg, reachableNodes); err != nil {
+		// wait for the ongoing removals, and report their errors first
+		if waitErr := eg.Wait(); waitErr != nil {
+			return waitErr
+		}
+		return err
+	}
+	return eg.Wait()
+}
+
+// gcBlobs enumerates the blobs in the storage, and removes the blobs not in
+// reachableNodes with eg.
+func (s *Store) gcBlobs(ctx context.Context, eg *errgroup.Group, reachableNodes set.Set[digest.Digest]) error {
 	rootpath := filepath.Join(s.root, ocispec.ImageBlobsDir)
 	algDirs, err := os.ReadDir(rootpath)
 	if err != nil {
@@ -496,10 +698,10 @@ func (s *Store) GC(ctx context.Context) error {
 			}
 			if !reachableNodes.Contains(blobDigest) {
 				// remove the blob from storage if it does not exist in Store
-				err = os.Remove(path.Join(algPath, dgst))
-				if err != nil {
-					return err
-				}
+				blobPath := path.Join(algPath, dgst)
+				eg.Go(func() error {
+					return os.Remove(blobPath)
+				})
 			}
 		}
 	}
@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"context"
 	_ "crypto/sha256"
+	_ "crypto/sha512"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -76,6 +77,39 @@ func TestStoreInterface(t *testing.T) {
 	if _, ok := store.(registry.TagLister); !ok {
 		t.Error("&Store{} does not conform registry.TagLister")
 	}
+	if _, ok := store.(content.DigestAlgorithmLimiter); !ok {
+		t.Error("&Store{} does not conform content.DigestAlgorithmLimiter")
+	}
+}
+
+func TestStore_Push_BufferSize(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := content.NewDescriptorFromBytes("test", blob)
+
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	s.BufferSize = 1
+	var reported []ocispec.Descriptor
+	s.OnThroughput = func(desc ocispec.Descriptor, bytesPerSecond float64) {
+		reported = append(reported, desc)
+	}
+	ctx := context.Background()
+
+	if err := s.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	got, err := content.FetchAll(ctx, s, blobDesc)
+	if err != nil {
+		t.Fatal("Store.Fetch() error =", err)
+	}
+	if !bytes.Equal(got, blob) {
+		t.Errorf("Store.Fetch() = %v, want %v", got, blob)
+	}
+	if want := []ocispec.Descriptor{blobDesc}; !reflect.DeepEqual(reported, want) {
+		t.Errorf("OnThroughput() reported = %v, want %v", reported, want)
+	}
 }
 
 func TestStore_Success(t *testing.T) {
@@ -531,6 +565,66 @@ func TestStore_ResolveByDigestReturnsPlainDescriptor(t *testing.T) {
 	}
 }
 
+func TestStore_ResolveByDigestPrefix(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	ctx := context.Background()
+
+	// push enough blobs so that at least two of them share the first
+	// character of the encoded digest
+	var descs []ocispec.Descriptor
+	byFirstChar := make(map[byte][]ocispec.Descriptor)
+	for i := 0; i < 17; i++ {
+		blob := []byte(fmt.Sprintf("blob %d", i))
+		desc := content.NewDescriptorFromBytes("test", blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("Store.Push() error = %v", err)
+		}
+		descs = append(descs, desc)
+		c := desc.Digest.Encoded()[0]
+		byFirstChar[c] = append(byFirstChar[c], desc)
+	}
+
+	// unique prefix
+	want := descs[0]
+	encoded := want.Digest.Encoded()
+	for _, prefix := range []string{encoded[:12], encoded[:len(encoded)-1]} {
+		got, err := s.Resolve(ctx, "sha256:"+prefix)
+		if err != nil {
+			t.Fatalf("Store.Resolve(%s) error = %v", prefix, err)
+		}
+		if got.Digest != want.Digest || got.Size != want.Size {
+			t.Errorf("Store.Resolve(%s) = %v, want %v", prefix, got, want)
+		}
+	}
+
+	// ambiguous prefix
+	for c, matches := range byFirstChar {
+		if len(matches) < 2 {
+			continue
+		}
+		_, err := s.Resolve(ctx, "sha256:"+string(c))
+		if !errors.Is(err, errdef.ErrAmbiguousReference) {
+			t.Errorf("Store.Resolve(%c) error = %v, want %v", c, err, errdef.ErrAmbiguousReference)
+		}
+		break
+	}
+
+	// non-matching prefix
+	for _, c := range "0123456789abcdef" {
+		if _, ok := byFirstChar[byte(c)]; ok {
+			continue
+		}
+		_, err := s.Resolve(ctx, "sha256:"+string(c))
+		if !errors.Is(err, errdef.ErrNotFound) {
+			t.Errorf("Store.Resolve(%c) error = %v, want %v", c, err, errdef.ErrNotFound)
+		}
+	}
+}
+
 func TestStore_TagNotFound(t *testing.T) {
 	ref := "foobar"
 
@@ -1680,6 +1774,118 @@ func TestCopyGraph_MemoryToOCI_PartialCopy(t *testing.T) {
 	}
 }
 
+func TestStore_DigestAlgorithms(t *testing.T) {
+	src := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, alg digest.Algorithm, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    alg.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, digest.SHA256, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, digest.SHA256, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, digest.SHA256, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, digest.SHA512, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1])                                      // Blob 3
+	generateManifest(descs[0], descs[1:3]...)                                 // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		if err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i])); err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	newStore := func(t *testing.T) *Store {
+		s, err := New(t.TempDir())
+		if err != nil {
+			t.Fatal("New() error =", err)
+		}
+		s.DigestAlgorithms = []digest.Algorithm{digest.SHA256}
+		return s
+	}
+
+	t.Run("push not allowed algorithm", func(t *testing.T) {
+		s := newStore(t)
+		err := s.Push(ctx, descs[2], bytes.NewReader(blobs[2]))
+		if !errors.Is(err, errdef.ErrUnsupported) {
+			t.Fatalf("Store.Push() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+		exists, err := s.Exists(ctx, descs[2])
+		if err != nil {
+			t.Fatal("Store.Exists() error =", err)
+		}
+		if exists {
+			t.Errorf("Store.Exists() = %v, want %v", exists, false)
+		}
+	})
+
+	t.Run("copy allowed algorithms", func(t *testing.T) {
+		s := newStore(t)
+		if err := oras.CopyGraph(ctx, src, s, descs[3], oras.CopyGraphOptions{}); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		for i := range descs[:2] {
+			exists, err := s.Exists(ctx, descs[i])
+			if err != nil {
+				t.Fatalf("Store.Exists(%d) error = %v", i, err)
+			}
+			if !exists {
+				t.Errorf("Store.Exists(%d) = %v, want %v", i, exists, true)
+			}
+		}
+	})
+
+	t.Run("copy not allowed algorithms", func(t *testing.T) {
+		s := newStore(t)
+		err := oras.CopyGraph(ctx, src, s, descs[4], oras.CopyGraphOptions{})
+		if !errors.Is(err, errdef.ErrUnsupported) {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+		// fail fast before any content is pushed
+		for i, desc := range descs {
+			exists, err := s.Exists(ctx, desc)
+			if err != nil {
+				t.Fatalf("Store.Exists(%d) error = %v", i, err)
+			}
+			if exists {
+				t.Errorf("Store.Exists(%d) = %v, want %v", i, exists, false)
+			}
+		}
+	})
+
+	t.Run("algorithms not restricted", func(t *testing.T) {
+		s, err := New(t.TempDir())
+		if err != nil {
+			t.Fatal("New() error =", err)
+		}
+		if got := s.AllowedDigestAlgorithms(); len(got) != 0 {
+			t.Errorf("Store.AllowedDigestAlgorithms() = %v, want empty", got)
+		}
+		if err := oras.CopyGraph(ctx, src, s, descs[4], oras.CopyGraphOptions{}); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+	})
+}
+
 func TestCopyGraph_OCIToMemory_FullCopy(t *testing.T) {
 	tempDir := t.TempDir()
 	src, err := New(tempDir)
@@ -2000,6 +2206,106 @@ func TestStore_Tags(t *testing.T) {
 	}
 }
 
+func TestStore_RefNames(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	s.TagListPageSize = 2
+	ctx := context.Background()
+
+	var descs []ocispec.Descriptor
+	for i := 0; i < 3; i++ {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			Config:      ocispec.DescriptorEmptyJSON,
+			Annotations: map[string]string{"blob_index": strconv.Itoa(i)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := s.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil {
+			t.Fatalf("failed to push test content: %d: %v", i, err)
+		}
+		descs = append(descs, desc)
+	}
+
+	// ref names are not restricted to the tag grammar
+	refs := map[string]ocispec.Descriptor{
+		"example.com/foo:v1": descs[0],
+		"a/b":                descs[1],
+		"sha256:abc":         descs[2],
+	}
+	for ref, desc := range refs {
+		if err := s.Tag(ctx, desc, ref); err != nil {
+			t.Fatalf("Store.Tag(%q) error = %v", ref, err)
+		}
+	}
+
+	// resolve by ref names, including the one looking like a digest prefix
+	for ref, want := range refs {
+		got, err := s.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("Store.Resolve(%q) error = %v", ref, err)
+		}
+		if got.Digest != want.Digest {
+			t.Errorf("Store.Resolve(%q) = %v, want %v", ref, got.Digest, want.Digest)
+		}
+	}
+
+	// list ref names in pages
+	var pages [][]string
+	if err := s.Tags(ctx, "", func(tags []string) error {
+		pages = append(pages, tags)
+		return nil
+	}); err != nil {
+		t.Fatal("Store.Tags() error =", err)
+	}
+	wantPages := [][]string{{"a/b", "example.com/foo:v1"}, {"sha256:abc"}}
+	if !reflect.DeepEqual(pages, wantPages) {
+		t.Errorf("Store.Tags() pages = %v, want %v", pages, wantPages)
+	}
+	pages = nil
+	if err := s.Tags(ctx, "a/b", func(tags []string) error {
+		pages = append(pages, tags)
+		return nil
+	}); err != nil {
+		t.Fatal("Store.Tags() error =", err)
+	}
+	wantPages = [][]string{{"example.com/foo:v1", "sha256:abc"}}
+	if !reflect.DeepEqual(pages, wantPages) {
+		t.Errorf("Store.Tags() pages = %v, want %v", pages, wantPages)
+	}
+
+	// ref names are read back from index.json
+	rs, err := NewFromFS(ctx, os.DirFS(tempDir))
+	if err != nil {
+		t.Fatal("NewFromFS() error =", err)
+	}
+	for ref, want := range refs {
+		got, err := rs.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("ReadOnlyStore.Resolve(%q) error = %v", ref, err)
+		}
+		if got.Digest != want.Digest {
+			t.Errorf("ReadOnlyStore.Resolve(%q) = %v, want %v", ref, got.Digest, want.Digest)
+		}
+	}
+	rs.TagListPageSize = 1
+	pages = nil
+	if err := rs.Tags(ctx, "", func(tags []string) error {
+		pages = append(pages, tags)
+		return nil
+	}); err != nil {
+		t.Fatal("ReadOnlyStore.Tags() error =", err)
+	}
+	wantPages = [][]string{{"a/b"}, {"example.com/foo:v1"}, {"sha256:abc"}}
+	if !reflect.DeepEqual(pages, wantPages) {
+		t.Errorf("ReadOnlyStore.Tags() pages = %v, want %v", pages, wantPages)
+	}
+}
+
 func TestStore_BasicDelete(t *testing.T) {
 	content := []byte("test delete")
 	desc := ocispec.Descriptor{
@@ -2100,6 +2406,75 @@ func TestStore_FetchAndDelete(t *testing.T) {
 	}
 }
 
+func TestStore_Predecessors_OutOfBand(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	ctx := context.Background()
+
+	pushManifest := func(s *Store, manifest ocispec.Manifest) ocispec.Descriptor {
+		manifest.MediaType = ocispec.MediaTypeImageManifest
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := s.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil {
+			t.Fatalf("Store.Push() error = %v", err)
+		}
+		return desc
+	}
+	config := []byte("{}")
+	configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, config)
+	if err := s.Push(ctx, configDesc, bytes.NewReader(config)); err != nil {
+		t.Fatalf("Store.Push() error = %v", err)
+	}
+	image := pushManifest(s, ocispec.Manifest{
+		Config: configDesc,
+		Layers: []ocispec.Descriptor{},
+	})
+	predecessors, err := s.Predecessors(ctx, image)
+	if err != nil {
+		t.Fatalf("Store.Predecessors() error = %v", err)
+	}
+	if len(predecessors) != 0 {
+		t.Errorf("Store.Predecessors() = %v, want none", predecessors)
+	}
+
+	// push a referrer through another store opened on the same layout
+	other, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	referrer := pushManifest(other, ocispec.Manifest{
+		ArtifactType: "application/vnd.test",
+		Config:       configDesc,
+		Layers:       []ocispec.Descriptor{},
+		Subject:      &image,
+	})
+
+	// the index file is not checked again within the check interval
+	predecessors, err = s.Predecessors(ctx, image)
+	if err != nil {
+		t.Fatalf("Store.Predecessors() error = %v", err)
+	}
+	if len(predecessors) != 0 {
+		t.Errorf("Store.Predecessors() = %v, want none", predecessors)
+	}
+
+	// the referrer is discovered lazily once the check interval elapses
+	s.indexChecked = s.indexChecked.Add(-indexCheckInterval)
+	predecessors, err = s.Predecessors(ctx, image)
+	if err != nil {
+		t.Fatalf("Store.Predecessors() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{referrer}; !equalDescriptorSet(predecessors, want) {
+		t.Errorf("Store.Predecessors() = %v, want %v", predecessors, want)
+	}
+}
+
 func TestStore_PredecessorsAndDelete(t *testing.T) {
 	tempDir := t.TempDir()
 	s, err := New(tempDir)
@@ -2672,6 +3047,233 @@ func TestStore_GC(t *testing.T) {
 	}
 }
 
+func TestStore_ConcurrentPushAndTag(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	ctx := context.Background()
+
+	// generate test content: a layer and a manifest per artifact
+	const count = 32
+	var layers, manifests []ocispec.Descriptor
+	var layerBlobs, manifestBlobs [][]byte
+	for i := 0; i < count; i++ {
+		layer := []byte("layer " + strconv.Itoa(i))
+		layerDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, layer)
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    ocispec.DescriptorEmptyJSON,
+			Layers:    []ocispec.Descriptor{layerDesc},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		layers = append(layers, layerDesc)
+		layerBlobs = append(layerBlobs, layer)
+		manifests = append(manifests, content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON))
+		manifestBlobs = append(manifestBlobs, manifestJSON)
+	}
+	if err := s.Push(ctx, ocispec.DescriptorEmptyJSON, bytes.NewReader(ocispec.DescriptorEmptyJSON.Data)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+
+	// push and tag concurrently
+	eg, egCtx := errgroup.WithContext(ctx)
+	for i := 0; i < count; i++ {
+		eg.Go(func() error {
+			if err := s.Push(egCtx, layers[i], bytes.NewReader(layerBlobs[i])); err != nil {
+				return fmt.Errorf("failed to push layer %d: %w", i, err)
+			}
+			if err := s.Push(egCtx, manifests[i], bytes.NewReader(manifestBlobs[i])); err != nil {
+				return fmt.Errorf("failed to push manifest %d: %w", i, err)
+			}
+			return s.Tag(egCtx, manifests[i], "v"+strconv.Itoa(i))
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	// verify the saved index by reloading the store
+	s, err = New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	if got := len(s.index.Manifests); got != count {
+		t.Errorf("len(index.Manifests) = %d, want %d", got, count)
+	}
+	for i := 0; i < count; i++ {
+		got, err := s.Resolve(ctx, "v"+strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("Store.Resolve(%d) error = %v", i, err)
+		}
+		if !content.Equal(got, manifests[i]) {
+			t.Errorf("Store.Resolve(%d) = %v, want %v", i, got, manifests[i])
+		}
+		exists, err := s.Exists(ctx, layers[i])
+		if err != nil {
+			t.Fatalf("Store.Exists(%d) error = %v", i, err)
+		}
+		if !exists {
+			t.Errorf("Store.Exists(%d) = %v, want true", i, exists)
+		}
+	}
+}
+
+func TestStore_DeleteAndGC_SharedBlob(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	s.AutoGC = false
+	ctx := context.Background()
+
+	// generate test content
+	var descs []ocispec.Descriptor
+	push := func(mediaType string, blob []byte) {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("Store.Push() error = %v", err)
+		}
+		descs = append(descs, desc)
+	}
+	pushManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		push(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	push(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	push(ocispec.MediaTypeImageLayer, []byte("shared"))  // Blob 1
+	push(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 2
+	pushManifest(descs[0], descs[1], descs[2])           // Blob 3
+	pushManifest(descs[0], descs[1])                     // Blob 4
+	if err := s.Tag(ctx, descs[3], "foo"); err != nil {
+		t.Fatalf("Store.Tag() error = %v", err)
+	}
+	if err := s.Tag(ctx, descs[4], "bar"); err != nil {
+		t.Fatalf("Store.Tag() error = %v", err)
+	}
+
+	// delete the manifest without removing the dangling blobs
+	if err := s.Delete(ctx, descs[3]); err != nil {
+		t.Fatalf("Store.Delete() error = %v", err)
+	}
+	indexJSON, err := os.ReadFile(filepath.Join(tempDir, ocispec.ImageIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		t.Fatal(err)
+	}
+	for _, desc := range index.Manifests {
+		if desc.Digest == descs[3].Digest {
+			t.Errorf("index.json contains deleted manifest %s", desc.Digest)
+		}
+	}
+	if exists, _ := s.Exists(ctx, descs[2]); !exists {
+		t.Errorf("Store.Delete() removed blob %d without AutoGC", 2)
+	}
+
+	// GC removes the blob referenced only by the deleted manifest
+	if err := s.GC(ctx); err != nil {
+		t.Fatalf("Store.GC() error = %v", err)
+	}
+	for i, want := range []bool{true, true, false, false, true} {
+		exists, err := s.Exists(ctx, descs[i])
+		if err != nil {
+			t.Fatalf("Store.Exists(%d) error = %v", i, err)
+		}
+		if exists != want {
+			t.Errorf("Store.Exists(%d) = %v, want %v", i, exists, want)
+		}
+	}
+}
+
+func TestStore_GCConcurrency(t *testing.T) {
+	tempDir := t.TempDir()
+	s, err := New(tempDir)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	s.GCConcurrency = 4
+	ctx := context.Background()
+
+	// push a tagged manifest
+	layer := []byte("layer")
+	layerDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, layer)
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    layerDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	if err := s.Push(ctx, layerDesc, bytes.NewReader(layer)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	if err := s.Push(ctx, manifestDesc, bytes.NewReader(manifestJSON)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	if err := s.Tag(ctx, manifestDesc, "latest"); err != nil {
+		t.Fatal("Store.Tag() error =", err)
+	}
+
+	// push garbage blobs whose metadata is not in s
+	var garbage []ocispec.Descriptor
+	for i := 0; i < 32; i++ {
+		blob := []byte("garbage " + strconv.Itoa(i))
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+		if err := s.storage.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal("Storage.Push() error =", err)
+		}
+		garbage = append(garbage, desc)
+	}
+
+	if err := s.GC(ctx); err != nil {
+		t.Fatal("Store.GC() error =", err)
+	}
+	for _, desc := range []ocispec.Descriptor{layerDesc, manifestDesc} {
+		exists, err := s.Exists(ctx, desc)
+		if err != nil {
+			t.Fatal("Store.Exists() error =", err)
+		}
+		if !exists {
+			t.Errorf("Store.Exists(%s) = %v, want true", desc.Digest, exists)
+		}
+	}
+	for i, desc := range garbage {
+		exists, err := s.storage.Exists(ctx, desc)
+		if err != nil {
+			t.Fatal("Storage.Exists() error =", err)
+		}
+		if exists {
+			t.Errorf("Storage.Exists(garbage %d) = %v, want false", i, exists)
+		}
+	}
+
+	// GC is canceled with the context
+	if err := s.storage.Push(ctx, garbage[0], bytes.NewReader([]byte("garbage 0"))); err != nil {
+		t.Fatal("Storage.Push() error =", err)
+	}
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := s.GC(canceledCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Store.GC() error = %v, wantErr %v", err, context.Canceled)
+	}
+}
+
 func TestStore_GCAndDeleteOnIndex(t *testing.T) {
 	tempDir := t.TempDir()
 	s, err := New(tempDir)
@@ -22,7 +22,9 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"path"
 	"slices"
+	"strings"
 
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -38,6 +40,12 @@ import (
 // content store based on file system with the OCI-Image layout.
 // Reference: https://github.com/opencontainers/image-spec/blob/v1.1.0/image-layout.md
 type ReadOnlyStore struct {
+	// TagListPageSize specifies the maximum number of tags passed to each
+	// invocation of the callback of Tags(), consistent with the pagination of
+	// the tag list API of remote repositories.
+	// If less than or equal to zero, all tags are listed in a single page.
+	TagListPageSize int
+
 	fsys        fs.FS
 	storage     content.ReadOnlyStorage
 	tagResolver *resolver.Memory
@@ -93,6 +101,16 @@ func (s *ReadOnlyStore) Resolve(ctx context.Context, reference string) (ocispec.
 		return ocispec.Descriptor{}, errdef.ErrMissingReference
 	}
 
+	// expand digest prefix, unless the reference is a ref name as ref names
+	// in OCI layouts are not restricted to the tag grammar.
+	if alg, prefix, ok := descriptor.ParseDigestPrefix(reference); ok && !isRefName(ctx, s.tagResolver, reference) {
+		dgst, err := resolveDigestPrefix(s.fsys, alg, prefix)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		reference = dgst.String()
+	}
+
 	// attempt resolving manifest
 	desc, err := s.tagResolver.Resolve(ctx, reference)
 	if err != nil {
@@ -125,7 +143,7 @@ func (s *ReadOnlyStore) Predecessors(ctx context.Context, node ocispec.Descripto
 //
 // See also `Tags()` in the package `registry`.
 func (s *ReadOnlyStore) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
-	return listTags(s.tagResolver, last, fn)
+	return listTags(s.tagResolver, last, s.TagListPageSize, fn)
 }
 
 // validateOCILayoutFile validates the `oci-layout` file.
@@ -210,13 +228,48 @@ func resolveBlob(fsys fs.FS, dgst string) (ocispec.Descriptor, error) {
 	}, nil
 }
 
+// resolveDigestPrefix resolves the digest of the given algorithm starting with
+// the given encoded prefix, looking up the blobs in fsys.
+// Returns ErrNotFound if no digest matches, and ErrAmbiguousReference if more
+// than one digest match.
+func resolveDigestPrefix(fsys fs.FS, alg digest.Algorithm, prefix string) (digest.Digest, error) {
+	reference := alg.String() + ":" + prefix
+	entries, err := fs.ReadDir(fsys, path.Join(ocispec.ImageBlobsDir, alg.String()))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+		}
+		return "", err
+	}
+	var matches []digest.Digest
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), prefix) {
+			continue
+		}
+		dgst := digest.NewDigestFromEncoded(alg, entry.Name())
+		if err := dgst.Validate(); err != nil {
+			// skip irrelevant content
+			continue
+		}
+		matches = append(matches, dgst)
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%s: matches %d digests: %w", reference, len(matches), errdef.ErrAmbiguousReference)
+	}
+}
+
 // listTags returns the tags in ascending order.
 // If `last` is NOT empty, the entries in the response start after the tag
 // specified by `last`. Otherwise, the response starts from the top of the tags
 // list.
 //
 // See also `Tags()` in the package `registry`.
-func listTags(tagResolver *resolver.Memory, last string, fn func(tags []string) error) error {
+func listTags(tagResolver *resolver.Memory, last string, pageSize int, fn func(tags []string) error) error {
 	var tags []string
 
 	tagMap := tagResolver.Map()
@@ -231,7 +284,23 @@ func listTags(tagResolver *resolver.Memory, last string, fn func(tags []string)
 	}
 	slices.Sort(tags)
 
-	return fn(tags)
+	if pageSize <= 0 || len(tags) <= pageSize {
+		return fn(tags)
+	}
+	for len(tags) > 0 {
+		n := min(pageSize, len(tags))
+		if err := fn(tags[:n]); err != nil {
+			return err
+		}
+		tags = tags[n:]
+	}
+	return nil
+}
+
+// isRefName returns true if reference is a ref name in the tag resolver.
+func isRefName(ctx context.Context, tagResolver *resolver.Memory, reference string) bool {
+	_, err := tagResolver.Resolve(ctx, reference)
+	return err == nil
 }
 
 // deleteAnnotationRefName deletes the AnnotationRefName from the annotation map
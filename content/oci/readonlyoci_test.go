@@ -36,6 +36,7 @@ import (
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/docker"
 	"oras.land/oras-go/v2/internal/spec"
 	"oras.land/oras-go/v2/registry"
@@ -509,6 +510,20 @@ func TestReadOnlyStore_TarFS(t *testing.T) {
 			if want := descs[3]; gotDesc.Size != want.Size || gotDesc.Digest != want.Digest {
 				t.Errorf("ReadOnlyStore.Resolve() = %v, want %v", gotDesc, want)
 			}
+			// test resolving by digest prefix
+			gotDesc, err = s.Resolve(ctx, "sha256:2db29710")
+			if err != nil {
+				t.Fatal("ReadOnlyStore: Resolve() error =", err)
+			}
+			if want := descs[1]; gotDesc.Size != want.Size || gotDesc.Digest != want.Digest {
+				t.Errorf("ReadOnlyStore.Resolve() = %v, want %v", gotDesc, want)
+			}
+			if _, err := s.Resolve(ctx, "sha256:f"); !errors.Is(err, errdef.ErrAmbiguousReference) {
+				t.Errorf("ReadOnlyStore.Resolve() error = %v, want %v", err, errdef.ErrAmbiguousReference)
+			}
+			if _, err := s.Resolve(ctx, "sha256:0"); !errors.Is(err, errdef.ErrNotFound) {
+				t.Errorf("ReadOnlyStore.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+			}
 
 			// test Predecessors
 			wantPredecessors := [][]ocispec.Descriptor{
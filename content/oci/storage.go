@@ -24,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/errdef"
@@ -46,6 +47,18 @@ var bufPool = sync.Pool{
 // Reference: https://github.com/opencontainers/image-spec/blob/v1.1.0/image-layout.md
 type Storage struct {
 	*ReadOnlyStorage
+	// BufferSize specifies the size of the buffer used for streaming content
+	// to the file system on push.
+	//   - If less than or equal to 0, a default (currently 1 MiB) is used,
+	//     which suits both local and high-latency transfers.
+	//   - Smaller buffers (e.g. 32 KiB) reduce the memory footprint of
+	//     concurrent pushes from local sources, while larger buffers (e.g.
+	//     4 MiB) reduce the number of disk writes for high-latency sources
+	//     delivering content in large chunks.
+	BufferSize int
+	// OnThroughput reports the throughput, in bytes per second, achieved by
+	// each successful push, if set. OnThroughput may be invoked concurrently.
+	OnThroughput func(desc ocispec.Descriptor, bytesPerSecond float64)
 	// root is the root directory of the OCI layout.
 	root string
 	// ingestRoot is the root directory of the temporary ingest files.
@@ -66,8 +79,23 @@ func NewStorage(root string) (*Storage, error) {
 	}, nil
 }
 
+// ingestOptions contains parameters for ingesting content.
+type ingestOptions struct {
+	bufferSize   int
+	onThroughput func(desc ocispec.Descriptor, bytesPerSecond float64)
+}
+
 // Push pushes the content, matching the expected descriptor.
 func (s *Storage) Push(_ context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	return s.push(expected, content, ingestOptions{
+		bufferSize:   s.BufferSize,
+		onThroughput: s.OnThroughput,
+	})
+}
+
+// push pushes the content, matching the expected descriptor, with the given
+// options.
+func (s *Storage) push(expected ocispec.Descriptor, content io.Reader, opts ingestOptions) error {
 	path, err := blobPath(expected.Digest)
 	if err != nil {
 		return fmt.Errorf("%s: %s: %w", expected.Digest, expected.MediaType, errdef.ErrInvalidDigest)
@@ -86,10 +114,12 @@ func (s *Storage) Push(_ context.Context, expected ocispec.Descriptor, content i
 	}
 
 	// write the content to a temporary ingest file.
-	ingest, err := s.ingest(expected, content)
+	start := time.Now()
+	ingest, err := s.ingest(expected, content, opts.bufferSize)
 	if err != nil {
 		return err
 	}
+	elapsed := time.Since(start)
 
 	// move the content from the temporary ingest file to the target path.
 	// since blobs are read-only once stored, if the target blob already exists,
@@ -104,6 +134,9 @@ func (s *Storage) Push(_ context.Context, expected ocispec.Descriptor, content i
 		return err
 	}
 
+	if opts.onThroughput != nil && elapsed > 0 {
+		opts.onThroughput(expected, float64(expected.Size)/elapsed.Seconds())
+	}
 	return nil
 }
 
@@ -124,8 +157,10 @@ func (s *Storage) Delete(ctx context.Context, target ocispec.Descriptor) error {
 	return nil
 }
 
-// ingest write the content into a temporary ingest file.
-func (s *Storage) ingest(expected ocispec.Descriptor, content io.Reader) (path string, ingestErr error) {
+// ingest write the content into a temporary ingest file, through a buffer of
+// the given size. If bufferSize is less than or equal to 0, a pooled buffer of
+// the default size is used.
+func (s *Storage) ingest(expected ocispec.Descriptor, content io.Reader, bufferSize int) (path string, ingestErr error) {
 	if err := ensureDir(s.ingestRoot); err != nil {
 		return "", fmt.Errorf("failed to ensure ingest dir: %w", err)
 	}
@@ -152,9 +187,17 @@ func (s *Storage) ingest(expected ocispec.Descriptor, content io.Reader) (path s
 		}
 	}()
 
-	buf := bufPool.Get().(*[]byte)
-	defer bufPool.Put(buf)
-	if err := ioutil.CopyBuffer(fp, content, *buf, expected); err != nil {
+	var buf []byte
+	if bufferSize > 0 {
+		buf = make([]byte, bufferSize)
+	} else {
+		pooled := bufPool.Get().(*[]byte)
+		defer bufPool.Put(pooled)
+		buf = *pooled
+	}
+	// hide the ReadFrom method of the file so that the buffer is used
+	w := struct{ io.Writer }{fp}
+	if err := ioutil.CopyBuffer(w, content, buf, expected); err != nil {
 		return "", fmt.Errorf("failed to ingest: %w", err)
 	}
 
@@ -279,6 +279,93 @@ func TestStorage_Push_Concurrent(t *testing.T) {
 	}
 }
 
+func TestStorage_Push_BufferSize(t *testing.T) {
+	content := bytes.Repeat([]byte("hello world "), 1024)
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+
+	s, err := NewStorage(t.TempDir())
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	s.BufferSize = 7 // tiny buffer not aligned with the content size
+	var reports int
+	s.OnThroughput = func(got ocispec.Descriptor, bytesPerSecond float64) {
+		reports++
+		if got.Digest != desc.Digest {
+			t.Errorf("OnThroughput() desc = %v, want %v", got, desc)
+		}
+		if bytesPerSecond <= 0 {
+			t.Errorf("OnThroughput() bytesPerSecond = %v, want positive", bytesPerSecond)
+		}
+	}
+	ctx := context.Background()
+
+	if err := s.Push(ctx, desc, bytes.NewReader(content)); err != nil {
+		t.Fatal("Storage.Push() error =", err)
+	}
+	if reports != 1 {
+		t.Errorf("OnThroughput() called %d times, want 1", reports)
+	}
+	rc, err := s.Fetch(ctx, desc)
+	if err != nil {
+		t.Fatal("Storage.Fetch() error =", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal("Storage.Fetch().Read() error =", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Error("Storage.Fetch().Close() error =", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("Storage.Fetch() = %v, want %v", got, content)
+	}
+
+	// corrupted content is still rejected
+	badDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes([]byte("foo")),
+		Size:      int64(len(content)),
+	}
+	if err := s.Push(ctx, badDesc, bytes.NewReader(content)); err == nil {
+		t.Error("Storage.Push() error = nil, wantErr true")
+	}
+	if reports != 1 {
+		t.Errorf("OnThroughput() called %d times, want 1", reports)
+	}
+}
+
+func BenchmarkStorage_Push(b *testing.B) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 1<<18) // 4 MiB
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	ctx := context.Background()
+	for _, bufferSize := range []int{4 << 10, 32 << 10, 1 << 20, 4 << 20} {
+		b.Run(fmt.Sprintf("buffer=%dKiB", bufferSize>>10), func(b *testing.B) {
+			b.SetBytes(desc.Size)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				s, err := NewStorage(b.TempDir())
+				if err != nil {
+					b.Fatal("New() error =", err)
+				}
+				s.BufferSize = bufferSize
+				b.StartTimer()
+				if err := s.Push(ctx, desc, bytes.NewReader(content)); err != nil {
+					b.Fatal("Storage.Push() error =", err)
+				}
+			}
+		})
+	}
+}
+
 func TestStorage_Fetch_ExistingBlobs(t *testing.T) {
 	content := []byte("hello world")
 	dgst := digest.FromBytes(content)
@@ -0,0 +1,291 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/container/set"
+)
+
+// VerifyOptions contains parameters for [Verify].
+type VerifyOptions struct {
+	// ExistenceOnly skips reading the blobs.
+	//   - If true, only the existence and the size of the blobs referenced by
+	//     `index.json` are checked.
+	//   - If false, every blob under the `blobs` directory is additionally
+	//     read and verified against the digest in its file name.
+	//   - Default value: false.
+	ExistenceOnly bool
+}
+
+// VerifyProblem describes a problem found in an OCI layout.
+type VerifyProblem struct {
+	// Path is the slash-separated path of the problematic file, relative to
+	// the root of the OCI layout.
+	Path string
+	// Descriptor describes the problematic content, if applicable.
+	Descriptor ocispec.Descriptor
+	// Err is the problem found.
+	Err error
+}
+
+// Error returns the message of the problem.
+func (p VerifyProblem) Error() string {
+	return fmt.Sprintf("%s: %v", p.Path, p.Err)
+}
+
+// Unwrap returns the inner error of the problem.
+func (p VerifyProblem) Unwrap() error {
+	return p.Err
+}
+
+// VerifyReport records the problems found by [Verify].
+type VerifyReport struct {
+	// Problems lists the problems found, in the order of discovery.
+	Problems []VerifyProblem
+}
+
+// OK returns true if no problem is found.
+func (r *VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Err returns the problems found as a single error, or nil if no problem is
+// found.
+func (r *VerifyReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	errs := make([]error, 0, len(r.Problems))
+	for _, p := range r.Problems {
+		errs = append(errs, p)
+	}
+	return errors.Join(errs...)
+}
+
+// Verify checks the integrity of the OCI layout in fsys, which can be used to
+// validate a layout before opening it as a Store or a ReadOnlyStore.
+// The following are checked:
+//   - the `oci-layout` file exists and declares a supported version.
+//   - the `index.json` file exists and can be decoded.
+//   - the manifests listed in `index.json`, and the content referenced by
+//     them, exist with the expected sizes.
+//   - unless opts.ExistenceOnly is set, every blob matches its digest.
+//
+// Problems found in the layout are recorded in the returned report, while the
+// returned error is reserved for failures preventing the verification, such as
+// context cancellation.
+//
+// Reference: https://github.com/opencontainers/image-spec/blob/v1.1.0/image-layout.md
+func Verify(ctx context.Context, fsys fs.FS, opts VerifyOptions) (*VerifyReport, error) {
+	v := &verifier{
+		fsys:     fsys,
+		storage:  NewStorageFromFS(fsys),
+		report:   &VerifyReport{},
+		reported: set.New[string](),
+	}
+	v.verifyOCILayoutFile()
+	if err := v.verifyIndex(ctx); err != nil {
+		return nil, err
+	}
+	if !opts.ExistenceOnly {
+		if err := v.verifyBlobs(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return v.report, nil
+}
+
+// verifier holds the state of a verification.
+type verifier struct {
+	fsys     fs.FS
+	storage  content.ReadOnlyStorage
+	report   *VerifyReport
+	reported set.Set[string]
+}
+
+// addProblem records a problem, deduplicated by the path of the file.
+func (v *verifier) addProblem(path string, desc ocispec.Descriptor, err error) {
+	if v.reported.Contains(path) {
+		return
+	}
+	v.reported.Add(path)
+	v.report.Problems = append(v.report.Problems, VerifyProblem{
+		Path:       path,
+		Descriptor: desc,
+		Err:        err,
+	})
+}
+
+// verifyOCILayoutFile verifies the `oci-layout` file.
+func (v *verifier) verifyOCILayoutFile() {
+	layoutJSON, err := fs.ReadFile(v.fsys, ocispec.ImageLayoutFile)
+	if err != nil {
+		v.addProblem(ocispec.ImageLayoutFile, ocispec.Descriptor{}, fileError(err))
+		return
+	}
+	var layout ocispec.ImageLayout
+	if err := json.Unmarshal(layoutJSON, &layout); err != nil {
+		v.addProblem(ocispec.ImageLayoutFile, ocispec.Descriptor{}, fmt.Errorf("failed to decode OCI layout file: %w", err))
+		return
+	}
+	if err := validateOCILayout(&layout); err != nil {
+		v.addProblem(ocispec.ImageLayoutFile, ocispec.Descriptor{}, fmt.Errorf("version %q: %w", layout.Version, err))
+	}
+}
+
+// verifyIndex verifies the `index.json` file and the graphs rooted by the
+// manifests listed in it.
+func (v *verifier) verifyIndex(ctx context.Context) error {
+	indexJSON, err := fs.ReadFile(v.fsys, ocispec.ImageIndexFile)
+	if err != nil {
+		v.addProblem(ocispec.ImageIndexFile, ocispec.Descriptor{}, fileError(err))
+		return nil
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		v.addProblem(ocispec.ImageIndexFile, ocispec.Descriptor{}, fmt.Errorf("failed to decode index file: %w", err))
+		return nil
+	}
+
+	visited := set.New[digest.Digest]()
+	nodes := make([]ocispec.Descriptor, 0, len(index.Manifests))
+	for _, desc := range index.Manifests {
+		nodes = append(nodes, deleteAnnotationRefName(desc))
+	}
+	for len(nodes) > 0 {
+		if err := isContextDone(ctx); err != nil {
+			return err
+		}
+		node := nodes[len(nodes)-1]
+		nodes = nodes[:len(nodes)-1]
+		if visited.Contains(node.Digest) {
+			continue
+		}
+		visited.Add(node.Digest)
+
+		p, ok := v.verifyExistence(node)
+		if !ok {
+			continue
+		}
+		successors, err := content.Successors(ctx, v.storage, node)
+		if err != nil {
+			v.addProblem(p, node, fmt.Errorf("failed to find successors: %w", err))
+			continue
+		}
+		nodes = append(nodes, successors...)
+	}
+	return nil
+}
+
+// verifyExistence verifies that the blob described by desc exists with the
+// expected size. verifyExistence returns the path of the blob, and true if no
+// problem is found.
+func (v *verifier) verifyExistence(desc ocispec.Descriptor) (string, bool) {
+	p, err := blobPath(desc.Digest)
+	if err != nil {
+		v.addProblem(ocispec.ImageIndexFile, desc, err)
+		return "", false
+	}
+	fi, err := fs.Stat(v.fsys, p)
+	if err != nil {
+		v.addProblem(p, desc, fileError(err))
+		return p, false
+	}
+	if fi.Size() != desc.Size {
+		v.addProblem(p, desc, fmt.Errorf("mismatched size: expect %d, got %d: %w", desc.Size, fi.Size(), content.ErrInvalidDescriptorSize))
+		return p, false
+	}
+	return p, true
+}
+
+// verifyBlobs verifies that the content of every blob under the `blobs`
+// directory matches the digest in its file name.
+func (v *verifier) verifyBlobs(ctx context.Context) error {
+	algDirs, err := fs.ReadDir(v.fsys, ocispec.ImageBlobsDir)
+	if err != nil {
+		v.addProblem(ocispec.ImageBlobsDir, ocispec.Descriptor{}, fileError(err))
+		return nil
+	}
+	for _, algDir := range algDirs {
+		alg := algDir.Name()
+		// skip unsupported directories
+		if !algDir.IsDir() || !isKnownAlgorithm(alg) {
+			continue
+		}
+		algPath := path.Join(ocispec.ImageBlobsDir, alg)
+		entries, err := fs.ReadDir(v.fsys, algPath)
+		if err != nil {
+			v.addProblem(algPath, ocispec.Descriptor{}, fileError(err))
+			continue
+		}
+		for _, entry := range entries {
+			if err := isContextDone(ctx); err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				continue
+			}
+			blobDigest := digest.NewDigestFromEncoded(digest.Algorithm(alg), entry.Name())
+			if err := blobDigest.Validate(); err != nil {
+				// skip irrelevant content
+				continue
+			}
+			p := path.Join(algPath, entry.Name())
+			if err := v.verifyDigest(p, blobDigest); err != nil {
+				v.addProblem(p, ocispec.Descriptor{Digest: blobDigest}, err)
+			}
+		}
+	}
+	return nil
+}
+
+// verifyDigest reads the blob at path and verifies it against dgst.
+func (v *verifier) verifyDigest(path string, dgst digest.Digest) error {
+	fp, err := v.fsys.Open(path)
+	if err != nil {
+		return fileError(err)
+	}
+	defer fp.Close()
+
+	dv := dgst.Verifier()
+	if _, err := io.Copy(dv, fp); err != nil {
+		return fmt.Errorf("failed to read blob: %w", err)
+	}
+	if !dv.Verified() {
+		return content.ErrMismatchedDigest
+	}
+	return nil
+}
+
+// fileError converts a file system error to a verification problem.
+func fileError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return errdef.ErrNotFound
+	}
+	return err
+}
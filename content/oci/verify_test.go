@@ -0,0 +1,255 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// newVerifyTestLayout creates an OCI layout with a tagged manifest, and returns
+// the root of the layout and the descriptors of the config, the layer and the
+// manifest.
+func newVerifyTestLayout(t *testing.T) (string, []ocispec.Descriptor) {
+	t.Helper()
+	root := t.TempDir()
+	s, err := New(root)
+	if err != nil {
+		t.Fatal("New() error =", err)
+	}
+	ctx := context.Background()
+
+	var descs []ocispec.Descriptor
+	push := func(mediaType string, blob []byte) {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("Store.Push() error = %v", err)
+		}
+		descs = append(descs, desc)
+	}
+	push(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	push(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:2],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(ocispec.MediaTypeImageManifest, manifestJSON) // Blob 2
+	if err := s.Tag(ctx, descs[2], "latest"); err != nil {
+		t.Fatalf("Store.Tag() error = %v", err)
+	}
+	return root, descs
+}
+
+// overwriteBlob replaces the content of the blob described by desc.
+func overwriteBlob(t *testing.T, root string, desc ocispec.Descriptor, blob []byte) string {
+	t.Helper()
+	path, err := blobPath(desc.Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullPath := filepath.Join(root, path)
+	if err := os.Chmod(fullPath, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fullPath, blob, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerify_GoodLayout(t *testing.T) {
+	root, _ := newVerifyTestLayout(t)
+	ctx := context.Background()
+	for _, opts := range []VerifyOptions{{}, {ExistenceOnly: true}} {
+		report, err := Verify(ctx, os.DirFS(root), opts)
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if !report.OK() {
+			t.Errorf("Verify(%+v) problems = %v, want none", opts, report.Err())
+		}
+		if err := report.Err(); err != nil {
+			t.Errorf("VerifyReport.Err() = %v, want nil", err)
+		}
+	}
+}
+
+func TestVerify_CorruptedBlob(t *testing.T) {
+	root, descs := newVerifyTestLayout(t)
+	ctx := context.Background()
+
+	// corrupt the layer while keeping its size
+	path := overwriteBlob(t, root, descs[1], []byte("bar"))
+
+	// full verification detects the corruption
+	report, err := Verify(ctx, os.DirFS(root), VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(report.Problems) != 1 {
+		t.Fatalf("Verify() problems = %v, want 1 problem", report.Problems)
+	}
+	problem := report.Problems[0]
+	if problem.Path != path {
+		t.Errorf("VerifyProblem.Path = %s, want %s", problem.Path, path)
+	}
+	if problem.Descriptor.Digest != descs[1].Digest {
+		t.Errorf("VerifyProblem.Descriptor.Digest = %s, want %s", problem.Descriptor.Digest, descs[1].Digest)
+	}
+	if !errors.Is(report.Err(), content.ErrMismatchedDigest) {
+		t.Errorf("VerifyReport.Err() = %v, want %v", report.Err(), content.ErrMismatchedDigest)
+	}
+
+	// existence check does not read the layer
+	report, err = Verify(ctx, os.DirFS(root), VerifyOptions{ExistenceOnly: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("Verify(ExistenceOnly) problems = %v, want none", report.Err())
+	}
+
+	// truncated blobs are detected by existence check
+	overwriteBlob(t, root, descs[1], []byte("ba"))
+	report, err = Verify(ctx, os.DirFS(root), VerifyOptions{ExistenceOnly: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !errors.Is(report.Err(), content.ErrInvalidDescriptorSize) {
+		t.Errorf("VerifyReport.Err() = %v, want %v", report.Err(), content.ErrInvalidDescriptorSize)
+	}
+}
+
+func TestVerify_CorruptedManifest(t *testing.T) {
+	root, descs := newVerifyTestLayout(t)
+	ctx := context.Background()
+
+	// corrupt the manifest while keeping its size
+	manifestJSON := bytes.Repeat([]byte("x"), int(descs[2].Size))
+	path := overwriteBlob(t, root, descs[2], manifestJSON)
+
+	for _, opts := range []VerifyOptions{{}, {ExistenceOnly: true}} {
+		report, err := Verify(ctx, os.DirFS(root), opts)
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		if len(report.Problems) != 1 {
+			t.Fatalf("Verify(%+v) problems = %v, want 1 problem", opts, report.Problems)
+		}
+		if got := report.Problems[0].Path; got != path {
+			t.Errorf("VerifyProblem.Path = %s, want %s", got, path)
+		}
+		if !errors.Is(report.Err(), content.ErrMismatchedDigest) {
+			t.Errorf("VerifyReport.Err() = %v, want %v", report.Err(), content.ErrMismatchedDigest)
+		}
+	}
+}
+
+func TestVerify_MissingBlob(t *testing.T) {
+	root, descs := newVerifyTestLayout(t)
+	ctx := context.Background()
+
+	path, err := blobPath(descs[0].Digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(root, path)); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Verify(ctx, os.DirFS(root), VerifyOptions{ExistenceOnly: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(report.Problems) != 1 {
+		t.Fatalf("Verify() problems = %v, want 1 problem", report.Problems)
+	}
+	if got := report.Problems[0].Path; got != path {
+		t.Errorf("VerifyProblem.Path = %s, want %s", got, path)
+	}
+	if !errors.Is(report.Err(), errdef.ErrNotFound) {
+		t.Errorf("VerifyReport.Err() = %v, want %v", report.Err(), errdef.ErrNotFound)
+	}
+}
+
+func TestVerify_InvalidLayoutFiles(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("foo")
+	dgst := digest.FromBytes(blob)
+	fsys := fstest.MapFS{
+		ocispec.ImageLayoutFile: {
+			Data: []byte(`{"imageLayoutVersion":"2.0.0"}`),
+		},
+		ocispec.ImageIndexFile: {
+			Data: []byte(`{"manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"` + dgst.String() + `","size":3}]}`),
+		},
+	}
+	report, err := Verify(ctx, fsys, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	var gotPaths []string
+	for _, p := range report.Problems {
+		gotPaths = append(gotPaths, p.Path)
+	}
+	manifestPath, err := blobPath(dgst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPaths := []string{ocispec.ImageLayoutFile, manifestPath, ocispec.ImageBlobsDir}
+	if len(gotPaths) != len(wantPaths) {
+		t.Fatalf("Verify() problem paths = %v, want %v", gotPaths, wantPaths)
+	}
+	for i := range wantPaths {
+		if gotPaths[i] != wantPaths[i] {
+			t.Errorf("Verify() problem paths = %v, want %v", gotPaths, wantPaths)
+			break
+		}
+	}
+	if !errors.Is(report.Problems[0], errdef.ErrUnsupportedVersion) {
+		t.Errorf("VerifyProblem = %v, want %v", report.Problems[0], errdef.ErrUnsupportedVersion)
+	}
+	if !errors.Is(report.Problems[1], errdef.ErrNotFound) {
+		t.Errorf("VerifyProblem = %v, want %v", report.Problems[1], errdef.ErrNotFound)
+	}
+
+	// missing index file
+	report, err = Verify(ctx, fstest.MapFS{}, VerifyOptions{ExistenceOnly: true})
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(report.Problems) != 2 {
+		t.Fatalf("Verify() problems = %v, want 2 problems", report.Problems)
+	}
+	if got := report.Problems[1].Path; got != ocispec.ImageIndexFile {
+		t.Errorf("VerifyProblem.Path = %s, want %s", got, ocispec.ImageIndexFile)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package overlay provides a copy-on-write target over a read-only base.
+package overlay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// ReadOnlyTarget represents a read-only base, which is compatible with
+// `oras.ReadOnlyTarget`.
+type ReadOnlyTarget interface {
+	content.ReadOnlyStorage
+	content.Resolver
+}
+
+// Target represents a writable overlay, which is compatible with
+// `oras.Target`.
+type Target interface {
+	content.Storage
+	content.TagResolver
+}
+
+// Store represents a copy-on-write target, which implements `oras.Target`.
+// Reads fall through to the base if the content or the reference is not found
+// in the overlay, and writes only go to the overlay. Therefore, the content
+// and the references in the overlay shadow the ones in the base.
+type Store struct {
+	base    ReadOnlyTarget
+	overlay Target
+}
+
+// New creates a new copy-on-write target reading from base and overlay, and
+// writing to overlay only.
+func New(base ReadOnlyTarget, overlay Target) *Store {
+	return &Store{
+		base:    base,
+		overlay: overlay,
+	}
+}
+
+// Fetch fetches the content identified by the descriptor from the overlay,
+// or from the base if the content does not exist in the overlay.
+func (s *Store) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	exists, err := s.overlay.Exists(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return s.overlay.Fetch(ctx, target)
+	}
+	return s.base.Fetch(ctx, target)
+}
+
+// Push pushes the content, matching the expected descriptor, to the overlay.
+// ErrAlreadyExists is returned if the content exists in the overlay or the
+// base.
+func (s *Store) Push(ctx context.Context, expected ocispec.Descriptor, reader io.Reader) error {
+	exists, err := s.base.Exists(ctx, expected)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%s: %s: %w", expected.Digest, expected.MediaType, errdef.ErrAlreadyExists)
+	}
+	return s.overlay.Push(ctx, expected, reader)
+}
+
+// Exists returns true if the described content exists in the overlay or the
+// base.
+func (s *Store) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	exists, err := s.overlay.Exists(ctx, target)
+	if err != nil || exists {
+		return exists, err
+	}
+	return s.base.Exists(ctx, target)
+}
+
+// Resolve resolves a reference to a descriptor from the overlay, or from the
+// base if the reference is not found in the overlay.
+func (s *Store) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	desc, err := s.overlay.Resolve(ctx, reference)
+	if err == nil || !errors.Is(err, errdef.ErrNotFound) {
+		return desc, err
+	}
+	return s.base.Resolve(ctx, reference)
+}
+
+// Tag tags a descriptor with a reference string in the overlay.
+// If the tagged content only exists in the base, it is copied to the overlay
+// before tagging.
+func (s *Store) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	exists, err := s.overlay.Exists(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := s.copyUp(ctx, desc); err != nil {
+			return err
+		}
+	}
+	return s.overlay.Tag(ctx, desc, reference)
+}
+
+// Delete removes the content identified by the descriptor from the overlay.
+// The content in the base is not affected, and remains readable after
+// deletion. ErrUnsupported is returned if the overlay does not support
+// deletion.
+func (s *Store) Delete(ctx context.Context, target ocispec.Descriptor) error {
+	deleter, ok := s.overlay.(content.Deleter)
+	if !ok {
+		return fmt.Errorf("overlay does not support deletion: %w", errdef.ErrUnsupported)
+	}
+	return deleter.Delete(ctx, target)
+}
+
+// copyUp copies the content described by desc from the base to the overlay.
+func (s *Store) copyUp(ctx context.Context, desc ocispec.Descriptor) error {
+	rc, err := s.base.Fetch(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := s.overlay.Push(ctx, desc, rc); err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package overlay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+func TestStore(t *testing.T) {
+	baseContent := []byte("base")
+	baseDesc := content.NewDescriptorFromBytes("test", baseContent)
+	overlayContent := []byte("overlay")
+	overlayDesc := content.NewDescriptorFromBytes("test", overlayContent)
+	ref := "foobar"
+	ctx := context.Background()
+
+	base := memory.New()
+	if err := base.Push(ctx, baseDesc, bytes.NewReader(baseContent)); err != nil {
+		t.Fatalf("base.Push() error = %v", err)
+	}
+	if err := base.Tag(ctx, baseDesc, ref); err != nil {
+		t.Fatalf("base.Tag() error = %v", err)
+	}
+	overlay := memory.New()
+	s := New(base, overlay)
+
+	// base content is readable
+	exists, err := s.Exists(ctx, baseDesc)
+	if err != nil {
+		t.Fatalf("Store.Exists() error = %v", err)
+	}
+	if !exists {
+		t.Errorf("Store.Exists() = %v, want %v", exists, true)
+	}
+	got, err := content.FetchAll(ctx, s, baseDesc)
+	if err != nil {
+		t.Fatalf("Store.Fetch() error = %v", err)
+	}
+	if !bytes.Equal(got, baseContent) {
+		t.Errorf("Store.Fetch() = %v, want %v", got, baseContent)
+	}
+	gotDesc, err := s.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatalf("Store.Resolve() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotDesc, baseDesc) {
+		t.Errorf("Store.Resolve() = %v, want %v", gotDesc, baseDesc)
+	}
+
+	// pushing base content is rejected
+	err = s.Push(ctx, baseDesc, bytes.NewReader(baseContent))
+	if !errors.Is(err, errdef.ErrAlreadyExists) {
+		t.Errorf("Store.Push() error = %v, wantErr %v", err, errdef.ErrAlreadyExists)
+	}
+
+	// writes go to the overlay only
+	if err := s.Push(ctx, overlayDesc, bytes.NewReader(overlayContent)); err != nil {
+		t.Fatalf("Store.Push() error = %v", err)
+	}
+	if err := s.Tag(ctx, overlayDesc, ref); err != nil {
+		t.Fatalf("Store.Tag() error = %v", err)
+	}
+	exists, err = base.Exists(ctx, overlayDesc)
+	if err != nil {
+		t.Fatalf("base.Exists() error = %v", err)
+	}
+	if exists {
+		t.Errorf("base.Exists() = %v, want %v", exists, false)
+	}
+	gotDesc, err = base.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatalf("base.Resolve() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotDesc, baseDesc) {
+		t.Errorf("base.Resolve() = %v, want %v", gotDesc, baseDesc)
+	}
+
+	// the overlay shadows the base
+	gotDesc, err = s.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatalf("Store.Resolve() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotDesc, overlayDesc) {
+		t.Errorf("Store.Resolve() = %v, want %v", gotDesc, overlayDesc)
+	}
+	got, err = content.FetchAll(ctx, s, overlayDesc)
+	if err != nil {
+		t.Fatalf("Store.Fetch() error = %v", err)
+	}
+	if !bytes.Equal(got, overlayContent) {
+		t.Errorf("Store.Fetch() = %v, want %v", got, overlayContent)
+	}
+
+	// tagging base content copies it to the overlay
+	newRef := "hello"
+	if err := s.Tag(ctx, baseDesc, newRef); err != nil {
+		t.Fatalf("Store.Tag() error = %v", err)
+	}
+	exists, err = overlay.Exists(ctx, baseDesc)
+	if err != nil {
+		t.Fatalf("overlay.Exists() error = %v", err)
+	}
+	if !exists {
+		t.Errorf("overlay.Exists() = %v, want %v", exists, true)
+	}
+	if _, err := base.Resolve(ctx, newRef); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("base.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+
+	// memory store does not support deletion
+	if err := s.Delete(ctx, overlayDesc); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Store.Delete() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+	}
+}
+
+func TestStore_NotFound(t *testing.T) {
+	ctx := context.Background()
+	s := New(memory.New(), memory.New())
+	desc := content.NewDescriptorFromBytes("test", []byte("foo"))
+
+	exists, err := s.Exists(ctx, desc)
+	if err != nil {
+		t.Fatalf("Store.Exists() error = %v", err)
+	}
+	if exists {
+		t.Errorf("Store.Exists() = %v, want %v", exists, false)
+	}
+	if _, err := s.Fetch(ctx, desc); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Fetch() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+	if _, err := s.Resolve(ctx, "foobar"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+	if err := s.Tag(ctx, desc, "foobar"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Tag() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
@@ -132,6 +132,49 @@ func ReadAll(r io.Reader, desc ocispec.Descriptor) ([]byte, error) {
 	return buf, nil
 }
 
+// DescribedReadCloser is an io.ReadCloser of the content described by a
+// descriptor, which is exposed for the downstream consumers of the reader.
+type DescribedReadCloser interface {
+	io.ReadCloser
+
+	// Descriptor returns the descriptor of the content being read.
+	Descriptor() ocispec.Descriptor
+}
+
+// describedReadCloser is the basic implementation of DescribedReadCloser.
+type describedReadCloser struct {
+	io.ReadCloser
+	desc ocispec.Descriptor
+}
+
+// Descriptor returns the descriptor of the content being read.
+func (rc *describedReadCloser) Descriptor() ocispec.Descriptor {
+	return rc.desc
+}
+
+// describedReadSeekCloser is a describedReadCloser on top of a seekable
+// reader.
+type describedReadSeekCloser struct {
+	*describedReadCloser
+	io.Seeker
+}
+
+// NewDescribedReadCloser wraps rc for reading the content described by desc.
+// The returned reader is an io.Seeker if rc is an io.Seeker.
+func NewDescribedReadCloser(rc io.ReadCloser, desc ocispec.Descriptor) DescribedReadCloser {
+	drc := &describedReadCloser{
+		ReadCloser: rc,
+		desc:       desc,
+	}
+	if seeker, ok := rc.(io.Seeker); ok {
+		return &describedReadSeekCloser{
+			describedReadCloser: drc,
+			Seeker:              seeker,
+		}
+	}
+	return drc
+}
+
 // ensureEOF ensures the read operation ends with an EOF and no
 // trailing data is present.
 func ensureEOF(r io.Reader) error {
@@ -20,6 +20,7 @@ import (
 	_ "crypto/sha256"
 	"errors"
 	"io"
+	"reflect"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -224,3 +225,50 @@ func TestReadAll_InvalidDescriptorSize(t *testing.T) {
 		t.Errorf("ReadAll() error = %v, want %v", err, ErrInvalidDescriptorSize)
 	}
 }
+
+func TestNewDescribedReadCloser(t *testing.T) {
+	content := []byte("example content")
+	desc := NewDescriptorFromBytes("test", content)
+
+	// non-seekable reader
+	rc := NewDescribedReadCloser(io.NopCloser(bytes.NewReader(content)), desc)
+	if got := rc.Descriptor(); !reflect.DeepEqual(got, desc) {
+		t.Errorf("DescribedReadCloser.Descriptor() = %v, want %v", got, desc)
+	}
+	if _, ok := rc.(io.Seeker); ok {
+		t.Error("DescribedReadCloser is an io.Seeker, want not")
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal("DescribedReadCloser.Read() error = ", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("DescribedReadCloser.Read() = %v, want %v", got, content)
+	}
+	if err := rc.Close(); err != nil {
+		t.Error("DescribedReadCloser.Close() error = ", err)
+	}
+
+	// seekable reader
+	rc = NewDescribedReadCloser(struct {
+		io.ReadSeeker
+		io.Closer
+	}{bytes.NewReader(content), io.NopCloser(nil)}, desc)
+	if got := rc.Descriptor(); !reflect.DeepEqual(got, desc) {
+		t.Errorf("DescribedReadCloser.Descriptor() = %v, want %v", got, desc)
+	}
+	seeker, ok := rc.(io.Seeker)
+	if !ok {
+		t.Fatal("DescribedReadCloser is not an io.Seeker, want io.Seeker")
+	}
+	if _, err := seeker.Seek(8, io.SeekStart); err != nil {
+		t.Fatal("DescribedReadCloser.Seek() error = ", err)
+	}
+	got, err = io.ReadAll(rc)
+	if err != nil {
+		t.Fatal("DescribedReadCloser.Read() error = ", err)
+	}
+	if want := content[8:]; !bytes.Equal(got, want) {
+		t.Errorf("DescribedReadCloser.Read() = %v, want %v", got, want)
+	}
+}
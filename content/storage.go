@@ -19,12 +19,15 @@ import (
 	"context"
 	"io"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // Fetcher fetches content.
 type Fetcher interface {
 	// Fetch fetches the content identified by the descriptor.
+	// The returned reader may optionally implement DescribedReadCloser.
+	// See also FetchDescribed.
 	Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error)
 }
 
@@ -60,6 +63,16 @@ type Deleter interface {
 	Delete(ctx context.Context, target ocispec.Descriptor) error
 }
 
+// DigestAlgorithmLimiter restricts the digest algorithms of the content that
+// can be pushed.
+// DigestAlgorithmLimiter is an extension of Storage.
+type DigestAlgorithmLimiter interface {
+	// AllowedDigestAlgorithms returns the digest algorithms accepted by the
+	// storage. An empty list indicates that the digest algorithms are not
+	// restricted.
+	AllowedDigestAlgorithms() []digest.Algorithm
+}
+
 // FetchAll safely fetches the content described by the descriptor.
 // The fetched content is verified against the size and the digest.
 func FetchAll(ctx context.Context, fetcher Fetcher, desc ocispec.Descriptor) ([]byte, error) {
@@ -71,6 +84,22 @@ func FetchAll(ctx context.Context, fetcher Fetcher, desc ocispec.Descriptor) ([]
 	return ReadAll(rc, desc)
 }
 
+// FetchDescribed fetches the content described by the descriptor, and returns
+// a reader exposing the descriptor so that it does not need to be passed along
+// with the reader.
+// The reader returned by fetcher is returned as is if it is already a
+// DescribedReadCloser.
+func FetchDescribed(ctx context.Context, fetcher Fetcher, desc ocispec.Descriptor) (DescribedReadCloser, error) {
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, err
+	}
+	if drc, ok := rc.(DescribedReadCloser); ok {
+		return drc, nil
+	}
+	return NewDescribedReadCloser(rc, desc), nil
+}
+
 // FetcherFunc is the basic Fetch method defined in Fetcher.
 type FetcherFunc func(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error)
 
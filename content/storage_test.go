@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"reflect"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -56,3 +57,64 @@ func TestFetcherFunc_Fetch(t *testing.T) {
 		t.Errorf("FetcherFunc.Fetch() = %v, want %v", got, data)
 	}
 }
+
+func TestFetchDescribed(t *testing.T) {
+	data := []byte("test content")
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	ctx := context.Background()
+
+	// the fetched reader is wrapped
+	fetcher := FetcherFunc(func(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+		if target.Digest != desc.Digest {
+			return nil, errors.New("content not found")
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	rc, err := FetchDescribed(ctx, fetcher, desc)
+	if err != nil {
+		t.Fatalf("FetchDescribed() error = %v", err)
+	}
+	defer rc.Close()
+	if got := rc.Descriptor(); !reflect.DeepEqual(got, desc) {
+		t.Errorf("FetchDescribed().Descriptor() = %v, want %v", got, desc)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("FetchDescribed().Read() error = %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("FetchDescribed() = %v, want %v", got, data)
+	}
+
+	// the fetched reader is returned as is if it is already described
+	fetched := desc
+	fetched.Annotations = map[string]string{"foo": "bar"}
+	want := NewDescribedReadCloser(io.NopCloser(bytes.NewReader(data)), fetched)
+	fetcher = FetcherFunc(func(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+		return want, nil
+	})
+	rc, err = FetchDescribed(ctx, fetcher, desc)
+	if err != nil {
+		t.Fatalf("FetchDescribed() error = %v", err)
+	}
+	defer rc.Close()
+	if rc != want {
+		t.Errorf("FetchDescribed() = %v, want %v", rc, want)
+	}
+	if got := rc.Descriptor(); !reflect.DeepEqual(got, fetched) {
+		t.Errorf("FetchDescribed().Descriptor() = %v, want %v", got, fetched)
+	}
+
+	// fetch failure
+	errFetch := errors.New("content not found")
+	fetcher = FetcherFunc(func(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+		return nil, errFetch
+	})
+	if _, err := FetchDescribed(ctx, fetcher, desc); !errors.Is(err, errFetch) {
+		t.Errorf("FetchDescribed() error = %v, want %v", err, errFetch)
+	}
+}
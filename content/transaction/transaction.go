@@ -0,0 +1,276 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package transaction provides a target wrapper tracking the mutations of a
+// multi-step push, so that they can be committed or rolled back as a whole on
+// a best-effort basis.
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+)
+
+// ErrTransactionDone is returned when a transaction is used after it is
+// committed or rolled back.
+var ErrTransactionDone = errors.New("transaction has already been committed or rolled back")
+
+// Target represents the underlying target, which is compatible with
+// `oras.Target`.
+// The target should implement content.Deleter for rolling back the pushed
+// content.
+type Target interface {
+	content.Storage
+	content.TagResolver
+}
+
+// Transaction represents a target tracking the mutations to the underlying
+// target, which implements `oras.Target`.
+//
+// As remote registries lack true transactions, content is pushed to the
+// underlying target immediately, and the descriptors of the pushed content are
+// tracked for cleanup. Tags are buffered until Commit is called, so that the
+// partially pushed content is not referenced by any tag on failure.
+type Transaction struct {
+	target Target
+
+	lock   sync.Mutex
+	pushed []ocispec.Descriptor
+	tags   []tagOperation
+	done   bool
+}
+
+// tagOperation is a buffered tag operation.
+type tagOperation struct {
+	desc      ocispec.Descriptor
+	reference string
+}
+
+// New creates a new transaction on top of the target.
+func New(target Target) *Transaction {
+	return &Transaction{
+		target: target,
+	}
+}
+
+// Fetch fetches the content identified by the descriptor.
+func (t *Transaction) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	return t.target.Fetch(ctx, target)
+}
+
+// Exists returns true if the described content exists.
+func (t *Transaction) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	return t.target.Exists(ctx, target)
+}
+
+// Push pushes the content, matching the expected descriptor, to the
+// underlying target, and tracks the descriptor for rolling back.
+// The content already existing in the underlying target before the push is
+// not tracked, so that it is not deleted on rollback, even if the underlying
+// target accepts pushing existing content, as remote registries do.
+func (t *Transaction) Push(ctx context.Context, expected ocispec.Descriptor, reader io.Reader) error {
+	if t.isDone() {
+		return ErrTransactionDone
+	}
+	exists, err := t.target.Exists(ctx, expected)
+	if err != nil {
+		return err
+	}
+	if err := t.target.Push(ctx, expected, reader); err != nil {
+		return err
+	}
+	if !exists {
+		t.track(expected)
+	}
+	return nil
+}
+
+// Mount mounts the blob described by desc from fromRepo into the underlying
+// target, and tracks the descriptor for rolling back, unless the blob already
+// exists in the underlying target before mounting.
+// If the underlying target does not implement registry.Mounter, the content
+// returned by getContent is pushed instead, and ErrUnsupported is returned if
+// getContent is nil.
+func (t *Transaction) Mount(ctx context.Context, desc ocispec.Descriptor, fromRepo string, getContent func() (io.ReadCloser, error)) error {
+	if t.isDone() {
+		return ErrTransactionDone
+	}
+	exists, err := t.target.Exists(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if mounter, ok := t.target.(registry.Mounter); ok {
+		err = mounter.Mount(ctx, desc, fromRepo, getContent)
+	} else {
+		err = t.pushContent(ctx, desc, getContent)
+	}
+	if err != nil {
+		return err
+	}
+	if !exists {
+		t.track(desc)
+	}
+	return nil
+}
+
+// pushContent pushes the content returned by getContent to the underlying
+// target.
+func (t *Transaction) pushContent(ctx context.Context, desc ocispec.Descriptor, getContent func() (io.ReadCloser, error)) error {
+	if getContent == nil {
+		return fmt.Errorf("failed to mount %s: %w", desc.Digest, errdef.ErrUnsupported)
+	}
+	rc, err := getContent()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return t.target.Push(ctx, desc, rc)
+}
+
+// Locate returns the location of the underlying target, if the underlying
+// target implements registry.Locator.
+func (t *Transaction) Locate() (string, string) {
+	if locator, ok := t.target.(registry.Locator); ok {
+		return locator.Locate()
+	}
+	return "", ""
+}
+
+// track tracks the descriptor of the pushed content for rolling back.
+func (t *Transaction) track(desc ocispec.Descriptor) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.pushed = append(t.pushed, desc)
+}
+
+// Resolve resolves a reference to a descriptor, taking the tags buffered in
+// the transaction into account.
+func (t *Transaction) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	t.lock.Lock()
+	for i := len(t.tags) - 1; i >= 0; i-- {
+		if t.tags[i].reference == reference {
+			desc := t.tags[i].desc
+			t.lock.Unlock()
+			return desc, nil
+		}
+	}
+	t.lock.Unlock()
+	return t.target.Resolve(ctx, reference)
+}
+
+// Tag buffers the tag operation until the transaction is committed.
+// The descriptor must exist in the underlying target.
+func (t *Transaction) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	exists, err := t.target.Exists(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrNotFound)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.done {
+		return ErrTransactionDone
+	}
+	t.tags = append(t.tags, tagOperation{
+		desc:      desc,
+		reference: reference,
+	})
+	return nil
+}
+
+// Pushed returns the descriptors of the content pushed in the transaction, in
+// the order of pushing.
+func (t *Transaction) Pushed() []ocispec.Descriptor {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	pushed := make([]ocispec.Descriptor, len(t.pushed))
+	copy(pushed, t.pushed)
+	return pushed
+}
+
+// Commit applies the buffered tag operations to the underlying target in
+// order, and ends the transaction.
+// Commit stops at the first failed tag operation, leaving the tags applied
+// before in place.
+func (t *Transaction) Commit(ctx context.Context) error {
+	t.lock.Lock()
+	if t.done {
+		t.lock.Unlock()
+		return ErrTransactionDone
+	}
+	t.done = true
+	tags := t.tags
+	t.lock.Unlock()
+
+	for _, op := range tags {
+		if err := t.target.Tag(ctx, op.desc, op.reference); err != nil {
+			return fmt.Errorf("failed to tag %s as %s: %w", op.desc.Digest, op.reference, err)
+		}
+	}
+	return nil
+}
+
+// Rollback discards the buffered tag operations, attempts to delete the
+// content pushed in the transaction in the reverse order of pushing, and ends
+// the transaction.
+// Rollback is best-effort: it attempts to delete all the pushed content, and
+// returns the errors of the failed deletions joined. Content not found is
+// considered deleted.
+// If the underlying target does not implement content.Deleter, nothing is
+// deleted and ErrUnsupported is returned.
+func (t *Transaction) Rollback(ctx context.Context) error {
+	t.lock.Lock()
+	if t.done {
+		t.lock.Unlock()
+		return ErrTransactionDone
+	}
+	t.done = true
+	pushed := t.pushed
+	t.lock.Unlock()
+
+	if len(pushed) == 0 {
+		return nil
+	}
+	deleter, ok := t.target.(content.Deleter)
+	if !ok {
+		return fmt.Errorf("failed to delete pushed content: %w", errdef.ErrUnsupported)
+	}
+	var errs []error
+	for i := len(pushed) - 1; i >= 0; i-- {
+		desc := pushed[i]
+		if err := deleter.Delete(ctx, desc); err != nil && !errors.Is(err, errdef.ErrNotFound) {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %s: %w", desc.Digest, desc.MediaType, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isDone returns true if the transaction is committed or rolled back.
+func (t *Transaction) isDone() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.done
+}
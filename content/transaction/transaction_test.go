@@ -0,0 +1,241 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// deletableStore is a memory store recording the attempts of deletions and
+// failing the pushes of the given media type. If acceptExisting is set,
+// pushing existing content succeeds, as remote registries do.
+type deletableStore struct {
+	*memory.Store
+	failMediaType  string
+	acceptExisting bool
+
+	lock    sync.Mutex
+	deleted []ocispec.Descriptor
+}
+
+func (s *deletableStore) Push(ctx context.Context, expected ocispec.Descriptor, reader io.Reader) error {
+	if expected.MediaType == s.failMediaType {
+		return errors.New("simulated push failure")
+	}
+	if err := s.Store.Push(ctx, expected, reader); err != nil {
+		if s.acceptExisting && errors.Is(err, errdef.ErrAlreadyExists) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *deletableStore) Delete(ctx context.Context, target ocispec.Descriptor) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.deleted = append(s.deleted, target)
+	return nil
+}
+
+// newTestSource creates a source with a manifest tagged as "latest", and
+// returns the source and the descriptors of the config, the layers and the
+// manifest.
+func newTestSource(t *testing.T) (*memory.Store, []ocispec.Descriptor) {
+	t.Helper()
+	ctx := context.Background()
+	src := memory.New()
+	var descs []ocispec.Descriptor
+	push := func(mediaType string, blob []byte) {
+		desc := content.NewDescriptorFromBytes(mediaType, blob)
+		if err := src.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatal(err)
+		}
+		descs = append(descs, desc)
+	}
+	push(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	push(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	push(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:3],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	push(ocispec.MediaTypeImageManifest, manifestJSON) // Blob 3
+	if err := src.Tag(ctx, descs[3], "latest"); err != nil {
+		t.Fatal(err)
+	}
+	return src, descs
+}
+
+func TestTransaction_Commit(t *testing.T) {
+	src, descs := newTestSource(t)
+	dst := &deletableStore{Store: memory.New()}
+	ctx := context.Background()
+
+	txn := New(dst)
+	root, err := oras.Copy(ctx, src, "latest", txn, "", oras.DefaultCopyOptions)
+	if err != nil {
+		t.Fatalf("oras.Copy() error = %v", err)
+	}
+	if !reflect.DeepEqual(root, descs[3]) {
+		t.Errorf("oras.Copy() = %v, want %v", root, descs[3])
+	}
+	if got := len(txn.Pushed()); got != len(descs) {
+		t.Errorf("Transaction.Pushed() = %v, want %d descriptors", txn.Pushed(), len(descs))
+	}
+
+	// tags are buffered until commit
+	if got, err := txn.Resolve(ctx, "latest"); err != nil || !reflect.DeepEqual(got, root) {
+		t.Errorf("Transaction.Resolve() = %v, %v, want %v, nil", got, err, root)
+	}
+	if _, err := dst.Resolve(ctx, "latest"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("dst.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+
+	if err := txn.Commit(ctx); err != nil {
+		t.Fatalf("Transaction.Commit() error = %v", err)
+	}
+	if got, err := dst.Resolve(ctx, "latest"); err != nil || !reflect.DeepEqual(got, root) {
+		t.Errorf("dst.Resolve() = %v, %v, want %v, nil", got, err, root)
+	}
+	if len(dst.deleted) != 0 {
+		t.Errorf("deleted = %v, want none", dst.deleted)
+	}
+
+	// the transaction is done
+	if err := txn.Commit(ctx); !errors.Is(err, ErrTransactionDone) {
+		t.Errorf("Transaction.Commit() error = %v, want %v", err, ErrTransactionDone)
+	}
+	if err := txn.Rollback(ctx); !errors.Is(err, ErrTransactionDone) {
+		t.Errorf("Transaction.Rollback() error = %v, want %v", err, ErrTransactionDone)
+	}
+	if err := txn.Push(ctx, descs[0], bytes.NewReader([]byte("config"))); !errors.Is(err, ErrTransactionDone) {
+		t.Errorf("Transaction.Push() error = %v, want %v", err, ErrTransactionDone)
+	}
+}
+
+func TestTransaction_Rollback(t *testing.T) {
+	src, descs := newTestSource(t)
+	dst := &deletableStore{
+		Store:         memory.New(),
+		failMediaType: ocispec.MediaTypeImageManifest,
+	}
+	ctx := context.Background()
+
+	// the config is already in the destination before the transaction
+	if err := dst.Store.Push(ctx, descs[0], bytes.NewReader([]byte("config"))); err != nil {
+		t.Fatal(err)
+	}
+
+	txn := New(dst)
+	opts := oras.DefaultCopyOptions
+	opts.Concurrency = 1
+	if _, err := oras.Copy(ctx, src, "latest", txn, "", opts); err == nil {
+		t.Fatal("oras.Copy() error = nil, wantErr true")
+	}
+
+	pushed := txn.Pushed()
+	if len(pushed) != 2 {
+		t.Fatalf("Transaction.Pushed() = %v, want the 2 layers", pushed)
+	}
+	if err := txn.Rollback(ctx); err != nil {
+		t.Fatalf("Transaction.Rollback() error = %v", err)
+	}
+	want := []ocispec.Descriptor{pushed[1], pushed[0]}
+	if !reflect.DeepEqual(dst.deleted, want) {
+		t.Errorf("deleted = %v, want %v", dst.deleted, want)
+	}
+	if _, err := dst.Resolve(ctx, "latest"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("dst.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	if err := txn.Tag(ctx, descs[1], "latest"); !errors.Is(err, ErrTransactionDone) {
+		t.Errorf("Transaction.Tag() error = %v, want %v", err, ErrTransactionDone)
+	}
+}
+
+func TestTransaction_Rollback_ExistingContent(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("foo")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+	newBlob := []byte("bar")
+	newDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, newBlob)
+
+	// the destination accepts re-pushing the existing content
+	dst := &deletableStore{
+		Store:          memory.New(),
+		acceptExisting: true,
+	}
+	if err := dst.Store.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatal(err)
+	}
+
+	txn := New(dst)
+	if err := txn.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Transaction.Push() error = %v", err)
+	}
+	if err := txn.Push(ctx, newDesc, bytes.NewReader(newBlob)); err != nil {
+		t.Fatalf("Transaction.Push() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{newDesc}; !reflect.DeepEqual(txn.Pushed(), want) {
+		t.Errorf("Transaction.Pushed() = %v, want %v", txn.Pushed(), want)
+	}
+	if err := txn.Rollback(ctx); err != nil {
+		t.Fatalf("Transaction.Rollback() error = %v", err)
+	}
+	if want := []ocispec.Descriptor{newDesc}; !reflect.DeepEqual(dst.deleted, want) {
+		t.Errorf("deleted = %v, want %v", dst.deleted, want)
+	}
+}
+
+func TestTransaction_Rollback_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("foo")
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+
+	txn := New(memory.New())
+	if err := txn.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Transaction.Push() error = %v", err)
+	}
+	if err := txn.Rollback(ctx); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Transaction.Rollback() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+}
+
+func TestTransaction_Tag_NotFound(t *testing.T) {
+	ctx := context.Background()
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, []byte("foo"))
+	txn := New(memory.New())
+	if err := txn.Tag(ctx, desc, "latest"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Transaction.Tag() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+}
@@ -26,6 +26,8 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -753,6 +755,85 @@ func TestResolve_Repository(t *testing.T) {
 	}
 }
 
+func TestResolvePlatform_Memory(t *testing.T) {
+	target := memory.New()
+	ctx := context.Background()
+
+	// generate an index of manifests for different platforms
+	platforms := []*ocispec.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64"},
+		{OS: "windows", Architecture: "amd64"},
+	}
+	host := oras.HostPlatform()
+	if host.OS != runtime.GOOS || host.Architecture != runtime.GOARCH {
+		t.Fatalf("HostPlatform() = %v, want %s/%s", host, runtime.GOOS, runtime.GOARCH)
+	}
+	if !slices.ContainsFunc(platforms, func(p *ocispec.Platform) bool {
+		return p.OS == host.OS && p.Architecture == host.Architecture
+	}) {
+		platforms = append(platforms, host)
+	}
+	var manifests []ocispec.Descriptor
+	for _, p := range platforms {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			Config:      ocispec.DescriptorEmptyJSON,
+			Annotations: map[string]string{"platform": p.OS + "/" + p.Architecture},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc, err := oras.PushBytes(ctx, target, ocispec.MediaTypeImageManifest, manifestJSON)
+		if err != nil {
+			t.Fatal("oras.PushBytes() error =", err)
+		}
+		desc.Platform = p
+		manifests = append(manifests, desc)
+	}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := "foobar"
+	if _, err := oras.TagBytes(ctx, target, ocispec.MediaTypeImageIndex, indexJSON, ref); err != nil {
+		t.Fatal("oras.TagBytes() error =", err)
+	}
+
+	// test resolving with the host platform by default
+	want := manifests[slices.IndexFunc(platforms, func(p *ocispec.Platform) bool {
+		return p.OS == host.OS && p.Architecture == host.Architecture
+	})]
+	got, err := oras.ResolvePlatform(ctx, target, ref, oras.DefaultResolveOptions)
+	if err != nil {
+		t.Fatal("oras.ResolvePlatform() error =", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("oras.ResolvePlatform() = %v, want %v", got, want)
+	}
+
+	// test resolving with an overridden platform
+	opts := oras.ResolveOptions{
+		TargetPlatform: &ocispec.Platform{OS: "linux", Architecture: "arm64"},
+	}
+	got, err = oras.ResolvePlatform(ctx, target, ref, opts)
+	if err != nil {
+		t.Fatal("oras.ResolvePlatform() error =", err)
+	}
+	if !reflect.DeepEqual(got, manifests[1]) {
+		t.Errorf("oras.ResolvePlatform() = %v, want %v", got, manifests[1])
+	}
+
+	// test resolving with an unknown platform
+	opts.TargetPlatform = &ocispec.Platform{OS: "linux", Architecture: "s390x"}
+	_, err = oras.ResolvePlatform(ctx, target, ref, opts)
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("oras.ResolvePlatform() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
+
 func TestFetch_Memory(t *testing.T) {
 	target := memory.New()
 	arc_1 := "test-arc-1"
@@ -16,22 +16,35 @@ limitations under the License.
 package oras
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/semaphore"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/cas"
+	"oras.land/oras-go/v2/internal/container/set"
+	"oras.land/oras-go/v2/internal/copyutil"
 	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/docker"
 	"oras.land/oras-go/v2/internal/platform"
 	"oras.land/oras-go/v2/internal/registryutil"
 	"oras.land/oras-go/v2/internal/status"
 	"oras.land/oras-go/v2/internal/syncutil"
 	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/trace"
 )
 
 // defaultConcurrency is the default value of CopyGraphOptions.Concurrency.
@@ -46,6 +59,39 @@ var DefaultCopyOptions CopyOptions = CopyOptions{
 	CopyGraphOptions: DefaultCopyGraphOptions,
 }
 
+// TagConflictPolicy specifies how [oras.Copy] handles a destination reference
+// that already exists in the destination.
+type TagConflictPolicy int
+
+const (
+	// TagConflictOverwrite copies the root node and overwrites the
+	// destination reference regardless of what it points to.
+	TagConflictOverwrite TagConflictPolicy = iota
+	// TagConflictSkipIfSame skips the copy if the destination reference
+	// already points to the root node, and overwrites it otherwise.
+	TagConflictSkipIfSame
+	// TagConflictFailOnDifferent skips the copy if the destination reference
+	// already points to the root node, and fails with an error wrapping
+	// errdef.ErrAlreadyExists if it points to a different node.
+	TagConflictFailOnDifferent
+)
+
+// CopySchedulingPolicy specifies the order in which [oras.CopyGraph] schedules
+// the successors of a node for copy.
+type CopySchedulingPolicy int
+
+const (
+	// CopyScheduleAsDiscovered schedules the successors in the order listed
+	// by CopyGraphOptions.FindSuccessors.
+	CopyScheduleAsDiscovered CopySchedulingPolicy = iota
+	// CopyScheduleLargestFirst schedules the successors in the descending
+	// order of their sizes.
+	CopyScheduleLargestFirst
+	// CopyScheduleSmallestFirst schedules the successors in the ascending
+	// order of their sizes.
+	CopyScheduleSmallestFirst
+)
+
 // CopyOptions contains parameters for [oras.Copy].
 type CopyOptions struct {
 	CopyGraphOptions
@@ -54,6 +100,42 @@ type CopyOptions struct {
 	// reference will be passed to MapRoot, and the mapped descriptor will be
 	// used as the root node for copy.
 	MapRoot func(ctx context.Context, src content.ReadOnlyStorage, root ocispec.Descriptor) (ocispec.Descriptor, error)
+	// Resolve resolves the source reference to the root node for copy, which
+	// can be used to apply policies or redirections on resolution. MapRoot,
+	// if provided, is applied on the resolved root node.
+	// If Resolve is nil, the source reference is resolved from the source.
+	Resolve func(ctx context.Context, ref string) (ocispec.Descriptor, error)
+	// TagConflictPolicy specifies how to handle the destination reference if
+	// it can be resolved in the destination before copy.
+	// When the copy is skipped, the graph rooted by the node pointed by the
+	// destination reference is assumed to be complete in the destination.
+	// Default value: TagConflictOverwrite.
+	TagConflictPolicy TagConflictPolicy
+	// MapManifest maps the content of each manifest in the graph before it is
+	// pushed to the destination, which can be used to rewrite or strip
+	// annotations during copy.
+	// If the mapped content differs from the original content, the manifest
+	// is pushed under the digest of the mapped content, and the reference of
+	// every index or referrer pointing to it is rewritten to the new digest.
+	// As a result, the digests of all the manifests on the path from the
+	// mapped manifest to the root node change as well, and the root node is
+	// tagged and returned with its new digest.
+	// As the digest of the root node is only known after copy, the tag
+	// conflict is checked against the mapped root node after copy, and copy
+	// is not skipped by TagConflictPolicy.
+	// Manifests existing in the destination under their source digests are
+	// not copied, and therefore not mapped.
+	// To preserve unknown fields, such as vendor extensions, manifestJSON
+	// should be modified on a generic representation like
+	// map[string]json.RawMessage.
+	// If MapManifest is nil, manifests are copied as is.
+	MapManifest func(ctx context.Context, desc ocispec.Descriptor, manifestJSON []byte) ([]byte, error)
+	// CheckPush checks if the destination accepts pushes before any content
+	// is copied, so that copy fails fast on a lack of permission instead of
+	// after transferring content. The check is performed only if the
+	// destination implements registry.PushChecker, as remote repositories do.
+	// Default value: false.
+	CheckPush bool
 }
 
 // WithTargetPlatform configures opts.MapRoot to select the manifest whose
@@ -84,6 +166,16 @@ func (opts *CopyOptions) WithTargetPlatform(p *ocispec.Platform) {
 // CopyGraphOptions.MaxMetadataBytes.
 const defaultCopyMaxMetadataBytes int64 = 4 * 1024 * 1024 // 4 MiB
 
+// defaultProgressInterval is the default value of
+// CopyGraphOptions.ProgressInterval.
+const defaultProgressInterval = 100 * time.Millisecond
+
+// defaultRetryBackoff is the default value of CopyGraphOptions.RetryBackoff.
+const defaultRetryBackoff = time.Second
+
+// defaultMaxNodes is the default value of CopyGraphOptions.MaxNodes.
+const defaultMaxNodes = 1 << 20
+
 // DefaultCopyGraphOptions provides the default CopyGraphOptions.
 var DefaultCopyGraphOptions CopyGraphOptions
 
@@ -92,6 +184,17 @@ type CopyGraphOptions struct {
 	// Concurrency limits the maximum number of concurrent copy tasks.
 	// If less than or equal to 0, a default (currently 3) is used.
 	Concurrency int
+	// NodeConcurrency returns the maximum number of nodes copied concurrently
+	// along with desc, which can be used to give large blobs fewer parallel
+	// transfers than small blobs. The nodes for which the same limit is
+	// returned share the limit, while the total number of concurrent copy
+	// tasks is still bounded by Concurrency. For instance, returning 1 for
+	// blobs larger than 1 GiB and 16 otherwise, along with a Concurrency of
+	// 17, allows one large blob to be copied alongside 16 small ones.
+	// The nodes waiting for their limits do not count towards Concurrency.
+	// If NodeConcurrency is nil or returns a value less than or equal to 0
+	// for desc, only Concurrency applies to desc.
+	NodeConcurrency func(desc ocispec.Descriptor) int
 	// MaxMetadataBytes limits the maximum size of the metadata that can be
 	// cached in the memory.
 	// If less than or equal to 0, a default (currently 4 MiB) is used.
@@ -111,6 +214,16 @@ type CopyGraphOptions struct {
 	MountFrom func(ctx context.Context, desc ocispec.Descriptor) ([]string, error)
 	// OnMounted will be invoked when desc is mounted.
 	OnMounted func(ctx context.Context, desc ocispec.Descriptor) error
+	// TryMount enables mounting blobs from the source repository when the
+	// source and the destination implement [registry.Locator] with the same
+	// registry host but different repositories, and the destination
+	// implements [registry.Mounter], as the repositories of the
+	// registry/remote package do. The source repository is tried after the
+	// candidates returned by MountFrom, if any. If the registry does not
+	// support cross-repository mounting, the blobs are copied as usual.
+	// Wrappers of the source or the destination need to forward Locate, and
+	// Mount for the destination, for TryMount to take effect.
+	TryMount bool
 	// FindSuccessors finds the successors of the current node.
 	// fetcher provides cached access to the source storage, and is suitable
 	// for fetching non-leaf nodes like manifests. Since anything fetched from
@@ -118,6 +231,182 @@ type CopyGraphOptions struct {
 	// source storage to fetch large blobs.
 	// If FindSuccessors is nil, content.Successors will be used.
 	FindSuccessors func(ctx context.Context, fetcher content.Fetcher, desc ocispec.Descriptor) ([]ocispec.Descriptor, error)
+	// OnProgress reports the number of bytes of the current descriptor
+	// copied so far, out of a total of desc.Size bytes, as the content is
+	// read from the source. The reports for a descriptor are throttled by
+	// ProgressInterval.
+	// A final report is always made once for every node copied, mounted, or
+	// skipped as existing in the destination, including the empty ones,
+	// before PostCopy, OnMounted, or OnCopySkipped is invoked respectively.
+	// The nodes mounted or skipped are reported as completed in full.
+	// OnProgress is not invoked in DryRun mode.
+	// OnProgress may be invoked concurrently for different descriptors, but
+	// is never invoked concurrently for the same descriptor.
+	OnProgress func(desc ocispec.Descriptor, copied, total int64)
+	// ProgressInterval is the minimum interval between two consecutive
+	// OnProgress reports of a descriptor.
+	// If less than or equal to 0, a default (currently 100 milliseconds) is
+	// used.
+	ProgressInterval time.Duration
+	// Tracer traces the copy of each node, if set. A span is started for
+	// each node copied, excluding the nodes skipped.
+	// See also: package oras.land/oras-go/v2/trace
+	Tracer trace.Tracer
+	// NodeTimeout bounds the transfer of each node, which includes
+	// the hooks invoked for the node such as PreCopy and PostCopy. If the
+	// transfer of a node exceeds NodeTimeout, the copy fails with an error
+	// wrapping context.DeadlineExceeded, independently of the deadline of the
+	// context of the whole copy.
+	// If less than or equal to 0, the transfer of a node is only bounded by
+	// the context of the whole copy.
+	NodeTimeout time.Duration
+	// ContinueOnError continues copying the rest of the graph when a node
+	// fails to be copied, instead of failing fast. The predecessors of a
+	// failed node are not copied. If any node eventually fails, the copy
+	// returns a *CopyGraphError reporting the failed nodes.
+	// Errors caused by the cancellation of the context of the whole copy
+	// are returned immediately.
+	ContinueOnError bool
+	// RetryAttempts is the maximum number of retry passes over the failed
+	// nodes after the initial traversal. Nodes copied in previous passes are
+	// not copied again.
+	// RetryAttempts is only effective if ContinueOnError is set.
+	RetryAttempts int
+	// RetryBackoff is the delay before the first retry pass, which is then
+	// doubled for every subsequent pass.
+	// If less than or equal to 0, a default (currently 1 second) is used.
+	RetryBackoff time.Duration
+	// DestinationCache remembers the content known to be present in the
+	// destination, which can be shared across multiple copies to the same
+	// destination. Nodes found in the cache are skipped without checking
+	// their existence in the destination, and nodes found existing or copied
+	// are added to the cache.
+	// If nil, the existence of every node is checked in the destination.
+	// See also: [DestinationCache]
+	DestinationCache *DestinationCache
+	// DryRun walks the graph without writing anything to the destination,
+	// which can be used to plan a copy. The existence of the nodes is still
+	// checked in the destination, and the hooks are invoked as usual, where
+	// PostCopy is invoked for every node that would be copied and
+	// OnCopySkipped for every node that would be skipped. No content is
+	// pushed, mounted, or tagged, and only the non-leaf nodes are fetched
+	// from the source to find their successors.
+	DryRun bool
+	// MaxNodes limits the maximum number of distinct nodes visited in the
+	// graph, which guards against maliciously large graphs exhausting the
+	// resources. The nodes skipped along with their existing rooted sub-DAGs
+	// are not visited. If exceeded, the copy is aborted with an error
+	// wrapping errdef.ErrSizeExceedsLimit.
+	// If less than or equal to 0, a default (currently 1048576) is used.
+	MaxNodes int
+	// SchedulingPolicy specifies the order in which the successors of each
+	// node are scheduled for copy, e.g. the largest blobs first to keep the
+	// concurrent transfers busy, or the smallest blobs first for a faster
+	// perceived progress. Since a node is always copied after its successors,
+	// only the successors of the same node, which are independent of each
+	// other, are reordered. The successors of equal sizes keep their order.
+	// Default value: CopyScheduleAsDiscovered.
+	SchedulingPolicy CopySchedulingPolicy
+}
+
+// DestinationCache remembers the digests of the content known to be present
+// in a copy destination, so that copies sharing the cache do not check the
+// existence of the same content repeatedly.
+// A DestinationCache should only be shared by copies to the same destination.
+// Since the destination is not consulted for cached content, the cache goes
+// stale if the content is deleted from the destination, for instance, by
+// garbage collection or retention policies, in which case the copies may
+// skip missing content and produce incomplete graphs. Long-living caches
+// should be invalidated or recreated accordingly.
+// DestinationCache is safe for concurrent use.
+type DestinationCache struct {
+	lock    sync.RWMutex
+	entries set.Set[digest.Digest]
+}
+
+// NewDestinationCache creates an empty DestinationCache.
+func NewDestinationCache() *DestinationCache {
+	return &DestinationCache{
+		entries: set.New[digest.Digest](),
+	}
+}
+
+// Contains returns true if the content described by desc is known to be
+// present in the destination.
+func (dc *DestinationCache) Contains(desc ocispec.Descriptor) bool {
+	if dc == nil {
+		return false
+	}
+	dc.lock.RLock()
+	defer dc.lock.RUnlock()
+	return dc.entries.Contains(desc.Digest)
+}
+
+// Add remembers that the content described by desc is present in the
+// destination.
+func (dc *DestinationCache) Add(desc ocispec.Descriptor) {
+	if dc == nil {
+		return
+	}
+	dc.lock.Lock()
+	defer dc.lock.Unlock()
+	dc.entries.Add(desc.Digest)
+}
+
+// Invalidate forgets that the content described by desc is present in the
+// destination.
+func (dc *DestinationCache) Invalidate(desc ocispec.Descriptor) {
+	if dc == nil {
+		return
+	}
+	dc.lock.Lock()
+	defer dc.lock.Unlock()
+	delete(dc.entries, desc.Digest)
+}
+
+// CopyNodeError records a node failed to be copied and the error.
+type CopyNodeError struct {
+	// Node is the descriptor of the node failed to be copied.
+	Node ocispec.Descriptor
+	// Err is the error of the last attempt to copy the node.
+	Err error
+}
+
+// Error returns the error message of the failed node.
+func (e *CopyNodeError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", e.Node.Digest, e.Node.MediaType, e.Err)
+}
+
+// Unwrap returns the inner error of CopyNodeError.
+func (e *CopyNodeError) Unwrap() error {
+	return e.Err
+}
+
+// CopyGraphError is returned by [oras.CopyGraph] and [oras.Copy] when some
+// nodes fail to be copied with CopyGraphOptions.ContinueOnError set.
+type CopyGraphError struct {
+	// Failures lists the nodes failed in the last pass, sorted by digest.
+	// Nodes not copied because of the failures of their successors are not
+	// listed.
+	Failures []*CopyNodeError
+}
+
+// Error returns the error message of CopyGraphError.
+func (e *CopyGraphError) Error() string {
+	msgs := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		msgs = append(msgs, f.Error())
+	}
+	return fmt.Sprintf("failed to copy %d node(s): %s", len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the errors of the failed nodes.
+func (e *CopyGraphError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		errs = append(errs, f)
+	}
+	return errs
 }
 
 // Copy copies a rooted directed acyclic graph (DAG), such as an artifact,
@@ -145,7 +434,13 @@ func Copy(ctx context.Context, src ReadOnlyTarget, srcRef string, dst Target, ds
 		opts.MaxMetadataBytes = defaultCopyMaxMetadataBytes
 	}
 	proxy := cas.NewProxyWithLimit(src, cas.NewMemory(), opts.MaxMetadataBytes)
-	root, err := resolveRoot(ctx, src, srcRef, proxy)
+	var root ocispec.Descriptor
+	var err error
+	if opts.Resolve != nil {
+		root, err = opts.Resolve(ctx, srcRef)
+	} else {
+		root, err = resolveRoot(ctx, src, srcRef, proxy)
+	}
 	if err != nil {
 		return ocispec.Descriptor{}, fmt.Errorf("failed to resolve %s: %w", srcRef, err)
 	}
@@ -159,9 +454,31 @@ func Copy(ctx context.Context, src ReadOnlyTarget, srcRef string, dst Target, ds
 		proxy.StopCaching = false
 	}
 
-	if err := prepareCopy(ctx, dst, dstRef, proxy, root, &opts); err != nil {
+	if opts.CheckPush {
+		if checker, ok := dst.(registry.PushChecker); ok {
+			if err := checker.CheckPush(ctx); err != nil {
+				return ocispec.Descriptor{}, fmt.Errorf("failed to check push to the destination: %w", err)
+			}
+		}
+	}
+
+	if opts.MapManifest != nil {
+		return copyWithMapManifest(ctx, src, dst, dstRef, proxy, root, opts)
+	}
+
+	skip, err := checkTagConflict(ctx, dst, dstRef, root, opts.TagConflictPolicy)
+	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
+	if skip {
+		return root, nil
+	}
+
+	if !opts.DryRun {
+		if err := prepareCopy(ctx, dst, dstRef, proxy, root, &opts); err != nil {
+			return ocispec.Descriptor{}, err
+		}
+	}
 
 	if err := copyGraph(ctx, src, dst, root, proxy, nil, nil, opts.CopyGraphOptions); err != nil {
 		return ocispec.Descriptor{}, err
@@ -170,9 +487,256 @@ func Copy(ctx context.Context, src ReadOnlyTarget, srcRef string, dst Target, ds
 	return root, nil
 }
 
+// copyWithMapManifest copies the graph rooted by root with the manifests
+// mapped by opts.MapManifest, and tags the mapped root node with dstRef.
+func copyWithMapManifest(ctx context.Context, src ReadOnlyTarget, dst Target, dstRef string, proxy *cas.Proxy, root ocispec.Descriptor, opts CopyOptions) (ocispec.Descriptor, error) {
+	transformer := newTransformStorage(dst, opts.MapManifest)
+	var graphDst content.Storage = transformer
+	if mounter, ok := dst.(registry.Mounter); ok {
+		graphDst = &transformMounter{
+			transformStorage: transformer,
+			Mounter:          mounter,
+		}
+	}
+	if err := copyGraph(ctx, src, graphDst, root, proxy, nil, nil, opts.CopyGraphOptions); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if opts.DryRun {
+		return root, nil
+	}
+
+	root = transformer.mapped(root)
+	skip, err := checkTagConflict(ctx, dst, dstRef, root, opts.TagConflictPolicy)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if skip {
+		return root, nil
+	}
+	if err := dst.Tag(ctx, root, dstRef); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	return root, nil
+}
+
+// checkTagConflict checks the node pointed by dstRef in dst against root
+// according to policy, and returns true if the copy should be skipped.
+func checkTagConflict(ctx context.Context, dst Target, dstRef string, root ocispec.Descriptor, policy TagConflictPolicy) (bool, error) {
+	switch policy {
+	case TagConflictOverwrite:
+		return false, nil
+	case TagConflictSkipIfSame, TagConflictFailOnDifferent:
+	default:
+		return false, fmt.Errorf("tag conflict policy %d: %w", policy, errdef.ErrUnsupported)
+	}
+
+	existing, err := dst.Resolve(ctx, dstRef)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			// no conflict
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to resolve %s in the destination: %w", dstRef, err)
+	}
+	if existing.Digest == root.Digest {
+		return true, nil
+	}
+	if policy == TagConflictFailOnDifferent {
+		return false, fmt.Errorf("%s: points to %s in the destination, want %s: %w", dstRef, existing.Digest, root.Digest, errdef.ErrAlreadyExists)
+	}
+	return false, nil
+}
+
+// CopyPinned copies a rooted directed acyclic graph (DAG) whose root node is
+// pinned by the digest from the source Target to the destination Target.
+//
+// The root node is fetched from the source by the pinned digest, and must
+// remain the same after opts.MapRoot is applied. Therefore, the source must
+// support resolving digest references, as remote repositories and OCI layouts
+// do. After copy, the destination
+// reference is resolved from the destination to verify that it identifies the
+// pinned digest.
+// The destination reference will be the pinned digest if it is empty.
+//
+// Returns the descriptor of the root node on successful copy, or an error
+// wrapping content.ErrMismatchedDigest if the copied root node does not match
+// the pinned digest.
+func CopyPinned(ctx context.Context, src ReadOnlyTarget, pinned digest.Digest, dst Target, dstRef string, opts CopyOptions) (ocispec.Descriptor, error) {
+	if err := pinned.Validate(); err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("invalid pinned digest %q: %w", pinned, err)
+	}
+	mapRoot := opts.MapRoot
+	opts.MapRoot = func(ctx context.Context, src content.ReadOnlyStorage, root ocispec.Descriptor) (ocispec.Descriptor, error) {
+		if mapRoot != nil {
+			var err error
+			if root, err = mapRoot(ctx, src, root); err != nil {
+				return ocispec.Descriptor{}, err
+			}
+		}
+		if root.Digest != pinned {
+			return ocispec.Descriptor{}, fmt.Errorf("source root %s does not match pinned digest %s: %w", root.Digest, pinned, content.ErrMismatchedDigest)
+		}
+		return root, nil
+	}
+
+	srcRef := pinned.String()
+	if dstRef == "" {
+		dstRef = srcRef
+	}
+	root, err := Copy(ctx, src, srcRef, dst, dstRef, opts)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if opts.DryRun {
+		return root, nil
+	}
+
+	desc, err := dst.Resolve(ctx, dstRef)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to resolve %s from destination: %w", dstRef, err)
+	}
+	if desc.Digest != pinned {
+		return ocispec.Descriptor{}, fmt.Errorf("destination root %s does not match pinned digest %s: %w", desc.Digest, pinned, content.ErrMismatchedDigest)
+	}
+	return root, nil
+}
+
+// CopyIndexSubset copies an index identified by the source reference from the
+// source Target to the destination Target, keeping only the child manifests
+// whose digests are listed in manifests.
+//
+// A reduced index is synthesized from the source index by removing the
+// unlisted child manifests, while the other fields such as annotations and
+// subject are preserved. As the content is changed, the reduced index has a
+// digest different from the source index. Only the sub-DAGs rooted by the
+// listed child manifests are copied.
+// When opts.MapRoot is provided, the subset selection is applied on the
+// mapped root node.
+//
+// Returns the descriptor of the reduced index on successful copy.
+func CopyIndexSubset(ctx context.Context, src ReadOnlyTarget, srcRef string, dst Target, dstRef string, manifests []digest.Digest, opts CopyOptions) (ocispec.Descriptor, error) {
+	if src == nil {
+		return ocispec.Descriptor{}, errors.New("nil source target")
+	}
+	maxMetadataBytes := opts.MaxMetadataBytes
+	if maxMetadataBytes <= 0 {
+		maxMetadataBytes = defaultCopyMaxMetadataBytes
+	}
+
+	subsetSrc := &synthesizedTarget{ReadOnlyTarget: src}
+	mapRoot := opts.MapRoot
+	opts.MapRoot = func(ctx context.Context, src content.ReadOnlyStorage, root ocispec.Descriptor) (desc ocispec.Descriptor, err error) {
+		if mapRoot != nil {
+			if root, err = mapRoot(ctx, src, root); err != nil {
+				return ocispec.Descriptor{}, err
+			}
+		}
+		desc, indexJSON, err := reduceIndex(ctx, src, root, manifests, maxMetadataBytes)
+		if err != nil {
+			return ocispec.Descriptor{}, err
+		}
+		subsetSrc.node = desc
+		subsetSrc.content = indexJSON
+		return desc, nil
+	}
+	return Copy(ctx, subsetSrc, srcRef, dst, dstRef, opts)
+}
+
+// reduceIndex generates an index from the index described by root, keeping
+// only the child manifests listed in manifests.
+func reduceIndex(ctx context.Context, src content.ReadOnlyStorage, root ocispec.Descriptor, manifests []digest.Digest, maxMetadataBytes int64) (ocispec.Descriptor, []byte, error) {
+	switch root.MediaType {
+	case ocispec.MediaTypeImageIndex, docker.MediaTypeManifestList:
+	default:
+		return ocispec.Descriptor{}, nil, fmt.Errorf("%s: %s: expecting an index: %w", root.Digest, root.MediaType, errdef.ErrUnsupported)
+	}
+	if root.Size > maxMetadataBytes {
+		return ocispec.Descriptor{}, nil, fmt.Errorf(
+			"content size %v exceeds MaxMetadataBytes %v: %w",
+			root.Size,
+			maxMetadataBytes,
+			errdef.ErrSizeExceedsLimit)
+	}
+	indexJSON, err := content.FetchAll(ctx, src, root)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+
+	// decode the fields generically so that all the other fields are preserved
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(indexJSON, &fields); err != nil {
+		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to decode index %s: %w", root.Digest, err)
+	}
+	var children []ocispec.Descriptor
+	if raw, ok := fields["manifests"]; ok {
+		if err := json.Unmarshal(raw, &children); err != nil {
+			return ocispec.Descriptor{}, nil, fmt.Errorf("failed to decode manifests of index %s: %w", root.Digest, err)
+		}
+	}
+
+	wanted := set.New[digest.Digest]()
+	for _, dgst := range manifests {
+		wanted.Add(dgst)
+	}
+	kept := make([]ocispec.Descriptor, 0, len(manifests))
+	found := set.New[digest.Digest]()
+	for _, child := range children {
+		if wanted.Contains(child.Digest) {
+			kept = append(kept, child)
+			found.Add(child.Digest)
+		}
+	}
+	for _, dgst := range manifests {
+		if !found.Contains(dgst) {
+			return ocispec.Descriptor{}, nil, fmt.Errorf("%s: manifest not found in index %s: %w", dgst, root.Digest, errdef.ErrNotFound)
+		}
+	}
+
+	if fields["manifests"], err = marshalJSON(kept); err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	if indexJSON, err = marshalJSON(fields); err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	desc := content.NewDescriptorFromBytes(root.MediaType, indexJSON)
+	desc.ArtifactType = root.ArtifactType
+	return desc, indexJSON, nil
+}
+
+// synthesizedTarget serves a synthesized node on top of a ReadOnlyTarget.
+type synthesizedTarget struct {
+	ReadOnlyTarget
+	node    ocispec.Descriptor
+	content []byte
+}
+
+// Fetch fetches the content identified by the descriptor.
+func (t *synthesizedTarget) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	if t.content != nil && content.Equal(target, t.node) {
+		return io.NopCloser(bytes.NewReader(t.content)), nil
+	}
+	return t.ReadOnlyTarget.Fetch(ctx, target)
+}
+
+// Locate returns the location of the underlying target, if known.
+func (t *synthesizedTarget) Locate() (string, string) {
+	return locate(t.ReadOnlyTarget)
+}
+
+// Exists returns true if the described content exists.
+func (t *synthesizedTarget) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	if t.content != nil && content.Equal(target, t.node) {
+		return true, nil
+	}
+	return t.ReadOnlyTarget.Exists(ctx, target)
+}
+
 // CopyGraph copies a rooted directed acyclic graph (DAG), such as an artifact,
 // from the source CAS to the destination CAS.
 // The root node (e.g. a manifest of the artifact) is identified by a descriptor.
+//
+// If dst implements content.DigestAlgorithmLimiter, the digest algorithms of
+// all the nodes in the graph are checked before any content is transferred.
 func CopyGraph(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, root ocispec.Descriptor, opts CopyGraphOptions) error {
 	return copyGraph(ctx, src, dst, root, nil, nil, nil, opts)
 }
@@ -203,6 +767,37 @@ func copyGraph(ctx context.Context, src content.ReadOnlyStorage, dst content.Sto
 	if opts.FindSuccessors == nil {
 		opts.FindSuccessors = content.Successors
 	}
+	if opts.TryMount {
+		if fromRepo, ok := sameRegistrySource(src, dst); ok {
+			opts.MountFrom = appendMountSource(opts.MountFrom, fromRepo)
+		}
+	}
+	// limit the number of nodes visited
+	if opts.MaxNodes <= 0 {
+		opts.MaxNodes = defaultMaxNodes
+	}
+	var visited atomic.Int64
+
+	// fail fast if the destination cannot accept the digests of the graph
+	if algLimiter, ok := dst.(content.DigestAlgorithmLimiter); ok {
+		if allowed := algLimiter.AllowedDigestAlgorithms(); len(allowed) > 0 {
+			if err := checkDigestAlgorithms(ctx, proxy, root, allowed, opts); err != nil {
+				return err
+			}
+		}
+	}
+
+	// limit the concurrency per node, if requested
+	var nodeLimiters *nodeLimiters
+	if opts.NodeConcurrency != nil {
+		nodeLimiters = newNodeLimiters(opts.NodeConcurrency)
+	}
+
+	// record failures instead of failing fast, if requested
+	var failures *copyFailures
+	if opts.ContinueOnError {
+		failures = newCopyFailures()
+	}
 
 	// traverse the graph
 	var fn syncutil.GoFunc[ocispec.Descriptor]
@@ -212,19 +807,40 @@ func copyGraph(ctx context.Context, src content.ReadOnlyStorage, dst content.Sto
 		if !committed {
 			return nil
 		}
+		if visited.Add(1) > int64(opts.MaxNodes) {
+			return fmt.Errorf("%s: %s: number of nodes in the graph exceeds the limit %d: %w",
+				desc.Digest, desc.MediaType, opts.MaxNodes, errdef.ErrSizeExceedsLimit)
+		}
 		defer func() {
 			if err == nil {
 				// mark the content as done on success
 				close(done)
 			}
 		}()
+		if failures != nil {
+			// skip the descriptor if it is done in previous passes
+			if failures.isDone(desc) {
+				return nil
+			}
+			defer func() {
+				err = failures.record(ctx, desc, err)
+			}()
+		}
 
 		// skip if a rooted sub-DAG exists
-		exists, err := dst.Exists(ctx, desc)
-		if err != nil {
-			return err
+		exists := opts.DestinationCache.Contains(desc)
+		if !exists {
+			if exists, err = dst.Exists(ctx, desc); err != nil {
+				return err
+			}
+			if exists {
+				opts.DestinationCache.Add(desc)
+			}
 		}
 		if exists {
+			if !opts.DryRun {
+				reportCompleted(desc, opts)
+			}
 			if opts.OnCopySkipped != nil {
 				if err := opts.OnCopySkipped(ctx, desc); err != nil {
 					return err
@@ -239,6 +855,7 @@ func copyGraph(ctx context.Context, src content.ReadOnlyStorage, dst content.Sto
 			return err
 		}
 		successors = removeForeignLayers(successors)
+		successors = scheduleSuccessors(successors, opts.SchedulingPolicy)
 
 		if len(successors) != 0 {
 			// for non-leaf nodes, process successors and wait for them to complete
@@ -257,114 +874,389 @@ func copyGraph(ctx context.Context, src content.ReadOnlyStorage, dst content.Sto
 					return ctx.Err()
 				}
 			}
+			if failures != nil && failures.anyFailed(successors) {
+				return errSuccessorFailed
+			}
 			if err := region.Start(); err != nil {
 				return err
 			}
 		}
 
-		exists, err = proxy.Cache.Exists(ctx, desc)
+		if opts.DryRun {
+			return dryRunNode(ctx, desc, opts)
+		}
+		release, err := nodeLimiters.acquire(ctx, region, desc)
 		if err != nil {
 			return err
 		}
-		if exists {
-			return copyNode(ctx, proxy.Cache, dst, desc, opts)
+		defer release()
+		if err := copyNodeWithTimeout(ctx, desc, opts, func(ctx context.Context) error {
+			return traceCopyNode(ctx, desc, opts, func(ctx context.Context) error {
+				exists, err := proxy.Cache.Exists(ctx, desc)
+				if err != nil {
+					return err
+				}
+				if exists {
+					return copyNode(ctx, proxy.Cache, dst, desc, opts)
+				}
+				return mountOrCopyNode(ctx, src, dst, desc, opts)
+			})
+		}); err != nil {
+			return err
+		}
+		opts.DestinationCache.Add(desc)
+		return nil
+	}
+
+	if err := syncutil.Go(ctx, limiter, fn, root); err != nil || failures == nil {
+		return err
+	}
+
+	// retry the failed nodes
+	backoff := opts.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	for attempt := 0; failures.failed(); attempt++ {
+		if attempt >= opts.RetryAttempts {
+			return failures.err()
+		}
+		timer := time.NewTimer(backoff << attempt)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		failures.reset()
+		tracker = status.NewTracker()
+		visited.Store(0)
+		if err := syncutil.Go(ctx, limiter, fn, root); err != nil {
+			return err
 		}
-		return mountOrCopyNode(ctx, src, dst, desc, opts)
 	}
+	return nil
+}
 
-	return syncutil.Go(ctx, limiter, fn, root)
+// nodeLimiters limits the number of concurrent node copies by the limits
+// returned by CopyGraphOptions.NodeConcurrency, where the nodes of the same
+// limit share a limiter.
+type nodeLimiters struct {
+	concurrency func(desc ocispec.Descriptor) int
+	lock        sync.Mutex
+	limiters    map[int]*semaphore.Weighted
 }
 
-// mountOrCopyNode tries to mount the node, if not falls back to copying.
-func mountOrCopyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, desc ocispec.Descriptor, opts CopyGraphOptions) error {
-	// Need MountFrom and it must be a blob
-	if opts.MountFrom == nil || descriptor.IsManifest(desc) {
-		return copyNode(ctx, src, dst, desc, opts)
+// newNodeLimiters creates a new nodeLimiters with the given concurrency
+// function.
+func newNodeLimiters(concurrency func(desc ocispec.Descriptor) int) *nodeLimiters {
+	return &nodeLimiters{
+		concurrency: concurrency,
+		limiters:    make(map[int]*semaphore.Weighted),
+	}
+}
+
+// acquire acquires a slot for copying desc, and returns a function releasing
+// the slot. No slot is acquired if nl is nil or desc is not limited.
+// The region is ended while waiting for the slot, so that the nodes waiting
+// for their limits do not hold the global concurrency, and is restarted once
+// the slot is acquired.
+func (nl *nodeLimiters) acquire(ctx context.Context, region *syncutil.LimitedRegion, desc ocispec.Descriptor) (func(), error) {
+	if nl == nil {
+		return func() {}, nil
+	}
+	n := nl.concurrency(desc)
+	if n <= 0 {
+		return func() {}, nil
 	}
 
-	mounter, ok := dst.(registry.Mounter)
+	nl.lock.Lock()
+	limiter, ok := nl.limiters[n]
 	if !ok {
-		// mounting is not supported by the destination
-		return copyNode(ctx, src, dst, desc, opts)
+		limiter = semaphore.NewWeighted(int64(n))
+		nl.limiters[n] = limiter
 	}
+	nl.lock.Unlock()
 
-	sourceRepositories, err := opts.MountFrom(ctx, desc)
-	if err != nil {
-		// Technically this error is not fatal, we can still attempt to copy the node
-		// But for consistency with the other callbacks we bail out.
-		return err
+	region.End()
+	if err := limiter.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	if err := region.Start(); err != nil {
+		limiter.Release(1)
+		return nil, err
+	}
+	return func() { limiter.Release(1) }, nil
+}
+
+// errSuccessorFailed signals that a node is not copied since some of its
+// successors failed to be copied.
+var errSuccessorFailed = errors.New("successor failed")
+
+// copyFailures records the status of the nodes across the passes of a copy
+// with CopyGraphOptions.ContinueOnError.
+type copyFailures struct {
+	lock     sync.Mutex
+	done     set.Set[descriptor.Descriptor]
+	failures map[descriptor.Descriptor]*CopyNodeError
+	blocked  set.Set[descriptor.Descriptor]
+}
+
+// newCopyFailures creates a new copyFailures.
+func newCopyFailures() *copyFailures {
+	return &copyFailures{
+		done:     set.New[descriptor.Descriptor](),
+		failures: make(map[descriptor.Descriptor]*CopyNodeError),
+		blocked:  set.New[descriptor.Descriptor](),
 	}
+}
 
-	if len(sourceRepositories) == 0 {
-		return copyNode(ctx, src, dst, desc, opts)
+// record records the result of copying desc. record returns the error of the
+// context if the context is done, and nil otherwise.
+func (f *copyFailures) record(ctx context.Context, desc ocispec.Descriptor, err error) error {
+	if err != nil && ctx.Err() != nil {
+		if errors.Is(err, ctx.Err()) {
+			return err
+		}
+		return ctx.Err()
 	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	key := descriptor.FromOCI(desc)
+	switch {
+	case err == nil:
+		f.done.Add(key)
+	case errors.Is(err, errSuccessorFailed):
+		f.blocked.Add(key)
+	default:
+		f.failures[key] = &CopyNodeError{Node: desc, Err: err}
+	}
+	return nil
+}
 
-	skipSource := errors.New("skip source")
-	for i, sourceRepository := range sourceRepositories {
-		// try mounting this source repository
-		var mountFailed bool
-		getContent := func() (io.ReadCloser, error) {
-			// the invocation of getContent indicates that mounting has failed
-			mountFailed = true
+// isDone returns true if desc has been copied or skipped.
+func (f *copyFailures) isDone(desc ocispec.Descriptor) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.done.Contains(descriptor.FromOCI(desc))
+}
 
-			if i < len(sourceRepositories)-1 {
-				// If this is not the last one, skip this source and try next one
-				// We want to return an error that we will test for from mounter.Mount()
-				return nil, skipSource
-			}
-			// this is the last iteration so we need to actually get the content and do the copy
-			// but first call the PreCopy function
-			if opts.PreCopy != nil {
-				if err := opts.PreCopy(ctx, desc); err != nil {
-					return nil, err
-				}
-			}
-			return src.Fetch(ctx, desc)
+// anyFailed returns true if any of descs is failed or blocked in the current
+// pass.
+func (f *copyFailures) anyFailed(descs []ocispec.Descriptor) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, desc := range descs {
+		key := descriptor.FromOCI(desc)
+		if _, ok := f.failures[key]; ok || f.blocked.Contains(key) {
+			return true
 		}
+	}
+	return false
+}
 
-		// Mount or copy
-		if err := mounter.Mount(ctx, desc, sourceRepository, getContent); err != nil && !errors.Is(err, skipSource) {
-			return err
+// failed returns true if any node is failed in the current pass.
+func (f *copyFailures) failed() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return len(f.failures) > 0
+}
+
+// reset clears the failures of the current pass for a new pass.
+func (f *copyFailures) reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	clear(f.failures)
+	clear(f.blocked)
+}
+
+// err returns the failures of the current pass as a *CopyGraphError.
+func (f *copyFailures) err() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	errs := &CopyGraphError{
+		Failures: make([]*CopyNodeError, 0, len(f.failures)),
+	}
+	for _, failure := range f.failures {
+		errs.Failures = append(errs.Failures, failure)
+	}
+	slices.SortFunc(errs.Failures, func(a, b *CopyNodeError) int {
+		return strings.Compare(a.Node.Digest.String(), b.Node.Digest.String())
+	})
+	return errs
+}
+
+// checkDigestAlgorithms walks the graph rooted by root and ensures that all the
+// nodes are digested with one of the allowed algorithms, before any content is
+// transferred. The walk visits at most opts.MaxNodes distinct nodes.
+func checkDigestAlgorithms(ctx context.Context, proxy *cas.Proxy, root ocispec.Descriptor, allowed []digest.Algorithm, opts CopyGraphOptions) error {
+	visited := set.New[descriptor.Descriptor]()
+	nodes := []ocispec.Descriptor{root}
+	for len(nodes) > 0 {
+		desc := nodes[len(nodes)-1]
+		nodes = nodes[:len(nodes)-1]
+		key := descriptor.FromOCI(desc)
+		if visited.Contains(key) {
+			continue
+		}
+		visited.Add(key)
+		if len(visited) > opts.MaxNodes {
+			return fmt.Errorf("%s: %s: number of nodes in the graph exceeds the limit %d: %w",
+				desc.Digest, desc.MediaType, opts.MaxNodes, errdef.ErrSizeExceedsLimit)
 		}
 
-		if !mountFailed {
-			// mounted, success
-			if opts.OnMounted != nil {
-				if err := opts.OnMounted(ctx, desc); err != nil {
-					return err
-				}
+		if alg := desc.Digest.Algorithm(); !slices.Contains(allowed, alg) {
+			names := make([]string, 0, len(allowed))
+			for _, a := range allowed {
+				names = append(names, a.String())
 			}
-			return nil
+			return fmt.Errorf("%s: %s: digest algorithm %q is not allowed by the destination (allowed: %s): %w",
+				desc.Digest, desc.MediaType, alg, strings.Join(names, ", "), errdef.ErrUnsupported)
 		}
-	}
 
-	// we copied it
-	if opts.PostCopy != nil {
-		if err := opts.PostCopy(ctx, desc); err != nil {
+		successors, err := opts.FindSuccessors(ctx, proxy, desc)
+		if err != nil {
 			return err
 		}
+		nodes = append(nodes, removeForeignLayers(successors)...)
 	}
-
 	return nil
 }
 
-// doCopyNode copies a single content from the source CAS to the destination CAS.
-func doCopyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, desc ocispec.Descriptor) error {
-	rc, err := src.Fetch(ctx, desc)
-	if err != nil {
-		return err
+// sameRegistrySource returns the name of the source repository if src and dst
+// are located in different repositories of the same registry, so that blobs
+// can be mounted from src to dst.
+func sameRegistrySource(src content.ReadOnlyStorage, dst content.Storage) (string, bool) {
+	srcLocator, ok := src.(registry.Locator)
+	if !ok {
+		return "", false
 	}
-	defer rc.Close()
-	err = dst.Push(ctx, desc, rc)
-	if err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
+	dstLocator, ok := dst.(registry.Locator)
+	if !ok {
+		return "", false
+	}
+	srcHost, srcRepo := srcLocator.Locate()
+	dstHost, dstRepo := dstLocator.Locate()
+	if srcHost == "" || srcRepo == "" || srcHost != dstHost || srcRepo == dstRepo {
+		return "", false
+	}
+	return srcRepo, true
+}
+
+// locate returns the location of target if target implements
+// registry.Locator, or empty strings otherwise.
+func locate(target any) (string, string) {
+	if locator, ok := target.(registry.Locator); ok {
+		return locator.Locate()
+	}
+	return "", ""
+}
+
+// appendMountSource returns a MountFrom function returning the candidates of
+// mountFrom followed by fromRepo.
+func appendMountSource(mountFrom func(ctx context.Context, desc ocispec.Descriptor) ([]string, error), fromRepo string) func(ctx context.Context, desc ocispec.Descriptor) ([]string, error) {
+	if mountFrom == nil {
+		return func(ctx context.Context, desc ocispec.Descriptor) ([]string, error) {
+			return []string{fromRepo}, nil
+		}
+	}
+	return func(ctx context.Context, desc ocispec.Descriptor) ([]string, error) {
+		candidates, err := mountFrom(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(candidates, fromRepo) {
+			return candidates, nil
+		}
+		return append(slices.Clip(candidates), fromRepo), nil
+	}
+}
+
+// mountOrCopyNode tries to mount the node, if not falls back to copying.
+func mountOrCopyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, desc ocispec.Descriptor, opts CopyGraphOptions) error {
+	return transferNode(ctx, src, dst, desc, opts, opts.MountFrom)
+}
+
+// copyNodeWithTimeout runs copyFn copying desc with a context bounded by
+// opts.NodeTimeout, if set.
+func copyNodeWithTimeout(ctx context.Context, desc ocispec.Descriptor, opts CopyGraphOptions, copyFn func(ctx context.Context) error) error {
+	if opts.NodeTimeout <= 0 {
+		return copyFn(ctx)
+	}
+	nodeCtx, cancel := context.WithTimeout(ctx, opts.NodeTimeout)
+	defer cancel()
+	err := copyFn(nodeCtx)
+	if err == nil || ctx.Err() != nil || !errors.Is(nodeCtx.Err(), context.DeadlineExceeded) {
 		return err
 	}
-	return nil
+	if !errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: %v", context.DeadlineExceeded, err)
+	}
+	return fmt.Errorf("%s: %s: node timeout %v exceeded: %w", desc.Digest, desc.MediaType, opts.NodeTimeout, err)
+}
+
+// traceCopyNode runs copyFn copying desc within a span started by
+// opts.Tracer, if set.
+func traceCopyNode(ctx context.Context, desc ocispec.Descriptor, opts CopyGraphOptions, copyFn func(ctx context.Context) error) error {
+	if opts.Tracer == nil {
+		return copyFn(ctx)
+	}
+	ctx, span := opts.Tracer.StartSpan(ctx, trace.SpanCopyNode,
+		trace.Attribute{Key: trace.AttributeDigest, Value: desc.Digest.String()},
+		trace.Attribute{Key: trace.AttributeMediaType, Value: desc.MediaType},
+	)
+	err := copyFn(ctx)
+	span.End(err)
+	return err
 }
 
 // copyNode copies a single content from the source CAS to the destination CAS,
 // and apply the given options.
 func copyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, desc ocispec.Descriptor, opts CopyGraphOptions) error {
+	return transferNode(ctx, src, dst, desc, opts, nil)
+}
+
+// transferNode mounts a single content from the candidate repositories
+// returned by mountFrom, if set, or copies it from the source CAS to the
+// destination CAS, and apply the given options.
+// PreCopy is invoked only if the content is to be copied.
+func transferNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Storage, desc ocispec.Descriptor, opts CopyGraphOptions, mountFrom func(ctx context.Context, desc ocispec.Descriptor) ([]string, error)) error {
+	fetch := func(ctx context.Context) (io.ReadCloser, error) {
+		if opts.PreCopy != nil {
+			if err := opts.PreCopy(ctx, desc); err != nil {
+				return nil, err
+			}
+		}
+		rc, err := src.Fetch(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		return newProgressReader(rc, desc, opts), nil
+	}
+	status, err := copyutil.CopyNode(ctx, dst, desc, mountFrom, fetch)
+	if err != nil {
+		if err == SkipNode {
+			return nil
+		}
+		return err
+	}
+
+	if status == copyutil.NodeMounted {
+		reportCompleted(desc, opts)
+		if opts.OnMounted != nil {
+			return opts.OnMounted(ctx, desc)
+		}
+		return nil
+	}
+	if opts.PostCopy != nil {
+		return opts.PostCopy(ctx, desc)
+	}
+	return nil
+}
+
+// dryRunNode invokes the hooks for copying a single content without
+// transferring it.
+func dryRunNode(ctx context.Context, desc ocispec.Descriptor, opts CopyGraphOptions) error {
 	if opts.PreCopy != nil {
 		if err := opts.PreCopy(ctx, desc); err != nil {
 			if err == SkipNode {
@@ -373,11 +1265,6 @@ func copyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Stor
 			return err
 		}
 	}
-
-	if err := doCopyNode(ctx, src, dst, desc); err != nil {
-		return err
-	}
-
 	if opts.PostCopy != nil {
 		return opts.PostCopy(ctx, desc)
 	}
@@ -386,18 +1273,18 @@ func copyNode(ctx context.Context, src content.ReadOnlyStorage, dst content.Stor
 
 // copyCachedNodeWithReference copies a single content with a reference from the
 // source cache to the destination ReferencePusher.
-func copyCachedNodeWithReference(ctx context.Context, src *cas.Proxy, dst registry.ReferencePusher, desc ocispec.Descriptor, dstRef string) error {
+func copyCachedNodeWithReference(ctx context.Context, src *cas.Proxy, dst registry.ReferencePusher, desc ocispec.Descriptor, dstRef string, opts CopyGraphOptions) error {
 	rc, err := src.FetchCached(ctx, desc)
 	if err != nil {
 		return err
 	}
-	defer rc.Close()
-
+	rc = newProgressReader(rc, desc, opts)
 	err = dst.PushReference(ctx, desc, rc, dstRef)
-	if err != nil && !errors.Is(err, errdef.ErrAlreadyExists) {
-		return err
+	if errors.Is(err, errdef.ErrAlreadyExists) {
+		err = nil
 	}
-	return nil
+	// close explicitly to surface the error of closing the content
+	return errors.Join(err, rc.Close())
 }
 
 // resolveRoot resolves the source reference to the root node.
@@ -450,7 +1337,7 @@ func prepareCopy(ctx context.Context, dst Target, dstRef string, proxy *cas.Prox
 			}
 
 			// for root node, prepare optimized copy
-			if err := copyCachedNodeWithReference(ctx, proxy, refPusher, desc, dstRef); err != nil {
+			if err := copyCachedNodeWithReference(ctx, proxy, refPusher, desc, dstRef, opts.CopyGraphOptions); err != nil {
 				return err
 			}
 			if opts.PostCopy != nil {
@@ -489,8 +1376,11 @@ func prepareCopy(ctx context.Context, dst Target, dstRef string, proxy *cas.Prox
 		// enforce tagging when the skipped node is root
 		if refPusher, ok := dst.(registry.ReferencePusher); ok {
 			// NOTE: refPusher tags the node by copying it with the reference,
-			// so onCopySkipped shouldn't be invoked in this case
-			return copyCachedNodeWithReference(ctx, proxy, refPusher, desc, dstRef)
+			// so onCopySkipped shouldn't be invoked in this case. The progress
+			// of the node has been reported as skipped.
+			tagOpts := opts.CopyGraphOptions
+			tagOpts.OnProgress = nil
+			return copyCachedNodeWithReference(ctx, proxy, refPusher, desc, dstRef, tagOpts)
 		}
 
 		// invoke onCopySkipped before tagging
@@ -505,6 +1395,27 @@ func prepareCopy(ctx context.Context, dst Target, dstRef string, proxy *cas.Prox
 	return nil
 }
 
+// scheduleSuccessors returns the successors in the order specified by
+// policy.
+func scheduleSuccessors(successors []ocispec.Descriptor, policy CopySchedulingPolicy) []ocispec.Descriptor {
+	var compare func(a, b ocispec.Descriptor) int
+	switch policy {
+	case CopyScheduleLargestFirst:
+		compare = func(a, b ocispec.Descriptor) int {
+			return cmp.Compare(b.Size, a.Size)
+		}
+	case CopyScheduleSmallestFirst:
+		compare = func(a, b ocispec.Descriptor) int {
+			return cmp.Compare(a.Size, b.Size)
+		}
+	default:
+		return successors
+	}
+	successors = slices.Clone(successors)
+	slices.SortStableFunc(successors, compare)
+	return successors
+}
+
 // removeForeignLayers in-place removes all foreign layers in the given slice.
 func removeForeignLayers(descs []ocispec.Descriptor) []ocispec.Descriptor {
 	var j int
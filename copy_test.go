@@ -19,24 +19,40 @@ import (
 	"bytes"
 	"context"
 	_ "crypto/sha256"
+	_ "crypto/sha512"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/file"
 	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/content/transaction"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/cas"
 	"oras.land/oras-go/v2/internal/docker"
 	"oras.land/oras-go/v2/internal/spec"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+	"oras.land/oras-go/v2/trace"
 )
 
 // storageTracker tracks storage API counts.
@@ -1196,6 +1212,213 @@ func TestCopy_RestoreDuplicates(t *testing.T) {
 	}
 }
 
+func TestCopy_TagConflictPolicy(t *testing.T) {
+	src := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1])                       // Blob 3
+	generateManifest(descs[0], descs[2])                       // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	root := descs[3]
+	other := descs[4]
+	ref := "foobar"
+	if err := src.Tag(ctx, root, ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+	if err := src.Tag(ctx, other, "other"); err != nil {
+		t.Fatal("fail to tag other node", err)
+	}
+
+	tests := []struct {
+		name       string
+		policy     oras.TagConflictPolicy
+		existing   string // the source reference copied to dst beforehand
+		wantCopied bool
+		wantErr    error
+		wantTagged ocispec.Descriptor
+	}{
+		{"overwrite: absent", oras.TagConflictOverwrite, "", true, nil, root},
+		{"overwrite: same", oras.TagConflictOverwrite, ref, true, nil, root},
+		{"overwrite: different", oras.TagConflictOverwrite, "other", true, nil, root},
+		{"skip if same: absent", oras.TagConflictSkipIfSame, "", true, nil, root},
+		{"skip if same: same", oras.TagConflictSkipIfSame, ref, false, nil, root},
+		{"skip if same: different", oras.TagConflictSkipIfSame, "other", true, nil, root},
+		{"fail on different: absent", oras.TagConflictFailOnDifferent, "", true, nil, root},
+		{"fail on different: same", oras.TagConflictFailOnDifferent, ref, false, nil, root},
+		{"fail on different: different", oras.TagConflictFailOnDifferent, "other", false, errdef.ErrAlreadyExists, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := memory.New()
+			if tt.existing != "" {
+				if _, err := oras.Copy(ctx, src, tt.existing, dst, ref, oras.DefaultCopyOptions); err != nil {
+					t.Fatalf("failed to prepare dst: %v", err)
+				}
+			}
+
+			var visited int64
+			opts := oras.CopyOptions{
+				CopyGraphOptions: oras.CopyGraphOptions{
+					PreCopy: func(ctx context.Context, desc ocispec.Descriptor) error {
+						atomic.AddInt64(&visited, 1)
+						return nil
+					},
+					OnCopySkipped: func(ctx context.Context, desc ocispec.Descriptor) error {
+						atomic.AddInt64(&visited, 1)
+						return nil
+					},
+				},
+				TagConflictPolicy: tt.policy,
+			}
+			gotDesc, err := oras.Copy(ctx, src, ref, dst, "", opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Copy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(gotDesc, root) {
+				t.Errorf("Copy() = %v, want %v", gotDesc, root)
+			}
+			if gotCopied := visited > 0; gotCopied != tt.wantCopied {
+				t.Errorf("Copy() copied = %v, want %v", gotCopied, tt.wantCopied)
+			}
+			gotTagged, err := dst.Resolve(ctx, ref)
+			if err != nil {
+				t.Fatal("dst.Resolve() error =", err)
+			}
+			if !content.Equal(gotTagged, tt.wantTagged) {
+				t.Errorf("dst.Resolve() = %v, want %v", gotTagged, tt.wantTagged)
+			}
+		})
+	}
+
+	// unsupported policy
+	_, err := oras.Copy(ctx, src, ref, memory.New(), "", oras.CopyOptions{TagConflictPolicy: -1})
+	if !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Copy() error = %v, want %v", err, errdef.ErrUnsupported)
+	}
+}
+
+func TestCopy_WithResolve(t *testing.T) {
+	src := memory.New()
+	dst := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1])                       // Blob 3
+	generateManifest(descs[0], descs[2])                       // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, descs[3], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// redirect the tag to a specific digest
+	opts := oras.CopyOptions{
+		Resolve: func(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+			if reference != ref {
+				t.Errorf("Resolve() reference = %s, want %s", reference, ref)
+			}
+			return descs[4], nil
+		},
+	}
+	gotDesc, err := oras.Copy(ctx, src, ref, dst, "", opts)
+	if err != nil {
+		t.Fatalf("Copy() error = %v, wantErr %v", err, false)
+	}
+	if !reflect.DeepEqual(gotDesc, descs[4]) {
+		t.Errorf("Copy() = %v, want %v", gotDesc, descs[4])
+	}
+	gotTagged, err := dst.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatal("dst.Resolve() error =", err)
+	}
+	if !reflect.DeepEqual(gotTagged, descs[4]) {
+		t.Errorf("dst.Resolve() = %v, want %v", gotTagged, descs[4])
+	}
+
+	// verify contents
+	for i, want := range []bool{true, false, true, false, true} {
+		exists, err := dst.Exists(ctx, descs[i])
+		if err != nil {
+			t.Fatalf("dst.Exists(%d) error = %v", i, err)
+		}
+		if exists != want {
+			t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, want)
+		}
+	}
+
+	// resolution failure
+	errResolve := errors.New("resolution denied")
+	opts.Resolve = func(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+		return ocispec.Descriptor{}, errResolve
+	}
+	if _, err := oras.Copy(ctx, src, ref, memory.New(), "", opts); !errors.Is(err, errResolve) {
+		t.Errorf("Copy() error = %v, want %v", err, errResolve)
+	}
+}
+
 func TestCopy_DiscardDuplicates(t *testing.T) {
 	src := memory.New()
 	temp := t.TempDir()
@@ -2027,72 +2250,103 @@ func TestCopyGraph_WithConcurrencyLimit(t *testing.T) {
 	}
 }
 
-func TestCopyGraph_ForeignLayers(t *testing.T) {
-	src := cas.NewMemory()
-	dst := cas.NewMemory()
+// concurrencyStorage records the maximum number of concurrent pushes per
+// class of content, and the classes of the pushes in the order of starting.
+type concurrencyStorage struct {
+	content.Storage
+	classify func(desc ocispec.Descriptor) string
+	lock     sync.Mutex
+	active   map[string]int
+	max      map[string]int
+	started  []string
+}
 
+func (s *concurrencyStorage) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	class := s.classify(expected)
+	s.lock.Lock()
+	s.active[class]++
+	s.max[class] = max(s.max[class], s.active[class])
+	s.started = append(s.started, class)
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		s.active[class]--
+		s.lock.Unlock()
+	}()
+
+	// hold the push for a while to let the copies overlap
+	time.Sleep(20 * time.Millisecond)
+	return s.Storage.Push(ctx, expected, content)
+}
+
+func TestCopyGraph_WithNodeConcurrency(t *testing.T) {
+	src := cas.NewMemory()
 	// generate test content
 	var blobs [][]byte
 	var descs []ocispec.Descriptor
 	appendBlob := func(mediaType string, blob []byte) {
-		desc := ocispec.Descriptor{
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
 			MediaType: mediaType,
 			Digest:    digest.FromBytes(blob),
 			Size:      int64(len(blob)),
-		}
-		if mediaType == ocispec.MediaTypeImageLayerNonDistributable {
-			desc.URLs = append(desc.URLs, "http://127.0.0.1/dummy")
-			blob = nil
-		}
-		descs = append(descs, desc)
-		blobs = append(blobs, blob)
+		})
 	}
-	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
-		manifest := ocispec.Manifest{
-			Config: config,
-			Layers: layers,
-		}
-		manifestJSON, err := json.Marshal(manifest)
-		if err != nil {
-			t.Fatal(err)
-		}
-		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	const largeSize = 1024
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	for i := 0; i < 4; i++ {
+		appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte{byte(i)}, largeSize)) // Blob 1-4
 	}
-
-	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))               // Blob 0
-	appendBlob(ocispec.MediaTypeImageLayerNonDistributable, []byte("hello")) // Blob 1
-	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))                   // Blob 2
-	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))                   // Blob 3
-	generateManifest(descs[0], descs[1:4]...)                                // Blob 4
+	for i := 0; i < 8; i++ {
+		appendBlob(ocispec.MediaTypeImageLayer, []byte(fmt.Sprintf("small %d", i))) // Blob 5-12
+	}
+	manifest := ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:],
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlob(manifest.MediaType, manifestJSON) // Blob 13
 
 	ctx := context.Background()
 	for i := range blobs {
-		if blobs[i] == nil {
-			continue
-		}
 		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
 		if err != nil {
 			t.Fatalf("failed to push test content to src: %d: %v", i, err)
 		}
 	}
 
-	// test copy
-	srcTracker := &storageTracker{Storage: src}
-	dstTracker := &storageTracker{Storage: dst}
+	classify := func(desc ocispec.Descriptor) string {
+		if desc.Size >= largeSize {
+			return "large"
+		}
+		return "small"
+	}
+	dst := &concurrencyStorage{
+		Storage:  cas.NewMemory(),
+		classify: classify,
+		active:   make(map[string]int),
+		max:      make(map[string]int),
+	}
+	opts := oras.CopyGraphOptions{
+		Concurrency: 16,
+		NodeConcurrency: func(desc ocispec.Descriptor) int {
+			if classify(desc) == "large" {
+				return 1
+			}
+			return 3
+		},
+	}
 	root := descs[len(descs)-1]
-	if err := oras.CopyGraph(ctx, srcTracker, dstTracker, root, oras.CopyGraphOptions{}); err != nil {
+	if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
 		t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
 	}
 
 	// verify contents
-	contents := dst.Map()
-	if got, want := len(contents), len(blobs)-1; got != want {
-		t.Errorf("len(dst) = %v, wantErr %v", got, want)
-	}
 	for i := range blobs {
-		if blobs[i] == nil {
-			continue
-		}
 		got, err := content.FetchAll(ctx, dst, descs[i])
 		if err != nil {
 			t.Errorf("content[%d] error = %v, wantErr %v", i, err, false)
@@ -2103,90 +2357,387 @@ func TestCopyGraph_ForeignLayers(t *testing.T) {
 		}
 	}
 
-	// verify API counts
-	if got, want := srcTracker.fetch, int64(len(blobs)-1); got != want {
-		t.Errorf("count(src.Fetch()) = %v, want %v", got, want)
-	}
-	if got, want := srcTracker.push, int64(0); got != want {
-		t.Errorf("count(src.Push()) = %v, want %v", got, want)
-	}
-	if got, want := srcTracker.exists, int64(0); got != want {
-		t.Errorf("count(src.Exists()) = %v, want %v", got, want)
-	}
-	if got, want := dstTracker.fetch, int64(0); got != want {
-		t.Errorf("count(dst.Fetch()) = %v, want %v", got, want)
+	// verify concurrency
+	if got, want := dst.max["large"], 1; got != want {
+		t.Errorf("max concurrent copies of large blobs = %d, want %d", got, want)
 	}
-	if got, want := dstTracker.push, int64(len(blobs)-1); got != want {
-		t.Errorf("count(dst.Push()) = %v, want %v", got, want)
-	}
-	if got, want := dstTracker.exists, int64(len(blobs)-1); got != want {
-		t.Errorf("count(dst.Exists()) = %v, want %v", got, want)
+	if got, want := dst.max["small"], 3; got != want {
+		t.Errorf("max concurrent copies of small blobs = %d, want %d", got, want)
 	}
 }
 
-func TestCopyGraph_ForeignLayers_Mixed(t *testing.T) {
+func TestCopyGraph_WithNodeConcurrency_QueuedNodes(t *testing.T) {
 	src := cas.NewMemory()
-	dst := cas.NewMemory()
-
 	// generate test content
 	var blobs [][]byte
 	var descs []ocispec.Descriptor
 	appendBlob := func(mediaType string, blob []byte) {
-		desc := ocispec.Descriptor{
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
 			MediaType: mediaType,
 			Digest:    digest.FromBytes(blob),
 			Size:      int64(len(blob)),
-		}
-		if mediaType == ocispec.MediaTypeImageLayerNonDistributable {
-			desc.URLs = append(desc.URLs, "http://127.0.0.1/dummy")
-			blob = nil
-		}
-		descs = append(descs, desc)
-		blobs = append(blobs, blob)
+		})
 	}
-	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
-		manifest := ocispec.Manifest{
-			Config: config,
-			Layers: layers,
-		}
-		manifestJSON, err := json.Marshal(manifest)
-		if err != nil {
-			t.Fatal(err)
-		}
-		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	const largeSize = 1024
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	// more large blobs than the concurrency are discovered first
+	for i := 0; i < 6; i++ {
+		appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte{byte(i)}, largeSize)) // Blob 1-6
 	}
-
-	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))               // Blob 0
-	appendBlob(ocispec.MediaTypeImageLayerNonDistributable, []byte("hello")) // Blob 1
-	appendBlob(ocispec.MediaTypeImageLayer, []byte("hello"))                 // Blob 2
-	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))                   // Blob 3
-	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))                   // Blob 4
-	generateManifest(descs[0], descs[1:5]...)                                // Blob 5
+	for i := 0; i < 4; i++ {
+		appendBlob(ocispec.MediaTypeImageLayer, []byte(fmt.Sprintf("small %d", i))) // Blob 7-10
+	}
+	manifest := ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:],
+	}
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlob(manifest.MediaType, manifestJSON) // Blob 11
 
 	ctx := context.Background()
 	for i := range blobs {
-		if blobs[i] == nil {
-			continue
-		}
 		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
 		if err != nil {
 			t.Fatalf("failed to push test content to src: %d: %v", i, err)
 		}
 	}
 
-	// test copy
-	srcTracker := &storageTracker{Storage: src}
-	dstTracker := &storageTracker{Storage: dst}
+	classify := func(desc ocispec.Descriptor) string {
+		if desc.Size >= largeSize {
+			return "large"
+		}
+		return "small"
+	}
+	dst := &concurrencyStorage{
+		Storage:  cas.NewMemory(),
+		classify: classify,
+		active:   make(map[string]int),
+		max:      make(map[string]int),
+	}
+	opts := oras.CopyGraphOptions{
+		Concurrency: 3,
+		NodeConcurrency: func(desc ocispec.Descriptor) int {
+			if classify(desc) == "large" {
+				return 1
+			}
+			return 2
+		},
+	}
 	root := descs[len(descs)-1]
-	if err := oras.CopyGraph(ctx, srcTracker, dstTracker, root, oras.CopyGraphOptions{
-		Concurrency: 1,
-	}); err != nil {
+	if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
 		t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
 	}
 
-	// verify contents
-	contents := dst.Map()
-	if got, want := len(contents), len(blobs)-1; got != want {
+	// the queued large blobs do not hold the concurrency, so that the small
+	// blobs are copied alongside the first large blob
+	var smallBeforeSecondLarge, large int
+	for _, class := range dst.started {
+		if class == "large" {
+			if large++; large == 2 {
+				break
+			}
+			continue
+		}
+		if class == "small" {
+			smallBeforeSecondLarge++
+		}
+	}
+	if got, want := smallBeforeSecondLarge, 2; got < want {
+		t.Errorf("small blobs copied before the second large blob = %d, want at least %d; pushes = %v", got, want, dst.started)
+	}
+	if got, want := dst.max["large"], 1; got != want {
+		t.Errorf("max concurrent copies of large blobs = %d, want %d", got, want)
+	}
+	if got, want := dst.max["small"], 2; got != want {
+		t.Errorf("max concurrent copies of small blobs = %d, want %d", got, want)
+	}
+}
+
+// digestTracker tracks storage API counts per digest.
+type digestTracker struct {
+	content.Storage
+	lock   sync.Mutex
+	push   map[digest.Digest]int
+	exists map[digest.Digest]int
+}
+
+func (t *digestTracker) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	t.lock.Lock()
+	t.push[expected.Digest]++
+	t.lock.Unlock()
+	return t.Storage.Push(ctx, expected, content)
+}
+
+func (t *digestTracker) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	t.lock.Lock()
+	t.exists[target.Digest]++
+	t.lock.Unlock()
+	return t.Storage.Exists(ctx, target)
+}
+
+func TestCopyGraph_WithDestinationCache(t *testing.T) {
+	src := cas.NewMemory()
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(manifest.MediaType, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config 1")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config 2")) // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("base"))      // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))       // Blob 3
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))       // Blob 4
+	generateManifest(descs[0], descs[2], descs[3])               // Blob 5
+	generateManifest(descs[1], descs[2], descs[4])               // Blob 6
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	dst := &digestTracker{
+		Storage: cas.NewMemory(),
+		push:    make(map[digest.Digest]int),
+		exists:  make(map[digest.Digest]int),
+	}
+	var skipped []ocispec.Descriptor
+	opts := oras.CopyGraphOptions{
+		DestinationCache: oras.NewDestinationCache(),
+		OnCopySkipped: func(ctx context.Context, desc ocispec.Descriptor) error {
+			skipped = append(skipped, desc)
+			return nil
+		},
+	}
+	for _, root := range descs[5:7] {
+		if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+	}
+
+	// verify contents
+	for i := range blobs {
+		got, err := content.FetchAll(ctx, dst, descs[i])
+		if err != nil {
+			t.Errorf("content[%d] error = %v, wantErr %v", i, err, false)
+			continue
+		}
+		if want := blobs[i]; !bytes.Equal(got, want) {
+			t.Errorf("content[%d] = %v, want %v", i, got, want)
+		}
+	}
+
+	// verify the shared layer is checked and pushed only once
+	base := descs[2].Digest
+	if got := dst.exists[base]; got != 1 {
+		t.Errorf("count(Exists(base)) = %d, want %d", got, 1)
+	}
+	if got := dst.push[base]; got != 1 {
+		t.Errorf("count(Push(base)) = %d, want %d", got, 1)
+	}
+	if want := descs[2:3]; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("OnCopySkipped() = %v, want %v", skipped, want)
+	}
+	// verify the cache is updated
+	for i, desc := range descs {
+		if !opts.DestinationCache.Contains(desc) {
+			t.Errorf("DestinationCache.Contains(content[%d]) = false, want true", i)
+		}
+	}
+
+	// verify invalidation
+	opts.DestinationCache.Invalidate(descs[2])
+	if opts.DestinationCache.Contains(descs[2]) {
+		t.Errorf("DestinationCache.Contains(content[2]) = true, want false")
+	}
+}
+
+func TestCopyGraph_ForeignLayers(t *testing.T) {
+	src := cas.NewMemory()
+	dst := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if mediaType == ocispec.MediaTypeImageLayerNonDistributable {
+			desc.URLs = append(desc.URLs, "http://127.0.0.1/dummy")
+			blob = nil
+		}
+		descs = append(descs, desc)
+		blobs = append(blobs, blob)
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))               // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayerNonDistributable, []byte("hello")) // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))                   // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))                   // Blob 3
+	generateManifest(descs[0], descs[1:4]...)                                // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		if blobs[i] == nil {
+			continue
+		}
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	// test copy
+	srcTracker := &storageTracker{Storage: src}
+	dstTracker := &storageTracker{Storage: dst}
+	root := descs[len(descs)-1]
+	if err := oras.CopyGraph(ctx, srcTracker, dstTracker, root, oras.CopyGraphOptions{}); err != nil {
+		t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+	}
+
+	// verify contents
+	contents := dst.Map()
+	if got, want := len(contents), len(blobs)-1; got != want {
+		t.Errorf("len(dst) = %v, wantErr %v", got, want)
+	}
+	for i := range blobs {
+		if blobs[i] == nil {
+			continue
+		}
+		got, err := content.FetchAll(ctx, dst, descs[i])
+		if err != nil {
+			t.Errorf("content[%d] error = %v, wantErr %v", i, err, false)
+			continue
+		}
+		if want := blobs[i]; !bytes.Equal(got, want) {
+			t.Errorf("content[%d] = %v, want %v", i, got, want)
+		}
+	}
+
+	// verify API counts
+	if got, want := srcTracker.fetch, int64(len(blobs)-1); got != want {
+		t.Errorf("count(src.Fetch()) = %v, want %v", got, want)
+	}
+	if got, want := srcTracker.push, int64(0); got != want {
+		t.Errorf("count(src.Push()) = %v, want %v", got, want)
+	}
+	if got, want := srcTracker.exists, int64(0); got != want {
+		t.Errorf("count(src.Exists()) = %v, want %v", got, want)
+	}
+	if got, want := dstTracker.fetch, int64(0); got != want {
+		t.Errorf("count(dst.Fetch()) = %v, want %v", got, want)
+	}
+	if got, want := dstTracker.push, int64(len(blobs)-1); got != want {
+		t.Errorf("count(dst.Push()) = %v, want %v", got, want)
+	}
+	if got, want := dstTracker.exists, int64(len(blobs)-1); got != want {
+		t.Errorf("count(dst.Exists()) = %v, want %v", got, want)
+	}
+}
+
+func TestCopyGraph_ForeignLayers_Mixed(t *testing.T) {
+	src := cas.NewMemory()
+	dst := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		desc := ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+		if mediaType == ocispec.MediaTypeImageLayerNonDistributable {
+			desc.URLs = append(desc.URLs, "http://127.0.0.1/dummy")
+			blob = nil
+		}
+		descs = append(descs, desc)
+		blobs = append(blobs, blob)
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))               // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayerNonDistributable, []byte("hello")) // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("hello"))                 // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))                   // Blob 3
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))                   // Blob 4
+	generateManifest(descs[0], descs[1:5]...)                                // Blob 5
+
+	ctx := context.Background()
+	for i := range blobs {
+		if blobs[i] == nil {
+			continue
+		}
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	// test copy
+	srcTracker := &storageTracker{Storage: src}
+	dstTracker := &storageTracker{Storage: dst}
+	root := descs[len(descs)-1]
+	if err := oras.CopyGraph(ctx, srcTracker, dstTracker, root, oras.CopyGraphOptions{
+		Concurrency: 1,
+	}); err != nil {
+		t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+	}
+
+	// verify contents
+	contents := dst.Map()
+	if got, want := len(contents), len(blobs)-1; got != want {
 		t.Errorf("len(dst) = %v, wantErr %v", got, want)
 	}
 	for i := range blobs {
@@ -2349,3 +2900,1701 @@ func TestCopy_Error(t *testing.T) {
 		}
 	})
 }
+
+// algorithmLimitedStorage is a storage accepting only the allowed digest
+// algorithms.
+type algorithmLimitedStorage struct {
+	storageTracker
+	allowed []digest.Algorithm
+}
+
+func (s *algorithmLimitedStorage) AllowedDigestAlgorithms() []digest.Algorithm {
+	return s.allowed
+}
+
+func TestCopyGraph_DigestAlgorithmLimiter(t *testing.T) {
+	src := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, alg digest.Algorithm, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    alg.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, digest.SHA256, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, digest.SHA256, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, digest.SHA256, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, digest.SHA512, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1])                                      // Blob 3
+	generateManifest(descs[0], descs[1:3]...)                                 // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	t.Run("all algorithms allowed", func(t *testing.T) {
+		dst := &algorithmLimitedStorage{
+			storageTracker: storageTracker{Storage: cas.NewMemory()},
+			allowed:        []digest.Algorithm{digest.SHA256},
+		}
+		root := descs[3]
+		if err := oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{}); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		for i := range descs[:2] {
+			exists, err := dst.Exists(ctx, descs[i])
+			if err != nil {
+				t.Fatalf("dst.Exists(%d) error = %v", i, err)
+			}
+			if !exists {
+				t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, true)
+			}
+		}
+	})
+
+	t.Run("child algorithm not allowed", func(t *testing.T) {
+		dst := &algorithmLimitedStorage{
+			storageTracker: storageTracker{Storage: cas.NewMemory()},
+			allowed:        []digest.Algorithm{digest.SHA256},
+		}
+		root := descs[4]
+		err := oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{})
+		if !errors.Is(err, errdef.ErrUnsupported) {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+		if got := dst.push; got != 0 {
+			t.Errorf("count(Push()) = %d, want %d", got, 0)
+		}
+	})
+
+	t.Run("root algorithm not allowed", func(t *testing.T) {
+		dst := &algorithmLimitedStorage{
+			storageTracker: storageTracker{Storage: cas.NewMemory()},
+			allowed:        []digest.Algorithm{digest.SHA512},
+		}
+		root := descs[3]
+		err := oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{})
+		if !errors.Is(err, errdef.ErrUnsupported) {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+		if got := dst.push; got != 0 {
+			t.Errorf("count(Push()) = %d, want %d", got, 0)
+		}
+	})
+
+	t.Run("nodes exceed MaxNodes", func(t *testing.T) {
+		dst := &algorithmLimitedStorage{
+			storageTracker: storageTracker{Storage: cas.NewMemory()},
+			allowed:        []digest.Algorithm{digest.SHA256},
+		}
+		root := descs[3]
+		opts := oras.CopyGraphOptions{MaxNodes: 2}
+		err := oras.CopyGraph(ctx, src, dst, root, opts)
+		if !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, errdef.ErrSizeExceedsLimit)
+		}
+		if got := dst.push; got != 0 {
+			t.Errorf("count(Push()) = %d, want %d", got, 0)
+		}
+	})
+
+	t.Run("algorithms not restricted", func(t *testing.T) {
+		dst := &algorithmLimitedStorage{
+			storageTracker: storageTracker{Storage: cas.NewMemory()},
+		}
+		root := descs[4]
+		if err := oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{}); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		if got, want := dst.push, int64(len(descs)-1); got != want {
+			t.Errorf("count(Push()) = %d, want %d", got, want)
+		}
+	})
+}
+
+func TestCopy_MapManifest(t *testing.T) {
+	src := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+			Annotations: map[string]string{
+				"internal.host": "build-01",
+			},
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	generateIndex := func(manifests ...ocispec.Descriptor) {
+		index := ocispec.Index{
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: manifests,
+		}
+		indexJSON, err := json.Marshal(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageIndex, indexJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1])                       // Blob 3
+	generateManifest(descs[0], descs[2])                       // Blob 4
+	descs[3].Platform = &ocispec.Platform{OS: "linux", Architecture: "amd64"}
+	generateIndex(descs[3:5]...) // Blob 5
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	root := descs[5]
+	ref := "foobar"
+	if err := src.Tag(ctx, root, ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// strip the internal annotations from the image manifests
+	opts := oras.CopyOptions{
+		MapManifest: func(ctx context.Context, desc ocispec.Descriptor, manifestJSON []byte) ([]byte, error) {
+			if desc.MediaType != ocispec.MediaTypeImageManifest {
+				return manifestJSON, nil
+			}
+			var manifest ocispec.Manifest
+			if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+				return nil, err
+			}
+			delete(manifest.Annotations, "internal.host")
+			return json.Marshal(manifest)
+		},
+	}
+	dst := memory.New()
+	gotDesc, err := oras.Copy(ctx, src, ref, dst, "", opts)
+	if err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if gotDesc.Digest == root.Digest {
+		t.Errorf("Copy() digest = %v, want a new digest", gotDesc.Digest)
+	}
+
+	// verify the mapped root is tagged
+	resolved, err := dst.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatal("dst.Resolve() error =", err)
+	}
+	if !reflect.DeepEqual(resolved, gotDesc) {
+		t.Errorf("dst.Resolve() = %v, want %v", resolved, gotDesc)
+	}
+
+	// verify the index points to the mapped manifests
+	indexJSON, err := content.FetchAll(ctx, dst, gotDesc)
+	if err != nil {
+		t.Fatal("dst.Fetch() error =", err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 2 {
+		t.Fatalf("len(index.Manifests) = %d, want 2", len(index.Manifests))
+	}
+	for i, desc := range index.Manifests {
+		if desc.Digest == descs[3+i].Digest {
+			t.Errorf("index.Manifests[%d] = %v, want a new digest", i, desc.Digest)
+		}
+		if !reflect.DeepEqual(desc.Platform, descs[3+i].Platform) {
+			t.Errorf("index.Manifests[%d].Platform = %v, want %v", i, desc.Platform, descs[3+i].Platform)
+		}
+		manifestJSON, err := content.FetchAll(ctx, dst, desc)
+		if err != nil {
+			t.Fatalf("dst.Fetch(%d) error = %v", i, err)
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := manifest.Annotations["internal.host"]; ok {
+			t.Errorf("manifest %d annotations = %v, want stripped", i, manifest.Annotations)
+		}
+	}
+
+	// verify the blobs are copied and the source manifests are not
+	for i, want := range []bool{true, true, true, false, false, false} {
+		exists, err := dst.Exists(ctx, descs[i])
+		if err != nil {
+			t.Fatalf("dst.Exists(%d) error = %v", i, err)
+		}
+		if exists != want {
+			t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, want)
+		}
+	}
+}
+
+func TestCopyIndexSubset(t *testing.T) {
+	src := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	generateIndex := func(subject *ocispec.Descriptor, manifests ...ocispec.Descriptor) {
+		index := ocispec.Index{
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: manifests,
+			Subject:   subject,
+			Annotations: map[string]string{
+				"foo": "bar",
+			},
+		}
+		indexJSON, err := json.Marshal(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageIndex, indexJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("hello"))   // Blob 3
+	generateManifest(descs[0], descs[1])                       // Blob 4
+	generateManifest(descs[0], descs[2])                       // Blob 5
+	generateManifest(descs[0], descs[3])                       // Blob 6
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("subject")) // Blob 7
+	generateManifest(descs[0], descs[7])                       // Blob 8
+	generateIndex(&descs[8], descs[4:7]...)                    // Blob 9
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	root := descs[9]
+	ref := "foobar"
+	if err := src.Tag(ctx, root, ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	t.Run("copy two of three manifests", func(t *testing.T) {
+		dst := memory.New()
+		gotDesc, err := oras.CopyIndexSubset(ctx, src, ref, dst, "", []digest.Digest{descs[6].Digest, descs[4].Digest}, oras.DefaultCopyOptions)
+		if err != nil {
+			t.Fatalf("CopyIndexSubset() error = %v", err)
+		}
+		if gotDesc.Digest == root.Digest {
+			t.Errorf("CopyIndexSubset() digest = %v, want a new digest", gotDesc.Digest)
+		}
+		if gotDesc.MediaType != ocispec.MediaTypeImageIndex {
+			t.Errorf("CopyIndexSubset() media type = %v, want %v", gotDesc.MediaType, ocispec.MediaTypeImageIndex)
+		}
+
+		// verify the reduced index
+		resolved, err := dst.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatal("dst.Resolve() error =", err)
+		}
+		if !reflect.DeepEqual(resolved, gotDesc) {
+			t.Errorf("dst.Resolve() = %v, want %v", resolved, gotDesc)
+		}
+		indexJSON, err := content.FetchAll(ctx, dst, gotDesc)
+		if err != nil {
+			t.Fatal("dst.Fetch() error =", err)
+		}
+		var index ocispec.Index
+		if err := json.Unmarshal(indexJSON, &index); err != nil {
+			t.Fatal("failed to decode index:", err)
+		}
+		wantManifests := []ocispec.Descriptor{descs[4], descs[6]}
+		if !reflect.DeepEqual(index.Manifests, wantManifests) {
+			t.Errorf("index.Manifests = %v, want %v", index.Manifests, wantManifests)
+		}
+		if want := map[string]string{"foo": "bar"}; !reflect.DeepEqual(index.Annotations, want) {
+			t.Errorf("index.Annotations = %v, want %v", index.Annotations, want)
+		}
+		if index.Subject == nil || !content.Equal(*index.Subject, descs[8]) {
+			t.Errorf("index.Subject = %v, want %v", index.Subject, descs[8])
+		}
+		if index.MediaType != ocispec.MediaTypeImageIndex {
+			t.Errorf("index.MediaType = %v, want %v", index.MediaType, ocispec.MediaTypeImageIndex)
+		}
+
+		// verify transferred contents
+		for i, want := range []bool{true, true, false, true, true, false, true, true, true, false} {
+			exists, err := dst.Exists(ctx, descs[i])
+			if err != nil {
+				t.Fatalf("dst.Exists(%d) error = %v", i, err)
+			}
+			if exists != want {
+				t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, want)
+			}
+		}
+	})
+
+	t.Run("manifest not in index", func(t *testing.T) {
+		dst := memory.New()
+		_, err := oras.CopyIndexSubset(ctx, src, ref, dst, "", []digest.Digest{descs[4].Digest, descs[1].Digest}, oras.DefaultCopyOptions)
+		if !errors.Is(err, errdef.ErrNotFound) {
+			t.Fatalf("CopyIndexSubset() error = %v, wantErr %v", err, errdef.ErrNotFound)
+		}
+	})
+
+	t.Run("root is not an index", func(t *testing.T) {
+		dst := memory.New()
+		if err := src.Tag(ctx, descs[4], "manifest"); err != nil {
+			t.Fatal("fail to tag manifest", err)
+		}
+		_, err := oras.CopyIndexSubset(ctx, src, "manifest", dst, "", []digest.Digest{descs[1].Digest}, oras.DefaultCopyOptions)
+		if !errors.Is(err, errdef.ErrUnsupported) {
+			t.Fatalf("CopyIndexSubset() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+	})
+}
+
+// oneByteStorage fetches content one byte per read.
+type oneByteStorage struct {
+	content.Storage
+}
+
+func (s *oneByteStorage) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, err := s.Storage.Fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: iotest.OneByteReader(rc),
+		Closer: rc,
+	}, nil
+}
+
+func TestCopyGraph_OnProgress(t *testing.T) {
+	src := &oneByteStorage{Storage: cas.NewMemory()}
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))                      // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("foo"), 16*1024))   // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("bar"), 32*1024))   // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("hello"), 32*1024)) // Blob 3
+	generateManifest(descs[0], descs[1:4]...)                                       // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	root := descs[len(descs)-1]
+
+	tests := []struct {
+		name          string
+		interval      time.Duration
+		wantPerReport bool
+	}{
+		{
+			name:     "frequent reports",
+			interval: time.Nanosecond,
+		},
+		{
+			name:          "throttled reports",
+			interval:      time.Hour,
+			wantPerReport: true,
+		},
+	}
+	// progressRecorder records the progress reported for each descriptor
+	type progressRecorder struct {
+		mu      sync.Mutex
+		copied  map[digest.Digest]int64
+		reports map[digest.Digest]int
+	}
+	newProgressRecorder := func() *progressRecorder {
+		return &progressRecorder{
+			copied:  make(map[digest.Digest]int64),
+			reports: make(map[digest.Digest]int),
+		}
+	}
+	onProgress := func(t *testing.T, pr *progressRecorder) func(desc ocispec.Descriptor, copied, total int64) {
+		return func(desc ocispec.Descriptor, copied, total int64) {
+			pr.mu.Lock()
+			defer pr.mu.Unlock()
+			if total != desc.Size {
+				t.Errorf("OnProgress() total = %d, want %d", total, desc.Size)
+			}
+			if last, ok := pr.copied[desc.Digest]; ok && copied < last {
+				t.Errorf("OnProgress() copied = %d, want no less than %d", copied, last)
+			}
+			pr.copied[desc.Digest] = copied
+			pr.reports[desc.Digest]++
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := newProgressRecorder()
+			opts := oras.CopyGraphOptions{
+				ProgressInterval: tt.interval,
+				OnProgress:       onProgress(t, pr),
+			}
+			dst := cas.NewMemory()
+			if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+				t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+			}
+			for i, desc := range descs {
+				if got := pr.copied[desc.Digest]; got != desc.Size {
+					t.Errorf("copied bytes of blob %d = %d, want %d", i, got, desc.Size)
+				}
+				if tt.wantPerReport {
+					if got := pr.reports[desc.Digest]; got != 1 {
+						t.Errorf("reports of blob %d = %d, want %d", i, got, 1)
+					}
+				}
+			}
+			if !tt.wantPerReport && pr.reports[descs[3].Digest] <= 1 {
+				t.Errorf("reports of blob %d = %d, want more than 1", 3, pr.reports[descs[3].Digest])
+			}
+		})
+	}
+
+	t.Run("final report on close", func(t *testing.T) {
+		pr := newProgressRecorder()
+		opts := oras.CopyGraphOptions{
+			ProgressInterval: time.Hour,
+			OnProgress:       onProgress(t, pr),
+		}
+		// the content is fully read without reaching EOF, so the progress is
+		// only reported on close
+		dst := &exactReadStorage{Storage: cas.NewMemory()}
+		if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		for i, desc := range descs {
+			if got := pr.copied[desc.Digest]; got != desc.Size {
+				t.Errorf("copied bytes of blob %d = %d, want %d", i, got, desc.Size)
+			}
+			if got := pr.reports[desc.Digest]; got != 1 {
+				t.Errorf("reports of blob %d = %d, want %d", i, got, 1)
+			}
+		}
+	})
+
+	t.Run("zero-size content", func(t *testing.T) {
+		src := cas.NewMemory()
+		empty := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, nil)
+		if err := src.Push(ctx, empty, bytes.NewReader(nil)); err != nil {
+			t.Fatalf("failed to push test content to src: %v", err)
+		}
+		pr := newProgressRecorder()
+		opts := oras.CopyGraphOptions{
+			OnProgress: onProgress(t, pr),
+		}
+		if err := oras.CopyGraph(ctx, src, cas.NewMemory(), empty, opts); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		if got := pr.reports[empty.Digest]; got != 1 {
+			t.Errorf("reports of empty blob = %d, want %d", got, 1)
+		}
+		if got, ok := pr.copied[empty.Digest]; !ok || got != 0 {
+			t.Errorf("copied bytes of empty blob = %d, want %d", got, 0)
+		}
+	})
+
+	t.Run("skipped content", func(t *testing.T) {
+		dst := cas.NewMemory()
+		// blob 1 exists in the destination
+		if err := dst.Push(ctx, descs[1], bytes.NewReader(blobs[1])); err != nil {
+			t.Fatalf("failed to push test content to dst: %v", err)
+		}
+		pr := newProgressRecorder()
+		opts := oras.CopyGraphOptions{
+			OnProgress: onProgress(t, pr),
+		}
+		if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		for i, desc := range descs {
+			if got := pr.copied[desc.Digest]; got != desc.Size {
+				t.Errorf("copied bytes of blob %d = %d, want %d", i, got, desc.Size)
+			}
+			if got := pr.reports[desc.Digest]; i == 1 && got != 1 {
+				t.Errorf("reports of blob %d = %d, want %d", i, got, 1)
+			}
+		}
+	})
+
+	t.Run("mounted content", func(t *testing.T) {
+		storage := cas.NewMemory()
+		var numMount atomic.Int64
+		dst := &countingStorage{
+			storage: storage,
+			mount: func(ctx context.Context, desc ocispec.Descriptor, fromRepo string, getContent func() (io.ReadCloser, error)) error {
+				// mount the content without reading it from the source
+				numMount.Add(1)
+				for i := range descs {
+					if content.Equal(descs[i], desc) {
+						return storage.Push(ctx, desc, bytes.NewReader(blobs[i]))
+					}
+				}
+				return errdef.ErrNotFound
+			},
+		}
+		pr := newProgressRecorder()
+		opts := oras.CopyGraphOptions{
+			MountFrom: func(ctx context.Context, desc ocispec.Descriptor) ([]string, error) {
+				return []string{"source"}, nil
+			},
+			OnProgress: onProgress(t, pr),
+		}
+		if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		if got := numMount.Load(); got != 4 {
+			t.Errorf("mounts = %d, want %d", got, 4)
+		}
+		for i, desc := range descs {
+			if got := pr.copied[desc.Digest]; got != desc.Size {
+				t.Errorf("copied bytes of blob %d = %d, want %d", i, got, desc.Size)
+			}
+			if got := pr.reports[desc.Digest]; i < 4 && got != 1 {
+				t.Errorf("reports of blob %d = %d, want %d", i, got, 1)
+			}
+		}
+	})
+}
+
+// exactReadStorage reads exactly the size of the content on push without
+// reading to EOF.
+type exactReadStorage struct {
+	content.Storage
+}
+
+func (s *exactReadStorage) Push(ctx context.Context, expected ocispec.Descriptor, r io.Reader) error {
+	buf := make([]byte, expected.Size)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	return s.Storage.Push(ctx, expected, bytes.NewReader(buf))
+}
+
+// stuckStorage blocks the reads of the content of stuck until the context of
+// the fetch is done.
+type stuckStorage struct {
+	content.Storage
+	stuck digest.Digest
+}
+
+func (s *stuckStorage) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	if target.Digest != s.stuck {
+		return s.Storage.Fetch(ctx, target)
+	}
+	return io.NopCloser(readerFunc(func(p []byte) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})), nil
+}
+
+// readerFunc is a function implementing io.Reader.
+type readerFunc func(p []byte) (int, error)
+
+func (fn readerFunc) Read(p []byte) (int, error) {
+	return fn(p)
+}
+
+func TestCopyGraph_NodeTimeout(t *testing.T) {
+	src := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1:3]...)                  // Blob 3
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	root := descs[3]
+
+	t.Run("no stuck node", func(t *testing.T) {
+		dst := cas.NewMemory()
+		opts := oras.CopyGraphOptions{
+			NodeTimeout: time.Minute,
+		}
+		if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		for i, desc := range descs {
+			exists, err := dst.Exists(ctx, desc)
+			if err != nil {
+				t.Fatalf("dst.Exists(%d) error = %v", i, err)
+			}
+			if !exists {
+				t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, true)
+			}
+		}
+	})
+
+	t.Run("stuck node", func(t *testing.T) {
+		stuck := descs[2]
+		stuckSrc := &stuckStorage{
+			Storage: src,
+			stuck:   stuck.Digest,
+		}
+		dst := cas.NewMemory()
+		opts := oras.CopyGraphOptions{
+			NodeTimeout: 100 * time.Millisecond,
+		}
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		err := oras.CopyGraph(ctx, stuckSrc, dst, root, opts)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, context.DeadlineExceeded)
+		}
+		if !strings.Contains(err.Error(), stuck.Digest.String()) {
+			t.Errorf("CopyGraph() error = %v, want error on %s", err, stuck.Digest)
+		}
+		if ctx.Err() != nil {
+			t.Errorf("overall context error = %v, want nil", ctx.Err())
+		}
+		exists, err := dst.Exists(ctx, root)
+		if err != nil {
+			t.Fatal("dst.Exists() error =", err)
+		}
+		if exists {
+			t.Errorf("dst.Exists(root) = %v, want %v", exists, false)
+		}
+	})
+}
+
+// flakyStorage fails the first pushes of the content of flaky.
+type flakyStorage struct {
+	content.Storage
+	lock     sync.Mutex
+	failures map[digest.Digest]int
+	pushes   map[digest.Digest]int
+}
+
+var errFlakyPush = errors.New("flaky push")
+
+func (s *flakyStorage) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	s.lock.Lock()
+	s.pushes[expected.Digest]++
+	fail := s.failures[expected.Digest] > 0
+	if fail {
+		s.failures[expected.Digest]--
+	}
+	s.lock.Unlock()
+	if fail {
+		return errFlakyPush
+	}
+	return s.Storage.Push(ctx, expected, content)
+}
+
+// sortedDigests returns the digests of descs in ascending order.
+func sortedDigests(descs []ocispec.Descriptor) []digest.Digest {
+	digests := make([]digest.Digest, 0, len(descs))
+	for _, desc := range descs {
+		digests = append(digests, desc.Digest)
+	}
+	slices.Sort(digests)
+	return digests
+}
+
+func TestCopyGraph_ContinueOnError(t *testing.T) {
+	src := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1:3]...)                  // Blob 3
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	root := descs[3]
+
+	// blob 1 and blob 2 fail once
+	newDst := func() *flakyStorage {
+		return &flakyStorage{
+			Storage: cas.NewMemory(),
+			failures: map[digest.Digest]int{
+				descs[1].Digest: 1,
+				descs[2].Digest: 1,
+			},
+			pushes: make(map[digest.Digest]int),
+		}
+	}
+
+	t.Run("retry succeeds", func(t *testing.T) {
+		dst := newDst()
+		var postCopied []ocispec.Descriptor
+		var lock sync.Mutex
+		opts := oras.CopyGraphOptions{
+			ContinueOnError: true,
+			RetryAttempts:   2,
+			RetryBackoff:    time.Millisecond,
+			PostCopy: func(ctx context.Context, desc ocispec.Descriptor) error {
+				lock.Lock()
+				defer lock.Unlock()
+				postCopied = append(postCopied, desc)
+				return nil
+			},
+			OnCopySkipped: func(ctx context.Context, desc ocispec.Descriptor) error {
+				t.Errorf("OnCopySkipped(%s) invoked, want not invoked", desc.Digest)
+				return nil
+			},
+		}
+		if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, false)
+		}
+		for i, desc := range descs {
+			exists, err := dst.Exists(ctx, desc)
+			if err != nil {
+				t.Fatalf("dst.Exists(%d) error = %v", i, err)
+			}
+			if !exists {
+				t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, true)
+			}
+		}
+		wantPushes := []int{1, 2, 2, 1}
+		for i, want := range wantPushes {
+			if got := dst.pushes[descs[i].Digest]; got != want {
+				t.Errorf("count(Push(%d)) = %d, want %d", i, got, want)
+			}
+		}
+		if got, want := sortedDigests(postCopied), sortedDigests(descs); !reflect.DeepEqual(got, want) {
+			t.Errorf("PostCopy() invoked on %v, want %v", got, want)
+		}
+	})
+
+	t.Run("retry exhausted", func(t *testing.T) {
+		dst := newDst()
+		opts := oras.CopyGraphOptions{
+			ContinueOnError: true,
+		}
+		err := oras.CopyGraph(ctx, src, dst, root, opts)
+		var copyErr *oras.CopyGraphError
+		if !errors.As(err, &copyErr) {
+			t.Fatalf("CopyGraph() error = %v, want %T", err, copyErr)
+		}
+		if !errors.Is(err, errFlakyPush) {
+			t.Errorf("CopyGraph() error = %v, wantErr %v", err, errFlakyPush)
+		}
+		var gotFailed []ocispec.Descriptor
+		for _, failure := range copyErr.Failures {
+			gotFailed = append(gotFailed, failure.Node)
+		}
+		if want := sortedDigests(descs[1:3]); !reflect.DeepEqual(sortedDigests(gotFailed), want) {
+			t.Errorf("CopyGraphError.Failures = %v, want %v", gotFailed, want)
+		}
+
+		// the other successor is copied while the root is not
+		if exists, err := dst.Exists(ctx, descs[0]); err != nil || !exists {
+			t.Errorf("dst.Exists(0) = %v, %v, want %v", exists, err, true)
+		}
+		if exists, err := dst.Exists(ctx, root); err != nil || exists {
+			t.Errorf("dst.Exists(root) = %v, %v, want %v", exists, err, false)
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		dst := newDst()
+		err := oras.CopyGraph(ctx, src, dst, root, oras.CopyGraphOptions{})
+		if !errors.Is(err, errFlakyPush) {
+			t.Fatalf("CopyGraph() error = %v, wantErr %v", err, errFlakyPush)
+		}
+		var copyErr *oras.CopyGraphError
+		if errors.As(err, &copyErr) {
+			t.Errorf("CopyGraph() error = %v, want not %T", err, copyErr)
+		}
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		dst := newDst()
+		ctx, cancel := context.WithCancel(ctx)
+		opts := oras.CopyGraphOptions{
+			ContinueOnError: true,
+			RetryAttempts:   1,
+			RetryBackoff:    time.Minute,
+			PostCopy: func(ctx context.Context, desc ocispec.Descriptor) error {
+				if content.Equal(desc, descs[0]) {
+					cancel()
+				}
+				return nil
+			},
+		}
+		err := oras.CopyGraph(ctx, src, dst, root, opts)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("CopyGraph() error = %v, wantErr %v", err, context.Canceled)
+		}
+	})
+}
+
+// mistaggingTarget resolves every reference to a fixed descriptor.
+type mistaggingTarget struct {
+	oras.Target
+	desc ocispec.Descriptor
+}
+
+func (t *mistaggingTarget) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return t.desc, nil
+}
+
+func TestCopyPinned(t *testing.T) {
+	src := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1])                       // Blob 3
+	generateManifest(descs[0], descs[2])                       // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	pinned := descs[3]
+	// make the pinned digest resolvable
+	if err := src.Tag(ctx, pinned, pinned.Digest.String()); err != nil {
+		t.Fatalf("failed to tag test content in src: %v", err)
+	}
+	ref := "foobar"
+
+	t.Run("copy pinned digest", func(t *testing.T) {
+		dst := memory.New()
+		got, err := oras.CopyPinned(ctx, src, pinned.Digest, dst, ref, oras.CopyOptions{})
+		if err != nil {
+			t.Fatalf("CopyPinned() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, pinned) {
+			t.Errorf("CopyPinned() = %v, want %v", got, pinned)
+		}
+		gotDesc, err := dst.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("dst.Resolve() error = %v", err)
+		}
+		if gotDesc.Digest != pinned.Digest {
+			t.Errorf("dst.Resolve() digest = %v, want %v", gotDesc.Digest, pinned.Digest)
+		}
+		for _, i := range []int{0, 1, 3} {
+			exists, err := dst.Exists(ctx, descs[i])
+			if err != nil {
+				t.Fatalf("dst.Exists(%d) error = %v", i, err)
+			}
+			if !exists {
+				t.Errorf("dst.Exists(%d) = %v, want %v", i, exists, true)
+			}
+		}
+	})
+
+	t.Run("pinned digest not found", func(t *testing.T) {
+		_, err := oras.CopyPinned(ctx, src, digest.FromString("foo"), memory.New(), ref, oras.CopyOptions{})
+		if !errors.Is(err, errdef.ErrNotFound) {
+			t.Errorf("CopyPinned() error = %v, wantErr %v", err, errdef.ErrNotFound)
+		}
+	})
+
+	t.Run("invalid pinned digest", func(t *testing.T) {
+		if _, err := oras.CopyPinned(ctx, src, "sha256:invalid", memory.New(), ref, oras.CopyOptions{}); err == nil {
+			t.Errorf("CopyPinned() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("mapped root mismatch", func(t *testing.T) {
+		opts := oras.CopyOptions{
+			MapRoot: func(ctx context.Context, src content.ReadOnlyStorage, root ocispec.Descriptor) (ocispec.Descriptor, error) {
+				return descs[4], nil
+			},
+		}
+		dst := memory.New()
+		_, err := oras.CopyPinned(ctx, src, pinned.Digest, dst, ref, opts)
+		if !errors.Is(err, content.ErrMismatchedDigest) {
+			t.Errorf("CopyPinned() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+		}
+		if _, err := dst.Resolve(ctx, ref); !errors.Is(err, errdef.ErrNotFound) {
+			t.Errorf("dst.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+		}
+	})
+
+	t.Run("destination root mismatch", func(t *testing.T) {
+		dst := &mistaggingTarget{
+			Target: memory.New(),
+			desc:   descs[4],
+		}
+		_, err := oras.CopyPinned(ctx, src, pinned.Digest, dst, ref, oras.CopyOptions{})
+		if !errors.Is(err, content.ErrMismatchedDigest) {
+			t.Errorf("CopyPinned() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+		}
+	})
+}
+
+// nodeTracer records the attributes of the started spans.
+type nodeTracer struct {
+	mu    sync.Mutex
+	names []string
+	attrs []map[string]string
+	ended atomic.Int64
+}
+
+func (t *nodeTracer) StartSpan(ctx context.Context, name string, attrs ...trace.Attribute) (context.Context, trace.Span) {
+	m := make(map[string]string)
+	for _, attr := range attrs {
+		m[attr.Key] = attr.Value
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.names = append(t.names, name)
+	t.attrs = append(t.attrs, m)
+	return ctx, t
+}
+
+func (t *nodeTracer) SetAttributes(attrs ...trace.Attribute) {}
+
+func (t *nodeTracer) End(err error) {
+	t.ended.Add(1)
+}
+
+func TestCopyGraph_Tracer(t *testing.T) {
+	src := cas.NewMemory()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	generateManifest := func(config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config: config,
+			Layers: layers,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(descs[0], descs[1:3]...)                  // Blob 3
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	tracer := &nodeTracer{}
+	opts := oras.CopyGraphOptions{
+		Tracer: tracer,
+	}
+	if err := oras.CopyGraph(ctx, src, cas.NewMemory(), descs[3], opts); err != nil {
+		t.Fatalf("CopyGraph() error = %v", err)
+	}
+	if got := len(tracer.names); got != len(descs) {
+		t.Fatalf("number of spans = %d, want %d", got, len(descs))
+	}
+	if got := tracer.ended.Load(); got != int64(len(descs)) {
+		t.Errorf("number of ended spans = %d, want %d", got, len(descs))
+	}
+	want := make(map[string]string)
+	for _, desc := range descs {
+		want[desc.Digest.String()] = desc.MediaType
+	}
+	for i, name := range tracer.names {
+		if name != trace.SpanCopyNode {
+			t.Errorf("span[%d].name = %s, want %s", i, name, trace.SpanCopyNode)
+		}
+		attrs := tracer.attrs[i]
+		dgst := attrs[trace.AttributeDigest]
+		mediaType, ok := want[dgst]
+		if !ok {
+			t.Errorf("span[%d] has unexpected digest %s", i, dgst)
+			continue
+		}
+		if got := attrs[trace.AttributeMediaType]; got != mediaType {
+			t.Errorf("span[%d].attrs[%s] = %s, want %s", i, trace.AttributeMediaType, got, mediaType)
+		}
+		delete(want, dgst)
+	}
+}
+
+func TestCopy_Offline(t *testing.T) {
+	src := memory.New()
+	dst := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		Config: descs[0],
+		Layers: descs[1:2],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlob(ocispec.MediaTypeImageManifest, manifestJSON) // Blob 2
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, descs[2], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// copy between local stores succeeds offline
+	gotDesc, err := oras.Copy(ctx, src, ref, dst, "", oras.DefaultCopyOptions)
+	if err != nil {
+		t.Fatalf("Copy() error = %v, wantErr %v", err, false)
+	}
+	if !reflect.DeepEqual(gotDesc, descs[2]) {
+		t.Errorf("Copy() = %v, want %v", gotDesc, descs[2])
+	}
+
+	// copy to a remote repository fails offline
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	repo, err := remote.NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.Client = &auth.Client{Offline: true}
+
+	_, err = oras.Copy(ctx, src, ref, repo, "", oras.DefaultCopyOptions)
+	var offlineErr *auth.OfflineError
+	if !errors.As(err, &offlineErr) {
+		t.Errorf("Copy() error = %v, want %T", err, offlineErr)
+	}
+	_, err = oras.Copy(ctx, repo, ref, dst, "", oras.DefaultCopyOptions)
+	if !errors.As(err, &offlineErr) {
+		t.Errorf("Copy() error = %v, want %T", err, offlineErr)
+	}
+	if got := atomic.LoadInt64(&requestCount); got != 0 {
+		t.Errorf("request count = %v, want 0", got)
+	}
+}
+
+func TestCopy_CheckPush(t *testing.T) {
+	src := memory.New()
+	ctx := context.Background()
+	blob := []byte("foo")
+	blobDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, blob)
+	if err := src.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+		t.Fatal("failed to push test content to src:", err)
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, blobDesc, ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// the destination denies pushes
+	var requests []string
+	var lock sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		lock.Unlock()
+		if r.Method != http.MethodPost || r.URL.Path != "/v2/test/blobs/uploads/" {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		if _, err := w.Write([]byte(`{"errors":[{"code":"DENIED","message":"requested access to the resource is denied"}]}`)); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	repo, err := remote.NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+
+	opts := oras.CopyOptions{
+		CheckPush: true,
+	}
+	_, err = oras.Copy(ctx, src, ref, repo, "", opts)
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Copy() error = %v, want %v", err, http.StatusForbidden)
+	}
+	want := []string{"POST /v2/test/blobs/uploads/"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
+	}
+}
+
+func TestCopyGraph_TryMount(t *testing.T) {
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:2],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlob(ocispec.MediaTypeImageManifest, manifestJSON) // Blob 2
+	root := descs[2]
+
+	// the registry mounts the config but not the layer
+	var lock sync.Mutex
+	var mountRequests []string
+	var uploaded []digest.Digest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/src/manifests/"+root.Digest.String():
+			w.Header().Set("Content-Type", root.MediaType)
+			w.Header().Set("Docker-Content-Digest", root.Digest.String())
+			w.Write(manifestJSON)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/src/blobs/"+descs[1].Digest.String():
+			w.Header().Set("Docker-Content-Digest", descs[1].Digest.String())
+			w.Write(blobs[1])
+		case r.Method == http.MethodHead && strings.HasPrefix(r.URL.Path, "/v2/dst/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/dst/blobs/uploads/":
+			q := r.URL.Query()
+			lock.Lock()
+			mountRequests = append(mountRequests, q.Get("from")+"@"+q.Get("mount"))
+			lock.Unlock()
+			if q.Get("mount") == descs[0].Digest.String() {
+				w.Header().Set("Docker-Content-Digest", descs[0].Digest.String())
+				w.WriteHeader(http.StatusCreated)
+				return
+			}
+			w.Header().Set("Location", "/v2/dst/blobs/uploads/layer")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/dst/blobs/uploads/layer":
+			dgst := digest.Digest(r.URL.Query().Get("digest"))
+			lock.Lock()
+			uploaded = append(uploaded, dgst)
+			lock.Unlock()
+			w.Header().Set("Docker-Content-Digest", dgst.String())
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/dst/manifests/"+root.Digest.String():
+			w.Header().Set("Docker-Content-Digest", root.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	newRepo := func(name string) *remote.Repository {
+		repo, err := remote.NewRepository(uri.Host + "/" + name)
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		return repo
+	}
+	src := newRepo("src")
+	dst := newRepo("dst")
+
+	ctx := context.Background()
+	var mounted []digest.Digest
+	opts := oras.CopyGraphOptions{
+		Concurrency: 1,
+		TryMount:    true,
+		OnMounted: func(ctx context.Context, desc ocispec.Descriptor) error {
+			mounted = append(mounted, desc.Digest)
+			return nil
+		},
+	}
+	if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+		t.Fatalf("CopyGraph() error = %v", err)
+	}
+	slices.Sort(mountRequests)
+	wantMountRequests := []string{
+		"src@" + descs[0].Digest.String(),
+		"src@" + descs[1].Digest.String(),
+	}
+	slices.Sort(wantMountRequests)
+	if !reflect.DeepEqual(mountRequests, wantMountRequests) {
+		t.Errorf("mount requests = %v, want %v", mountRequests, wantMountRequests)
+	}
+	if want := []digest.Digest{descs[0].Digest}; !reflect.DeepEqual(mounted, want) {
+		t.Errorf("mounted = %v, want %v", mounted, want)
+	}
+	if want := []digest.Digest{descs[1].Digest}; !reflect.DeepEqual(uploaded, want) {
+		t.Errorf("uploaded = %v, want %v", uploaded, want)
+	}
+
+	// mounting works through wrappers forwarding the location
+	mountRequests = nil
+	mounted = nil
+	uploaded = nil
+	txn := transaction.New(dst)
+	if err := oras.CopyGraph(ctx, src, txn, root, opts); err != nil {
+		t.Fatalf("CopyGraph() error = %v", err)
+	}
+	slices.Sort(mountRequests)
+	if !reflect.DeepEqual(mountRequests, wantMountRequests) {
+		t.Errorf("mount requests = %v, want %v", mountRequests, wantMountRequests)
+	}
+	if want := []digest.Digest{descs[0].Digest}; !reflect.DeepEqual(mounted, want) {
+		t.Errorf("mounted = %v, want %v", mounted, want)
+	}
+	if got := len(txn.Pushed()); got != len(descs) {
+		t.Errorf("Transaction.Pushed() = %v, want %d descriptors", txn.Pushed(), len(descs))
+	}
+}
+
+func TestCopy_DryRun(t *testing.T) {
+	src := memory.New()
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:3],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlob(ocispec.MediaTypeImageManifest, manifestJSON) // Blob 3
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, descs[3], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// the destination has the first layer only
+	dst := memory.New()
+	if err := dst.Push(ctx, descs[1], bytes.NewReader(blobs[1])); err != nil {
+		t.Fatal("failed to push test content to dst:", err)
+	}
+	var lock sync.Mutex
+	var copied, skipped []ocispec.Descriptor
+	opts := oras.CopyOptions{
+		CopyGraphOptions: oras.CopyGraphOptions{
+			DryRun: true,
+			PostCopy: func(ctx context.Context, desc ocispec.Descriptor) error {
+				lock.Lock()
+				defer lock.Unlock()
+				copied = append(copied, desc)
+				return nil
+			},
+			OnCopySkipped: func(ctx context.Context, desc ocispec.Descriptor) error {
+				lock.Lock()
+				defer lock.Unlock()
+				skipped = append(skipped, desc)
+				return nil
+			},
+		},
+	}
+	root, err := oras.Copy(ctx, src, ref, dst, "", opts)
+	if err != nil {
+		t.Fatalf("Copy() error = %v, wantErr %v", err, false)
+	}
+	if !reflect.DeepEqual(root, descs[3]) {
+		t.Errorf("Copy() = %v, want %v", root, descs[3])
+	}
+
+	// verify the transfer plan
+	if got, want := sortedDigests(copied), sortedDigests([]ocispec.Descriptor{descs[0], descs[2], descs[3]}); !slices.Equal(got, want) {
+		t.Errorf("PostCopy() nodes = %v, want %v", got, want)
+	}
+	if got, want := sortedDigests(skipped), sortedDigests(descs[1:2]); !slices.Equal(got, want) {
+		t.Errorf("OnCopySkipped() nodes = %v, want %v", got, want)
+	}
+
+	// verify nothing is written
+	for i, desc := range descs {
+		exists, err := dst.Exists(ctx, desc)
+		if err != nil {
+			t.Fatalf("dst.Exists(content[%d]) error = %v", i, err)
+		}
+		if want := i == 1; exists != want {
+			t.Errorf("dst.Exists(content[%d]) = %v, want %v", i, exists, want)
+		}
+	}
+	if _, err := dst.Resolve(ctx, ref); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("dst.Resolve() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+}
+
+func TestCopyGraph_MaxNodes(t *testing.T) {
+	src := memory.New()
+	ctx := context.Background()
+
+	// generate an index referencing a number of manifests
+	const count = 16
+	var manifests []ocispec.Descriptor
+	for i := 0; i < count; i++ {
+		manifestJSON, err := json.Marshal(ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    ocispec.DescriptorEmptyJSON,
+			Layers:    []ocispec.Descriptor{ocispec.DescriptorEmptyJSON},
+			Annotations: map[string]string{
+				"index": strconv.Itoa(i),
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+		if err := src.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+		manifests = append(manifests, desc)
+	}
+	if err := src.Push(ctx, ocispec.DescriptorEmptyJSON, bytes.NewReader(ocispec.DescriptorEmptyJSON.Data)); err != nil {
+		t.Fatal("failed to push test content to src:", err)
+	}
+	indexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, indexJSON)
+	if err := src.Push(ctx, root, bytes.NewReader(indexJSON)); err != nil {
+		t.Fatal("failed to push test content to src:", err)
+	}
+
+	// the graph consists of the index, the manifests, and the shared blob
+	const nodes = count + 2
+	tests := []struct {
+		name     string
+		maxNodes int
+		wantErr  error
+	}{
+		{
+			name:     "default limit",
+			maxNodes: 0,
+		},
+		{
+			name:     "within limit",
+			maxNodes: nodes,
+		},
+		{
+			name:     "exceeding limit",
+			maxNodes: nodes - 1,
+			wantErr:  errdef.ErrSizeExceedsLimit,
+		},
+		{
+			name:     "exceeding limit by far",
+			maxNodes: 1,
+			wantErr:  errdef.ErrSizeExceedsLimit,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := memory.New()
+			opts := oras.CopyGraphOptions{
+				MaxNodes: tt.maxNodes,
+			}
+			err := oras.CopyGraph(ctx, src, dst, root, opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CopyGraph() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			exists, err := dst.Exists(ctx, root)
+			if err != nil {
+				t.Fatal("dst.Exists() error =", err)
+			}
+			if !exists {
+				t.Errorf("dst.Exists(%v) = %v, want true", root, exists)
+			}
+		})
+	}
+
+	// the limit also applies to ContinueOnError
+	opts := oras.CopyGraphOptions{
+		MaxNodes:        nodes - 1,
+		ContinueOnError: true,
+	}
+	if err := oras.CopyGraph(ctx, src, memory.New(), root, opts); !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+		t.Errorf("CopyGraph() error = %v, wantErr %v", err, errdef.ErrSizeExceedsLimit)
+	}
+}
+
+func TestCopyGraph_SchedulingPolicy(t *testing.T) {
+	src := memory.New()
+	ctx := context.Background()
+
+	// generate independent layers of different sizes
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, content.NewDescriptorFromBytes(mediaType, blob))
+	}
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))             // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("a"), 8))  // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("b"), 2))  // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("c"), 32)) // Blob 3
+	appendBlob(ocispec.MediaTypeImageLayer, bytes.Repeat([]byte("d"), 2))  // Blob 4
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:5],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendBlob(ocispec.MediaTypeImageManifest, manifestJSON) // Blob 5
+	for i := range blobs {
+		if err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i])); err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	root := descs[5]
+
+	tests := []struct {
+		name      string
+		policy    oras.CopySchedulingPolicy
+		wantOrder []int
+	}{
+		{
+			name:      "as discovered",
+			policy:    oras.CopyScheduleAsDiscovered,
+			wantOrder: []int{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name:      "largest first",
+			policy:    oras.CopyScheduleLargestFirst,
+			wantOrder: []int{3, 1, 0, 2, 4, 5},
+		},
+		{
+			name:      "smallest first",
+			policy:    oras.CopyScheduleSmallestFirst,
+			wantOrder: []int{2, 4, 0, 1, 3, 5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var order []int
+			opts := oras.CopyGraphOptions{
+				// copy one node at a time for a deterministic order
+				Concurrency:      1,
+				SchedulingPolicy: tt.policy,
+				PreCopy: func(ctx context.Context, desc ocispec.Descriptor) error {
+					order = append(order, slices.IndexFunc(descs, func(d ocispec.Descriptor) bool {
+						return content.Equal(d, desc)
+					}))
+					return nil
+				},
+			}
+			dst := memory.New()
+			if err := oras.CopyGraph(ctx, src, dst, root, opts); err != nil {
+				t.Fatalf("CopyGraph() error = %v", err)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("copy order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/internal/docker"
+)
+
+// defaultVerifyDiffIDsMaxMetadataBytes is the maximum size of the manifest and
+// the config read by VerifyDiffIDs.
+const defaultVerifyDiffIDsMaxMetadataBytes int64 = 4 * 1024 * 1024 // 4 MiB
+
+// VerifyDiffIDs verifies that the uncompressed content of each layer of the
+// image manifest described by desc matches the corresponding diff ID listed in
+// `rootfs.diff_ids` of the image config.
+// The layers are streamed from src, and are verified against their digests
+// as well. Uncompressed, gzip compressed, and zstd compressed layers of both
+// OCI and Docker media types are supported, and foreign layers are skipped.
+//
+// Reference: https://github.com/opencontainers/image-spec/blob/v1.1.0/config.md#layer-diffid
+func VerifyDiffIDs(ctx context.Context, src content.ReadOnlyStorage, desc ocispec.Descriptor) error {
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest, docker.MediaTypeManifest:
+	default:
+		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, errdef.ErrUnsupported)
+	}
+
+	var manifest ocispec.Manifest
+	if err := fetchJSON(ctx, src, desc, &manifest); err != nil {
+		return fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	var config ocispec.Image
+	if err := fetchJSON(ctx, src, manifest.Config, &config); err != nil {
+		return fmt.Errorf("failed to fetch config: %w", err)
+	}
+	diffIDs := config.RootFS.DiffIDs
+	if len(diffIDs) != len(manifest.Layers) {
+		return fmt.Errorf("%s: %d layers mismatch %d diff IDs: %w",
+			desc.Digest, len(manifest.Layers), len(diffIDs), content.ErrMismatchedDigest)
+	}
+
+	for i, layer := range manifest.Layers {
+		if descriptor.IsForeignLayer(layer) {
+			continue
+		}
+		diffID, err := computeDiffID(ctx, src, layer, diffIDs[i].Algorithm())
+		if err != nil {
+			return fmt.Errorf("layer %d: %s: %w", i, layer.Digest, err)
+		}
+		if diffID != diffIDs[i] {
+			return fmt.Errorf("layer %d: %s: diff ID %s mismatches %s: %w",
+				i, layer.Digest, diffID, diffIDs[i], content.ErrMismatchedDigest)
+		}
+	}
+	return nil
+}
+
+// fetchJSON fetches the content described by desc from src and decodes it
+// into v.
+func fetchJSON(ctx context.Context, src content.ReadOnlyStorage, desc ocispec.Descriptor, v any) error {
+	if desc.Size > defaultVerifyDiffIDsMaxMetadataBytes {
+		return fmt.Errorf(
+			"content size %v exceeds MaxMetadataBytes %v: %w",
+			desc.Size,
+			defaultVerifyDiffIDsMaxMetadataBytes,
+			errdef.ErrSizeExceedsLimit)
+	}
+	contentBytes, err := content.FetchAll(ctx, src, desc)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(contentBytes, v); err != nil {
+		return fmt.Errorf("%s: %s: %w", desc.Digest, desc.MediaType, err)
+	}
+	return nil
+}
+
+// computeDiffID streams the layer described by desc from src, and computes
+// the digest of its uncompressed content with the algorithm alg.
+func computeDiffID(ctx context.Context, src content.ReadOnlyStorage, desc ocispec.Descriptor, alg digest.Algorithm) (digest.Digest, error) {
+	if !alg.Available() {
+		return "", fmt.Errorf("diff ID algorithm %q: %w", alg, errdef.ErrUnsupported)
+	}
+	rc, err := src.Fetch(ctx, desc)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	vr := content.NewVerifyReader(rc, desc)
+
+	var r io.Reader
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageLayer, ocispec.MediaTypeImageLayerNonDistributable, docker.MediaTypeLayerUncompressed:
+		r = vr
+	case ocispec.MediaTypeImageLayerGzip, ocispec.MediaTypeImageLayerNonDistributableGzip, docker.MediaTypeLayer:
+		zr, err := gzip.NewReader(vr)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress layer: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	case ocispec.MediaTypeImageLayerZstd, ocispec.MediaTypeImageLayerNonDistributableZstd:
+		zr, err := zstd.NewReader(vr)
+		if err != nil {
+			return "", fmt.Errorf("failed to decompress layer: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return "", fmt.Errorf("%s: %w", desc.MediaType, errdef.ErrUnsupported)
+	}
+
+	digester := alg.Digester()
+	if _, err := io.Copy(digester.Hash(), r); err != nil {
+		return "", fmt.Errorf("failed to read layer: %w", err)
+	}
+	if err := vr.Verify(); err != nil {
+		return "", err
+	}
+	return digester.Digest(), nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	_ "crypto/sha512"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/docker"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zw.Close()
+	return zw.EncodeAll(data, nil)
+}
+
+// pushImage pushes an image with the given layers and diff IDs to s, and
+// returns the descriptor of the image manifest.
+func pushImage(t *testing.T, s content.Storage, layers []ocispec.Descriptor, blobs [][]byte, diffIDs []digest.Digest) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+	push := func(desc ocispec.Descriptor, blob []byte) {
+		if err := s.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("Storage.Push() error = %v", err)
+		}
+	}
+	for i, layer := range layers {
+		push(layer, blobs[i])
+	}
+	configJSON, err := json.Marshal(ocispec.Image{
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: diffIDs,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, configJSON)
+	push(configDesc, configJSON)
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    layers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	push(manifestDesc, manifestJSON)
+	return manifestDesc
+}
+
+func TestVerifyDiffIDs(t *testing.T) {
+	ctx := context.Background()
+	foo := []byte("foo")
+	bar := []byte("bar")
+	gzippedBar := gzipBytes(t, bar)
+	layers := []ocispec.Descriptor{
+		content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, foo),
+		content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, gzippedBar),
+	}
+	blobs := [][]byte{foo, gzippedBar}
+
+	t.Run("matched diff IDs of other layer media types", func(t *testing.T) {
+		hello := []byte("hello")
+		zstdHello := zstdBytes(t, hello)
+		gzippedHello := gzipBytes(t, hello)
+		otherLayers := []ocispec.Descriptor{
+			content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerZstd, zstdHello),
+			content.NewDescriptorFromBytes(docker.MediaTypeLayerUncompressed, foo),
+			content.NewDescriptorFromBytes(docker.MediaTypeLayer, gzippedHello),
+		}
+		s := memory.New()
+		diffIDs := []digest.Digest{digest.FromBytes(hello), digest.FromBytes(foo), digest.FromBytes(hello)}
+		desc := pushImage(t, s, otherLayers, [][]byte{zstdHello, foo, gzippedHello}, diffIDs)
+		if err := VerifyDiffIDs(ctx, s, desc); err != nil {
+			t.Errorf("VerifyDiffIDs() error = %v", err)
+		}
+
+		// tampered zstd layers are detected
+		diffIDs[0] = digest.FromBytes(foo)
+		s = memory.New()
+		desc = pushImage(t, s, otherLayers, [][]byte{zstdHello, foo, gzippedHello}, diffIDs)
+		if err := VerifyDiffIDs(ctx, s, desc); !errors.Is(err, content.ErrMismatchedDigest) {
+			t.Errorf("VerifyDiffIDs() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+		}
+	})
+
+	t.Run("matched diff IDs", func(t *testing.T) {
+		s := memory.New()
+		desc := pushImage(t, s, layers, blobs, []digest.Digest{digest.FromBytes(foo), digest.FromBytes(bar)})
+		if err := VerifyDiffIDs(ctx, s, desc); err != nil {
+			t.Errorf("VerifyDiffIDs() error = %v", err)
+		}
+	})
+
+	t.Run("matched sha512 diff IDs", func(t *testing.T) {
+		s := memory.New()
+		diffIDs := []digest.Digest{digest.SHA512.FromBytes(foo), digest.SHA512.FromBytes(bar)}
+		desc := pushImage(t, s, layers, blobs, diffIDs)
+		if err := VerifyDiffIDs(ctx, s, desc); err != nil {
+			t.Errorf("VerifyDiffIDs() error = %v", err)
+		}
+
+		// mismatched sha512 diff IDs are still detected
+		diffIDs[1] = digest.SHA512.FromBytes(foo)
+		s = memory.New()
+		desc = pushImage(t, s, layers, blobs, diffIDs)
+		if err := VerifyDiffIDs(ctx, s, desc); !errors.Is(err, content.ErrMismatchedDigest) {
+			t.Errorf("VerifyDiffIDs() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+		}
+	})
+
+	t.Run("unsupported diff ID algorithm", func(t *testing.T) {
+		s := memory.New()
+		diffIDs := []digest.Digest{digest.NewDigestFromEncoded("sha1", "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"), digest.FromBytes(bar)}
+		desc := pushImage(t, s, layers, blobs, diffIDs)
+		if err := VerifyDiffIDs(ctx, s, desc); !errors.Is(err, errdef.ErrUnsupported) {
+			t.Errorf("VerifyDiffIDs() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+	})
+
+	t.Run("tampered layer", func(t *testing.T) {
+		s := memory.New()
+		// the layer is tampered before being referenced by the manifest, so
+		// that its digest is consistent but its diff ID is not
+		tampered := gzipBytes(t, []byte("baz"))
+		tamperedLayers := []ocispec.Descriptor{
+			layers[0],
+			content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayerGzip, tampered),
+		}
+		desc := pushImage(t, s, tamperedLayers, [][]byte{foo, tampered}, []digest.Digest{digest.FromBytes(foo), digest.FromBytes(bar)})
+		if err := VerifyDiffIDs(ctx, s, desc); !errors.Is(err, content.ErrMismatchedDigest) {
+			t.Errorf("VerifyDiffIDs() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+		}
+	})
+
+	t.Run("mismatched number of diff IDs", func(t *testing.T) {
+		s := memory.New()
+		desc := pushImage(t, s, layers, blobs, []digest.Digest{digest.FromBytes(foo)})
+		if err := VerifyDiffIDs(ctx, s, desc); !errors.Is(err, content.ErrMismatchedDigest) {
+			t.Errorf("VerifyDiffIDs() error = %v, wantErr %v", err, content.ErrMismatchedDigest)
+		}
+	})
+
+	t.Run("unsupported layer media type", func(t *testing.T) {
+		s := memory.New()
+		unknownLayers := []ocispec.Descriptor{
+			content.NewDescriptorFromBytes("application/vnd.test.layer", foo),
+		}
+		desc := pushImage(t, s, unknownLayers, [][]byte{foo}, []digest.Digest{digest.FromBytes(foo)})
+		if err := VerifyDiffIDs(ctx, s, desc); !errors.Is(err, errdef.ErrUnsupported) {
+			t.Errorf("VerifyDiffIDs() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+	})
+
+	t.Run("unsupported manifest media type", func(t *testing.T) {
+		desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageIndex}
+		if err := VerifyDiffIDs(ctx, memory.New(), desc); !errors.Is(err, errdef.ErrUnsupported) {
+			t.Errorf("VerifyDiffIDs() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+		}
+	})
+}
@@ -20,6 +20,7 @@ import "errors"
 // Common errors used in ORAS
 var (
 	ErrAlreadyExists      = errors.New("already exists")
+	ErrAmbiguousReference = errors.New("ambiguous reference")
 	ErrInvalidDigest      = errors.New("invalid digest")
 	ErrInvalidReference   = errors.New("invalid reference")
 	ErrInvalidMediaType   = errors.New("invalid media type")
@@ -25,7 +25,6 @@ import (
 	"golang.org/x/sync/semaphore"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/internal/cas"
-	"oras.land/oras-go/v2/internal/container/set"
 	"oras.land/oras-go/v2/internal/copyutil"
 	"oras.land/oras-go/v2/internal/descriptor"
 	"oras.land/oras-go/v2/internal/docker"
@@ -54,13 +53,34 @@ var DefaultExtendedCopyGraphOptions ExtendedCopyGraphOptions = ExtendedCopyGraph
 type ExtendedCopyGraphOptions struct {
 	CopyGraphOptions
 	// Depth limits the maximum depth of the directed acyclic graph (DAG) that
-	// will be extended-copied.
+	// will be extended-copied, counted in the predecessors (e.g. referrers)
+	// from the given node along the shortest path. For instance, a Depth of 1
+	// copies the given node with its direct predecessors, but not the
+	// predecessors of the predecessors. To copy the given node only, use
+	// [oras.Copy] or [oras.CopyGraph] instead.
 	// If Depth is no specified, or the specified value is less than or
 	// equal to 0, the depth limit will be considered as infinity.
 	Depth int
 	// FindPredecessors finds the predecessors of the current node.
 	// If FindPredecessors is nil, src.Predecessors will be adapted and used.
 	FindPredecessors func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error)
+	// TransformManifest transforms the content of a manifest before it is
+	// copied to the destination.
+	// If the transformed content differs from the original content, the
+	// manifest is copied under the digest of the transformed content, and the
+	// Subject of every referrer of the manifest is rewritten to the new digest
+	// so that the referrer graph stays intact at the destination. Likewise,
+	// the manifests listed by every index are rewritten. As a result, the
+	// digests of those referrers and indexes change as well, and so on for
+	// their own predecessors.
+	// The descriptors passed to the other hooks, such as PreCopy and
+	// PostCopy, are the descriptors of the source content.
+	// To preserve unknown fields, such as vendor extensions, manifestJSON
+	// should be modified on a generic representation like
+	// map[string]json.RawMessage, since decoding it into a typed manifest and
+	// encoding it back drops the unknown fields.
+	// If TransformManifest is nil, manifests are copied as is.
+	TransformManifest func(ctx context.Context, desc ocispec.Descriptor, manifestJSON []byte) ([]byte, error)
 }
 
 // ExtendedCopy copies the directed acyclic graph (DAG) that are reachable from
@@ -73,7 +93,9 @@ type ExtendedCopyGraphOptions struct {
 // The destination reference will be the same as the source reference if the
 // destination reference is left blank.
 //
-// Returns the descriptor of the tagged node on successful copy.
+// Returns the descriptor of the tagged node on successful copy. If the tagged
+// node is transformed by opts.TransformManifest, the descriptor of the
+// transformed node is tagged and returned.
 func ExtendedCopy(ctx context.Context, src ReadOnlyGraphTarget, srcRef string, dst Target, dstRef string, opts ExtendedCopyOptions) (ocispec.Descriptor, error) {
 	if src == nil {
 		return ocispec.Descriptor{}, errors.New("nil source graph target")
@@ -90,10 +112,14 @@ func ExtendedCopy(ctx context.Context, src ReadOnlyGraphTarget, srcRef string, d
 		return ocispec.Descriptor{}, err
 	}
 
-	if err := ExtendedCopyGraph(ctx, src, dst, node, opts.ExtendedCopyGraphOptions); err != nil {
+	node, err = extendedCopyGraph(ctx, src, dst, node, opts.ExtendedCopyGraphOptions)
+	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
 
+	if opts.DryRun {
+		return node, nil
+	}
 	if err := dst.Tag(ctx, node, dstRef); err != nil {
 		return ocispec.Descriptor{}, err
 	}
@@ -107,9 +133,17 @@ func ExtendedCopy(ctx context.Context, src ReadOnlyGraphTarget, srcRef string, d
 // predecessor manifests referencing it.
 // The node (e.g. a manifest of the artifact) is identified by a descriptor.
 func ExtendedCopyGraph(ctx context.Context, src content.ReadOnlyGraphStorage, dst content.Storage, node ocispec.Descriptor, opts ExtendedCopyGraphOptions) error {
+	_, err := extendedCopyGraph(ctx, src, dst, node, opts)
+	return err
+}
+
+// extendedCopyGraph implements ExtendedCopyGraph, and returns the descriptor of
+// the given node at the destination, which differs from the given node if the
+// node is transformed by opts.TransformManifest.
+func extendedCopyGraph(ctx context.Context, src content.ReadOnlyGraphStorage, dst content.Storage, node ocispec.Descriptor, opts ExtendedCopyGraphOptions) (ocispec.Descriptor, error) {
 	roots, err := findRoots(ctx, src, node, opts)
 	if err != nil {
-		return err
+		return ocispec.Descriptor{}, err
 	}
 
 	// if Concurrency is not set or invalid, use the default concurrency
@@ -124,9 +158,21 @@ func ExtendedCopyGraph(ctx context.Context, src content.ReadOnlyGraphStorage, ds
 	proxy := cas.NewProxyWithLimit(src, cas.NewMemory(), opts.MaxMetadataBytes)
 	// track content status
 	tracker := status.NewTracker()
+	// transform manifests on push to the destination
+	var transformer *transformStorage
+	if opts.TransformManifest != nil {
+		transformer = newTransformStorage(dst, opts.TransformManifest)
+		dst = transformer
+		if mounter, ok := transformer.Storage.(registry.Mounter); ok {
+			dst = &transformMounter{
+				transformStorage: transformer,
+				Mounter:          mounter,
+			}
+		}
+	}
 
 	// copy the sub-DAGs rooted by the root nodes
-	return syncutil.Go(ctx, limiter, func(ctx context.Context, region *syncutil.LimitedRegion, root ocispec.Descriptor) error {
+	if err := syncutil.Go(ctx, limiter, func(ctx context.Context, region *syncutil.LimitedRegion, root ocispec.Descriptor) error {
 		// As a root can be a predecessor of other roots, release the limit here
 		// for dispatching, to avoid dead locks where predecessor roots are
 		// handled first and are waiting for its successors to complete.
@@ -135,13 +181,26 @@ func ExtendedCopyGraph(ctx context.Context, src content.ReadOnlyGraphStorage, ds
 			return err
 		}
 		return region.Start()
-	}, roots...)
+	}, roots...); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if transformer != nil {
+		return transformer.mapped(node), nil
+	}
+	return node, nil
 }
 
 // findRoots finds the root nodes reachable from the given node through a
 // depth-first search.
 func findRoots(ctx context.Context, storage content.ReadOnlyGraphStorage, node ocispec.Descriptor, opts ExtendedCopyGraphOptions) ([]ocispec.Descriptor, error) {
-	visited := set.New[descriptor.Descriptor]()
+	// visited records the minimum depth at which each node is visited, as a
+	// node reachable by multiple paths must be extended from its shallowest
+	// occurrence to honor the depth limit.
+	visited := make(map[descriptor.Descriptor]int)
+	isVisited := func(key descriptor.Descriptor, depth int) bool {
+		visitedDepth, ok := visited[key]
+		return ok && (opts.Depth <= 0 || visitedDepth <= depth)
+	}
 	rootMap := make(map[descriptor.Descriptor]ocispec.Descriptor)
 	addRoot := func(key descriptor.Descriptor, val ocispec.Descriptor) {
 		if _, exists := rootMap[key]; !exists {
@@ -168,11 +227,12 @@ func findRoots(ctx context.Context, storage content.ReadOnlyGraphStorage, node o
 		currentNode := current.Node
 		currentKey := descriptor.FromOCI(currentNode)
 
-		if visited.Contains(currentKey) {
-			// skip the current node if it has been visited
+		if isVisited(currentKey, current.Depth) {
+			// skip the current node if it has been visited at the same or a
+			// shallower depth
 			continue
 		}
-		visited.Add(currentKey)
+		visited[currentKey] = current.Depth
 
 		// stop finding predecessors if the target depth is reached
 		if opts.Depth > 0 && current.Depth == opts.Depth {
@@ -192,11 +252,13 @@ func findRoots(ctx context.Context, storage content.ReadOnlyGraphStorage, node o
 			continue
 		}
 
-		// The current node has predecessor nodes, which means it is NOT a root node.
+		// The current node has predecessor nodes, which means it is NOT a root
+		// node, even if it has been a root when visited at the depth limit.
 		// Push the predecessor nodes to the stack and keep finding from there.
+		delete(rootMap, currentKey)
 		for _, predecessor := range predecessors {
 			predecessorKey := descriptor.FromOCI(predecessor)
-			if !visited.Contains(predecessorKey) {
+			if !isVisited(predecessorKey, current.Depth+1) {
 				// push the predecessor node with increased depth
 				stack.Push(copyutil.NodeInfo{Node: predecessor, Depth: current.Depth + 1})
 			}
@@ -395,3 +457,33 @@ func fetchArtifactType(ctx context.Context, src content.ReadOnlyGraphStorage, de
 		return "", nil
 	}
 }
+
+// AddAnnotations configures opts.TransformManifest to add the given
+// annotations to the image manifests, the image indexes and the artifact
+// manifests copied, overwriting the annotations with the same keys.
+// The other fields of the manifests, including unknown fields such as vendor
+// extensions, are preserved.
+//
+// If opts.TransformManifest is already set, the annotations are added to the
+// manifests transformed by it.
+func (opts *ExtendedCopyGraphOptions) AddAnnotations(annotations map[string]string) {
+	if len(annotations) == 0 {
+		return
+	}
+
+	transform := opts.TransformManifest
+	opts.TransformManifest = func(ctx context.Context, desc ocispec.Descriptor, manifestJSON []byte) ([]byte, error) {
+		if transform != nil {
+			var err error
+			if manifestJSON, err = transform(ctx, desc, manifestJSON); err != nil {
+				return nil, err
+			}
+		}
+		switch desc.MediaType {
+		case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex, spec.MediaTypeArtifactManifest:
+			return addAnnotations(manifestJSON, annotations)
+		default:
+			return manifestJSON, nil
+		}
+	}
+}
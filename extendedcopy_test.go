@@ -25,8 +25,10 @@ import (
 	"net/url"
 	"reflect"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -38,6 +40,7 @@ import (
 	"oras.land/oras-go/v2/content/memory"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/spec"
+	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
 )
 
@@ -596,6 +599,108 @@ func TestExtendedCopyGraph_WithDepthOption(t *testing.T) {
 	verifyCopy(dst, copiedIndice, uncopiedIndice)
 }
 
+func TestExtendedCopyGraph_WithDepthOption_MultiplePaths(t *testing.T) {
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(subject *ocispec.Descriptor, config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+			Subject:   subject,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	generateIndex := func(manifests ...ocispec.Descriptor) {
+		index := ocispec.Index{
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: manifests,
+		}
+		indexJSON, err := json.Marshal(index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageIndex, indexJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	generateManifest(nil, descs[0], descs[1])                  // Blob 2 (image)
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sig"))     // Blob 3
+	generateManifest(&descs[2], descs[0], descs[3])            // Blob 4 (referrer of image)
+	generateIndex(descs[2], descs[4])                          // Blob 5 (index of image and referrer)
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sbom"))    // Blob 6
+	generateManifest(&descs[5], descs[0], descs[6])            // Blob 7 (referrer of index)
+
+	ctx := context.Background()
+	src := memory.New()
+	for i := range blobs {
+		if err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i])); err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+
+	// the index is reachable from the image by a path of depth 1 via the
+	// image, and by a path of depth 2 via the referrer of the image, which is
+	// explored first as the predecessors are pushed to a stack.
+	order := map[digest.Digest]int{descs[5].Digest: 0, descs[4].Digest: 1}
+	opts := oras.ExtendedCopyGraphOptions{
+		Depth: 2,
+		FindPredecessors: func(ctx context.Context, src content.ReadOnlyGraphStorage, desc ocispec.Descriptor) ([]ocispec.Descriptor, error) {
+			predecessors, err := src.Predecessors(ctx, desc)
+			if err != nil {
+				return nil, err
+			}
+			slices.SortFunc(predecessors, func(a, b ocispec.Descriptor) int {
+				return order[a.Digest] - order[b.Digest]
+			})
+			return predecessors, nil
+		},
+	}
+	dst := memory.New()
+	if err := oras.ExtendedCopyGraph(ctx, src, dst, descs[2], opts); err != nil {
+		t.Fatalf("ExtendedCopyGraph() error = %v, wantErr %v", err, false)
+	}
+	// the referrer of the index is at depth 2 along the shortest path
+	for i := range blobs {
+		got, err := content.FetchAll(ctx, dst, descs[i])
+		if err != nil {
+			t.Errorf("content[%d] error = %v, wantErr %v", i, err, false)
+			continue
+		}
+		if want := blobs[i]; !bytes.Equal(got, want) {
+			t.Errorf("content[%d] = %v, want %v", i, got, want)
+		}
+	}
+
+	// with the depth of 1, only the image and its direct predecessors are
+	// copied
+	opts.Depth = 1
+	dst = memory.New()
+	if err := oras.ExtendedCopyGraph(ctx, src, dst, descs[2], opts); err != nil {
+		t.Fatalf("ExtendedCopyGraph() error = %v, wantErr %v", err, false)
+	}
+	if _, err := content.FetchAll(ctx, dst, descs[5]); err != nil {
+		t.Errorf("content[5] error = %v, wantErr %v", err, false)
+	}
+	if _, err := content.FetchAll(ctx, dst, descs[7]); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("content[7] error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
+
 func TestExtendedCopyGraph_WithFindPredecessorsOption(t *testing.T) {
 	// generate test content
 	var blobs [][]byte
@@ -1847,3 +1952,737 @@ func TestExtededCopy_Error(t *testing.T) {
 		}
 	})
 }
+
+func TestExtendedCopy_TransformManifest(t *testing.T) {
+	src := memory.New()
+	dst := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(subject *ocispec.Descriptor, config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Config:  config,
+			Layers:  layers,
+			Subject: subject,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+	generateArtifactManifest := func(subject ocispec.Descriptor, blobs ...ocispec.Descriptor) {
+		manifest := spec.Artifact{
+			MediaType: spec.MediaTypeArtifactManifest,
+			Subject:   &subject,
+			Blobs:     blobs,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(spec.MediaTypeArtifactManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("bar"))     // Blob 2
+	generateManifest(nil, descs[0], descs[1:3]...)             // Blob 3
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sig_1"))   // Blob 4
+	generateArtifactManifest(descs[3], descs[4])               // Blob 5
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sig_2"))   // Blob 6
+	generateArtifactManifest(descs[5], descs[6])               // Blob 7
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("baz"))     // Blob 8
+	generateManifest(&descs[3], descs[0], descs[8])            // Blob 9
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, descs[3], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// transform the subject manifest by annotating it
+	opts := oras.ExtendedCopyOptions{}
+	opts.TransformManifest = func(ctx context.Context, desc ocispec.Descriptor, manifestJSON []byte) ([]byte, error) {
+		if desc.Digest != descs[3].Digest {
+			return manifestJSON, nil
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+			return nil, err
+		}
+		manifest.Annotations = map[string]string{"transformed": "true"}
+		return json.Marshal(manifest)
+	}
+	gotDesc, err := oras.ExtendedCopy(ctx, src, ref, dst, "", opts)
+	if err != nil {
+		t.Fatalf("ExtendedCopy() error = %v, wantErr %v", err, false)
+	}
+	if gotDesc.Digest == descs[3].Digest {
+		t.Fatalf("ExtendedCopy() = %v, want transformed descriptor", gotDesc)
+	}
+	if gotDesc.MediaType != descs[3].MediaType {
+		t.Errorf("ExtendedCopy() media type = %v, want %v", gotDesc.MediaType, descs[3].MediaType)
+	}
+
+	// verify the transformed subject is tagged and the original is not copied
+	tagged, err := dst.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatal("dst.Resolve() error =", err)
+	}
+	if !reflect.DeepEqual(tagged, gotDesc) {
+		t.Errorf("dst.Resolve() = %v, want %v", tagged, gotDesc)
+	}
+	exists, err := dst.Exists(ctx, descs[3])
+	if err != nil {
+		t.Fatal("dst.Exists() error =", err)
+	}
+	if exists {
+		t.Errorf("original subject %v exists in dst", descs[3].Digest)
+	}
+	// verify the blobs are copied as is
+	for _, i := range []int{0, 1, 2, 4, 6, 8} {
+		got, err := content.FetchAll(ctx, dst, descs[i])
+		if err != nil {
+			t.Errorf("content[%d] error = %v, wantErr %v", i, err, false)
+			continue
+		}
+		if want := blobs[i]; !bytes.Equal(got, want) {
+			t.Errorf("content[%d] = %v, want %v", i, got, want)
+		}
+	}
+
+	// verify the referrers point at the transformed subject
+	subjectOf := func(desc ocispec.Descriptor) digest.Digest {
+		manifestJSON, err := content.FetchAll(ctx, dst, desc)
+		if err != nil {
+			t.Fatal("content.FetchAll() error =", err)
+		}
+		var manifest struct {
+			Subject *ocispec.Descriptor `json:"subject"`
+		}
+		if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+			t.Fatal(err)
+		}
+		if manifest.Subject == nil {
+			t.Fatalf("manifest %v has no subject", desc.Digest)
+		}
+		return manifest.Subject.Digest
+	}
+	referrers, err := dst.Predecessors(ctx, gotDesc)
+	if err != nil {
+		t.Fatal("dst.Predecessors() error =", err)
+	}
+	if len(referrers) != 2 {
+		t.Fatalf("dst.Predecessors() = %v, want 2 referrers", referrers)
+	}
+	var artifact ocispec.Descriptor
+	for _, referrer := range referrers {
+		if referrer.Digest == descs[5].Digest || referrer.Digest == descs[9].Digest {
+			t.Errorf("referrer %v is not rewritten", referrer.Digest)
+		}
+		if got := subjectOf(referrer); got != gotDesc.Digest {
+			t.Errorf("referrer %v subject = %v, want %v", referrer.Digest, got, gotDesc.Digest)
+		}
+		if referrer.MediaType == spec.MediaTypeArtifactManifest {
+			artifact = referrer
+		}
+	}
+
+	// verify the referrer of the rewritten referrer is rewritten as well
+	referrers, err = dst.Predecessors(ctx, artifact)
+	if err != nil {
+		t.Fatal("dst.Predecessors() error =", err)
+	}
+	if len(referrers) != 1 {
+		t.Fatalf("dst.Predecessors() = %v, want 1 referrer", referrers)
+	}
+	if referrers[0].Digest == descs[7].Digest {
+		t.Errorf("referrer %v is not rewritten", referrers[0].Digest)
+	}
+	if got := subjectOf(referrers[0]); got != artifact.Digest {
+		t.Errorf("referrer %v subject = %v, want %v", referrers[0].Digest, got, artifact.Digest)
+	}
+}
+
+func TestExtendedCopyGraphOptions_AddAnnotations(t *testing.T) {
+	src := memory.New()
+	dst := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	descJSON := func(desc ocispec.Descriptor) string {
+		b, err := json.Marshal(desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	extension := `{"vendor":"a<b>&c","nested":{"list":[1,2,3]}}`
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))     // Blob 1
+	appendBlob(ocispec.MediaTypeImageManifest, []byte(`{"schemaVersion":2,"mediaType":"`+ocispec.MediaTypeImageManifest+
+		`","config":`+descJSON(descs[0])+`,"layers":[`+descJSON(descs[1])+`],"annotations":{"foo":"bar"},"vnd.example.extension":`+extension+`}`)) // Blob 2
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sig")) // Blob 3
+	appendBlob(spec.MediaTypeArtifactManifest, []byte(`{"mediaType":"`+spec.MediaTypeArtifactManifest+
+		`","blobs":[`+descJSON(descs[3])+`],"subject":`+descJSON(descs[2])+`,"vnd.example.extension":`+extension+`}`)) // Blob 4
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, descs[2], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	opts := oras.DefaultExtendedCopyOptions
+	opts.AddAnnotations(map[string]string{"hello": "world"})
+	got, err := oras.ExtendedCopy(ctx, src, ref, dst, ref, opts)
+	if err != nil {
+		t.Fatalf("ExtendedCopy() error = %v", err)
+	}
+	if got.Digest == descs[2].Digest {
+		t.Fatal("ExtendedCopy() returned the source root, want the transformed root")
+	}
+
+	// verify the fields of the manifests
+	verifyFields := func(desc ocispec.Descriptor) map[string]json.RawMessage {
+		t.Helper()
+		manifestJSON, err := content.FetchAll(ctx, dst, desc)
+		if err != nil {
+			t.Fatalf("failed to fetch %s: %v", desc.Digest, err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(manifestJSON, &fields); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(fields["vnd.example.extension"]); got != extension {
+			t.Errorf("extension field = %s, want %s", got, extension)
+		}
+		var annotations map[string]string
+		if err := json.Unmarshal(fields["annotations"], &annotations); err != nil {
+			t.Fatal(err)
+		}
+		if got := annotations["hello"]; got != "world" {
+			t.Errorf("annotation hello = %q, want %q", got, "world")
+		}
+		return fields
+	}
+	rootFields := verifyFields(got)
+	if got := string(rootFields["layers"]); got != "["+descJSON(descs[1])+"]" {
+		t.Errorf("layers field = %s, want %s", got, "["+descJSON(descs[1])+"]")
+	}
+	var rootAnnotations map[string]string
+	if err := json.Unmarshal(rootFields["annotations"], &rootAnnotations); err != nil {
+		t.Fatal(err)
+	}
+	if got := rootAnnotations["foo"]; got != "bar" {
+		t.Errorf("annotation foo = %q, want %q", got, "bar")
+	}
+
+	referrers, err := dst.Predecessors(ctx, got)
+	if err != nil {
+		t.Fatal("Predecessors() error =", err)
+	}
+	if len(referrers) != 1 {
+		t.Fatalf("Predecessors() = %v, want 1 referrer", referrers)
+	}
+	referrerFields := verifyFields(referrers[0])
+	var subject ocispec.Descriptor
+	if err := json.Unmarshal(referrerFields["subject"], &subject); err != nil {
+		t.Fatal(err)
+	}
+	if subject.Digest != got.Digest {
+		t.Errorf("subject digest = %s, want %s", subject.Digest, got.Digest)
+	}
+}
+
+func TestExtendedCopy_ImageManifestReferrers_ReferrersTagSchema(t *testing.T) {
+	src := memory.New()
+
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(subject *ocispec.Descriptor, artifactType string, annotations map[string]string, config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2,
+			},
+			MediaType:    ocispec.MediaTypeImageManifest,
+			ArtifactType: artifactType,
+			Config:       config,
+			Layers:       layers,
+			Subject:      subject,
+			Annotations:  annotations,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))                           // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))                               // Blob 1
+	generateManifest(nil, "", nil, descs[0], descs[1])                                   // Blob 2
+	appendBlob("application/vnd.test.signature.config", []byte("sig_conf"))              // Blob 3
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sig"))                               // Blob 4
+	generateManifest(&descs[2], "", map[string]string{"foo": "bar"}, descs[3], descs[4]) // Blob 5
+	appendBlob(ocispec.MediaTypeEmptyJSON, []byte("{}"))                                 // Blob 6
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sbom"))                              // Blob 7
+	generateManifest(&descs[2], "application/vnd.test.sbom", nil, descs[6], descs[7])    // Blob 8
+
+	ctx := context.Background()
+	for i := range blobs {
+		err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i]))
+		if err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, descs[2], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+
+	// a stateful registry without the Referrers API
+	var lock sync.Mutex
+	manifests := map[string][]byte{}
+	manifestTypes := map[string]string{}
+	dstBlobs := map[string][]byte{}
+	uploadPath := "/v2/test/blobs/uploads/upload"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/test/manifests/"):
+			ref := strings.TrimPrefix(r.URL.Path, "/v2/test/manifests/")
+			switch r.Method {
+			case http.MethodPut:
+				buf := bytes.NewBuffer(nil)
+				if _, err := buf.ReadFrom(r.Body); err != nil {
+					t.Errorf("fail to read: %v", err)
+				}
+				dgst := digest.FromBytes(buf.Bytes())
+				for _, key := range []string{dgst.String(), ref} {
+					manifests[key] = buf.Bytes()
+					manifestTypes[key] = r.Header.Get("Content-Type")
+				}
+				w.Header().Set("Docker-Content-Digest", dgst.String())
+				w.WriteHeader(http.StatusCreated)
+			case http.MethodGet, http.MethodHead:
+				manifestJSON, ok := manifests[ref]
+				if !ok {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", manifestTypes[ref])
+				w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifestJSON).String())
+				w.Header().Set("Content-Length", strconv.Itoa(len(manifestJSON)))
+				if r.Method == http.MethodGet {
+					w.Write(manifestJSON)
+				}
+			case http.MethodDelete:
+				delete(manifests, ref)
+				w.WriteHeader(http.StatusAccepted)
+			default:
+				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", uploadPath)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && r.URL.Path == uploadPath:
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			dstBlobs[r.URL.Query().Get("digest")] = buf.Bytes()
+			w.WriteHeader(http.StatusCreated)
+		case (r.Method == http.MethodHead || r.Method == http.MethodGet) && strings.HasPrefix(r.URL.Path, "/v2/test/blobs/"):
+			blob, ok := dstBlobs[strings.TrimPrefix(r.URL.Path, "/v2/test/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(blob)))
+			if r.Method == http.MethodGet {
+				w.Write(blob)
+			}
+		case strings.HasPrefix(r.URL.Path, "/v2/test/referrers/"):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	dst, err := remote.NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	dst.PlainHTTP = true
+
+	if _, err := oras.ExtendedCopy(ctx, src, ref, dst, ref, oras.DefaultExtendedCopyOptions); err != nil {
+		t.Fatalf("ExtendedCopy() error = %v", err)
+	}
+
+	// verify the fallback referrers index at the destination
+	referrersTag := strings.Replace(descs[2].Digest.String(), ":", "-", 1)
+	lock.Lock()
+	indexJSON, ok := manifests[referrersTag]
+	lock.Unlock()
+	if !ok {
+		t.Fatalf("referrers index tagged by %s is not pushed", referrersTag)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		t.Fatal("failed to decode referrers index", err)
+	}
+	want := map[digest.Digest]ocispec.Descriptor{
+		descs[5].Digest: {
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Digest:       descs[5].Digest,
+			Size:         descs[5].Size,
+			ArtifactType: descs[3].MediaType, // derived from the config
+			Annotations:  map[string]string{"foo": "bar"},
+		},
+		descs[8].Digest: {
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Digest:       descs[8].Digest,
+			Size:         descs[8].Size,
+			ArtifactType: "application/vnd.test.sbom",
+		},
+	}
+	if len(index.Manifests) != len(want) {
+		t.Fatalf("referrers index entries = %v, want %v", index.Manifests, want)
+	}
+	for _, got := range index.Manifests {
+		if !reflect.DeepEqual(got, want[got.Digest]) {
+			t.Errorf("referrers index entry = %v, want %v", got, want[got.Digest])
+		}
+	}
+
+	// verify the referrers listed from the destination
+	referrers, err := registry.Referrers(ctx, dst, descs[2], "")
+	if err != nil {
+		t.Fatal("Referrers() error =", err)
+	}
+	if len(referrers) != len(want) {
+		t.Fatalf("Referrers() = %v, want %v", referrers, want)
+	}
+	for _, got := range referrers {
+		if !reflect.DeepEqual(got, want[got.Digest]) {
+			t.Errorf("Referrers() entry = %v, want %v", got, want[got.Digest])
+		}
+	}
+}
+
+// testReferrersRegistry is a stateful registry serving the repository "test",
+// optionally with the Referrers API.
+type testReferrersRegistry struct {
+	t            *testing.T
+	referrersAPI bool
+
+	lock          sync.Mutex
+	manifests     map[string][]byte
+	manifestTypes map[string]string
+	blobs         map[string][]byte
+	pushedTags    []string
+}
+
+func newTestReferrersRegistry(t *testing.T, referrersAPI bool) *testReferrersRegistry {
+	return &testReferrersRegistry{
+		t:             t,
+		referrersAPI:  referrersAPI,
+		manifests:     map[string][]byte{},
+		manifestTypes: map[string]string{},
+		blobs:         map[string][]byte{},
+	}
+}
+
+func (reg *testReferrersRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t := reg.t
+	reg.lock.Lock()
+	defer reg.lock.Unlock()
+	uploadPath := "/v2/test/blobs/uploads/upload"
+	switch {
+	case strings.HasPrefix(r.URL.Path, "/v2/test/manifests/"):
+		ref := strings.TrimPrefix(r.URL.Path, "/v2/test/manifests/")
+		switch r.Method {
+		case http.MethodPut:
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			dgst := digest.FromBytes(buf.Bytes())
+			if ref != dgst.String() {
+				reg.pushedTags = append(reg.pushedTags, ref)
+			}
+			for _, key := range []string{dgst.String(), ref} {
+				reg.manifests[key] = buf.Bytes()
+				reg.manifestTypes[key] = r.Header.Get("Content-Type")
+			}
+			if reg.referrersAPI {
+				var manifest struct {
+					Subject *ocispec.Descriptor `json:"subject"`
+				}
+				if err := json.Unmarshal(buf.Bytes(), &manifest); err != nil {
+					t.Errorf("fail to decode manifest: %v", err)
+				}
+				if manifest.Subject != nil {
+					w.Header().Set("OCI-Subject", manifest.Subject.Digest.String())
+				}
+			}
+			w.Header().Set("Docker-Content-Digest", dgst.String())
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet, http.MethodHead:
+			manifestJSON, ok := reg.manifests[ref]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", reg.manifestTypes[ref])
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifestJSON).String())
+			w.Header().Set("Content-Length", strconv.Itoa(len(manifestJSON)))
+			if r.Method == http.MethodGet {
+				w.Write(manifestJSON)
+			}
+		case http.MethodDelete:
+			delete(reg.manifests, ref)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+		w.Header().Set("Location", uploadPath)
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodPut && r.URL.Path == uploadPath:
+		buf := bytes.NewBuffer(nil)
+		if _, err := buf.ReadFrom(r.Body); err != nil {
+			t.Errorf("fail to read: %v", err)
+		}
+		reg.blobs[r.URL.Query().Get("digest")] = buf.Bytes()
+		w.WriteHeader(http.StatusCreated)
+	case (r.Method == http.MethodHead || r.Method == http.MethodGet) && strings.HasPrefix(r.URL.Path, "/v2/test/blobs/"):
+		blob, ok := reg.blobs[strings.TrimPrefix(r.URL.Path, "/v2/test/blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(blob)))
+		if r.Method == http.MethodGet {
+			w.Write(blob)
+		}
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/test/referrers/"):
+		if !reg.referrersAPI {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		subject := strings.TrimPrefix(r.URL.Path, "/v2/test/referrers/")
+		referrers := []ocispec.Descriptor{}
+		for key, manifestJSON := range reg.manifests {
+			if key != digest.FromBytes(manifestJSON).String() {
+				continue
+			}
+			var manifest ocispec.Manifest
+			if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+				t.Errorf("fail to decode manifest: %v", err)
+			}
+			if manifest.Subject == nil || manifest.Subject.Digest.String() != subject {
+				continue
+			}
+			artifactType := manifest.ArtifactType
+			if artifactType == "" {
+				artifactType = manifest.Config.MediaType
+			}
+			referrers = append(referrers, ocispec.Descriptor{
+				MediaType:    reg.manifestTypes[key],
+				Digest:       digest.Digest(key),
+				Size:         int64(len(manifestJSON)),
+				ArtifactType: artifactType,
+				Annotations:  manifest.Annotations,
+			})
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		if err := json.NewEncoder(w).Encode(ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2,
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+		}); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	default:
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestExtendedCopy_ReferrersTagSchemaToReferrersAPI(t *testing.T) {
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
+	}
+	generateManifest := func(subject *ocispec.Descriptor, artifactType string, annotations map[string]string, config ocispec.Descriptor, layers ...ocispec.Descriptor) {
+		manifest := ocispec.Manifest{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2,
+			},
+			MediaType:    ocispec.MediaTypeImageManifest,
+			ArtifactType: artifactType,
+			Config:       config,
+			Layers:       layers,
+			Subject:      subject,
+			Annotations:  annotations,
+		}
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		appendBlob(ocispec.MediaTypeImageManifest, manifestJSON)
+	}
+
+	appendBlob(ocispec.MediaTypeImageConfig, []byte("config"))                           // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("foo"))                               // Blob 1
+	generateManifest(nil, "", nil, descs[0], descs[1])                                   // Blob 2
+	appendBlob("application/vnd.test.signature.config", []byte("sig_conf"))              // Blob 3
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sig"))                               // Blob 4
+	generateManifest(&descs[2], "", map[string]string{"foo": "bar"}, descs[3], descs[4]) // Blob 5
+	appendBlob(ocispec.MediaTypeEmptyJSON, []byte("{}"))                                 // Blob 6
+	appendBlob(ocispec.MediaTypeImageLayer, []byte("sbom"))                              // Blob 7
+	generateManifest(&descs[2], "application/vnd.test.sbom", nil, descs[6], descs[7])    // Blob 8
+
+	newRepository := func(reg *testReferrersRegistry) *remote.Repository {
+		ts := httptest.NewServer(reg)
+		t.Cleanup(ts.Close)
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+		repo, err := remote.NewRepository(uri.Host + "/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		return repo
+	}
+	srcRegistry := newTestReferrersRegistry(t, false)
+	src := newRepository(srcRegistry)
+	dstRegistry := newTestReferrersRegistry(t, true)
+	dst := newRepository(dstRegistry)
+
+	// populate the source with the referrers tag schema
+	ctx := context.Background()
+	for i := range blobs {
+		if err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i])); err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
+	}
+	ref := "foobar"
+	if err := src.Tag(ctx, descs[2], ref); err != nil {
+		t.Fatal("fail to tag root node", err)
+	}
+	referrersTag := strings.Replace(descs[2].Digest.String(), ":", "-", 1)
+	if _, ok := srcRegistry.manifests[referrersTag]; !ok {
+		t.Fatalf("referrers index tagged by %s is not pushed to src", referrersTag)
+	}
+
+	if _, err := oras.ExtendedCopy(ctx, src, ref, dst, ref, oras.DefaultExtendedCopyOptions); err != nil {
+		t.Fatalf("ExtendedCopy() error = %v", err)
+	}
+
+	// verify the content copied to the destination
+	dstRegistry.lock.Lock()
+	for i, desc := range descs {
+		if _, ok := dstRegistry.blobs[desc.Digest.String()]; ok {
+			continue
+		}
+		if _, ok := dstRegistry.manifests[desc.Digest.String()]; !ok {
+			t.Errorf("Blob %d is not copied to dst", i)
+		}
+	}
+	// no fallback referrers index is created at the destination
+	if want := []string{ref}; !reflect.DeepEqual(dstRegistry.pushedTags, want) {
+		t.Errorf("tags pushed to dst = %v, want %v", dstRegistry.pushedTags, want)
+	}
+	dstRegistry.lock.Unlock()
+
+	// verify the referrers listed from the destination
+	want := map[digest.Digest]ocispec.Descriptor{
+		descs[5].Digest: {
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Digest:       descs[5].Digest,
+			Size:         descs[5].Size,
+			ArtifactType: descs[3].MediaType, // derived from the config
+			Annotations:  map[string]string{"foo": "bar"},
+		},
+		descs[8].Digest: {
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Digest:       descs[8].Digest,
+			Size:         descs[8].Size,
+			ArtifactType: "application/vnd.test.sbom",
+		},
+	}
+	referrers, err := registry.Referrers(ctx, dst, descs[2], "")
+	if err != nil {
+		t.Fatal("Referrers() error =", err)
+	}
+	if len(referrers) != len(want) {
+		t.Fatalf("Referrers() = %v, want %v", referrers, want)
+	}
+	for _, got := range referrers {
+		if !reflect.DeepEqual(got, want[got.Digest]) {
+			t.Errorf("Referrers() entry = %v, want %v", got, want[got.Digest])
+		}
+	}
+}
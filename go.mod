@@ -3,6 +3,7 @@ module oras.land/oras-go/v2
 go 1.22
 
 require (
+	github.com/klauspost/compress v1.18.0
 	github.com/opencontainers/go-digest v1.0.0
 	github.com/opencontainers/image-spec v1.1.0
 	golang.org/x/sync v0.10.0
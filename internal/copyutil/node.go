@@ -0,0 +1,111 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package copyutil
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/descriptor"
+)
+
+// NodeStatus describes how a node is transferred by CopyNode.
+type NodeStatus int
+
+const (
+	// NodeExists indicates that the node is found existing on push.
+	NodeExists NodeStatus = iota
+	// NodeMounted indicates that the node is mounted.
+	NodeMounted
+	// NodeCopied indicates that the node is fetched and pushed.
+	NodeCopied
+)
+
+// Pusher pushes content.
+type Pusher interface {
+	Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error
+}
+
+// Mounter has the same method set as registry.Mounter, which cannot be
+// referenced from this package.
+type Mounter interface {
+	Mount(ctx context.Context, desc ocispec.Descriptor, fromRepo string, getContent func() (io.ReadCloser, error)) error
+}
+
+// CopyNode transfers a single node desc to dst without checking its existence.
+// If mountFrom is set, desc is a blob, and dst is a Mounter, desc is mounted
+// from the candidate repositories returned by mountFrom in order. Otherwise,
+// or if none of the candidates can be mounted, the content returned by fetch
+// is pushed to dst. fetch is invoked at most once.
+func CopyNode(ctx context.Context, dst Pusher, desc ocispec.Descriptor, mountFrom func(ctx context.Context, desc ocispec.Descriptor) ([]string, error), fetch func(ctx context.Context) (io.ReadCloser, error)) (NodeStatus, error) {
+	if m, ok := dst.(Mounter); ok && mountFrom != nil && !descriptor.IsManifest(desc) {
+		sourceRepositories, err := mountFrom(ctx, desc)
+		if err != nil {
+			return 0, err
+		}
+		if len(sourceRepositories) > 0 {
+			return mountNode(ctx, m, desc, sourceRepositories, fetch)
+		}
+	}
+	return pushNode(ctx, dst, desc, fetch)
+}
+
+// mountNode tries mounting desc from each of the source repositories in order,
+// and falls back to pushing the content returned by fetch on the last one.
+func mountNode(ctx context.Context, dst Mounter, desc ocispec.Descriptor, sourceRepositories []string, fetch func(ctx context.Context) (io.ReadCloser, error)) (NodeStatus, error) {
+	skipSource := errors.New("skip source")
+	for i, sourceRepository := range sourceRepositories {
+		var mountFailed bool
+		getContent := func() (io.ReadCloser, error) {
+			// the invocation of getContent indicates that mounting has failed
+			mountFailed = true
+			if i < len(sourceRepositories)-1 {
+				// not the last candidate, try the next one
+				return nil, skipSource
+			}
+			return fetch(ctx)
+		}
+		if err := dst.Mount(ctx, desc, sourceRepository, getContent); err != nil && !errors.Is(err, skipSource) {
+			return 0, err
+		}
+		if !mountFailed {
+			return NodeMounted, nil
+		}
+	}
+	return NodeCopied, nil
+}
+
+// pushNode pushes the content returned by fetch to dst.
+func pushNode(ctx context.Context, dst Pusher, desc ocispec.Descriptor, fetch func(ctx context.Context) (io.ReadCloser, error)) (NodeStatus, error) {
+	rc, err := fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	status := NodeCopied
+	err = dst.Push(ctx, desc, rc)
+	if errors.Is(err, errdef.ErrAlreadyExists) {
+		status = NodeExists
+		err = nil
+	}
+	// close explicitly to surface the error of closing the content
+	if err := errors.Join(err, rc.Close()); err != nil {
+		return 0, err
+	}
+	return status, nil
+}
@@ -16,6 +16,8 @@ limitations under the License.
 package descriptor
 
 import (
+	"strings"
+
 	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2/internal/docker"
@@ -87,3 +89,25 @@ func Plain(desc ocispec.Descriptor) ocispec.Descriptor {
 		Size:      desc.Size,
 	}
 }
+
+// ParseDigestPrefix parses a reference in the form of
+// `<algorithm>:<encoded prefix>`, where the encoded prefix is a non-empty
+// proper prefix of an encoded digest of the algorithm.
+// ok is false if the reference is not a digest prefix, including the case
+// that the reference is a full digest.
+func ParseDigestPrefix(reference string) (alg digest.Algorithm, prefix string, ok bool) {
+	algStr, prefix, found := strings.Cut(reference, ":")
+	if !found || prefix == "" {
+		return "", "", false
+	}
+	alg = digest.Algorithm(algStr)
+	if !alg.Available() || len(prefix) >= alg.Size()*2 {
+		return "", "", false
+	}
+	for _, c := range prefix {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", "", false
+		}
+	}
+	return alg, prefix, true
+}
@@ -17,8 +17,14 @@ package docker
 
 // docker media types
 const (
-	MediaTypeConfig       = "application/vnd.docker.container.image.v1+json"
-	MediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
-	MediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
-	MediaTypeForeignLayer = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+	MediaTypeConfig            = "application/vnd.docker.container.image.v1+json"
+	MediaTypeManifestList      = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeManifest          = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeForeignLayer      = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+	MediaTypeLayer             = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	MediaTypeLayerUncompressed = "application/vnd.docker.image.rootfs.diff.tar"
+
+	// deprecated schema 1 media types
+	MediaTypeManifestSchema1       = "application/vnd.docker.distribution.manifest.v1+json"
+	MediaTypeManifestSchema1Signed = "application/vnd.docker.distribution.manifest.v1+prettyjws"
 )
@@ -24,6 +24,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"oras.land/oras-go/v2/errdef"
 )
@@ -106,6 +108,34 @@ func (tfs *TarFS) Stat(name string) (fs.FileInfo, error) {
 	return entry.header.FileInfo(), nil
 }
 
+// ReadDir reads the named directory and returns a list of directory entries
+// sorted by filename.
+// A directory is considered existing if it is presented in the tar archive, or
+// if any entry of the tar archive is under it.
+func (tfs *TarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	dir, found := tfs.entries[name]
+	if found && dir.header.Typeflag != tar.TypeDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	var entries []fs.DirEntry
+	for entryPath, e := range tfs.entries {
+		if entryPath == name || path.Dir(entryPath) != name {
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(e.header.FileInfo()))
+	}
+	if !found && len(entries) == 0 {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, nil
+}
+
 // getEntry returns the named entry.
 func (tfs *TarFS) getEntry(operation string, path string) (*entry, error) {
 	if !fs.ValidPath(path) {
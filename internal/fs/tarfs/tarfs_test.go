@@ -21,6 +21,7 @@ import (
 	"io"
 	"io/fs"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"oras.land/oras-go/v2/errdef"
@@ -421,3 +422,49 @@ func TestTarFs_New_Error(t *testing.T) {
 		}
 	})
 }
+
+func TestTarFS_ReadDir(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{".", []string{"dir", "foobar", "foobar_link", "foobar_symlink"}},
+		{"dir", []string{"hello", "subdir"}},
+		{"dir/subdir", []string{"world"}},
+	}
+	tarPaths := []string{
+		"testdata/cleaned_path.tar",
+		"testdata/prefixed_path.tar",
+	}
+	for _, tarPath := range tarPaths {
+		tfs, err := New(tarPath)
+		if err != nil {
+			t.Fatalf("New() error = %v, wantErr %v", err, nil)
+		}
+		for _, tt := range tests {
+			t.Run(tarPath+": "+tt.name, func(t *testing.T) {
+				entries, err := fs.ReadDir(tfs, tt.name)
+				if err != nil {
+					t.Fatalf("fs.ReadDir() error = %v", err)
+				}
+				var got []string
+				for _, entry := range entries {
+					got = append(got, entry.Name())
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("fs.ReadDir() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+
+		if _, err := tfs.ReadDir("foobar"); err == nil {
+			t.Error("TarFS.ReadDir() error = nil, wantErr true")
+		}
+		if _, err := tfs.ReadDir("nonexistent"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("TarFS.ReadDir() error = %v, wantErr %v", err, fs.ErrNotExist)
+		}
+		if _, err := tfs.ReadDir("../dir"); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("TarFS.ReadDir() error = %v, wantErr %v", err, fs.ErrInvalid)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimeoutReadCloser reads the HTTP response body, and cancels the request
+// if a single read receives no data within the idle timeout.
+type idleTimeoutReadCloser struct {
+	rc       io.ReadCloser
+	timeout  time.Duration
+	cancel   context.CancelFunc
+	timer    *time.Timer
+	timedOut atomic.Bool
+}
+
+// NewIdleTimeoutReadCloser returns a reader of the response body rc, which
+// calls cancel to abort the request if a read is blocked for longer than
+// timeout without receiving any data. The timeout is reset on each read, and
+// the time between reads is not counted.
+// cancel is also called on close, and must cancel the context of the request
+// of rc.
+// If rc implements io.Seeker, the returned reader also implements io.Seeker.
+func NewIdleTimeoutReadCloser(rc io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) io.ReadCloser {
+	r := &idleTimeoutReadCloser{
+		rc:      rc,
+		timeout: timeout,
+		cancel:  cancel,
+	}
+	if seeker, ok := rc.(io.Seeker); ok {
+		return &idleTimeoutReadSeekCloser{
+			idleTimeoutReadCloser: r,
+			seeker:                seeker,
+		}
+	}
+	return r
+}
+
+// Read reads from the response body, and fails with an error wrapping
+// context.DeadlineExceeded if no data is received within the idle timeout.
+func (r *idleTimeoutReadCloser) Read(p []byte) (int, error) {
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.timeout, r.expire)
+	} else {
+		r.timer.Reset(r.timeout)
+	}
+	n, err := r.rc.Read(p)
+	r.timer.Stop()
+	if err != nil && r.timedOut.Load() {
+		return n, fmt.Errorf("no data received within %v: %w", r.timeout, context.DeadlineExceeded)
+	}
+	return n, err
+}
+
+// Close stops the idle timer, and closes the response body.
+func (r *idleTimeoutReadCloser) Close() error {
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	defer r.cancel()
+	return r.rc.Close()
+}
+
+// expire aborts the request on idle timeout.
+func (r *idleTimeoutReadCloser) expire() {
+	r.timedOut.Store(true)
+	r.cancel()
+}
+
+// idleTimeoutReadSeekCloser is an idleTimeoutReadCloser supporting seeking.
+type idleTimeoutReadSeekCloser struct {
+	*idleTimeoutReadCloser
+	seeker io.Seeker
+}
+
+// Seek seeks the underlying response body.
+func (r *idleTimeoutReadSeekCloser) Seek(offset int64, whence int) (int64, error) {
+	return r.seeker.Seek(offset, whence)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_idleTimeoutReadCloser_Read(t *testing.T) {
+	pr, pw := io.Pipe()
+	var canceled atomic.Bool
+	cancel := func() {
+		canceled.Store(true)
+		pw.CloseWithError(context.Canceled)
+	}
+	rc := NewIdleTimeoutReadCloser(pr, 50*time.Millisecond, cancel)
+
+	// data arriving within the idle timeout, with a slow consumer
+	go func() {
+		for _, chunk := range []string{"hello", " ", "world"} {
+			time.Sleep(20 * time.Millisecond)
+			if _, err := pw.Write([]byte(chunk)); err != nil {
+				return
+			}
+		}
+	}()
+	buf := make([]byte, 32)
+	var got []byte
+	for len(got) < len("hello world") {
+		n, err := rc.Read(buf)
+		if err != nil {
+			t.Fatalf("idleTimeoutReadCloser.Read() error = %v", err)
+		}
+		got = append(got, buf[:n]...)
+		time.Sleep(60 * time.Millisecond) // time between reads is not counted
+	}
+	if want := []byte("hello world"); !bytes.Equal(got, want) {
+		t.Errorf("idleTimeoutReadCloser.Read() = %s, want %s", got, want)
+	}
+	if canceled.Load() {
+		t.Fatal("idleTimeoutReadCloser canceled the request before idle timeout")
+	}
+
+	// no data arriving within the idle timeout
+	_, err := rc.Read(buf)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("idleTimeoutReadCloser.Read() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !canceled.Load() {
+		t.Error("idleTimeoutReadCloser did not cancel the request on idle timeout")
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+}
+
+func TestNewIdleTimeoutReadCloser_Seeker(t *testing.T) {
+	rc := NewIdleTimeoutReadCloser(io.NopCloser(strings.NewReader("foo")), time.Second, func() {})
+	if _, ok := rc.(io.Seeker); ok {
+		t.Error("NewIdleTimeoutReadCloser() implements io.Seeker for non-seekable reader")
+	}
+	rsc := struct {
+		io.ReadSeeker
+		io.Closer
+	}{strings.NewReader("foo"), io.NopCloser(nil)}
+	rc = NewIdleTimeoutReadCloser(rsc, time.Second, func() {})
+	seeker, ok := rc.(io.Seeker)
+	if !ok {
+		t.Fatal("NewIdleTimeoutReadCloser() does not implement io.Seeker for seekable reader")
+	}
+	if _, err := seeker.Seek(1, io.SeekStart); err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if want := "oo"; string(got) != want {
+		t.Errorf("ReadAll() = %s, want %s", got, want)
+	}
+}
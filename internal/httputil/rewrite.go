@@ -0,0 +1,64 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// maxRedirects is the maximum number of redirects followed by default, which
+// is consistent with the default policy of http.Client.
+const maxRedirects = 10
+
+// urlRewriteContextKey is the context key for the URL rewrite function.
+type urlRewriteContextKey struct{}
+
+// WithURLRewrite returns a context carrying the function rewriting the
+// redirect targets of the requests sent with the context.
+func WithURLRewrite(ctx context.Context, rewrite func(u *url.URL) *url.URL) context.Context {
+	return context.WithValue(ctx, urlRewriteContextKey{}, rewrite)
+}
+
+// URLRewriteFromContext returns the URL rewrite function carried by ctx, or
+// nil if ctx does not carry any.
+func URLRewriteFromContext(ctx context.Context) func(u *url.URL) *url.URL {
+	rewrite, _ := ctx.Value(urlRewriteContextKey{}).(func(u *url.URL) *url.URL)
+	return rewrite
+}
+
+// ClientWithURLRewrite returns a shallow copy of client, which rewrites the
+// redirect targets with rewrite before following them. The redirect policy of
+// client, if any, is applied on the rewritten targets.
+func ClientWithURLRewrite(client *http.Client, rewrite func(u *url.URL) *url.URL) *http.Client {
+	c := *client
+	checkRedirect := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if u := rewrite(req.URL); u != nil {
+			req.URL = u
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &c
+}
@@ -0,0 +1,82 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestClientWithURLRewrite(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "http://unreachable.invalid/target", http.StatusTemporaryRedirect)
+		case "/target":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	rewrite := func(u *url.URL) *url.URL {
+		if u.Host != "unreachable.invalid" {
+			return nil
+		}
+		rewritten := *u
+		rewritten.Host = uri.Host
+		return &rewritten
+	}
+
+	var checked []string
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			checked = append(checked, req.URL.Host)
+			return nil
+		},
+	}
+	resp, err := ClientWithURLRewrite(client, rewrite).Get(ts.URL + "/redirect")
+	if err != nil {
+		t.Fatalf("Client.Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Client.Get() status code = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+	if want := uri.Host; len(checked) != 1 || checked[0] != want {
+		t.Errorf("CheckRedirect() hosts = %v, want [%v]", checked, want)
+	}
+}
+
+func TestURLRewriteFromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := URLRewriteFromContext(ctx); got != nil {
+		t.Error("URLRewriteFromContext() = non-nil, want nil")
+	}
+	ctx = WithURLRewrite(ctx, func(u *url.URL) *url.URL {
+		return u
+	})
+	if got := URLRewriteFromContext(ctx); got == nil {
+		t.Error("URLRewriteFromContext() = nil, want non-nil")
+	}
+}
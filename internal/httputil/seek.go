@@ -51,14 +51,14 @@ func NewReadSeekCloser(client Client, req *http.Request, respBody io.ReadCloser,
 	}
 }
 
-// Read reads the content body and counts offset.
+// Read reads the content body, counts offset, and checks for truncation.
 func (rsc *readSeekCloser) Read(p []byte) (n int, err error) {
 	if rsc.closed {
 		return 0, errors.New("read: already closed")
 	}
 	n, err = rsc.rc.Read(p)
 	rsc.offset += int64(n)
-	return
+	return n, checkTruncation(rsc.req, err, rsc.offset, rsc.size)
 }
 
 // Seek starts a new connection to the remote for reading if position changes.
@@ -0,0 +1,65 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// sizeCheckReadCloser reads the HTTP response body, and fails if the body
+// ends before the expected size is read.
+type sizeCheckReadCloser struct {
+	req  *http.Request
+	rc   io.ReadCloser
+	size int64
+	read int64
+}
+
+// NewSizeCheckReadCloser returns a reader of the response body for req, which
+// fails with an error wrapping io.ErrUnexpectedEOF if the body ends before size
+// bytes are read, e.g. when the connection is cut in the middle of the
+// transfer.
+func NewSizeCheckReadCloser(req *http.Request, respBody io.ReadCloser, size int64) io.ReadCloser {
+	return &sizeCheckReadCloser{
+		req:  req,
+		rc:   respBody,
+		size: size,
+	}
+}
+
+// Read reads the content body and checks for truncation.
+func (r *sizeCheckReadCloser) Read(p []byte) (n int, err error) {
+	n, err = r.rc.Read(p)
+	r.read += int64(n)
+	return n, checkTruncation(r.req, err, r.read, r.size)
+}
+
+// Close closes the content body.
+func (r *sizeCheckReadCloser) Close() error {
+	return r.rc.Close()
+}
+
+// checkTruncation converts err returned by reading a response body to a
+// truncation error if the body ends after read bytes, before size bytes.
+func checkTruncation(req *http.Request, err error, read, size int64) error {
+	if read >= size || (err != io.EOF && !errors.Is(err, io.ErrUnexpectedEOF)) {
+		return err
+	}
+	return fmt.Errorf("%s %q: response body truncated: read %d of %d bytes: %w", req.Method, req.URL, read, size, io.ErrUnexpectedEOF)
+}
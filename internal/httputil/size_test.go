@@ -0,0 +1,55 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httputil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func Test_sizeCheckReadCloser_Read(t *testing.T) {
+	content := []byte("hello world")
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/testpath", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	// complete body
+	rc := NewSizeCheckReadCloser(req, io.NopCloser(bytes.NewReader(content)), int64(len(content)))
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("sizeCheckReadCloser.Read() error = %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("sizeCheckReadCloser.Read() = %v, want %v", got, content)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+
+	// truncated body
+	rc = NewSizeCheckReadCloser(req, io.NopCloser(bytes.NewReader(content[:5])), int64(len(content)))
+	got, err = io.ReadAll(rc)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("sizeCheckReadCloser.Read() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if want := content[:5]; !bytes.Equal(got, want) {
+		t.Errorf("sizeCheckReadCloser.Read() = %v, want %v", got, want)
+	}
+}
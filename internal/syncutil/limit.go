@@ -69,12 +69,13 @@ type GoFunc[T any] func(ctx context.Context, region *LimitedRegion, t T) error
 // Go concurrently invokes fn on items.
 func Go[T any](ctx context.Context, limiter *semaphore.Weighted, fn GoFunc[T], items ...T) error {
 	eg, egCtx := errgroup.WithContext(ctx)
-	var egErr atomic.Value
+	// errors of different concrete types may be stored
+	var egErr atomic.Pointer[error]
 	for _, item := range items {
 		region := LimitRegion(egCtx, limiter)
 		if err := region.Start(); err != nil {
-			if egErr, ok := egErr.Load().(error); ok && egErr != nil {
-				return egErr
+			if egErr := egErr.Load(); egErr != nil {
+				return *egErr
 			}
 			return err
 		}
@@ -83,7 +84,7 @@ func Go[T any](ctx context.Context, limiter *semaphore.Weighted, fn GoFunc[T], i
 				defer region.End()
 				err := fn(egCtx, region, t)
 				if err != nil {
-					egErr.CompareAndSwap(nil, err)
+					egErr.CompareAndSwap(nil, &err)
 					return err
 				}
 				return nil
@@ -47,9 +47,10 @@ const (
 )
 
 var (
-	// ErrInvalidDateTimeFormat is returned by [Pack] and [PackManifest] when
-	// "org.opencontainers.artifact.created" or "org.opencontainers.image.created"
-	// is provided, but its value is not in RFC 3339 format.
+	// ErrInvalidDateTimeFormat is returned by [Pack], [PackManifest] and
+	// [PackIndex] when "org.opencontainers.artifact.created" or
+	// "org.opencontainers.image.created" is provided, but its value is not in
+	// RFC 3339 format.
 	// Reference: https://www.rfc-editor.org/rfc/rfc3339#section-5.6
 	ErrInvalidDateTimeFormat = errors.New("invalid date and time format")
 
@@ -147,6 +148,64 @@ func PackManifest(ctx context.Context, pusher content.Pusher, packManifestVersio
 	}
 }
 
+// PackIndexOptions contains optional parameters for [PackIndex].
+type PackIndexOptions struct {
+	// Subject is the subject of the index.
+	Subject *ocispec.Descriptor
+
+	// IndexAnnotations is the annotation map of the index. In order to
+	// make [PackIndex] reproducible, set the key ocispec.AnnotationCreated
+	// (i.e. "org.opencontainers.image.created") to a fixed value. The value
+	// must conform to RFC 3339.
+	IndexAnnotations map[string]string
+}
+
+// PackIndex generates an OCI Image Index defined in image-spec v1.1.0 based on
+// the given parameters, and pushes the packed index to a content storage using
+// pusher. The manifests referenced by the index are expected to exist in the
+// storage.
+//
+// artifactType is optional, and MUST comply with RFC 6838 if not empty. The
+// returned descriptor carries artifactType, so that the packed index can be
+// listed as a referrer of opts.Subject.
+//
+// Each time when PackIndex is called, if a time stamp is not specified, a new
+// time stamp is generated in the index annotations with the key
+// ocispec.AnnotationCreated (i.e. "org.opencontainers.image.created"). To make
+// [PackIndex] reproducible, set the key ocispec.AnnotationCreated to a fixed
+// value in opts.IndexAnnotations. The value MUST conform to RFC 3339.
+//
+// If succeeded, returns a descriptor of the packed index.
+//
+// Reference: https://github.com/opencontainers/image-spec/blob/v1.1.0/image-index.md
+func PackIndex(ctx context.Context, pusher content.Pusher, artifactType string, manifests []ocispec.Descriptor, opts PackIndexOptions) (ocispec.Descriptor, error) {
+	if artifactType != "" {
+		if err := validateMediaType(artifactType); err != nil {
+			return ocispec.Descriptor{}, fmt.Errorf("invalid artifactType format: %w", err)
+		}
+	}
+	annotations, err := ensureAnnotationCreated(opts.IndexAnnotations, ocispec.AnnotationCreated)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if manifests == nil {
+		// the manifests field is required
+		manifests = []ocispec.Descriptor{}
+	}
+
+	index := ocispec.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType:    ocispec.MediaTypeImageIndex,
+		ArtifactType: artifactType,
+		Manifests:    manifests,
+		Subject:      opts.Subject,
+		Annotations:  annotations,
+	}
+	return pushManifest(ctx, pusher, index, index.MediaType, index.ArtifactType, index.Annotations)
+}
+
 // PackOptions contains optional parameters for [Pack].
 //
 // Deprecated: This type is deprecated and not recommended for future use.
@@ -1090,3 +1090,121 @@ func Test_PackManifest_UnsupportedPackManifestVersion(t *testing.T) {
 		t.Errorf("Oras.PackManifest() error = %v, wantErr = %v", err, wantErr)
 	}
 }
+
+func Test_PackIndex(t *testing.T) {
+	s := memory.New()
+	ctx := context.Background()
+
+	// prepare test content
+	manifestDesc, err := PackManifest(ctx, s, PackManifestVersion1_1, "application/vnd.test.manifest", PackManifestOptions{})
+	if err != nil {
+		t.Fatal("Oras.PackManifest() error =", err)
+	}
+	subjectManifest := []byte(`{"layers":[]}`)
+	subjectDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(subjectManifest),
+		Size:      int64(len(subjectManifest)),
+	}
+	if err := s.Push(ctx, subjectDesc, bytes.NewReader(subjectManifest)); err != nil {
+		t.Fatal("Store.Push() error =", err)
+	}
+	annotations := map[string]string{
+		ocispec.AnnotationCreated: "2000-01-01T00:00:00Z",
+		"foo":                     "bar",
+	}
+	artifactType := "application/vnd.test"
+
+	// test PackIndex
+	opts := PackIndexOptions{
+		Subject:          &subjectDesc,
+		IndexAnnotations: annotations,
+	}
+	indexDesc, err := PackIndex(ctx, s, artifactType, []ocispec.Descriptor{manifestDesc}, opts)
+	if err != nil {
+		t.Fatal("Oras.PackIndex() error =", err)
+	}
+
+	expectedIndex := ocispec.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType:    ocispec.MediaTypeImageIndex,
+		ArtifactType: artifactType,
+		Manifests:    []ocispec.Descriptor{manifestDesc},
+		Subject:      &subjectDesc,
+		Annotations:  annotations,
+	}
+	expectedIndexBytes, err := json.Marshal(expectedIndex)
+	if err != nil {
+		t.Fatal("failed to marshal index:", err)
+	}
+	got, err := content.FetchAll(ctx, s, indexDesc)
+	if err != nil {
+		t.Fatal("Store.Fetch() error =", err)
+	}
+	if !bytes.Equal(got, expectedIndexBytes) {
+		t.Errorf("Store.Fetch() = %v, want %v", string(got), string(expectedIndexBytes))
+	}
+
+	// verify descriptor
+	expectedIndexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, expectedIndexBytes)
+	expectedIndexDesc.ArtifactType = artifactType
+	expectedIndexDesc.Annotations = annotations
+	if !reflect.DeepEqual(indexDesc, expectedIndexDesc) {
+		t.Errorf("PackIndex() = %v, want %v", indexDesc, expectedIndexDesc)
+	}
+
+	// verify the index is listed as a referrer of the subject
+	predecessors, err := s.Predecessors(ctx, subjectDesc)
+	if err != nil {
+		t.Fatal("Store.Predecessors() error =", err)
+	}
+	if want := []ocispec.Descriptor{indexDesc}; !reflect.DeepEqual(predecessors, want) {
+		t.Errorf("Store.Predecessors() = %v, want %v", predecessors, want)
+	}
+}
+
+func Test_PackIndex_NoOption(t *testing.T) {
+	s := memory.New()
+
+	ctx := context.Background()
+	indexDesc, err := PackIndex(ctx, s, "", nil, PackIndexOptions{})
+	if err != nil {
+		t.Fatal("Oras.PackIndex() error =", err)
+	}
+	var index ocispec.Index
+	got, err := content.FetchAll(ctx, s, indexDesc)
+	if err != nil {
+		t.Fatal("Store.Fetch() error =", err)
+	}
+	if err := json.Unmarshal(got, &index); err != nil {
+		t.Fatal("error decoding index, error =", err)
+	}
+	if index.Manifests == nil || len(index.Manifests) != 0 {
+		t.Errorf("got manifests = %v, want empty", index.Manifests)
+	}
+	if index.ArtifactType != "" || indexDesc.ArtifactType != "" {
+		t.Errorf("got artifactType = %q, want empty", index.ArtifactType)
+	}
+	if _, err := time.Parse(time.RFC3339, index.Annotations[ocispec.AnnotationCreated]); err != nil {
+		t.Errorf("error parsing AnnotationCreated, error = %v", err)
+	}
+}
+
+func Test_PackIndex_InvalidOptions(t *testing.T) {
+	s := memory.New()
+
+	ctx := context.Background()
+	if _, err := PackIndex(ctx, s, "random", nil, PackIndexOptions{}); !errors.Is(err, errdef.ErrInvalidMediaType) {
+		t.Errorf("Oras.PackIndex() error = %v, wantErr = %v", err, errdef.ErrInvalidMediaType)
+	}
+	opts := PackIndexOptions{
+		IndexAnnotations: map[string]string{
+			ocispec.AnnotationCreated: "2000/01/01 00:00:00",
+		},
+	}
+	if _, err := PackIndex(ctx, s, "", nil, opts); !errors.Is(err, ErrInvalidDateTimeFormat) {
+		t.Errorf("Oras.PackIndex() error = %v, wantErr = %v", err, ErrInvalidDateTimeFormat)
+	}
+}
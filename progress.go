@@ -0,0 +1,102 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"io"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// progressReader reports the number of bytes read from the underlying reader
+// to CopyGraphOptions.OnProgress, throttled by
+// CopyGraphOptions.ProgressInterval.
+type progressReader struct {
+	io.ReadCloser
+	desc       ocispec.Descriptor
+	onProgress func(desc ocispec.Descriptor, copied, total int64)
+	interval   time.Duration
+	lastReport time.Time
+	copied     int64
+	reported   int64
+	done       bool
+}
+
+// newProgressReader wraps rc to report the progress of reading desc.
+// rc is returned as is if opts.OnProgress is not set.
+func newProgressReader(rc io.ReadCloser, desc ocispec.Descriptor, opts CopyGraphOptions) io.ReadCloser {
+	if opts.OnProgress == nil {
+		return rc
+	}
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = defaultProgressInterval
+	}
+	return &progressReader{
+		ReadCloser: rc,
+		desc:       desc,
+		onProgress: opts.OnProgress,
+		interval:   interval,
+		lastReport: time.Now(),
+	}
+}
+
+// Read reads from the underlying reader and reports the progress if the
+// interval has elapsed, or the final progress if the end of the content is
+// reached.
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.copied += int64(n)
+	switch {
+	case err == io.EOF:
+		r.finish()
+	case r.copied > r.reported && time.Since(r.lastReport) >= r.interval:
+		r.report()
+	}
+	return n, err
+}
+
+// Close reports the final progress, if not yet reported, and closes the
+// underlying reader.
+func (r *progressReader) Close() error {
+	r.finish()
+	return r.ReadCloser.Close()
+}
+
+// finish reports the final progress once.
+func (r *progressReader) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+	r.report()
+}
+
+// report reports the number of bytes read so far.
+func (r *progressReader) report() {
+	r.reported = r.copied
+	r.lastReport = time.Now()
+	r.onProgress(r.desc, r.copied, r.desc.Size)
+}
+
+// reportCompleted reports the final progress of desc, which is completed
+// without being read, to opts.OnProgress, if set.
+func reportCompleted(desc ocispec.Descriptor, opts CopyGraphOptions) {
+	if opts.OnProgress != nil {
+		opts.OnProgress(desc, desc.Size, desc.Size)
+	}
+}
@@ -44,6 +44,13 @@ var (
 	tagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
 )
 
+// maxNameLength is the maximum length of the name, i.e. the concatenation of
+// the registry, `/`, and the repository, which is imposed by many registries
+// and clients.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pulling-manifests
+const maxNameLength = 255
+
 // Reference references either a resource descriptor (where Reference.Reference
 // is a tag or a digest), or a resource repository (where Reference.Reference
 // is the empty string).
@@ -192,6 +199,11 @@ func (r Reference) Validate() error {
 
 // ValidateRegistry validates the registry.
 func (r Reference) ValidateRegistry() error {
+	if strings.Contains(r.Registry, "@") {
+		// the registry is not included in the error message to avoid leaking
+		// the embedded credentials
+		return fmt.Errorf("%w: registry must not contain user info", errdef.ErrInvalidReference)
+	}
 	if uri, err := url.ParseRequestURI("dummy://" + r.Registry); err != nil || uri.Host == "" || uri.Host != r.Registry {
 		return fmt.Errorf("%w: invalid registry %q", errdef.ErrInvalidReference, r.Registry)
 	}
@@ -206,6 +218,21 @@ func (r Reference) ValidateRepository() error {
 	return nil
 }
 
+// ValidateName validates the repository as [Reference.ValidateRepository]
+// does, and additionally enforces the limit of 255 characters on the length of
+// the name, i.e. the concatenation of the registry, `/`, and the repository.
+// ValidateName is stricter than the validation applied by [ParseReference],
+// and can be used to reject names that registries would reject later.
+func (r Reference) ValidateName() error {
+	if err := r.ValidateRepository(); err != nil {
+		return err
+	}
+	if n := len(r.Registry) + 1 + len(r.Repository); n > maxNameLength {
+		return fmt.Errorf("%w: name %q exceeds %d characters", errdef.ErrInvalidReference, r.Registry+"/"+r.Repository, maxNameLength)
+	}
+	return nil
+}
+
 // ValidateReferenceAsTag validates the reference as a tag.
 func (r Reference) ValidateReferenceAsTag() error {
 	if !tagRegexp.MatchString(r.Reference) {
@@ -244,6 +271,21 @@ func (r Reference) Host() string {
 	return r.Registry
 }
 
+// NormalizeDockerHub returns the reference with the namespace of the Docker Hub
+// official images normalized. That is, a single-segment repository on the
+// registry "docker.io" is prefixed with "library/". For example,
+// "docker.io/ubuntu" is normalized to "docker.io/library/ubuntu".
+// Other references are returned as is.
+//
+// Note: ParseReference does not normalize references. NormalizeDockerHub needs
+// to be called explicitly on the parsed reference for normalization.
+func (r Reference) NormalizeDockerHub() Reference {
+	if r.Registry == "docker.io" && r.Repository != "" && !strings.Contains(r.Repository, "/") {
+		r.Repository = "library/" + r.Repository
+	}
+	return r
+}
+
 // ReferenceOrDefault returns the reference or the default reference if empty.
 func (r Reference) ReferenceOrDefault() string {
 	if r.Reference == "" {
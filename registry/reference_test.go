@@ -17,9 +17,13 @@ package registry
 
 import (
 	_ "crypto/sha256"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+
+	"oras.land/oras-go/v2/errdef"
 )
 
 const ValidDigest = "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
@@ -137,6 +141,10 @@ func TestParseReferenceUglies(t *testing.T) {
 			name: "invalid digest prefix: double at sign",
 			raw:  fmt.Sprintf("registry.example.com/hello-world@@%s", ValidDigest),
 		},
+		{
+			name: "embedded credentials",
+			raw:  "user:pass@registry/repo:tag",
+		},
 		{
 			name: "invalid digest prefix: space",
 			raw:  fmt.Sprintf("registry.example.com/hello-world @%s", ValidDigest),
@@ -153,6 +161,25 @@ func TestParseReferenceUglies(t *testing.T) {
 	}
 }
 
+func TestParseReference_UserInfo(t *testing.T) {
+	for _, raw := range []string{
+		"user:pass@registry.example.com/hello-world:linux",
+		"user:pass@registry.example.com/hello-world",
+		fmt.Sprintf("user:pass@registry.example.com/hello-world@%s", ValidDigest),
+		"user@localhost:5000/hello-world:linux",
+	} {
+		t.Run(raw, func(t *testing.T) {
+			_, err := ParseReference(raw)
+			if !errors.Is(err, errdef.ErrInvalidReference) {
+				t.Fatalf("ParseReference() error = %v, want %v", err, errdef.ErrInvalidReference)
+			}
+			if msg := err.Error(); strings.Contains(msg, "pass") || strings.Contains(msg, "user@") || strings.Contains(msg, "user:") {
+				t.Errorf("ParseReference() error = %q, leaks credentials", msg)
+			}
+		})
+	}
+}
+
 func TestReference_Validate(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -232,6 +259,67 @@ func TestReference_Validate(t *testing.T) {
 	}
 }
 
+func TestReference_ValidateName(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference Reference
+		wantErr   bool
+	}{
+		{
+			name: "valid name",
+			reference: Reference{
+				Registry:   "registry.example.com",
+				Repository: "foo/hello-world",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid name at the length limit",
+			reference: Reference{
+				Registry:   "registry.example.com",
+				Repository: strings.Repeat("a", 255-len("registry.example.com/")),
+			},
+			wantErr: false,
+		},
+		{
+			name: "uppercase repository",
+			reference: Reference{
+				Registry:   "registry.example.com",
+				Repository: "Hello-World",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty path component",
+			reference: Reference{
+				Registry:   "registry.example.com",
+				Repository: "foo//bar",
+			},
+			wantErr: true,
+		},
+		{
+			name: "name exceeding the length limit",
+			reference: Reference{
+				Registry:   "registry.example.com",
+				Repository: "foo/" + strings.Repeat("a", 255),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.reference.ValidateName()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reference.ValidateName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, errdef.ErrInvalidReference) {
+				t.Errorf("Reference.ValidateName() error = %v, want %v", err, errdef.ErrInvalidReference)
+			}
+		})
+	}
+}
+
 func TestReference_Host(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -261,6 +349,55 @@ func TestReference_Host(t *testing.T) {
 		})
 	}
 }
+func TestReference_NormalizeDockerHub(t *testing.T) {
+	tests := []struct {
+		name      string
+		reference string
+		want      string
+	}{
+		{
+			name:      "official image",
+			reference: "docker.io/ubuntu",
+			want:      "docker.io/library/ubuntu",
+		},
+		{
+			name:      "official image with tag",
+			reference: "docker.io/ubuntu:24.04",
+			want:      "docker.io/library/ubuntu:24.04",
+		},
+		{
+			name:      "official image with library namespace",
+			reference: "docker.io/library/ubuntu",
+			want:      "docker.io/library/ubuntu",
+		},
+		{
+			name:      "image in organization",
+			reference: "docker.io/org/app",
+			want:      "docker.io/org/app",
+		},
+		{
+			name:      "other registry",
+			reference: "registry.example.com/ubuntu",
+			want:      "registry.example.com/ubuntu",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := ParseReference(tt.reference)
+			if err != nil {
+				t.Fatalf("ParseReference() error = %v", err)
+			}
+			if got := ref.String(); got != tt.reference {
+				t.Errorf("ParseReference() = %v, want %v", got, tt.reference)
+			}
+			if got := ref.NormalizeDockerHub().String(); got != tt.want {
+				t.Errorf("Reference.NormalizeDockerHub() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestReference_ReferenceOrDefault(t *testing.T) {
 	tests := []struct {
 		name      string
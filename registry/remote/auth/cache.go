@@ -16,9 +16,11 @@ limitations under the License.
 package auth
 
 import (
+	"container/list"
 	"context"
 	"strings"
 	"sync"
+	"time"
 
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/syncutil"
@@ -31,6 +33,8 @@ var DefaultCache Cache = NewCache()
 // accessing the remote registry.
 // Precisely, the header is `Authorization: auth-scheme auth-token`.
 // The `auth-token` is a generic term as `token68` in RFC 7235 section 2.1.
+// Cache can be implemented by callers, for instance, to share the tokens
+// across processes with an external store.
 type Cache interface {
 	// GetScheme returns the auth-scheme part cached for the given registry.
 	// A single registry is assumed to have a consistent scheme.
@@ -70,6 +74,36 @@ func NewCache() Cache {
 	return &concurrentCache{}
 }
 
+// CacheOptions contains parameters for NewCacheWithOptions.
+type CacheOptions struct {
+	// MaxTokens limits the number of cached tokens across all registries.
+	// When the limit is reached, the least recently used token is evicted.
+	// If less than or equal to 0, the number of cached tokens is unlimited.
+	MaxTokens int
+
+	// TTL is the time-to-live of the cached tokens, after which they are
+	// evicted on access.
+	// If less than or equal to 0, the cached tokens never expire.
+	TTL time.Duration
+}
+
+// NewCacheWithOptions creates a new go-routine safe cache instance bounded by
+// the given options.
+// NewCacheWithOptions is equivalent to NewCache if the options are zero.
+func NewCacheWithOptions(opts CacheOptions) Cache {
+	if opts.MaxTokens <= 0 && opts.TTL <= 0 {
+		return NewCache()
+	}
+	return &lruCache{
+		maxTokens: opts.MaxTokens,
+		ttl:       opts.TTL,
+		now:       time.Now,
+		schemes:   make(map[string]Scheme),
+		tokens:    make(map[lruCacheKey]*list.Element),
+		lru:       list.New(),
+	}
+}
+
 // GetScheme returns the auth-scheme part cached for the given registry.
 func (cc *concurrentCache) GetScheme(ctx context.Context, registry string) (Scheme, error) {
 	entry, ok := cc.cache.Load(registry)
@@ -140,6 +174,134 @@ func (cc *concurrentCache) Set(ctx context.Context, registry string, scheme Sche
 	return token, nil
 }
 
+// lruCacheKey is the key of a token cached in lruCache.
+type lruCacheKey struct {
+	registry string
+	scheme   Scheme
+	key      string
+}
+
+// lruCacheEntry is a token cached in lruCache.
+type lruCacheEntry struct {
+	key    lruCacheKey
+	token  string
+	expiry time.Time
+}
+
+// lruCache is a cache suitable for concurrent invocation, which bounds the
+// number of cached tokens with a least recently used eviction policy, and
+// expires the cached tokens after a TTL.
+type lruCache struct {
+	maxTokens int
+	ttl       time.Duration
+	now       func() time.Time
+	status    sync.Map // map[string]*syncutil.Once
+
+	lock    sync.Mutex
+	schemes map[string]Scheme
+	tokens  map[lruCacheKey]*list.Element
+	lru     *list.List // list of *lruCacheEntry, most recently used first
+}
+
+// GetScheme returns the auth-scheme part cached for the given registry.
+func (lc *lruCache) GetScheme(ctx context.Context, registry string) (Scheme, error) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	scheme, ok := lc.schemes[registry]
+	if !ok {
+		return SchemeUnknown, errdef.ErrNotFound
+	}
+	return scheme, nil
+}
+
+// GetToken returns the auth-token part cached for the given registry of a given
+// scheme.
+func (lc *lruCache) GetToken(ctx context.Context, registry string, scheme Scheme, key string) (string, error) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	if lc.schemes[registry] != scheme {
+		return "", errdef.ErrNotFound
+	}
+	elem, ok := lc.tokens[lruCacheKey{registry: registry, scheme: scheme, key: key}]
+	if !ok {
+		return "", errdef.ErrNotFound
+	}
+	entry := elem.Value.(*lruCacheEntry)
+	if lc.ttl > 0 && !lc.now().Before(entry.expiry) {
+		lc.remove(elem)
+		return "", errdef.ErrNotFound
+	}
+	lc.lru.MoveToFront(elem)
+	return entry.token, nil
+}
+
+// Set fetches the token using the given fetch function and caches the token
+// for the given scheme with the given key for the given registry.
+// Set combines the fetch operation if the Set is invoked multiple times at the
+// same time.
+func (lc *lruCache) Set(ctx context.Context, registry string, scheme Scheme, key string, fetch func(context.Context) (string, error)) (string, error) {
+	// fetch token
+	statusKey := strings.Join([]string{
+		registry,
+		scheme.String(),
+		key,
+	}, " ")
+	statusValue, _ := lc.status.LoadOrStore(statusKey, syncutil.NewOnce())
+	fetchOnce := statusValue.(*syncutil.Once)
+	fetchedFirst, result, err := fetchOnce.Do(ctx, func() (interface{}, error) {
+		return fetch(ctx)
+	})
+	if fetchedFirst {
+		lc.status.Delete(statusKey)
+	}
+	if err != nil {
+		return "", err
+	}
+	token := result.(string)
+	if !fetchedFirst {
+		return token, nil
+	}
+
+	// cache token
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+	if cachedScheme, ok := lc.schemes[registry]; ok && cachedScheme != scheme {
+		// there is a scheme change, which is not expected in most scenarios.
+		// force invalidating all previous cache of the registry.
+		for elem := lc.lru.Front(); elem != nil; {
+			next := elem.Next()
+			if elem.Value.(*lruCacheEntry).key.registry == registry {
+				lc.remove(elem)
+			}
+			elem = next
+		}
+	}
+	lc.schemes[registry] = scheme
+	cacheKey := lruCacheKey{registry: registry, scheme: scheme, key: key}
+	entry := &lruCacheEntry{
+		key:    cacheKey,
+		token:  token,
+		expiry: lc.now().Add(lc.ttl),
+	}
+	if elem, ok := lc.tokens[cacheKey]; ok {
+		elem.Value = entry
+		lc.lru.MoveToFront(elem)
+	} else {
+		lc.tokens[cacheKey] = lc.lru.PushFront(entry)
+	}
+	for lc.maxTokens > 0 && lc.lru.Len() > lc.maxTokens {
+		lc.remove(lc.lru.Back())
+	}
+	return token, nil
+}
+
+// remove removes the given element from the cache.
+// The caller must hold lc.lock.
+func (lc *lruCache) remove(elem *list.Element) {
+	lc.lru.Remove(elem)
+	delete(lc.tokens, elem.Value.(*lruCacheEntry).key)
+}
+
 // noCache is a cache implementation that does not do cache at all.
 type noCache struct{}
 
@@ -230,3 +392,50 @@ func NewSingleContextCache() Cache {
 		secondary: &hostCache{cache},
 	}
 }
+
+// AnonymousCache remembers the scopes that are accessible anonymously per
+// registry (i.e. host:port), so that the requests for those scopes can be
+// sent without resolving any authentication.
+// AnonymousCache is safe for concurrent use.
+type AnonymousCache struct {
+	lock    sync.RWMutex
+	entries map[string]struct{}
+}
+
+// NewAnonymousCache creates an empty AnonymousCache.
+func NewAnonymousCache() *AnonymousCache {
+	return &AnonymousCache{
+		entries: make(map[string]struct{}),
+	}
+}
+
+// Contains returns true if the given scopes of the given registry are known
+// to be accessible anonymously.
+func (ac *AnonymousCache) Contains(registry string, scopes []string) bool {
+	ac.lock.RLock()
+	defer ac.lock.RUnlock()
+	_, ok := ac.entries[anonymousCacheKey(registry, scopes)]
+	return ok
+}
+
+// Add remembers that the given scopes of the given registry are accessible
+// anonymously.
+func (ac *AnonymousCache) Add(registry string, scopes []string) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+	ac.entries[anonymousCacheKey(registry, scopes)] = struct{}{}
+}
+
+// Invalidate forgets that the given scopes of the given registry are
+// accessible anonymously.
+func (ac *AnonymousCache) Invalidate(registry string, scopes []string) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+	delete(ac.entries, anonymousCacheKey(registry, scopes))
+}
+
+// anonymousCacheKey returns the key of the given scopes of the given registry
+// in AnonymousCache.
+func anonymousCacheKey(registry string, scopes []string) string {
+	return registry + " " + strings.Join(CleanScopes(scopes), " ")
+}
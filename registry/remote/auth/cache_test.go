@@ -541,6 +541,122 @@ func Test_concurrentCache_Set_Fetch_Failure(t *testing.T) {
 	}
 }
 
+func TestNewCacheWithOptions(t *testing.T) {
+	if _, ok := NewCacheWithOptions(CacheOptions{}).(*concurrentCache); !ok {
+		t.Error("NewCacheWithOptions() with zero options is not the default cache")
+	}
+	if _, ok := NewCacheWithOptions(CacheOptions{MaxTokens: 1}).(*lruCache); !ok {
+		t.Error("NewCacheWithOptions() with MaxTokens is not a bounded cache")
+	}
+}
+
+func Test_lruCache_MaxTokens(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCacheWithOptions(CacheOptions{MaxTokens: 2})
+	registry := "localhost:5000"
+	set := func(key string) {
+		t.Helper()
+		if _, err := cache.Set(ctx, registry, SchemeBearer, key, func(context.Context) (string, error) {
+			return "token-" + key, nil
+		}); err != nil {
+			t.Fatalf("lruCache.Set() error = %v", err)
+		}
+	}
+	get := func(key string) (string, error) {
+		return cache.GetToken(ctx, registry, SchemeBearer, key)
+	}
+
+	set("foo")
+	set("bar")
+	// access foo so that bar becomes the least recently used
+	if got, err := get("foo"); err != nil || got != "token-foo" {
+		t.Fatalf("lruCache.GetToken(foo) = (%v, %v), want (%v, nil)", got, err, "token-foo")
+	}
+	set("hello")
+	if _, err := get("bar"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("lruCache.GetToken(bar) error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	for _, key := range []string{"foo", "hello"} {
+		if got, err := get(key); err != nil || got != "token-"+key {
+			t.Errorf("lruCache.GetToken(%s) = (%v, %v), want (%v, nil)", key, got, err, "token-"+key)
+		}
+	}
+	if got := cache.(*lruCache).lru.Len(); got != 2 {
+		t.Errorf("cached tokens = %v, want 2", got)
+	}
+
+	// the scheme is kept after eviction
+	if got, err := cache.GetScheme(ctx, registry); err != nil || got != SchemeBearer {
+		t.Errorf("lruCache.GetScheme() = (%v, %v), want (%v, nil)", got, err, SchemeBearer)
+	}
+
+	// scheme change invalidates the previous tokens of the registry
+	if _, err := cache.Set(ctx, registry, SchemeBasic, "", func(context.Context) (string, error) {
+		return "basic", nil
+	}); err != nil {
+		t.Fatalf("lruCache.Set() error = %v", err)
+	}
+	if got, err := cache.GetScheme(ctx, registry); err != nil || got != SchemeBasic {
+		t.Errorf("lruCache.GetScheme() = (%v, %v), want (%v, nil)", got, err, SchemeBasic)
+	}
+	if _, err := get("foo"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("lruCache.GetToken(foo) error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	if got := cache.(*lruCache).lru.Len(); got != 1 {
+		t.Errorf("cached tokens = %v, want 1", got)
+	}
+}
+
+func Test_lruCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCacheWithOptions(CacheOptions{TTL: time.Minute}).(*lruCache)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	registry := "localhost:5000"
+
+	if _, err := cache.Set(ctx, registry, SchemeBearer, "foo", func(context.Context) (string, error) {
+		return "token", nil
+	}); err != nil {
+		t.Fatalf("lruCache.Set() error = %v", err)
+	}
+	now = now.Add(30 * time.Second)
+	if got, err := cache.GetToken(ctx, registry, SchemeBearer, "foo"); err != nil || got != "token" {
+		t.Errorf("lruCache.GetToken() = (%v, %v), want (%v, nil)", got, err, "token")
+	}
+	now = now.Add(30 * time.Second)
+	if _, err := cache.GetToken(ctx, registry, SchemeBearer, "foo"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("lruCache.GetToken() error = %v, want %v", err, errdef.ErrNotFound)
+	}
+	if got := cache.lru.Len(); got != 0 {
+		t.Errorf("cached tokens = %v, want 0", got)
+	}
+}
+
+func Test_lruCache_Set_Fetch_Once(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCacheWithOptions(CacheOptions{MaxTokens: 10})
+	var fetchCount int64
+	fetch := func(context.Context) (string, error) {
+		atomic.AddInt64(&fetchCount, 1)
+		time.Sleep(50 * time.Millisecond)
+		return "token", nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := cache.Set(ctx, "localhost:5000", SchemeBearer, "foo", fetch); err != nil || got != "token" {
+				t.Errorf("lruCache.Set() = (%v, %v), want (%v, nil)", got, err, "token")
+			}
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt64(&fetchCount); got != 1 {
+		t.Errorf("fetch count = %v, want 1", got)
+	}
+}
+
 func Test_hostCache(t *testing.T) {
 	base := NewCache()
 
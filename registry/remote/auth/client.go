@@ -26,7 +26,9 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 
+	"oras.land/oras-go/v2/internal/httputil"
 	"oras.land/oras-go/v2/registry/remote/internal/errutil"
 	"oras.land/oras-go/v2/registry/remote/retry"
 )
@@ -35,6 +37,56 @@ import (
 // basic auth.
 var ErrBasicCredentialNotFound = errors.New("basic credential not found")
 
+// OfflineError is returned by Client when a request is attempted while the
+// client is in the offline mode.
+type OfflineError struct {
+	// Method is the method of the attempted request.
+	Method string
+	// URL is the URL of the attempted request.
+	URL *url.URL
+}
+
+// Error returns the error message.
+func (e *OfflineError) Error() string {
+	return fmt.Sprintf("%s %q: network access is disabled in offline mode", e.Method, e.URL)
+}
+
+// RequestCounter counts the HTTP requests sent by a Client, which helps
+// asserting the number of requests incurred by an operation in tests, or
+// budgeting the requests to a remote server.
+// The zero value is ready for use. RequestCounter is safe for concurrent use.
+type RequestCounter struct {
+	count atomic.Int64
+}
+
+// Count returns the number of requests counted since the creation or the last
+// reset of the counter.
+func (rc *RequestCounter) Count() int64 {
+	return rc.count.Load()
+}
+
+// Reset resets the counter to zero, and returns the number of requests counted
+// before the reset, which is useful for measuring a window of operations.
+func (rc *RequestCounter) Reset() int64 {
+	return rc.count.Swap(0)
+}
+
+// Limiter limits the rate of the HTTP requests sent by a Client.
+// *rate.Limiter of the golang.org/x/time/rate package implements Limiter.
+//
+// A Limiter only sees the requests sent by the Client, not the attempts
+// retried by its underlying HTTP client. In particular, with the underlying
+// HTTP client of DefaultClient, retry.DefaultClient, the attempts retried by
+// retry.Transport on 429 and 5xx responses, such as the ones throttled by the
+// pull rate limits of a registry, are NOT rate limited. To rate limit every
+// attempt, use an underlying HTTP client that does not retry, such as
+// http.DefaultClient, and set Client.RetryPolicy to retry within the Client.
+type Limiter interface {
+	// Wait blocks until a request is allowed to be sent, or returns an error
+	// if the request should be aborted, such as when ctx is done.
+	Wait(ctx context.Context) error
+}
+
 // DefaultClient is the default auth-decorated client.
 var DefaultClient = &Client{
 	Client: retry.DefaultClient,
@@ -69,9 +121,15 @@ type CredentialFunc func(ctx context.Context, hostport string) (Credential, erro
 func StaticCredential(registry string, cred Credential) CredentialFunc {
 	if registry == "docker.io" {
 		// it is expected that traffic targeting "docker.io" will be redirected
-		// to "registry-1.docker.io"
+		// to "registry-1.docker.io" or "index.docker.io"
 		// reference: https://github.com/moby/moby/blob/v24.0.0-beta.2/registry/config.go#L25-L48
-		registry = "registry-1.docker.io"
+		return func(_ context.Context, hostport string) (Credential, error) {
+			switch hostport {
+			case "docker.io", "registry-1.docker.io", "index.docker.io":
+				return cred, nil
+			}
+			return EmptyCredential, nil
+		}
 	}
 	return func(_ context.Context, hostport string) (Credential, error) {
 		if hostport == registry {
@@ -104,6 +162,28 @@ type Client struct {
 	// If nil, the credential is always resolved to EmptyCredential.
 	Credential CredentialFunc
 
+	// CredentialCache caches the credentials resolved by Credential per
+	// registry, so that Credential is not invoked on every authentication
+	// challenge. The cached credential of a registry is invalidated on
+	// authentication failure.
+	// If nil, Credential is invoked whenever a credential is required.
+	CredentialCache *CredentialCache
+
+	// AnonymousCache remembers the scopes accessible anonymously. If set, the
+	// scopes of a request succeeding without authentication are remembered,
+	// and the subsequent requests for the same scopes are sent anonymously
+	// without attaching the cached tokens. The credential is resolved and the
+	// token exchange takes place only if the remote server challenges an
+	// anonymous request, in which case the scopes are forgotten.
+	// If nil, the cached tokens are always attempted first.
+	AnonymousCache *AnonymousCache
+
+	// ScopeRecorder records the scopes requested in the token exchanges with
+	// the authorization service, including the ones added by AppendScopes, for
+	// debugging purposes.
+	// If nil, the requested scopes are not recorded.
+	ScopeRecorder *ScopeRecorder
+
 	// Cache caches credentials for direct accessing the remote registry.
 	// If nil, no cache is used.
 	Cache Cache
@@ -120,6 +200,41 @@ type Client struct {
 	// - https://docs.docker.com/registry/spec/auth/jwt/
 	// - https://docs.docker.com/registry/spec/auth/oauth/
 	ForceAttemptOAuth2 bool
+
+	// Offline disables any network access. If set, every request, including
+	// the ones for authentication, fails with an *OfflineError without being
+	// sent, which can be used to verify that an operation is fully served by
+	// local stores.
+	Offline bool
+
+	// RetryPolicy decides whether a failed request should be re-issued, and
+	// how long to wait before doing so. The Retry-After header of the
+	// response, if present, takes precedence over the returned duration.
+	// Only idempotent requests with rewindable bodies are retried, up to 10
+	// attempts in total.
+	// If nil, every request is sent only once.
+	RetryPolicy RetryPolicy
+
+	// RequestCounter counts every HTTP request sent to the remote servers,
+	// including the ones for authentication and the attempts retried by
+	// RetryPolicy.
+	// Requests rejected in the offline mode are not counted.
+	// Attempts retried by the underlying HTTP client itself, such as the ones
+	// retried by retry.Transport of retry.DefaultClient, are not visible to
+	// Client and are counted only once.
+	// If nil, the requests are not counted.
+	RequestCounter *RequestCounter
+
+	// Limiter is consulted before every HTTP request sent to the remote
+	// servers, including the ones for authentication and the attempts retried
+	// by RetryPolicy, so that the request rate, such as the one to a registry
+	// with pull rate limits, can be capped. If Limiter returns an error, the
+	// request is aborted without being sent or counted.
+	// Attempts retried by the underlying HTTP client itself, including
+	// retry.DefaultClient used by DefaultClient, are not rate limited.
+	// See [Limiter] for details.
+	// If nil, the requests are not rate limited.
+	Limiter Limiter
 }
 
 // client returns an HTTP client used to access the remote registry.
@@ -131,11 +246,52 @@ func (c *Client) client() *http.Client {
 	return c.Client
 }
 
-// send adds headers to the request and sends the request to the remote server.
+// send adds headers to the request and sends the request to the remote server
+// with the underlying HTTP client.
 func (c *Client) send(req *http.Request) (*http.Response, error) {
+	return c.sendWith(roundTripperFunc(c.roundTrip), req)
+}
+
+// sendWith adds headers to the request and sends the request to the remote
+// server with base, applying the Offline, RetryPolicy, RequestCounter and
+// Limiter settings of c.
+func (c *Client) sendWith(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if c.Offline {
+		return nil, &OfflineError{
+			Method: req.Method,
+			URL:    req.URL,
+		}
+	}
 	for key, values := range c.Header {
 		req.Header[key] = append(req.Header[key], values...)
 	}
+	if c.RetryPolicy == nil {
+		return c.do(base, req)
+	}
+	return c.sendWithRetry(base, req)
+}
+
+// do sends the request with base, waiting for c.Limiter and counting the
+// request if they are set.
+func (c *Client) do(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	if c.Limiter != nil {
+		if err := c.Limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("%s %q: rate limiter: %w", req.Method, req.URL, err)
+		}
+	}
+	if c.RequestCounter != nil {
+		c.RequestCounter.count.Add(1)
+	}
+	return base.RoundTrip(req)
+}
+
+// roundTrip sends the request with the underlying HTTP client. The redirect
+// targets are rewritten if the context of the request carries a URL rewrite
+// function.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	if rewrite := httputil.URLRewriteFromContext(req.Context()); rewrite != nil {
+		return httputil.ClientWithURLRewrite(c.client(), rewrite).Do(req)
+	}
 	return c.client().Do(req)
 }
 
@@ -144,9 +300,20 @@ func (c *Client) credential(ctx context.Context, reg string) (Credential, error)
 	if c.Credential == nil {
 		return EmptyCredential, nil
 	}
+	if c.CredentialCache != nil {
+		return c.CredentialCache.Get(ctx, reg, c.Credential)
+	}
 	return c.Credential(ctx, reg)
 }
 
+// invalidateCredential invalidates the cached credential for the given
+// registry, if any.
+func (c *Client) invalidateCredential(reg string) {
+	if c.CredentialCache != nil {
+		c.CredentialCache.Invalidate(reg)
+	}
+}
+
 // cache resolves the cache.
 // noCache is return if the cache is not configured.
 func (c *Client) cache() Cache {
@@ -170,111 +337,15 @@ func (c *Client) SetUserAgent(userAgent string) {
 // On authentication failure due to bad credential,
 //   - Do returns error if it fails to fetch token for bearer auth.
 //   - Do returns the registry response without error for basic auth.
+//
+// Do is equivalent to sending the request with a Transport wrapping the
+// underlying HTTP client of c.
 func (c *Client) Do(originalReq *http.Request) (*http.Response, error) {
-	if auth := originalReq.Header.Get("Authorization"); auth != "" {
-		return c.send(originalReq)
-	}
-
-	ctx := originalReq.Context()
-	req := originalReq.Clone(ctx)
-
-	// attempt cached auth token
-	var attemptedKey string
-	cache := c.cache()
-	host := originalReq.Host
-	scheme, err := cache.GetScheme(ctx, host)
-	if err == nil {
-		switch scheme {
-		case SchemeBasic:
-			token, err := cache.GetToken(ctx, host, SchemeBasic, "")
-			if err == nil {
-				req.Header.Set("Authorization", "Basic "+token)
-			}
-		case SchemeBearer:
-			scopes := GetAllScopesForHost(ctx, host)
-			attemptedKey = strings.Join(scopes, " ")
-			token, err := cache.GetToken(ctx, host, SchemeBearer, attemptedKey)
-			if err == nil {
-				req.Header.Set("Authorization", "Bearer "+token)
-			}
-		}
+	t := &Transport{
+		Base:   roundTripperFunc(c.roundTrip),
+		Client: c,
 	}
-
-	resp, err := c.send(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != http.StatusUnauthorized {
-		return resp, nil
-	}
-
-	// attempt again with credentials for recognized schemes
-	challenge := resp.Header.Get("Www-Authenticate")
-	scheme, params := parseChallenge(challenge)
-	switch scheme {
-	case SchemeBasic:
-		resp.Body.Close()
-
-		token, err := cache.Set(ctx, host, SchemeBasic, "", func(ctx context.Context) (string, error) {
-			return c.fetchBasicAuth(ctx, host)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("%s %q: %w", resp.Request.Method, resp.Request.URL, err)
-		}
-
-		req = originalReq.Clone(ctx)
-		req.Header.Set("Authorization", "Basic "+token)
-	case SchemeBearer:
-		resp.Body.Close()
-
-		scopes := GetAllScopesForHost(ctx, host)
-		if paramScope := params["scope"]; paramScope != "" {
-			// merge hinted scopes with challenged scopes
-			scopes = append(scopes, strings.Split(paramScope, " ")...)
-			scopes = CleanScopes(scopes)
-		}
-		key := strings.Join(scopes, " ")
-
-		// attempt the cache again if there is a scope change
-		if key != attemptedKey {
-			if token, err := cache.GetToken(ctx, host, SchemeBearer, key); err == nil {
-				req = originalReq.Clone(ctx)
-				req.Header.Set("Authorization", "Bearer "+token)
-				if err := rewindRequestBody(req); err != nil {
-					return nil, err
-				}
-
-				resp, err := c.send(req)
-				if err != nil {
-					return nil, err
-				}
-				if resp.StatusCode != http.StatusUnauthorized {
-					return resp, nil
-				}
-				resp.Body.Close()
-			}
-		}
-
-		// attempt with credentials
-		realm := params["realm"]
-		service := params["service"]
-		token, err := cache.Set(ctx, host, SchemeBearer, key, func(ctx context.Context) (string, error) {
-			return c.fetchBearerToken(ctx, host, realm, service, scopes)
-		})
-		if err != nil {
-			return nil, fmt.Errorf("%s %q: %w", resp.Request.Method, resp.Request.URL, err)
-		}
-
-		req = originalReq.Clone(ctx)
-		req.Header.Set("Authorization", "Bearer "+token)
-	default:
-		return resp, nil
-	}
-	if err := rewindRequestBody(req); err != nil {
-		return nil, err
-	}
-
-	return c.send(req)
+	return t.RoundTrip(originalReq)
 }
 
 // fetchBasicAuth fetches a basic auth token for the basic challenge.
@@ -302,6 +373,9 @@ func (c *Client) fetchBearerToken(ctx context.Context, registry, realm, service
 	if cred.AccessToken != "" {
 		return cred.AccessToken, nil
 	}
+	if c.ScopeRecorder != nil {
+		c.ScopeRecorder.record(scopes)
+	}
 	if cred == EmptyCredential || (cred.RefreshToken == "" && !c.ForceAttemptOAuth2) {
 		return c.fetchDistributionToken(ctx, realm, service, scopes, cred.Username, cred.Password)
 	}
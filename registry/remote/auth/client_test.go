@@ -20,15 +20,19 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"oras.land/oras-go/v2/registry/remote/errcode"
+	"oras.land/oras-go/v2/registry/remote/retry"
 )
 
 func TestClient_SetUserAgent(t *testing.T) {
@@ -3700,6 +3704,19 @@ func TestStaticCredential(t *testing.T) {
 				Password: "password",
 			},
 		},
+		{
+			name:     "Matched credential for docker.io on index.docker.io",
+			registry: "docker.io",
+			target:   "index.docker.io",
+			cred: Credential{
+				Username: "username",
+				Password: "password",
+			},
+			want: Credential{
+				Username: "username",
+				Password: "password",
+			},
+		},
 		{
 			name:     "Mismatched credential for regular registry",
 			registry: "registry.example.com",
@@ -3976,3 +3993,869 @@ func TestClient_fetchBasicAuth(t *testing.T) {
 		t.Errorf("incorrect error: %v, expected %v", err, ErrBasicCredentialNotFound)
 	}
 }
+
+func TestClient_Do_Basic_Auth_CredentialCache(t *testing.T) {
+	username := "test_user"
+	password := "test_password"
+	var serverPassword atomic.Value
+	serverPassword.Store(password)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+serverPassword.Load().(string)))
+		if auth := r.Header.Get("Authorization"); auth != header {
+			w.Header().Set("Www-Authenticate", `Basic realm="Test Server"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	var credentialCount int64
+	var credentialPassword atomic.Value
+	credentialPassword.Store(password)
+	client := &Client{
+		Credential: func(ctx context.Context, reg string) (Credential, error) {
+			atomic.AddInt64(&credentialCount, 1)
+			if reg != uri.Host {
+				err := fmt.Errorf("registry mismatch: got %v, want %v", reg, uri.Host)
+				t.Error(err)
+				return EmptyCredential, err
+			}
+			return Credential{
+				Username: username,
+				Password: credentialPassword.Load().(string),
+			}, nil
+		},
+		CredentialCache: NewCredentialCache(time.Hour),
+	}
+	do := func() int {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Errorf("failed to create test request: %v", err)
+			return 0
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Errorf("Client.Do() error = %v", err)
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// concurrent requests within the TTL resolve the credential once
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if status := do(); status != http.StatusOK {
+				t.Errorf("Client.Do() = %v, want %v", status, http.StatusOK)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt64(&credentialCount); got != 1 {
+		t.Errorf("credential function called %d times, want 1", got)
+	}
+
+	// auth failure invalidates the cached credential
+	serverPassword.Store("test_password2")
+	if status := do(); status != http.StatusUnauthorized {
+		t.Errorf("Client.Do() = %v, want %v", status, http.StatusUnauthorized)
+	}
+	if got := atomic.LoadInt64(&credentialCount); got != 1 {
+		t.Errorf("credential function called %d times, want 1", got)
+	}
+	credentialPassword.Store("test_password2")
+	if status := do(); status != http.StatusOK {
+		t.Errorf("Client.Do() = %v, want %v", status, http.StatusOK)
+	}
+	if got := atomic.LoadInt64(&credentialCount); got != 2 {
+		t.Errorf("credential function called %d times, want 2", got)
+	}
+}
+
+func TestCredentialCache_Get(t *testing.T) {
+	ctx := context.Background()
+	var count int
+	resolve := func(ctx context.Context, reg string) (Credential, error) {
+		count++
+		if reg == "error.example" {
+			return EmptyCredential, errors.New("resolve error")
+		}
+		return Credential{Username: reg, Password: "password"}, nil
+	}
+
+	cc := NewCredentialCache(time.Hour)
+	for i := 0; i < 3; i++ {
+		cred, err := cc.Get(ctx, "registry.example", resolve)
+		if err != nil {
+			t.Fatalf("CredentialCache.Get() error = %v", err)
+		}
+		if cred.Username != "registry.example" {
+			t.Errorf("CredentialCache.Get() = %v, want username %s", cred, "registry.example")
+		}
+	}
+	if count != 1 {
+		t.Errorf("resolve called %d times, want 1", count)
+	}
+
+	// credentials are cached per registry
+	if _, err := cc.Get(ctx, "other.example", resolve); err != nil {
+		t.Fatalf("CredentialCache.Get() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("resolve called %d times, want 2", count)
+	}
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := cc.Get(ctx, "error.example", resolve); err == nil {
+			t.Error("CredentialCache.Get() error = nil, wantErr true")
+		}
+	}
+	if count != 4 {
+		t.Errorf("resolve called %d times, want 4", count)
+	}
+
+	// invalidation
+	cc.Invalidate("registry.example")
+	if _, err := cc.Get(ctx, "registry.example", resolve); err != nil {
+		t.Fatalf("CredentialCache.Get() error = %v", err)
+	}
+	if count != 5 {
+		t.Errorf("resolve called %d times, want 5", count)
+	}
+
+	// expiry
+	cc = NewCredentialCache(time.Millisecond)
+	count = 0
+	if _, err := cc.Get(ctx, "registry.example", resolve); err != nil {
+		t.Fatalf("CredentialCache.Get() error = %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := cc.Get(ctx, "registry.example", resolve); err != nil {
+		t.Fatalf("CredentialCache.Get() error = %v", err)
+	}
+	if count != 2 {
+		t.Errorf("resolve called %d times, want 2", count)
+	}
+
+	// zero value
+	cc = &CredentialCache{}
+	count = 0
+	cc.Invalidate("registry.example")
+	for i := 0; i < 2; i++ {
+		if _, err := cc.Get(ctx, "registry.example", resolve); err != nil {
+			t.Fatalf("CredentialCache.Get() error = %v", err)
+		}
+	}
+	if count != 1 {
+		t.Errorf("resolve called %d times, want 1", count)
+	}
+}
+
+func TestCredentialCache_Get_LeaderCanceled(t *testing.T) {
+	cc := NewCredentialCache(time.Hour)
+	started := make(chan struct{})
+	var count atomic.Int64
+	resolve := func(ctx context.Context, reg string) (Credential, error) {
+		if count.Add(1) == 1 {
+			// the first resolution is aborted by its caller
+			close(started)
+			<-ctx.Done()
+			return EmptyCredential, ctx.Err()
+		}
+		return Credential{Username: reg, Password: "password"}, nil
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := cc.Get(leaderCtx, "registry.example", resolve)
+		leaderErr <- err
+	}()
+	<-started
+
+	waiterResult := make(chan error, 1)
+	go func() {
+		cred, err := cc.Get(context.Background(), "registry.example", resolve)
+		if err == nil && cred.Username != "registry.example" {
+			err = fmt.Errorf("unexpected credential: %v", cred)
+		}
+		waiterResult <- err
+	}()
+	// let the waiter join the shared resolution before cancelling it
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("CredentialCache.Get() error = %v, want %v", err, context.Canceled)
+	}
+	if err := <-waiterResult; err != nil {
+		t.Errorf("CredentialCache.Get() error = %v, want nil", err)
+	}
+	if got := count.Load(); got != 2 {
+		t.Errorf("resolve called %d times, want 2", got)
+	}
+}
+
+func TestClient_Do_Offline(t *testing.T) {
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		w.Header().Set("Www-Authenticate", `Basic realm="Test Server"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	client := &Client{
+		Credential: StaticCredential(ts.Listener.Addr().String(), Credential{
+			Username: "test_user",
+			Password: "test_password",
+		}),
+		Offline: true,
+	}
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("Client.Do() error = nil, wantErr true")
+	}
+	var offlineErr *OfflineError
+	if !errors.As(err, &offlineErr) {
+		t.Fatalf("Client.Do() error = %v, want %T", err, offlineErr)
+	}
+	if offlineErr.Method != http.MethodGet || offlineErr.URL.String() != ts.URL {
+		t.Errorf("OfflineError = %v, want request %s %s", offlineErr, http.MethodGet, ts.URL)
+	}
+	if got := atomic.LoadInt64(&requestCount); got != 0 {
+		t.Errorf("request count = %v, want 0", got)
+	}
+}
+
+func TestClient_Do_RequestCounter(t *testing.T) {
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requestCount, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	counter := &RequestCounter{}
+	client := &Client{
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			return err == nil && resp.StatusCode >= 500, time.Millisecond
+		},
+		RequestCounter: counter,
+	}
+	send := func() error {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to create test request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// retried attempts are counted
+	if err := send(); err != nil {
+		t.Fatalf("Client.Do() error = %v", err)
+	}
+	if got := counter.Count(); got != 2 {
+		t.Errorf("RequestCounter.Count() = %v, want 2", got)
+	}
+
+	// requests in offline mode are not counted
+	client.Offline = true
+	if err := send(); err == nil {
+		t.Fatal("Client.Do() error = nil, wantErr true")
+	}
+	if got := counter.Reset(); got != 2 {
+		t.Errorf("RequestCounter.Reset() = %v, want 2", got)
+	}
+	if got := counter.Count(); got != 0 {
+		t.Errorf("RequestCounter.Count() = %v, want 0", got)
+	}
+}
+
+// testLimiter counts the waits, and fails them with err if set.
+type testLimiter struct {
+	waits atomic.Int64
+	err   error
+}
+
+func (l *testLimiter) Wait(ctx context.Context) error {
+	l.waits.Add(1)
+	if l.err != nil {
+		return l.err
+	}
+	return ctx.Err()
+}
+
+func TestClient_Do_Limiter(t *testing.T) {
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requestCount, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	limiter := &testLimiter{}
+	client := &Client{
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			return err == nil && resp.StatusCode >= 500, time.Millisecond
+		},
+		Limiter: limiter,
+	}
+	send := func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to create test request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	// retried attempts are rate limited
+	if err := send(context.Background()); err != nil {
+		t.Fatalf("Client.Do() error = %v", err)
+	}
+	if got := limiter.waits.Load(); got != 2 {
+		t.Errorf("Limiter.Wait() calls = %v, want 2", got)
+	}
+
+	// requests are aborted on limiter errors
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := send(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Client.Do() error = %v, want %v", err, context.Canceled)
+	}
+	errLimited := errors.New("limited")
+	limiter.err = errLimited
+	if err := send(context.Background()); !errors.Is(err, errLimited) {
+		t.Errorf("Client.Do() error = %v, want %v", err, errLimited)
+	}
+	if got := atomic.LoadInt64(&requestCount); got != 2 {
+		t.Errorf("requests sent = %v, want 2", got)
+	}
+}
+
+func TestClient_Do_Limiter_RetryTransport(t *testing.T) {
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&requestCount, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	limiter := &testLimiter{}
+	counter := &RequestCounter{}
+	client := &Client{
+		Client:         retry.DefaultClient,
+		RequestCounter: counter,
+		Limiter:        limiter,
+	}
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Client.Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Client.Do() status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	// the attempts retried by retry.Transport are not visible to Client
+	if got := atomic.LoadInt64(&requestCount); got != 2 {
+		t.Errorf("requests sent = %v, want 2", got)
+	}
+	if got := limiter.waits.Load(); got != 1 {
+		t.Errorf("Limiter.Wait() calls = %v, want 1", got)
+	}
+	if got := counter.Count(); got != 1 {
+		t.Errorf("RequestCounter.Count() = %v, want 1", got)
+	}
+}
+
+func TestClient_Do_RetryPolicy(t *testing.T) {
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt64(&requestCount, 1)
+		if r.Method == http.MethodPut {
+			if body, _ := io.ReadAll(r.Body); string(body) != "hello" {
+				t.Errorf("unexpected body: %q", body)
+			}
+		}
+		switch count {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer ts.Close()
+
+	var attempts []int
+	client := &Client{
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			attempts = append(attempts, attempt)
+			if err != nil {
+				return false, 0
+			}
+			return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, time.Millisecond
+		},
+	}
+
+	tests := []struct {
+		name           string
+		newRequest     func() (*http.Request, error)
+		wantStatusCode int
+		wantCount      int64
+	}{
+		{
+			name: "GET is retried",
+			newRequest: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodGet, ts.URL, nil)
+			},
+			wantStatusCode: http.StatusOK,
+			wantCount:      3,
+		},
+		{
+			name: "PUT with rewindable body is retried",
+			newRequest: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPut, ts.URL, strings.NewReader("hello"))
+			},
+			wantStatusCode: http.StatusOK,
+			wantCount:      3,
+		},
+		{
+			name: "PUT with non-rewindable body is not retried",
+			newRequest: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPut, ts.URL, io.NopCloser(strings.NewReader("hello")))
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+			wantCount:      1,
+		},
+		{
+			name: "POST is not retried",
+			newRequest: func() (*http.Request, error) {
+				return http.NewRequest(http.MethodPost, ts.URL, nil)
+			},
+			wantStatusCode: http.StatusServiceUnavailable,
+			wantCount:      1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt64(&requestCount, 0)
+			attempts = nil
+			req, err := tt.newRequest()
+			if err != nil {
+				t.Fatalf("failed to create test request: %v", err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Client.Do() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatusCode {
+				t.Errorf("Client.Do() status code = %v, want %v", resp.StatusCode, tt.wantStatusCode)
+			}
+			if got := atomic.LoadInt64(&requestCount); got != tt.wantCount {
+				t.Errorf("request count = %v, want %v", got, tt.wantCount)
+			}
+			if tt.wantCount > 1 {
+				if want := []int{0, 1, 2}; !reflect.DeepEqual(attempts, want) {
+					t.Errorf("RetryPolicy() attempts = %v, want %v", attempts, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Do_RetryPolicy_MaxAttempts(t *testing.T) {
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	client := &Client{
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			return true, 0
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Client.Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Client.Do() status code = %v, want %v", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+	if got := atomic.LoadInt64(&requestCount); got != maxRetryAttempts {
+		t.Errorf("request count = %v, want %v", got, maxRetryAttempts)
+	}
+
+	// the last error is returned wrapped
+	req, err = http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	var attemptCount int
+	wantErr := errors.New("transient error")
+	client = &Client{
+		Client: &http.Client{
+			Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				return nil, wantErr
+			}),
+		},
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			attemptCount++
+			return true, 0
+		},
+	}
+	if _, err := client.Do(req); !errors.Is(err, wantErr) {
+		t.Errorf("Client.Do() error = %v, want %v", err, wantErr)
+	}
+	if attemptCount != maxRetryAttempts {
+		t.Errorf("RetryPolicy() calls = %v, want %v", attemptCount, maxRetryAttempts)
+	}
+}
+
+func TestClient_Do_RetryPolicy_RewindFailure(t *testing.T) {
+	var body *closeTrackingReader
+	client := &Client{
+		Client: &http.Client{
+			Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				body = &closeTrackingReader{Reader: strings.NewReader("unavailable")}
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Header:     http.Header{},
+					Body:       body,
+				}, nil
+			}),
+		},
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			return true, 0
+		},
+	}
+	req, err := http.NewRequest(http.MethodPut, "http://registry.example", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	wantErr := errors.New("rewind error")
+	req.GetBody = func() (io.ReadCloser, error) {
+		return nil, wantErr
+	}
+	resp, err := client.Do(req)
+	if !errors.Is(err, wantErr) {
+		t.Errorf("Client.Do() error = %v, want %v", err, wantErr)
+	}
+	if resp != nil {
+		t.Errorf("Client.Do() response = %v, want nil", resp)
+	}
+	if body == nil || !body.closed {
+		t.Error("response body of the failed attempt is not closed")
+	}
+}
+
+// closeTrackingReader records if it is closed.
+type closeTrackingReader struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeTrackingReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestClient_Do_RetryPolicy_ContextCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &Client{
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			cancel()
+			return true, time.Hour
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	if _, err := client.Do(req); !errors.Is(err, context.Canceled) {
+		t.Errorf("Client.Do() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"invalid", 0, false},
+		{now.Add(5 * time.Second).Format(http.TimeFormat), 5 * time.Second, true},
+		{now.Add(-5 * time.Second).Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if tt.value != "" {
+				resp.Header.Set("Retry-After", tt.value)
+			}
+			got, ok := parseRetryAfter(resp, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfter() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClient_Do_AnonymousCache(t *testing.T) {
+	accessToken := "test/access/token"
+	scope := "repository:test:pull"
+	var requireAuth atomic.Bool
+	var authCount, credentialCount int64
+	as := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&authCount, 1)
+		if _, err := fmt.Fprintf(w, `{"access_token":%q}`, accessToken); err != nil {
+			t.Errorf("failed to write %q: %v", r.URL, err)
+		}
+	}))
+	defer as.Close()
+	var requestCount int64
+	var gotAuth []string
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		auth := r.Header.Get("Authorization")
+		mu.Lock()
+		gotAuth = append(gotAuth, auth)
+		mu.Unlock()
+		if auth == "Bearer "+accessToken || (auth == "" && !requireAuth.Load()) {
+			return
+		}
+		challenge := fmt.Sprintf("Bearer realm=%q,service=%q,scope=%q", as.URL, "test", scope)
+		w.Header().Set("Www-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	host := uri.Host
+
+	cache := NewCache()
+	anonymousCache := NewAnonymousCache()
+	client := &Client{
+		Credential: func(ctx context.Context, reg string) (Credential, error) {
+			atomic.AddInt64(&credentialCount, 1)
+			return EmptyCredential, nil
+		},
+		Cache:          cache,
+		AnonymousCache: anonymousCache,
+	}
+	ctx := WithScopes(context.Background(), scope)
+	send := func() {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to create test request: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Client.Do() error = %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Client.Do() status code = %v, want %v", resp.StatusCode, http.StatusOK)
+		}
+	}
+	reset := func() {
+		atomic.StoreInt64(&requestCount, 0)
+		atomic.StoreInt64(&authCount, 0)
+		atomic.StoreInt64(&credentialCount, 0)
+		gotAuth = nil
+	}
+
+	// the known anonymous scope is accessed anonymously even if a token is
+	// cached for it
+	if _, err := cache.Set(ctx, host, SchemeBearer, scope, func(context.Context) (string, error) {
+		return "invalid", nil
+	}); err != nil {
+		t.Fatalf("Cache.Set() error = %v", err)
+	}
+	anonymousCache.Add(host, []string{scope})
+	send()
+	if got := atomic.LoadInt64(&requestCount); got != 1 {
+		t.Errorf("request count = %v, want 1", got)
+	}
+	if got := atomic.LoadInt64(&authCount) + atomic.LoadInt64(&credentialCount); got != 0 {
+		t.Errorf("credential and token requests = %v, want 0", got)
+	}
+	if want := []string{""}; !reflect.DeepEqual(gotAuth, want) {
+		t.Errorf("Authorization = %q, want %q", gotAuth, want)
+	}
+	if !anonymousCache.Contains(host, []string{scope}) {
+		t.Error("AnonymousCache.Contains() = false, want true")
+	}
+
+	// anonymous access is challenged, the token exchange takes place
+	reset()
+	requireAuth.Store(true)
+	send()
+	if got := atomic.LoadInt64(&requestCount); got != 3 {
+		t.Errorf("request count = %v, want 3", got)
+	}
+	if got := atomic.LoadInt64(&authCount); got != 1 {
+		t.Errorf("token request count = %v, want 1", got)
+	}
+	if got := atomic.LoadInt64(&credentialCount); got != 1 {
+		t.Errorf("credential resolution count = %v, want 1", got)
+	}
+	if anonymousCache.Contains(host, []string{scope}) {
+		t.Error("AnonymousCache.Contains() = true, want false")
+	}
+
+	// the cached token is used afterwards
+	reset()
+	send()
+	if want := []string{"Bearer " + accessToken}; !reflect.DeepEqual(gotAuth, want) {
+		t.Errorf("Authorization = %q, want %q", gotAuth, want)
+	}
+	if anonymousCache.Contains(host, []string{scope}) {
+		t.Error("AnonymousCache.Contains() = true, want false")
+	}
+
+	// a fresh anonymous success is remembered
+	otherHost := "registry.example"
+	client.Client = &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		}),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://"+otherHost, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Client.Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if !anonymousCache.Contains(otherHost, []string{scope}) {
+		t.Error("AnonymousCache.Contains() = false, want true")
+	}
+}
+
+func TestClient_Do_ScopeRecorder(t *testing.T) {
+	accessToken := "test/access/token"
+	var gotScopes []string
+	as := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotScopes = r.URL.Query()["scope"]
+		if _, err := fmt.Fprintf(w, `{"access_token":%q}`, accessToken); err != nil {
+			t.Errorf("failed to write %q: %v", r.URL, err)
+		}
+	}))
+	defer as.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer "+accessToken {
+			return
+		}
+		challenge := fmt.Sprintf("Bearer realm=%q,service=%q,scope=%q", as.URL, "test", "repository:test:pull")
+		w.Header().Set("Www-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	recorder := &ScopeRecorder{}
+	client := &Client{
+		ScopeRecorder: recorder,
+	}
+	if got := recorder.LastRequestedScopes(); got != nil {
+		t.Errorf("ScopeRecorder.LastRequestedScopes() = %v, want nil", got)
+	}
+
+	ctx := WithScopesForHost(context.Background(), uri.Host, ScopeRepository("test", ActionPull, ActionPush))
+	ctx = AppendScopes(ctx, "registry:catalog:*")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Client.Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Client.Do() status code = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+
+	want := []string{"registry:catalog:*", "repository:test:pull,push"}
+	if !reflect.DeepEqual(gotScopes, want) {
+		t.Errorf("requested scopes = %v, want %v", gotScopes, want)
+	}
+	got := recorder.LastRequestedScopes()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScopeRecorder.LastRequestedScopes() = %v, want %v", got, want)
+	}
+
+	// the returned scopes are a copy
+	got[0] = "modified"
+	if got := recorder.LastRequestedScopes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ScopeRecorder.LastRequestedScopes() = %v, want %v", got, want)
+	}
+}
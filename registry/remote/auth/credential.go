@@ -15,6 +15,13 @@ limitations under the License.
 
 package auth
 
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
 // EmptyCredential represents an empty credential.
 var EmptyCredential Credential
 
@@ -38,3 +45,102 @@ type Credential struct {
 	// Reference: https://docs.docker.com/registry/spec/auth/token/
 	AccessToken string
 }
+
+// credentialCacheEntry is a credential cached for a single registry.
+type credentialCacheEntry struct {
+	ready      chan struct{}
+	credential Credential
+	err        error
+	expiry     time.Time
+}
+
+// CredentialCache caches the credentials resolved by a [CredentialFunc] per
+// registry (i.e. host:port) for a period of time, so that expensive credential
+// lookups are not repeated on every authentication challenge.
+// CredentialCache is safe for concurrent use. Its zero value is an empty cache
+// where the resolved credentials never expire until invalidated.
+type CredentialCache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[string]*credentialCacheEntry
+}
+
+// NewCredentialCache creates a credential cache keeping the resolved
+// credentials for the given TTL.
+// If ttl is not positive, the resolved credentials never expire until
+// invalidated.
+func NewCredentialCache(ttl time.Duration) *CredentialCache {
+	return &CredentialCache{
+		ttl:     ttl,
+		entries: make(map[string]*credentialCacheEntry),
+	}
+}
+
+// Get returns the cached credential for the given registry, or resolves it
+// using the resolve function if it is not cached or has expired.
+// Concurrent calls for the same registry share a single resolution. If the
+// shared resolution fails with a context error while the context of a waiting
+// call is still live, the waiting call resolves the credential again.
+// Failed resolutions are not cached.
+func (cc *CredentialCache) Get(ctx context.Context, registry string, resolve CredentialFunc) (Credential, error) {
+	cc.lock.Lock()
+	entry, ok := cc.entries[registry]
+	if ok {
+		select {
+		case <-entry.ready:
+			if cc.ttl > 0 && !time.Now().Before(entry.expiry) {
+				ok = false
+			}
+		default:
+			// resolution in progress
+		}
+	}
+	if !ok {
+		entry = &credentialCacheEntry{
+			ready: make(chan struct{}),
+		}
+		if cc.entries == nil {
+			cc.entries = make(map[string]*credentialCacheEntry)
+		}
+		cc.entries[registry] = entry
+		cc.lock.Unlock()
+
+		entry.credential, entry.err = resolve(ctx, registry)
+		entry.expiry = time.Now().Add(cc.ttl)
+		if entry.err != nil {
+			cc.remove(registry, entry)
+		}
+		close(entry.ready)
+		return entry.credential, entry.err
+	}
+	cc.lock.Unlock()
+
+	select {
+	case <-entry.ready:
+		if ctx.Err() == nil && (errors.Is(entry.err, context.Canceled) || errors.Is(entry.err, context.DeadlineExceeded)) {
+			// the shared resolution is aborted by the context of the call
+			// resolving it, retry under the context of this call
+			return cc.Get(ctx, registry, resolve)
+		}
+		return entry.credential, entry.err
+	case <-ctx.Done():
+		return EmptyCredential, ctx.Err()
+	}
+}
+
+// Invalidate removes the cached credential for the given registry.
+func (cc *CredentialCache) Invalidate(registry string) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	delete(cc.entries, registry)
+}
+
+// remove removes the given entry for the given registry if it is still
+// cached.
+func (cc *CredentialCache) remove(registry string, entry *credentialCacheEntry) {
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+	if cc.entries[registry] == entry {
+		delete(cc.entries, registry)
+	}
+}
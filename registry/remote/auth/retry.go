@@ -0,0 +1,102 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"oras.land/oras-go/v2/registry/remote/internal/errutil"
+)
+
+// maxRetryAttempts is the maximum number of attempts, including the first
+// one, to send a request when Client.RetryPolicy is set.
+const maxRetryAttempts = 10
+
+// RetryPolicy decides whether a request should be retried.
+// resp and err are the result of the attempt, where attempt starts at 0 for
+// the first attempt. The returned duration is the time to wait before the
+// next attempt.
+type RetryPolicy func(resp *http.Response, err error, attempt int) (retry bool, after time.Duration)
+
+// sendWithRetry sends req with base, and re-issues it according to
+// c.RetryPolicy.
+// On exceeding maxRetryAttempts, the response of the last attempt is returned
+// as is, or the error of the last attempt is returned wrapped.
+func (c *Client) sendWithRetry(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(base, req)
+		if !isRetryable(req) {
+			return resp, err
+		}
+		retry, after := c.RetryPolicy(resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if attempt+1 >= maxRetryAttempts {
+			if err != nil {
+				return nil, fmt.Errorf("%s %q: giving up after %d attempts: %w", req.Method, req.URL, attempt+1, err)
+			}
+			return resp, nil
+		}
+
+		nextReq := req.Clone(ctx)
+		if err := rewindRequestBody(nextReq); err != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, err
+		}
+		if resp != nil {
+			if d, ok := parseRetryAfter(resp, time.Now()); ok {
+				after = d
+			}
+			// drain the body so that the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4*1024))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(after)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		req = nextReq
+	}
+}
+
+// isRetryable returns true if req is idempotent and its body, if any, can be
+// rewound.
+func isRetryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// parseRetryAfter parses the Retry-After header of resp, which is either a
+// number of seconds or an HTTP date.
+// Reference: https://www.rfc-editor.org/rfc/rfc9110.html#name-retry-after
+func parseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	return errutil.ParseRetryAfter(resp, now)
+}
@@ -19,6 +19,7 @@ import (
 	"context"
 	"slices"
 	"strings"
+	"sync"
 
 	"oras.land/oras-go/v2/registry"
 )
@@ -323,3 +324,27 @@ func cleanActions(actions []string) []string {
 	}
 	return actions[:n]
 }
+
+// ScopeRecorder records the scopes requested from the authorization service
+// in the most recent token exchange, which helps diagnosing authorization
+// failures.
+// The zero value is ready for use. ScopeRecorder is safe for concurrent use.
+type ScopeRecorder struct {
+	lock   sync.RWMutex
+	scopes []string
+}
+
+// LastRequestedScopes returns the scopes requested in the most recent token
+// exchange, or nil if no token has been requested.
+func (sr *ScopeRecorder) LastRequestedScopes() []string {
+	sr.lock.RLock()
+	defer sr.lock.RUnlock()
+	return slices.Clone(sr.scopes)
+}
+
+// record records the scopes requested in a token exchange.
+func (sr *ScopeRecorder) record(scopes []string) {
+	sr.lock.Lock()
+	defer sr.lock.Unlock()
+	sr.scopes = slices.Clone(scopes)
+}
@@ -0,0 +1,220 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Transport is an http.RoundTripper resolving the authentication challenges
+// of the remote server, and attaching the resolved credentials or tokens to
+// the requests, which are then sent by the Base round tripper.
+// Transport can be composed with other round trippers, such as the ones for
+// tracing or for retries.
+//
+// Requests are cloned before being modified, and requests with the
+// 'Authorization' header set are passed through to Base without resolving
+// authentication.
+//
+// The requests sent through Base are subject to the Header, Offline,
+// RetryPolicy, RequestCounter and Limiter settings of Client, in the same way
+// as the requests sent by Client.Do. The underlying HTTP client of Client is
+// used only for fetching tokens.
+//
+// Its zero value is a usable transport that uses http.DefaultTransport with
+// the zero value of Client.
+type Transport struct {
+	// Base is the underlying round tripper used to send the requests to the
+	// remote server.
+	// If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Client provides the credentials, the caches and the other settings for
+	// authentication. The requests for fetching tokens are sent by Client
+	// instead of Base.
+	// If nil, the zero value of Client is used.
+	Client *Client
+}
+
+// zeroClient is the zero value of Client used by the transports without a
+// client configured.
+var zeroClient = &Client{}
+
+// base returns the underlying round tripper.
+// http.DefaultTransport is returned if the round tripper is not configured.
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+// client returns the client for authentication.
+// A zero value Client is returned if the client is not configured.
+func (t *Transport) client() *Client {
+	if t.Client == nil {
+		return zeroClient
+	}
+	return t.Client
+}
+
+// RoundTrip sends the request to the remote server, attempting to resolve
+// authentication if 'Authorization' header is not set.
+//
+// On authentication failure due to bad credential,
+//   - RoundTrip returns error if it fails to fetch token for bearer auth.
+//   - RoundTrip returns the registry response without error for basic auth.
+func (t *Transport) RoundTrip(originalReq *http.Request) (*http.Response, error) {
+	ctx := originalReq.Context()
+	req := originalReq.Clone(ctx)
+	c := t.client()
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		return c.sendWith(t.base(), req)
+	}
+
+	// attempt anonymous access if the scopes are known to be accessible
+	// anonymously, otherwise attempt cached auth token
+	var attemptedKey string
+	cache := c.cache()
+	host := originalReq.Host
+	var anonymous bool
+	var anonymousScopes []string
+	if c.AnonymousCache != nil {
+		anonymousScopes = GetAllScopesForHost(ctx, host)
+		anonymous = c.AnonymousCache.Contains(host, anonymousScopes)
+	}
+	if scheme, err := cache.GetScheme(ctx, host); !anonymous && err == nil {
+		switch scheme {
+		case SchemeBasic:
+			token, err := cache.GetToken(ctx, host, SchemeBasic, "")
+			if err == nil {
+				req.Header.Set("Authorization", "Basic "+token)
+			}
+		case SchemeBearer:
+			scopes := GetAllScopesForHost(ctx, host)
+			attemptedKey = strings.Join(scopes, " ")
+			token, err := cache.GetToken(ctx, host, SchemeBearer, attemptedKey)
+			if err == nil {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+	}
+
+	resp, err := c.sendWith(t.base(), req)
+	if err != nil {
+		return nil, err
+	}
+	if c.AnonymousCache != nil && req.Header.Get("Authorization") == "" {
+		// remember the anonymous success only, as some registries respond
+		// anonymous requests to private content with 403 or 404
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			c.AnonymousCache.Add(host, anonymousScopes)
+		} else if anonymous {
+			c.AnonymousCache.Invalidate(host, anonymousScopes)
+		}
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	// attempt again with credentials for recognized schemes
+	challenge := resp.Header.Get("Www-Authenticate")
+	scheme, params := parseChallenge(challenge)
+	switch scheme {
+	case SchemeBasic:
+		resp.Body.Close()
+
+		token, err := cache.Set(ctx, host, SchemeBasic, "", func(ctx context.Context) (string, error) {
+			return c.fetchBasicAuth(ctx, host)
+		})
+		if err != nil {
+			c.invalidateCredential(host)
+			return nil, fmt.Errorf("%s %q: %w", resp.Request.Method, resp.Request.URL, err)
+		}
+
+		req = originalReq.Clone(ctx)
+		req.Header.Set("Authorization", "Basic "+token)
+	case SchemeBearer:
+		resp.Body.Close()
+
+		scopes := GetAllScopesForHost(ctx, host)
+		if paramScope := params["scope"]; paramScope != "" {
+			// merge hinted scopes with challenged scopes
+			scopes = append(scopes, strings.Split(paramScope, " ")...)
+			scopes = CleanScopes(scopes)
+		}
+		key := strings.Join(scopes, " ")
+
+		// attempt the cache again if there is a scope change
+		if key != attemptedKey {
+			if token, err := cache.GetToken(ctx, host, SchemeBearer, key); err == nil {
+				req = originalReq.Clone(ctx)
+				req.Header.Set("Authorization", "Bearer "+token)
+				if err := rewindRequestBody(req); err != nil {
+					return nil, err
+				}
+
+				resp, err := c.sendWith(t.base(), req)
+				if err != nil {
+					return nil, err
+				}
+				if resp.StatusCode != http.StatusUnauthorized {
+					return resp, nil
+				}
+				resp.Body.Close()
+			}
+		}
+
+		// attempt with credentials
+		realm := params["realm"]
+		service := params["service"]
+		token, err := cache.Set(ctx, host, SchemeBearer, key, func(ctx context.Context) (string, error) {
+			return c.fetchBearerToken(ctx, host, realm, service, scopes)
+		})
+		if err != nil {
+			c.invalidateCredential(host)
+			return nil, fmt.Errorf("%s %q: %w", resp.Request.Method, resp.Request.URL, err)
+		}
+
+		req = originalReq.Clone(ctx)
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return resp, nil
+	}
+	if err := rewindRequestBody(req); err != nil {
+		return nil, err
+	}
+
+	resp, err = c.sendWith(t.base(), req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		c.invalidateCredential(host)
+	}
+	return resp, nil
+}
+
+// roundTripperFunc is a function implementing http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls fn(req).
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport_RoundTrip_BasicAuth(t *testing.T) {
+	username := "test_user"
+	password := "test_password"
+	var requestCount, baseCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		if user, pass, ok := r.BasicAuth(); !ok || user != username || pass != password {
+			w.Header().Set("Www-Authenticate", `Basic realm="Test Server"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}))
+	defer ts.Close()
+
+	transport := &Transport{
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt64(&baseCount, 1)
+			return http.DefaultTransport.RoundTrip(req)
+		}),
+		Client: &Client{
+			Credential: StaticCredential(ts.Listener.Addr().String(), Credential{
+				Username: username,
+				Password: password,
+			}),
+			Cache: NewCache(),
+		},
+	}
+	client := &http.Client{Transport: transport}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	for i, wantCount := range []int64{2, 3} {
+		// the request is reused
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("http.Client.Do() #%d error = %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("http.Client.Do() #%d status code = %v, want %v", i, resp.StatusCode, http.StatusOK)
+		}
+		if got := atomic.LoadInt64(&requestCount); got != wantCount {
+			t.Errorf("request count = %v, want %v", got, wantCount)
+		}
+		if got := atomic.LoadInt64(&baseCount); got != wantCount {
+			t.Errorf("base round trip count = %v, want %v", got, wantCount)
+		}
+		if auth := req.Header.Get("Authorization"); auth != "" {
+			t.Errorf("original request is modified: Authorization = %q", auth)
+		}
+	}
+}
+
+func TestTransport_RoundTrip_AuthorizationSet(t *testing.T) {
+	wantAuth := "Bearer caller-token"
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requestCount, 1)
+		if auth := r.Header.Get("Authorization"); auth != wantAuth {
+			t.Errorf("unexpected Authorization: %q, want %q", auth, wantAuth)
+		}
+		w.Header().Set("Www-Authenticate", `Basic realm="Test Server"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	transport := &Transport{
+		Client: &Client{
+			Credential: StaticCredential(ts.Listener.Addr().String(), Credential{
+				Username: "test_user",
+				Password: "test_password",
+			}),
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	req.Header.Set("Authorization", wantAuth)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Transport.RoundTrip() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Transport.RoundTrip() status code = %v, want %v", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if got := atomic.LoadInt64(&requestCount); got != 1 {
+		t.Errorf("request count = %v, want 1", got)
+	}
+}
+
+func TestTransport_RoundTrip_ClientSettings(t *testing.T) {
+	wantUserAgent := "test-agent"
+	var requestCount int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.Header.Get("User-Agent"); ua != wantUserAgent {
+			t.Errorf("unexpected User-Agent: %q, want %q", ua, wantUserAgent)
+		}
+		if atomic.AddInt64(&requestCount, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	limiter := &testLimiter{}
+	counter := &RequestCounter{}
+	client := &Client{
+		RetryPolicy: func(resp *http.Response, err error, attempt int) (bool, time.Duration) {
+			return err == nil && resp.StatusCode >= 500, time.Millisecond
+		},
+		RequestCounter: counter,
+		Limiter:        limiter,
+	}
+	client.SetUserAgent(wantUserAgent)
+	transport := &Transport{Client: client}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create test request: %v", err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Transport.RoundTrip() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Transport.RoundTrip() status code = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+	if got := atomic.LoadInt64(&requestCount); got != 2 {
+		t.Errorf("request count = %v, want 2", got)
+	}
+	if got := counter.Count(); got != 2 {
+		t.Errorf("RequestCounter.Count() = %v, want 2", got)
+	}
+	if got := limiter.waits.Load(); got != 2 {
+		t.Errorf("Limiter.Wait() calls = %v, want 2", got)
+	}
+	if ua := req.Header.Get("User-Agent"); ua != "" {
+		t.Errorf("original request is modified: User-Agent = %q", ua)
+	}
+
+	// requests are not sent in the offline mode
+	client.Offline = true
+	_, err = transport.RoundTrip(req)
+	var offlineErr *OfflineError
+	if !errors.As(err, &offlineErr) {
+		t.Errorf("Transport.RoundTrip() error = %v, want %T", err, offlineErr)
+	}
+	if got := atomic.LoadInt64(&requestCount); got != 2 {
+		t.Errorf("request count = %v, want 2", got)
+	}
+}
+
+func TestTransport_client(t *testing.T) {
+	t1 := &Transport{}
+	t2 := &Transport{}
+	if c1, c2 := t1.client(), t2.client(); c1 != c2 {
+		t.Errorf("Transport.client() = %p, %p, want the same client", c1, c2)
+	}
+	client := &Client{}
+	if c := (&Transport{Client: client}).client(); c != client {
+		t.Errorf("Transport.client() = %p, want %p", c, client)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+	"oras.land/oras-go/v2/registry/remote/internal/errutil"
+)
+
+// headerDockerDistributionAPIVersion is the "Docker-Distribution-Api-Version"
+// header. If present on the response to the base API, it contains the version
+// of the API implemented by the registry, e.g. "registry/2.0".
+//
+// Reference: https://distribution.github.io/distribution/spec/api/#api-version-check
+const headerDockerDistributionAPIVersion = "Docker-Distribution-Api-Version"
+
+// Capability represents the support of a feature by a repository.
+type Capability int
+
+const (
+	// CapabilityUnknown represents that the support of the feature is not yet
+	// known, as it is neither probed nor observed.
+	CapabilityUnknown Capability = iota
+	// CapabilitySupported represents that the feature is known to be
+	// supported.
+	CapabilitySupported
+	// CapabilityUnsupported represents that the feature is known to be not
+	// supported.
+	CapabilityUnsupported
+)
+
+// Capabilities describes the capabilities of a repository, as probed by
+// Repository.Ping or observed from the earlier responses of the registry.
+type Capabilities struct {
+	// APIVersion is the value of the "Docker-Distribution-Api-Version" header
+	// returned by the base API, e.g. "registry/2.0". It is empty if the
+	// header is absent or the base API is not yet requested.
+	APIVersion string
+	// Referrers represents the support of the Referrers API.
+	// See also: Repository.SetReferrersCapability
+	Referrers Capability
+	// ChunkedUpload represents the support of uploading blobs in chunks.
+	ChunkedUpload Capability
+	// Deletion represents the support of deleting manifests and blobs.
+	Deletion Capability
+}
+
+// Ping checks whether or not the registry of the repository implements Docker
+// Registry API V2 or OCI Distribution Specification, and returns the
+// capabilities of the repository.
+// The support of the Referrers API is probed if it is unknown, and cached on
+// the repository so that Referrers and Push skip probing it later. The other
+// capabilities are reported as observed from the earlier responses.
+// If the repository does not exist yet, the support of the Referrers API
+// remains unknown.
+//
+// References:
+//   - https://docs.docker.com/registry/spec/api/#base
+//   - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#api
+func (r *Repository) Ping(ctx context.Context) (Capabilities, error) {
+	url := buildRegistryBaseURL(r.PlainHTTP, r.Reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	resp, err := r.do(req)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return Capabilities{}, errdef.ErrNotFound
+	default:
+		return Capabilities{}, errutil.ParseErrorResponse(resp)
+	}
+	apiVersion := resp.Header.Get(headerDockerDistributionAPIVersion)
+	r.apiVersion.Store(&apiVersion)
+
+	if _, err := r.pingReferrers(ctx); err != nil && !errutil.IsErrorCode(err, errcode.ErrorCodeNameUnknown) {
+		return Capabilities{}, err
+	}
+	return r.Capabilities(), nil
+}
+
+// Capabilities returns the capabilities of the repository known so far,
+// without sending any request.
+// See also: Repository.Ping
+func (r *Repository) Capabilities() Capabilities {
+	var caps Capabilities
+	if apiVersion := r.apiVersion.Load(); apiVersion != nil {
+		caps.APIVersion = *apiVersion
+	}
+	caps.Referrers = referrersCapabilityOf(r.loadReferrersState())
+	caps.ChunkedUpload = capabilityOf(atomic.LoadInt32(&r.chunkedUploadState))
+	caps.Deletion = capabilityOf(atomic.LoadInt32(&r.deletionState))
+	return caps
+}
+
+// capabilityState represents the observed state of a feature of a repository.
+type capabilityState = int32
+
+const (
+	// capabilityStateUnknown represents that the feature is not yet observed.
+	capabilityStateUnknown capabilityState = iota
+	// capabilityStateSupported represents that the feature is observed to be
+	// supported.
+	capabilityStateSupported
+	// capabilityStateUnsupported represents that the feature is observed to
+	// be not supported.
+	capabilityStateUnsupported
+)
+
+// capabilityOf converts the state of a feature to a Capability.
+func capabilityOf(state capabilityState) Capability {
+	switch state {
+	case capabilityStateSupported:
+		return CapabilitySupported
+	case capabilityStateUnsupported:
+		return CapabilityUnsupported
+	default:
+		return CapabilityUnknown
+	}
+}
+
+// referrersCapabilityOf converts the state of Referrers API to a Capability.
+func referrersCapabilityOf(state referrersState) Capability {
+	switch state {
+	case referrersStateSupported:
+		return CapabilitySupported
+	case referrersStateUnsupported:
+		return CapabilityUnsupported
+	default:
+		return CapabilityUnknown
+	}
+}
+
+// storeCapability records the observed support of a feature in state.
+func storeCapability(state *capabilityState, supported bool) {
+	if supported {
+		atomic.StoreInt32(state, capabilityStateSupported)
+	} else {
+		atomic.StoreInt32(state, capabilityStateUnsupported)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestRepository_Ping(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	var referrersSupported bool
+	var repoExists bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/":
+			w.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+zeroDigest:
+			if !repoExists {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"errors":[{"code":"NAME_UNKNOWN","message":"repository name not known to registry"}]}`))
+				return
+			}
+			if !referrersSupported {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			w.Write([]byte(`{"manifests":[]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name               string
+		repoExists         bool
+		referrersSupported bool
+		want               Capabilities
+	}{
+		{
+			name:       "repository not found",
+			repoExists: false,
+			want: Capabilities{
+				APIVersion: "registry/2.0",
+			},
+		},
+		{
+			name:               "referrers API supported",
+			repoExists:         true,
+			referrersSupported: true,
+			want: Capabilities{
+				APIVersion: "registry/2.0",
+				Referrers:  CapabilitySupported,
+			},
+		},
+		{
+			name:       "referrers API unsupported",
+			repoExists: true,
+			want: Capabilities{
+				APIVersion: "registry/2.0",
+				Referrers:  CapabilityUnsupported,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoExists = tt.repoExists
+			referrersSupported = tt.referrersSupported
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			if got := repo.Capabilities(); got != (Capabilities{}) {
+				t.Errorf("Repository.Capabilities() = %v, want %v", got, Capabilities{})
+			}
+			got, err := repo.Ping(ctx)
+			if err != nil {
+				t.Fatalf("Repository.Ping() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Repository.Ping() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// capabilities observed from the earlier responses
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	if err := repo.Delete(ctx, blobDesc); err == nil {
+		t.Fatal("Repository.Delete() error = nil, want error")
+	}
+	if got := repo.Capabilities().Deletion; got != CapabilityUnsupported {
+		t.Errorf("Repository.Capabilities().Deletion = %v, want %v", got, CapabilityUnsupported)
+	}
+}
@@ -75,28 +75,56 @@ func Credential(store Store) auth.CredentialFunc {
 		if hostport == "" {
 			return auth.EmptyCredential, nil
 		}
-		return store.Get(ctx, hostport)
+		if hostport != dockerHubServerAddress {
+			return store.Get(ctx, hostport)
+		}
+		// credentials of docker.io may be stored under the user-facing
+		// registry names as well
+		for _, key := range dockerHubCredentialKeys {
+			cred, err := store.Get(ctx, key)
+			if err != nil {
+				return auth.EmptyCredential, err
+			}
+			if cred != auth.EmptyCredential {
+				return cred, nil
+			}
+		}
+		return auth.EmptyCredential, nil
 	}
 }
 
+// dockerHubServerAddress is the server address of the registry 'docker.io',
+// which is used as a key for credentials store.
+const dockerHubServerAddress = "https://index.docker.io/v1/"
+
+// dockerHubCredentialKeys lists the keys, in the order of lookup, under which
+// the credentials of the registry 'docker.io' may be stored.
+var dockerHubCredentialKeys = []string{
+	dockerHubServerAddress,
+	"docker.io",
+	"index.docker.io",
+	"registry-1.docker.io",
+}
+
 // ServerAddressFromRegistry maps a registry to a server address, which is used as
 // a key for credentials store. The Docker CLI expects that the credentials of
 // the registry 'docker.io' will be added under the key "https://index.docker.io/v1/".
 // See: https://github.com/moby/moby/blob/v24.0.2/registry/config.go#L25-L48
 func ServerAddressFromRegistry(registry string) string {
 	if registry == "docker.io" {
-		return "https://index.docker.io/v1/"
+		return dockerHubServerAddress
 	}
 	return registry
 }
 
 // ServerAddressFromHostname maps a hostname to a server address, which is used as
 // a key for credentials store. It is expected that the traffic targetting the
-// host "registry-1.docker.io" will be redirected to "https://index.docker.io/v1/".
+// hosts "registry-1.docker.io" and "index.docker.io" will be redirected to
+// "https://index.docker.io/v1/".
 // See: https://github.com/moby/moby/blob/v24.0.2/registry/config.go#L25-L48
 func ServerAddressFromHostname(hostname string) string {
-	if hostname == "registry-1.docker.io" {
-		return "https://index.docker.io/v1/"
+	if hostname == "registry-1.docker.io" || hostname == "index.docker.io" {
+		return dockerHubServerAddress
 	}
 	return hostname
 }
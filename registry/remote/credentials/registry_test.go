@@ -222,6 +222,11 @@ func TestCredential(t *testing.T) {
 			registry:       "registry-1.docker.io",
 			wantCredential: auth.Credential{Username: "user", Password: "word"},
 		},
+		{
+			name:           "get credentials for index.docker.io",
+			registry:       "index.docker.io",
+			wantCredential: auth.Credential{Username: "user", Password: "word"},
+		},
 		{
 			name:           "get credentials for a registry not stored",
 			registry:       "localhost:6666",
@@ -245,3 +250,39 @@ func TestCredential(t *testing.T) {
 		})
 	}
 }
+
+func TestCredential_DockerHubKeys(t *testing.T) {
+	cred := auth.Credential{Username: "user", Password: "word"}
+	for _, key := range []string{"docker.io", "index.docker.io", "registry-1.docker.io"} {
+		t.Run(key, func(t *testing.T) {
+			s := &testStore{}
+			s.storage = map[string]auth.Credential{
+				key: cred,
+			}
+			credFunc := Credential(s)
+			for _, host := range []string{"registry-1.docker.io", "index.docker.io"} {
+				got, err := credFunc(context.Background(), host)
+				if err != nil {
+					t.Fatalf("Credential() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, cred) {
+					t.Errorf("Credential(%s) = %v, want %v", host, got, cred)
+				}
+			}
+		})
+	}
+
+	// the server address takes precedence
+	s := &testStore{}
+	s.storage = map[string]auth.Credential{
+		"https://index.docker.io/v1/": cred,
+		"docker.io":                   {Username: "other", Password: "other"},
+	}
+	got, err := Credential(s)(context.Background(), "registry-1.docker.io")
+	if err != nil {
+		t.Fatalf("Credential() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, cred) {
+		t.Errorf("Credential() = %v, want %v", got, cred)
+	}
+}
@@ -16,10 +16,13 @@ limitations under the License.
 package errcode
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -40,6 +43,24 @@ const (
 	ErrorCodeUnauthorized        = "UNAUTHORIZED"
 	ErrorCodeDenied              = "DENIED"
 	ErrorCodeUnsupported         = "UNSUPPORTED"
+	ErrorCodeTooManyRequests     = "TOOMANYREQUESTS"
+)
+
+// Common failure modes of the remote registry, which can be checked against
+// an ErrorResponse with errors.Is.
+var (
+	// ErrUnauthorized indicates that the request is not authenticated, by the
+	// status code 401 or the error code UNAUTHORIZED.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrDenied indicates that the access to the resource is denied, by the
+	// status code 403 or the error code DENIED.
+	ErrDenied = errors.New("denied")
+	// ErrRepositoryNotFound indicates that the repository is unknown to the
+	// remote registry, by the error code NAME_UNKNOWN.
+	ErrRepositoryNotFound = errors.New("repository not found")
+	// ErrTooManyRequests indicates that the request is rate-limited, by the
+	// status code 429 or the error code TOOMANYREQUESTS.
+	ErrTooManyRequests = errors.New("too many requests")
 )
 
 // Error represents a response inner error returned by the remote
@@ -106,6 +127,10 @@ type ErrorResponse struct {
 	URL        *url.URL
 	StatusCode int
 	Errors     Errors
+	// RetryAfter is the duration to wait before retrying, parsed from the
+	// `Retry-After` header of the response. It is zero if the header is
+	// absent or invalid.
+	RetryAfter time.Duration
 }
 
 // Error returns a error string describing the error.
@@ -126,3 +151,28 @@ func (err *ErrorResponse) Unwrap() error {
 	}
 	return err.Errors
 }
+
+// Is returns true if target is one of the failure modes indicated by the
+// status code or the error codes of err, i.e. ErrUnauthorized, ErrDenied,
+// ErrRepositoryNotFound or ErrTooManyRequests.
+func (err *ErrorResponse) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return err.StatusCode == http.StatusUnauthorized || err.hasCode(ErrorCodeUnauthorized)
+	case ErrDenied:
+		return err.StatusCode == http.StatusForbidden || err.hasCode(ErrorCodeDenied)
+	case ErrRepositoryNotFound:
+		return err.hasCode(ErrorCodeNameUnknown)
+	case ErrTooManyRequests:
+		return err.StatusCode == http.StatusTooManyRequests || err.hasCode(ErrorCodeTooManyRequests)
+	default:
+		return false
+	}
+}
+
+// hasCode returns true if any of the inner errors of err has the given code.
+func (err *ErrorResponse) hasCode(code string) bool {
+	return slices.ContainsFunc(err.Errors, func(e Error) bool {
+		return e.Code == code
+	})
+}
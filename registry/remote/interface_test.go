@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/internal/interfaces"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
@@ -35,4 +36,7 @@ func TestRepositoryInterface(t *testing.T) {
 	if _, ok := repo.(interfaces.ReferenceParser); !ok {
 		t.Error("&Repository{} does not conform interfaces.ReferenceParser")
 	}
+	if _, ok := repo.(content.DigestAlgorithmLimiter); !ok {
+		t.Error("&Repository{} does not conform content.DigestAlgorithmLimiter")
+	}
 }
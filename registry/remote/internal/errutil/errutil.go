@@ -20,6 +20,8 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"oras.land/oras-go/v2/registry/remote/errcode"
 )
@@ -44,11 +46,45 @@ func ParseErrorResponse(resp *http.Response) error {
 	if err := json.NewDecoder(lr).Decode(&body); err == nil {
 		resultErr.Errors = body.Errors
 	}
+	if d, ok := ParseRetryAfter(resp, time.Now()); ok {
+		resultErr.RetryAfter = d
+	}
 	return resultErr
 }
 
+// ParseRetryAfter parses the Retry-After header of resp, which is either a
+// number of seconds or an HTTP date.
+// Reference: https://www.rfc-editor.org/rfc/rfc9110.html#name-retry-after
+func ParseRetryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
 // IsErrorCode returns true if err is an Error and its Code equals to code.
 func IsErrorCode(err error, code string) bool {
 	var ec errcode.Error
 	return errors.As(err, &ec) && ec.Code == code
 }
+
+// IsBlobUploadSessionError returns true if err indicates that the blob upload
+// session is invalid or unknown to the remote registry, which can be recovered
+// by restarting the upload with a new session.
+func IsBlobUploadSessionError(err error) bool {
+	return IsErrorCode(err, errcode.ErrorCodeBlobUploadInvalid) ||
+		IsErrorCode(err, errcode.ErrorCodeBlobUploadUnknown)
+}
@@ -19,8 +19,10 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"oras.land/oras-go/v2/registry/remote/errcode"
 )
@@ -127,6 +129,91 @@ func Test_ParseErrorResponse_plain(t *testing.T) {
 	}
 }
 
+func Test_ParseErrorResponse_failureModes(t *testing.T) {
+	tests := []struct {
+		name           string
+		statusCode     int
+		header         http.Header
+		body           string
+		wantErrs       []error
+		wantRetryAfter time.Duration
+	}{
+		{
+			name:       "unauthorized",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`,
+			wantErrs:   []error{errcode.ErrUnauthorized},
+		},
+		{
+			name:       "denied",
+			statusCode: http.StatusForbidden,
+			wantErrs:   []error{errcode.ErrDenied},
+		},
+		{
+			name:       "repository not found",
+			statusCode: http.StatusNotFound,
+			body:       `{"errors":[{"code":"NAME_UNKNOWN","message":"repository name not known to registry"}]}`,
+			wantErrs:   []error{errcode.ErrRepositoryNotFound},
+		},
+		{
+			name:           "too many requests",
+			statusCode:     http.StatusTooManyRequests,
+			header:         http.Header{"Retry-After": {"30"}},
+			body:           `{"errors":[{"code":"TOOMANYREQUESTS","message":"rate limit exceeded"}]}`,
+			wantErrs:       []error{errcode.ErrTooManyRequests},
+			wantRetryAfter: 30 * time.Second,
+		},
+		{
+			name:       "manifest unknown",
+			statusCode: http.StatusNotFound,
+			body:       `{"errors":[{"code":"MANIFEST_UNKNOWN","message":"manifest unknown"}]}`,
+		},
+	}
+	allErrs := []error{
+		errcode.ErrUnauthorized,
+		errcode.ErrDenied,
+		errcode.ErrRepositoryNotFound,
+		errcode.ErrTooManyRequests,
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tt.statusCode)
+				if _, err := w.Write([]byte(tt.body)); err != nil {
+					t.Errorf("failed to write %q: %v", r.URL, err)
+				}
+			}))
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL)
+			if err != nil {
+				t.Fatalf("failed to do request: %v", err)
+			}
+			defer resp.Body.Close()
+			err = ParseErrorResponse(resp)
+			for _, target := range allErrs {
+				want := slices.Contains(tt.wantErrs, target)
+				if got := errors.Is(err, target); got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, target, got, want)
+				}
+			}
+			var errResp *errcode.ErrorResponse
+			if !errors.As(err, &errResp) {
+				t.Fatalf("ParseErrorResponse() error = %v, want %T", err, errResp)
+			}
+			if errResp.StatusCode != tt.statusCode {
+				t.Errorf("ParseErrorResponse() StatusCode = %v, want %v", errResp.StatusCode, tt.statusCode)
+			}
+			if errResp.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("ParseErrorResponse() RetryAfter = %v, want %v", errResp.RetryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
 func TestIsErrorCode(t *testing.T) {
 	tests := []struct {
 		name string
@@ -237,3 +324,45 @@ func TestIsErrorCode(t *testing.T) {
 		})
 	}
 }
+
+func TestIsBlobUploadSessionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "blob upload invalid",
+			err: &errcode.ErrorResponse{
+				Errors: errcode.Errors{{Code: errcode.ErrorCodeBlobUploadInvalid}},
+			},
+			want: true,
+		},
+		{
+			name: "blob upload unknown",
+			err: &errcode.ErrorResponse{
+				Errors: errcode.Errors{{Code: errcode.ErrorCodeBlobUploadUnknown}},
+			},
+			want: true,
+		},
+		{
+			name: "other error code",
+			err: &errcode.ErrorResponse{
+				Errors: errcode.Errors{{Code: errcode.ErrorCodeDigestInvalid}},
+			},
+			want: false,
+		},
+		{
+			name: "nil error",
+			err:  nil,
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBlobUploadSessionError(tt.err); got != tt.want {
+				t.Errorf("IsBlobUploadSessionError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -16,25 +16,78 @@ limitations under the License.
 package remote
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/docker"
 	"oras.land/oras-go/v2/internal/spec"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+	"oras.land/oras-go/v2/registry/remote/internal/errutil"
 )
 
-// defaultManifestMediaTypes contains the default set of manifests media types.
+// defaultManifestMediaTypes contains the default set of manifests media types,
+// in the order of preference.
 var defaultManifestMediaTypes = []string{
-	docker.MediaTypeManifest,
-	docker.MediaTypeManifestList,
 	ocispec.MediaTypeImageManifest,
 	ocispec.MediaTypeImageIndex,
 	spec.MediaTypeArtifactManifest,
+	docker.MediaTypeManifest,
+	docker.MediaTypeManifestList,
+}
+
+// DefaultManifestMediaTypes returns a copy of the default manifest media
+// types, in the order of preference, which are used if
+// Repository.ManifestMediaTypes is empty. It can be used to augment the
+// default media types with custom ones, e.g.
+//
+//	repo.ManifestMediaTypes = append(remote.DefaultManifestMediaTypes(), customMediaType)
+func DefaultManifestMediaTypes() []string {
+	return slices.Clone(defaultManifestMediaTypes)
+}
+
+// defaultManifestMediaTypeQualities contains the q-values of the default
+// manifest media types, which prefer the OCI manifest media types over the
+// Docker ones.
+// See also: Repository.ManifestMediaTypeQualities
+var defaultManifestMediaTypeQualities = map[string]float64{
+	docker.MediaTypeManifest:     0.9,
+	docker.MediaTypeManifestList: 0.9,
+}
+
+// dockerManifestMediaTypes contains the Docker manifest media types, which are
+// used for retrying when resolving manifests with non-Docker media types fails.
+// See also: Repository.FallbackToDockerMediaTypes
+var dockerManifestMediaTypes = []string{
+	docker.MediaTypeManifest,
+	docker.MediaTypeManifestList,
+}
+
+// manifestSchemaVersions maps the manifest media types to their expected
+// schemaVersion.
+var manifestSchemaVersions = map[string]int{
+	docker.MediaTypeManifestSchema1:       1,
+	docker.MediaTypeManifestSchema1Signed: 1,
+	docker.MediaTypeManifest:              2,
+	docker.MediaTypeManifestList:          2,
+	ocispec.MediaTypeImageManifest:        2,
+	ocispec.MediaTypeImageIndex:           2,
 }
 
 // defaultManifestAcceptHeader is the default set in the `Accept` header for
 // resolving manifests from tags.
-var defaultManifestAcceptHeader = strings.Join(defaultManifestMediaTypes, ", ")
+var defaultManifestAcceptHeader = buildAcceptHeader(defaultManifestMediaTypes, defaultManifestMediaTypeQualities)
 
 // isManifest determines if the given descriptor points to a manifest.
 func isManifest(manifestMediaTypes []string, desc ocispec.Descriptor) bool {
@@ -51,9 +104,164 @@ func isManifest(manifestMediaTypes []string, desc ocispec.Descriptor) bool {
 
 // manifestAcceptHeader generates the set in the `Accept` header for resolving
 // manifests from tags.
-func manifestAcceptHeader(manifestMediaTypes []string) string {
+func manifestAcceptHeader(manifestMediaTypes []string, qualities map[string]float64) string {
 	if len(manifestMediaTypes) == 0 {
-		return defaultManifestAcceptHeader
+		if qualities == nil {
+			return defaultManifestAcceptHeader
+		}
+		manifestMediaTypes = defaultManifestMediaTypes
+	}
+	return buildAcceptHeader(manifestMediaTypes, qualities)
+}
+
+// buildAcceptHeader builds the `Accept` header from the media types in the
+// given order, where the media types found in qualities are weighted by the
+// q-values, rounded to 3 decimal places and clamped to the range [0, 1].
+// Reference: https://www.rfc-editor.org/rfc/rfc9110#section-12.4.2
+func buildAcceptHeader(mediaTypes []string, qualities map[string]float64) string {
+	var sb strings.Builder
+	for i, mediaType := range mediaTypes {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(mediaType)
+		if q, ok := qualities[mediaType]; ok {
+			q = min(max(math.Round(q*1000)/1000, 0), 1)
+			sb.WriteString(";q=")
+			sb.WriteString(strconv.FormatFloat(q, 'f', -1, 64))
+		}
+	}
+	return sb.String()
+}
+
+// hasDockerManifestMediaType determines if the given manifest media types
+// contain any Docker manifest media type.
+func hasDockerManifestMediaType(manifestMediaTypes []string) bool {
+	if len(manifestMediaTypes) == 0 {
+		manifestMediaTypes = defaultManifestMediaTypes
+	}
+	return slices.ContainsFunc(manifestMediaTypes, func(mediaType string) bool {
+		return slices.Contains(dockerManifestMediaTypes, mediaType)
+	})
+}
+
+// probeManifest is the invalid manifest pushed for probing the manifest media
+// types supported by a registry.
+var probeManifest = []byte("{}")
+
+// ProbeManifestMediaTypes probes which of the given manifest media types are
+// accepted by the remote repository on push, and returns the accepted ones in
+// the given order. If no media type is given, r.ManifestMediaTypes or the
+// default manifest media types are probed.
+//
+// The probe is best-effort and heuristic: for each media type, an invalid
+// manifest is pushed by digest with the media type as the `Content-Type`.
+//   - If the registry responds with `415 Unsupported Media Type`, or with the
+//     `UNSUPPORTED` error code, the media type is considered unsupported.
+//   - If the registry rejects the manifest for other reasons, such as
+//     `MANIFEST_INVALID`, or accepts it, the media type is considered
+//     supported.
+//   - Other failures, such as authentication failures, are returned as errors.
+//
+// Since registries are not required to report unsupported media types in a
+// distinguishable way, a media type reported as supported may still be
+// rejected on the actual push.
+//
+// The probe writes to the remote repository and thus requires the push
+// permission. If the probe manifest is accepted, it is deleted on a
+// best-effort basis, which requires the delete permission. Otherwise, the
+// probe manifest is left in the repository.
+func (r *Repository) ProbeManifestMediaTypes(ctx context.Context, mediaTypes ...string) ([]string, error) {
+	if len(mediaTypes) == 0 {
+		mediaTypes = r.ManifestMediaTypes
+		if len(mediaTypes) == 0 {
+			mediaTypes = defaultManifestMediaTypes
+		}
+	}
+	var supported []string
+	for _, mediaType := range mediaTypes {
+		ok, err := r.probeManifestMediaType(ctx, mediaType)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			supported = append(supported, mediaType)
+		}
+	}
+	return supported, nil
+}
+
+// probeManifestMediaType probes if mediaType is accepted by the remote
+// repository on push.
+func (r *Repository) probeManifestMediaType(ctx context.Context, mediaType string) (bool, error) {
+	probeDesc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(probeManifest),
+		Size:      int64(len(probeManifest)),
+	}
+	ref := r.Reference
+	ref.Reference = probeDesc.Digest.String()
+	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull, auth.ActionPush)
+	url := buildRepositoryManifestURL(r.PlainHTTP, ref)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(probeManifest))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", mediaType)
+	resp, err := r.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		// clean up the probe manifest accepted (error ignored)
+		_ = r.delete(ctx, probeDesc, true)
+		return true, nil
+	case http.StatusUnsupportedMediaType:
+		return false, nil
+	case http.StatusBadRequest:
+		if err := errutil.ParseErrorResponse(resp); errutil.IsErrorCode(err, errcode.ErrorCodeUnsupported) {
+			return false, nil
+		}
+		// the media type is recognized but the manifest is rejected
+		return true, nil
+	default:
+		return false, errutil.ParseErrorResponse(resp)
+	}
+}
+
+// verifyManifestMediaType verifies that the mediaType embedded in the manifest
+// of resp, if present, matches mediaType, and that the schemaVersion, if
+// present, is the one expected for mediaType.
+// The response body is buffered in memory and restored for later reads.
+// See also: Repository.VerifyManifestMediaType
+func verifyManifestMediaType(resp *http.Response, mediaType string, maxMetadataBytes int64) error {
+	body := resp.Body
+	defer body.Close()
+	manifestJSON, err := io.ReadAll(limitReader(body, maxMetadataBytes))
+	if err != nil {
+		return fmt.Errorf("%s %q: failed to read response body: %w", resp.Request.Method, resp.Request.URL, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(manifestJSON))
+
+	var manifest struct {
+		SchemaVersion *int   `json:"schemaVersion"`
+		MediaType     string `json:"mediaType"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return fmt.Errorf("%s %q: failed to decode manifest: %w", resp.Request.Method, resp.Request.URL, err)
+	}
+	if manifest.MediaType != "" && manifest.MediaType != mediaType {
+		return fmt.Errorf("%s %q: mismatch manifest mediaType %q: expect %q: %w",
+			resp.Request.Method, resp.Request.URL, manifest.MediaType, mediaType, errdef.ErrInvalidMediaType)
+	}
+	if manifest.SchemaVersion != nil {
+		if want, ok := manifestSchemaVersions[mediaType]; ok && *manifest.SchemaVersion != want {
+			return fmt.Errorf("%s %q: mismatch manifest schemaVersion %d of media type %q: expect %d: %w",
+				resp.Request.Method, resp.Request.URL, *manifest.SchemaVersion, mediaType, want, errdef.ErrUnsupportedVersion)
+		}
 	}
-	return strings.Join(manifestMediaTypes, ", ")
+	return nil
 }
@@ -17,6 +17,8 @@ package remote
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
@@ -28,6 +30,12 @@ import (
 // for pinging Referrers API.
 const zeroDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
 
+// defaultReferrersMediaTypes contains the default set of media types accepted in
+// the responses of the Referrers API.
+var defaultReferrersMediaTypes = []string{
+	ocispec.MediaTypeImageIndex,
+}
+
 // referrersState represents the state of Referrers API.
 type referrersState = int32
 
@@ -42,6 +50,32 @@ const (
 	referrersStateUnsupported
 )
 
+// ReferrersMethod represents the method used to discover the referrers of a
+// manifest.
+type ReferrersMethod int
+
+const (
+	// ReferrersMethodAPI represents that the referrers are listed by the
+	// Referrers API.
+	ReferrersMethodAPI ReferrersMethod = iota + 1
+	// ReferrersMethodTagSchema represents that the referrers are listed from
+	// the referrers index tagged by the referrers tag schema, as the fallback
+	// of the Referrers API.
+	ReferrersMethodTagSchema
+)
+
+// String returns the name of the method.
+func (m ReferrersMethod) String() string {
+	switch m {
+	case ReferrersMethodAPI:
+		return "referrers API"
+	case ReferrersMethodTagSchema:
+		return "referrers tag schema"
+	default:
+		return fmt.Sprintf("unknown referrers method %d", int(m))
+	}
+}
+
 // referrerOperation represents an operation on a referrer.
 type referrerOperation = int32
 
@@ -66,6 +100,10 @@ var (
 	// errNoReferrerUpdate is returned by applyReferrerChanges() when there
 	// is no any referrer update.
 	errNoReferrerUpdate = errors.New("no referrer update")
+
+	// errPreconditionFailed is returned by manifestStore.pushIfMatch() when
+	// the manifest has been modified since its ETag was read.
+	errPreconditionFailed = errors.New("precondition failed")
 )
 
 const (
@@ -109,6 +147,94 @@ func buildReferrersTag(desc ocispec.Descriptor) string {
 	return alg + "-" + encoded
 }
 
+// referrersAcceptHeader generates the set in the `Accept` header for requesting
+// the Referrers API.
+func referrersAcceptHeader(referrersMediaTypes []string) string {
+	if len(referrersMediaTypes) == 0 {
+		referrersMediaTypes = defaultReferrersMediaTypes
+	}
+	return strings.Join(referrersMediaTypes, ", ")
+}
+
+// isReferrersMediaType determines if the given content type is an accepted
+// response media type of the Referrers API.
+func isReferrersMediaType(referrersMediaTypes []string, contentType string) bool {
+	if len(referrersMediaTypes) == 0 {
+		referrersMediaTypes = defaultReferrersMediaTypes
+	}
+	return slices.Contains(referrersMediaTypes, contentType)
+}
+
+// ReferrersFilter specifies the filters applied on the list of referrers.
+type ReferrersFilter struct {
+	// ArtifactType filters the referrers by the artifact type, if not empty.
+	ArtifactType string
+
+	// ArtifactTypes filters the referrers by multiple artifact types, if not
+	// empty. A referrer matches if its artifact type is any of ArtifactTypes
+	// or ArtifactType.
+	// As the distribution-spec defines the server side filtering by a single
+	// artifact type only, the remote registry is requested to filter by
+	// artifact type only if a single one is specified in total. Otherwise,
+	// the referrers are filtered on the client side regardless of the
+	// "OCI-Filters-Applied" response header.
+	ArtifactTypes []string
+
+	// Annotations filters the referrers by the annotations, if not empty.
+	// A referrer matches if it has all of the annotations with the same
+	// values.
+	// The annotation filter is not defined by the distribution-spec, and is
+	// applied on the client side unless the remote registry reports it as
+	// applied.
+	Annotations map[string]string
+}
+
+// isEmpty returns true if no filter is specified.
+func (f ReferrersFilter) isEmpty() bool {
+	return f.ArtifactType == "" && len(f.ArtifactTypes) == 0 && len(f.Annotations) == 0
+}
+
+// artifactTypes returns the distinct artifact types to filter by.
+func (f ReferrersFilter) artifactTypes() []string {
+	var types []string
+	if f.ArtifactType != "" {
+		types = append(types, f.ArtifactType)
+	}
+	for _, artifactType := range f.ArtifactTypes {
+		if artifactType != "" && !slices.Contains(types, artifactType) {
+			types = append(types, artifactType)
+		}
+	}
+	return types
+}
+
+// queryArtifactType returns the artifact type to be filtered by the remote
+// registry, or an empty string if the artifact types are to be filtered on the
+// client side.
+func (f ReferrersFilter) queryArtifactType() string {
+	if types := f.artifactTypes(); len(types) == 1 {
+		return types[0]
+	}
+	return ""
+}
+
+// apply filters refs in place by the filters that are not listed in any of
+// the applied filter lists. The returned slice contains matching referrers.
+func (f ReferrersFilter) apply(refs []ocispec.Descriptor, appliedLists ...string) []ocispec.Descriptor {
+	isApplied := func(filterType string) bool {
+		return slices.ContainsFunc(appliedLists, func(applied string) bool {
+			return isReferrersFilterApplied(applied, filterType)
+		})
+	}
+	if types := f.artifactTypes(); len(types) > 1 || !isApplied(filterTypeArtifactType) {
+		refs = filterReferrers(refs, types...)
+	}
+	if !isApplied(filterTypeAnnotation) {
+		refs = filterReferrersByAnnotations(refs, f.Annotations)
+	}
+	return refs
+}
+
 // isReferrersFilterApplied checks if requsted is in the applied filter list.
 func isReferrersFilterApplied(applied, requested string) bool {
 	if applied == "" || requested == "" {
@@ -116,22 +242,43 @@ func isReferrersFilterApplied(applied, requested string) bool {
 	}
 	filters := strings.Split(applied, ",")
 	for _, f := range filters {
-		if f == requested {
+		if strings.TrimSpace(f) == requested {
 			return true
 		}
 	}
 	return false
 }
 
-// filterReferrers filters a slice of referrers by artifactType in place.
-// The returned slice contains matching referrers.
-func filterReferrers(refs []ocispec.Descriptor, artifactType string) []ocispec.Descriptor {
-	if artifactType == "" {
+// filterReferrers filters a slice of referrers in place by artifactTypes, of
+// which any one matches. The returned slice contains matching referrers.
+func filterReferrers(refs []ocispec.Descriptor, artifactTypes ...string) []ocispec.Descriptor {
+	artifactTypes = slices.DeleteFunc(slices.Clone(artifactTypes), func(artifactType string) bool {
+		return artifactType == ""
+	})
+	if len(artifactTypes) == 0 {
+		return refs
+	}
+	var j int
+	for i, ref := range refs {
+		if slices.Contains(artifactTypes, ref.ArtifactType) {
+			if i != j {
+				refs[j] = ref
+			}
+			j++
+		}
+	}
+	return refs[:j]
+}
+
+// filterReferrersByAnnotations filters a slice of referrers by annotations in
+// place. The returned slice contains matching referrers.
+func filterReferrersByAnnotations(refs []ocispec.Descriptor, annotations map[string]string) []ocispec.Descriptor {
+	if len(annotations) == 0 {
 		return refs
 	}
 	var j int
 	for i, ref := range refs {
-		if ref.ArtifactType == artifactType {
+		if hasAnnotations(ref, annotations) {
 			if i != j {
 				refs[j] = ref
 			}
@@ -141,6 +288,17 @@ func filterReferrers(refs []ocispec.Descriptor, artifactType string) []ocispec.D
 	return refs[:j]
 }
 
+// hasAnnotations checks if desc has all of the annotations with the same
+// values.
+func hasAnnotations(desc ocispec.Descriptor, annotations map[string]string) bool {
+	for key, value := range annotations {
+		if v, ok := desc.Annotations[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
 // applyReferrerChanges applies referrerChanges on referrers and returns the
 // updated referrers.
 // Returns errNoReferrerUpdate if there is no any referrers updates.
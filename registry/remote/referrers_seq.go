@@ -0,0 +1,57 @@
+//go:build go1.23
+
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"iter"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// errStopReferrers signals that the caller of the referrers iterator stops the
+// iteration.
+var errStopReferrers = errors.New("stop referrers")
+
+// ReferrersSeq returns an iterator over the descriptors of image or artifact
+// manifests directly referencing the given manifest descriptor.
+//
+// Unlike [Repository.Referrers], the referrers are yielded one by one. The
+// pages of the referrers result are requested on demand, and no further page
+// is requested once the caller stops the iteration. If an error occurs, it is
+// yielded with an empty descriptor as the last element of the sequence.
+// If artifactType is not empty, only referrers of the same artifact type are
+// yielded.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
+func (r *Repository) ReferrersSeq(ctx context.Context, desc ocispec.Descriptor, artifactType string) iter.Seq2[ocispec.Descriptor, error] {
+	return func(yield func(ocispec.Descriptor, error) bool) {
+		err := r.Referrers(ctx, desc, artifactType, func(referrers []ocispec.Descriptor) error {
+			for _, referrer := range referrers {
+				if !yield(referrer, nil) {
+					return errStopReferrers
+				}
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errStopReferrers) {
+			yield(ocispec.Descriptor{}, err)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+//go:build go1.23
+
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/spec"
+)
+
+func TestRepository_ReferrersSeq(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	var referrers []ocispec.Descriptor
+	for i := 1; i <= 5; i++ {
+		artifactType := "application/vnd.test"
+		if i%2 == 0 {
+			artifactType = "application/vnd.foo"
+		}
+		referrers = append(referrers, ocispec.Descriptor{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         int64(i),
+			Digest:       digest.FromString(fmt.Sprint(i)),
+			ArtifactType: artifactType,
+		})
+	}
+	referrerSet := [][]ocispec.Descriptor{
+		referrers[0:2],
+		referrers[2:4],
+		referrers[4:],
+	}
+	var pageCount atomic.Int64
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		pageCount.Add(1)
+		var page []ocispec.Descriptor
+		switch r.URL.Query().Get("test") {
+		case "foo":
+			page = referrerSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=bar>; rel="next"`, path))
+		case "bar":
+			page = referrerSet[2]
+		default:
+			page = referrerSet[0]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
+		}
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: page,
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ctx := context.Background()
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.ReferrerListPageSize = 2
+
+	// test iterating over all pages
+	var got []ocispec.Descriptor
+	for referrer, err := range repo.ReferrersSeq(ctx, manifestDesc, "") {
+		if err != nil {
+			t.Fatalf("Repository.ReferrersSeq() error = %v", err)
+		}
+		got = append(got, referrer)
+	}
+	if !reflect.DeepEqual(got, referrers) {
+		t.Errorf("Repository.ReferrersSeq() = %v, want %v", got, referrers)
+	}
+	if got := pageCount.Load(); got != 3 {
+		t.Errorf("count(pages) = %d, want %d", got, 3)
+	}
+
+	// test client-side filtering
+	got = nil
+	for referrer, err := range repo.ReferrersSeq(ctx, manifestDesc, "application/vnd.test") {
+		if err != nil {
+			t.Fatalf("Repository.ReferrersSeq() error = %v", err)
+		}
+		got = append(got, referrer)
+	}
+	if want := []ocispec.Descriptor{referrers[0], referrers[2], referrers[4]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.ReferrersSeq() = %v, want %v", got, want)
+	}
+
+	// test early termination
+	pageCount.Store(0)
+	got = nil
+	for referrer, err := range repo.ReferrersSeq(ctx, manifestDesc, "") {
+		if err != nil {
+			t.Fatalf("Repository.ReferrersSeq() error = %v", err)
+		}
+		got = append(got, referrer)
+		if len(got) == 1 {
+			break
+		}
+	}
+	if want := referrers[:1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.ReferrersSeq() = %v, want %v", got, want)
+	}
+	if got := pageCount.Load(); got != 1 {
+		t.Errorf("count(pages) = %d, want %d", got, 1)
+	}
+}
+
+func TestRepository_ReferrersSeq_TagSchemaFallback(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         2,
+			Digest:       digest.FromString("2"),
+			ArtifactType: "application/vnd.foo",
+		},
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+		path := "/v2/test/manifests/" + referrersTag
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			if r.URL.Path != "/v2/test/referrers/"+manifestDesc.Digest.String() {
+				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ctx := context.Background()
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	var got []ocispec.Descriptor
+	for referrer, err := range repo.ReferrersSeq(ctx, manifestDesc, "application/vnd.test") {
+		if err != nil {
+			t.Fatalf("Repository.ReferrersSeq() error = %v", err)
+		}
+		got = append(got, referrer)
+	}
+	if want := referrers[:1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.ReferrersSeq() = %v, want %v", got, want)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	}
+}
+
+func TestRepository_ReferrersSeq_Error(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ctx := context.Background()
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.SetReferrersCapability(true)
+	var errs []error
+	for referrer, err := range repo.ReferrersSeq(ctx, manifestDesc, "") {
+		if err == nil {
+			t.Errorf("Repository.ReferrersSeq() = %v, want no referrer", referrer)
+			continue
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Repository.ReferrersSeq() errors = %v, want 1 error", errs)
+	}
+	if !errors.Is(errs[0], errdef.ErrUnsupported) {
+		t.Errorf("Repository.ReferrersSeq() error = %v, wantErr %v", errs[0], errdef.ErrUnsupported)
+	}
+}
@@ -17,6 +17,7 @@ package remote
 
 import (
 	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/opencontainers/go-digest"
@@ -86,6 +87,12 @@ func Test_isReferrersFilterApplied(t *testing.T) {
 			requested: "artifactType",
 			want:      true,
 		},
+		{
+			name:      "multiple filters applied with spaces, specified filter matches",
+			applied:   "foo, artifactType",
+			requested: "artifactType",
+			want:      true,
+		},
 		{
 			name:      "multiple filters applied, specified filter does not match",
 			applied:   "foo,bar",
@@ -120,6 +127,45 @@ func Test_isReferrersFilterApplied(t *testing.T) {
 	}
 }
 
+func Test_isReferrersMediaType(t *testing.T) {
+	tests := []struct {
+		name        string
+		mediaTypes  []string
+		contentType string
+		want        bool
+	}{
+		{
+			name:        "default media types, image index",
+			contentType: ocispec.MediaTypeImageIndex,
+			want:        true,
+		},
+		{
+			name:        "default media types, json",
+			contentType: "application/json",
+			want:        false,
+		},
+		{
+			name:        "custom media types, matched",
+			mediaTypes:  []string{"application/vnd.example.index+json"},
+			contentType: "application/vnd.example.index+json",
+			want:        true,
+		},
+		{
+			name:        "custom media types, image index not listed",
+			mediaTypes:  []string{"application/vnd.example.index+json"},
+			contentType: ocispec.MediaTypeImageIndex,
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isReferrersMediaType(tt.mediaTypes, tt.contentType); got != tt.want {
+				t.Errorf("isReferrersMediaType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_filterReferrers(t *testing.T) {
 	refs := []ocispec.Descriptor{
 		{
@@ -200,6 +246,106 @@ func Test_filterReferrers_allMatch(t *testing.T) {
 	}
 }
 
+func Test_filterReferrersByAnnotations(t *testing.T) {
+	refs := []ocispec.Descriptor{
+		{
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Size:        1,
+			Digest:      digest.FromString("1"),
+			Annotations: map[string]string{"foo": "bar", "hello": "world"},
+		},
+		{
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Size:        2,
+			Digest:      digest.FromString("2"),
+			Annotations: map[string]string{"foo": "baz"},
+		},
+		{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Size:      3,
+			Digest:    digest.FromString("3"),
+		},
+		{
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Size:        4,
+			Digest:      digest.FromString("4"),
+			Annotations: map[string]string{"foo": "bar"},
+		},
+	}
+	want := []ocispec.Descriptor{refs[0], refs[3]}
+	got := filterReferrersByAnnotations(slices.Clone(refs), map[string]string{"foo": "bar"})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("filterReferrersByAnnotations() = %v, want %v", got, want)
+	}
+
+	got = filterReferrersByAnnotations(slices.Clone(refs), nil)
+	if !reflect.DeepEqual(got, refs) {
+		t.Errorf("filterReferrersByAnnotations() = %v, want %v", got, refs)
+	}
+}
+
+func TestReferrersFilter_apply(t *testing.T) {
+	refs := []ocispec.Descriptor{
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+			Annotations:  map[string]string{"foo": "bar"},
+		},
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         2,
+			Digest:       digest.FromString("2"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         3,
+			Digest:       digest.FromString("3"),
+			ArtifactType: "application/vnd.foo",
+			Annotations:  map[string]string{"foo": "bar"},
+		},
+	}
+	filter := ReferrersFilter{
+		ArtifactType: "application/vnd.test",
+		Annotations:  map[string]string{"foo": "bar"},
+	}
+	tests := []struct {
+		name    string
+		applied []string
+		want    []ocispec.Descriptor
+	}{
+		{
+			name: "no filter applied",
+			want: refs[:1],
+		},
+		{
+			name:    "artifactType applied",
+			applied: []string{"artifactType"},
+			want:    []ocispec.Descriptor{refs[0], refs[2]},
+		},
+		{
+			name:    "annotation applied",
+			applied: []string{"", "annotation"},
+			want:    refs[:2],
+		},
+		{
+			name:    "all filters applied",
+			applied: []string{"artifactType,annotation"},
+			want:    refs,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filter.apply(slices.Clone(refs), tt.applied...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReferrersFilter.apply() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_applyReferrerChanges(t *testing.T) {
 	descs := []ocispec.Descriptor{
 		{
@@ -76,11 +76,12 @@ func (r *Registry) client() Client {
 // do sends an HTTP request and returns an HTTP response using the HTTP client
 // returned by r.client().
 func (r *Registry) do(req *http.Request) (*http.Response, error) {
+	client := withURLRewrite(r.client(), r.HostRewrite)
 	if r.HandleWarning == nil {
-		return r.client().Do(req)
+		return client.Do(req)
 	}
 
-	resp, err := r.client().Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
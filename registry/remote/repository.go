@@ -22,13 +22,16 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"mime"
 	"net/http"
+	"net/url"
 	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go"
@@ -44,6 +47,7 @@ import (
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/errcode"
 	"oras.land/oras-go/v2/registry/remote/internal/errutil"
+	"oras.land/oras-go/v2/trace"
 )
 
 const (
@@ -68,6 +72,12 @@ const (
 	// If present on the response, it contains the digest of the subject,
 	// indicating that Referrers API is supported by the registry.
 	headerOCISubject = "OCI-Subject"
+
+	// headerOCIChunkMinLength is the "OCI-Chunk-Min-Length" header.
+	// If present on the response to the upload initiation, it indicates the
+	// minimum size of the chunks accepted by the registry.
+	// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pushing-a-blob-in-chunks
+	headerOCIChunkMinLength = "OCI-Chunk-Min-Length"
 )
 
 // filterTypeArtifactType is the "artifactType" filter applied on the list of
@@ -78,6 +88,15 @@ const (
 //   - Compatible spec: https://github.com/opencontainers/distribution-spec/blob/v1.1.0-rc1/spec.md#listing-referrers
 const filterTypeArtifactType = "artifactType"
 
+// filterTypeAnnotation is the "annotation" filter applied on the list of
+// referrers. It is not defined by the distribution-spec, and is only applied
+// by registries supporting it.
+const filterTypeAnnotation = "annotation"
+
+// maxTagLength is the maximum length of a tag.
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pulling-manifests
+const maxTagLength = 128
+
 // Client is an interface for a HTTP client.
 type Client interface {
 	// Do sends an HTTP request and returns an HTTP response.
@@ -105,11 +124,95 @@ type Repository struct {
 	PlainHTTP bool
 
 	// ManifestMediaTypes is used in `Accept` header for resolving manifests
-	// from references. It is also used in identifying manifests and blobs from
-	// descriptors. If an empty list is present, default manifest media types
-	// are used.
+	// from references, in the order of preference. It is also used in
+	// identifying manifests and blobs from descriptors. If an empty list is
+	// present, default manifest media types are used, where the OCI manifest
+	// media types are preferred over the Docker ones.
+	// To accept custom manifest media types in addition to the default ones,
+	// append them to DefaultManifestMediaTypes(). Note that the q-values of
+	// the default manifest media types are only sent if
+	// ManifestMediaTypeQualities is set accordingly.
 	ManifestMediaTypes []string
 
+	// ManifestMediaTypeQualities specifies the quality values (q-values),
+	// ranging from 0 to 1, of the media types in the `Accept` header for
+	// resolving manifests from references. When a manifest is available in
+	// multiple formats, registries honoring the q-values return the format
+	// with the highest q-value. Media types absent from the map are sent
+	// without q-values, which is equivalent to the q-value 1.
+	// If nil, no q-values are sent for a non-empty ManifestMediaTypes, while
+	// the Docker manifest media types are weighted by the q-value 0.9 in the
+	// default manifest media types.
+	// Reference: https://www.rfc-editor.org/rfc/rfc9110#section-12.5.1
+	ManifestMediaTypeQualities map[string]float64
+
+	// FallbackToDockerMediaTypes controls whether to retry resolving manifests
+	// from references with the Docker manifest media types in the `Accept`
+	// header, when the remote registry responds with 404 or 406 to the
+	// request with ManifestMediaTypes containing no Docker manifest media
+	// types. It is useful for mirrors serving Docker manifests only.
+	// By default, it is disabled (set to false), and the default manifest media
+	// types already include the Docker manifest media types.
+	FallbackToDockerMediaTypes bool
+
+	// FallbackToGET controls whether to resolve manifests with the GET method,
+	// discarding the response body, when the remote registry responds with
+	// 405 to HEAD requests for manifests. Once a 405 response is received, the
+	// repository remembers that the HEAD method is not supported and sends
+	// GET requests directly for subsequent resolutions.
+	// By default, it is disabled (set to false).
+	FallbackToGET bool
+
+	// FallbackToTemporaryTag controls whether to push manifests under a
+	// temporary tag when the remote registry rejects pushing manifests by
+	// digest with 400 or 405. After a successful push, the temporary tag is
+	// deleted on a best-effort basis. The temporary tag remains in the
+	// repository if the remote registry does not support deleting tags.
+	// By default, it is disabled (set to false).
+	FallbackToTemporaryTag bool
+
+	// ProbeRangeSupport controls whether to probe the range request capability
+	// of the remote registry by fetching blobs with the `Range: bytes=0-`
+	// header. If the remote registry responds with 206 and a Content-Range
+	// covering the whole blob, the fetched blob is seekable even if the
+	// Accept-Ranges header is absent, which is the case for some servers.
+	// By default, it is disabled (set to false), and fetched blobs are
+	// seekable only if the remote registry responds with
+	// `Accept-Ranges: bytes`.
+	ProbeRangeSupport bool
+
+	// VerifyManifestMediaType controls whether to verify the fetched
+	// manifests against their media types. If enabled, the `mediaType` field
+	// of a fetched manifest, when present, must match the `Content-Type` of
+	// the response, and the `schemaVersion` field, when present, must be the
+	// one defined for that media type. Manifests larger than MaxMetadataBytes
+	// are rejected as they are buffered in memory for the verification.
+	// By default, it is disabled (set to false).
+	VerifyManifestMediaType bool
+
+	// BlobUploadContentType returns the `Content-Type` header to be sent when
+	// uploading the blob described by desc, which can be used for the
+	// compatibility with the storage backends of the remote registry.
+	// The header does not affect the digest of the uploaded blob.
+	// If nil or an empty string is returned, "application/octet-stream" is
+	// used.
+	BlobUploadContentType func(desc ocispec.Descriptor) string
+
+	// UploadChunkSize specifies the maximum size of the chunks when pushing
+	// blobs in chunks.
+	//   - If positive, blobs larger than UploadChunkSize are streamed from
+	//     the source to the remote registry by a sequence of `PATCH` requests,
+	//     where only the current chunk is held in memory. As each chunk is
+	//     rewindable, a failed chunk request can be retried by the client
+	//     (e.g. auth.Client.RetryPolicy) without re-reading the source. The
+	//     chunk size is raised to the `OCI-Chunk-Min-Length` requested by the
+	//     remote registry, if any. If the remote registry does not accept
+	//     chunked uploads, the push falls back to a monolithic upload.
+	//   - If less than or equal to zero, blobs are pushed by a single
+	//     monolithic upload, which streams the content but cannot be retried.
+	// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pushing-a-blob-in-chunks
+	UploadChunkSize int64
+
 	// TagListPageSize specifies the page size when invoking the tag list API.
 	// If zero, the page size is determined by the remote registry.
 	// Reference: https://docs.docker.com/registry/spec/api/#tags
@@ -126,16 +229,56 @@ type Repository struct {
 	// Reference: https://github.com/oras-project/oras-go/issues/841
 	ReferrerListPageSize int
 
+	// ReferrerListWarnTruncation is invoked with the subject descriptor when
+	// the referrers listed by the Referrers API are suspected to be
+	// truncated, i.e. the last page contains exactly ReferrerListPageSize
+	// referrers but no `Link` header to the next page. Some registries are
+	// known to paginate the referrers without any continuation indicator
+	// defined by the distribution spec, in which case the remaining referrers
+	// cannot be listed.
+	// ReferrerListWarnTruncation is only invoked if ReferrerListPageSize is
+	// positive.
+	ReferrerListWarnTruncation func(subject ocispec.Descriptor)
+
+	// OnReferrersMethod is invoked with the subject descriptor and the method
+	// used to discover its referrers on each call to Referrers or
+	// ReferrersWithFilter, which is useful for diagnostics.
+	// The method is reported once it is determined, i.e. after probing the
+	// Referrers API if the referrers capability of the repository is unknown.
+	// See also: SetReferrersCapability
+	OnReferrersMethod func(subject ocispec.Descriptor, method ReferrersMethod)
+
+	// ReferrersMediaTypes is used in `Accept` header for requesting the
+	// Referrers API. It is also used in validating the `Content-Type` of the
+	// responses, where a response of other media types indicates that the
+	// Referrers API is not supported. The listed media types must share the
+	// schema of the OCI image index. If an empty list is present, only the OCI
+	// image index media type is accepted.
+	// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
+	ReferrersMediaTypes []string
+
 	// MaxMetadataBytes specifies a limit on how many response bytes are allowed
-	// in the server's response to the metadata APIs, such as catalog list, tag
-	// list, and referrers list.
+	// in the server's response to the metadata APIs, such as manifest fetch,
+	// catalog list, tag list, and referrers list. Responses exceeding the
+	// limit, either by the declared Content-Length or by the actual body, fail
+	// with errdef.ErrSizeExceedsLimit.
 	// If less than or equal to zero, a default (currently 4MiB) is used.
 	MaxMetadataBytes int64
 
+	// DigestAlgorithms restricts the digest algorithms of the blobs and the
+	// manifests accepted by the push operations, which is useful for
+	// registries supporting a limited set of digest algorithms. Content
+	// digested with other algorithms is rejected with errdef.ErrUnsupported
+	// without contacting the remote registry.
+	// If empty, the digest algorithms are not restricted.
+	// See also: content.DigestAlgorithmLimiter
+	DigestAlgorithms []digest.Algorithm
+
 	// SkipReferrersGC specifies whether to delete the dangling referrers
 	// index when referrers tag schema is utilized.
 	//  - If false, the old referrers index will be deleted after the new one
-	//    is successfully uploaded.
+	//    is successfully uploaded. The old referrers index is never deleted
+	//    if the upload of the new one fails.
 	//  - If true, the old referrers index is kept.
 	// By default, it is disabled (set to false). See also:
 	//  - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#referrers-tag-schema
@@ -143,6 +286,16 @@ type Repository struct {
 	//  - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#deleting-manifests
 	SkipReferrersGC bool
 
+	// ReferrerAnnotations returns the annotations of the descriptor of a
+	// referrer to be added into the referrers index when referrers tag schema
+	// is utilized. The given referrer descriptor carries a copy of the
+	// annotations of the referrer manifest, which can be filtered, for
+	// instance, to keep internal annotations out of the referrers index.
+	// The referrer manifest itself is pushed unchanged.
+	// If nil, the annotations of the referrer manifest are used as is.
+	// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#referrers-tag-schema
+	ReferrerAnnotations func(referrer ocispec.Descriptor) map[string]string
+
 	// HandleWarning handles the warning returned by the remote server.
 	// Callers SHOULD deduplicate warnings from multiple associated responses.
 	//
@@ -151,6 +304,49 @@ type Repository struct {
 	//   - https://www.rfc-editor.org/rfc/rfc7234#section-5.5
 	HandleWarning func(warning Warning)
 
+	// HostRewrite rewrites the URLs of the redirect targets and the upload
+	// session locations returned by the remote registry before they are
+	// requested, e.g. to route a redirect to an unreachable CDN host back
+	// through a proxy. Returning nil keeps the URL unchanged.
+	// The redirect targets are rewritten only if Client is an *auth.Client
+	// or an *http.Client.
+	// If nil, the URLs are requested as is.
+	HostRewrite func(u *url.URL) *url.URL
+
+	// ReadIdleTimeout limits the time a read of a fetched blob can be blocked
+	// without receiving any data, e.g. on a stalled connection. On timeout,
+	// the request is aborted and the read fails with an error wrapping
+	// context.DeadlineExceeded. The timeout is distinct from the deadline of
+	// the context, and is reset on each read.
+	// If less than or equal to zero, reads are limited by the context only.
+	ReadIdleTimeout time.Duration
+
+	// RequestTimeout limits the time of each HTTP request sent to the remote
+	// registry, including reading its response body, so that a single stalled
+	// request does not consume the whole deadline of the context. The timeout
+	// is applied on top of the context, which is still respected.
+	// Requests transferring blob content, i.e. fetching blobs and uploading
+	// blob data, are exempt from RequestTimeout as they may take arbitrarily
+	// long for large blobs. Use ReadIdleTimeout to limit stalled blob reads.
+	// If less than or equal to zero, requests are limited by the context only.
+	RequestTimeout time.Duration
+
+	// RequestPushScope requests the push action along with the pull action
+	// on the repository for every request, so that a single token covers
+	// both reading and writing the repository. It saves a token fetch when
+	// the repository is written after being read, or vice versa, e.g. the
+	// existence checks followed by the pushes in oras.Copy.
+	// The credential must be authorized to push to the repository. The
+	// requested scopes are only effective if Client is an *auth.Client.
+	// By default, it is disabled (set to false).
+	RequestPushScope bool
+
+	// Tracer traces the HTTP requests sent to the remote repository, if set.
+	// A span is started for each request, and ended once the response
+	// headers are received.
+	// See also: package oras.land/oras-go/v2/trace
+	Tracer trace.Tracer
+
 	// NOTE: Must keep fields in sync with clone().
 
 	// referrersState represents that if the repository supports Referrers API.
@@ -161,9 +357,27 @@ type Repository struct {
 	// one go-routine to send the request.
 	referrersPingLock sync.Mutex
 
+	// apiVersion is the API version returned by the base API in Ping().
+	apiVersion atomic.Pointer[string]
+
+	// chunkedUploadState represents if the repository is observed to support
+	// chunked uploads.
+	// default: capabilityStateUnknown
+	chunkedUploadState capabilityState
+
+	// deletionState represents if the repository is observed to support
+	// deletion.
+	// default: capabilityStateUnknown
+	deletionState capabilityState
+
 	// referrersMergePool provides a way to manage concurrent updates to a
 	// referrers index tagged by referrers tag schema.
 	referrersMergePool syncutil.Pool[syncutil.Merge[referrerChange]]
+
+	// headUnsupported represents that if the repository is known to not
+	// support HEAD requests for manifests.
+	// See also: FallbackToGET
+	headUnsupported atomic.Bool
 }
 
 // NewRepository creates a client to the remote repository identified by a
@@ -179,6 +393,24 @@ func NewRepository(reference string) (*Repository, error) {
 	}, nil
 }
 
+// NewRepositoryStrict creates a client to the remote repository identified by
+// a reference as NewRepository does, and additionally validates the name of
+// the repository with [registry.Reference.ValidateName] before any network
+// call is made.
+// Example: localhost:5000/hello-world
+func NewRepositoryStrict(reference string) (*Repository, error) {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	if err := ref.ValidateName(); err != nil {
+		return nil, err
+	}
+	return &Repository{
+		Reference: ref,
+	}, nil
+}
+
 // newRepositoryWithOptions returns a Repository with the given Reference and
 // RepositoryOptions.
 //
@@ -195,18 +427,47 @@ func newRepositoryWithOptions(ref registry.Reference, opts *RepositoryOptions) (
 	return repo, nil
 }
 
+// blobUploadContentType returns the `Content-Type` header for uploading the
+// blob described by desc.
+func (r *Repository) blobUploadContentType(desc ocispec.Descriptor) string {
+	if r.BlobUploadContentType != nil {
+		if contentType := r.BlobUploadContentType(desc); contentType != "" {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
+
 // clone makes a copy of the Repository being careful not to copy non-copyable fields (sync.Mutex and syncutil.Pool types)
 func (r *Repository) clone() *Repository {
 	return &Repository{
-		Client:               r.Client,
-		Reference:            r.Reference,
-		PlainHTTP:            r.PlainHTTP,
-		ManifestMediaTypes:   slices.Clone(r.ManifestMediaTypes),
-		TagListPageSize:      r.TagListPageSize,
-		ReferrerListPageSize: r.ReferrerListPageSize,
-		MaxMetadataBytes:     r.MaxMetadataBytes,
-		SkipReferrersGC:      r.SkipReferrersGC,
-		HandleWarning:        r.HandleWarning,
+		Client:                     r.Client,
+		Reference:                  r.Reference,
+		PlainHTTP:                  r.PlainHTTP,
+		ManifestMediaTypes:         slices.Clone(r.ManifestMediaTypes),
+		ManifestMediaTypeQualities: maps.Clone(r.ManifestMediaTypeQualities),
+		TagListPageSize:            r.TagListPageSize,
+		FallbackToDockerMediaTypes: r.FallbackToDockerMediaTypes,
+		FallbackToGET:              r.FallbackToGET,
+		FallbackToTemporaryTag:     r.FallbackToTemporaryTag,
+		ProbeRangeSupport:          r.ProbeRangeSupport,
+		VerifyManifestMediaType:    r.VerifyManifestMediaType,
+		BlobUploadContentType:      r.BlobUploadContentType,
+		UploadChunkSize:            r.UploadChunkSize,
+		ReferrerListPageSize:       r.ReferrerListPageSize,
+		ReferrerListWarnTruncation: r.ReferrerListWarnTruncation,
+		OnReferrersMethod:          r.OnReferrersMethod,
+		ReferrersMediaTypes:        slices.Clone(r.ReferrersMediaTypes),
+		MaxMetadataBytes:           r.MaxMetadataBytes,
+		DigestAlgorithms:           slices.Clone(r.DigestAlgorithms),
+		SkipReferrersGC:            r.SkipReferrersGC,
+		ReferrerAnnotations:        r.ReferrerAnnotations,
+		HandleWarning:              r.HandleWarning,
+		HostRewrite:                r.HostRewrite,
+		ReadIdleTimeout:            r.ReadIdleTimeout,
+		RequestTimeout:             r.RequestTimeout,
+		RequestPushScope:           r.RequestPushScope,
+		Tracer:                     r.Tracer,
 	}
 }
 
@@ -254,14 +515,86 @@ func (r *Repository) client() Client {
 	return r.Client
 }
 
+// rewritingClient returns the client returned by r.client(), which rewrites
+// the redirect targets with r.HostRewrite if set, and requests the push scope
+// if r.RequestPushScope is set.
+func (r *Repository) rewritingClient() Client {
+	client := withURLRewrite(r.client(), r.HostRewrite)
+	if r.RequestPushScope {
+		client = pushScopeClient{
+			Client: client,
+			ref:    r.Reference,
+		}
+	}
+	return client
+}
+
 // do sends an HTTP request and returns an HTTP response using the HTTP client
 // returned by r.client().
 func (r *Repository) do(req *http.Request) (*http.Response, error) {
+	if r.RequestTimeout <= 0 || isRequestTimeoutExempt(req.Context()) {
+		return r.send(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), r.RequestTimeout)
+	resp, err := r.send(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// the timeout covers reading the response body
+	resp.Body = &cancelOnCloseReadCloser{
+		ReadCloser: resp.Body,
+		cancel:     cancel,
+	}
+	return resp, nil
+}
+
+// send sends an HTTP request with tracing if r.Tracer is set.
+func (r *Repository) send(req *http.Request) (*http.Response, error) {
+	if r.Tracer != nil {
+		return r.doWithSpan(req)
+	}
+	return r.doRequest(req)
+}
+
+// requestTimeoutExemptContextKey is the context key for exempting requests
+// from Repository.RequestTimeout.
+type requestTimeoutExemptContextKey struct{}
+
+// withoutRequestTimeout returns a context exempting the requests made with it
+// from Repository.RequestTimeout.
+func withoutRequestTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestTimeoutExemptContextKey{}, true)
+}
+
+// isRequestTimeoutExempt returns true if ctx is returned by
+// withoutRequestTimeout.
+func isRequestTimeoutExempt(ctx context.Context) bool {
+	exempt, _ := ctx.Value(requestTimeoutExemptContextKey{}).(bool)
+	return exempt
+}
+
+// cancelOnCloseReadCloser cancels the context of the request on close.
+type cancelOnCloseReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the underlying ReadCloser and cancels the context.
+func (rc *cancelOnCloseReadCloser) Close() error {
+	defer rc.cancel()
+	return rc.ReadCloser.Close()
+}
+
+// doRequest sends an HTTP request and handles the warning headers in the
+// response.
+func (r *Repository) doRequest(req *http.Request) (*http.Response, error) {
 	if r.HandleWarning == nil {
-		return r.client().Do(req)
+		return r.rewritingClient().Do(req)
 	}
 
-	resp, err := r.client().Do(req)
+	resp, err := r.rewritingClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -277,16 +610,57 @@ func (r *Repository) blobStore(desc ocispec.Descriptor) registry.BlobStore {
 	return r.Blobs()
 }
 
+// Locate returns the host of the registry and the name of the repository.
+func (r *Repository) Locate() (string, string) {
+	return r.Reference.Registry, r.Reference.Repository
+}
+
 // Fetch fetches the content identified by the descriptor.
 func (r *Repository) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
 	return r.blobStore(target).Fetch(ctx, target)
 }
 
+// FetchWithResponse fetches the content identified by the descriptor, and
+// returns the headers of the HTTP response alongside the content, such as
+// `Content-Type` and the registry specific headers, which is useful for
+// proxies forwarding the registry metadata.
+// The returned content is the same as the one returned by Fetch, and is
+// checked in the same way.
+func (r *Repository) FetchWithResponse(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, http.Header, error) {
+	if isManifest(r.ManifestMediaTypes, target) {
+		s := &manifestStore{repo: r}
+		return s.FetchWithResponse(ctx, target)
+	}
+	s := &blobStore{repo: r}
+	return s.FetchWithResponse(ctx, target)
+}
+
 // Push pushes the content, matching the expected descriptor.
 func (r *Repository) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
 	return r.blobStore(expected).Push(ctx, expected, content)
 }
 
+// AllowedDigestAlgorithms returns the digest algorithms accepted by the push
+// operations. An empty list indicates that the digest algorithms are not
+// restricted.
+// See also: Repository.DigestAlgorithms
+func (r *Repository) AllowedDigestAlgorithms() []digest.Algorithm {
+	return slices.Clone(r.DigestAlgorithms)
+}
+
+// checkDigestAlgorithm ensures that desc is digested with one of the allowed
+// digest algorithms.
+func (r *Repository) checkDigestAlgorithm(desc ocispec.Descriptor) error {
+	if len(r.DigestAlgorithms) == 0 {
+		return nil
+	}
+	if alg := desc.Digest.Algorithm(); !slices.Contains(r.DigestAlgorithms, alg) {
+		return fmt.Errorf("%s: %s: digest algorithm %q is not allowed: %w",
+			desc.Digest, desc.MediaType, alg, errdef.ErrUnsupported)
+	}
+	return nil
+}
+
 // Mount makes the blob with the given digest in fromRepo
 // available in the repository signified by the receiver.
 //
@@ -296,8 +670,51 @@ func (r *Repository) Push(ctx context.Context, expected ocispec.Descriptor, cont
 // content to push. If getContent is nil, the content will be pulled from the source
 // repository. If getContent returns an error, it will be wrapped inside the error
 // returned from Mount.
+//
+// Manifests are copied from fromRepo within the same registry instead, as
+// described by the Mount method of the manifest store.
 func (r *Repository) Mount(ctx context.Context, desc ocispec.Descriptor, fromRepo string, getContent func() (io.ReadCloser, error)) error {
-	return r.Blobs().(registry.Mounter).Mount(ctx, desc, fromRepo, getContent)
+	return r.blobStore(desc).(registry.Mounter).Mount(ctx, desc, fromRepo, getContent)
+}
+
+// CheckPush checks if content can be pushed to the repository by starting a
+// blob upload session with the pull and push actions, which is cancelled
+// right after it is accepted. No content is transferred.
+// An error is returned if the upload session is not accepted, e.g. due to a
+// lack of the push permission.
+func (r *Repository) CheckPush(ctx context.Context) error {
+	ctx = auth.AppendRepositoryScope(ctx, r.Reference, auth.ActionPull, auth.ActionPush)
+	url := buildRepositoryBlobUploadURL(r.PlainHTTP, r.Reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return errutil.ParseErrorResponse(resp)
+	}
+
+	// cancel the upload session on a best-effort basis, as not all
+	// registries support cancelling uploads
+	location, err := r.uploadLocation(req, resp)
+	if err != nil {
+		return nil
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodDelete, location.String(), nil)
+	if err != nil {
+		return nil
+	}
+	if authHeader := resp.Request.Header.Get("Authorization"); authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	if resp, err := r.do(req); err == nil {
+		resp.Body.Close()
+	}
+	return nil
 }
 
 // Exists returns true if the described content exists.
@@ -396,11 +813,31 @@ func (r *Repository) ParseReference(reference string) (registry.Reference, error
 //   - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#content-discovery
 //   - https://docs.docker.com/registry/spec/api/#tags
 func (r *Repository) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	return r.TagsWithFilter(ctx, last, TagsFilter{}, fn)
+}
+
+// TagsWithFilter lists the tags available in the repository, and matching
+// the given filter.
+// See also `TagListPageSize`.
+// `last` is handled in the same way as [Repository.Tags].
+//
+// fn is called for each page of the tags result containing matching tags.
+// The prefix filter is sent to the remote registry as a hint, and the filters
+// not listed in the "OCI-Filters-Applied" response header are applied on the
+// client side.
+//
+// References:
+//   - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#content-discovery
+//   - https://docs.docker.com/registry/spec/api/#tags
+func (r *Repository) TagsWithFilter(ctx context.Context, last string, filter TagsFilter, fn func(tags []string) error) error {
+	if err := filter.validate(); err != nil {
+		return err
+	}
 	ctx = auth.AppendRepositoryScope(ctx, r.Reference, auth.ActionPull)
 	url := buildRepositoryTagListURL(r.PlainHTTP, r.Reference)
 	var err error
 	for err == nil {
-		url, err = r.tags(ctx, last, fn, url)
+		url, err = r.tags(ctx, last, filter, fn, url)
 		// clear `last` for subsequent pages
 		last = ""
 	}
@@ -411,12 +848,12 @@ func (r *Repository) Tags(ctx context.Context, last string, fn func(tags []strin
 }
 
 // tags returns a single page of tag list with the next link.
-func (r *Repository) tags(ctx context.Context, last string, fn func(tags []string) error, url string) (string, error) {
+func (r *Repository) tags(ctx context.Context, last string, filter TagsFilter, fn func(tags []string) error, url string) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
-	if r.TagListPageSize > 0 || last != "" {
+	if r.TagListPageSize > 0 || last != "" || filter.Prefix != "" {
 		q := req.URL.Query()
 		if r.TagListPageSize > 0 {
 			q.Set("n", strconv.Itoa(r.TagListPageSize))
@@ -424,6 +861,9 @@ func (r *Repository) tags(ctx context.Context, last string, fn func(tags []strin
 		if last != "" {
 			q.Set("last", last)
 		}
+		if filter.Prefix != "" {
+			q.Set(filterTypePrefix, filter.Prefix)
+		}
 		req.URL.RawQuery = q.Encode()
 	}
 	resp, err := r.do(req)
@@ -442,8 +882,14 @@ func (r *Repository) tags(ctx context.Context, last string, fn func(tags []strin
 	if err := json.NewDecoder(lr).Decode(&page); err != nil {
 		return "", fmt.Errorf("%s %q: failed to decode response: %w", resp.Request.Method, resp.Request.URL, err)
 	}
-	if err := fn(page.Tags); err != nil {
-		return "", err
+	if filter.isEmpty() {
+		if err := fn(page.Tags); err != nil {
+			return "", err
+		}
+	} else if tags := filter.apply(page.Tags, resp.Header.Get(headerOCIFiltersApplied)); len(tags) > 0 {
+		if err := fn(tags); err != nil {
+			return "", err
+		}
 	}
 
 	return parseLink(resp)
@@ -473,46 +919,71 @@ func (r *Repository) Predecessors(ctx context.Context, desc ocispec.Descriptor)
 //
 // Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
 func (r *Repository) Referrers(ctx context.Context, desc ocispec.Descriptor, artifactType string, fn func(referrers []ocispec.Descriptor) error) error {
+	return r.ReferrersWithFilter(ctx, desc, ReferrersFilter{ArtifactType: artifactType}, fn)
+}
+
+// ReferrersWithFilter lists the descriptors of image or artifact manifests
+// directly referencing the given manifest descriptor, and matching the given
+// filter.
+//
+// fn is called for each page of the referrers result.
+// The filters are sent to the remote registry, and the ones not listed in the
+// "OCI-Filters-Applied" response header are applied on the client side.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
+func (r *Repository) ReferrersWithFilter(ctx context.Context, desc ocispec.Descriptor, filter ReferrersFilter, fn func(referrers []ocispec.Descriptor) error) error {
 	state := r.loadReferrersState()
 	if state == referrersStateUnsupported {
 		// The repository is known to not support Referrers API, fallback to
 		// referrers tag schema.
-		return r.referrersByTagSchema(ctx, desc, artifactType, fn)
+		r.reportReferrersMethod(desc, ReferrersMethodTagSchema)
+		return r.referrersByTagSchema(ctx, desc, filter, fn)
 	}
 
-	err := r.referrersByAPI(ctx, desc, artifactType, fn)
 	if state == referrersStateSupported {
 		// The repository is known to support Referrers API, no fallback.
-		return err
+		r.reportReferrersMethod(desc, ReferrersMethodAPI)
+		return r.referrersByAPI(ctx, desc, filter, fn)
 	}
 
 	// The referrers state is unknown.
-	if err != nil {
+	if err := r.referrersByAPI(ctx, desc, filter, fn); err != nil {
 		if errors.Is(err, errdef.ErrUnsupported) {
 			// Referrers API is not supported, fallback to referrers tag schema.
 			r.SetReferrersCapability(false)
-			return r.referrersByTagSchema(ctx, desc, artifactType, fn)
+			r.reportReferrersMethod(desc, ReferrersMethodTagSchema)
+			return r.referrersByTagSchema(ctx, desc, filter, fn)
 		}
+		r.reportReferrersMethod(desc, ReferrersMethodAPI)
 		return err
 	}
 
 	r.SetReferrersCapability(true)
+	r.reportReferrersMethod(desc, ReferrersMethodAPI)
 	return nil
 }
 
+// reportReferrersMethod reports the method used to discover the referrers of
+// subject to r.OnReferrersMethod, if set.
+func (r *Repository) reportReferrersMethod(subject ocispec.Descriptor, method ReferrersMethod) {
+	if r.OnReferrersMethod != nil {
+		r.OnReferrersMethod(subject, method)
+	}
+}
+
 // referrersByAPI lists the descriptors of manifests directly referencing
 // the given manifest descriptor by requesting Referrers API.
-// fn is called for the referrers result. If artifactType is not empty,
-// only referrers of the same artifact type are fed to fn.
-func (r *Repository) referrersByAPI(ctx context.Context, desc ocispec.Descriptor, artifactType string, fn func(referrers []ocispec.Descriptor) error) error {
+// fn is called for the referrers result. Only referrers matching the filter
+// are fed to fn.
+func (r *Repository) referrersByAPI(ctx context.Context, desc ocispec.Descriptor, filter ReferrersFilter, fn func(referrers []ocispec.Descriptor) error) error {
 	ref := r.Reference
 	ref.Reference = desc.Digest.String()
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
 
-	url := buildReferrersURL(r.PlainHTTP, ref, artifactType)
+	url := buildReferrersFilterURL(r.PlainHTTP, ref, filter)
 	var err error
 	for err == nil {
-		url, err = r.referrersPageByAPI(ctx, artifactType, fn, url)
+		url, err = r.referrersPageByAPI(ctx, desc, filter, fn, url)
 	}
 	if err == errNoLink {
 		return nil
@@ -523,14 +994,14 @@ func (r *Repository) referrersByAPI(ctx context.Context, desc ocispec.Descriptor
 // referrersPageByAPI lists a single page of the descriptors of manifests
 // directly referencing the given manifest descriptor. fn is called for
 // a page of referrersPageByAPI result.
-// If artifactType is not empty, only referrersPageByAPI of the same
-// artifact type are fed to fn.
+// Only referrers matching the filter are fed to fn.
 // referrersPageByAPI returns the link url for the next page.
-func (r *Repository) referrersPageByAPI(ctx context.Context, artifactType string, fn func(referrers []ocispec.Descriptor) error, url string) (string, error) {
+func (r *Repository) referrersPageByAPI(ctx context.Context, desc ocispec.Descriptor, filter ReferrersFilter, fn func(referrers []ocispec.Descriptor) error, url string) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
+	req.Header.Set("Accept", referrersAcceptHeader(r.ReferrersMediaTypes))
 	if r.ReferrerListPageSize > 0 {
 		q := req.URL.Query()
 		q.Set("n", strconv.Itoa(r.ReferrerListPageSize))
@@ -557,7 +1028,7 @@ func (r *Repository) referrersPageByAPI(ctx context.Context, artifactType string
 	}
 
 	// also check the content type
-	if ct := resp.Header.Get("Content-Type"); ct != ocispec.MediaTypeImageIndex {
+	if ct := resp.Header.Get("Content-Type"); !isReferrersMediaType(r.ReferrersMediaTypes, ct) {
 		return "", fmt.Errorf("unknown content returned (%s), expecting image index: %w", ct, errdef.ErrUnsupported)
 	}
 
@@ -568,34 +1039,38 @@ func (r *Repository) referrersPageByAPI(ctx context.Context, artifactType string
 	}
 
 	referrers := index.Manifests
-	if artifactType != "" {
+	if !filter.isEmpty() {
 		// check both filters header and filters annotations for compatibility
 		// latest spec for filters header: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
 		// older spec for filters annotations: https://github.com/opencontainers/distribution-spec/blob/v1.1.0-rc1/spec.md#listing-referrers
 		filtersHeader := resp.Header.Get(headerOCIFiltersApplied)
 		filtersAnnotation := index.Annotations[spec.AnnotationReferrersFiltersApplied]
-		if !isReferrersFilterApplied(filtersHeader, filterTypeArtifactType) &&
-			!isReferrersFilterApplied(filtersAnnotation, filterTypeArtifactType) {
-			// perform client side filtering if the filter is not applied on the server side
-			referrers = filterReferrers(referrers, artifactType)
-		}
+		// perform client side filtering for the filters not applied on the
+		// server side
+		referrers = filter.apply(referrers, filtersHeader, filtersAnnotation)
 	}
 	if len(referrers) > 0 {
 		if err := fn(referrers); err != nil {
 			return "", err
 		}
 	}
-	return parseLink(resp)
+	link, err := parseLink(resp)
+	if err == errNoLink && r.ReferrerListWarnTruncation != nil &&
+		r.ReferrerListPageSize > 0 && len(index.Manifests) == r.ReferrerListPageSize {
+		// a full last page may be followed by referrers not linked
+		r.ReferrerListWarnTruncation(desc)
+	}
+	return link, err
 }
 
 // referrersByTagSchema lists the descriptors of manifests directly
 // referencing the given manifest descriptor by requesting referrers tag.
-// fn is called for the referrers result. If artifactType is not empty,
-// only referrers of the same artifact type are fed to fn.
+// fn is called for the referrers result. Only referrers matching the filter
+// are fed to fn.
 // reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#backwards-compatibility
-func (r *Repository) referrersByTagSchema(ctx context.Context, desc ocispec.Descriptor, artifactType string, fn func(referrers []ocispec.Descriptor) error) error {
+func (r *Repository) referrersByTagSchema(ctx context.Context, desc ocispec.Descriptor, filter ReferrersFilter, fn func(referrers []ocispec.Descriptor) error) error {
 	referrersTag := buildReferrersTag(desc)
-	_, referrers, err := r.referrersFromIndex(ctx, referrersTag)
+	_, referrers, _, err := r.referrersFromIndex(ctx, referrersTag)
 	if err != nil {
 		if errors.Is(err, errdef.ErrNotFound) {
 			// no referrers to the manifest
@@ -603,33 +1078,99 @@ func (r *Repository) referrersByTagSchema(ctx context.Context, desc ocispec.Desc
 		}
 		return err
 	}
+	if len(filter.artifactTypes()) > 0 {
+		if err := r.fillArtifactTypes(ctx, referrers); err != nil {
+			return err
+		}
+	}
 
-	filtered := filterReferrers(referrers, artifactType)
+	filtered := filter.apply(referrers)
 	if len(filtered) == 0 {
 		return nil
 	}
 	return fn(filtered)
 }
 
+// fillArtifactTypes fills in the artifact types of the referrers in place for
+// the referrers lacking them in the referrers index, by fetching the referrer
+// manifests. Referrers already carrying an artifact type are not fetched.
+func (r *Repository) fillArtifactTypes(ctx context.Context, referrers []ocispec.Descriptor) error {
+	for i, referrer := range referrers {
+		if referrer.ArtifactType != "" {
+			continue
+		}
+		switch referrer.MediaType {
+		case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex, spec.MediaTypeArtifactManifest:
+		default:
+			// no artifact type defined for the media type
+			continue
+		}
+		manifestJSON, err := content.FetchAll(ctx, r.Manifests(), referrer)
+		if err != nil {
+			return fmt.Errorf("failed to fetch referrer %s: %w", referrer.Digest, err)
+		}
+		var manifest struct {
+			ArtifactType string              `json:"artifactType"`
+			Config       *ocispec.Descriptor `json:"config"`
+		}
+		if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+			return fmt.Errorf("failed to decode referrer %s: %s: %w", referrer.Digest, referrer.MediaType, err)
+		}
+		referrers[i].ArtifactType = manifest.ArtifactType
+		if referrers[i].ArtifactType == "" && manifest.Config != nil {
+			referrers[i].ArtifactType = manifest.Config.MediaType
+		}
+	}
+	return nil
+}
+
 // referrersFromIndex queries the referrers index using the the given referrers
-// tag. If Succeeded, returns the descriptor of referrers index and the
-// referrers list.
-func (r *Repository) referrersFromIndex(ctx context.Context, referrersTag string) (ocispec.Descriptor, []ocispec.Descriptor, error) {
-	desc, rc, err := r.FetchReference(ctx, referrersTag)
+// tag. If Succeeded, returns the descriptor of referrers index, the referrers
+// list, and the ETag of the referrers index returned by the registry, if any.
+func (r *Repository) referrersFromIndex(ctx context.Context, referrersTag string) (ocispec.Descriptor, []ocispec.Descriptor, string, error) {
+	s := &manifestStore{repo: r}
+	desc, rc, etag, err := s.fetchReference(ctx, referrersTag)
 	if err != nil {
-		return ocispec.Descriptor{}, nil, err
+		return ocispec.Descriptor{}, nil, "", err
 	}
 	defer rc.Close()
 
 	if err := limitSize(desc, r.MaxMetadataBytes); err != nil {
-		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to read referrers index from referrers tag %s: %w", referrersTag, err)
+		return ocispec.Descriptor{}, nil, "", fmt.Errorf("failed to read referrers index from referrers tag %s: %w", referrersTag, err)
 	}
-	var index ocispec.Index
+	var index referrersIndex
 	if err := decodeJSON(rc, desc, &index); err != nil {
-		return ocispec.Descriptor{}, nil, fmt.Errorf("failed to decode referrers index from referrers tag %s: %w", referrersTag, err)
+		return ocispec.Descriptor{}, nil, "", fmt.Errorf("failed to decode referrers index from referrers tag %s: %w", referrersTag, err)
 	}
+	if err := index.validate(desc); err != nil {
+		return ocispec.Descriptor{}, nil, "", fmt.Errorf("invalid referrers index from referrers tag %s: %w", referrersTag, err)
+	}
+
+	return desc, index.Manifests, etag, nil
+}
 
-	return desc, index.Manifests, nil
+// referrersIndex is a referrers index, additionally decoding the fields of
+// image manifests for validation.
+type referrersIndex struct {
+	ocispec.Index
+	Config json.RawMessage `json:"config,omitempty"`
+	Layers json.RawMessage `json:"layers,omitempty"`
+}
+
+// validate checks if the referrers index described by desc is an OCI image
+// index. As some registries do not return the expected content type, only
+// the content identified as other types of manifests is rejected.
+func (index *referrersIndex) validate(desc ocispec.Descriptor) error {
+	if desc.MediaType != ocispec.MediaTypeImageIndex && slices.Contains(defaultManifestMediaTypes, desc.MediaType) {
+		return fmt.Errorf("%s: unexpected content type %q: %w", desc.Digest, desc.MediaType, errdef.ErrInvalidMediaType)
+	}
+	if index.MediaType != "" && index.MediaType != ocispec.MediaTypeImageIndex {
+		return fmt.Errorf("%s: unexpected media type %q in content: %w", desc.Digest, index.MediaType, errdef.ErrInvalidMediaType)
+	}
+	if len(index.Config) > 0 || len(index.Layers) > 0 {
+		return fmt.Errorf("%s: unexpected manifest content: %w", desc.Digest, errdef.ErrInvalidMediaType)
+	}
+	return nil
 }
 
 // pingReferrers returns true if the Referrers API is available for r.
@@ -662,6 +1203,7 @@ func (r *Repository) pingReferrers(ctx context.Context) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	req.Header.Set("Accept", referrersAcceptHeader(r.ReferrersMediaTypes))
 	resp, err := r.do(req)
 	if err != nil {
 		return false, err
@@ -670,7 +1212,7 @@ func (r *Repository) pingReferrers(ctx context.Context) (bool, error) {
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		supported := resp.Header.Get("Content-Type") == ocispec.MediaTypeImageIndex
+		supported := isReferrersMediaType(r.ReferrersMediaTypes, resp.Header.Get("Content-Type"))
 		r.SetReferrersCapability(supported)
 		return supported, nil
 	case http.StatusNotFound:
@@ -709,9 +1251,13 @@ func (r *Repository) delete(ctx context.Context, target ocispec.Descriptor, isMa
 
 	switch resp.StatusCode {
 	case http.StatusAccepted:
+		storeCapability(&r.deletionState, true)
 		return verifyContentDigest(resp, target.Digest)
 	case http.StatusNotFound:
 		return fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+	case http.StatusMethodNotAllowed:
+		storeCapability(&r.deletionState, false)
+		return errutil.ParseErrorResponse(resp)
 	default:
 		return errutil.ParseErrorResponse(resp)
 	}
@@ -723,19 +1269,46 @@ type blobStore struct {
 }
 
 // Fetch fetches the content identified by the descriptor.
-func (s *blobStore) Fetch(ctx context.Context, target ocispec.Descriptor) (rc io.ReadCloser, err error) {
+func (s *blobStore) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, _, err := s.FetchWithResponse(ctx, target)
+	return rc, err
+}
+
+// FetchWithResponse fetches the content identified by the descriptor, and
+// returns the headers of the HTTP response alongside the content.
+// See also [Repository.FetchWithResponse].
+func (s *blobStore) FetchWithResponse(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, http.Header, error) {
+	if s.repo.ReadIdleTimeout <= 0 {
+		return s.fetch(ctx, target)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	rc, header, err := s.fetch(ctx, target)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return httputil.NewIdleTimeoutReadCloser(rc, s.repo.ReadIdleTimeout, cancel), header, nil
+}
+
+// fetch fetches the content identified by the descriptor, and returns the
+// headers of the response.
+func (s *blobStore) fetch(ctx context.Context, target ocispec.Descriptor) (rc io.ReadCloser, header http.Header, err error) {
 	ref := s.repo.Reference
 	ref.Reference = target.Digest.String()
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
+	ctx = withoutRequestTimeout(ctx)
 	url := buildRepositoryBlobURL(s.repo.PlainHTTP, ref)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if s.repo.ProbeRangeSupport && target.Size > 0 {
+		req.Header.Set("Range", "bytes=0-")
 	}
 
 	resp, err := s.repo.do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func() {
 		if err != nil {
@@ -744,9 +1317,18 @@ func (s *blobStore) Fetch(ctx context.Context, target ocispec.Descriptor) (rc io
 	}()
 
 	switch resp.StatusCode {
+	case http.StatusPartialContent: // server honors `Range` on probing range support.
+		size, err := parseContentRangeSize(resp)
+		if err != nil {
+			return nil, nil, err
+		}
+		if size != target.Size {
+			return nil, nil, fmt.Errorf("%s %q: mismatch Content-Range", resp.Request.Method, resp.Request.URL)
+		}
+		return httputil.NewReadSeekCloser(s.repo.rewritingClient(), req, resp.Body, target.Size), resp.Header, nil
 	case http.StatusOK: // server does not support seek as `Range` was ignored.
 		if size := resp.ContentLength; size != -1 && size != target.Size {
-			return nil, fmt.Errorf("%s %q: mismatch Content-Length", resp.Request.Method, resp.Request.URL)
+			return nil, nil, fmt.Errorf("%s %q: mismatch Content-Length", resp.Request.Method, resp.Request.URL)
 		}
 
 		// check server range request capability.
@@ -754,13 +1336,13 @@ func (s *blobStore) Fetch(ctx context.Context, target ocispec.Descriptor) (rc io
 		// However, the remote server may still not RFC 7233 compliant.
 		// Reference: https://docs.docker.com/registry/spec/api/#blob
 		if rangeUnit := resp.Header.Get("Accept-Ranges"); rangeUnit == "bytes" {
-			return httputil.NewReadSeekCloser(s.repo.client(), req, resp.Body, target.Size), nil
+			return httputil.NewReadSeekCloser(s.repo.rewritingClient(), req, resp.Body, target.Size), resp.Header, nil
 		}
-		return resp.Body, nil
+		return httputil.NewSizeCheckReadCloser(req, resp.Body, target.Size), resp.Header, nil
 	case http.StatusNotFound:
-		return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+		return nil, nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
 	default:
-		return nil, errutil.ParseErrorResponse(resp)
+		return nil, nil, errutil.ParseErrorResponse(resp)
 	}
 }
 
@@ -838,11 +1420,64 @@ func (s *blobStore) sibling(otherRepoName string) *blobStore {
 // `POST` request for better overall performance. It also allows early fail on
 // authentication errors.
 //
+// If the upload session is reported invalid or unknown by the remote registry,
+// for instance, when the session expires, the upload is restarted once with a
+// new session, provided that the content implements io.Seeker to be read
+// again from the beginning. Content not implementing io.Seeker, such as the
+// content streamed by oras.Copy and oras.CopyGraph, is restarted only if
+// UploadChunkSize is set and no more than UploadChunkSize bytes of the content
+// have been read, i.e. within the first chunk, which is buffered for the
+// restart. Otherwise, the session error is returned.
+//
 // References:
 //   - https://docs.docker.com/registry/spec/api/#pushing-an-image
 //   - https://docs.docker.com/registry/spec/api/#initiate-blob-upload
 //   - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pushing-a-blob-monolithically
 func (s *blobStore) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	if err := s.repo.checkDigestAlgorithm(expected); err != nil {
+		return err
+	}
+	seeker, ok := content.(io.Seeker)
+	if !ok {
+		return s.pushBuffered(ctx, expected, content)
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return s.push(ctx, expected, content)
+	}
+	if err := s.push(ctx, expected, content); !errutil.IsBlobUploadSessionError(err) {
+		return err
+	}
+	// restart the upload with a fresh session
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind content to restart the upload: %w", err)
+	}
+	return s.push(ctx, expected, content)
+}
+
+// pushBuffered pushes the non-seekable content, buffering up to
+// UploadChunkSize bytes read from the content so that the upload can be
+// restarted once with a new session from the buffered bytes.
+func (s *blobStore) pushBuffered(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	if s.repo.UploadChunkSize <= 0 {
+		return s.push(ctx, expected, content)
+	}
+	buffered := newReplayReader(content, s.repo.UploadChunkSize)
+	err := s.push(ctx, expected, buffered)
+	if !errutil.IsBlobUploadSessionError(err) {
+		return err
+	}
+	replay, ok := buffered.replay()
+	if !ok {
+		// more than the buffered bytes have been read
+		return err
+	}
+	// restart the upload with a fresh session
+	return s.push(ctx, expected, replay)
+}
+
+// push pushes the content in a new upload session.
+func (s *blobStore) push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
 	// start an upload
 	// pushing usually requires both pull and push actions.
 	// Reference: https://github.com/distribution/distribution/blob/v2.7.1/registry/handlers/app.go#L921-L930
@@ -870,26 +1505,96 @@ func (s *blobStore) Push(ctx context.Context, expected ocispec.Descriptor, conte
 // Push or by Mount when the receiving repository does not implement the
 // mount endpoint.
 func (s *blobStore) completePushAfterInitialPost(ctx context.Context, req *http.Request, resp *http.Response, expected ocispec.Descriptor, content io.Reader) error {
-	reqHostname := req.URL.Hostname()
-	reqPort := req.URL.Port()
-	// monolithic upload
-	location, err := resp.Location()
+	location, err := s.repo.uploadLocation(req, resp)
 	if err != nil {
 		return err
 	}
+	// reuse credential from previous POST request
+	authHeader := resp.Request.Header.Get("Authorization")
+	s.repo.observeChunkMinLength(resp)
+	if chunkSize := s.repo.UploadChunkSize; chunkSize > 0 && expected.Size > chunkSize {
+		if minLength, err := strconv.ParseInt(resp.Header.Get(headerOCIChunkMinLength), 10, 64); err == nil && minLength > chunkSize {
+			chunkSize = minLength
+		}
+		if expected.Size > chunkSize {
+			return s.pushChunked(ctx, location, authHeader, expected, content, chunkSize, 0, nil)
+		}
+	}
+	return s.pushMonolithic(ctx, location, authHeader, expected, content)
+}
+
+// uploadLocation returns the location of the upload session from resp, which
+// is the response to req, rewritten by r.HostRewrite if set.
+func (r *Repository) uploadLocation(req *http.Request, resp *http.Response) (*url.URL, error) {
+	location, err := resp.Location()
+	if err != nil {
+		return nil, err
+	}
 	// work-around solution for https://github.com/oras-project/oras-go/issues/177
 	// For some registries, if the port 443 is explicitly set to the hostname
 	// like registry.wabbit-networks.io:443/myrepo, blob push will fail since
 	// the hostname of the Location header in the response is set to
 	// registry.wabbit-networks.io instead of registry.wabbit-networks.io:443.
+	reqHostname := req.URL.Hostname()
+	reqPort := req.URL.Port()
 	locationHostname := location.Hostname()
 	locationPort := location.Port()
 	// if location port 443 is missing, add it back
 	if reqPort == "443" && locationHostname == reqHostname && locationPort == "" {
 		location.Host = locationHostname + ":" + reqPort
 	}
-	url := location.String()
-	req, err = http.NewRequestWithContext(ctx, http.MethodPut, url, content)
+	if r.HostRewrite != nil {
+		if u := r.HostRewrite(location); u != nil {
+			location = u
+		}
+	}
+	return location, nil
+}
+
+// urlRewriteClient is a Client rewriting the redirect targets of the requests.
+type urlRewriteClient struct {
+	Client
+	rewrite func(u *url.URL) *url.URL
+}
+
+// withURLRewrite returns a Client sending the requests with client, and
+// rewriting the redirect targets with rewrite. client is returned as is if
+// rewrite is nil.
+func withURLRewrite(client Client, rewrite func(u *url.URL) *url.URL) Client {
+	if rewrite == nil {
+		return client
+	}
+	return urlRewriteClient{
+		Client:  client,
+		rewrite: rewrite,
+	}
+}
+
+// Do sends the request, rewriting the redirect targets.
+func (c urlRewriteClient) Do(req *http.Request) (*http.Response, error) {
+	if client, ok := c.Client.(*http.Client); ok {
+		return httputil.ClientWithURLRewrite(client, c.rewrite).Do(req)
+	}
+	return c.Client.Do(req.WithContext(httputil.WithURLRewrite(req.Context(), c.rewrite)))
+}
+
+// pushScopeClient is a Client requesting the pull and push actions on a
+// repository for every request.
+type pushScopeClient struct {
+	Client
+	ref registry.Reference
+}
+
+// Do sends the request with the pull and push scope of the repository.
+func (c pushScopeClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := auth.AppendRepositoryScope(req.Context(), c.ref, auth.ActionPull, auth.ActionPush)
+	return c.Client.Do(req.WithContext(ctx))
+}
+
+// pushMonolithic completes the upload session at location by a single `PUT`
+// request carrying the entire content.
+func (s *blobStore) pushMonolithic(ctx context.Context, location *url.URL, authHeader string, expected ocispec.Descriptor, content io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, location.String(), content)
 	if err != nil {
 		return err
 	}
@@ -899,16 +1604,20 @@ func (s *blobStore) completePushAfterInitialPost(ctx context.Context, req *http.
 	}
 	req.ContentLength = expected.Size
 	// the expected media type is ignored as in the API doc.
-	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Type", s.repo.blobUploadContentType(expected))
+	return s.closeUpload(req, authHeader, expected)
+}
+
+// closeUpload sends the `PUT` request req closing the upload session with the
+// digest of the expected content.
+func (s *blobStore) closeUpload(req *http.Request, authHeader string, expected ocispec.Descriptor) error {
 	q := req.URL.Query()
 	q.Set("digest", expected.Digest.String())
 	req.URL.RawQuery = q.Encode()
-
-	// reuse credential from previous POST request
-	if auth := resp.Request.Header.Get("Authorization"); auth != "" {
-		req.Header.Set("Authorization", auth)
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
 	}
-	resp, err = s.repo.do(req)
+	resp, err := s.repo.do(req.WithContext(withoutRequestTimeout(req.Context())))
 	if err != nil {
 		return err
 	}
@@ -917,7 +1626,10 @@ func (s *blobStore) completePushAfterInitialPost(ctx context.Context, req *http.
 	if resp.StatusCode != http.StatusCreated {
 		return errutil.ParseErrorResponse(resp)
 	}
-	return nil
+	// Some proxies do not return the digest on blob PUT. As the content has
+	// been verified against the expected descriptor by the client, the
+	// expected digest is trusted if the header is missing.
+	return verifyContentDigest(resp, expected.Digest)
 }
 
 // Exists returns true if the described content exists.
@@ -972,7 +1684,21 @@ func (s *blobStore) Resolve(ctx context.Context, reference string) (ocispec.Desc
 
 // FetchReference fetches the blob identified by the reference.
 // The reference must be a digest.
-func (s *blobStore) FetchReference(ctx context.Context, reference string) (desc ocispec.Descriptor, rc io.ReadCloser, err error) {
+func (s *blobStore) FetchReference(ctx context.Context, reference string) (ocispec.Descriptor, io.ReadCloser, error) {
+	if s.repo.ReadIdleTimeout <= 0 {
+		return s.fetchReference(ctx, reference)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	desc, rc, err := s.fetchReference(ctx, reference)
+	if err != nil {
+		cancel()
+		return ocispec.Descriptor{}, nil, err
+	}
+	return desc, httputil.NewIdleTimeoutReadCloser(rc, s.repo.ReadIdleTimeout, cancel), nil
+}
+
+// fetchReference fetches the blob identified by the reference.
+func (s *blobStore) fetchReference(ctx context.Context, reference string) (desc ocispec.Descriptor, rc io.ReadCloser, err error) {
 	ref, err := s.repo.ParseReference(reference)
 	if err != nil {
 		return ocispec.Descriptor{}, nil, err
@@ -983,11 +1709,15 @@ func (s *blobStore) FetchReference(ctx context.Context, reference string) (desc
 	}
 
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
+	ctx = withoutRequestTimeout(ctx)
 	url := buildRepositoryBlobURL(s.repo.PlainHTTP, ref)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return ocispec.Descriptor{}, nil, err
 	}
+	if s.repo.ProbeRangeSupport {
+		req.Header.Set("Range", "bytes=0-")
+	}
 
 	resp, err := s.repo.do(req)
 	if err != nil {
@@ -1000,6 +1730,21 @@ func (s *blobStore) FetchReference(ctx context.Context, reference string) (desc
 	}()
 
 	switch resp.StatusCode {
+	case http.StatusPartialContent: // server honors `Range` on probing range support.
+		var size int64
+		size, err = parseContentRangeSize(resp)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		if resp.ContentLength != -1 && resp.ContentLength != size {
+			return ocispec.Descriptor{}, nil, fmt.Errorf("%s %q: mismatch Content-Length", resp.Request.Method, resp.Request.URL)
+		}
+		resp.ContentLength = size
+		desc, err = generateBlobDescriptor(resp, refDigest)
+		if err != nil {
+			return ocispec.Descriptor{}, nil, err
+		}
+		return desc, httputil.NewReadSeekCloser(s.repo.rewritingClient(), req, resp.Body, desc.Size), nil
 	case http.StatusOK: // server does not support seek as `Range` was ignored.
 		if resp.ContentLength == -1 {
 			desc, err = s.Resolve(ctx, reference)
@@ -1015,9 +1760,9 @@ func (s *blobStore) FetchReference(ctx context.Context, reference string) (desc
 		// However, the remote server may still not RFC 7233 compliant.
 		// Reference: https://docs.docker.com/registry/spec/api/#blob
 		if rangeUnit := resp.Header.Get("Accept-Ranges"); rangeUnit == "bytes" {
-			return desc, httputil.NewReadSeekCloser(s.repo.client(), req, resp.Body, desc.Size), nil
+			return desc, httputil.NewReadSeekCloser(s.repo.rewritingClient(), req, resp.Body, desc.Size), nil
 		}
-		return desc, resp.Body, nil
+		return desc, httputil.NewSizeCheckReadCloser(req, resp.Body, desc.Size), nil
 	case http.StatusNotFound:
 		return ocispec.Descriptor{}, nil, fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
 	default:
@@ -1048,26 +1793,63 @@ func generateBlobDescriptor(resp *http.Response, refDigest digest.Digest) (ocisp
 	}, nil
 }
 
+// parseContentRangeSize parses the Content-Range header of a partial content
+// response to the `Range: bytes=0-` request, and returns the complete length of
+// the content. An error is returned if the range does not cover the whole
+// content.
+// Reference: https://www.rfc-editor.org/rfc/rfc9110#section-14.4
+func parseContentRangeSize(resp *http.Response) (int64, error) {
+	contentRange := resp.Header.Get("Content-Range")
+	invalidErr := fmt.Errorf("%s %q: invalid response header: `Content-Range: %s`", resp.Request.Method, resp.Request.URL, contentRange)
+	rangeSpec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, invalidErr
+	}
+	byteRange, completeLength, ok := strings.Cut(rangeSpec, "/")
+	if !ok {
+		return 0, invalidErr
+	}
+	size, err := strconv.ParseInt(completeLength, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, invalidErr
+	}
+	if byteRange != "0-"+strconv.FormatInt(size-1, 10) {
+		return 0, invalidErr
+	}
+	return size, nil
+}
+
 // manifestStore accesses the manifest part of the repository.
 type manifestStore struct {
 	repo *Repository
 }
 
 // Fetch fetches the content identified by the descriptor.
-func (s *manifestStore) Fetch(ctx context.Context, target ocispec.Descriptor) (rc io.ReadCloser, err error) {
+func (s *manifestStore) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	rc, _, err := s.FetchWithResponse(ctx, target)
+	return rc, err
+}
+
+// FetchWithResponse fetches the content identified by the descriptor, and
+// returns the headers of the HTTP response alongside the content.
+// See also [Repository.FetchWithResponse].
+func (s *manifestStore) FetchWithResponse(ctx context.Context, target ocispec.Descriptor) (rc io.ReadCloser, header http.Header, err error) {
 	ref := s.repo.Reference
 	ref.Reference = target.Digest.String()
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
 	url := buildRepositoryManifestURL(s.repo.PlainHTTP, ref)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	req.Header.Set("Accept", target.MediaType)
+	if err := limitSize(target, s.repo.MaxMetadataBytes); err != nil {
+		return nil, nil, err
+	}
 
 	resp, err := s.repo.do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer func() {
 		if err != nil {
@@ -1079,29 +1861,108 @@ func (s *manifestStore) Fetch(ctx context.Context, target ocispec.Descriptor) (r
 	case http.StatusOK:
 		// no-op
 	case http.StatusNotFound:
-		return nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
+		return nil, nil, fmt.Errorf("%s: %w", target.Digest, errdef.ErrNotFound)
 	default:
-		return nil, errutil.ParseErrorResponse(resp)
+		return nil, nil, errutil.ParseErrorResponse(resp)
 	}
 	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
 	if err != nil {
-		return nil, fmt.Errorf("%s %q: invalid response Content-Type: %w", resp.Request.Method, resp.Request.URL, err)
+		return nil, nil, fmt.Errorf("%s %q: invalid response Content-Type: %w", resp.Request.Method, resp.Request.URL, err)
 	}
 	if mediaType != target.MediaType {
-		return nil, fmt.Errorf("%s %q: mismatch response Content-Type %q: expect %q", resp.Request.Method, resp.Request.URL, mediaType, target.MediaType)
+		return nil, nil, fmt.Errorf("%s %q: mismatch response Content-Type %q: expect %q", resp.Request.Method, resp.Request.URL, mediaType, target.MediaType)
 	}
 	if size := resp.ContentLength; size != -1 && size != target.Size {
-		return nil, fmt.Errorf("%s %q: mismatch Content-Length", resp.Request.Method, resp.Request.URL)
+		return nil, nil, fmt.Errorf("%s %q: mismatch Content-Length", resp.Request.Method, resp.Request.URL)
 	}
 	if err := verifyContentDigest(resp, target.Digest); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return resp.Body, nil
+	resp.Body = limitReadCloser(resp.Body, s.repo.MaxMetadataBytes)
+	if s.repo.VerifyManifestMediaType {
+		if err := verifyManifestMediaType(resp, mediaType, s.repo.MaxMetadataBytes); err != nil {
+			return nil, nil, err
+		}
+	}
+	return resp.Body, resp.Header, nil
 }
 
 // Push pushes the content, matching the expected descriptor.
 func (s *manifestStore) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
-	return s.pushWithIndexing(ctx, expected, content, expected.Digest.String())
+	if err := s.repo.checkDigestAlgorithm(expected); err != nil {
+		return err
+	}
+	if !s.repo.FallbackToTemporaryTag {
+		return s.pushWithIndexing(ctx, expected, content, expected.Digest.String())
+	}
+	return s.pushWithTemporaryTagFallback(ctx, expected, content)
+}
+
+// pushWithTemporaryTagFallback pushes the manifest content by digest, and
+// retries pushing it under a temporary tag if pushing by digest is rejected.
+// See also: Repository.FallbackToTemporaryTag
+func (s *manifestStore) pushWithTemporaryTagFallback(ctx context.Context, expected ocispec.Descriptor, r io.Reader) error {
+	if err := limitSize(expected, s.repo.MaxMetadataBytes); err != nil {
+		return err
+	}
+	manifestJSON, err := content.ReadAll(r, expected)
+	if err != nil {
+		return err
+	}
+	err = s.pushWithIndexing(ctx, expected, bytes.NewReader(manifestJSON), expected.Digest.String())
+	var errResp *errcode.ErrorResponse
+	if err == nil || !errors.As(err, &errResp) ||
+		(errResp.StatusCode != http.StatusBadRequest && errResp.StatusCode != http.StatusMethodNotAllowed) {
+		return err
+	}
+
+	tag := buildTemporaryTag(expected)
+	if err := s.pushWithIndexing(ctx, expected, bytes.NewReader(manifestJSON), tag); err != nil {
+		return err
+	}
+	// best-effort removal of the temporary tag
+	_ = s.deleteTag(ctx, tag)
+	return nil
+}
+
+// buildTemporaryTag builds the temporary tag for pushing the manifest
+// described by desc.
+// Format: tmp-<alg>-<ref>, truncated to the maximum length of a tag.
+func buildTemporaryTag(desc ocispec.Descriptor) string {
+	tag := "tmp-" + desc.Digest.Algorithm().String() + "-" + desc.Digest.Encoded()
+	if len(tag) > maxTagLength {
+		tag = tag[:maxTagLength]
+	}
+	return tag
+}
+
+// deleteTag removes the tag from the repository, leaving the tagged manifest
+// intact.
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#deleting-tags
+func (s *manifestStore) deleteTag(ctx context.Context, tag string) error {
+	ref := s.repo.Reference
+	ref.Reference = tag
+	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionDelete)
+	url := buildRepositoryManifestURL(s.repo.PlainHTTP, ref)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.repo.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
+	default:
+		return errutil.ParseErrorResponse(resp)
+	}
 }
 
 // Exists returns true if the described content exists.
@@ -1116,6 +1977,80 @@ func (s *manifestStore) Exists(ctx context.Context, target ocispec.Descriptor) (
 	return false, err
 }
 
+// Mount makes the manifest with the given descriptor in fromRepo available in
+// the repository of s, where fromRepo is another repository in the same
+// registry.
+//
+// As the distribution spec defines no cross-repository mount for manifests,
+// Mount succeeds immediately if the manifest already exists in s. Otherwise,
+// the manifest is fetched from fromRepo by its digest and pushed to s, which
+// requires no access to the original source of the content.
+// If copying from fromRepo fails, for instance when the referenced blobs are
+// not yet available in s, Mount falls back to pushing the content returned by
+// getContent. If getContent is nil, the error of the copy is returned.
+// In any case, the manifest is verified to be resolvable by its digest in s
+// before declaring success.
+func (s *manifestStore) Mount(ctx context.Context, desc ocispec.Descriptor, fromRepo string, getContent func() (io.ReadCloser, error)) error {
+	exists, err := s.Exists(ctx, desc)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	if err := s.copyFrom(ctx, desc, fromRepo); err != nil {
+		if getContent == nil {
+			return err
+		}
+		rc, err := getContent()
+		if err != nil {
+			return fmt.Errorf("cannot read source manifest: %w", err)
+		}
+		defer rc.Close()
+		if err := s.Push(ctx, desc, rc); err != nil {
+			return err
+		}
+	}
+	return s.verifyMounted(ctx, desc)
+}
+
+// copyFrom copies the manifest with the given descriptor from fromRepo to the
+// repository of s.
+func (s *manifestStore) copyFrom(ctx context.Context, desc ocispec.Descriptor, fromRepo string) error {
+	if err := limitSize(desc, s.repo.MaxMetadataBytes); err != nil {
+		return err
+	}
+	manifestJSON, err := content.FetchAll(ctx, s.sibling(fromRepo), desc)
+	if err != nil {
+		return fmt.Errorf("cannot read source manifest: %w", err)
+	}
+	return s.Push(ctx, desc, bytes.NewReader(manifestJSON))
+}
+
+// verifyMounted verifies that the manifest with the given descriptor is
+// resolvable by its digest in the repository of s.
+func (s *manifestStore) verifyMounted(ctx context.Context, desc ocispec.Descriptor) error {
+	got, err := s.Resolve(ctx, desc.Digest.String())
+	if err != nil {
+		return fmt.Errorf("failed to verify mounted manifest %s: %w", desc.Digest, err)
+	}
+	if got.Digest != desc.Digest {
+		return fmt.Errorf("failed to verify mounted manifest %s: got digest %s", desc.Digest, got.Digest)
+	}
+	return nil
+}
+
+// sibling returns a manifest store for another repository in the same
+// registry.
+func (s *manifestStore) sibling(otherRepoName string) *manifestStore {
+	otherRepo := s.repo.clone()
+	otherRepo.Reference.Repository = otherRepoName
+	return &manifestStore{
+		repo: otherRepo,
+	}
+}
+
 // Delete removes the manifest content identified by the descriptor.
 func (s *manifestStore) Delete(ctx context.Context, target ocispec.Descriptor) error {
 	return s.deleteWithIndexing(ctx, target)
@@ -1190,9 +2125,9 @@ func (s *manifestStore) Resolve(ctx context.Context, reference string) (ocispec.
 	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
-	req.Header.Set("Accept", manifestAcceptHeader(s.repo.ManifestMediaTypes))
+	req.Header.Set("Accept", manifestAcceptHeader(s.repo.ManifestMediaTypes, s.repo.ManifestMediaTypeQualities))
 
-	resp, err := s.repo.do(req)
+	resp, err := s.doResolve(req)
 	if err != nil {
 		return ocispec.Descriptor{}, err
 	}
@@ -1200,7 +2135,7 @@ func (s *manifestStore) Resolve(ctx context.Context, reference string) (ocispec.
 
 	switch resp.StatusCode {
 	case http.StatusOK:
-		return s.generateDescriptor(resp, ref, req.Method)
+		return s.generateDescriptor(resp, ref, resp.Request.Method)
 	case http.StatusNotFound:
 		return ocispec.Descriptor{}, fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
 	default:
@@ -1210,23 +2145,30 @@ func (s *manifestStore) Resolve(ctx context.Context, reference string) (ocispec.
 
 // FetchReference fetches the manifest identified by the reference.
 // The reference can be a tag or digest.
-func (s *manifestStore) FetchReference(ctx context.Context, reference string) (desc ocispec.Descriptor, rc io.ReadCloser, err error) {
+func (s *manifestStore) FetchReference(ctx context.Context, reference string) (ocispec.Descriptor, io.ReadCloser, error) {
+	desc, rc, _, err := s.fetchReference(ctx, reference)
+	return desc, rc, err
+}
+
+// fetchReference fetches the manifest identified by the reference, and
+// additionally returns the value of the ETag header of the response, if any.
+func (s *manifestStore) fetchReference(ctx context.Context, reference string) (desc ocispec.Descriptor, rc io.ReadCloser, etag string, err error) {
 	ref, err := s.repo.ParseReference(reference)
 	if err != nil {
-		return ocispec.Descriptor{}, nil, err
+		return ocispec.Descriptor{}, nil, "", err
 	}
 
 	ctx = auth.AppendRepositoryScope(ctx, ref, auth.ActionPull)
 	url := buildRepositoryManifestURL(s.repo.PlainHTTP, ref)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return ocispec.Descriptor{}, nil, err
+		return ocispec.Descriptor{}, nil, "", err
 	}
-	req.Header.Set("Accept", manifestAcceptHeader(s.repo.ManifestMediaTypes))
+	req.Header.Set("Accept", manifestAcceptHeader(s.repo.ManifestMediaTypes, s.repo.ManifestMediaTypeQualities))
 
-	resp, err := s.repo.do(req)
+	resp, err := s.doWithDockerFallback(req)
 	if err != nil {
-		return ocispec.Descriptor{}, nil, err
+		return ocispec.Descriptor{}, nil, "", err
 	}
 	defer func() {
 		if err != nil {
@@ -1242,16 +2184,73 @@ func (s *manifestStore) FetchReference(ctx context.Context, reference string) (d
 			desc, err = s.generateDescriptor(resp, ref, req.Method)
 		}
 		if err != nil {
-			return ocispec.Descriptor{}, nil, err
+			return ocispec.Descriptor{}, nil, "", err
+		}
+		if err := limitSize(desc, s.repo.MaxMetadataBytes); err != nil {
+			return ocispec.Descriptor{}, nil, "", err
+		}
+		resp.Body = limitReadCloser(resp.Body, s.repo.MaxMetadataBytes)
+		if s.repo.VerifyManifestMediaType {
+			if err := verifyManifestMediaType(resp, desc.MediaType, s.repo.MaxMetadataBytes); err != nil {
+				return ocispec.Descriptor{}, nil, "", err
+			}
 		}
-		return desc, resp.Body, nil
+		return desc, resp.Body, resp.Header.Get("ETag"), nil
 	case http.StatusNotFound:
-		return ocispec.Descriptor{}, nil, fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
+		return ocispec.Descriptor{}, nil, "", fmt.Errorf("%s: %w", ref, errdef.ErrNotFound)
 	default:
-		return ocispec.Descriptor{}, nil, errutil.ParseErrorResponse(resp)
+		return ocispec.Descriptor{}, nil, "", errutil.ParseErrorResponse(resp)
 	}
 }
 
+// doResolve sends a HEAD request resolving a manifest. If FallbackToGET is
+// enabled, the request is sent with the GET method instead when the HEAD
+// method is not supported by the remote registry.
+func (s *manifestStore) doResolve(req *http.Request) (*http.Response, error) {
+	if s.repo.FallbackToGET && s.repo.headUnsupported.Load() {
+		req.Method = http.MethodGet
+		return s.doWithDockerFallback(req)
+	}
+
+	resp, err := s.doWithDockerFallback(req)
+	if err != nil {
+		return nil, err
+	}
+	if !s.repo.FallbackToGET || resp.StatusCode != http.StatusMethodNotAllowed {
+		return resp, nil
+	}
+	resp.Body.Close()
+	s.repo.headUnsupported.Store(true)
+
+	req = req.Clone(req.Context())
+	req.Method = http.MethodGet
+	return s.doWithDockerFallback(req)
+}
+
+// doWithDockerFallback sends a request resolving a manifest from a reference.
+// If FallbackToDockerMediaTypes is enabled and the request for non-Docker
+// manifest media types is responded with 404 or 406, the request is retried
+// with the Docker manifest media types.
+func (s *manifestStore) doWithDockerFallback(req *http.Request) (*http.Response, error) {
+	resp, err := s.repo.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !s.repo.FallbackToDockerMediaTypes || hasDockerManifestMediaType(s.repo.ManifestMediaTypes) {
+		return resp, nil
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusNotAcceptable:
+		resp.Body.Close()
+	default:
+		return resp, nil
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept", manifestAcceptHeader(dockerManifestMediaTypes, nil))
+	return s.repo.do(req)
+}
+
 // Tag tags a manifest descriptor with a reference string.
 func (s *manifestStore) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
 	ref, err := s.repo.ParseReference(reference)
@@ -1271,6 +2270,9 @@ func (s *manifestStore) Tag(ctx context.Context, desc ocispec.Descriptor, refere
 
 // PushReference pushes the manifest with a reference tag.
 func (s *manifestStore) PushReference(ctx context.Context, expected ocispec.Descriptor, content io.Reader, reference string) error {
+	if err := s.repo.checkDigestAlgorithm(expected); err != nil {
+		return err
+	}
 	ref, err := s.repo.ParseReference(reference)
 	if err != nil {
 		return err
@@ -1280,6 +2282,14 @@ func (s *manifestStore) PushReference(ctx context.Context, expected ocispec.Desc
 
 // push pushes the manifest content, matching the expected descriptor.
 func (s *manifestStore) push(ctx context.Context, expected ocispec.Descriptor, content io.Reader, reference string) error {
+	return s.pushIfMatch(ctx, expected, content, reference, "")
+}
+
+// pushIfMatch pushes the manifest content, matching the expected descriptor.
+// If etag is not empty, it is sent in the If-Match header so that the push
+// fails with errPreconditionFailed if the manifest tagged by reference has
+// been modified since it was read.
+func (s *manifestStore) pushIfMatch(ctx context.Context, expected ocispec.Descriptor, content io.Reader, reference string, etag string) error {
 	ref := s.repo.Reference
 	ref.Reference = reference
 	// pushing usually requires both pull and push actions.
@@ -1302,6 +2312,9 @@ func (s *manifestStore) push(ctx context.Context, expected ocispec.Descriptor, c
 	}
 	req.ContentLength = expected.Size
 	req.Header.Set("Content-Type", expected.MediaType)
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
 
 	// if the underlying client is an auth client, the content might be read
 	// more than once for obtaining the auth challenge and the actual request.
@@ -1328,6 +2341,9 @@ func (s *manifestStore) push(ctx context.Context, expected ocispec.Descriptor, c
 	}
 	defer resp.Body.Close()
 
+	if etag != "" && resp.StatusCode == http.StatusPreconditionFailed {
+		return fmt.Errorf("%s %q: %w", resp.Request.Method, resp.Request.URL, errPreconditionFailed)
+	}
 	if resp.StatusCode != http.StatusCreated {
 		return errutil.ParseErrorResponse(resp)
 	}
@@ -1435,6 +2451,11 @@ func (s *manifestStore) indexReferrersForPush(ctx context.Context, desc ocispec.
 	default:
 		return nil
 	}
+	if s.repo.ReferrerAnnotations != nil {
+		referrer := desc
+		referrer.Annotations = maps.Clone(desc.Annotations)
+		desc.Annotations = s.repo.ReferrerAnnotations(referrer)
+	}
 
 	// if the manifest has a subject but the remote registry does not process it,
 	// it means that the Referrers API is not supported by the registry.
@@ -1442,8 +2463,26 @@ func (s *manifestStore) indexReferrersForPush(ctx context.Context, desc ocispec.
 	return s.updateReferrersIndex(ctx, subject, referrerChange{desc, referrerOperationAdd})
 }
 
+// maxReferrersIndexUpdateRetries is the maximum number of retries updating
+// a referrers index concurrently modified by other clients.
+const maxReferrersIndexUpdateRetries = 3
+
 // updateReferrersIndex updates the referrers index for desc referencing subject
 // on manifest push and manifest delete.
+// As the referrers index may be concurrently updated by other clients, the
+// changes are re-applied on the latest referrers index if it has been modified
+// since the last read, bounded by maxReferrersIndexUpdateRetries:
+//   - If the registry returns an ETag for the referrers index, the updated
+//     index is pushed with the If-Match header, and the push fails if the
+//     index has been modified since the last read.
+//   - Otherwise, the referrers index is re-read right before pushing the
+//     updated index or deleting the old one, and re-read again afterwards to
+//     validate that the update has not been overwritten.
+//
+// Without the ETag, a concurrent update landing between the validation and
+// the update of another client may still be lost, since the distribution
+// spec provides no atomic compare-and-swap on tags. Dangling referrers
+// indexes may also be left behind by concurrent updates.
 // References:
 //   - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pushing-manifests-with-subject
 //   - https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#deleting-manifests
@@ -1452,58 +2491,94 @@ func (s *manifestStore) updateReferrersIndex(ctx context.Context, subject ocispe
 
 	var oldIndexDesc *ocispec.Descriptor
 	var oldReferrers []ocispec.Descriptor
-	prepare := func() error {
-		// 1. pull the original referrers list using the referrers tag schema
-		indexDesc, referrers, err := s.repo.referrersFromIndex(ctx, referrersTag)
+	var oldETag string
+	// readIndex pulls the current referrers list using the referrers tag
+	// schema, along with its ETag, if any.
+	readIndex := func() error {
+		indexDesc, referrers, etag, err := s.repo.referrersFromIndex(ctx, referrersTag)
 		if err != nil {
 			if errors.Is(err, errdef.ErrNotFound) {
 				// valid case: no old referrers index
+				oldIndexDesc = nil
+				oldReferrers = nil
+				oldETag = ""
 				return nil
 			}
 			return err
 		}
 		oldIndexDesc = &indexDesc
 		oldReferrers = referrers
+		oldETag = etag
 		return nil
 	}
+	prepare := func() error {
+		// 1. pull the original referrers list using the referrers tag schema
+		return readIndex()
+	}
 	update := func(referrerChanges []referrerChange) error {
-		// 2. apply the referrer changes on the referrers list
-		updatedReferrers, err := applyReferrerChanges(oldReferrers, referrerChanges)
-		if err != nil {
-			if err == errNoReferrerUpdate {
-				return nil
+		// the referrers index replaced by this update, to be deleted
+		var replacedIndexDesc *ocispec.Descriptor
+		var newIndexDesc *ocispec.Descriptor
+		// optimistic concurrency: if the registry returns an ETag for the
+		// referrers index, the updated index is pushed conditionally and the
+		// changes are re-applied if the index is concurrently modified by
+		// other clients. Otherwise, the index is pushed unconditionally.
+		for attempt := 0; ; attempt++ {
+			if attempt > maxReferrersIndexUpdateRetries {
+				return fmt.Errorf("failed to update referrers index tagged by %s: concurrently modified after %d retries", referrersTag, maxReferrersIndexUpdateRetries)
 			}
-			return err
-		}
-
-		// 3. push the updated referrers list using referrers tag schema
-		if len(updatedReferrers) > 0 || s.repo.SkipReferrersGC {
-			// push a new index in either case:
-			// 1. the referrers list has been updated with a non-zero size
-			// 2. OR the updated referrers list is empty but referrers GC
-			//    is skipped, in this case an empty index should still be pushed
-			//    as the old index won't get deleted
-			newIndexDesc, newIndex, err := generateIndex(updatedReferrers)
+			// 2. apply the referrer changes on the referrers list
+			updatedReferrers, err := applyReferrerChanges(oldReferrers, referrerChanges)
 			if err != nil {
-				return fmt.Errorf("failed to generate referrers index for referrers tag %s: %w", referrersTag, err)
+				if err == errNoReferrerUpdate {
+					return nil
+				}
+				return err
 			}
-			if err := s.push(ctx, newIndexDesc, bytes.NewReader(newIndex), referrersTag); err != nil {
-				return fmt.Errorf("failed to push referrers index tagged by %s: %w", referrersTag, err)
+			replacedIndexDesc = oldIndexDesc
+
+			// 3. push the updated referrers list using referrers tag schema
+			if len(updatedReferrers) > 0 || s.repo.SkipReferrersGC {
+				// push a new index in either case:
+				// 1. the referrers list has been updated with a non-zero size
+				// 2. OR the updated referrers list is empty but referrers GC
+				//    is skipped, in this case an empty index should still be pushed
+				//    as the old index won't get deleted
+				indexDesc, newIndex, err := generateIndex(updatedReferrers)
+				if err != nil {
+					return fmt.Errorf("failed to generate referrers index for referrers tag %s: %w", referrersTag, err)
+				}
+				if err := s.pushIfMatch(ctx, indexDesc, bytes.NewReader(newIndex), referrersTag, oldETag); err != nil {
+					if errors.Is(err, errPreconditionFailed) {
+						// modified since the last read
+						if err := readIndex(); err != nil {
+							return err
+						}
+						continue
+					}
+					return fmt.Errorf("failed to push referrers index tagged by %s: %w", referrersTag, err)
+				}
+				newIndexDesc = &indexDesc
 			}
+			break
 		}
 
-		// 4. delete the dangling original referrers index, if applicable
-		if s.repo.SkipReferrersGC || oldIndexDesc == nil {
+		// 4. delete the dangling original referrers index, if applicable.
+		// The deletion is best-effort: on failure, the new referrers index is
+		// kept and the returned ReferrersError tells the caller that only the
+		// garbage collection failed.
+		if s.repo.SkipReferrersGC || replacedIndexDesc == nil {
 			return nil
 		}
-		if err := s.repo.delete(ctx, *oldIndexDesc, true); err != nil {
-			return &ReferrersError{
-				Op:      opDeleteReferrersIndex,
-				Err:     fmt.Errorf("failed to delete dangling referrers index %s for referrers tag %s: %w", oldIndexDesc.Digest.String(), referrersTag, err),
-				Subject: subject,
-			}
+		// safety check: the original referrers index is deleted only after the
+		// push of the new one, if any, has been acknowledged by the registry
+		// with a matching digest, which is not further confirmed by resolving
+		// the new one. The original one is not deleted if the new one has the
+		// same digest, as it is still tagged.
+		if newIndexDesc != nil && newIndexDesc.Digest == replacedIndexDesc.Digest {
+			return nil
 		}
-		return nil
+		return s.deleteReferrersIndex(ctx, subject, referrersTag, *replacedIndexDesc)
 	}
 
 	merge, done := s.repo.referrersMergePool.Get(referrersTag)
@@ -1511,6 +2586,19 @@ func (s *manifestStore) updateReferrersIndex(ctx context.Context, subject ocispe
 	return merge.Do(change, prepare, update)
 }
 
+// deleteReferrersIndex deletes the referrers index tagged by referrersTag for
+// subject. On failure, a ReferrersError is returned.
+func (s *manifestStore) deleteReferrersIndex(ctx context.Context, subject ocispec.Descriptor, referrersTag string, indexDesc ocispec.Descriptor) error {
+	if err := s.repo.delete(ctx, indexDesc, true); err != nil {
+		return &ReferrersError{
+			Op:      opDeleteReferrersIndex,
+			Err:     fmt.Errorf("failed to delete dangling referrers index %s for referrers tag %s: %w", indexDesc.Digest.String(), referrersTag, err),
+			Subject: subject,
+		}
+	}
+	return nil
+}
+
 // ParseReference parses a reference to a fully qualified reference.
 func (s *manifestStore) ParseReference(reference string) (registry.Reference, error) {
 	return s.repo.ParseReference(reference)
@@ -18,32 +18,42 @@ package remote
 import (
 	"bytes"
 	"context"
+	_ "crypto/sha512"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/opencontainers/go-digest"
 	specs "github.com/opencontainers/image-spec/specs-go"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"golang.org/x/sync/errgroup"
+	"oras.land/oras-go/v2"
 	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
 	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/docker"
 	"oras.land/oras-go/v2/internal/interfaces"
 	"oras.land/oras-go/v2/internal/spec"
 	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/errcode"
+	"oras.land/oras-go/v2/registry/remote/internal/errutil"
 )
 
 type testIOStruct struct {
@@ -169,6 +179,28 @@ func TestNewRepository(t *testing.T) {
 	}
 }
 
+func TestNewRepositoryStrict(t *testing.T) {
+	longReference := "localhost:5000/" + strings.Repeat("a", 255)
+	if _, err := NewRepository(longReference); err != nil {
+		t.Fatalf("NewRepository() error = %v, wantErr nil", err)
+	}
+	if _, err := NewRepositoryStrict(longReference); !errors.Is(err, errdef.ErrInvalidReference) {
+		t.Errorf("NewRepositoryStrict() error = %v, wantErr %v", err, errdef.ErrInvalidReference)
+	}
+	if _, err := NewRepositoryStrict("localhost:5000/Hello-World"); !errors.Is(err, errdef.ErrInvalidReference) {
+		t.Errorf("NewRepositoryStrict() error = %v, wantErr %v", err, errdef.ErrInvalidReference)
+	}
+
+	reference := "localhost:5000/hello-world:v1"
+	repo, err := NewRepositoryStrict(reference)
+	if err != nil {
+		t.Fatalf("NewRepositoryStrict() error = %v", err)
+	}
+	if got := repo.Reference.String(); got != reference {
+		t.Errorf("NewRepositoryStrict() got = %v, want %v", got, reference)
+	}
+}
+
 func TestRepository_Fetch(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
@@ -255,62 +287,133 @@ func TestRepository_Fetch(t *testing.T) {
 	}
 }
 
-func TestRepository_Push(t *testing.T) {
+func TestRepository_HostRewrite(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
+	unreachableHost := "cdn.example.invalid"
 	var gotBlob []byte
-	index := []byte(`{"manifests":[]}`)
-	indexDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageIndex,
-		Digest:    digest.FromBytes(index),
-		Size:      int64(len(index)),
-	}
-	var gotIndex []byte
-	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
+			http.Redirect(w, r, "http://"+unreachableHost+"/cdn/blob", http.StatusTemporaryRedirect)
+		case r.Method == http.MethodGet && r.URL.Path == "/cdn/blob":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
 		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
-			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.Header().Set("Location", "http://"+unreachableHost+"/upload")
 			w.WriteHeader(http.StatusAccepted)
-			return
-		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
-			if contentType := r.Header.Get("Content-Type"); contentType != "application/octet-stream" {
-				w.WriteHeader(http.StatusBadRequest)
-				break
-			}
-			if contentDigest := r.URL.Query().Get("digest"); contentDigest != blobDesc.Digest.String() {
+		case r.Method == http.MethodPut && r.URL.Path == "/upload":
+			if r.URL.Query().Get("digest") != blobDesc.Digest.String() {
 				w.WriteHeader(http.StatusBadRequest)
-				break
+				return
 			}
 			buf := bytes.NewBuffer(nil)
 			if _, err := buf.ReadFrom(r.Body); err != nil {
 				t.Errorf("fail to read: %v", err)
 			}
 			gotBlob = buf.Bytes()
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
 			w.WriteHeader(http.StatusCreated)
-			return
-		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
-			if contentType := r.Header.Get("Content-Type"); contentType != indexDesc.MediaType {
-				w.WriteHeader(http.StatusBadRequest)
-				break
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	hostRewrite := func(u *url.URL) *url.URL {
+		if u.Host != unreachableHost {
+			return nil
+		}
+		rewritten := *u
+		rewritten.Host = uri.Host
+		return &rewritten
+	}
+
+	for _, client := range []Client{&auth.Client{}, &http.Client{}} {
+		t.Run(fmt.Sprintf("%T", client), func(t *testing.T) {
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
 			}
-			buf := bytes.NewBuffer(nil)
-			if _, err := buf.ReadFrom(r.Body); err != nil {
-				t.Errorf("fail to read: %v", err)
+			repo.Client = client
+			repo.PlainHTTP = true
+			repo.HostRewrite = hostRewrite
+			ctx := context.Background()
+
+			// redirect targets are rewritten
+			rc, err := repo.Fetch(ctx, blobDesc)
+			if err != nil {
+				t.Fatalf("Repository.Fetch() error = %v", err)
 			}
-			gotIndex = buf.Bytes()
-			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
-			w.WriteHeader(http.StatusCreated)
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("Repository.Fetch().Read() error = %v", err)
+			}
+			if err := rc.Close(); err != nil {
+				t.Errorf("Repository.Fetch().Close() error = %v", err)
+			}
+			if !bytes.Equal(got, blob) {
+				t.Errorf("Repository.Fetch() = %v, want %v", got, blob)
+			}
+
+			// upload locations are rewritten
+			gotBlob = nil
+			if err := repo.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+				t.Fatalf("Repository.Push() error = %v", err)
+			}
+			if !bytes.Equal(gotBlob, blob) {
+				t.Errorf("Repository.Push() = %v, want %v", gotBlob, blob)
+			}
+		})
+	}
+}
+
+func TestRepository_FetchWithResponse(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.Header().Set("X-Amz-Request-Id", "blob-request")
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		case "/v2/test/manifests/" + indexDesc.Digest.String():
+			w.Header().Set("Content-Type", indexDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			w.Header().Set("X-Amz-Request-Id", "manifest-request")
+			if _, err := w.Write(index); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
 		default:
-			w.WriteHeader(http.StatusForbidden)
+			w.WriteHeader(http.StatusNotFound)
 		}
-		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
@@ -325,57 +428,84 @@ func TestRepository_Push(t *testing.T) {
 	repo.PlainHTTP = true
 	ctx := context.Background()
 
-	err = repo.Push(ctx, blobDesc, bytes.NewReader(blob))
-	if err != nil {
-		t.Fatalf("Repository.Push() error = %v", err)
+	tests := []struct {
+		desc        ocispec.Descriptor
+		content     []byte
+		contentType string
+		requestID   string
+	}{
+		{blobDesc, blob, "application/octet-stream", "blob-request"},
+		{indexDesc, index, indexDesc.MediaType, "manifest-request"},
 	}
-	if !bytes.Equal(gotBlob, blob) {
-		t.Errorf("Repository.Push() = %v, want %v", gotBlob, blob)
+	for _, tt := range tests {
+		rc, header, err := repo.FetchWithResponse(ctx, tt.desc)
+		if err != nil {
+			t.Fatalf("Repository.FetchWithResponse() error = %v", err)
+		}
+		got, err := content.ReadAll(rc, tt.desc)
+		if err != nil {
+			t.Errorf("content.ReadAll() error = %v", err)
+		}
+		if err := rc.Close(); err != nil {
+			t.Errorf("fail to close: %v", err)
+		}
+		if !bytes.Equal(got, tt.content) {
+			t.Errorf("Repository.FetchWithResponse() = %v, want %v", got, tt.content)
+		}
+		if got := header.Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Content-Type = %v, want %v", got, tt.contentType)
+		}
+		if got := header.Get("X-Amz-Request-Id"); got != tt.requestID {
+			t.Errorf("X-Amz-Request-Id = %v, want %v", got, tt.requestID)
+		}
 	}
 
-	err = repo.Push(ctx, indexDesc, bytes.NewReader(index))
-	if err != nil {
-		t.Fatalf("Repository.Push() error = %v", err)
+	// the content is still verified
+	mismatchedDesc := blobDesc
+	mismatchedDesc.Size++
+	if _, _, err := repo.FetchWithResponse(ctx, mismatchedDesc); err == nil {
+		t.Errorf("Repository.FetchWithResponse() error = nil, want error")
 	}
-	if !bytes.Equal(gotIndex, index) {
-		t.Errorf("Repository.Push() = %v, want %v", gotIndex, index)
+
+	missingDesc := content.NewDescriptorFromBytes("test", []byte("missing"))
+	if _, _, err := repo.FetchWithResponse(ctx, missingDesc); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Repository.FetchWithResponse() error = %v, want %v", err, errdef.ErrNotFound)
 	}
 }
 
-func TestRepository_Mount(t *testing.T) {
+func TestRepository_Fetch_ReadIdleTimeout(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
-	gotMount := 0
+	stall := make(chan struct{})
+	defer close(stall)
+	var requestCount int64
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if got, want := r.Method, "POST"; got != want {
-			t.Errorf("unexpected HTTP method; got %q want %q", got, want)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if err := r.ParseForm(); err != nil {
-			t.Errorf("invalid form in HTTP request: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/test/blobs/"+blobDesc.Digest.String() {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
 			return
 		}
-		switch r.URL.Path {
-		case "/v2/test2/blobs/uploads/":
-			if got, want := r.Form.Get("mount"), blobDesc.Digest; digest.Digest(got) != want {
-				t.Errorf("unexpected value for 'mount' parameter; got %q want %q", got, want)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(blob)))
+		stalled := atomic.AddInt64(&requestCount, 1) == 1
+		for i := range blob {
+			if _, err := w.Write(blob[i : i+1]); err != nil {
+				return
 			}
-			if got, want := r.Form.Get("from"), "test"; got != want {
-				t.Errorf("unexpected value for 'from' parameter; got %q want %q", got, want)
+			w.(http.Flusher).Flush()
+			if i == len(blob)/2 && stalled {
+				// pause mid-stream beyond the idle timeout
+				select {
+				case <-stall:
+				case <-r.Context().Done():
+				}
+				return
 			}
-			gotMount++
-			w.Header().Set(headerDockerContentDigest, blobDesc.Digest.String())
-			w.WriteHeader(201)
-			return
-		default:
-			t.Errorf("unexpected URL for mount request %q", r.URL)
-			w.WriteHeader(http.StatusInternalServerError)
+			time.Sleep(20 * time.Millisecond)
 		}
 	}))
 	defer ts.Close()
@@ -383,79 +513,80 @@ func TestRepository_Mount(t *testing.T) {
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-	repo, err := NewRepository(uri.Host + "/test2")
+
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
+	repo.ReadIdleTimeout = 100 * time.Millisecond
 	ctx := context.Background()
 
-	err = repo.Mount(ctx, blobDesc, "test", nil)
+	// stalled stream
+	rc, err := repo.Fetch(ctx, blobDesc)
 	if err != nil {
-		t.Fatalf("Repository.Push() error = %v", err)
+		t.Fatalf("Repository.Fetch() error = %v", err)
 	}
-	if gotMount != 1 {
-		t.Errorf("did not get expected mount request")
+	_, err = io.ReadAll(rc)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Repository.Fetch().Read() error = %v, want %v", err, context.DeadlineExceeded)
 	}
-}
+	rc.Close()
 
-func TestRepository_Mount_Fallback(t *testing.T) {
-	// This test checks the case where the server does not know
-	// about the mount query parameters, so the call falls back to
-	// the regular push flow. This test is thus very similar to TestPush,
-	// except that it doesn't push a manifest because mounts aren't
-	// documented to be supported for manifests.
+	// slow stream making progress within the idle timeout
+	rc, err = repo.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Repository.Fetch() error = %v", err)
+	}
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("Repository.Fetch().Read() error = %v", err)
+	}
+	rc.Close()
+	if !bytes.Equal(got, blob) {
+		t.Errorf("Repository.Fetch() = %v, want %v", got, blob)
+	}
+}
 
+func TestRepository_RequestTimeout(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
-	var sequence string
-	var gotBlob []byte
-	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
-		case r.Method == http.MethodPost && r.URL.Path == "/v2/test2/blobs/uploads/":
-			w.Header().Set("Location", "/v2/test2/blobs/uploads/"+uuid)
-			w.WriteHeader(http.StatusAccepted)
-			sequence += "post "
-			return
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/test/manifests/stalled":
+			// stall beyond the request timeout
+			<-r.Context().Done()
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+manifestDesc.Digest.String():
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			if _, err := w.Write(manifest); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
 		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
 			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			if _, err := w.Write(blob); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
-			}
-			sequence += "get "
-			return
-		case r.Method == http.MethodPut && r.URL.Path == "/v2/test2/blobs/uploads/"+uuid:
-			if got, want := r.Header.Get("Content-Type"), "application/octet-stream"; got != want {
-				t.Errorf("unexpected content type; got %q want %q", got, want)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			if got, want := r.URL.Query().Get("digest"), blobDesc.Digest.String(); got != want {
-				t.Errorf("unexpected content digest; got %q want %q", got, want)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			data, err := io.ReadAll(r.Body)
-			if err != nil {
-				t.Errorf("error reading body: %v", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+			w.Header().Set("Content-Length", strconv.Itoa(len(blob)))
+			// stream slower than the request timeout in total
+			for i := range blob {
+				if _, err := w.Write(blob[i : i+1]); err != nil {
+					return
+				}
+				w.(http.Flusher).Flush()
+				time.Sleep(20 * time.Millisecond)
 			}
-			gotBlob = data
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			w.WriteHeader(http.StatusCreated)
-			sequence += "put "
-			return
 		default:
-			w.WriteHeader(http.StatusForbidden)
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
 		}
-		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
@@ -463,71 +594,61 @@ func TestRepository_Mount_Fallback(t *testing.T) {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	repo, err := NewRepository(uri.Host + "/test2")
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	ctx := context.Background()
+	repo.RequestTimeout = 100 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
 
-	t.Run("getContent is nil", func(t *testing.T) {
-		sequence = ""
+	// stalled request
+	if _, err := repo.Resolve(ctx, "stalled"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Repository.Resolve() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("parent context error = %v, want nil", err)
+	}
 
-		err = repo.Mount(ctx, blobDesc, "test", nil)
-		if err != nil {
-			t.Fatalf("Repository.Push() error = %v", err)
-		}
-		if !bytes.Equal(gotBlob, blob) {
-			t.Errorf("Repository.Mount() = %v, want %v", gotBlob, blob)
-		}
-		if got, want := sequence, "post get put "; got != want {
-			t.Errorf("unexpected request sequence; got %q want %q", got, want)
-		}
-	})
+	// the response body can be read within the request timeout
+	got, err := content.FetchAll(ctx, repo, manifestDesc)
+	if err != nil {
+		t.Fatalf("Repository.Fetch() error = %v", err)
+	}
+	if !bytes.Equal(got, manifest) {
+		t.Errorf("Repository.Fetch() = %v, want %v", got, manifest)
+	}
 
-	t.Run("getContent is non nil", func(t *testing.T) {
-		sequence = ""
-
-		err = repo.Mount(ctx, blobDesc, "test", func() (io.ReadCloser, error) {
-			return io.NopCloser(bytes.NewReader(blob)), nil
-		})
-		if err != nil {
-			t.Fatalf("Repository.Push() error = %v", err)
-		}
-		if !bytes.Equal(gotBlob, blob) {
-			t.Errorf("Repository.Mount() = %v, want %v", gotBlob, blob)
-		}
-		if got, want := sequence, "post put "; got != want {
-			t.Errorf("unexpected request sequence; got %q want %q", got, want)
-		}
-	})
+	// blob fetches are exempt from the request timeout
+	got, err = content.FetchAll(ctx, repo, blobDesc)
+	if err != nil {
+		t.Fatalf("Repository.Fetch() error = %v", err)
+	}
+	if !bytes.Equal(got, blob) {
+		t.Errorf("Repository.Fetch() = %v, want %v", got, blob)
+	}
 }
 
-func TestRepository_Mount_Error(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
-	}
+func TestRepository_CheckPush(t *testing.T) {
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	var allowed bool
+	var requests []string
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if got, want := r.Method, "POST"; got != want {
-			t.Errorf("unexpected HTTP method; got %q want %q", got, want)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		if err := r.ParseForm(); err != nil {
-			t.Errorf("invalid form in HTTP request: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/uploads/":
-			w.WriteHeader(400)
-			w.Write([]byte(`{ "errors": [ { "code": "NAME_UNKNOWN", "message": "some error" } ] }`))
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			w.WriteHeader(http.StatusNoContent)
 		default:
-			t.Errorf("unexpected URL for mount request %q", r.URL)
-			w.WriteHeader(http.StatusInternalServerError)
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
 		}
 	}))
 	defer ts.Close()
@@ -535,196 +656,288 @@ func TestRepository_Mount_Error(t *testing.T) {
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
+
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
+	ctx := context.Background()
 
-	err = repo.Mount(context.Background(), blobDesc, "foo", nil)
-	if err == nil {
-		t.Fatalf("expected error but got success instead")
-	}
+	// push denied
+	err = repo.CheckPush(ctx)
 	var errResp *errcode.ErrorResponse
-	if !errors.As(err, &errResp) {
-		t.Fatalf("unexpected error type %#v", err)
+	if !errors.As(err, &errResp) || errResp.StatusCode != http.StatusForbidden {
+		t.Errorf("Repository.CheckPush() error = %v, want %v", err, http.StatusForbidden)
 	}
-	if !reflect.DeepEqual(errResp.Errors, errcode.Errors{{
-		Code:    "NAME_UNKNOWN",
-		Message: "some error",
-	}}) {
-		t.Errorf("unexpected errors %#v", errResp.Errors)
+
+	// push allowed, and the upload session is cancelled
+	allowed = true
+	requests = nil
+	if err := repo.CheckPush(ctx); err != nil {
+		t.Fatalf("Repository.CheckPush() error = %v", err)
+	}
+	want := []string{
+		"POST /v2/test/blobs/uploads/",
+		"DELETE /v2/test/blobs/uploads/" + uuid,
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %v, want %v", requests, want)
 	}
 }
 
-func TestRepository_Mount_Fallback_GetContent(t *testing.T) {
-	// This test checks the case where the server does not know
-	// about the mount query parameters, so the call falls back to
-	// the regular push flow, but using the getContent function
-	// parameter to get the content to push.
-
+func TestRepository_Fetch_RequestCounter(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
-	var sequence string
-	var gotBlob []byte
-	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	token := "test-token"
+	var realm string
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch {
-		case r.Method == http.MethodPost && r.URL.Path == "/v2/test2/blobs/uploads/":
-			w.Header().Set("Location", "/v2/test2/blobs/uploads/"+uuid)
-			w.WriteHeader(http.StatusAccepted)
-			sequence += "post "
-			return
-		case r.Method == http.MethodPut && r.URL.Path == "/v2/test2/blobs/uploads/"+uuid:
-			if got, want := r.Header.Get("Content-Type"), "application/octet-stream"; got != want {
-				t.Errorf("unexpected content type; got %q want %q", got, want)
-				w.WriteHeader(http.StatusBadRequest)
-				return
+		switch r.URL.Path {
+		case "/token":
+			if err := json.NewEncoder(w).Encode(map[string]string{"token": token}); err != nil {
+				t.Errorf("failed to write response: %v", err)
 			}
-			if got, want := r.URL.Query().Get("digest"), blobDesc.Digest.String(); got != want {
-				t.Errorf("unexpected content digest; got %q want %q", got, want)
-				w.WriteHeader(http.StatusBadRequest)
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm=%q,service="test",scope="repository:test:pull"`, realm))
+				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
-			data, err := io.ReadAll(r.Body)
-			if err != nil {
-				t.Errorf("error reading body: %v", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
+			w.Header().Set("Content-Type", "application/octet-stream")
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
 			}
-			gotBlob = data
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			w.WriteHeader(http.StatusCreated)
-			sequence += "put "
-			return
 		default:
-			w.WriteHeader(http.StatusForbidden)
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
 		}
-		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 	}))
 	defer ts.Close()
+	realm = ts.URL + "/token"
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	repo, err := NewRepository(uri.Host + "/test2")
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
+	counter := &auth.RequestCounter{}
+	repo.Client = &auth.Client{
+		Cache:          auth.NewCache(),
+		RequestCounter: counter,
+	}
 	repo.PlainHTTP = true
 	ctx := context.Background()
 
-	err = repo.Mount(ctx, blobDesc, "test", func() (io.ReadCloser, error) {
-		return io.NopCloser(bytes.NewReader(blob)), nil
-	})
-	if err != nil {
-		t.Fatalf("Repository.Push() error = %v", err)
+	fetch := func() {
+		rc, err := repo.Fetch(ctx, blobDesc)
+		if err != nil {
+			t.Fatalf("Repository.Fetch() error = %v", err)
+		}
+		defer rc.Close()
+		got, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("Repository.Fetch().Read() error = %v", err)
+		}
+		if !bytes.Equal(got, blob) {
+			t.Errorf("Repository.Fetch() = %v, want %v", got, blob)
+		}
 	}
-	if !bytes.Equal(gotBlob, blob) {
-		t.Errorf("Repository.Mount() = %v, want %v", gotBlob, blob)
+
+	// the first fetch is challenged and exchanges a token
+	fetch()
+	if got, want := counter.Reset(), int64(3); got != want {
+		t.Errorf("RequestCounter.Reset() = %v, want %v", got, want)
 	}
-	if got, want := sequence, "post put "; got != want {
-		t.Errorf("unexpected request sequence; got %q want %q", got, want)
+	if got := counter.Count(); got != 0 {
+		t.Errorf("RequestCounter.Count() = %v, want 0", got)
 	}
-}
 
-func TestRepository_Mount_Fallback_GetContentError(t *testing.T) {
-	// This test checks the case where the server does not know
-	// about the mount query parameters, so the call falls back to
-	// the regular push flow, but it's possible the caller wants to
-	// avoid the pull/push pattern so returns an error from getContent
-	// and checks it to find out that's happened.
+	// the second fetch uses the cached token
+	fetch()
+	if got, want := counter.Count(), int64(1); got != want {
+		t.Errorf("RequestCounter.Count() = %v, want %v", got, want)
+	}
+}
 
+func TestRepository_RequestPushScope(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
-	var sequence string
 	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	var realm string
+	var gotScopes []string
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			// issue tokens carrying the granted scopes
+			scopes := r.URL.Query()["scope"]
+			gotScopes = append(gotScopes, strings.Join(scopes, " "))
+			if err := json.NewEncoder(w).Encode(map[string]string{"token": strings.Join(scopes, " ")}); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+			return
+		}
+		scope, actions := "repository:test:pull", []string{"pull"}
+		if r.Method != http.MethodHead {
+			scope, actions = "repository:test:pull,push", []string{"pull", "push"}
+		}
+		var granted []string
+		for _, tokenScope := range strings.Fields(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")) {
+			if grantedActions, ok := strings.CutPrefix(tokenScope, "repository:test:"); ok {
+				granted = append(granted, strings.Split(grantedActions, ",")...)
+			}
+		}
+		authorized := !slices.ContainsFunc(actions, func(action string) bool {
+			return !slices.Contains(granted, action)
+		})
+		if !authorized {
+			w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm=%q,service="test",scope=%q`, realm, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
 		switch {
-		case r.Method == http.MethodPost && r.URL.Path == "/v2/test2/blobs/uploads/":
-			w.Header().Set("Location", "/v2/test2/blobs/uploads/"+uuid)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
 			w.WriteHeader(http.StatusAccepted)
-			sequence += "post "
-			return
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			if contentDigest := r.URL.Query().Get("digest"); contentDigest != blobDesc.Digest.String() {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
 		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 			w.WriteHeader(http.StatusForbidden)
 		}
-		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 	}))
 	defer ts.Close()
+	realm = ts.URL + "/token"
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	repo, err := NewRepository(uri.Host + "/test2")
-	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
+	tests := []struct {
+		name             string
+		requestPushScope bool
+		wantScopes       []string
+		wantRequests     int64
+	}{
+		{
+			name:         "push after pull fetches another token",
+			wantScopes:   []string{"repository:test:pull", "repository:test:pull,push"},
+			wantRequests: 7,
+		},
+		{
+			name:             "push after pull shares a single token",
+			requestPushScope: true,
+			wantScopes:       []string{"repository:test:pull,push"},
+			wantRequests:     5,
+		},
 	}
-	repo.PlainHTTP = true
-	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotScopes = nil
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			counter := &auth.RequestCounter{}
+			repo.Client = &auth.Client{
+				Cache:          auth.NewCache(),
+				RequestCounter: counter,
+			}
+			repo.PlainHTTP = true
+			repo.RequestPushScope = tt.requestPushScope
+			ctx := context.Background()
 
-	testErr := errors.New("test error")
-	err = repo.Mount(ctx, blobDesc, "test", func() (io.ReadCloser, error) {
-		return nil, testErr
-	})
-	if err == nil {
-		t.Fatalf("expected error but found no error")
-	}
-	if !errors.Is(err, testErr) {
-		t.Fatalf("expected getContent error to be wrapped")
-	}
-	if got, want := sequence, "post "; got != want {
-		t.Errorf("unexpected request sequence; got %q want %q", got, want)
+			exists, err := repo.Exists(ctx, blobDesc)
+			if err != nil {
+				t.Fatalf("Repository.Exists() error = %v", err)
+			}
+			if exists {
+				t.Errorf("Repository.Exists() = %v, want %v", exists, false)
+			}
+			if err := repo.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+				t.Fatalf("Repository.Push() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotScopes, tt.wantScopes) {
+				t.Errorf("requested scopes = %q, want %q", gotScopes, tt.wantScopes)
+			}
+			if got := counter.Count(); got != tt.wantRequests {
+				t.Errorf("RequestCounter.Count() = %v, want %v", got, tt.wantRequests)
+			}
+		})
 	}
 }
 
-func TestRepository_Exists(t *testing.T) {
+func TestRepository_Push(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
+	var gotBlob []byte
 	index := []byte(`{"manifests":[]}`)
 	indexDesc := ocispec.Descriptor{
 		MediaType: ocispec.MediaTypeImageIndex,
 		Digest:    digest.FromBytes(index),
 		Size:      int64(len(index)),
 	}
+	var gotIndex []byte
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodHead {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
 			return
-		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			w.Header().Set("Content-Type", "application/octet-stream")
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			if contentType := r.Header.Get("Content-Type"); contentType != "application/octet-stream" {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			if contentDigest := r.URL.Query().Get("digest"); contentDigest != blobDesc.Digest.String() {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotBlob = buf.Bytes()
 			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			w.Header().Set("Content-Length", strconv.Itoa(int(blobDesc.Size)))
-		case "/v2/test/manifests/" + indexDesc.Digest.String():
-			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
-				t.Errorf("manifest not convertable: %s", accept)
+			w.WriteHeader(http.StatusCreated)
+			return
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
+			if contentType := r.Header.Get("Content-Type"); contentType != indexDesc.MediaType {
 				w.WriteHeader(http.StatusBadRequest)
-				return
+				break
 			}
-			w.Header().Set("Content-Type", indexDesc.MediaType)
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotIndex = buf.Bytes()
 			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
-			w.Header().Set("Content-Length", strconv.Itoa(int(indexDesc.Size)))
+			w.WriteHeader(http.StatusCreated)
+			return
 		default:
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
+			w.WriteHeader(http.StatusForbidden)
 		}
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
@@ -739,132 +952,75 @@ func TestRepository_Exists(t *testing.T) {
 	repo.PlainHTTP = true
 	ctx := context.Background()
 
-	exists, err := repo.Exists(ctx, blobDesc)
+	err = repo.Push(ctx, blobDesc, bytes.NewReader(blob))
 	if err != nil {
-		t.Fatalf("Repository.Exists() error = %v", err)
+		t.Fatalf("Repository.Push() error = %v", err)
 	}
-	if !exists {
-		t.Errorf("Repository.Exists() = %v, want %v", exists, true)
+	if !bytes.Equal(gotBlob, blob) {
+		t.Errorf("Repository.Push() = %v, want %v", gotBlob, blob)
 	}
 
-	exists, err = repo.Exists(ctx, indexDesc)
+	err = repo.Push(ctx, indexDesc, bytes.NewReader(index))
 	if err != nil {
-		t.Fatalf("Repository.Exists() error = %v", err)
+		t.Fatalf("Repository.Push() error = %v", err)
 	}
-	if !exists {
-		t.Errorf("Repository.Exists() = %v, want %v", exists, true)
+	if !bytes.Equal(gotIndex, index) {
+		t.Errorf("Repository.Push() = %v, want %v", gotIndex, index)
 	}
 }
 
-func TestRepository_Delete(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
-	}
-	index := []byte(`{"manifests":[]}`)
-	indexDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageIndex,
-		Digest:    digest.FromBytes(index),
-		Size:      int64(len(index)),
-	}
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
 
-	var blobDeleted bool
-	var indexDeleted bool
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch {
-		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
-			blobDeleted = true
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			w.WriteHeader(http.StatusAccepted)
-		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
-			indexDeleted = true
-			// no "Docker-Content-Digest" header for manifest deletion
-			w.WriteHeader(http.StatusAccepted)
-		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
-			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
-				t.Errorf("manifest not convertable: %s", accept)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			w.Header().Set("Content-Type", indexDesc.MediaType)
-			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
-			if _, err := w.Write(index); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
-			}
-		default:
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
-		}
-	}))
-	defer ts.Close()
-	uri, err := url.Parse(ts.URL)
-	if err != nil {
-		t.Fatalf("invalid test http server: %v", err)
-	}
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n.Add(int64(n))
+	return n, err
+}
 
-	repo, err := NewRepository(uri.Host + "/test")
-	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
-	}
-	repo.PlainHTTP = true
-	ctx := context.Background()
+func TestRepository_DigestAlgorithms(t *testing.T) {
+	src := memory.New()
 
-	err = repo.Delete(ctx, blobDesc)
-	if err != nil {
-		t.Fatalf("Repository.Delete() error = %v", err)
+	// generate test content
+	var blobs [][]byte
+	var descs []ocispec.Descriptor
+	appendBlob := func(mediaType string, alg digest.Algorithm, blob []byte) {
+		blobs = append(blobs, blob)
+		descs = append(descs, ocispec.Descriptor{
+			MediaType: mediaType,
+			Digest:    alg.FromBytes(blob),
+			Size:      int64(len(blob)),
+		})
 	}
-	if !blobDeleted {
-		t.Errorf("Repository.Delete() = %v, want %v", blobDeleted, true)
+	appendBlob(ocispec.MediaTypeImageConfig, digest.SHA256, []byte("config")) // Blob 0
+	appendBlob(ocispec.MediaTypeImageLayer, digest.SHA512, []byte("foo"))     // Blob 1
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    descs[0],
+		Layers:    descs[1:2],
 	}
-
-	err = repo.Delete(ctx, indexDesc)
+	manifestJSON, err := json.Marshal(manifest)
 	if err != nil {
-		t.Fatalf("Repository.Delete() error = %v", err)
-	}
-	if !indexDeleted {
-		t.Errorf("Repository.Delete() = %v, want %v", indexDeleted, true)
+		t.Fatal(err)
 	}
-}
+	appendBlob(ocispec.MediaTypeImageManifest, digest.SHA256, manifestJSON) // Blob 2
 
-func TestRepository_Resolve(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
-	}
-	index := []byte(`{"manifests":[]}`)
-	indexDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageIndex,
-		Digest:    digest.FromBytes(index),
-		Size:      int64(len(index)),
+	ctx := context.Background()
+	for i := range blobs {
+		if err := src.Push(ctx, descs[i], bytes.NewReader(blobs[i])); err != nil {
+			t.Fatalf("failed to push test content to src: %d: %v", i, err)
+		}
 	}
-	ref := "foobar"
+
+	var requestCount atomic.Int64
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodHead {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		switch r.URL.Path {
-		case "/v2/test/manifests/" + blobDesc.Digest.String():
-			w.WriteHeader(http.StatusNotFound)
-		case "/v2/test/manifests/" + indexDesc.Digest.String(),
-			"/v2/test/manifests/" + ref:
-			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
-				t.Errorf("manifest not convertable: %s", accept)
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			w.Header().Set("Content-Type", indexDesc.MediaType)
-			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
-			w.Header().Set("Content-Length", strconv.Itoa(int(indexDesc.Size)))
-		default:
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
-		}
+		requestCount.Add(1)
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusForbidden)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
@@ -872,98 +1028,108 @@ func TestRepository_Resolve(t *testing.T) {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	repoName := uri.Host + "/test"
-	repo, err := NewRepository(repoName)
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	ctx := context.Background()
-
-	_, err = repo.Resolve(ctx, blobDesc.Digest.String())
-	if !errors.Is(err, errdef.ErrNotFound) {
-		t.Errorf("Repository.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	repo.DigestAlgorithms = []digest.Algorithm{digest.SHA256}
+	if got, want := repo.AllowedDigestAlgorithms(), repo.DigestAlgorithms; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.AllowedDigestAlgorithms() = %v, want %v", got, want)
 	}
 
-	got, err := repo.Resolve(ctx, indexDesc.Digest.String())
-	if err != nil {
-		t.Fatalf("Repository.Resolve() error = %v", err)
-	}
-	if !reflect.DeepEqual(got, indexDesc) {
-		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
+	// pushing content of not allowed algorithms fails without any request
+	if err := repo.Push(ctx, descs[1], bytes.NewReader(blobs[1])); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Repository.Push() error = %v, wantErr %v", err, errdef.ErrUnsupported)
 	}
-
-	got, err = repo.Resolve(ctx, ref)
-	if err != nil {
-		t.Fatalf("Repository.Resolve() error = %v", err)
+	opts := PushChunkedOptions{ChunkSize: 1}
+	if err := repo.PushChunked(ctx, descs[1], bytes.NewReader(blobs[1]), opts); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Repository.PushChunked() error = %v, wantErr %v", err, errdef.ErrUnsupported)
 	}
-	if !reflect.DeepEqual(got, indexDesc) {
-		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
+	sha512Manifest := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	sha512Manifest.Digest = digest.SHA512.FromBytes(manifestJSON)
+	if err := repo.PushReference(ctx, sha512Manifest, bytes.NewReader(manifestJSON), "latest"); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("Repository.PushReference() error = %v, wantErr %v", err, errdef.ErrUnsupported)
 	}
 
-	tagDigestRef := "whatever" + "@" + indexDesc.Digest.String()
-	got, err = repo.Resolve(ctx, tagDigestRef)
-	if err != nil {
-		t.Fatalf("Repository.Resolve() error = %v", err)
+	// copying a graph of not allowed algorithms fails before any request
+	if err := oras.CopyGraph(ctx, src, repo, descs[2], oras.CopyGraphOptions{}); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("CopyGraph() error = %v, wantErr %v", err, errdef.ErrUnsupported)
 	}
-	if !reflect.DeepEqual(got, indexDesc) {
-		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
+	if got := requestCount.Load(); got != 0 {
+		t.Errorf("request count = %d, want %d", got, 0)
 	}
 
-	fqdnRef := repoName + ":" + tagDigestRef
-	got, err = repo.Resolve(ctx, fqdnRef)
-	if err != nil {
-		t.Fatalf("Repository.Resolve() error = %v", err)
-	}
-	if !reflect.DeepEqual(got, indexDesc) {
-		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
+	// the restriction is kept by the clones of the repository
+	if got, want := repo.clone().DigestAlgorithms, repo.DigestAlgorithms; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.clone().DigestAlgorithms = %v, want %v", got, want)
 	}
 }
 
-func TestRepository_Tag(t *testing.T) {
-	blob := []byte("hello world")
+func TestRepository_Push_Chunked(t *testing.T) {
+	const chunkSize = 64 * 1024
+	blob := make([]byte, 20*chunkSize+123)
+	for i := range blob {
+		blob[i] = byte(i * 31)
+	}
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
-	index := []byte(`{"manifests":[]}`)
-	indexDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageIndex,
-		Digest:    digest.FromBytes(index),
-		Size:      int64(len(index)),
-	}
-	var gotIndex []byte
-	ref := "foobar"
+	source := &countingReader{r: bytes.NewReader(blob)}
+	contentType := "application/vnd.test.blob"
+
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	var received bytes.Buffer
+	var patches, failures int
+	var maxBuffered int64
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
-		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+blobDesc.Digest.String():
-			w.WriteHeader(http.StatusNotFound)
-		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
-			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
-				t.Errorf("manifest not convertable: %s", accept)
-				w.WriteHeader(http.StatusBadRequest)
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPatch && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			// the client holds at most the current chunk not yet received
+			if buffered := source.n.Load() - int64(received.Len()); buffered > maxBuffered {
+				maxBuffered = buffered
+			}
+			if got := r.Header.Get("Content-Type"); got != contentType {
+				t.Errorf("PATCH Content-Type = %q, want %q", got, contentType)
+			}
+			chunk, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			if patches == 3 && failures == 0 {
+				// fail a chunk once to verify that it is retried
+				failures++
+				w.WriteHeader(http.StatusServiceUnavailable)
 				return
 			}
-			w.Header().Set("Content-Type", indexDesc.MediaType)
-			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
-			if _, err := w.Write(index); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
+			if len(chunk) > chunkSize {
+				t.Errorf("chunk size = %d, want <= %d", len(chunk), chunkSize)
 			}
-		case r.Method == http.MethodPut &&
-			r.URL.Path == "/v2/test/manifests/"+ref || r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
-			if contentType := r.Header.Get("Content-Type"); contentType != indexDesc.MediaType {
-				w.WriteHeader(http.StatusBadRequest)
-				break
+			wantRange := fmt.Sprintf("%d-%d", received.Len(), received.Len()+len(chunk)-1)
+			if got := r.Header.Get("Content-Range"); got != wantRange {
+				t.Errorf("Content-Range = %q, want %q", got, wantRange)
 			}
-			buf := bytes.NewBuffer(nil)
-			if _, err := buf.ReadFrom(r.Body); err != nil {
-				t.Errorf("fail to read: %v", err)
+			received.Write(chunk)
+			patches++
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			if got := r.URL.Query().Get("digest"); got != blobDesc.Digest.String() {
+				t.Errorf("unexpected digest: %v, want %v", got, blobDesc.Digest)
 			}
-			gotIndex = buf.Bytes()
-			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			if r.ContentLength != 0 {
+				t.Errorf("unexpected content length: %d, want 0", r.ContentLength)
+			}
+			if got := r.Header.Get("Content-Type"); got != contentType {
+				t.Errorf("PUT Content-Type = %q, want %q", got, contentType)
+			}
+			w.Header().Set(headerDockerContentDigest, blobDesc.Digest.String())
 			w.WriteHeader(http.StatusCreated)
-			return
 		default:
 			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 			w.WriteHeader(http.StatusForbidden)
@@ -980,55 +1146,59 @@ func TestRepository_Tag(t *testing.T) {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
+	repo.UploadChunkSize = chunkSize
+	repo.BlobUploadContentType = func(desc ocispec.Descriptor) string {
+		return contentType
+	}
 	ctx := context.Background()
 
-	err = repo.Tag(ctx, blobDesc, ref)
-	if err == nil {
-		t.Errorf("Repository.Tag() error = %v, wantErr %v", err, true)
+	// wrap the source so that it cannot be rewound
+	if err := repo.Push(ctx, blobDesc, struct{ io.Reader }{source}); err != nil {
+		t.Fatalf("Repository.Push() error = %v", err)
 	}
-
-	err = repo.Tag(ctx, indexDesc, ref)
-	if err != nil {
-		t.Fatalf("Repository.Tag() error = %v", err)
+	if !bytes.Equal(received.Bytes(), blob) {
+		t.Errorf("Repository.Push() received %d bytes, want %d bytes", received.Len(), len(blob))
 	}
-	if !bytes.Equal(gotIndex, index) {
-		t.Errorf("Repository.Tag() = %v, want %v", gotIndex, index)
+	if want := 21; patches != want {
+		t.Errorf("Repository.Push() sent %d chunks, want %d", patches, want)
 	}
-
-	gotIndex = nil
-	err = repo.Tag(ctx, indexDesc, indexDesc.Digest.String())
-	if err != nil {
-		t.Fatalf("Repository.Tag() error = %v", err)
+	if failures != 1 {
+		t.Errorf("Repository.Push() failed chunks = %d, want 1", failures)
 	}
-	if !bytes.Equal(gotIndex, index) {
-		t.Errorf("Repository.Tag() = %v, want %v", gotIndex, index)
+	if maxBuffered > chunkSize {
+		t.Errorf("Repository.Push() buffered %d bytes, want <= %d", maxBuffered, chunkSize)
 	}
 }
 
-func TestRepository_PushReference(t *testing.T) {
-	index := []byte(`{"manifests":[]}`)
-	indexDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageIndex,
-		Digest:    digest.FromBytes(index),
-		Size:      int64(len(index)),
+func TestRepository_Push_ChunkedFallback(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
 	}
-	var gotIndex []byte
-	ref := "foobar"
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	var gotBlob []byte
+	var patches int
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
-		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+ref:
-			if contentType := r.Header.Get("Content-Type"); contentType != indexDesc.MediaType {
-				w.WriteHeader(http.StatusBadRequest)
-				break
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPatch && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			patches++
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			if got := r.URL.Query().Get("digest"); got != blobDesc.Digest.String() {
+				t.Errorf("unexpected digest: %v, want %v", got, blobDesc.Digest)
 			}
 			buf := bytes.NewBuffer(nil)
 			if _, err := buf.ReadFrom(r.Body); err != nil {
 				t.Errorf("fail to read: %v", err)
 			}
-			gotIndex = buf.Bytes()
-			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			gotBlob = buf.Bytes()
+			w.Header().Set(headerDockerContentDigest, blobDesc.Digest.String())
 			w.WriteHeader(http.StatusCreated)
-			return
 		default:
 			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 			w.WriteHeader(http.StatusForbidden)
@@ -1045,193 +1215,281 @@ func TestRepository_PushReference(t *testing.T) {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
+	repo.UploadChunkSize = 4
 	ctx := context.Background()
-	err = repo.PushReference(ctx, indexDesc, bytes.NewReader(index), ref)
-	if err != nil {
-		t.Fatalf("Repository.PushReference() error = %v", err)
+
+	if err := repo.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Repository.Push() error = %v", err)
 	}
-	if !bytes.Equal(gotIndex, index) {
-		t.Errorf("Repository.PushReference() = %v, want %v", gotIndex, index)
+	if patches != 1 {
+		t.Errorf("Repository.Push() sent %d chunks, want 1", patches)
+	}
+	if !bytes.Equal(gotBlob, blob) {
+		t.Errorf("Repository.Push() = %v, want %v", gotBlob, blob)
 	}
 }
 
-func TestRepository_FetchReference(t *testing.T) {
+func TestRepository_Push_RestartUploadSession(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
 		MediaType: "test",
 		Digest:    digest.FromBytes(blob),
 		Size:      int64(len(blob)),
 	}
-	index := []byte(`{"manifests":[]}`)
-	indexDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageIndex,
-		Digest:    digest.FromBytes(index),
-		Size:      int64(len(index)),
+	sessionUnknown := `{"errors":[{"code":"BLOB_UPLOAD_UNKNOWN","message":"blob upload unknown to registry"}]}`
+
+	tests := []struct {
+		name      string
+		chunkSize int64
+		content   func() io.Reader
+		wantErr   bool
+	}{
+		{
+			name:    "monolithic upload",
+			content: func() io.Reader { return bytes.NewReader(blob) },
+		},
+		{
+			name:      "chunked upload",
+			chunkSize: 4,
+			content:   func() io.Reader { return bytes.NewReader(blob) },
+		},
+		{
+			name:    "non-seekable content",
+			content: func() io.Reader { return struct{ io.Reader }{bytes.NewReader(blob)} },
+			wantErr: true,
+		},
+		{
+			name:      "non-seekable content within chunk size",
+			chunkSize: 64,
+			content:   func() io.Reader { return struct{ io.Reader }{bytes.NewReader(blob)} },
+		},
+		{
+			name:      "non-seekable content with chunked upload",
+			chunkSize: 4,
+			content:   func() io.Reader { return struct{ io.Reader }{bytes.NewReader(blob)} },
+		},
 	}
-	ref := "foobar"
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		switch r.URL.Path {
-		case "/v2/test/manifests/" + blobDesc.Digest.String():
-			w.WriteHeader(http.StatusNotFound)
-		case "/v2/test/manifests/" + indexDesc.Digest.String(),
-			"/v2/test/manifests/" + ref:
-			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
-				t.Errorf("manifest not convertable: %s", accept)
-				w.WriteHeader(http.StatusBadRequest)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sessions int
+			var gotBlob []byte
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				uploadPath := "/v2/test/blobs/uploads/" + strconv.Itoa(sessions)
+				switch {
+				case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+					sessions++
+					gotBlob = nil
+					w.Header().Set("Location", "/v2/test/blobs/uploads/"+strconv.Itoa(sessions))
+					w.WriteHeader(http.StatusAccepted)
+				case r.URL.Path == uploadPath && sessions == 1:
+					// the first session expires on the first request
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusNotFound)
+					if _, err := w.Write([]byte(sessionUnknown)); err != nil {
+						t.Errorf("failed to write response: %v", err)
+					}
+				case r.Method == http.MethodPatch && r.URL.Path == uploadPath:
+					chunk, err := io.ReadAll(r.Body)
+					if err != nil {
+						t.Errorf("fail to read: %v", err)
+					}
+					gotBlob = append(gotBlob, chunk...)
+					w.Header().Set("Location", uploadPath)
+					w.WriteHeader(http.StatusAccepted)
+				case r.Method == http.MethodPut && r.URL.Path == uploadPath:
+					if got := r.URL.Query().Get("digest"); got != blobDesc.Digest.String() {
+						t.Errorf("unexpected digest: %v, want %v", got, blobDesc.Digest)
+					}
+					chunk, err := io.ReadAll(r.Body)
+					if err != nil {
+						t.Errorf("fail to read: %v", err)
+					}
+					gotBlob = append(gotBlob, chunk...)
+					w.Header().Set(headerDockerContentDigest, blobDesc.Digest.String())
+					w.WriteHeader(http.StatusCreated)
+				default:
+					t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusForbidden)
+				}
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			repo.UploadChunkSize = tt.chunkSize
+			ctx := context.Background()
+
+			err = repo.Push(ctx, blobDesc, tt.content())
+			if tt.wantErr {
+				if !errutil.IsBlobUploadSessionError(err) {
+					t.Fatalf("Repository.Push() error = %v, want blob upload unknown", err)
+				}
+				if sessions != 1 {
+					t.Errorf("Repository.Push() sessions = %d, want 1", sessions)
+				}
 				return
 			}
-			w.Header().Set("Content-Type", indexDesc.MediaType)
-			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
-			if _, err := w.Write(index); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
+			if err != nil {
+				t.Fatalf("Repository.Push() error = %v", err)
 			}
-		default:
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
-		}
-	}))
-	defer ts.Close()
-	uri, err := url.Parse(ts.URL)
-	if err != nil {
-		t.Fatalf("invalid test http server: %v", err)
+			if sessions != 2 {
+				t.Errorf("Repository.Push() sessions = %d, want 2", sessions)
+			}
+			if !bytes.Equal(gotBlob, blob) {
+				t.Errorf("Repository.Push() = %v, want %v", gotBlob, blob)
+			}
+		})
 	}
+}
 
-	repoName := uri.Host + "/test"
-	repo, err := NewRepository(repoName)
-	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
+func TestRepository_Push_RestartUploadSession_CopyGraph(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
 	}
-	repo.PlainHTTP = true
+	sessionUnknown := `{"errors":[{"code":"BLOB_UPLOAD_UNKNOWN","message":"blob upload unknown to registry"}]}`
+	src := memory.New()
 	ctx := context.Background()
-
-	// test with blob digest
-	_, _, err = repo.FetchReference(ctx, blobDesc.Digest.String())
-	if !errors.Is(err, errdef.ErrNotFound) {
-		t.Errorf("Repository.FetchReference() error = %v, wantErr %v", err, errdef.ErrNotFound)
-	}
-
-	// test with manifest digest
-	gotDesc, rc, err := repo.FetchReference(ctx, indexDesc.Digest.String())
-	if err != nil {
-		t.Fatalf("Repository.FetchReference() error = %v", err)
-	}
-	if !reflect.DeepEqual(gotDesc, indexDesc) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
-	}
-	buf := bytes.NewBuffer(nil)
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
-	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
-	}
-	if got := buf.Bytes(); !bytes.Equal(got, index) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
+	if err := src.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("failed to push test content to src: %v", err)
 	}
 
-	// test with manifest tag
-	gotDesc, rc, err = repo.FetchReference(ctx, ref)
-	if err != nil {
-		t.Fatalf("Repository.FetchReference() error = %v", err)
-	}
-	if !reflect.DeepEqual(gotDesc, indexDesc) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
-	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
-	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
-	}
-	if got := buf.Bytes(); !bytes.Equal(got, index) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
+	tests := []struct {
+		name      string
+		chunkSize int64
+		wantErr   bool
+	}{
+		{
+			// the content streamed by CopyGraph is not seekable
+			name:    "monolithic upload",
+			wantErr: true,
+		},
+		{
+			name:      "chunked upload",
+			chunkSize: 4,
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sessions int
+			var gotBlob []byte
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				uploadPath := "/v2/test/blobs/uploads/" + strconv.Itoa(sessions)
+				switch {
+				case r.Method == http.MethodHead && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
+					w.WriteHeader(http.StatusNotFound)
+				case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+					sessions++
+					gotBlob = nil
+					w.Header().Set("Location", "/v2/test/blobs/uploads/"+strconv.Itoa(sessions))
+					w.WriteHeader(http.StatusAccepted)
+				case r.URL.Path == uploadPath && sessions == 1:
+					// the first session expires on the first request
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusNotFound)
+					if _, err := w.Write([]byte(sessionUnknown)); err != nil {
+						t.Errorf("failed to write response: %v", err)
+					}
+				case r.Method == http.MethodPatch && r.URL.Path == uploadPath:
+					chunk, err := io.ReadAll(r.Body)
+					if err != nil {
+						t.Errorf("fail to read: %v", err)
+					}
+					gotBlob = append(gotBlob, chunk...)
+					w.Header().Set("Location", uploadPath)
+					w.WriteHeader(http.StatusAccepted)
+				case r.Method == http.MethodPut && r.URL.Path == uploadPath:
+					chunk, err := io.ReadAll(r.Body)
+					if err != nil {
+						t.Errorf("fail to read: %v", err)
+					}
+					gotBlob = append(gotBlob, chunk...)
+					w.Header().Set(headerDockerContentDigest, blobDesc.Digest.String())
+					w.WriteHeader(http.StatusCreated)
+				default:
+					t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusForbidden)
+				}
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
 
-	// test with manifest tag@digest
-	tagDigestRef := "whatever" + "@" + indexDesc.Digest.String()
-	gotDesc, rc, err = repo.FetchReference(ctx, tagDigestRef)
-	if err != nil {
-		t.Fatalf("Repository.FetchReference() error = %v", err)
-	}
-	if !reflect.DeepEqual(gotDesc, indexDesc) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
-	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
-	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
-	}
-	if got := buf.Bytes(); !bytes.Equal(got, index) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
-	}
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			repo.UploadChunkSize = tt.chunkSize
 
-	// test with manifest FQDN
-	fqdnRef := repoName + ":" + tagDigestRef
-	gotDesc, rc, err = repo.FetchReference(ctx, fqdnRef)
-	if err != nil {
-		t.Fatalf("Repository.FetchReference() error = %v", err)
-	}
-	if !reflect.DeepEqual(gotDesc, indexDesc) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
-	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
-	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
-	}
-	if got := buf.Bytes(); !bytes.Equal(got, index) {
-		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
+			err = oras.CopyGraph(ctx, src, repo, blobDesc, oras.CopyGraphOptions{})
+			if tt.wantErr {
+				if !errutil.IsBlobUploadSessionError(err) {
+					t.Fatalf("CopyGraph() error = %v, want blob upload unknown", err)
+				}
+				if sessions != 1 {
+					t.Errorf("CopyGraph() sessions = %d, want 1", sessions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CopyGraph() error = %v", err)
+			}
+			if sessions != 2 {
+				t.Errorf("CopyGraph() sessions = %d, want 2", sessions)
+			}
+			if !bytes.Equal(gotBlob, blob) {
+				t.Errorf("CopyGraph() = %v, want %v", gotBlob, blob)
+			}
+		})
 	}
 }
 
-func TestRepository_Tags(t *testing.T) {
-	tagSet := [][]string{
-		{"the", "quick", "brown", "fox"},
-		{"jumps", "over", "the", "lazy"},
-		{"dog"},
+func TestRepository_Mount(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
 	}
-	var ts *httptest.Server
-	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet || r.URL.Path != "/v2/test/tags/list" {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
+	gotMount := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Method, "POST"; got != want {
+			t.Errorf("unexpected HTTP method; got %q want %q", got, want)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		q := r.URL.Query()
-		n, err := strconv.Atoi(q.Get("n"))
-		if err != nil || n != 4 {
-			t.Errorf("bad page size: %s", q.Get("n"))
-			w.WriteHeader(http.StatusBadRequest)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid form in HTTP request: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		var tags []string
-		switch q.Get("test") {
-		case "foo":
-			tags = tagSet[1]
-			w.Header().Set("Link", fmt.Sprintf(`<%s/v2/test/tags/list?n=4&test=bar>; rel="next"`, ts.URL))
-		case "bar":
-			tags = tagSet[2]
+		switch r.URL.Path {
+		case "/v2/test2/blobs/uploads/":
+			if got, want := r.Form.Get("mount"), blobDesc.Digest; digest.Digest(got) != want {
+				t.Errorf("unexpected value for 'mount' parameter; got %q want %q", got, want)
+			}
+			if got, want := r.Form.Get("from"), "test"; got != want {
+				t.Errorf("unexpected value for 'from' parameter; got %q want %q", got, want)
+			}
+			gotMount++
+			w.Header().Set(headerDockerContentDigest, blobDesc.Digest.String())
+			w.WriteHeader(201)
+			return
 		default:
-			tags = tagSet[0]
-			w.Header().Set("Link", `</v2/test/tags/list?n=4&test=foo>; rel="next"`)
-		}
-		result := struct {
-			Tags []string `json:"tags"`
-		}{
-			Tags: tags,
-		}
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			t.Errorf("failed to write response: %v", err)
+			t.Errorf("unexpected URL for mount request %q", r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}))
 	defer ts.Close()
@@ -1239,112 +1497,151 @@ func TestRepository_Tags(t *testing.T) {
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-
-	repo, err := NewRepository(uri.Host + "/test")
+	repo, err := NewRepository(uri.Host + "/test2")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.TagListPageSize = 4
-
 	ctx := context.Background()
-	index := 0
-	if err := repo.Tags(ctx, "", func(got []string) error {
-		if index > 2 {
-			t.Fatalf("out of index bound: %d", index)
-		}
-		tags := tagSet[index]
-		index++
-		if !reflect.DeepEqual(got, tags) {
-			t.Errorf("Repository.Tags() = %v, want %v", got, tags)
-		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Tags() error = %v", err)
+
+	err = repo.Mount(ctx, blobDesc, "test", nil)
+	if err != nil {
+		t.Fatalf("Repository.Push() error = %v", err)
+	}
+	if gotMount != 1 {
+		t.Errorf("did not get expected mount request")
 	}
 }
 
-func TestRepository_Predecessors(t *testing.T) {
-	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
+func TestRepository_Mount_Fallback(t *testing.T) {
+	// This test checks the case where the server does not know
+	// about the mount query parameters, so the call falls back to
+	// the regular push flow. This test is thus very similar to TestPush,
+	// except that it doesn't push a manifest because mounts aren't
+	// documented to be supported for manifests.
+
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
 	}
-	referrerSet := [][]ocispec.Descriptor{
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         1,
-				Digest:       digest.FromString("1"),
-				ArtifactType: "application/vnd.test",
-			},
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         2,
-				Digest:       digest.FromString("2"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         3,
-				Digest:       digest.FromString("3"),
-				ArtifactType: "application/vnd.test",
-			},
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         4,
-				Digest:       digest.FromString("4"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         5,
-				Digest:       digest.FromString("5"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
+	var sequence string
+	var gotBlob []byte
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test2/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test2/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+			sequence += "post "
+			return
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+			sequence += "get "
+			return
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test2/blobs/uploads/"+uuid:
+			if got, want := r.Header.Get("Content-Type"), "application/octet-stream"; got != want {
+				t.Errorf("unexpected content type; got %q want %q", got, want)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if got, want := r.URL.Query().Get("digest"), blobDesc.Digest.String(); got != want {
+				t.Errorf("unexpected content digest; got %q want %q", got, want)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("error reading body: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			gotBlob = data
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+			sequence += "put "
+			return
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
 	}
-	var ts *httptest.Server
-	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		if r.Method != http.MethodGet || r.URL.Path != path {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
+
+	repo, err := NewRepository(uri.Host + "/test2")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	t.Run("getContent is nil", func(t *testing.T) {
+		sequence = ""
+
+		err = repo.Mount(ctx, blobDesc, "test", nil)
+		if err != nil {
+			t.Fatalf("Repository.Push() error = %v", err)
+		}
+		if !bytes.Equal(gotBlob, blob) {
+			t.Errorf("Repository.Mount() = %v, want %v", gotBlob, blob)
+		}
+		if got, want := sequence, "post get put "; got != want {
+			t.Errorf("unexpected request sequence; got %q want %q", got, want)
+		}
+	})
+
+	t.Run("getContent is non nil", func(t *testing.T) {
+		sequence = ""
+
+		err = repo.Mount(ctx, blobDesc, "test", func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(blob)), nil
+		})
+		if err != nil {
+			t.Fatalf("Repository.Push() error = %v", err)
+		}
+		if !bytes.Equal(gotBlob, blob) {
+			t.Errorf("Repository.Mount() = %v, want %v", gotBlob, blob)
+		}
+		if got, want := sequence, "post put "; got != want {
+			t.Errorf("unexpected request sequence; got %q want %q", got, want)
+		}
+	})
+}
+
+func TestRepository_Mount_Error(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Method, "POST"; got != want {
+			t.Errorf("unexpected HTTP method; got %q want %q", got, want)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		q := r.URL.Query()
-		n, err := strconv.Atoi(q.Get("n"))
-		if err != nil || n != 2 {
-			t.Errorf("bad page size: %s", q.Get("n"))
-			w.WriteHeader(http.StatusBadRequest)
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid form in HTTP request: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		var referrers []ocispec.Descriptor
-		switch q.Get("test") {
-		case "foo":
-			referrers = referrerSet[1]
-			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
-		case "bar":
-			referrers = referrerSet[2]
+		switch r.URL.Path {
+		case "/v2/test/blobs/uploads/":
+			w.WriteHeader(400)
+			w.Write([]byte(`{ "errors": [ { "code": "NAME_UNKNOWN", "message": "some error" } ] }`))
 		default:
-			referrers = referrerSet[0]
-			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
-		}
-		result := ocispec.Index{
-			Versioned: specs.Versioned{
-				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
-			},
-			MediaType: ocispec.MediaTypeImageIndex,
-			Manifests: referrers,
-		}
-		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			t.Errorf("failed to write response: %v", err)
+			t.Errorf("unexpected URL for mount request %q", r.URL)
+			w.WriteHeader(http.StatusInternalServerError)
 		}
 	}))
 	defer ts.Close()
@@ -1352,113 +1649,76 @@ func TestRepository_Predecessors(t *testing.T) {
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.ReferrerListPageSize = 2
 
-	ctx := context.Background()
-	got, err := repo.Predecessors(ctx, manifestDesc)
-	if err != nil {
-		t.Fatalf("Repository.Predecessors() error = %v", err)
+	err = repo.Mount(context.Background(), blobDesc, "foo", nil)
+	if err == nil {
+		t.Fatalf("expected error but got success instead")
 	}
-	var want []ocispec.Descriptor
-	for _, referrers := range referrerSet {
-		want = append(want, referrers...)
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) {
+		t.Fatalf("unexpected error type %#v", err)
 	}
-	if !reflect.DeepEqual(got, want) {
-		t.Errorf("Repository.Predecessors() = %v, want %v", got, want)
+	if !reflect.DeepEqual(errResp.Errors, errcode.Errors{{
+		Code:    "NAME_UNKNOWN",
+		Message: "some error",
+	}}) {
+		t.Errorf("unexpected errors %#v", errResp.Errors)
 	}
 }
 
-func TestRepository_Referrers(t *testing.T) {
-	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
+func TestRepository_Mount_Fallback_GetContent(t *testing.T) {
+	// This test checks the case where the server does not know
+	// about the mount query parameters, so the call falls back to
+	// the regular push flow, but using the getContent function
+	// parameter to get the content to push.
+
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
 	}
-	referrerSet := [][]ocispec.Descriptor{
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         1,
-				Digest:       digest.FromString("1"),
-				ArtifactType: "application/vnd.test",
-			},
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         2,
-				Digest:       digest.FromString("2"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         3,
-				Digest:       digest.FromString("3"),
-				ArtifactType: "application/vnd.test",
-			},
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         4,
-				Digest:       digest.FromString("4"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         5,
-				Digest:       digest.FromString("5"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-	}
-	var ts *httptest.Server
-	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		if r.Method != http.MethodGet || r.URL.Path != path {
-			referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
-			if r.URL.Path != "/v2/test/manifests/"+referrersTag {
-				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
-			}
-			w.WriteHeader(http.StatusNotFound)
+	var sequence string
+	var gotBlob []byte
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test2/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test2/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+			sequence += "post "
 			return
-		}
-		q := r.URL.Query()
-		n, err := strconv.Atoi(q.Get("n"))
-		if err != nil || n != 2 {
-			t.Errorf("bad page size: %s", q.Get("n"))
-			w.WriteHeader(http.StatusBadRequest)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test2/blobs/uploads/"+uuid:
+			if got, want := r.Header.Get("Content-Type"), "application/octet-stream"; got != want {
+				t.Errorf("unexpected content type; got %q want %q", got, want)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if got, want := r.URL.Query().Get("digest"), blobDesc.Digest.String(); got != want {
+				t.Errorf("unexpected content digest; got %q want %q", got, want)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			data, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("error reading body: %v", err)
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			gotBlob = data
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+			sequence += "put "
 			return
-		}
-		var referrers []ocispec.Descriptor
-		switch q.Get("test") {
-		case "foo":
-			referrers = referrerSet[1]
-			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
-		case "bar":
-			referrers = referrerSet[2]
 		default:
-			referrers = referrerSet[0]
-			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
-		}
-		result := ocispec.Index{
-			Versioned: specs.Versioned{
-				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
-			},
-			MediaType: ocispec.MediaTypeImageIndex,
-			Manifests: referrers,
-		}
-		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			t.Errorf("failed to write response: %v", err)
+			w.WriteHeader(http.StatusForbidden)
 		}
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
@@ -1466,149 +1726,224 @@ func TestRepository_Referrers(t *testing.T) {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	ctx := context.Background()
-
-	// test auto detect
-	// remote server supports Referrers, should be no error
-	repo, err := NewRepository(uri.Host + "/test")
+	repo, err := NewRepository(uri.Host + "/test2")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.ReferrerListPageSize = 2
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	ctx := context.Background()
+
+	err = repo.Mount(ctx, blobDesc, "test", func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(blob)), nil
+	})
+	if err != nil {
+		t.Fatalf("Repository.Push() error = %v", err)
 	}
-	index := 0
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		if index >= len(referrerSet) {
-			t.Fatalf("out of index bound: %d", index)
-		}
-		referrers := referrerSet[index]
-		index++
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
-		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
+	if !bytes.Equal(gotBlob, blob) {
+		t.Errorf("Repository.Mount() = %v, want %v", gotBlob, blob)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	if got, want := sequence, "post put "; got != want {
+		t.Errorf("unexpected request sequence; got %q want %q", got, want)
 	}
+}
 
-	// test force attempt Referrers
-	// remote server supports Referrers, should be no error
-	repo, err = NewRepository(uri.Host + "/test")
-	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
-	}
-	repo.PlainHTTP = true
-	repo.ReferrerListPageSize = 2
-	repo.SetReferrersCapability(true)
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+func TestRepository_Mount_Fallback_GetContentError(t *testing.T) {
+	// This test checks the case where the server does not know
+	// about the mount query parameters, so the call falls back to
+	// the regular push flow, but it's possible the caller wants to
+	// avoid the pull/push pattern so returns an error from getContent
+	// and checks it to find out that's happened.
+
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
 	}
-	index = 0
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		if index >= len(referrerSet) {
-			t.Fatalf("out of index bound: %d", index)
-		}
-		referrers := referrerSet[index]
-		index++
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+	var sequence string
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test2/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test2/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+			sequence += "post "
+			return
+		default:
+			w.WriteHeader(http.StatusForbidden)
 		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
-	}
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	// test force attempt tag schema
-	// request tag schema but got 404, should be no error
-	repo, err = NewRepository(uri.Host + "/test")
+	repo, err := NewRepository(uri.Host + "/test2")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.ReferrerListPageSize = 2
-	repo.SetReferrersCapability(false)
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	ctx := context.Background()
+
+	testErr := errors.New("test error")
+	err = repo.Mount(ctx, blobDesc, "test", func() (io.ReadCloser, error) {
+		return nil, testErr
+	})
+	if err == nil {
+		t.Fatalf("expected error but found no error")
 	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
+	if !errors.Is(err, testErr) {
+		t.Fatalf("expected getContent error to be wrapped")
 	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	if got, want := sequence, "post "; got != want {
+		t.Errorf("unexpected request sequence; got %q want %q", got, want)
 	}
 }
 
-func TestRepository_Referrers_TagSchemaFallback(t *testing.T) {
+func TestRepository_Mount_Manifest(t *testing.T) {
 	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
-	}
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifest)
+	manifestPath := "/manifests/" + manifestDesc.Digest.String()
 
-	referrers := []ocispec.Descriptor{
+	tests := []struct {
+		name         string
+		existing     bool
+		sourceExists bool
+		rejectCopy   bool
+		getContent   bool
+		wantSequence string
+		wantErr      bool
+	}{
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         1,
-			Digest:       digest.FromString("1"),
-			ArtifactType: "application/vnd.test",
+			name:         "already exists",
+			existing:     true,
+			wantSequence: "head ",
 		},
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         2,
-			Digest:       digest.FromString("2"),
-			ArtifactType: "application/vnd.test",
+			name:         "copy from the source repository",
+			sourceExists: true,
+			wantSequence: "head get-src put head ",
 		},
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         3,
-			Digest:       digest.FromString("3"),
-			ArtifactType: "application/vnd.test",
+			name:         "fall back to getContent if the source is missing",
+			getContent:   true,
+			wantSequence: "head get-src put head ",
 		},
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         4,
-			Digest:       digest.FromString("4"),
-			ArtifactType: "application/vnd.test",
+			name:         "fall back to getContent if the copy is rejected",
+			sourceExists: true,
+			rejectCopy:   true,
+			getContent:   true,
+			wantSequence: "head get-src put put head ",
 		},
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         5,
-			Digest:       digest.FromString("5"),
-			ArtifactType: "application/vnd.test",
+			name:         "no fallback without getContent",
+			wantSequence: "head get-src ",
+			wantErr:      true,
 		},
 	}
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
-		path := "/v2/test/manifests/" + referrersTag
-		if r.Method != http.MethodGet || r.URL.Path != path {
-			if r.URL.Path != "/v2/test/referrers/"+manifestDesc.Digest.String() {
-				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sequence string
+			var gotManifest []byte
+			existing := tt.existing
+			rejectCopy := tt.rejectCopy
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodHead && r.URL.Path == "/v2/test2"+manifestPath:
+					sequence += "head "
+					if !existing {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Content-Type", manifestDesc.MediaType)
+					w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+					w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+				case r.Method == http.MethodGet && r.URL.Path == "/v2/test"+manifestPath:
+					sequence += "get-src "
+					if !tt.sourceExists {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					w.Header().Set("Content-Type", manifestDesc.MediaType)
+					w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+					if _, err := w.Write(manifest); err != nil {
+						t.Errorf("failed to write %q: %v", r.URL, err)
+					}
+				case r.Method == http.MethodPut && r.URL.Path == "/v2/test2"+manifestPath:
+					sequence += "put "
+					if rejectCopy {
+						// reject the first push
+						rejectCopy = false
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					buf := bytes.NewBuffer(nil)
+					if _, err := buf.ReadFrom(r.Body); err != nil {
+						t.Errorf("fail to read: %v", err)
+					}
+					gotManifest = buf.Bytes()
+					existing = true
+					w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+					w.WriteHeader(http.StatusCreated)
+				default:
+					t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
 			}
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+			repo, err := NewRepository(uri.Host + "/test2")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			ctx := context.Background()
 
-		result := ocispec.Index{
-			Versioned: specs.Versioned{
-				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
-			},
-			MediaType: ocispec.MediaTypeImageIndex,
-			Manifests: referrers,
-		}
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			t.Errorf("failed to write response: %v", err)
+			var getContent func() (io.ReadCloser, error)
+			if tt.getContent {
+				getContent = func() (io.ReadCloser, error) {
+					return io.NopCloser(bytes.NewReader(manifest)), nil
+				}
+			}
+			err = repo.Mount(ctx, manifestDesc, "test", getContent)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Repository.Mount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := sequence; got != tt.wantSequence {
+				t.Errorf("unexpected request sequence; got %q want %q", got, tt.wantSequence)
+			}
+			if !tt.wantErr && !tt.existing && !bytes.Equal(gotManifest, manifest) {
+				t.Errorf("Repository.Mount() pushed %q, want %q", gotManifest, manifest)
+			}
+		})
+	}
+}
+
+func TestRepository_Mount_Manifest_VerifyFailure(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifest)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+			// the manifest never shows up
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+manifestDesc.Digest.String():
+			w.Header().Set("Content-Type", manifestDesc.MediaType)
+			if _, err := w.Write(manifest); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test2/manifests/"+manifestDesc.Digest.String():
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
 		}
 	}))
 	defer ts.Close()
@@ -1616,461 +1951,362 @@ func TestRepository_Referrers_TagSchemaFallback(t *testing.T) {
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-	ctx := context.Background()
-
-	// test auto detect
-	// remote server does not support Referrers, should fallback to tag schema
-	repo, err := NewRepository(uri.Host + "/test")
-	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
-	}
-	repo.PlainHTTP = true
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
-		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
-	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
-	}
-
-	// test force attempt Referrers
-	// remote server does not support Referrers, should return error
-	repo, err = NewRepository(uri.Host + "/test")
+	repo, err := NewRepository(uri.Host + "/test2")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.SetReferrersCapability(true)
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err == nil {
-		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, true)
-	}
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
-	}
 
-	// test force attempt tag schema
-	// should request tag schema
-	repo, err = NewRepository(uri.Host + "/test")
-	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
-	}
-	repo.PlainHTTP = true
-	repo.SetReferrersCapability(false)
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
-		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
-	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	err = repo.Mount(context.Background(), manifestDesc, "test", nil)
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Repository.Mount() error = %v, want %v", err, errdef.ErrNotFound)
 	}
 }
 
-func TestRepository_Referrers_TagSchemaFallback_NotFound(t *testing.T) {
-	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
+func TestRepository_Exists(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
 	}
-
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
-		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
-		if r.Method == http.MethodGet ||
-			r.URL.Path == referrersUrl ||
-			r.URL.Path == tagSchemaUrl {
-			w.WriteHeader(http.StatusNotFound)
+		if r.Method != http.MethodHead {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
-		w.WriteHeader(http.StatusNotFound)
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(int(blobDesc.Size)))
+		case "/v2/test/manifests/" + indexDesc.Digest.String():
+			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
+				t.Errorf("manifest not convertable: %s", accept)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", indexDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(int(indexDesc.Size)))
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-	ctx := context.Background()
 
-	// test auto detect
-	// tag schema referrers not found, should be no error
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
-	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
-	}
+	ctx := context.Background()
 
-	// test force attempt tag schema
-	// tag schema referrers not found, should be no error
-	repo, err = NewRepository(uri.Host + "/test")
+	exists, err := repo.Exists(ctx, blobDesc)
 	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
+		t.Fatalf("Repository.Exists() error = %v", err)
 	}
-	repo.PlainHTTP = true
-	repo.SetReferrersCapability(false)
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	if !exists {
+		t.Errorf("Repository.Exists() = %v, want %v", exists, true)
 	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
+
+	exists, err = repo.Exists(ctx, indexDesc)
+	if err != nil {
+		t.Fatalf("Repository.Exists() error = %v", err)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	if !exists {
+		t.Errorf("Repository.Exists() = %v, want %v", exists, true)
 	}
 }
 
-func TestRepository_Referrers_TagSchemaFallback_ContentType(t *testing.T) {
-	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
+func TestRepository_Delete(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
 	}
 
+	var blobDeleted bool
+	var indexDeleted bool
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
-		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
-		if r.URL.Path == referrersUrl {
-			w.Header().Set("Content-Type", "application/json") // not an OCI image index
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		if r.Method == http.MethodGet ||
-			r.URL.Path == tagSchemaUrl {
+		switch {
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
+			blobDeleted = true
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
+			indexDeleted = true
+			// no "Docker-Content-Digest" header for manifest deletion
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
+			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
+				t.Errorf("manifest not convertable: %s", accept)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", indexDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			if _, err := w.Write(index); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 			w.WriteHeader(http.StatusNotFound)
-			return
 		}
-		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
-		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-	ctx := context.Background()
 
-	// test auto detect
-	// tag schema referrers not found, should be no error
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	ctx := context.Background()
+
+	err = repo.Delete(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Repository.Delete() error = %v", err)
 	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
+	if !blobDeleted {
+		t.Errorf("Repository.Delete() = %v, want %v", blobDeleted, true)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+
+	err = repo.Delete(ctx, indexDesc)
+	if err != nil {
+		t.Fatalf("Repository.Delete() error = %v", err)
+	}
+	if !indexDeleted {
+		t.Errorf("Repository.Delete() = %v, want %v", indexDeleted, true)
 	}
 }
 
-func TestRepository_Referrers_BadRequest(t *testing.T) {
-	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
+func TestRepository_Resolve(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
 	}
+	ref := "foobar"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
-		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
-		if r.Method == http.MethodGet ||
-			r.URL.Path == referrersUrl ||
-			r.URL.Path == tagSchemaUrl {
-			w.WriteHeader(http.StatusBadRequest)
+		if r.Method != http.MethodHead {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
-		w.WriteHeader(http.StatusNotFound)
+		switch r.URL.Path {
+		case "/v2/test/manifests/" + blobDesc.Digest.String():
+			w.WriteHeader(http.StatusNotFound)
+		case "/v2/test/manifests/" + indexDesc.Digest.String(),
+			"/v2/test/manifests/" + ref:
+			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
+				t.Errorf("manifest not convertable: %s", accept)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", indexDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(int(indexDesc.Size)))
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-	ctx := context.Background()
 
-	// test auto detect
-	// Referrers returns error
-	repo, err := NewRepository(uri.Host + "/test")
+	repoName := uri.Host + "/test"
+	repo, err := NewRepository(repoName)
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err == nil {
-		t.Errorf("Repository.Referrers() error = nil, wantErr %v", true)
-	}
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	ctx := context.Background()
+
+	_, err = repo.Resolve(ctx, blobDesc.Digest.String())
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Repository.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
 	}
 
-	// test force attempt Referrers
-	// Referrers returns error
-	repo, err = NewRepository(uri.Host + "/test")
+	got, err := repo.Resolve(ctx, indexDesc.Digest.String())
 	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
+		t.Fatalf("Repository.Resolve() error = %v", err)
 	}
-	repo.PlainHTTP = true
-	repo.SetReferrersCapability(true)
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	if !reflect.DeepEqual(got, indexDesc) {
+		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
 	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err == nil {
-		t.Errorf("Repository.Referrers() error = nil, wantErr %v", true)
+
+	got, err = repo.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatalf("Repository.Resolve() error = %v", err)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	if !reflect.DeepEqual(got, indexDesc) {
+		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
 	}
 
-	// test force attempt tag schema
-	// Referrers returns error
-	repo, err = NewRepository(uri.Host + "/test")
+	tagDigestRef := "whatever" + "@" + indexDesc.Digest.String()
+	got, err = repo.Resolve(ctx, tagDigestRef)
 	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
+		t.Fatalf("Repository.Resolve() error = %v", err)
 	}
-	repo.PlainHTTP = true
-	repo.SetReferrersCapability(false)
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	if !reflect.DeepEqual(got, indexDesc) {
+		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
 	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err == nil {
-		t.Errorf("Repository.Referrers() error = nil, wantErr %v", true)
+
+	fqdnRef := repoName + ":" + tagDigestRef
+	got, err = repo.Resolve(ctx, fqdnRef)
+	if err != nil {
+		t.Fatalf("Repository.Resolve() error = %v", err)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	if !reflect.DeepEqual(got, indexDesc) {
+		t.Errorf("Repository.Resolve() = %v, want %v", got, indexDesc)
 	}
 }
 
-func TestRepository_Referrers_RepositoryNotFound(t *testing.T) {
-	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
+func TestRepository_Tag(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
 	}
+	var gotIndex []byte
+	ref := "foobar"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
-		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
-		if r.Method == http.MethodGet &&
-			(r.URL.Path == referrersUrl || r.URL.Path == tagSchemaUrl) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+blobDesc.Digest.String():
 			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte(`{ "errors": [ { "code": "NAME_UNKNOWN", "message": "repository name not known to registry" } ] }`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
+			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
+				t.Errorf("manifest not convertable: %s", accept)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", indexDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			if _, err := w.Write(index); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		case r.Method == http.MethodPut &&
+			r.URL.Path == "/v2/test/manifests/"+ref || r.URL.Path == "/v2/test/manifests/"+indexDesc.Digest.String():
+			if contentType := r.Header.Get("Content-Type"); contentType != indexDesc.MediaType {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotIndex = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
 			return
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusForbidden)
 		}
-		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
-		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
-	ctx := context.Background()
 
-	// test auto detect
-	// repository not found, should return error
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err == nil {
-		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, true)
-	}
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	ctx := context.Background()
+
+	err = repo.Tag(ctx, blobDesc, ref)
+	if err == nil {
+		t.Errorf("Repository.Tag() error = %v, wantErr %v", err, true)
 	}
 
-	// test force attempt Referrers
-	// repository not found, should return error
-	repo, err = NewRepository(uri.Host + "/test")
+	err = repo.Tag(ctx, indexDesc, ref)
 	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
-	}
-	repo.PlainHTTP = true
-	repo.SetReferrersCapability(true)
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err == nil {
-		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, true)
+		t.Fatalf("Repository.Tag() error = %v", err)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	if !bytes.Equal(gotIndex, index) {
+		t.Errorf("Repository.Tag() = %v, want %v", gotIndex, index)
 	}
 
-	// test force attempt tag schema
-	// repository not found, but should not return error
-	repo, err = NewRepository(uri.Host + "/test")
+	gotIndex = nil
+	err = repo.Tag(ctx, indexDesc, indexDesc.Digest.String())
 	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
-	}
-	repo.PlainHTTP = true
-	repo.SetReferrersCapability(false)
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
-	}
-	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, nil)
+		t.Fatalf("Repository.Tag() error = %v", err)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	if !bytes.Equal(gotIndex, index) {
+		t.Errorf("Repository.Tag() = %v, want %v", gotIndex, index)
 	}
 }
 
-func TestRepository_Referrers_ServerFiltering(t *testing.T) {
-	manifest := []byte(`{"layers":[]}`)
-	manifestDesc := ocispec.Descriptor{
-		MediaType: ocispec.MediaTypeImageManifest,
-		Digest:    digest.FromBytes(manifest),
-		Size:      int64(len(manifest)),
-	}
-	referrerSet := [][]ocispec.Descriptor{
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         1,
-				Digest:       digest.FromString("1"),
-				ArtifactType: "application/vnd.test",
-			},
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         2,
-				Digest:       digest.FromString("2"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         3,
-				Digest:       digest.FromString("3"),
-				ArtifactType: "application/vnd.test",
-			},
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         4,
-				Digest:       digest.FromString("4"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         5,
-				Digest:       digest.FromString("5"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
+func TestRepository_PushReference(t *testing.T) {
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
 	}
-
-	// Test with filter annotations only
-	var ts *httptest.Server
-	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		queryParams, err := url.ParseQuery(r.URL.RawQuery)
-		if err != nil {
-			t.Fatal("failed to parse url query")
-		}
-		if r.Method != http.MethodGet ||
-			r.URL.Path != path ||
-			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
-			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-		q := r.URL.Query()
-		n, err := strconv.Atoi(q.Get("n"))
-		if err != nil || n != 2 {
-			t.Errorf("bad page size: %s", q.Get("n"))
-			w.WriteHeader(http.StatusBadRequest)
+	var gotIndex []byte
+	ref := "foobar"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+ref:
+			if contentType := r.Header.Get("Content-Type"); contentType != indexDesc.MediaType {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotIndex = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
 			return
-		}
-		var referrers []ocispec.Descriptor
-		switch q.Get("test") {
-		case "foo":
-			referrers = referrerSet[1]
-			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
-		case "bar":
-			referrers = referrerSet[2]
 		default:
-			referrers = referrerSet[0]
-			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
-		}
-		result := ocispec.Index{
-			Versioned: specs.Versioned{
-				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
-			},
-			MediaType: ocispec.MediaTypeImageIndex,
-			Manifests: referrers,
-			// set filter annotations
-			Annotations: map[string]string{
-				spec.AnnotationReferrersFiltersApplied: "artifactType",
-			},
-		}
-		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			t.Errorf("failed to write response: %v", err)
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusForbidden)
 		}
 	}))
 	defer ts.Close()
@@ -2084,189 +2320,226 @@ func TestRepository_Referrers_ServerFiltering(t *testing.T) {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.ReferrerListPageSize = 2
-
 	ctx := context.Background()
-	index := 0
-	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
-		if index >= len(referrerSet) {
-			t.Fatalf("out of index bound: %d", index)
-		}
-		referrers := referrerSet[index]
-		index++
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
-		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
+	err = repo.PushReference(ctx, indexDesc, bytes.NewReader(index), ref)
+	if err != nil {
+		t.Fatalf("Repository.PushReference() error = %v", err)
 	}
-	if index != len(referrerSet) {
-		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+	if !bytes.Equal(gotIndex, index) {
+		t.Errorf("Repository.PushReference() = %v, want %v", gotIndex, index)
 	}
+}
 
-	// Test with filter header only
-	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		queryParams, err := url.ParseQuery(r.URL.RawQuery)
-		if err != nil {
-			t.Fatal("failed to parse url query")
-		}
-		if r.Method != http.MethodGet ||
-			r.URL.Path != path ||
-			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
-			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
-		q := r.URL.Query()
-		n, err := strconv.Atoi(q.Get("n"))
-		if err != nil || n != 2 {
-			t.Errorf("bad page size: %s", q.Get("n"))
-			w.WriteHeader(http.StatusBadRequest)
+func TestRepository_FetchReference(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	index := []byte(`{"manifests":[]}`)
+	indexDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(index),
+		Size:      int64(len(index)),
+	}
+	ref := "foobar"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
 			return
 		}
-		var referrers []ocispec.Descriptor
-		switch q.Get("test") {
-		case "foo":
-			referrers = referrerSet[1]
-			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
-		case "bar":
-			referrers = referrerSet[2]
+		switch r.URL.Path {
+		case "/v2/test/manifests/" + blobDesc.Digest.String():
+			w.WriteHeader(http.StatusNotFound)
+		case "/v2/test/manifests/" + indexDesc.Digest.String(),
+			"/v2/test/manifests/" + ref:
+			if accept := r.Header.Get("Accept"); !strings.Contains(accept, indexDesc.MediaType) {
+				t.Errorf("manifest not convertable: %s", accept)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", indexDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", indexDesc.Digest.String())
+			if _, err := w.Write(index); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
 		default:
-			referrers = referrerSet[0]
-			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
-		}
-		result := ocispec.Index{
-			Versioned: specs.Versioned{
-				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
-			},
-			MediaType: ocispec.MediaTypeImageIndex,
-			Manifests: referrers,
-		}
-		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
-		// set filter header
-		w.Header().Set("OCI-Filters-Applied", "artifactType")
-		if err := json.NewEncoder(w).Encode(result); err != nil {
-			t.Errorf("failed to write response: %v", err)
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
 		}
 	}))
 	defer ts.Close()
-	uri, err = url.Parse(ts.URL)
+	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	repo, err = NewRepository(uri.Host + "/test")
+	repoName := uri.Host + "/test"
+	repo, err := NewRepository(repoName)
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.ReferrerListPageSize = 2
+	ctx := context.Background()
 
-	ctx = context.Background()
-	index = 0
-	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
-		if index >= len(referrerSet) {
-			t.Fatalf("out of index bound: %d", index)
-		}
-		referrers := referrerSet[index]
-		index++
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
-		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
+	// test with blob digest
+	_, _, err = repo.FetchReference(ctx, blobDesc.Digest.String())
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Repository.FetchReference() error = %v, wantErr %v", err, errdef.ErrNotFound)
 	}
-	if index != len(referrerSet) {
-		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+
+	// test with manifest digest
+	gotDesc, rc, err := repo.FetchReference(ctx, indexDesc.Digest.String())
+	if err != nil {
+		t.Fatalf("Repository.FetchReference() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotDesc, indexDesc) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, index) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
 	}
 
-	// Test with both filter annotation and filter header
-	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		queryParams, err := url.ParseQuery(r.URL.RawQuery)
-		if err != nil {
-			t.Fatal("failed to parse url query")
-		}
-		if r.Method != http.MethodGet ||
-			r.URL.Path != path ||
-			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
-			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+	// test with manifest tag
+	gotDesc, rc, err = repo.FetchReference(ctx, ref)
+	if err != nil {
+		t.Fatalf("Repository.FetchReference() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotDesc, indexDesc) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, index) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
+	}
+
+	// test with manifest tag@digest
+	tagDigestRef := "whatever" + "@" + indexDesc.Digest.String()
+	gotDesc, rc, err = repo.FetchReference(ctx, tagDigestRef)
+	if err != nil {
+		t.Fatalf("Repository.FetchReference() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotDesc, indexDesc) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, index) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
+	}
+
+	// test with manifest FQDN
+	fqdnRef := repoName + ":" + tagDigestRef
+	gotDesc, rc, err = repo.FetchReference(ctx, fqdnRef)
+	if err != nil {
+		t.Fatalf("Repository.FetchReference() error = %v", err)
+	}
+	if !reflect.DeepEqual(gotDesc, indexDesc) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", gotDesc, indexDesc)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, index) {
+		t.Errorf("Repository.FetchReference() = %v, want %v", got, index)
+	}
+}
+
+func TestRepository_Tags(t *testing.T) {
+	tagSet := [][]string{
+		{"the", "quick", "brown", "fox"},
+		{"jumps", "over", "the", "lazy"},
+		{"dog"},
+	}
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/test/tags/list" {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
 		q := r.URL.Query()
 		n, err := strconv.Atoi(q.Get("n"))
-		if err != nil || n != 2 {
+		if err != nil || n != 4 {
 			t.Errorf("bad page size: %s", q.Get("n"))
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		var referrers []ocispec.Descriptor
+		var tags []string
 		switch q.Get("test") {
 		case "foo":
-			referrers = referrerSet[1]
-			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
+			tags = tagSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v2/test/tags/list?n=4&test=bar>; rel="next"`, ts.URL))
 		case "bar":
-			referrers = referrerSet[2]
+			tags = tagSet[2]
 		default:
-			referrers = referrerSet[0]
-			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
+			tags = tagSet[0]
+			w.Header().Set("Link", `</v2/test/tags/list?n=4&test=foo>; rel="next"`)
 		}
-		result := ocispec.Index{
-			Versioned: specs.Versioned{
-				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
-			},
-			MediaType: ocispec.MediaTypeImageIndex,
-			Manifests: referrers,
-			// set filter annotation
-			Annotations: map[string]string{
-				spec.AnnotationReferrersFiltersApplied: "artifactType",
-			},
+		result := struct {
+			Tags []string `json:"tags"`
+		}{
+			Tags: tags,
 		}
-		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
-		// set filter header
-		w.Header().Set("OCI-Filters-Applied", "artifactType")
 		if err := json.NewEncoder(w).Encode(result); err != nil {
 			t.Errorf("failed to write response: %v", err)
 		}
 	}))
 	defer ts.Close()
-	uri, err = url.Parse(ts.URL)
+	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	repo, err = NewRepository(uri.Host + "/test")
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	repo.ReferrerListPageSize = 2
+	repo.TagListPageSize = 4
 
-	ctx = context.Background()
-	index = 0
-	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
-		if index >= len(referrerSet) {
+	ctx := context.Background()
+	index := 0
+	if err := repo.Tags(ctx, "", func(got []string) error {
+		if index > 2 {
 			t.Fatalf("out of index bound: %d", index)
 		}
-		referrers := referrerSet[index]
+		tags := tagSet[index]
 		index++
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+		if !reflect.DeepEqual(got, tags) {
+			t.Errorf("Repository.Tags() = %v, want %v", got, tags)
 		}
 		return nil
 	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
-	}
-	if index != len(referrerSet) {
-		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+		t.Errorf("Repository.Tags() error = %v", err)
 	}
 }
 
-func TestRepository_Referrers_ClientFiltering(t *testing.T) {
+func TestRepository_Predecessors(t *testing.T) {
 	manifest := []byte(`{"layers":[]}`)
 	manifestDesc := ocispec.Descriptor{
 		MediaType: ocispec.MediaTypeImageManifest,
@@ -2285,7 +2558,7 @@ func TestRepository_Referrers_ClientFiltering(t *testing.T) {
 				MediaType:    spec.MediaTypeArtifactManifest,
 				Size:         2,
 				Digest:       digest.FromString("2"),
-				ArtifactType: "application/vnd.foo",
+				ArtifactType: "application/vnd.test",
 			},
 		},
 		{
@@ -2299,7 +2572,7 @@ func TestRepository_Referrers_ClientFiltering(t *testing.T) {
 				MediaType:    spec.MediaTypeArtifactManifest,
 				Size:         4,
 				Digest:       digest.FromString("4"),
-				ArtifactType: "application/vnd.bar",
+				ArtifactType: "application/vnd.test",
 			},
 		},
 		{
@@ -2307,24 +2580,6 @@ func TestRepository_Referrers_ClientFiltering(t *testing.T) {
 				MediaType:    spec.MediaTypeArtifactManifest,
 				Size:         5,
 				Digest:       digest.FromString("5"),
-				ArtifactType: "application/vnd.baz",
-			},
-		},
-	}
-	filteredReferrerSet := [][]ocispec.Descriptor{
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         1,
-				Digest:       digest.FromString("1"),
-				ArtifactType: "application/vnd.test",
-			},
-		},
-		{
-			{
-				MediaType:    spec.MediaTypeArtifactManifest,
-				Size:         3,
-				Digest:       digest.FromString("3"),
 				ArtifactType: "application/vnd.test",
 			},
 		},
@@ -2332,14 +2587,8 @@ func TestRepository_Referrers_ClientFiltering(t *testing.T) {
 	var ts *httptest.Server
 	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
-		queryParams, err := url.ParseQuery(r.URL.RawQuery)
-		if err != nil {
-			t.Fatal("failed to parse url query")
-		}
-		if r.Method != http.MethodGet ||
-			r.URL.Path != path ||
-			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
-			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
@@ -2387,91 +2636,93 @@ func TestRepository_Referrers_ClientFiltering(t *testing.T) {
 	repo.ReferrerListPageSize = 2
 
 	ctx := context.Background()
-	index := 0
-	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
-		if index >= len(filteredReferrerSet) {
-			t.Fatalf("out of index bound: %d", index)
-		}
-		referrers := filteredReferrerSet[index]
-		index++
-		if !reflect.DeepEqual(got, referrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
-		}
-		return nil
-	}); err != nil {
-		t.Errorf("Repository.Referrers() error = %v", err)
+	got, err := repo.Predecessors(ctx, manifestDesc)
+	if err != nil {
+		t.Fatalf("Repository.Predecessors() error = %v", err)
 	}
-	if index != len(filteredReferrerSet) {
-		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+	var want []ocispec.Descriptor
+	for _, referrers := range referrerSet {
+		want = append(want, referrers...)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.Predecessors() = %v, want %v", got, want)
 	}
 }
 
-func TestRepository_Referrers_TagSchemaFallback_ClientFiltering(t *testing.T) {
+func TestRepository_Referrers(t *testing.T) {
 	manifest := []byte(`{"layers":[]}`)
 	manifestDesc := ocispec.Descriptor{
 		MediaType: ocispec.MediaTypeImageManifest,
 		Digest:    digest.FromBytes(manifest),
 		Size:      int64(len(manifest)),
 	}
-
-	referrers := []ocispec.Descriptor{
-		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         1,
-			Digest:       digest.FromString("1"),
-			ArtifactType: "application/vnd.test",
-		},
-		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         2,
-			Digest:       digest.FromString("2"),
-			ArtifactType: "application/vnd.foo",
-		},
+	referrerSet := [][]ocispec.Descriptor{
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         3,
-			Digest:       digest.FromString("3"),
-			ArtifactType: "application/vnd.test",
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         1,
+				Digest:       digest.FromString("1"),
+				ArtifactType: "application/vnd.test",
+			},
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         2,
+				Digest:       digest.FromString("2"),
+				ArtifactType: "application/vnd.test",
+			},
 		},
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         4,
-			Digest:       digest.FromString("4"),
-			ArtifactType: "application/vnd.bar",
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         3,
+				Digest:       digest.FromString("3"),
+				ArtifactType: "application/vnd.test",
+			},
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         4,
+				Digest:       digest.FromString("4"),
+				ArtifactType: "application/vnd.test",
+			},
 		},
 		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         5,
-			Digest:       digest.FromString("5"),
-			ArtifactType: "application/vnd.baz",
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         5,
+				Digest:       digest.FromString("5"),
+				ArtifactType: "application/vnd.test",
+			},
 		},
 	}
-	filteredReferrers := []ocispec.Descriptor{
-		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         1,
-			Digest:       digest.FromString("1"),
-			ArtifactType: "application/vnd.test",
-		},
-		{
-			MediaType:    spec.MediaTypeArtifactManifest,
-			Size:         3,
-			Digest:       digest.FromString("3"),
-			ArtifactType: "application/vnd.test",
-		},
-	}
-
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
-		path := "/v2/test/manifests/" + referrersTag
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
 		if r.Method != http.MethodGet || r.URL.Path != path {
-			if r.URL.Path != "/v2/test/referrers/"+manifestDesc.Digest.String() {
+			referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+			if r.URL.Path != "/v2/test/manifests/"+referrersTag {
 				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
 			}
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
-
+		q := r.URL.Query()
+		n, err := strconv.Atoi(q.Get("n"))
+		if err != nil || n != 2 {
+			t.Errorf("bad page size: %s", q.Get("n"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var referrers []ocispec.Descriptor
+		switch q.Get("test") {
+		case "foo":
+			referrers = referrerSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
+		case "bar":
+			referrers = referrerSet[2]
+		default:
+			referrers = referrerSet[0]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
+		}
 		result := ocispec.Index{
 			Versioned: specs.Versioned{
 				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
@@ -2479,6 +2730,7 @@ func TestRepository_Referrers_TagSchemaFallback_ClientFiltering(t *testing.T) {
 			MediaType: ocispec.MediaTypeImageIndex,
 			Manifests: referrers,
 		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
 		if err := json.NewEncoder(w).Encode(result); err != nil {
 			t.Errorf("failed to write response: %v", err)
 		}
@@ -2489,232 +2741,372 @@ func TestRepository_Referrers_TagSchemaFallback_ClientFiltering(t *testing.T) {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
+	ctx := context.Background()
+
+	// test auto detect
+	// remote server supports Referrers, should be no error
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-
-	ctx := context.Background()
-	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
-		if !reflect.DeepEqual(got, filteredReferrers) {
-			t.Errorf("Repository.Referrers() = %v, want %v", got, filteredReferrers)
+	repo.ReferrerListPageSize = 2
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	}
+	index := 0
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		if index >= len(referrerSet) {
+			t.Fatalf("out of index bound: %d", index)
+		}
+		referrers := referrerSet[index]
+		index++
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
 		}
 		return nil
 	}); err != nil {
 		t.Errorf("Repository.Referrers() error = %v", err)
 	}
-}
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	}
 
-func Test_BlobStore_Fetch(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+	// test force attempt Referrers
+	// remote server supports Referrers, should be no error
+	repo, err = NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
 	}
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
+	repo.PlainHTTP = true
+	repo.ReferrerListPageSize = 2
+	repo.SetReferrersCapability(true)
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	}
+	index = 0
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		if index >= len(referrerSet) {
+			t.Fatalf("out of index bound: %d", index)
 		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			if _, err := w.Write(blob); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
-			}
-		default:
-			w.WriteHeader(http.StatusNotFound)
+		referrers := referrerSet[index]
+		index++
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
 		}
-	}))
-	defer ts.Close()
-	uri, err := url.Parse(ts.URL)
-	if err != nil {
-		t.Fatalf("invalid test http server: %v", err)
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
 	}
 
-	repo, err := NewRepository(uri.Host + "/test")
+	// test force attempt tag schema
+	// request tag schema but got 404, should be no error
+	repo, err = NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	rc, err := store.Fetch(ctx, blobDesc)
-	if err != nil {
-		t.Fatalf("Blobs.Fetch() error = %v", err)
-	}
-	buf := bytes.NewBuffer(nil)
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
+	repo.ReferrerListPageSize = 2
+	repo.SetReferrersCapability(false)
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
 	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
+}
 
-	content := []byte("foobar")
-	contentDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(content),
-		Size:      int64(len(content)),
+func TestRepository_Referrers_OnReferrersMethod(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
-	_, err = store.Fetch(ctx, contentDesc)
-	if !errors.Is(err, errdef.ErrNotFound) {
-		t.Errorf("Blobs.Fetch() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
 	}
-}
+	index := ocispec.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: referrers,
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("failed to marshal index: %v", err)
+	}
+	referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
 
-func Test_BlobStore_Fetch_Seek(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+	tests := []struct {
+		name      string
+		supported bool
+		want      ReferrersMethod
+	}{
+		{
+			name:      "referrers API supported",
+			supported: true,
+			want:      ReferrersMethodAPI,
+		},
+		{
+			name:      "referrers API unsupported",
+			supported: false,
+			want:      ReferrersMethodTagSchema,
+		},
 	}
-	seekable := false
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			if seekable {
-				w.Header().Set("Accept-Ranges", "bytes")
-			}
-			rangeHeader := r.Header.Get("Range")
-			if !seekable || rangeHeader == "" {
-				w.WriteHeader(http.StatusOK)
-				if _, err := w.Write(blob); err != nil {
-					t.Errorf("failed to write %q: %v", r.URL, err)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+manifestDesc.Digest.String():
+					if !tt.supported {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+				case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+					if tt.supported {
+						t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+					}
+				default:
+					t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+					w.WriteHeader(http.StatusNotFound)
+					return
 				}
-				return
-			}
-			var start, end int
-			_, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end)
+				w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+				if _, err := w.Write(indexJSON); err != nil {
+					t.Errorf("failed to write response: %v", err)
+				}
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
 			if err != nil {
-				t.Errorf("invalid range header: %s", rangeHeader)
-				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
-				return
+				t.Fatalf("invalid test http server: %v", err)
 			}
-			if start < 0 || start > end || start >= int(blobDesc.Size) {
-				t.Errorf("invalid range: %s", rangeHeader)
-				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
-				return
+
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
 			}
-			end++
-			if end > int(blobDesc.Size) {
-				end = int(blobDesc.Size)
+			repo.PlainHTTP = true
+			var got []ReferrersMethod
+			repo.OnReferrersMethod = func(subject ocispec.Descriptor, method ReferrersMethod) {
+				if !content.Equal(subject, manifestDesc) {
+					t.Errorf("OnReferrersMethod() subject = %v, want %v", subject, manifestDesc)
+				}
+				got = append(got, method)
+			}
+
+			// the first call determines the referrers capability, and the
+			// second call uses the known capability
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+					if !reflect.DeepEqual(got, referrers) {
+						t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+					}
+					return nil
+				}); err != nil {
+					t.Fatalf("Repository.Referrers() error = %v", err)
+				}
 			}
-			w.WriteHeader(http.StatusPartialContent)
-			if _, err := w.Write(blob[start:end]); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
+			want := []ReferrersMethod{tt.want, tt.want}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("reported methods = %v, want %v", got, want)
 			}
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
-	}))
-	defer ts.Close()
-	uri, err := url.Parse(ts.URL)
-	if err != nil {
-		t.Fatalf("invalid test http server: %v", err)
+		})
 	}
+}
 
-	repo, err := NewRepository(uri.Host + "/test")
-	if err != nil {
-		t.Fatalf("NewRepository() error = %v", err)
+func TestRepository_Referrers_WarnTruncation(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
-	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	rc, err := store.Fetch(ctx, blobDesc)
-	if err != nil {
-		t.Fatalf("Blobs.Fetch() error = %v", err)
-	}
-	if _, ok := rc.(io.Seeker); ok {
-		t.Errorf("Blobs.Fetch() returns io.Seeker on non-seekable content")
-	}
-	buf := bytes.NewBuffer(nil)
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
-	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
-	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	var referrers []ocispec.Descriptor
+	for i := 0; i < 3; i++ {
+		referrers = append(referrers, ocispec.Descriptor{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         int64(i),
+			Digest:       digest.FromString(strconv.Itoa(i)),
+			ArtifactType: "application/vnd.test",
+		})
 	}
 
-	seekable = true
-	rc, err = store.Fetch(ctx, blobDesc)
+	// pages are served without links unless linked is set
+	var pages [][]ocispec.Descriptor
+	var linked bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			page = 0
+		}
+		if linked && page+1 < len(pages) {
+			w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, path, page+1))
+		}
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: pages[page],
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
 	if err != nil {
-		t.Fatalf("Blobs.Fetch() error = %v", err)
-	}
-	s, ok := rc.(io.Seeker)
-	if !ok {
-		t.Fatalf("Blobs.Fetch() = %v, want io.Seeker", rc)
-	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
-	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	_, err = s.Seek(3, io.SeekStart)
-	if err != nil {
-		t.Errorf("fail to seek: %v", err)
-	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
+	tests := []struct {
+		name         string
+		pageSize     int
+		pages        [][]ocispec.Descriptor
+		linked       bool
+		wantWarnings int
+	}{
+		{
+			name:         "full page without link",
+			pageSize:     2,
+			pages:        [][]ocispec.Descriptor{referrers[:2]},
+			wantWarnings: 1,
+		},
+		{
+			name:     "partial page without link",
+			pageSize: 2,
+			pages:    [][]ocispec.Descriptor{referrers[:1]},
+		},
+		{
+			name:     "full pages with links",
+			pageSize: 2,
+			pages:    [][]ocispec.Descriptor{referrers[:2], referrers[2:]},
+			linked:   true,
+		},
+		{
+			name:  "page size determined by the registry",
+			pages: [][]ocispec.Descriptor{referrers[:2]},
+		},
 	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob[3:]) {
-		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob[3:])
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages = tt.pages
+			linked = tt.linked
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			repo.ReferrerListPageSize = tt.pageSize
+			var warnings int
+			repo.ReferrerListWarnTruncation = func(subject ocispec.Descriptor) {
+				if !reflect.DeepEqual(subject, manifestDesc) {
+					t.Errorf("ReferrerListWarnTruncation() subject = %v, want %v", subject, manifestDesc)
+				}
+				warnings++
+			}
+			ctx := context.Background()
+			var got []ocispec.Descriptor
+			if err := repo.Referrers(ctx, manifestDesc, "", func(refs []ocispec.Descriptor) error {
+				got = append(got, refs...)
+				return nil
+			}); err != nil {
+				t.Fatalf("Repository.Referrers() error = %v", err)
+			}
+			if want := slices.Concat(tt.pages...); !reflect.DeepEqual(got, want) {
+				t.Errorf("Repository.Referrers() = %v, want %v", got, want)
+			}
+			if warnings != tt.wantWarnings {
+				t.Errorf("ReferrerListWarnTruncation() calls = %d, want %d", warnings, tt.wantWarnings)
+			}
+		})
 	}
+}
 
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
+func TestRepository_Referrers_TagSchemaFallback(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
-}
 
-func Test_BlobStore_Fetch_ZeroSizedBlob(t *testing.T) {
-	blob := []byte("")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         2,
+			Digest:       digest.FromString("2"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         3,
+			Digest:       digest.FromString("3"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         4,
+			Digest:       digest.FromString("4"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         5,
+			Digest:       digest.FromString("5"),
+			ArtifactType: "application/vnd.test",
+		},
 	}
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+		path := "/v2/test/manifests/" + referrersTag
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			if r.URL.Path != "/v2/test/referrers/"+manifestDesc.Digest.String() {
+				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			}
+			w.WriteHeader(http.StatusNotFound)
 			return
 		}
 
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
-				t.Errorf("unexpected range header")
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-		default:
-			w.WriteHeader(http.StatusNotFound)
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
 		}
 	}))
 	defer ts.Close()
@@ -2722,677 +3114,2510 @@ func Test_BlobStore_Fetch_ZeroSizedBlob(t *testing.T) {
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
+	ctx := context.Background()
 
+	// test auto detect
+	// remote server does not support Referrers, should fallback to tag schema
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	rc, err := store.Fetch(ctx, blobDesc)
-	if err != nil {
-		t.Fatalf("Blobs.Fetch() error = %v", err)
-	}
-	buf := bytes.NewBuffer(nil)
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
 	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
 	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
-}
 
-func Test_BlobStore_Push(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
-	}
-	var gotBlob []byte
-	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch {
-		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
-			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
-			w.WriteHeader(http.StatusAccepted)
-			return
-		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
-			if contentType := r.Header.Get("Content-Type"); contentType != "application/octet-stream" {
-				w.WriteHeader(http.StatusBadRequest)
-				break
-			}
-			if contentDigest := r.URL.Query().Get("digest"); contentDigest != blobDesc.Digest.String() {
-				w.WriteHeader(http.StatusBadRequest)
-				break
-			}
-			buf := bytes.NewBuffer(nil)
-			if _, err := buf.ReadFrom(r.Body); err != nil {
-				t.Errorf("fail to read: %v", err)
-			}
-			gotBlob = buf.Bytes()
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			w.WriteHeader(http.StatusCreated)
-			return
-		default:
-			w.WriteHeader(http.StatusForbidden)
-		}
-		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-	}))
-	defer ts.Close()
-	uri, err := url.Parse(ts.URL)
+	// test force attempt Referrers
+	// remote server does not support Referrers, should return error
+	repo, err = NewRepository(uri.Host + "/test")
 	if err != nil {
-		t.Fatalf("invalid test http server: %v", err)
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.SetReferrersCapability(true)
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	}
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err == nil {
+		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, true)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
 	}
 
-	repo, err := NewRepository(uri.Host + "/test")
+	// test force attempt tag schema
+	// should request tag schema
+	repo, err = NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	err = store.Push(ctx, blobDesc, bytes.NewReader(blob))
-	if err != nil {
-		t.Fatalf("Blobs.Push() error = %v", err)
+	repo.SetReferrersCapability(false)
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
-	if !bytes.Equal(gotBlob, blob) {
-		t.Errorf("Blobs.Push() = %v, want %v", gotBlob, blob)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
 }
 
-func Test_BlobStore_Exists(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+func TestRepository_Referrers_TagSchemaFallback_NotFound(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
+
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodHead {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			w.Header().Set("Content-Length", strconv.Itoa(int(blobDesc.Size)))
-		default:
+		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
+		if r.Method == http.MethodGet ||
+			r.URL.Path == referrersUrl ||
+			r.URL.Path == tagSchemaUrl {
 			w.WriteHeader(http.StatusNotFound)
+			return
 		}
+		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
+	ctx := context.Background()
 
+	// test auto detect
+	// tag schema referrers not found, should be no error
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	exists, err := store.Exists(ctx, blobDesc)
-	if err != nil {
-		t.Fatalf("Blobs.Exists() error = %v", err)
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
 	}
-	if !exists {
-		t.Errorf("Blobs.Exists() = %v, want %v", exists, true)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
 	}
-
-	content := []byte("foobar")
-	contentDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(content),
-		Size:      int64(len(content)),
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
-	exists, err = store.Exists(ctx, contentDesc)
+
+	// test force attempt tag schema
+	// tag schema referrers not found, should be no error
+	repo, err = NewRepository(uri.Host + "/test")
 	if err != nil {
-		t.Fatalf("Blobs.Exists() error = %v", err)
+		t.Fatalf("NewRepository() error = %v", err)
 	}
-	if exists {
-		t.Errorf("Blobs.Exists() = %v, want %v", exists, false)
+	repo.PlainHTTP = true
+	repo.SetReferrersCapability(false)
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	}
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
 }
 
-func Test_BlobStore_Delete(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+func TestRepository_Referrers_TagSchemaFallback_ContentType(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
-	blobDeleted := false
+
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
+		if r.URL.Path == referrersUrl {
+			if accept := r.Header.Get("Accept"); accept != ocispec.MediaTypeImageIndex {
+				t.Errorf("unexpected Accept header: %q, want %q", accept, ocispec.MediaTypeImageIndex)
+			}
+			w.Header().Set("Content-Type", "application/json") // not an OCI image index
+			w.WriteHeader(http.StatusOK)
 			return
 		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			blobDeleted = true
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			w.WriteHeader(http.StatusAccepted)
-		default:
+		if r.Method == http.MethodGet ||
+			r.URL.Path == tagSchemaUrl {
 			w.WriteHeader(http.StatusNotFound)
+			return
 		}
+		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
+	ctx := context.Background()
 
+	// test auto detect
+	// tag schema referrers not found, should be no error
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	err = store.Delete(ctx, blobDesc)
-	if err != nil {
-		t.Fatalf("Blobs.Delete() error = %v", err)
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
 	}
-	if !blobDeleted {
-		t.Errorf("Blobs.Delete() = %v, want %v", blobDeleted, true)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
+}
 
-	content := []byte("foobar")
-	contentDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(content),
-		Size:      int64(len(content)),
+func TestRepository_Referrers_TagSchemaFallback_InvalidIndex(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
-	err = store.Delete(ctx, contentDesc)
-	if !errors.Is(err, errdef.ErrNotFound) {
-		t.Errorf("Blobs.Delete() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
 	}
-}
+	index, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: referrers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	untypedIndex, err := json.Marshal(ocispec.Index{
+		Manifests: referrers,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	imageManifest, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    ocispec.DescriptorEmptyJSON,
+		Layers:    []ocispec.Descriptor{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	untypedManifest := []byte(`{"schemaVersion":2,"config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
 
-func Test_BlobStore_Resolve(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+	tests := []struct {
+		name        string
+		contentType string
+		content     []byte
+		want        []ocispec.Descriptor
+		wantErr     error
+	}{
+		{
+			name:        "image index",
+			contentType: ocispec.MediaTypeImageIndex,
+			content:     index,
+			want:        referrers,
+		},
+		{
+			name:        "image index of unexpected content type",
+			contentType: "application/json",
+			content:     index,
+			want:        referrers,
+		},
+		{
+			name:        "untyped image index",
+			contentType: "application/json",
+			content:     untypedIndex,
+			want:        referrers,
+		},
+		{
+			name:        "image manifest",
+			contentType: ocispec.MediaTypeImageManifest,
+			content:     imageManifest,
+			wantErr:     errdef.ErrInvalidMediaType,
+		},
+		{
+			name:        "image manifest of unexpected content type",
+			contentType: "application/json",
+			content:     imageManifest,
+			wantErr:     errdef.ErrInvalidMediaType,
+		},
+		{
+			name:        "untyped image manifest",
+			contentType: "application/json",
+			content:     untypedManifest,
+			wantErr:     errdef.ErrInvalidMediaType,
+		},
+		{
+			name:        "image index of manifest content type",
+			contentType: docker.MediaTypeManifest,
+			content:     index,
+			wantErr:     errdef.ErrInvalidMediaType,
+		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+				path := "/v2/test/manifests/" + referrersTag
+				if r.Method != http.MethodGet || r.URL.Path != path {
+					t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Header().Set("Docker-Content-Digest", digest.FromBytes(tt.content).String())
+				if _, err := w.Write(tt.content); err != nil {
+					t.Errorf("failed to write response: %v", err)
+				}
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
 
-	t.Run("Successfully resolve", func(t *testing.T) {
-		ref := "foobar"
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodHead {
-				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-				w.WriteHeader(http.StatusMethodNotAllowed)
-				return
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
 			}
-			switch r.URL.Path {
-			case "/v2/test/blobs/" + blobDesc.Digest.String():
-				w.Header().Set("Content-Type", "application/octet-stream")
-				w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-				w.Header().Set("Content-Length", strconv.Itoa(int(blobDesc.Size)))
-			default:
-				w.WriteHeader(http.StatusNotFound)
+			repo.PlainHTTP = true
+			if err := repo.SetReferrersCapability(false); err != nil {
+				t.Fatalf("Repository.SetReferrersCapability() error = %v", err)
 			}
-		}))
-		defer ts.Close()
-		uri, err := url.Parse(ts.URL)
-		if err != nil {
-			t.Fatalf("invalid test http server: %v", err)
-		}
+			ctx := context.Background()
 
-		repoName := uri.Host + "/test"
-		repo, err := NewRepository(repoName)
-		if err != nil {
-			t.Fatalf("NewRepository() error = %v", err)
-		}
-		repo.PlainHTTP = true
-		store := repo.Blobs()
-		ctx := context.Background()
+			var got []ocispec.Descriptor
+			err = repo.Referrers(ctx, manifestDesc, "", func(referrers []ocispec.Descriptor) error {
+				got = append(got, referrers...)
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Repository.Referrers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Repository.Referrers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
-		got, err := store.Resolve(ctx, blobDesc.Digest.String())
-		if err != nil {
-			t.Fatalf("Blobs.Resolve() error = %v", err)
-		}
-		if got.Digest != blobDesc.Digest || got.Size != blobDesc.Size {
-			t.Errorf("Blobs.Resolve() = %v, want %v", got, blobDesc)
-		}
-
-		_, err = store.Resolve(ctx, ref)
-		if !errors.Is(err, digest.ErrDigestInvalidFormat) {
-			t.Errorf("Blobs.Resolve() error = %v, wantErr %v", err, digest.ErrDigestInvalidFormat)
-		}
+func TestRepository_Referrers_MediaTypes(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
+	}
+	const customMediaType = "application/vnd.example.index.v2+json"
 
-		fqdnRef := repoName + "@" + blobDesc.Digest.String()
-		got, err = store.Resolve(ctx, fqdnRef)
-		if err != nil {
-			t.Fatalf("Blobs.Resolve() error = %v", err)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
 		}
-		if got.Digest != blobDesc.Digest || got.Size != blobDesc.Size {
-			t.Errorf("Blobs.Resolve() = %v, want %v", got, blobDesc)
+		accept := r.Header.Get("Accept")
+		var contentType string
+		switch accept {
+		case ocispec.MediaTypeImageIndex:
+			contentType = ocispec.MediaTypeImageIndex
+		case customMediaType + ", " + ocispec.MediaTypeImageIndex:
+			contentType = customMediaType
+		default:
+			t.Errorf("unexpected Accept header: %q", accept)
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
 		}
-
-		content := []byte("foobar")
-		contentDesc := ocispec.Descriptor{
-			MediaType: "test",
-			Digest:    digest.FromBytes(content),
-			Size:      int64(len(content)),
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
 		}
-		_, err = store.Resolve(ctx, contentDesc.Digest.String())
-		if !errors.Is(err, errdef.ErrNotFound) {
-			t.Errorf("Blobs.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+		w.Header().Set("Content-Type", contentType)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
 		}
-	})
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ctx := context.Background()
 
-	t.Run("Resolve failed with server error", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodHead {
-				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-				w.WriteHeader(http.StatusMethodNotAllowed)
-				return
+	tests := []struct {
+		name       string
+		mediaTypes []string
+	}{
+		{
+			name: "default media types",
+		},
+		{
+			name:       "custom media types",
+			mediaTypes: []string{customMediaType, ocispec.MediaTypeImageIndex},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
 			}
-			switch r.URL.Path {
-			case "/v2/test/blobs/" + blobDesc.Digest.String():
-				w.WriteHeader(http.StatusInternalServerError)
-			default:
-				w.WriteHeader(http.StatusNotFound)
+			repo.PlainHTTP = true
+			repo.ReferrersMediaTypes = tt.mediaTypes
+			var got []ocispec.Descriptor
+			if err := repo.Referrers(ctx, manifestDesc, "", func(page []ocispec.Descriptor) error {
+				got = append(got, page...)
+				return nil
+			}); err != nil {
+				t.Fatalf("Repository.Referrers() error = %v", err)
 			}
-		}))
-		defer ts.Close()
-		uri, err := url.Parse(ts.URL)
-		if err != nil {
-			t.Fatalf("invalid test http server: %v", err)
-		}
-
-		repoName := uri.Host + "/test"
-		repo, err := NewRepository(repoName)
-		if err != nil {
-			t.Fatalf("NewRepository() error = %v", err)
-		}
-		repo.PlainHTTP = true
-		store := repo.Blobs()
-		ctx := context.Background()
-
-		if _, err := store.Resolve(ctx, blobDesc.Digest.String()); err == nil {
-			t.Error("Blobs.Resolve() error = nil, wantErr = true")
-		}
-	})
-
-	t.Run("Resolve failed with bad reference", func(t *testing.T) {
-		ref := "sha256:bad"
-		repo, err := NewRepository("localhost:5000/test")
-		if err != nil {
-			t.Fatalf("NewRepository() error = %v", err)
-		}
-		store := repo.Blobs()
-		ctx := context.Background()
-		if _, err := store.Resolve(ctx, ref); err == nil {
-			t.Error("Blobs.Resolve() error = nil, wantErr = true")
-		}
-	})
-
-	t.Run("Resolve failed with connection error", func(t *testing.T) {
-		ref := blobDesc.Digest.String()
-		repo, err := NewRepository("localhost:9876/test")
-		if err != nil {
-			t.Fatalf("NewRepository() error = %v", err)
-		}
-		store := repo.Blobs()
-		ctx := context.Background()
-		if _, err := store.Resolve(ctx, ref); err == nil {
-			t.Error("Blobs.Resolve() error = nil, wantErr = true")
-		}
-	})
+			if !reflect.DeepEqual(got, referrers) {
+				t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+			}
+			if state := repo.loadReferrersState(); state != referrersStateSupported {
+				t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+			}
+		})
+	}
 }
 
-func Test_BlobStore_FetchReference(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+func TestRepository_Referrers_BadRequest(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
-	ref := "foobar"
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
+		if r.Method == http.MethodGet ||
+			r.URL.Path == referrersUrl ||
+			r.URL.Path == tagSchemaUrl {
+			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			if _, err := w.Write(blob); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
-			}
-		default:
-			w.WriteHeader(http.StatusNotFound)
-		}
+		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
+	ctx := context.Background()
 
-	repoName := uri.Host + "/test"
-	repo, err := NewRepository(repoName)
+	// test auto detect
+	// Referrers returns error
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	// test with digest
-	gotDesc, rc, err := store.FetchReference(ctx, blobDesc.Digest.String())
-	if err != nil {
-		t.Fatalf("Blobs.FetchReference() error = %v", err)
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
 	}
-	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err == nil {
+		t.Errorf("Repository.Referrers() error = nil, wantErr %v", true)
 	}
-	buf := bytes.NewBuffer(nil)
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
 	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
+
+	// test force attempt Referrers
+	// Referrers returns error
+	repo, err = NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
 	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	repo.PlainHTTP = true
+	repo.SetReferrersCapability(true)
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
 	}
-
-	// test with non-digest reference
-	_, _, err = store.FetchReference(ctx, ref)
-	if !errors.Is(err, digest.ErrDigestInvalidFormat) {
-		t.Errorf("Blobs.FetchReference() error = %v, wantErr %v", err, digest.ErrDigestInvalidFormat)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err == nil {
+		t.Errorf("Repository.Referrers() error = nil, wantErr %v", true)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
 	}
 
-	// test with FQDN reference
-	fqdnRef := repoName + "@" + blobDesc.Digest.String()
-	gotDesc, rc, err = store.FetchReference(ctx, fqdnRef)
+	// test force attempt tag schema
+	// Referrers returns error
+	repo, err = NewRepository(uri.Host + "/test")
 	if err != nil {
-		t.Fatalf("Blobs.FetchReference() error = %v", err)
-	}
-	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
-	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
+		t.Fatalf("NewRepository() error = %v", err)
 	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
+	repo.PlainHTTP = true
+	repo.SetReferrersCapability(false)
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err == nil {
+		t.Errorf("Repository.Referrers() error = nil, wantErr %v", true)
 	}
-
-	content := []byte("foobar")
-	contentDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(content),
-		Size:      int64(len(content)),
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
+}
 
-	// test with other digest
-	_, _, err = store.FetchReference(ctx, contentDesc.Digest.String())
-	if !errors.Is(err, errdef.ErrNotFound) {
-		t.Errorf("Blobs.FetchReference() error = %v, wantErr %v", err, errdef.ErrNotFound)
-	}
-}
-
-func Test_BlobStore_FetchReference_Seek(t *testing.T) {
-	blob := []byte("hello world")
-	blobDesc := ocispec.Descriptor{
-		MediaType: "test",
-		Digest:    digest.FromBytes(blob),
-		Size:      int64(len(blob)),
+func TestRepository_Referrers_RepositoryNotFound(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
 	}
-	seekable := false
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-		switch r.URL.Path {
-		case "/v2/test/blobs/" + blobDesc.Digest.String():
-			w.Header().Set("Content-Type", "application/octet-stream")
-			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
-			if seekable {
-				w.Header().Set("Accept-Ranges", "bytes")
-			}
-			rangeHeader := r.Header.Get("Range")
-			if !seekable || rangeHeader == "" {
-				w.WriteHeader(http.StatusOK)
-				if _, err := w.Write(blob); err != nil {
-					t.Errorf("failed to write %q: %v", r.URL, err)
-				}
-				return
-			}
-			var start int
-			_, err := fmt.Sscanf(rangeHeader, "bytes=%d-", &start)
-			if err != nil {
-				t.Errorf("invalid range header: %s", rangeHeader)
-				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
-				return
-			}
-			if start < 0 || start >= int(blobDesc.Size) {
-				t.Errorf("invalid range: %s", rangeHeader)
-				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
-				return
-			}
-
-			w.WriteHeader(http.StatusPartialContent)
-			if _, err := w.Write(blob[start:]); err != nil {
-				t.Errorf("failed to write %q: %v", r.URL, err)
-			}
-		default:
+		referrersUrl := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+		tagSchemaUrl := "/v2/test/manifests/" + referrersTag
+		if r.Method == http.MethodGet &&
+			(r.URL.Path == referrersUrl || r.URL.Path == tagSchemaUrl) {
 			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{ "errors": [ { "code": "NAME_UNKNOWN", "message": "repository name not known to registry" } ] }`))
+			return
 		}
+		t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+		w.WriteHeader(http.StatusNotFound)
 	}))
 	defer ts.Close()
 	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
+	ctx := context.Background()
 
+	// test auto detect
+	// repository not found, should return error
 	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	store := repo.Blobs()
-	ctx := context.Background()
-
-	// test non-seekable content
-	gotDesc, rc, err := store.FetchReference(ctx, blobDesc.Digest.String())
-	if err != nil {
-		t.Fatalf("Blobs.FetchReference() error = %v", err)
-	}
-	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
-	}
-	if _, ok := rc.(io.Seeker); ok {
-		t.Errorf("Blobs.FetchReference() returns io.Seeker on non-seekable content")
-	}
-	buf := bytes.NewBuffer(nil)
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
 	}
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err == nil {
+		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, true)
 	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
 	}
 
-	// test seekable content
-	seekable = true
-	gotDesc, rc, err = store.FetchReference(ctx, blobDesc.Digest.String())
+	// test force attempt Referrers
+	// repository not found, should return error
+	repo, err = NewRepository(uri.Host + "/test")
 	if err != nil {
-		t.Fatalf("Blobs.FetchReference() error = %v", err)
-	}
-	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
+		t.Fatalf("NewRepository() error = %v", err)
 	}
-	s, ok := rc.(io.Seeker)
-	if !ok {
-		t.Fatalf("Blobs.FetchReference() = %v, want io.Seeker", rc)
+	repo.PlainHTTP = true
+	repo.SetReferrersCapability(true)
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
 	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err == nil {
+		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, true)
 	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob) {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
 	}
 
-	_, err = s.Seek(3, io.SeekStart)
+	// test force attempt tag schema
+	// repository not found, but should not return error
+	repo, err = NewRepository(uri.Host + "/test")
 	if err != nil {
-		t.Errorf("fail to seek: %v", err)
-	}
-	buf.Reset()
-	if _, err := buf.ReadFrom(rc); err != nil {
-		t.Errorf("fail to read: %v", err)
-	}
-	if got := buf.Bytes(); !bytes.Equal(got, blob[3:]) {
-		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob[3:])
-	}
-
-	if err := rc.Close(); err != nil {
-		t.Errorf("fail to close: %v", err)
-	}
-}
-
-func Test_generateBlobDescriptorWithVariousDockerContentDigestHeaders(t *testing.T) {
-	reference := registry.Reference{
-		Registry:   "eastern.haan.com",
-		Reference:  "<calculate>",
-		Repository: "from25to220ce",
+		t.Fatalf("NewRepository() error = %v", err)
 	}
-	tests := getTestIOStructMapForGetDescriptorClass()
-	for testName, dcdIOStruct := range tests {
-		if dcdIOStruct.isTag {
-			continue
-		}
-
-		for i, method := range []string{http.MethodGet, http.MethodHead} {
-			reference.Reference = dcdIOStruct.clientSuppliedReference
-
-			resp := http.Response{
-				Header: http.Header{
-					"Content-Type":            []string{"application/vnd.docker.distribution.manifest.v2+json"},
-					headerDockerContentDigest: []string{dcdIOStruct.serverCalculatedDigest.String()},
-				},
-			}
-			if method == http.MethodGet {
-				resp.Body = io.NopCloser(bytes.NewBufferString(theAmazingBanClan))
-			}
-			resp.Request = &http.Request{
-				Method: method,
-			}
-
-			var err error
-			var d digest.Digest
-			if d, err = reference.Digest(); err != nil {
-				t.Errorf(
-					"[Blob.%v] %v; got digest from a tag reference unexpectedly",
-					method, testName,
-				)
-			}
-
-			errExpected := []bool{dcdIOStruct.errExpectedOnGET, dcdIOStruct.errExpectedOnHEAD}[i]
-			if len(d) == 0 {
-				// To avoid an otherwise impossible scenario in the tested code
-				// path, we set d so that verifyContentDigest does not break.
-				d = dcdIOStruct.serverCalculatedDigest
-			}
-			_, err = generateBlobDescriptor(&resp, d)
-			if !errExpected && err != nil {
-				t.Errorf(
-					"[Blob.%v] %v; expected no error for request, but got err: %v",
-					method, testName, err,
-				)
-			} else if errExpected && err == nil {
-				t.Errorf(
-					"[Blob.%v] %v; expected an error for request, but got none",
-					method, testName,
-				)
-			}
-		}
+	repo.PlainHTTP = true
+	repo.SetReferrersCapability(false)
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
-}
-
-func TestManifestStoreInterface(t *testing.T) {
-	var ms interface{} = &manifestStore{}
-	if _, ok := ms.(interfaces.ReferenceParser); !ok {
-		t.Error("&manifestStore{} does not conform interfaces.ReferenceParser")
+	if err := repo.Referrers(ctx, manifestDesc, "", func(got []ocispec.Descriptor) error {
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v, wantErr %v", err, nil)
 	}
-}
-
-func TestRepositoryMounterInterface(t *testing.T) {
-	var r interface{} = &Repository{}
-	if _, ok := r.(registry.Mounter); !ok {
-		t.Error("&Repository{} does not conform to registry.Mounter")
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
 	}
 }
 
-func Test_ManifestStore_Fetch(t *testing.T) {
+func TestRepository_Referrers_ServerFiltering(t *testing.T) {
 	manifest := []byte(`{"layers":[]}`)
 	manifestDesc := ocispec.Descriptor{
 		MediaType: ocispec.MediaTypeImageManifest,
 		Digest:    digest.FromBytes(manifest),
 		Size:      int64(len(manifest)),
 	}
-
-	t.Run("successfull fetch", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Method != http.MethodGet {
-				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
-				w.WriteHeader(http.StatusMethodNotAllowed)
-				return
-			}
-			switch r.URL.Path {
-			case "/v2/test/manifests/" + manifestDesc.Digest.String():
-				if accept := r.Header.Get("Accept"); !strings.Contains(accept, manifestDesc.MediaType) {
-					t.Errorf("manifest not convertable: %s", accept)
-					w.WriteHeader(http.StatusBadRequest)
-					return
-				}
-				w.Header().Set("Content-Type", manifestDesc.MediaType)
-				w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
-				if _, err := w.Write(manifest); err != nil {
-					t.Errorf("failed to write %q: %v", r.URL, err)
-				}
-			default:
-				w.WriteHeader(http.StatusNotFound)
-			}
-		}))
-		defer ts.Close()
-		uri, err := url.Parse(ts.URL)
-		if err != nil {
-			t.Fatalf("invalid test http server: %v", err)
-		}
-
-		repo, err := NewRepository(uri.Host + "/test")
-		if err != nil {
+	referrerSet := [][]ocispec.Descriptor{
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         1,
+				Digest:       digest.FromString("1"),
+				ArtifactType: "application/vnd.test",
+			},
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         2,
+				Digest:       digest.FromString("2"),
+				ArtifactType: "application/vnd.test",
+			},
+		},
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         3,
+				Digest:       digest.FromString("3"),
+				ArtifactType: "application/vnd.test",
+			},
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         4,
+				Digest:       digest.FromString("4"),
+				ArtifactType: "application/vnd.test",
+			},
+		},
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         5,
+				Digest:       digest.FromString("5"),
+				ArtifactType: "application/vnd.test",
+			},
+		},
+	}
+
+	// Test with filter annotations only
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		queryParams, err := url.ParseQuery(r.URL.RawQuery)
+		if err != nil {
+			t.Fatal("failed to parse url query")
+		}
+		if r.Method != http.MethodGet ||
+			r.URL.Path != path ||
+			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		n, err := strconv.Atoi(q.Get("n"))
+		if err != nil || n != 2 {
+			t.Errorf("bad page size: %s", q.Get("n"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var referrers []ocispec.Descriptor
+		switch q.Get("test") {
+		case "foo":
+			referrers = referrerSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
+		case "bar":
+			referrers = referrerSet[2]
+		default:
+			referrers = referrerSet[0]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
+		}
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+			// set filter annotations
+			Annotations: map[string]string{
+				spec.AnnotationReferrersFiltersApplied: "artifactType",
+			},
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.ReferrerListPageSize = 2
+
+	ctx := context.Background()
+	index := 0
+	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
+		if index >= len(referrerSet) {
+			t.Fatalf("out of index bound: %d", index)
+		}
+		referrers := referrerSet[index]
+		index++
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if index != len(referrerSet) {
+		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+	}
+
+	// Test with filter header only
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		queryParams, err := url.ParseQuery(r.URL.RawQuery)
+		if err != nil {
+			t.Fatal("failed to parse url query")
+		}
+		if r.Method != http.MethodGet ||
+			r.URL.Path != path ||
+			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		n, err := strconv.Atoi(q.Get("n"))
+		if err != nil || n != 2 {
+			t.Errorf("bad page size: %s", q.Get("n"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var referrers []ocispec.Descriptor
+		switch q.Get("test") {
+		case "foo":
+			referrers = referrerSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
+		case "bar":
+			referrers = referrerSet[2]
+		default:
+			referrers = referrerSet[0]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
+		}
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		// set filter header
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err = url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err = NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.ReferrerListPageSize = 2
+
+	ctx = context.Background()
+	index = 0
+	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
+		if index >= len(referrerSet) {
+			t.Fatalf("out of index bound: %d", index)
+		}
+		referrers := referrerSet[index]
+		index++
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if index != len(referrerSet) {
+		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+	}
+
+	// Test with both filter annotation and filter header
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		queryParams, err := url.ParseQuery(r.URL.RawQuery)
+		if err != nil {
+			t.Fatal("failed to parse url query")
+		}
+		if r.Method != http.MethodGet ||
+			r.URL.Path != path ||
+			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		n, err := strconv.Atoi(q.Get("n"))
+		if err != nil || n != 2 {
+			t.Errorf("bad page size: %s", q.Get("n"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var referrers []ocispec.Descriptor
+		switch q.Get("test") {
+		case "foo":
+			referrers = referrerSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
+		case "bar":
+			referrers = referrerSet[2]
+		default:
+			referrers = referrerSet[0]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
+		}
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+			// set filter annotation
+			Annotations: map[string]string{
+				spec.AnnotationReferrersFiltersApplied: "artifactType",
+			},
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		// set filter header
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err = url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err = NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.ReferrerListPageSize = 2
+
+	ctx = context.Background()
+	index = 0
+	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
+		if index >= len(referrerSet) {
+			t.Fatalf("out of index bound: %d", index)
+		}
+		referrers := referrerSet[index]
+		index++
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if index != len(referrerSet) {
+		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+	}
+}
+
+func TestRepository_Referrers_ClientFiltering(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	referrerSet := [][]ocispec.Descriptor{
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         1,
+				Digest:       digest.FromString("1"),
+				ArtifactType: "application/vnd.test",
+			},
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         2,
+				Digest:       digest.FromString("2"),
+				ArtifactType: "application/vnd.foo",
+			},
+		},
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         3,
+				Digest:       digest.FromString("3"),
+				ArtifactType: "application/vnd.test",
+			},
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         4,
+				Digest:       digest.FromString("4"),
+				ArtifactType: "application/vnd.bar",
+			},
+		},
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         5,
+				Digest:       digest.FromString("5"),
+				ArtifactType: "application/vnd.baz",
+			},
+		},
+	}
+	filteredReferrerSet := [][]ocispec.Descriptor{
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         1,
+				Digest:       digest.FromString("1"),
+				ArtifactType: "application/vnd.test",
+			},
+		},
+		{
+			{
+				MediaType:    spec.MediaTypeArtifactManifest,
+				Size:         3,
+				Digest:       digest.FromString("3"),
+				ArtifactType: "application/vnd.test",
+			},
+		},
+	}
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		queryParams, err := url.ParseQuery(r.URL.RawQuery)
+		if err != nil {
+			t.Fatal("failed to parse url query")
+		}
+		if r.Method != http.MethodGet ||
+			r.URL.Path != path ||
+			reflect.DeepEqual(queryParams["artifactType"], []string{"application%2Fvnd.test"}) {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		n, err := strconv.Atoi(q.Get("n"))
+		if err != nil || n != 2 {
+			t.Errorf("bad page size: %s", q.Get("n"))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var referrers []ocispec.Descriptor
+		switch q.Get("test") {
+		case "foo":
+			referrers = referrerSet[1]
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?n=2&test=bar>; rel="next"`, ts.URL, path))
+		case "bar":
+			referrers = referrerSet[2]
+		default:
+			referrers = referrerSet[0]
+			w.Header().Set("Link", fmt.Sprintf(`<%s?n=2&test=foo>; rel="next"`, path))
+		}
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.ReferrerListPageSize = 2
+
+	ctx := context.Background()
+	index := 0
+	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
+		if index >= len(filteredReferrerSet) {
+			t.Fatalf("out of index bound: %d", index)
+		}
+		referrers := filteredReferrerSet[index]
+		index++
+		if !reflect.DeepEqual(got, referrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+	if index != len(filteredReferrerSet) {
+		t.Errorf("fn invoked %d time(s), want %d", index, len(referrerSet))
+	}
+}
+
+func TestRepository_Referrers_TagSchemaFallback_ClientFiltering(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         2,
+			Digest:       digest.FromString("2"),
+			ArtifactType: "application/vnd.foo",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         3,
+			Digest:       digest.FromString("3"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         4,
+			Digest:       digest.FromString("4"),
+			ArtifactType: "application/vnd.bar",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         5,
+			Digest:       digest.FromString("5"),
+			ArtifactType: "application/vnd.baz",
+		},
+	}
+	filteredReferrers := []ocispec.Descriptor{
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Size:         3,
+			Digest:       digest.FromString("3"),
+			ArtifactType: "application/vnd.test",
+		},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+		path := "/v2/test/manifests/" + referrersTag
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			if r.URL.Path != "/v2/test/referrers/"+manifestDesc.Digest.String() {
+				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: referrers,
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+
+	ctx := context.Background()
+	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(got []ocispec.Descriptor) error {
+		if !reflect.DeepEqual(got, filteredReferrers) {
+			t.Errorf("Repository.Referrers() = %v, want %v", got, filteredReferrers)
+		}
+		return nil
+	}); err != nil {
+		t.Errorf("Repository.Referrers() error = %v", err)
+	}
+}
+
+func TestRepository_Referrers_TagSchemaFallback_MissingArtifactType(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+
+	// referrers lacking artifact types in the referrers index
+	configTypedJSON := []byte(`{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.test","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+	artifactTypedJSON := []byte(`{"mediaType":"application/vnd.oci.image.manifest.v1+json","artifactType":"application/vnd.foo","config":{"mediaType":"application/vnd.test","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+	untyped := map[digest.Digest][]byte{
+		digest.FromBytes(configTypedJSON):   configTypedJSON,
+		digest.FromBytes(artifactTypedJSON): artifactTypedJSON,
+	}
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+		},
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         2,
+			Digest:       digest.FromString("2"),
+			ArtifactType: "application/vnd.foo",
+		},
+		{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Size:      int64(len(configTypedJSON)),
+			Digest:    digest.FromBytes(configTypedJSON),
+		},
+		{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Size:      int64(len(artifactTypedJSON)),
+			Digest:    digest.FromBytes(artifactTypedJSON),
+		},
+	}
+
+	var fetched []digest.Digest
+	referrersTag := strings.Replace(manifestDesc.Digest.String(), ":", "-", 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/referrers/" + manifestDesc.Digest.String():
+			w.WriteHeader(http.StatusNotFound)
+		case "/v2/test/manifests/" + referrersTag:
+			result := ocispec.Index{
+				Versioned: specs.Versioned{
+					SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+				},
+				MediaType: ocispec.MediaTypeImageIndex,
+				Manifests: referrers,
+			}
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			if err := json.NewEncoder(w).Encode(result); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		default:
+			dgst := digest.Digest(strings.TrimPrefix(r.URL.Path, "/v2/test/manifests/"))
+			content, ok := untyped[dgst]
+			if !ok {
+				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fetched = append(fetched, dgst)
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", dgst.String())
+			if _, err := w.Write(content); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	// referrers are not fetched without filtering by artifact types
+	var got []ocispec.Descriptor
+	if err := repo.Referrers(ctx, manifestDesc, "", func(refs []ocispec.Descriptor) error {
+		got = append(got, refs...)
+		return nil
+	}); err != nil {
+		t.Fatalf("Repository.Referrers() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, referrers) {
+		t.Errorf("Repository.Referrers() = %v, want %v", got, referrers)
+	}
+	if len(fetched) != 0 {
+		t.Errorf("fetched referrers = %v, want none", fetched)
+	}
+
+	// only referrers lacking artifact types are fetched
+	want := []ocispec.Descriptor{referrers[0], referrers[2]}
+	want[1].ArtifactType = "application/vnd.test"
+	got = nil
+	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.test", func(refs []ocispec.Descriptor) error {
+		got = append(got, refs...)
+		return nil
+	}); err != nil {
+		t.Fatalf("Repository.Referrers() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.Referrers() = %v, want %v", got, want)
+	}
+	wantFetched := []digest.Digest{referrers[2].Digest, referrers[3].Digest}
+	if !reflect.DeepEqual(fetched, wantFetched) {
+		t.Errorf("fetched referrers = %v, want %v", fetched, wantFetched)
+	}
+
+	// the artifact type field takes precedence over the config media type
+	want = []ocispec.Descriptor{referrers[1], referrers[3]}
+	want[1].ArtifactType = "application/vnd.foo"
+	got = nil
+	if err := repo.Referrers(ctx, manifestDesc, "application/vnd.foo", func(refs []ocispec.Descriptor) error {
+		got = append(got, refs...)
+		return nil
+	}); err != nil {
+		t.Fatalf("Repository.Referrers() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.Referrers() = %v, want %v", got, want)
+	}
+}
+
+func Test_BlobStore_Fetch(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	rc, err := store.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	}
+
+	content := []byte("foobar")
+	contentDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	_, err = store.Fetch(ctx, contentDesc)
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Blobs.Fetch() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
+
+func Test_BlobStore_Fetch_Seek(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	seekable := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			if seekable {
+				w.Header().Set("Accept-Ranges", "bytes")
+			}
+			rangeHeader := r.Header.Get("Range")
+			if !seekable || rangeHeader == "" {
+				w.WriteHeader(http.StatusOK)
+				if _, err := w.Write(blob); err != nil {
+					t.Errorf("failed to write %q: %v", r.URL, err)
+				}
+				return
+			}
+			var start, end int
+			_, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end)
+			if err != nil {
+				t.Errorf("invalid range header: %s", rangeHeader)
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			if start < 0 || start > end || start >= int(blobDesc.Size) {
+				t.Errorf("invalid range: %s", rangeHeader)
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			end++
+			if end > int(blobDesc.Size) {
+				end = int(blobDesc.Size)
+			}
+			w.WriteHeader(http.StatusPartialContent)
+			if _, err := w.Write(blob[start:end]); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	rc, err := store.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	if _, ok := rc.(io.Seeker); ok {
+		t.Errorf("Blobs.Fetch() returns io.Seeker on non-seekable content")
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	}
+
+	seekable = true
+	rc, err = store.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	s, ok := rc.(io.Seeker)
+	if !ok {
+		t.Fatalf("Blobs.Fetch() = %v, want io.Seeker", rc)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	}
+
+	_, err = s.Seek(3, io.SeekStart)
+	if err != nil {
+		t.Errorf("fail to seek: %v", err)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob[3:]) {
+		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob[3:])
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+}
+
+func Test_BlobStore_Fetch_TruncatedBody(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{
+			name:   "declared Content-Length",
+			header: "Content-Length: " + strconv.Itoa(len(blob)) + "\r\n",
+		},
+		{
+			name: "no Content-Length",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodGet || r.URL.Path != "/v2/test/blobs/"+blobDesc.Digest.String() {
+					t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				// write fewer bytes than declared and cut the connection
+				conn, buf, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Errorf("failed to hijack connection: %v", err)
+					return
+				}
+				defer conn.Close()
+				buf.WriteString("HTTP/1.1 200 OK\r\nConnection: close\r\n" + tt.header + "\r\n")
+				buf.Write(blob[:5])
+				buf.Flush()
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			store := repo.Blobs()
+			ctx := context.Background()
+
+			rc, err := store.Fetch(ctx, blobDesc)
+			if err != nil {
+				t.Fatalf("Blobs.Fetch() error = %v", err)
+			}
+			defer rc.Close()
+			got, err := io.ReadAll(rc)
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("Blobs.Fetch().Read() error = %v, want %v", err, io.ErrUnexpectedEOF)
+			}
+			if want := blob[:5]; !bytes.Equal(got, want) {
+				t.Errorf("Blobs.Fetch().Read() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_BlobStore_Fetch_ProbeRangeSupport(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	// the server honors Range but never sends Accept-Ranges
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/test/blobs/"+blobDesc.Digest.String() {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+		rangeHeader := r.Header.Get("Range")
+		if rangeHeader == "" {
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+			return
+		}
+		start, end := 0, len(blob)-1
+		if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end); err != nil && rangeHeader != "bytes=0-" {
+			t.Errorf("invalid range header: %s", rangeHeader)
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(blob)))
+		w.WriteHeader(http.StatusPartialContent)
+		if _, err := w.Write(blob[start : end+1]); err != nil {
+			t.Errorf("failed to write %q: %v", r.URL, err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	// without probing, the content is not seekable
+	rc, err := store.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	if _, ok := rc.(io.Seeker); ok {
+		t.Errorf("Blobs.Fetch() returns io.Seeker without probing range support")
+	}
+	rc.Close()
+
+	// with probing, the content is seekable
+	repo.ProbeRangeSupport = true
+	verifySeek := func(rc io.ReadCloser) {
+		t.Helper()
+		defer rc.Close()
+		s, ok := rc.(io.Seeker)
+		if !ok {
+			t.Fatalf("Blobs.Fetch() = %v, want io.Seeker", rc)
+		}
+		got, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("fail to read: %v", err)
+		}
+		if !bytes.Equal(got, blob) {
+			t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+		}
+		offset := int64(6)
+		if pos, err := s.Seek(offset, io.SeekStart); err != nil || pos != offset {
+			t.Fatalf("Blobs.Fetch().Seek() = %v, %v, want %v, nil", pos, err, offset)
+		}
+		got, err = io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("fail to read: %v", err)
+		}
+		if want := blob[offset:]; !bytes.Equal(got, want) {
+			t.Errorf("Blobs.Fetch().Seek().Read() = %v, want %v", got, want)
+		}
+	}
+	rc, err = store.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	verifySeek(rc)
+
+	gotDesc, rc, err := store.(registry.ReferenceFetcher).FetchReference(ctx, blobDesc.Digest.String())
+	if err != nil {
+		t.Fatalf("Blobs.FetchReference() error = %v", err)
+	}
+	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
+	}
+	verifySeek(rc)
+}
+
+func Test_parseContentRangeSize(t *testing.T) {
+	tests := []struct {
+		contentRange string
+		want         int64
+		wantErr      bool
+	}{
+		{contentRange: "bytes 0-10/11", want: 11},
+		{contentRange: "bytes 0-0/1", want: 1},
+		{contentRange: "bytes 1-10/11", wantErr: true},
+		{contentRange: "bytes 0-9/11", wantErr: true},
+		{contentRange: "bytes 0-10/*", wantErr: true},
+		{contentRange: "bytes */11", wantErr: true},
+		{contentRange: "items 0-10/11", wantErr: true},
+		{contentRange: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentRange, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			resp := &http.Response{
+				Request: req,
+				Header:  http.Header{"Content-Range": {tt.contentRange}},
+			}
+			got, err := parseContentRangeSize(resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseContentRangeSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseContentRangeSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_BlobStore_Fetch_ZeroSizedBlob(t *testing.T) {
+	blob := []byte("")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			if rangeHeader := r.Header.Get("Range"); rangeHeader != "" {
+				t.Errorf("unexpected range header")
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	rc, err := store.Fetch(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.Fetch() = %v, want %v", got, blob)
+	}
+}
+
+func Test_BlobStore_Push(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	var gotBlob []byte
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			if contentType := r.Header.Get("Content-Type"); contentType != "application/octet-stream" {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			if contentDigest := r.URL.Query().Get("digest"); contentDigest != blobDesc.Digest.String() {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotBlob = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+			return
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+		t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	err = store.Push(ctx, blobDesc, bytes.NewReader(blob))
+	if err != nil {
+		t.Fatalf("Blobs.Push() error = %v", err)
+	}
+	if !bytes.Equal(gotBlob, blob) {
+		t.Errorf("Blobs.Push() = %v, want %v", gotBlob, blob)
+	}
+}
+
+func Test_BlobStore_Push_ContentDigestHeader(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	var respDigest string
+	var gotBlob []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotBlob = buf.Bytes()
+			if respDigest != "" {
+				w.Header().Set("Docker-Content-Digest", respDigest)
+			}
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	// missing digest header: the local digest is trusted
+	if err := store.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Blobs.Push() error = %v", err)
+	}
+	if !bytes.Equal(gotBlob, blob) {
+		t.Errorf("Blobs.Push() = %v, want %v", gotBlob, blob)
+	}
+
+	// matching digest header
+	respDigest = blobDesc.Digest.String()
+	if err := store.Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+		t.Fatalf("Blobs.Push() error = %v", err)
+	}
+
+	// mismatched digest header
+	respDigest = digest.FromBytes([]byte("foo")).String()
+	if err := store.Push(ctx, blobDesc, bytes.NewReader(blob)); err == nil {
+		t.Error("Blobs.Push() error = nil, wantErr true")
+	}
+}
+
+func Test_BlobStore_Push_BlobUploadContentType(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	uuid := "4fd53bc9-565d-4527-ab80-3e051ac4880c"
+	var gotContentType string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/test/blobs/uploads/":
+			w.Header().Set("Location", "/v2/test/blobs/uploads/"+uuid)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/blobs/uploads/"+uuid:
+			if got := r.URL.Query().Get("digest"); got != blobDesc.Digest.String() {
+				t.Errorf("unexpected digest: %v, want %v", got, blobDesc.Digest)
+			}
+			gotContentType = r.Header.Get("Content-Type")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	tests := []struct {
+		name                  string
+		blobUploadContentType func(desc ocispec.Descriptor) string
+		want                  string
+	}{
+		{
+			name: "default",
+			want: "application/octet-stream",
+		},
+		{
+			name: "empty",
+			blobUploadContentType: func(desc ocispec.Descriptor) string {
+				return ""
+			},
+			want: "application/octet-stream",
+		},
+		{
+			name: "custom",
+			blobUploadContentType: func(desc ocispec.Descriptor) string {
+				if desc.Digest != blobDesc.Digest {
+					t.Errorf("BlobUploadContentType() desc = %v, want %v", desc, blobDesc)
+				}
+				return "application/vnd.test.blob"
+			},
+			want: "application/vnd.test.blob",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo.BlobUploadContentType = tt.blobUploadContentType
+			gotContentType = ""
+			if err := repo.Blobs().Push(ctx, blobDesc, bytes.NewReader(blob)); err != nil {
+				t.Fatalf("Blobs.Push() error = %v", err)
+			}
+			if gotContentType != tt.want {
+				t.Errorf("Blobs.Push() Content-Type = %v, want %v", gotContentType, tt.want)
+			}
+		})
+	}
+}
+
+func Test_BlobStore_Exists(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(int(blobDesc.Size)))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	exists, err := store.Exists(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Exists() error = %v", err)
+	}
+	if !exists {
+		t.Errorf("Blobs.Exists() = %v, want %v", exists, true)
+	}
+
+	content := []byte("foobar")
+	contentDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	exists, err = store.Exists(ctx, contentDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Exists() error = %v", err)
+	}
+	if exists {
+		t.Errorf("Blobs.Exists() = %v, want %v", exists, false)
+	}
+}
+
+func Test_BlobStore_Delete(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	blobDeleted := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			blobDeleted = true
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	err = store.Delete(ctx, blobDesc)
+	if err != nil {
+		t.Fatalf("Blobs.Delete() error = %v", err)
+	}
+	if !blobDeleted {
+		t.Errorf("Blobs.Delete() = %v, want %v", blobDeleted, true)
+	}
+
+	content := []byte("foobar")
+	contentDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+	err = store.Delete(ctx, contentDesc)
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Blobs.Delete() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
+
+func Test_BlobStore_Resolve(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+
+	t.Run("Successfully resolve", func(t *testing.T) {
+		ref := "foobar"
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodHead {
+				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			switch r.URL.Path {
+			case "/v2/test/blobs/" + blobDesc.Digest.String():
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+				w.Header().Set("Content-Length", strconv.Itoa(int(blobDesc.Size)))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+
+		repoName := uri.Host + "/test"
+		repo, err := NewRepository(repoName)
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		store := repo.Blobs()
+		ctx := context.Background()
+
+		got, err := store.Resolve(ctx, blobDesc.Digest.String())
+		if err != nil {
+			t.Fatalf("Blobs.Resolve() error = %v", err)
+		}
+		if got.Digest != blobDesc.Digest || got.Size != blobDesc.Size {
+			t.Errorf("Blobs.Resolve() = %v, want %v", got, blobDesc)
+		}
+
+		_, err = store.Resolve(ctx, ref)
+		if !errors.Is(err, digest.ErrDigestInvalidFormat) {
+			t.Errorf("Blobs.Resolve() error = %v, wantErr %v", err, digest.ErrDigestInvalidFormat)
+		}
+
+		fqdnRef := repoName + "@" + blobDesc.Digest.String()
+		got, err = store.Resolve(ctx, fqdnRef)
+		if err != nil {
+			t.Fatalf("Blobs.Resolve() error = %v", err)
+		}
+		if got.Digest != blobDesc.Digest || got.Size != blobDesc.Size {
+			t.Errorf("Blobs.Resolve() = %v, want %v", got, blobDesc)
+		}
+
+		content := []byte("foobar")
+		contentDesc := ocispec.Descriptor{
+			MediaType: "test",
+			Digest:    digest.FromBytes(content),
+			Size:      int64(len(content)),
+		}
+		_, err = store.Resolve(ctx, contentDesc.Digest.String())
+		if !errors.Is(err, errdef.ErrNotFound) {
+			t.Errorf("Blobs.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+		}
+	})
+
+	t.Run("Resolve failed with server error", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodHead {
+				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			switch r.URL.Path {
+			case "/v2/test/blobs/" + blobDesc.Digest.String():
+				w.WriteHeader(http.StatusInternalServerError)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+
+		repoName := uri.Host + "/test"
+		repo, err := NewRepository(repoName)
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		store := repo.Blobs()
+		ctx := context.Background()
+
+		if _, err := store.Resolve(ctx, blobDesc.Digest.String()); err == nil {
+			t.Error("Blobs.Resolve() error = nil, wantErr = true")
+		}
+	})
+
+	t.Run("Resolve failed with bad reference", func(t *testing.T) {
+		ref := "sha256:bad"
+		repo, err := NewRepository("localhost:5000/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		store := repo.Blobs()
+		ctx := context.Background()
+		if _, err := store.Resolve(ctx, ref); err == nil {
+			t.Error("Blobs.Resolve() error = nil, wantErr = true")
+		}
+	})
+
+	t.Run("Resolve failed with connection error", func(t *testing.T) {
+		ref := blobDesc.Digest.String()
+		repo, err := NewRepository("localhost:9876/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		store := repo.Blobs()
+		ctx := context.Background()
+		if _, err := store.Resolve(ctx, ref); err == nil {
+			t.Error("Blobs.Resolve() error = nil, wantErr = true")
+		}
+	})
+}
+
+func Test_BlobStore_FetchReference(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	ref := "foobar"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repoName := uri.Host + "/test"
+	repo, err := NewRepository(repoName)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	// test with digest
+	gotDesc, rc, err := store.FetchReference(ctx, blobDesc.Digest.String())
+	if err != nil {
+		t.Fatalf("Blobs.FetchReference() error = %v", err)
+	}
+	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	}
+
+	// test with non-digest reference
+	_, _, err = store.FetchReference(ctx, ref)
+	if !errors.Is(err, digest.ErrDigestInvalidFormat) {
+		t.Errorf("Blobs.FetchReference() error = %v, wantErr %v", err, digest.ErrDigestInvalidFormat)
+	}
+
+	// test with FQDN reference
+	fqdnRef := repoName + "@" + blobDesc.Digest.String()
+	gotDesc, rc, err = store.FetchReference(ctx, fqdnRef)
+	if err != nil {
+		t.Fatalf("Blobs.FetchReference() error = %v", err)
+	}
+	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	}
+
+	content := []byte("foobar")
+	contentDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(content),
+		Size:      int64(len(content)),
+	}
+
+	// test with other digest
+	_, _, err = store.FetchReference(ctx, contentDesc.Digest.String())
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Blobs.FetchReference() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+}
+
+func Test_BlobStore_FetchReference_Seek(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	seekable := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/test/blobs/" + blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			if seekable {
+				w.Header().Set("Accept-Ranges", "bytes")
+			}
+			rangeHeader := r.Header.Get("Range")
+			if !seekable || rangeHeader == "" {
+				w.WriteHeader(http.StatusOK)
+				if _, err := w.Write(blob); err != nil {
+					t.Errorf("failed to write %q: %v", r.URL, err)
+				}
+				return
+			}
+			var start int
+			_, err := fmt.Sscanf(rangeHeader, "bytes=%d-", &start)
+			if err != nil {
+				t.Errorf("invalid range header: %s", rangeHeader)
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			if start < 0 || start >= int(blobDesc.Size) {
+				t.Errorf("invalid range: %s", rangeHeader)
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+
+			w.WriteHeader(http.StatusPartialContent)
+			if _, err := w.Write(blob[start:]); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Blobs()
+	ctx := context.Background()
+
+	// test non-seekable content
+	gotDesc, rc, err := store.FetchReference(ctx, blobDesc.Digest.String())
+	if err != nil {
+		t.Fatalf("Blobs.FetchReference() error = %v", err)
+	}
+	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
+	}
+	if _, ok := rc.(io.Seeker); ok {
+		t.Errorf("Blobs.FetchReference() returns io.Seeker on non-seekable content")
+	}
+	buf := bytes.NewBuffer(nil)
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	}
+
+	// test seekable content
+	seekable = true
+	gotDesc, rc, err = store.FetchReference(ctx, blobDesc.Digest.String())
+	if err != nil {
+		t.Fatalf("Blobs.FetchReference() error = %v", err)
+	}
+	if gotDesc.Digest != blobDesc.Digest || gotDesc.Size != blobDesc.Size {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", gotDesc, blobDesc)
+	}
+	s, ok := rc.(io.Seeker)
+	if !ok {
+		t.Fatalf("Blobs.FetchReference() = %v, want io.Seeker", rc)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob) {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob)
+	}
+
+	_, err = s.Seek(3, io.SeekStart)
+	if err != nil {
+		t.Errorf("fail to seek: %v", err)
+	}
+	buf.Reset()
+	if _, err := buf.ReadFrom(rc); err != nil {
+		t.Errorf("fail to read: %v", err)
+	}
+	if got := buf.Bytes(); !bytes.Equal(got, blob[3:]) {
+		t.Errorf("Blobs.FetchReference() = %v, want %v", got, blob[3:])
+	}
+
+	if err := rc.Close(); err != nil {
+		t.Errorf("fail to close: %v", err)
+	}
+}
+
+func Test_generateBlobDescriptorWithVariousDockerContentDigestHeaders(t *testing.T) {
+	reference := registry.Reference{
+		Registry:   "eastern.haan.com",
+		Reference:  "<calculate>",
+		Repository: "from25to220ce",
+	}
+	tests := getTestIOStructMapForGetDescriptorClass()
+	for testName, dcdIOStruct := range tests {
+		if dcdIOStruct.isTag {
+			continue
+		}
+
+		for i, method := range []string{http.MethodGet, http.MethodHead} {
+			reference.Reference = dcdIOStruct.clientSuppliedReference
+
+			resp := http.Response{
+				Header: http.Header{
+					"Content-Type":            []string{"application/vnd.docker.distribution.manifest.v2+json"},
+					headerDockerContentDigest: []string{dcdIOStruct.serverCalculatedDigest.String()},
+				},
+			}
+			if method == http.MethodGet {
+				resp.Body = io.NopCloser(bytes.NewBufferString(theAmazingBanClan))
+			}
+			resp.Request = &http.Request{
+				Method: method,
+			}
+
+			var err error
+			var d digest.Digest
+			if d, err = reference.Digest(); err != nil {
+				t.Errorf(
+					"[Blob.%v] %v; got digest from a tag reference unexpectedly",
+					method, testName,
+				)
+			}
+
+			errExpected := []bool{dcdIOStruct.errExpectedOnGET, dcdIOStruct.errExpectedOnHEAD}[i]
+			if len(d) == 0 {
+				// To avoid an otherwise impossible scenario in the tested code
+				// path, we set d so that verifyContentDigest does not break.
+				d = dcdIOStruct.serverCalculatedDigest
+			}
+			_, err = generateBlobDescriptor(&resp, d)
+			if !errExpected && err != nil {
+				t.Errorf(
+					"[Blob.%v] %v; expected no error for request, but got err: %v",
+					method, testName, err,
+				)
+			} else if errExpected && err == nil {
+				t.Errorf(
+					"[Blob.%v] %v; expected an error for request, but got none",
+					method, testName,
+				)
+			}
+		}
+	}
+}
+
+func TestManifestStoreInterface(t *testing.T) {
+	var ms interface{} = &manifestStore{}
+	if _, ok := ms.(interfaces.ReferenceParser); !ok {
+		t.Error("&manifestStore{} does not conform interfaces.ReferenceParser")
+	}
+}
+
+func TestRepositoryMounterInterface(t *testing.T) {
+	var r interface{} = &Repository{}
+	if _, ok := r.(registry.Mounter); !ok {
+		t.Error("&Repository{} does not conform to registry.Mounter")
+	}
+	var s interface{} = &manifestStore{}
+	if _, ok := s.(registry.Mounter); !ok {
+		t.Error("&manifestStore{} does not conform to registry.Mounter")
+	}
+}
+
+func Test_ManifestStore_Fetch(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+
+	t.Run("successfull fetch", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			switch r.URL.Path {
+			case "/v2/test/manifests/" + manifestDesc.Digest.String():
+				if accept := r.Header.Get("Accept"); !strings.Contains(accept, manifestDesc.MediaType) {
+					t.Errorf("manifest not convertable: %s", accept)
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.Header().Set("Content-Type", manifestDesc.MediaType)
+				w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+				if _, err := w.Write(manifest); err != nil {
+					t.Errorf("failed to write %q: %v", r.URL, err)
+				}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+
+		repo, err := NewRepository(uri.Host + "/test")
+		if err != nil {
 			t.Fatalf("NewRepository() error = %v", err)
 		}
 		repo.PlainHTTP = true
@@ -3592,9 +5817,209 @@ func Test_ManifestStore_Fetch(t *testing.T) {
 		store := repo.Manifests()
 		ctx := context.Background()
 
-		_, err = store.Fetch(ctx, manifestDesc)
-		if err == nil {
-			t.Error("Manifests.Fetch() error = nil, wantErr = true")
+		_, err = store.Fetch(ctx, manifestDesc)
+		if err == nil {
+			t.Error("Manifests.Fetch() error = nil, wantErr = true")
+		}
+	})
+}
+
+func Test_ManifestStore_Fetch_VerifyManifestMediaType(t *testing.T) {
+	const tag = "latest"
+	tests := []struct {
+		name     string
+		manifest string
+		verify   bool
+		wantErr  error
+	}{
+		{
+			name:     "mismatched mediaType without verification",
+			manifest: `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`,
+		},
+		{
+			name:     "mismatched mediaType",
+			manifest: `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`,
+			verify:   true,
+			wantErr:  errdef.ErrInvalidMediaType,
+		},
+		{
+			name:     "mismatched schemaVersion",
+			manifest: `{"schemaVersion":1,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[]}`,
+			verify:   true,
+			wantErr:  errdef.ErrUnsupportedVersion,
+		},
+		{
+			name:     "matched mediaType",
+			manifest: `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[]}`,
+			verify:   true,
+		},
+		{
+			name:     "absent mediaType",
+			manifest: `{"layers":[]}`,
+			verify:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := []byte(tt.manifest)
+			manifestDesc := ocispec.Descriptor{
+				MediaType: ocispec.MediaTypeImageManifest,
+				Digest:    digest.FromBytes(manifest),
+				Size:      int64(len(manifest)),
+			}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v2/test/manifests/" + manifestDesc.Digest.String(), "/v2/test/manifests/" + tag:
+					w.Header().Set("Content-Type", manifestDesc.MediaType)
+					w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+					if _, err := w.Write(manifest); err != nil {
+						t.Errorf("failed to write %q: %v", r.URL, err)
+					}
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			repo.VerifyManifestMediaType = tt.verify
+			store := repo.Manifests()
+			ctx := context.Background()
+
+			check := func(name string, rc io.ReadCloser, err error) {
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Errorf("%s error = %v, wantErr %v", name, err, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("%s error = %v", name, err)
+				}
+				defer rc.Close()
+				got, err := io.ReadAll(rc)
+				if err != nil {
+					t.Fatalf("failed to read: %v", err)
+				}
+				if !bytes.Equal(got, manifest) {
+					t.Errorf("%s = %s, want %s", name, got, manifest)
+				}
+			}
+			rc, err := store.Fetch(ctx, manifestDesc)
+			check("Manifests.Fetch()", rc, err)
+			_, rc, err = store.FetchReference(ctx, tag)
+			check("Manifests.FetchReference()", rc, err)
+		})
+	}
+}
+
+func Test_ManifestStore_Fetch_MaxMetadataBytes(t *testing.T) {
+	const tag = "latest"
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	ctx := context.Background()
+
+	t.Run("declared size exceeds limit", func(t *testing.T) {
+		var requestCount atomic.Int64
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestCount.Add(1)
+			if r.Method != http.MethodGet || r.URL.Path != "/v2/test/manifests/"+tag {
+				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", manifestDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+			if _, err := w.Write(manifest); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		}))
+		defer ts.Close()
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+		repo, err := NewRepository(uri.Host + "/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		repo.MaxMetadataBytes = manifestDesc.Size - 1
+		store := repo.Manifests()
+
+		if _, err := store.Fetch(ctx, manifestDesc); !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+			t.Errorf("Manifests.Fetch() error = %v, wantErr %v", err, errdef.ErrSizeExceedsLimit)
+		}
+		if got := requestCount.Load(); got != 0 {
+			t.Errorf("count(requests) = %d, want %d", got, 0)
+		}
+		if _, _, err := store.FetchReference(ctx, tag); !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+			t.Errorf("Manifests.FetchReference() error = %v, wantErr %v", err, errdef.ErrSizeExceedsLimit)
+		}
+	})
+
+	t.Run("body exceeds limit", func(t *testing.T) {
+		// the server declares the expected size but sends an oversized body
+		oversized := append(slices.Clone(manifest), bytes.Repeat([]byte(" "), 64)...)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v2/test/manifests/" + manifestDesc.Digest.String(), "/v2/test/manifests/" + tag:
+				w.Header().Set("Content-Type", manifestDesc.MediaType)
+				w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+				if r.Method == http.MethodHead {
+					w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+					return
+				}
+				// flush the header to omit Content-Length
+				w.(http.Flusher).Flush()
+				if _, err := w.Write(oversized); err != nil {
+					t.Errorf("failed to write %q: %v", r.URL, err)
+				}
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		defer ts.Close()
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+		repo, err := NewRepository(uri.Host + "/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		repo.MaxMetadataBytes = manifestDesc.Size * 2
+		store := repo.Manifests()
+
+		rc, err := store.Fetch(ctx, manifestDesc)
+		if err != nil {
+			t.Fatalf("Manifests.Fetch() error = %v", err)
+		}
+		defer rc.Close()
+		if _, err := io.ReadAll(rc); !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+			t.Errorf("Manifests.Fetch() read error = %v, wantErr %v", err, errdef.ErrSizeExceedsLimit)
+		}
+
+		_, rc, err = store.FetchReference(ctx, tag)
+		if err != nil {
+			t.Fatalf("Manifests.FetchReference() error = %v", err)
+		}
+		defer rc.Close()
+		if _, err := io.ReadAll(rc); !errors.Is(err, errdef.ErrSizeExceedsLimit) {
+			t.Errorf("Manifests.FetchReference() read error = %v, wantErr %v", err, errdef.ErrSizeExceedsLimit)
 		}
 	})
 }
@@ -4132,97 +6557,565 @@ func Test_ManifestStore_Push_ReferrersAPIUnavailable(t *testing.T) {
 		ArtifactType: "test/index",
 		Annotations:  map[string]string{"foo": "bar"},
 	}
-	indexManifestJSON, err := json.Marshal(indexManifest)
-	if err != nil {
-		t.Fatalf("failed to marshal manifest: %v", err)
+	indexManifestJSON, err := json.Marshal(indexManifest)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	indexManifestDesc := content.NewDescriptorFromBytes(indexManifest.MediaType, indexManifestJSON)
+	indexManifestDesc.ArtifactType = indexManifest.ArtifactType
+	indexManifestDesc.Annotations = indexManifest.Annotations
+	index_3 := ocispec.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{
+			artifactDesc,
+			manifestDesc,
+			indexManifestDesc,
+		},
+	}
+	indexJSON_3, err := json.Marshal(index_3)
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	indexDesc_3 := content.NewDescriptorFromBytes(index_3.MediaType, indexJSON_3)
+	indexDeleted = false
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+indexManifestDesc.Digest.String():
+			if contentType := r.Header.Get("Content-Type"); contentType != indexManifestDesc.MediaType {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotManifest = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", indexManifestDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+			w.Write(indexJSON_2)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+			if contentType := r.Header.Get("Content-Type"); contentType != ocispec.MediaTypeImageIndex {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotReferrerIndex = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", indexDesc_3.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/manifests/"+indexDesc_2.Digest.String():
+			indexDeleted = true
+			// no "Docker-Content-Digest" header for manifest deletion
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err = url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	ctx = context.Background()
+	repo, err = NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	}
+	err = repo.Push(ctx, indexManifestDesc, bytes.NewReader(indexManifestJSON))
+	if err != nil {
+		t.Fatalf("Manifests.Push() error = %v", err)
+	}
+	if !bytes.Equal(gotManifest, indexManifestJSON) {
+		t.Errorf("Manifests.Push() = %v, want %v", string(gotManifest), string(indexManifestJSON))
+	}
+	if !bytes.Equal(gotReferrerIndex, indexJSON_3) {
+		t.Errorf("got referrers index = %v, want %v", string(gotReferrerIndex), string(indexJSON_3))
+	}
+	if !indexDeleted {
+		t.Errorf("indexDeleted = %v, want %v", indexDeleted, true)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	}
+}
+
+func Test_ManifestStore_Push_ReferrersAPIUnavailable_ConcurrentModification(t *testing.T) {
+	// generate test content
+	subject := []byte(`{"layers":[]}`)
+	subjectDesc := content.NewDescriptorFromBytes(spec.MediaTypeArtifactManifest, subject)
+	referrersTag := strings.Replace(subjectDesc.Digest.String(), ":", "-", 1)
+	generateArtifact := func(artifactType string) ([]byte, ocispec.Descriptor) {
+		artifact := spec.Artifact{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Subject:      &subjectDesc,
+			ArtifactType: artifactType,
+		}
+		artifactJSON, err := json.Marshal(artifact)
+		if err != nil {
+			t.Fatalf("failed to marshal manifest: %v", err)
+		}
+		artifactDesc := content.NewDescriptorFromBytes(artifact.MediaType, artifactJSON)
+		artifactDesc.ArtifactType = artifact.ArtifactType
+		return artifactJSON, artifactDesc
+	}
+	generateIndex := func(manifests ...ocispec.Descriptor) []byte {
+		indexJSON, err := json.Marshal(ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: manifests,
+		})
+		if err != nil {
+			t.Fatalf("failed to marshal manifest: %v", err)
+		}
+		return indexJSON
+	}
+	artifactJSON, artifactDesc := generateArtifact("application/vnd.test")
+	// the referrer pushed by another client
+	_, otherDesc := generateArtifact("application/vnd.other")
+	oldIndexJSON := generateIndex()
+	otherIndexJSON := generateIndex(otherDesc)
+	otherIndexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, otherIndexJSON)
+	etagOf := func(manifest []byte) string {
+		return `"` + digest.FromBytes(manifest).String() + `"`
+	}
+
+	// a stateful registry without the Referrers API, supporting ETag
+	var lock sync.Mutex
+	tagged := oldIndexJSON
+	var indexReads int
+	var indexPushes int
+	var ifMatches []string
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+artifactDesc.Digest.String():
+			if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+				t.Errorf("unexpected If-Match header: %s", ifMatch)
+			}
+			w.Header().Set("Docker-Content-Digest", artifactDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+subjectDesc.Digest.String():
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+			indexReads++
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(tagged).String())
+			w.Header().Set("ETag", etagOf(tagged))
+			if _, err := w.Write(tagged); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+			indexPushes++
+			if indexPushes == 1 {
+				// simulate a concurrent modification between the read and
+				// the write
+				tagged = otherIndexJSON
+			}
+			ifMatch := r.Header.Get("If-Match")
+			ifMatches = append(ifMatches, ifMatch)
+			if ifMatch != etagOf(tagged) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			tagged = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(tagged).String())
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v2/test/manifests/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/v2/test/manifests/"))
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+	if err := repo.Manifests().Push(ctx, artifactDesc, bytes.NewReader(artifactJSON)); err != nil {
+		t.Fatalf("Manifests.Push() error = %v", err)
+	}
+
+	// both referrers end up in the final index
+	var index ocispec.Index
+	if err := json.Unmarshal(tagged, &index); err != nil {
+		t.Fatalf("failed to decode referrers index: %v", err)
+	}
+	want := []ocispec.Descriptor{otherDesc, artifactDesc}
+	if !reflect.DeepEqual(index.Manifests, want) {
+		t.Errorf("referrers index = %v, want %v", index.Manifests, want)
+	}
+	// the index is pushed conditionally instead of being re-read around the
+	// pushes
+	if indexReads != 2 {
+		t.Errorf("referrers index read %d times, want 2", indexReads)
+	}
+	if want := []string{etagOf(oldIndexJSON), etagOf(otherIndexJSON)}; !reflect.DeepEqual(ifMatches, want) {
+		t.Errorf("If-Match = %v, want %v", ifMatches, want)
+	}
+	// the index replaced is garbage collected
+	if want := []string{otherIndexDesc.Digest.String()}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+}
+
+func Test_ManifestStore_Push_ReferrersAPIUnavailable_NoETag(t *testing.T) {
+	// generate test content
+	subject := []byte(`{"layers":[]}`)
+	subjectDesc := content.NewDescriptorFromBytes(spec.MediaTypeArtifactManifest, subject)
+	referrersTag := strings.Replace(subjectDesc.Digest.String(), ":", "-", 1)
+	generateArtifact := func(artifactType string) ([]byte, ocispec.Descriptor) {
+		artifact := spec.Artifact{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Subject:      &subjectDesc,
+			ArtifactType: artifactType,
+		}
+		artifactJSON, err := json.Marshal(artifact)
+		if err != nil {
+			t.Fatalf("failed to marshal manifest: %v", err)
+		}
+		artifactDesc := content.NewDescriptorFromBytes(artifact.MediaType, artifactJSON)
+		artifactDesc.ArtifactType = artifact.ArtifactType
+		return artifactJSON, artifactDesc
+	}
+	artifactJSON, artifactDesc := generateArtifact("application/vnd.test")
+	// the referrer pushed before
+	_, otherDesc := generateArtifact("application/vnd.other")
+	otherIndexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{otherDesc},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	otherIndexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, otherIndexJSON)
+
+	// a stateful registry without the Referrers API, not supporting ETag
+	var lock sync.Mutex
+	manifests := map[string][]byte{
+		otherIndexDesc.Digest.String(): otherIndexJSON,
+		referrersTag:                   otherIndexJSON,
+	}
+	var indexReads int
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch {
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v2/test/manifests/"):
+			if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+				t.Errorf("unexpected If-Match header: %s", ifMatch)
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			dgst := digest.FromBytes(buf.Bytes())
+			manifests[dgst.String()] = buf.Bytes()
+			manifests[strings.TrimPrefix(r.URL.Path, "/v2/test/manifests/")] = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", dgst.String())
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+subjectDesc.Digest.String():
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+			indexReads++
+			manifestJSON, ok := manifests[referrersTag]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifestJSON).String())
+			if _, err := w.Write(manifestJSON); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v2/test/manifests/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, "/v2/test/manifests/"))
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+	if err := repo.Manifests().Push(ctx, artifactDesc, bytes.NewReader(artifactJSON)); err != nil {
+		t.Fatalf("Manifests.Push() error = %v", err)
+	}
+
+	// the referrer is added to the existing index
+	var index ocispec.Index
+	if err := json.Unmarshal(manifests[referrersTag], &index); err != nil {
+		t.Fatalf("failed to decode referrers index: %v", err)
+	}
+	want := []ocispec.Descriptor{otherDesc, artifactDesc}
+	if !reflect.DeepEqual(index.Manifests, want) {
+		t.Errorf("referrers index = %v, want %v", index.Manifests, want)
+	}
+	// the index is read once without validation reads around the push
+	if indexReads != 1 {
+		t.Errorf("referrers index read %d times, want 1", indexReads)
+	}
+	// the index replaced is garbage collected
+	if want := []string{otherIndexDesc.Digest.String()}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+}
+
+func Test_ManifestStore_Push_ReferrersAPIUnavailable_PushIndexFailure(t *testing.T) {
+	// generate test content
+	subject := []byte(`{"layers":[]}`)
+	subjectDesc := content.NewDescriptorFromBytes(spec.MediaTypeArtifactManifest, subject)
+	referrersTag := strings.Replace(subjectDesc.Digest.String(), ":", "-", 1)
+	generateArtifact := func(artifactType string) ([]byte, ocispec.Descriptor) {
+		artifact := spec.Artifact{
+			MediaType:    spec.MediaTypeArtifactManifest,
+			Subject:      &subjectDesc,
+			ArtifactType: artifactType,
+		}
+		artifactJSON, err := json.Marshal(artifact)
+		if err != nil {
+			t.Fatalf("failed to marshal manifest: %v", err)
+		}
+		artifactDesc := content.NewDescriptorFromBytes(artifact.MediaType, artifactJSON)
+		artifactDesc.ArtifactType = artifact.ArtifactType
+		return artifactJSON, artifactDesc
+	}
+	artifactJSON, artifactDesc := generateArtifact("application/vnd.test")
+	_, oldDesc := generateArtifact("application/vnd.old")
+	oldIndexJSON, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+		},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{oldDesc},
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	oldIndexDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageIndex, oldIndexJSON)
+
+	tests := []struct {
+		name      string
+		pushIndex func(w http.ResponseWriter)
+	}{
+		{
+			name: "server error",
+			pushIndex: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+		},
+		{
+			name: "digest mismatch",
+			pushIndex: func(w http.ResponseWriter) {
+				// the registry stores content other than the new index
+				w.Header().Set("Docker-Content-Digest", oldIndexDesc.Digest.String())
+				w.WriteHeader(http.StatusCreated)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotManifest []byte
+			var indexDeleted bool
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+artifactDesc.Digest.String():
+					buf := bytes.NewBuffer(nil)
+					if _, err := buf.ReadFrom(r.Body); err != nil {
+						t.Errorf("fail to read: %v", err)
+					}
+					gotManifest = buf.Bytes()
+					w.Header().Set("Docker-Content-Digest", artifactDesc.Digest.String())
+					w.WriteHeader(http.StatusCreated)
+				case r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+subjectDesc.Digest.String():
+					w.WriteHeader(http.StatusNotFound)
+				case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+					w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+					w.Header().Set("Docker-Content-Digest", oldIndexDesc.Digest.String())
+					if _, err := w.Write(oldIndexJSON); err != nil {
+						t.Errorf("failed to write response: %v", err)
+					}
+				case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+referrersTag:
+					tt.pushIndex(w)
+				case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/manifests/"+oldIndexDesc.Digest.String():
+					indexDeleted = true
+					w.WriteHeader(http.StatusAccepted)
+				default:
+					t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			ctx := context.Background()
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+
+			err = repo.Push(ctx, artifactDesc, bytes.NewReader(artifactJSON))
+			if err == nil {
+				t.Fatal("Manifests.Push() error = nil, wantErr true")
+			}
+			var re *ReferrersError
+			if errors.As(err, &re) && re.IsReferrersIndexDelete() {
+				t.Errorf("Manifests.Push() error = %v, want a failure of pushing the referrers index", err)
+			}
+			if !bytes.Equal(gotManifest, artifactJSON) {
+				t.Errorf("Manifests.Push() = %v, want %v", string(gotManifest), string(artifactJSON))
+			}
+			if indexDeleted {
+				t.Error("the old referrers index is deleted while the new one is not pushed")
+			}
+		})
 	}
-	indexManifestDesc := content.NewDescriptorFromBytes(indexManifest.MediaType, indexManifestJSON)
-	indexManifestDesc.ArtifactType = indexManifest.ArtifactType
-	indexManifestDesc.Annotations = indexManifest.Annotations
-	index_3 := ocispec.Index{
+}
+
+func Test_ManifestStore_Push_ReferrersAPIUnavailable_ReferrerAnnotations(t *testing.T) {
+	// generate test content
+	subject := []byte(`{"layers":[]}`)
+	subjectDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, subject)
+	referrersTag := strings.Replace(subjectDesc.Digest.String(), ":", "-", 1)
+	const internalAnnotation = "com.example.internal"
+	manifest := ocispec.Manifest{
 		Versioned: specs.Versioned{
 			SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
 		},
-		MediaType: ocispec.MediaTypeImageIndex,
-		Manifests: []ocispec.Descriptor{
-			artifactDesc,
-			manifestDesc,
-			indexManifestDesc,
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.test",
+		Config:       ocispec.DescriptorEmptyJSON,
+		Layers:       []ocispec.Descriptor{ocispec.DescriptorEmptyJSON},
+		Subject:      &subjectDesc,
+		Annotations: map[string]string{
+			internalAnnotation:        "secret",
+			ocispec.AnnotationCreated: "2000-01-01T00:00:00Z",
 		},
 	}
-	indexJSON_3, err := json.Marshal(index_3)
+	manifestJSON, err := json.Marshal(manifest)
 	if err != nil {
 		t.Fatalf("failed to marshal manifest: %v", err)
 	}
-	indexDesc_3 := content.NewDescriptorFromBytes(index_3.MediaType, indexJSON_3)
-	indexDeleted = false
-	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	manifestDesc := content.NewDescriptorFromBytes(manifest.MediaType, manifestJSON)
+
+	var gotManifest, gotIndex []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch {
-		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+indexManifestDesc.Digest.String():
-			if contentType := r.Header.Get("Content-Type"); contentType != indexManifestDesc.MediaType {
-				w.WriteHeader(http.StatusBadRequest)
-				break
-			}
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+manifestDesc.Digest.String():
 			buf := bytes.NewBuffer(nil)
 			if _, err := buf.ReadFrom(r.Body); err != nil {
 				t.Errorf("fail to read: %v", err)
 			}
 			gotManifest = buf.Bytes()
-			w.Header().Set("Docker-Content-Digest", indexManifestDesc.Digest.String())
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
 			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+subjectDesc.Digest.String():
+			w.WriteHeader(http.StatusNotFound)
 		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+referrersTag:
-			w.Write(indexJSON_2)
+			w.WriteHeader(http.StatusNotFound)
 		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+referrersTag:
-			if contentType := r.Header.Get("Content-Type"); contentType != ocispec.MediaTypeImageIndex {
-				w.WriteHeader(http.StatusBadRequest)
-				break
-			}
 			buf := bytes.NewBuffer(nil)
 			if _, err := buf.ReadFrom(r.Body); err != nil {
 				t.Errorf("fail to read: %v", err)
 			}
-			gotReferrerIndex = buf.Bytes()
-			w.Header().Set("Docker-Content-Digest", indexDesc_3.Digest.String())
+			gotIndex = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(gotIndex).String())
 			w.WriteHeader(http.StatusCreated)
-		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/manifests/"+indexDesc_2.Digest.String():
-			indexDeleted = true
-			// no "Docker-Content-Digest" header for manifest deletion
-			w.WriteHeader(http.StatusAccepted)
 		default:
 			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
 			w.WriteHeader(http.StatusNotFound)
 		}
 	}))
 	defer ts.Close()
-	uri, err = url.Parse(ts.URL)
+	uri, err := url.Parse(ts.URL)
 	if err != nil {
 		t.Fatalf("invalid test http server: %v", err)
 	}
 
-	ctx = context.Background()
-	repo, err = NewRepository(uri.Host + "/test")
+	ctx := context.Background()
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
 	repo.PlainHTTP = true
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	repo.ReferrerAnnotations = func(referrer ocispec.Descriptor) map[string]string {
+		if referrer.Digest != manifestDesc.Digest {
+			t.Errorf("ReferrerAnnotations() referrer = %v, want %v", referrer.Digest, manifestDesc.Digest)
+		}
+		delete(referrer.Annotations, internalAnnotation)
+		return referrer.Annotations
 	}
-	err = repo.Push(ctx, indexManifestDesc, bytes.NewReader(indexManifestJSON))
-	if err != nil {
+
+	if err := repo.Push(ctx, manifestDesc, bytes.NewReader(manifestJSON)); err != nil {
 		t.Fatalf("Manifests.Push() error = %v", err)
 	}
-	if !bytes.Equal(gotManifest, indexManifestJSON) {
-		t.Errorf("Manifests.Push() = %v, want %v", string(gotManifest), string(indexManifestJSON))
+	if !bytes.Equal(gotManifest, manifestJSON) {
+		t.Errorf("Manifests.Push() = %v, want %v", string(gotManifest), string(manifestJSON))
 	}
-	if !bytes.Equal(gotReferrerIndex, indexJSON_3) {
-		t.Errorf("got referrers index = %v, want %v", string(gotReferrerIndex), string(indexJSON_3))
+	var index ocispec.Index
+	if err := json.Unmarshal(gotIndex, &index); err != nil {
+		t.Fatalf("failed to decode referrers index: %v", err)
 	}
-	if !indexDeleted {
-		t.Errorf("indexDeleted = %v, want %v", indexDeleted, true)
+	if len(index.Manifests) != 1 {
+		t.Fatalf("referrers index manifests = %v, want 1 entry", index.Manifests)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateUnsupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnsupported)
+	wantAnnotations := map[string]string{
+		ocispec.AnnotationCreated: "2000-01-01T00:00:00Z",
+	}
+	if got := index.Manifests[0].Annotations; !reflect.DeepEqual(got, wantAnnotations) {
+		t.Errorf("referrers index entry annotations = %v, want %v", got, wantAnnotations)
+	}
+	if got := index.Manifests[0].ArtifactType; got != manifest.ArtifactType {
+		t.Errorf("referrers index entry artifactType = %v, want %v", got, manifest.ArtifactType)
+	}
+	if got := manifest.Annotations[internalAnnotation]; got != "secret" {
+		t.Errorf("manifest annotation %s = %q, want %q", internalAnnotation, got, "secret")
 	}
 }
 
@@ -5783,6 +8676,152 @@ func Test_ManifestStore_FetchReference(t *testing.T) {
 	}
 }
 
+func Test_ManifestStore_FetchReference_AcceptQualities(t *testing.T) {
+	ociManifest := []byte(`{"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+	dockerManifest := []byte(`{"mediaType":"application/vnd.docker.distribution.manifest.v2+json"}`)
+	manifests := map[string][]byte{
+		ocispec.MediaTypeImageManifest: ociManifest,
+		docker.MediaTypeManifest:       dockerManifest,
+	}
+	ref := "foobar"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/test/manifests/"+ref {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// serve the acceptable format with the highest q-value, and prefer
+		// the Docker format on ties.
+		var mediaType string
+		var quality float64
+		for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+			mt, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+			if err != nil {
+				t.Errorf("invalid Accept header %q: %v", accept, err)
+				continue
+			}
+			if _, ok := manifests[mt]; !ok {
+				continue
+			}
+			q := 1.0
+			if value, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(value, 64); err != nil {
+					t.Errorf("invalid q-value %q: %v", value, err)
+				}
+			}
+			if q > quality || (q == quality && mt == docker.MediaTypeManifest) {
+				mediaType, quality = mt, q
+			}
+		}
+		if mediaType == "" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		manifest := manifests[mediaType]
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+		if _, err := w.Write(manifest); err != nil {
+			t.Errorf("failed to write %q: %v", r.URL, err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	tests := []struct {
+		name               string
+		manifestMediaTypes []string
+		qualities          map[string]float64
+		want               string
+	}{
+		{
+			name: "default media types prefer OCI",
+			want: ocispec.MediaTypeImageManifest,
+		},
+		{
+			name:      "default media types with custom q-values",
+			qualities: map[string]float64{ocispec.MediaTypeImageManifest: 0.5},
+			want:      docker.MediaTypeManifest,
+		},
+		{
+			name:               "custom media types without q-values",
+			manifestMediaTypes: []string{ocispec.MediaTypeImageManifest, docker.MediaTypeManifest},
+			want:               docker.MediaTypeManifest,
+		},
+		{
+			name:               "custom media types with q-values",
+			manifestMediaTypes: []string{docker.MediaTypeManifest, ocispec.MediaTypeImageManifest},
+			qualities:          map[string]float64{docker.MediaTypeManifest: 0.1},
+			want:               ocispec.MediaTypeImageManifest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			repo.ManifestMediaTypes = tt.manifestMediaTypes
+			repo.ManifestMediaTypeQualities = tt.qualities
+			store := &manifestStore{repo: repo}
+			ctx := context.Background()
+
+			desc, rc, err := store.FetchReference(ctx, ref)
+			if err != nil {
+				t.Fatalf("Manifests.FetchReference() error = %v", err)
+			}
+			defer rc.Close()
+			if desc.MediaType != tt.want {
+				t.Errorf("Manifests.FetchReference() media type = %v, want %v", desc.MediaType, tt.want)
+			}
+			got, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("Manifests.FetchReference().Read() error = %v", err)
+			}
+			if !bytes.Equal(got, manifests[tt.want]) {
+				t.Errorf("Manifests.FetchReference() = %v, want %v", string(got), string(manifests[tt.want]))
+			}
+		})
+	}
+}
+
+func Test_buildAcceptHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		mediaTypes []string
+		qualities  map[string]float64
+		want       string
+	}{
+		{
+			name:       "no q-values",
+			mediaTypes: []string{"a", "b"},
+			want:       "a, b",
+		},
+		{
+			name:       "partial q-values",
+			mediaTypes: []string{"a", "b", "c"},
+			qualities:  map[string]float64{"b": 0.5, "d": 0.1},
+			want:       "a, b;q=0.5, c",
+		},
+		{
+			name:       "rounded and clamped q-values",
+			mediaTypes: []string{"a", "b", "c"},
+			qualities:  map[string]float64{"a": 0.12345, "b": 2, "c": -1},
+			want:       "a;q=0.123, b;q=1, c;q=0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildAcceptHeader(tt.mediaTypes, tt.qualities); got != tt.want {
+				t.Errorf("buildAcceptHeader() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_ManifestStore_Tag(t *testing.T) {
 	blob := []byte("hello world")
 	blobDesc := ocispec.Descriptor{
@@ -6819,6 +9858,143 @@ func TestRepository_Tags_WithLastParam(t *testing.T) {
 	}
 }
 
+func TestRepository_TagsWithFilter(t *testing.T) {
+	tagSet := []string{"latest", "v0.1", "v1.0", "v1.1", "v1.1-rc", "v2.0"}
+	var supportPrefix bool
+	var gotPrefixes []string
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v2/test/tags/list" {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		q := r.URL.Query()
+		gotPrefixes = append(gotPrefixes, q.Get("prefix"))
+		tags := tagSet
+		if prefix := q.Get("prefix"); supportPrefix && prefix != "" {
+			tags = slices.DeleteFunc(slices.Clone(tags), func(tag string) bool {
+				return !strings.HasPrefix(tag, prefix)
+			})
+			w.Header().Set("OCI-Filters-Applied", "prefix")
+		}
+		// serve 2 tags per page
+		var offset int
+		if last := q.Get("last"); last != "" {
+			offset = indexOf(last, tags) + 1
+		}
+		end := min(offset+2, len(tags))
+		if end < len(tags) {
+			next := url.Values{
+				"n":    []string{"2"},
+				"last": []string{tags[end-1]},
+			}
+			w.Header().Set("Link", fmt.Sprintf(`<%s/v2/test/tags/list?%s>; rel="next"`, ts.URL, next.Encode()))
+		}
+		result := struct {
+			Tags []string `json:"tags"`
+		}{
+			Tags: tags[offset:end],
+		}
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.TagListPageSize = 2
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		supportPrefix bool
+		filter        TagsFilter
+		want          [][]string
+		wantPrefixes  []string
+	}{
+		{
+			name:          "prefix filtered on the server side",
+			supportPrefix: true,
+			filter:        TagsFilter{Prefix: "v1."},
+			want:          [][]string{{"v1.0", "v1.1"}, {"v1.1-rc"}},
+			wantPrefixes:  []string{"v1.", "v1."},
+		},
+		{
+			name:         "prefix filtered on the client side",
+			filter:       TagsFilter{Prefix: "v1."},
+			want:         [][]string{{"v1.0", "v1.1"}, {"v1.1-rc"}},
+			wantPrefixes: []string{"v1.", "v1.", "v1."},
+		},
+		{
+			name:         "pattern filtered on the client side",
+			filter:       TagsFilter{Pattern: "v*.?"},
+			want:         [][]string{{"v0.1"}, {"v1.0", "v1.1"}, {"v2.0"}},
+			wantPrefixes: []string{"", "", ""},
+		},
+		{
+			name:          "prefix and pattern",
+			supportPrefix: true,
+			filter:        TagsFilter{Prefix: "v1.", Pattern: "*-rc"},
+			want:          [][]string{{"v1.1-rc"}},
+			wantPrefixes:  []string{"v1.", "v1."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supportPrefix = tt.supportPrefix
+			gotPrefixes = nil
+			var got [][]string
+			if err := repo.TagsWithFilter(ctx, "", tt.filter, func(tags []string) error {
+				got = append(got, tags)
+				return nil
+			}); err != nil {
+				t.Fatalf("Repository.TagsWithFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Repository.TagsWithFilter() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(gotPrefixes, tt.wantPrefixes) {
+				t.Errorf("prefix query = %q, want %q", gotPrefixes, tt.wantPrefixes)
+			}
+		})
+	}
+
+	// last is honored along with the filter
+	supportPrefix = false
+	var got [][]string
+	if err := repo.TagsWithFilter(ctx, "v1.0", TagsFilter{Prefix: "v1."}, func(tags []string) error {
+		got = append(got, tags)
+		return nil
+	}); err != nil {
+		t.Fatalf("Repository.TagsWithFilter() error = %v", err)
+	}
+	if want := [][]string{{"v1.1", "v1.1-rc"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.TagsWithFilter() = %v, want %v", got, want)
+	}
+
+	// malformed patterns are rejected
+	gotPrefixes = nil
+	err = repo.TagsWithFilter(ctx, "", TagsFilter{Pattern: "v1.["}, func(tags []string) error {
+		t.Errorf("unexpected tags: %v", tags)
+		return nil
+	})
+	if !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("Repository.TagsWithFilter() error = %v, wantErr %v", err, path.ErrBadPattern)
+	}
+	if len(gotPrefixes) != 0 {
+		t.Errorf("unexpected requests: %d", len(gotPrefixes))
+	}
+}
+
 func TestRepository_ParseReference(t *testing.T) {
 	type args struct {
 		reference string
@@ -7062,34 +10238,219 @@ func TestRepository_ParseReference(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("Repository.ParseReference() = %v, want %v", got, tt.want)
 			}
-		})
+		})
+	}
+}
+
+func TestRepository_SetReferrersCapability(t *testing.T) {
+	repo, err := NewRepository("registry.example.com/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	// initial state
+	if state := repo.loadReferrersState(); state != referrersStateUnknown {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	}
+
+	// valid first time set
+	if err := repo.SetReferrersCapability(true); err != nil {
+		t.Errorf("Repository.SetReferrersCapability() error = %v", err)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	}
+
+	// invalid second time set, state should be no changed
+	if err := repo.SetReferrersCapability(false); !errors.Is(err, ErrReferrersCapabilityAlreadySet) {
+		t.Errorf("Repository.SetReferrersCapability() error = %v, wantErr %v", err, ErrReferrersCapabilityAlreadySet)
+	}
+	if state := repo.loadReferrersState(); state != referrersStateSupported {
+		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	}
+}
+
+func TestRepository_CustomManifestMediaType(t *testing.T) {
+	const customMediaType = "application/vnd.example.wasm.manifest.v1+json"
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: customMediaType,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	ref := "latest"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/test/manifests/" + ref, "/v2/test/manifests/" + manifestDesc.Digest.String():
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		accept := r.Header.Get("Accept")
+		if !strings.Contains(accept, customMediaType) {
+			t.Errorf("Accept header = %q, want %q included", accept, customMediaType)
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Type", customMediaType)
+		w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+		w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+		if r.Method == http.MethodGet {
+			if _, err := w.Write(manifest); err != nil {
+				t.Errorf("failed to write response: %v", err)
+			}
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	repo.ManifestMediaTypes = append(DefaultManifestMediaTypes(), customMediaType)
+	ctx := context.Background()
+
+	got, err := repo.Resolve(ctx, ref)
+	if err != nil {
+		t.Fatalf("Repository.Resolve() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, manifestDesc) {
+		t.Errorf("Repository.Resolve() = %v, want %v", got, manifestDesc)
+	}
+	gotContent, err := content.FetchAll(ctx, repo, manifestDesc)
+	if err != nil {
+		t.Fatalf("Repository.Fetch() error = %v", err)
+	}
+	if !bytes.Equal(gotContent, manifest) {
+		t.Errorf("Repository.Fetch() = %v, want %v", gotContent, manifest)
+	}
+
+	// the default manifest media types are not modified
+	if slices.Contains(DefaultManifestMediaTypes(), customMediaType) {
+		t.Errorf("DefaultManifestMediaTypes() = %v, want %q excluded", DefaultManifestMediaTypes(), customMediaType)
 	}
 }
 
-func TestRepository_SetReferrersCapability(t *testing.T) {
-	repo, err := NewRepository("registry.example.com/test")
+func TestRepository_ProbeManifestMediaTypes(t *testing.T) {
+	probePath := "/v2/test/manifests/" + digest.FromBytes([]byte("{}")).String()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != probePath {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Header.Get("Content-Type") {
+		case docker.MediaTypeManifestList:
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		case spec.MediaTypeArtifactManifest:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"code":"UNSUPPORTED","message":"unsupported manifest type"}]}`))
+		case "application/vnd.test.failure":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"code":"MANIFEST_INVALID","message":"manifest invalid"}]}`))
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
 	if err != nil {
 		t.Fatalf("NewRepository() error = %v", err)
 	}
-	// initial state
-	if state := repo.loadReferrersState(); state != referrersStateUnknown {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateUnknown)
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	// probe the default manifest media types
+	got, err := repo.ProbeManifestMediaTypes(ctx)
+	if err != nil {
+		t.Fatalf("Repository.ProbeManifestMediaTypes() error = %v", err)
+	}
+	want := []string{
+		ocispec.MediaTypeImageManifest,
+		ocispec.MediaTypeImageIndex,
+		docker.MediaTypeManifest,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.ProbeManifestMediaTypes() = %v, want %v", got, want)
 	}
 
-	// valid first time set
-	if err := repo.SetReferrersCapability(true); err != nil {
-		t.Errorf("Repository.SetReferrersCapability() error = %v", err)
+	// probe the given manifest media types
+	got, err = repo.ProbeManifestMediaTypes(ctx, docker.MediaTypeManifestList, ocispec.MediaTypeImageIndex)
+	if err != nil {
+		t.Fatalf("Repository.ProbeManifestMediaTypes() error = %v", err)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+	want = []string{ocispec.MediaTypeImageIndex}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.ProbeManifestMediaTypes() = %v, want %v", got, want)
 	}
 
-	// invalid second time set, state should be no changed
-	if err := repo.SetReferrersCapability(false); !errors.Is(err, ErrReferrersCapabilityAlreadySet) {
-		t.Errorf("Repository.SetReferrersCapability() error = %v, wantErr %v", err, ErrReferrersCapabilityAlreadySet)
+	// probe failure
+	_, err = repo.ProbeManifestMediaTypes(ctx, ocispec.MediaTypeImageManifest, "application/vnd.test.failure")
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Repository.ProbeManifestMediaTypes() error = %v, want status code %d", err, http.StatusInternalServerError)
 	}
-	if state := repo.loadReferrersState(); state != referrersStateSupported {
-		t.Errorf("Repository.loadReferrersState() = %v, want %v", state, referrersStateSupported)
+}
+
+func TestRepository_ProbeManifestMediaTypes_Accepted(t *testing.T) {
+	probeDigest := digest.FromBytes([]byte("{}")).String()
+	probePath := "/v2/test/manifests/" + probeDigest
+	var deleted []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != probePath {
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("Content-Type") == docker.MediaTypeManifestList {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", probeDigest)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodDelete:
+			deleted = append(deleted, probeDigest)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	got, err := repo.ProbeManifestMediaTypes(ctx, ocispec.MediaTypeImageManifest, docker.MediaTypeManifestList)
+	if err != nil {
+		t.Fatalf("Repository.ProbeManifestMediaTypes() error = %v", err)
+	}
+	if want := []string{ocispec.MediaTypeImageManifest}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Repository.ProbeManifestMediaTypes() = %v, want %v", got, want)
+	}
+	// the probe manifest accepted is deleted
+	if want := []string{probeDigest}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
 	}
 }
 
@@ -7794,11 +11155,157 @@ func TestManifestStore_generateDescriptor(t *testing.T) {
 			wantErr:        true,
 		},
 		{
-			name: "bad Docker-Content-Digest",
+			name: "bad Docker-Content-Digest",
+			resp: &http.Response{
+				Header: http.Header{
+					"Content-Type":          []string{mediaType},
+					"Docker-Content-Digest": []string{"not-a-digest"},
+				},
+				ContentLength: dataSize,
+				Request: &http.Request{
+					Method: http.MethodGet,
+					URL:    &url.URL{Path: "/test"},
+				},
+			},
+			ref: registry.Reference{
+				Registry:   "registry.example.com",
+				Repository: "hello-world",
+				Reference:  dataDigest.String(),
+			},
+			httpMethod:     http.MethodGet,
+			wantDescriptor: ocispec.Descriptor{},
+			wantErr:        true,
+		},
+		{
+			name: "resp with body, missing Docker-Content-Digest",
+			resp: &http.Response{
+				Header: http.Header{
+					"Content-Type": []string{mediaType},
+				},
+				ContentLength: dataSize,
+				Request: &http.Request{
+					Method: http.MethodGet,
+					URL:    &url.URL{Path: "/test"},
+				},
+				Body: io.NopCloser(bytes.NewReader(data)),
+			},
+			ref: registry.Reference{
+				Registry:   "registry.example.com",
+				Repository: "hello-world",
+				Reference:  dataDigest.String(),
+			},
+			httpMethod: http.MethodGet,
+			wantDescriptor: ocispec.Descriptor{
+				MediaType: mediaType,
+				Digest:    dataDigest,
+				Size:      dataSize,
+			},
+			wantErr: false,
+		},
+		{
+			name: "failed to read resp with body, missing Docker-Content-Digest",
+			resp: &http.Response{
+				Header: http.Header{
+					"Content-Type": []string{mediaType},
+				},
+				ContentLength: dataSize,
+				Request: &http.Request{
+					Method: http.MethodGet,
+					URL:    &url.URL{Path: "/test"},
+				},
+				Body: &badReader{},
+			},
+			ref: registry.Reference{
+				Registry:   "registry.example.com",
+				Repository: "hello-world",
+				Reference:  dataDigest.String(),
+			},
+			httpMethod:     http.MethodGet,
+			wantDescriptor: ocispec.Descriptor{},
+			wantErr:        true,
+		},
+		{
+			name: "digest mismatch",
+			resp: &http.Response{
+				Header: http.Header{
+					"Content-Type":          []string{mediaType},
+					"Docker-Content-Digest": []string{string(dataDigest)},
+				},
+				ContentLength: dataSize,
+				Request: &http.Request{
+					Method: http.MethodGet,
+					URL:    &url.URL{Path: "/test"},
+				},
+			},
+			ref: registry.Reference{
+				Registry:   "registry.example.com",
+				Repository: "hello-world",
+				Reference:  string(digest.FromBytes([]byte("whatever"))),
+			},
+			httpMethod:     http.MethodGet,
+			wantDescriptor: ocispec.Descriptor{},
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &manifestStore{
+				repo: &Repository{
+					MaxMetadataBytes: 1024,
+				},
+			}
+			got, err := s.generateDescriptor(tt.resp, tt.ref, tt.httpMethod)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("generateDescriptor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.wantDescriptor) {
+				t.Errorf("generateDescriptor() = %v, want %v", got, tt.wantDescriptor)
+			}
+		})
+	}
+}
+
+func Test_generateBlobDescriptor(t *testing.T) {
+	data := []byte("test")
+	dataSize := int64(len(data))
+	dataDigest := digest.FromBytes(data)
+	mediaType := "application/vnd.test"
+
+	tests := []struct {
+		name           string
+		resp           *http.Response
+		refDigest      digest.Digest
+		wantDescriptor ocispec.Descriptor
+		wantErr        bool
+	}{
+		{
+			name: "valid response with Content-Type and Docker-Content-Digest",
+			resp: &http.Response{
+				Header: http.Header{
+					"Content-Type":          []string{mediaType},
+					"Docker-Content-Digest": []string{dataDigest.String()},
+				},
+				ContentLength: dataSize,
+				Request: &http.Request{
+					Method: http.MethodGet,
+					URL:    &url.URL{Path: "/test"},
+				},
+			},
+			refDigest: dataDigest,
+			wantDescriptor: ocispec.Descriptor{
+				MediaType: mediaType,
+				Digest:    dataDigest,
+				Size:      dataSize,
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing Content-Type",
 			resp: &http.Response{
 				Header: http.Header{
-					"Content-Type":          []string{mediaType},
-					"Docker-Content-Digest": []string{"not-a-digest"},
+					"Docker-Content-Digest": []string{dataDigest.String()},
 				},
 				ContentLength: dataSize,
 				Request: &http.Request{
@@ -7806,60 +11313,66 @@ func TestManifestStore_generateDescriptor(t *testing.T) {
 					URL:    &url.URL{Path: "/test"},
 				},
 			},
-			ref: registry.Reference{
-				Registry:   "registry.example.com",
-				Repository: "hello-world",
-				Reference:  dataDigest.String(),
+			refDigest: dataDigest,
+			wantDescriptor: ocispec.Descriptor{
+				MediaType: "application/octet-stream",
+				Digest:    dataDigest,
+				Size:      dataSize,
 			},
-			httpMethod:     http.MethodGet,
-			wantDescriptor: ocispec.Descriptor{},
-			wantErr:        true,
+			wantErr: false,
 		},
 		{
-			name: "resp with body, missing Docker-Content-Digest",
+			name: "invalid Content-Type",
 			resp: &http.Response{
 				Header: http.Header{
-					"Content-Type": []string{mediaType},
+					"Content-Type":          []string{"invalid content type"},
+					"Docker-Content-Digest": []string{dataDigest.String()},
 				},
 				ContentLength: dataSize,
 				Request: &http.Request{
 					Method: http.MethodGet,
 					URL:    &url.URL{Path: "/test"},
 				},
-				Body: io.NopCloser(bytes.NewReader(data)),
 			},
-			ref: registry.Reference{
-				Registry:   "registry.example.com",
-				Repository: "hello-world",
-				Reference:  dataDigest.String(),
-			},
-			httpMethod: http.MethodGet,
+			refDigest: dataDigest,
 			wantDescriptor: ocispec.Descriptor{
-				MediaType: mediaType,
+				MediaType: "application/octet-stream",
 				Digest:    dataDigest,
 				Size:      dataSize,
 			},
 			wantErr: false,
 		},
 		{
-			name: "failed to read resp with body, missing Docker-Content-Digest",
+			name: "unknown Content-Length",
 			resp: &http.Response{
 				Header: http.Header{
-					"Content-Type": []string{mediaType},
+					"Content-Type":          []string{mediaType},
+					"Docker-Content-Digest": []string{dataDigest.String()},
 				},
-				ContentLength: dataSize,
+				ContentLength: -1,
 				Request: &http.Request{
 					Method: http.MethodGet,
 					URL:    &url.URL{Path: "/test"},
 				},
-				Body: &badReader{},
 			},
-			ref: registry.Reference{
-				Registry:   "registry.example.com",
-				Repository: "hello-world",
-				Reference:  dataDigest.String(),
+			refDigest:      dataDigest,
+			wantDescriptor: ocispec.Descriptor{},
+			wantErr:        true,
+		},
+		{
+			name: "bad Docker-Content-Digest",
+			resp: &http.Response{
+				Header: http.Header{
+					"Content-Type":          []string{mediaType},
+					"Docker-Content-Digest": []string{"not-a-digest"},
+				},
+				ContentLength: dataSize,
+				Request: &http.Request{
+					Method: http.MethodGet,
+					URL:    &url.URL{Path: "/test"},
+				},
 			},
-			httpMethod:     http.MethodGet,
+			refDigest:      dataDigest,
 			wantDescriptor: ocispec.Descriptor{},
 			wantErr:        true,
 		},
@@ -7876,12 +11389,7 @@ func TestManifestStore_generateDescriptor(t *testing.T) {
 					URL:    &url.URL{Path: "/test"},
 				},
 			},
-			ref: registry.Reference{
-				Registry:   "registry.example.com",
-				Repository: "hello-world",
-				Reference:  string(digest.FromBytes([]byte("whatever"))),
-			},
-			httpMethod:     http.MethodGet,
+			refDigest:      digest.FromBytes([]byte("mismatch")),
 			wantDescriptor: ocispec.Descriptor{},
 			wantErr:        true,
 		},
@@ -7889,161 +11397,501 @@ func TestManifestStore_generateDescriptor(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := &manifestStore{
-				repo: &Repository{
-					MaxMetadataBytes: 1024,
-				},
-			}
-			got, err := s.generateDescriptor(tt.resp, tt.ref, tt.httpMethod)
+			got, err := generateBlobDescriptor(tt.resp, tt.refDigest)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("generateDescriptor() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("generateBlobDescriptor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.wantDescriptor) {
+				t.Errorf("generateBlobDescriptor() = %v, want %v", got, tt.wantDescriptor)
+			}
+		})
+	}
+}
+
+func Test_ManifestStore_FallbackToDockerMediaTypes(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: docker.MediaTypeManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	ref := "foobar"
+	for _, notFoundStatus := range []int{http.StatusNotFound, http.StatusNotAcceptable} {
+		var requestCount int
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestCount++
+			if r.URL.Path != "/v2/test/manifests/"+ref {
+				t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if accept := r.Header.Get("Accept"); !strings.Contains(accept, docker.MediaTypeManifest) {
+				w.WriteHeader(notFoundStatus)
+				return
+			}
+			w.Header().Set("Content-Type", manifestDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(int(manifestDesc.Size)))
+			if r.Method == http.MethodGet {
+				if _, err := w.Write(manifest); err != nil {
+					t.Errorf("failed to write %q: %v", r.URL, err)
+				}
+			}
+		}))
+		defer ts.Close()
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+
+		repo, err := NewRepository(uri.Host + "/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		repo.ManifestMediaTypes = []string{ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex}
+		store := repo.Manifests()
+		ctx := context.Background()
+
+		// fallback disabled
+		if _, err := store.Resolve(ctx, ref); err == nil {
+			t.Errorf("Manifests.Resolve() error = %v, wantErr %v", err, true)
+		}
+		if _, _, err := store.(registry.ReferenceFetcher).FetchReference(ctx, ref); err == nil {
+			t.Errorf("Manifests.FetchReference() error = %v, wantErr %v", err, true)
+		}
+
+		// fallback enabled
+		repo.FallbackToDockerMediaTypes = true
+		requestCount = 0
+		got, err := store.Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("Manifests.Resolve() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, manifestDesc) {
+			t.Errorf("Manifests.Resolve() = %v, want %v", got, manifestDesc)
+		}
+		if want := 2; requestCount != want {
+			t.Errorf("Manifests.Resolve() request count = %d, want %d", requestCount, want)
+		}
+
+		got, rc, err := store.(registry.ReferenceFetcher).FetchReference(ctx, ref)
+		if err != nil {
+			t.Fatalf("Manifests.FetchReference() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, manifestDesc) {
+			t.Errorf("Manifests.FetchReference() = %v, want %v", got, manifestDesc)
+		}
+		buf := bytes.NewBuffer(nil)
+		if _, err := buf.ReadFrom(rc); err != nil {
+			t.Errorf("fail to read: %v", err)
+		}
+		if err := rc.Close(); err != nil {
+			t.Errorf("Manifests.FetchReference().Close() error = %v", err)
+		}
+		if got := buf.Bytes(); !bytes.Equal(got, manifest) {
+			t.Errorf("Manifests.FetchReference() = %v, want %v", got, manifest)
+		}
+
+		// no fallback if Docker media types are accepted
+		repo.ManifestMediaTypes = nil
+		requestCount = 0
+		if _, err := store.Resolve(ctx, ref); err != nil {
+			t.Fatalf("Manifests.Resolve() error = %v", err)
+		}
+		if want := 1; requestCount != want {
+			t.Errorf("Manifests.Resolve() request count = %d, want %d", requestCount, want)
+		}
+	}
+}
+
+func Test_ManifestStore_FallbackToGET(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	ref := "foobar"
+	var headCount, getCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/test/manifests/" + manifestDesc.Digest.String(),
+			"/v2/test/manifests/" + ref:
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodHead:
+			headCount++
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case http.MethodGet:
+			getCount++
+			w.Header().Set("Content-Type", manifestDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			if _, err := w.Write(manifest); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("fallback disabled", func(t *testing.T) {
+		repo, err := NewRepository(uri.Host + "/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		headCount, getCount = 0, 0
+		if _, err := repo.Manifests().Resolve(ctx, ref); err == nil {
+			t.Errorf("Manifests.Resolve() error = %v, wantErr %v", err, true)
+		}
+		if _, err := repo.Exists(ctx, manifestDesc); err == nil {
+			t.Errorf("Repository.Exists() error = %v, wantErr %v", err, true)
+		}
+		if headCount != 2 || getCount != 0 {
+			t.Errorf("count(HEAD) = %d, count(GET) = %d, want 2 and 0", headCount, getCount)
+		}
+	})
+
+	t.Run("fallback enabled", func(t *testing.T) {
+		repo, err := NewRepository(uri.Host + "/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		repo.FallbackToGET = true
+		headCount, getCount = 0, 0
+
+		got, err := repo.Manifests().Resolve(ctx, ref)
+		if err != nil {
+			t.Fatalf("Manifests.Resolve() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, manifestDesc) {
+			t.Errorf("Manifests.Resolve() = %v, want %v", got, manifestDesc)
+		}
+		if headCount != 1 || getCount != 1 {
+			t.Errorf("count(HEAD) = %d, count(GET) = %d, want 1 and 1", headCount, getCount)
+		}
+
+		// HEAD is no longer attempted
+		exists, err := repo.Exists(ctx, manifestDesc)
+		if err != nil {
+			t.Fatalf("Repository.Exists() error = %v", err)
+		}
+		if !exists {
+			t.Errorf("Repository.Exists() = %v, want %v", exists, true)
+		}
+		if headCount != 1 || getCount != 2 {
+			t.Errorf("count(HEAD) = %d, count(GET) = %d, want 1 and 2", headCount, getCount)
+		}
+	})
+}
+
+func TestRepository_ReferrersWithFilter_PartialServerFiltering(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	// the server filters by artifactType only
+	referrers := []ocispec.Descriptor{
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test",
+			Annotations:  map[string]string{"foo": "bar"},
+		},
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         2,
+			Digest:       digest.FromString("2"),
+			ArtifactType: "application/vnd.test",
+			Annotations:  map[string]string{"foo": "baz"},
+		},
+		{
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         3,
+			Digest:       digest.FromString("3"),
+			ArtifactType: "application/vnd.test",
+		},
+	}
+	filter := ReferrersFilter{
+		ArtifactType: "application/vnd.test",
+		Annotations:  map[string]string{"foo": "bar"},
+	}
+
+	for _, applied := range []string{"artifactType", "artifactType,annotation"} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+			if r.Method != http.MethodGet || r.URL.Path != path {
+				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.wantDescriptor) {
-				t.Errorf("generateDescriptor() = %v, want %v", got, tt.wantDescriptor)
+			q := r.URL.Query()
+			if got, want := q.Get("artifactType"), filter.ArtifactType; got != want {
+				t.Errorf("artifactType query = %q, want %q", got, want)
+			}
+			if got, want := q["annotation"], []string{"foo=bar"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("annotation query = %q, want %q", got, want)
+			}
+			result := ocispec.Index{
+				Versioned: specs.Versioned{
+					SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+				},
+				MediaType: ocispec.MediaTypeImageIndex,
+				Manifests: referrers,
+			}
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			w.Header().Set("OCI-Filters-Applied", applied)
+			if err := json.NewEncoder(w).Encode(result); err != nil {
+				t.Errorf("failed to write response: %v", err)
 			}
-		})
+		}))
+		defer ts.Close()
+		uri, err := url.Parse(ts.URL)
+		if err != nil {
+			t.Fatalf("invalid test http server: %v", err)
+		}
+
+		repo, err := NewRepository(uri.Host + "/test")
+		if err != nil {
+			t.Fatalf("NewRepository() error = %v", err)
+		}
+		repo.PlainHTTP = true
+		ctx := context.Background()
+
+		var got []ocispec.Descriptor
+		if err := repo.ReferrersWithFilter(ctx, manifestDesc, filter, func(refs []ocispec.Descriptor) error {
+			got = append(got, refs...)
+			return nil
+		}); err != nil {
+			t.Fatalf("Repository.ReferrersWithFilter() error = %v", err)
+		}
+		want := referrers[:1]
+		if applied == "artifactType,annotation" {
+			// the server claims to have applied all filters
+			want = referrers
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Repository.ReferrersWithFilter(%s) = %v, want %v", applied, got, want)
+		}
 	}
 }
 
-func Test_generateBlobDescriptor(t *testing.T) {
-	data := []byte("test")
-	dataSize := int64(len(data))
-	dataDigest := digest.FromBytes(data)
-	mediaType := "application/vnd.test"
-
-	tests := []struct {
-		name           string
-		resp           *http.Response
-		refDigest      digest.Digest
-		wantDescriptor ocispec.Descriptor
-		wantErr        bool
-	}{
+func TestRepository_ReferrersWithFilter_MultipleArtifactTypes(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	referrers := []ocispec.Descriptor{
 		{
-			name: "valid response with Content-Type and Docker-Content-Digest",
-			resp: &http.Response{
-				Header: http.Header{
-					"Content-Type":          []string{mediaType},
-					"Docker-Content-Digest": []string{dataDigest.String()},
-				},
-				ContentLength: dataSize,
-				Request: &http.Request{
-					Method: http.MethodGet,
-					URL:    &url.URL{Path: "/test"},
-				},
-			},
-			refDigest: dataDigest,
-			wantDescriptor: ocispec.Descriptor{
-				MediaType: mediaType,
-				Digest:    dataDigest,
-				Size:      dataSize,
-			},
-			wantErr: false,
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         1,
+			Digest:       digest.FromString("1"),
+			ArtifactType: "application/vnd.test.signature",
 		},
 		{
-			name: "missing Content-Type",
-			resp: &http.Response{
-				Header: http.Header{
-					"Docker-Content-Digest": []string{dataDigest.String()},
-				},
-				ContentLength: dataSize,
-				Request: &http.Request{
-					Method: http.MethodGet,
-					URL:    &url.URL{Path: "/test"},
-				},
-			},
-			refDigest: dataDigest,
-			wantDescriptor: ocispec.Descriptor{
-				MediaType: "application/octet-stream",
-				Digest:    dataDigest,
-				Size:      dataSize,
-			},
-			wantErr: false,
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         2,
+			Digest:       digest.FromString("2"),
+			ArtifactType: "application/vnd.test.sbom",
 		},
 		{
-			name: "invalid Content-Type",
-			resp: &http.Response{
-				Header: http.Header{
-					"Content-Type":          []string{"invalid content type"},
-					"Docker-Content-Digest": []string{dataDigest.String()},
-				},
-				ContentLength: dataSize,
-				Request: &http.Request{
-					Method: http.MethodGet,
-					URL:    &url.URL{Path: "/test"},
-				},
-			},
-			refDigest: dataDigest,
-			wantDescriptor: ocispec.Descriptor{
-				MediaType: "application/octet-stream",
-				Digest:    dataDigest,
-				Size:      dataSize,
-			},
-			wantErr: false,
+			MediaType:    ocispec.MediaTypeImageManifest,
+			Size:         3,
+			Digest:       digest.FromString("3"),
+			ArtifactType: "application/vnd.test.other",
 		},
+	}
+	var gotQuery []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := "/v2/test/referrers/" + manifestDesc.Digest.String()
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		gotQuery = r.URL.Query()["artifactType"]
+		result := ocispec.Index{
+			Versioned: specs.Versioned{
+				SchemaVersion: 2, // historical value. does not pertain to OCI or docker version
+			},
+			MediaType: ocispec.MediaTypeImageIndex,
+			Manifests: filterReferrers(slices.Clone(referrers), gotQuery...),
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+		// the server claims to have applied the filter regardless
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		filter    ReferrersFilter
+		wantQuery []string
+		want      []ocispec.Descriptor
+	}{
 		{
-			name: "unknown Content-Length",
-			resp: &http.Response{
-				Header: http.Header{
-					"Content-Type":          []string{mediaType},
-					"Docker-Content-Digest": []string{dataDigest.String()},
-				},
-				ContentLength: -1,
-				Request: &http.Request{
-					Method: http.MethodGet,
-					URL:    &url.URL{Path: "/test"},
-				},
+			name: "multiple artifact types are filtered on the client side",
+			filter: ReferrersFilter{
+				ArtifactTypes: []string{"application/vnd.test.signature", "application/vnd.test.sbom"},
 			},
-			refDigest:      dataDigest,
-			wantDescriptor: ocispec.Descriptor{},
-			wantErr:        true,
+			want: referrers[:2],
 		},
 		{
-			name: "bad Docker-Content-Digest",
-			resp: &http.Response{
-				Header: http.Header{
-					"Content-Type":          []string{mediaType},
-					"Docker-Content-Digest": []string{"not-a-digest"},
-				},
-				ContentLength: dataSize,
-				Request: &http.Request{
-					Method: http.MethodGet,
-					URL:    &url.URL{Path: "/test"},
-				},
+			name: "artifact types are combined",
+			filter: ReferrersFilter{
+				ArtifactType:  "application/vnd.test.signature",
+				ArtifactTypes: []string{"application/vnd.test.other"},
 			},
-			refDigest:      dataDigest,
-			wantDescriptor: ocispec.Descriptor{},
-			wantErr:        true,
+			want: []ocispec.Descriptor{referrers[0], referrers[2]},
 		},
 		{
-			name: "digest mismatch",
-			resp: &http.Response{
-				Header: http.Header{
-					"Content-Type":          []string{mediaType},
-					"Docker-Content-Digest": []string{string(dataDigest)},
-				},
-				ContentLength: dataSize,
-				Request: &http.Request{
-					Method: http.MethodGet,
-					URL:    &url.URL{Path: "/test"},
-				},
+			name: "a single distinct artifact type is filtered on the server side",
+			filter: ReferrersFilter{
+				ArtifactType:  "application/vnd.test.sbom",
+				ArtifactTypes: []string{"application/vnd.test.sbom"},
 			},
-			refDigest:      digest.FromBytes([]byte("mismatch")),
-			wantDescriptor: ocispec.Descriptor{},
-			wantErr:        true,
+			wantQuery: []string{"application/vnd.test.sbom"},
+			want:      referrers[1:2],
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := generateBlobDescriptor(tt.resp, tt.refDigest)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("generateBlobDescriptor() error = %v, wantErr %v", err, tt.wantErr)
-				return
+			var got []ocispec.Descriptor
+			if err := repo.ReferrersWithFilter(ctx, manifestDesc, tt.filter, func(refs []ocispec.Descriptor) error {
+				got = append(got, refs...)
+				return nil
+			}); err != nil {
+				t.Fatalf("Repository.ReferrersWithFilter() error = %v", err)
 			}
-			if !reflect.DeepEqual(got, tt.wantDescriptor) {
-				t.Errorf("generateBlobDescriptor() = %v, want %v", got, tt.wantDescriptor)
+			if !reflect.DeepEqual(gotQuery, tt.wantQuery) {
+				t.Errorf("artifactType query = %q, want %q", gotQuery, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Repository.ReferrersWithFilter() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_ManifestStore_Push_FallbackToTemporaryTag(t *testing.T) {
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	tag := buildTemporaryTag(manifestDesc)
+	var gotManifest []byte
+	var digestPushCount, tagPushCount, tagDeleteCount int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+manifestDesc.Digest.String():
+			digestPushCount++
+			w.WriteHeader(http.StatusBadRequest)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+tag:
+			tagPushCount++
+			if contentType := r.Header.Get("Content-Type"); contentType != manifestDesc.MediaType {
+				w.WriteHeader(http.StatusBadRequest)
+				break
+			}
+			buf := bytes.NewBuffer(nil)
+			if _, err := buf.ReadFrom(r.Body); err != nil {
+				t.Errorf("fail to read: %v", err)
+			}
+			gotManifest = buf.Bytes()
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodDelete && r.URL.Path == "/v2/test/manifests/"+tag:
+			tagDeleteCount++
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	ctx := context.Background()
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	store := repo.Manifests()
+
+	// fallback disabled
+	err = store.Push(ctx, manifestDesc, bytes.NewReader(manifest))
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Manifests.Push() error = %v, want 400 error response", err)
+	}
+	if digestPushCount != 1 || tagPushCount != 0 {
+		t.Errorf("count(push by digest) = %d, count(push by tag) = %d, want 1 and 0", digestPushCount, tagPushCount)
+	}
+
+	// fallback enabled
+	repo.FallbackToTemporaryTag = true
+	digestPushCount = 0
+	if err := store.Push(ctx, manifestDesc, bytes.NewReader(manifest)); err != nil {
+		t.Fatalf("Manifests.Push() error = %v", err)
+	}
+	if digestPushCount != 1 || tagPushCount != 1 || tagDeleteCount != 1 {
+		t.Errorf("count(push by digest) = %d, count(push by tag) = %d, count(delete tag) = %d, want 1, 1 and 1",
+			digestPushCount, tagPushCount, tagDeleteCount)
+	}
+	if !bytes.Equal(gotManifest, manifest) {
+		t.Errorf("Manifests.Push() = %v, want %v", gotManifest, manifest)
+	}
+}
+
+func Test_buildTemporaryTag(t *testing.T) {
+	desc := ocispec.Descriptor{
+		Digest: digest.FromString("foo"),
+	}
+	want := "tmp-sha256-" + desc.Digest.Encoded()
+	if got := buildTemporaryTag(desc); got != want {
+		t.Errorf("buildTemporaryTag() = %s, want %s", got, want)
+	}
+
+	desc.Digest = digest.SHA512.FromString("foo")
+	if got := buildTemporaryTag(desc); len(got) != maxTagLength {
+		t.Errorf("len(buildTemporaryTag()) = %d, want %d", len(got), maxTagLength)
+	}
+}
@@ -30,6 +30,28 @@ func NewClient() *http.Client {
 	}
 }
 
+// ClientOptions contains parameters for [NewClientWithOptions].
+type ClientOptions struct {
+	// MaxConnsPerHost limits the total number of connections per host,
+	// including connections in the dialing, active, and idle states.
+	// Requests exceeding the limit block until a connection is available.
+	// Therefore, when copying with a concurrency (e.g.
+	// CopyGraphOptions.Concurrency) greater than MaxConnsPerHost, the copy
+	// tasks exceeding the limit wait for the connections to the same host.
+	// Zero means no limit.
+	MaxConnsPerHost int
+}
+
+// NewClientWithOptions creates an HTTP client with the default retry policy,
+// based on a clone of http.DefaultTransport configured by opts.
+func NewClientWithOptions(opts ClientOptions) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.MaxConnsPerHost = opts.MaxConnsPerHost
+	return &http.Client{
+		Transport: NewTransport(base),
+	}
+}
+
 // Transport is an HTTP transport with retry policy.
 type Transport struct {
 	// Base is the underlying HTTP transport to use.
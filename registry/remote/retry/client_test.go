@@ -17,9 +17,13 @@ package retry
 
 import (
 	"bytes"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func Test_Client(t *testing.T) {
@@ -95,3 +99,75 @@ func Test_Client(t *testing.T) {
 		})
 	}
 }
+
+// countingListener tracks the number of open connections.
+type countingListener struct {
+	net.Listener
+	open    atomic.Int32
+	maxOpen atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	open := l.open.Add(1)
+	for {
+		maxOpen := l.maxOpen.Load()
+		if open <= maxOpen || l.maxOpen.CompareAndSwap(maxOpen, open) {
+			break
+		}
+	}
+	return &countingConn{Conn: conn, listener: l}, nil
+}
+
+// countingConn decreases the count of open connections on close.
+type countingConn struct {
+	net.Conn
+	listener *countingListener
+	once     sync.Once
+}
+
+func (c *countingConn) Close() error {
+	c.once.Do(func() {
+		c.listener.open.Add(-1)
+	})
+	return c.Conn.Close()
+}
+
+func Test_NewClientWithOptions_MaxConnsPerHost(t *testing.T) {
+	const maxConns = 2
+	const requests = 8
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	listener := &countingListener{Listener: ts.Listener}
+	ts.Listener = listener
+	ts.Start()
+	defer ts.Close()
+
+	client := NewClientWithOptions(ClientOptions{MaxConnsPerHost: maxConns})
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(ts.URL)
+			if err != nil {
+				t.Errorf("Client.Get() error = %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := listener.maxOpen.Load(); got > maxConns {
+		t.Errorf("max open connections = %d, want <= %d", got, maxConns)
+	}
+	if got := listener.maxOpen.Load(); got == 0 {
+		t.Errorf("max open connections = %d, want > 0", got)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// filterTypePrefix is the "prefix" filter applied on the list of tags. It is
+// not defined by the distribution-spec, and is only applied by registries
+// supporting it.
+const filterTypePrefix = "prefix"
+
+// TagsFilter specifies the filters applied on the list of tags.
+type TagsFilter struct {
+	// Prefix filters the tags by the prefix, if not empty.
+	// The prefix filter is not defined by the distribution-spec, and is
+	// applied on the client side unless the remote registry reports it as
+	// applied in the "OCI-Filters-Applied" response header.
+	Prefix string
+
+	// Pattern filters the tags by the pattern, if not empty.
+	// The pattern syntax is the one of [path.Match], for instance, "v1.*".
+	// The pattern filter is always applied on the client side.
+	Pattern string
+}
+
+// isEmpty returns true if no filter is specified.
+func (f TagsFilter) isEmpty() bool {
+	return f.Prefix == "" && f.Pattern == ""
+}
+
+// validate checks if the filter is well-formed.
+func (f TagsFilter) validate() error {
+	if f.Pattern == "" {
+		return nil
+	}
+	if _, err := path.Match(f.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid tag pattern %q: %w", f.Pattern, err)
+	}
+	return nil
+}
+
+// apply filters tags in place by the filters that are not listed in the
+// applied filter list. The returned slice contains matching tags.
+// apply expects a validated filter.
+func (f TagsFilter) apply(tags []string, applied string) []string {
+	filterPrefix := f.Prefix != "" && !isReferrersFilterApplied(applied, filterTypePrefix)
+	if !filterPrefix && f.Pattern == "" {
+		return tags
+	}
+	var j int
+	for _, tag := range tags {
+		if filterPrefix && !strings.HasPrefix(tag, f.Prefix) {
+			continue
+		}
+		if f.Pattern != "" {
+			if matched, _ := path.Match(f.Pattern, tag); !matched {
+				continue
+			}
+		}
+		tags[j] = tag
+		j++
+	}
+	return tags[:j]
+}
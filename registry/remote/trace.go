@@ -0,0 +1,87 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	"oras.land/oras-go/v2/trace"
+)
+
+// doWithSpan sends an HTTP request within a span started by r.Tracer.
+func (r *Repository) doWithSpan(req *http.Request) (*http.Response, error) {
+	ctx, span := r.Tracer.StartSpan(req.Context(), spanName(req.Method), r.traceAttributes(req)...)
+	resp, err := r.doRequest(req.WithContext(ctx))
+	if err == nil {
+		span.SetAttributes(trace.Attribute{
+			Key:   trace.AttributeStatus,
+			Value: strconv.Itoa(resp.StatusCode),
+		})
+	}
+	span.End(err)
+	return resp, err
+}
+
+// spanName returns the name of the span for the given HTTP method.
+func spanName(method string) string {
+	switch method {
+	case http.MethodHead:
+		return trace.SpanResolve
+	case http.MethodPut, http.MethodPost, http.MethodPatch:
+		return trace.SpanPush
+	case http.MethodDelete:
+		return trace.SpanDelete
+	default:
+		return trace.SpanFetch
+	}
+}
+
+// traceAttributes returns the attributes describing the request.
+// The reference and the digest are extracted from the URL of the manifest,
+// blob, and referrers requests.
+func (r *Repository) traceAttributes(req *http.Request) []trace.Attribute {
+	attrs := []trace.Attribute{
+		{Key: trace.AttributeRegistry, Value: r.Reference.Registry},
+		{Key: trace.AttributeRepository, Value: r.Reference.Repository},
+		{Key: trace.AttributeMethod, Value: req.Method},
+	}
+
+	var reference string
+	prefix := "/v2/" + r.Reference.Repository + "/"
+	if path, ok := strings.CutPrefix(req.URL.Path, prefix); ok {
+		for _, entity := range []string{"manifests/", "blobs/", "referrers/"} {
+			if ref, ok := strings.CutPrefix(path, entity); ok && !strings.Contains(ref, "/") {
+				reference = ref
+				break
+			}
+		}
+	}
+	if reference == "" {
+		// blob upload with a digest query, such as the final PUT request
+		reference = req.URL.Query().Get("digest")
+	}
+	if reference == "" {
+		return attrs
+	}
+	attrs = append(attrs, trace.Attribute{Key: trace.AttributeReference, Value: reference})
+	if dgst, err := digest.Parse(reference); err == nil {
+		attrs = append(attrs, trace.Attribute{Key: trace.AttributeDigest, Value: dgst.String()})
+	}
+	return attrs
+}
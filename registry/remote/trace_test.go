@@ -0,0 +1,174 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/trace"
+)
+
+// testSpan records the attributes and the end of a span.
+type testSpan struct {
+	name  string
+	attrs map[string]string
+	ended bool
+	err   error
+}
+
+func (s *testSpan) SetAttributes(attrs ...trace.Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *testSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+// testTracer records the started spans.
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(ctx context.Context, name string, attrs ...trace.Attribute) (context.Context, trace.Span) {
+	span := &testSpan{
+		name:  name,
+		attrs: make(map[string]string),
+	}
+	span.SetAttributes(attrs...)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestRepository_Tracer(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifest)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/blobs/"+blobDesc.Digest.String():
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Docker-Content-Digest", blobDesc.Digest.String())
+			if _, err := w.Write(blob); err != nil {
+				t.Errorf("failed to write %q: %v", r.URL, err)
+			}
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/test/manifests/latest":
+			w.Header().Set("Content-Type", manifestDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.Header().Set("Content-Length", strconv.Itoa(int(manifestDesc.Size)))
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/"+manifestDesc.Digest.String():
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+	tracer := &testTracer{}
+	repo.Tracer = tracer
+	repo.SkipReferrersGC = true
+	ctx := context.Background()
+
+	if _, err := content.FetchAll(ctx, repo.Blobs(), blobDesc); err != nil {
+		t.Fatalf("Blobs.Fetch() error = %v", err)
+	}
+	if _, err := repo.Resolve(ctx, "latest"); err != nil {
+		t.Fatalf("Repository.Resolve() error = %v", err)
+	}
+	if err := repo.SetReferrersCapability(true); err != nil {
+		t.Fatalf("Repository.SetReferrersCapability() error = %v", err)
+	}
+	if err := repo.Push(ctx, manifestDesc, bytes.NewReader(manifest)); err != nil {
+		t.Fatalf("Repository.Push() error = %v", err)
+	}
+	if _, err := repo.Blobs().Resolve(ctx, digest.FromString("foo").String()); err == nil {
+		t.Fatalf("Blobs.Resolve() error = %v, wantErr %v", err, true)
+	}
+
+	wants := []struct {
+		name      string
+		method    string
+		reference string
+		digest    string
+		status    string
+	}{
+		{trace.SpanFetch, http.MethodGet, blobDesc.Digest.String(), blobDesc.Digest.String(), "200"},
+		{trace.SpanResolve, http.MethodHead, "latest", "", "200"},
+		{trace.SpanPush, http.MethodPut, manifestDesc.Digest.String(), manifestDesc.Digest.String(), "201"},
+		{trace.SpanResolve, http.MethodHead, digest.FromString("foo").String(), digest.FromString("foo").String(), "404"},
+	}
+	if len(tracer.spans) != len(wants) {
+		t.Fatalf("number of spans = %d, want %d", len(tracer.spans), len(wants))
+	}
+	for i, want := range wants {
+		span := tracer.spans[i]
+		if span.name != want.name {
+			t.Errorf("span[%d].name = %s, want %s", i, span.name, want.name)
+		}
+		if !span.ended {
+			t.Errorf("span[%d] not ended", i)
+		}
+		wantAttrs := map[string]string{
+			trace.AttributeRegistry:   uri.Host,
+			trace.AttributeRepository: "test",
+			trace.AttributeMethod:     want.method,
+			trace.AttributeReference:  want.reference,
+			trace.AttributeStatus:     want.status,
+		}
+		if want.digest != "" {
+			wantAttrs[trace.AttributeDigest] = want.digest
+		}
+		if len(span.attrs) != len(wantAttrs) {
+			t.Errorf("span[%d].attrs = %v, want %v", i, span.attrs, wantAttrs)
+			continue
+		}
+		for key, value := range wantAttrs {
+			if got := span.attrs[key]; got != value {
+				t.Errorf("span[%d].attrs[%s] = %s, want %s", i, key, got, value)
+			}
+		}
+	}
+}
@@ -0,0 +1,331 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+	"oras.land/oras-go/v2/registry/remote/internal/errutil"
+)
+
+// defaultUploadChunkSize is the default chunk size of PushChunked.
+const defaultUploadChunkSize int64 = 8 * 1024 * 1024 // 8 MiB
+
+// BlobUpload describes the progress of a chunked blob upload, which can be
+// persisted to resume the upload after a failure.
+type BlobUpload struct {
+	// Location is the absolute URL of the upload session.
+	Location string
+	// Offset is the number of bytes acknowledged by the remote registry.
+	Offset int64
+}
+
+// PushChunkedOptions contains parameters for [Repository.PushChunked].
+type PushChunkedOptions struct {
+	// ChunkSize specifies the maximum size of the chunks.
+	// If less than or equal to zero, Repository.UploadChunkSize is used, or a
+	// default (currently 8 MiB) if it is not positive either.
+	ChunkSize int64
+	// Resume resumes the given upload instead of starting a new upload
+	// session. The acknowledged offset is queried from the remote registry,
+	// and the content before the offset is skipped. If the upload session is
+	// no longer known to the remote registry, a new upload session is started.
+	Resume *BlobUpload
+	// OnProgress is invoked with the progress of the upload after every
+	// chunk acknowledged by the remote registry, which can be persisted for
+	// resuming the upload later.
+	OnProgress func(upload BlobUpload)
+}
+
+// PushChunked pushes the blob described by expected by a sequence of `PATCH`
+// requests uploading the content in chunks, where only the current chunk is
+// held in memory. The upload can be resumed from the last acknowledged chunk
+// by setting opts.Resume to the progress reported by opts.OnProgress.
+// content always provides the whole blob; when resuming, the content before
+// the acknowledged offset is skipped, by seeking if content implements
+// io.Seeker.
+//
+// If the remote registry does not support chunked uploads, the blob is pushed
+// by a monolithic upload instead.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pushing-a-blob-in-chunks
+func (r *Repository) PushChunked(ctx context.Context, expected ocispec.Descriptor, content io.Reader, opts PushChunkedOptions) error {
+	s := &blobStore{repo: r}
+	return s.PushChunked(ctx, expected, content, opts)
+}
+
+// PushChunked pushes the blob described by expected in chunks.
+// See also [Repository.PushChunked].
+func (s *blobStore) PushChunked(ctx context.Context, expected ocispec.Descriptor, content io.Reader, opts PushChunkedOptions) error {
+	if err := s.repo.checkDigestAlgorithm(expected); err != nil {
+		return err
+	}
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = s.repo.UploadChunkSize
+		if chunkSize <= 0 {
+			chunkSize = defaultUploadChunkSize
+		}
+	}
+	// pushing usually requires both pull and push actions.
+	// Reference: https://github.com/distribution/distribution/blob/v2.7.1/registry/handlers/app.go#L921-L930
+	ctx = auth.AppendRepositoryScope(ctx, s.repo.Reference, auth.ActionPull, auth.ActionPush)
+
+	if opts.Resume != nil {
+		location, offset, err := s.uploadStatus(ctx, opts.Resume)
+		if err == nil {
+			if err := skipContent(content, offset); err != nil {
+				return fmt.Errorf("failed to skip content to offset %d: %w", offset, err)
+			}
+			return s.pushChunked(ctx, location, "", expected, content, chunkSize, offset, opts.OnProgress)
+		}
+		var errResp *errcode.ErrorResponse
+		if !errutil.IsBlobUploadSessionError(err) && !(errors.As(err, &errResp) && errResp.StatusCode == http.StatusNotFound) {
+			return err
+		}
+		// the upload session is gone, start over with a new one
+	}
+
+	url := buildRepositoryBlobUploadURL(s.repo.PlainHTTP, s.repo.Reference)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.repo.do(req)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		defer resp.Body.Close()
+		return errutil.ParseErrorResponse(resp)
+	}
+	resp.Body.Close()
+	location, err := s.repo.uploadLocation(req, resp)
+	if err != nil {
+		return err
+	}
+	s.repo.observeChunkMinLength(resp)
+	if minLength, err := strconv.ParseInt(resp.Header.Get(headerOCIChunkMinLength), 10, 64); err == nil && minLength > chunkSize {
+		chunkSize = minLength
+	}
+	if opts.OnProgress != nil {
+		opts.OnProgress(BlobUpload{Location: location.String()})
+	}
+	// reuse credential from previous POST request
+	authHeader := resp.Request.Header.Get("Authorization")
+	return s.pushChunked(ctx, location, authHeader, expected, content, chunkSize, 0, opts.OnProgress)
+}
+
+// uploadStatus queries the location and the acknowledged offset of the given
+// upload session.
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pushing-a-blob-in-chunks
+func (s *blobStore) uploadStatus(ctx context.Context, upload *BlobUpload) (*url.URL, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upload.Location, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := s.repo.do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return nil, 0, errutil.ParseErrorResponse(resp)
+	}
+	location := req.URL
+	if resp.Header.Get("Location") != "" {
+		if location, err = s.repo.uploadLocation(req, resp); err != nil {
+			return nil, 0, err
+		}
+	}
+	end, ok := parseUploadRange(resp.Header.Get("Range"))
+	if !ok || (end == 0 && upload.Offset == 0) {
+		// The range `0-0` is ambiguous as some registries report it for
+		// empty uploads.
+		return location, 0, nil
+	}
+	return location, end + 1, nil
+}
+
+// pushChunked uploads the content from offset to the upload session at
+// location by a sequence of `PATCH` requests of at most chunkSize bytes each,
+// and then closes the session by a `PUT` request without body.
+// Only a single chunk is buffered in memory at a time, and onProgress, if not
+// nil, is invoked after every acknowledged chunk. If the remote registry
+// acknowledges a part of a chunk by the `Range` header, the rest is sent again.
+// If the first chunk is rejected as the method is not supported, the content
+// is pushed monolithically instead.
+//
+// Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#pushing-a-blob-in-chunks
+func (s *blobStore) pushChunked(ctx context.Context, location *url.URL, authHeader string, expected ocispec.Descriptor, content io.Reader, chunkSize, offset int64, onProgress func(upload BlobUpload)) error {
+	if offset == 0 && atomic.LoadInt32(&s.repo.chunkedUploadState) == capabilityStateUnsupported {
+		// chunked upload is known to be not supported by the remote registry
+		return s.pushMonolithic(ctx, location, authHeader, expected, content)
+	}
+	buf := make([]byte, chunkSize)
+	for offset < expected.Size {
+		chunk := buf[:min(chunkSize, expected.Size-offset)]
+		if _, err := io.ReadFull(content, chunk); err != nil {
+			return fmt.Errorf("failed to read content at offset %d: %w", offset, err)
+		}
+		for len(chunk) > 0 {
+			req, err := http.NewRequestWithContext(withoutRequestTimeout(ctx), http.MethodPatch, location.String(), bytes.NewReader(chunk))
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Content-Type", s.repo.blobUploadContentType(expected))
+			req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", offset, offset+int64(len(chunk))-1))
+			if authHeader != "" {
+				req.Header.Set("Authorization", authHeader)
+			}
+			resp, err := s.repo.do(req)
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode != http.StatusAccepted {
+				defer resp.Body.Close()
+				if offset == 0 && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+					// chunked upload is not supported by the remote registry
+					storeCapability(&s.repo.chunkedUploadState, false)
+					return s.pushMonolithic(ctx, location, authHeader, expected, io.MultiReader(bytes.NewReader(chunk), content))
+				}
+				return errutil.ParseErrorResponse(resp)
+			}
+			resp.Body.Close()
+			storeCapability(&s.repo.chunkedUploadState, true)
+			if location, err = s.repo.uploadLocation(req, resp); err != nil {
+				return err
+			}
+			acknowledged := offset + int64(len(chunk))
+			if end, ok := parseUploadRange(resp.Header.Get("Range")); ok && end >= offset && end+1 < acknowledged {
+				acknowledged = end + 1
+			}
+			chunk = chunk[acknowledged-offset:]
+			offset = acknowledged
+			if onProgress != nil {
+				onProgress(BlobUpload{Location: location.String(), Offset: offset})
+			}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, location.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", s.repo.blobUploadContentType(expected))
+	return s.closeUpload(req, authHeader, expected)
+}
+
+// observeChunkMinLength records that chunked uploads are supported if the
+// response to an upload initiation specifies the minimum chunk length.
+func (r *Repository) observeChunkMinLength(resp *http.Response) {
+	if resp.Header.Get(headerOCIChunkMinLength) != "" {
+		storeCapability(&r.chunkedUploadState, true)
+	}
+}
+
+// parseUploadRange parses the `Range` header of the responses to the upload
+// requests in the form of `0-<end>`, where end is inclusive.
+func parseUploadRange(value string) (int64, bool) {
+	value = strings.TrimPrefix(value, "bytes=")
+	start, end, ok := strings.Cut(value, "-")
+	if !ok || start != "0" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(end, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// skipContent skips the first n bytes of content.
+func skipContent(content io.Reader, n int64) error {
+	if n == 0 {
+		return nil
+	}
+	if seeker, ok := content.(io.Seeker); ok {
+		_, err := seeker.Seek(n, io.SeekCurrent)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, content, n)
+	return err
+}
+
+// errReplayReaderDetached is returned by the reads of a replayReader after
+// its content is replayed.
+var errReplayReaderDetached = errors.New("reader detached for replay")
+
+// replayReader records up to limit bytes read from the underlying reader, so
+// that the read content can be replayed as long as no more than limit bytes
+// have been read.
+type replayReader struct {
+	lock     sync.Mutex
+	r        io.Reader
+	limit    int64
+	buf      bytes.Buffer
+	n        int64
+	detached bool
+}
+
+// newReplayReader returns a replayReader reading from r, recording up to
+// limit bytes.
+func newReplayReader(r io.Reader, limit int64) *replayReader {
+	return &replayReader{r: r, limit: limit}
+}
+
+// Read reads from the underlying reader, recording the bytes read.
+func (rr *replayReader) Read(p []byte) (int, error) {
+	rr.lock.Lock()
+	defer rr.lock.Unlock()
+	if rr.detached {
+		return 0, errReplayReaderDetached
+	}
+	n, err := rr.r.Read(p)
+	if remaining := rr.limit - rr.n; remaining > 0 {
+		rr.buf.Write(p[:min(int64(n), remaining)])
+	}
+	rr.n += int64(n)
+	return n, err
+}
+
+// replay returns a reader reading the recorded bytes followed by the rest of
+// the underlying reader, and detaches rr from the underlying reader, so that
+// rr is no longer readable, for instance, by a request still in flight.
+// replay returns false if more than limit bytes have been read.
+func (rr *replayReader) replay() (io.Reader, bool) {
+	rr.lock.Lock()
+	defer rr.lock.Unlock()
+	if rr.detached || rr.n > rr.limit {
+		return nil, false
+	}
+	rr.detached = true
+	return io.MultiReader(bytes.NewReader(rr.buf.Bytes()), rr.r), true
+}
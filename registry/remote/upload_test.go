@@ -0,0 +1,339 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// chunkedUploadServer is a test registry supporting chunked uploads.
+type chunkedUploadServer struct {
+	t        *testing.T
+	lock     sync.Mutex
+	sessions map[string][]byte
+	nextID   int
+	blobs    map[digest.Digest][]byte
+	posts    int
+	patches  int
+	// maxAccept limits the bytes accepted by each PATCH request, if positive.
+	maxAccept int
+	// noChunk rejects PATCH requests if set.
+	noChunk bool
+}
+
+func newChunkedUploadServer(t *testing.T) *chunkedUploadServer {
+	return &chunkedUploadServer{
+		t:        t,
+		sessions: make(map[string][]byte),
+		blobs:    make(map[digest.Digest][]byte),
+	}
+}
+
+func (s *chunkedUploadServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	const uploadPrefix = "/v2/test/blobs/uploads/"
+	if r.Method == http.MethodPost && r.URL.Path == uploadPrefix {
+		s.posts++
+		s.nextID++
+		id := strconv.Itoa(s.nextID)
+		s.sessions[id] = nil
+		w.Header().Set("Location", uploadPrefix+id)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	id, ok := strings.CutPrefix(r.URL.Path, uploadPrefix)
+	if !ok {
+		s.t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	data, ok := s.sessions[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"errors":[{"code":"BLOB_UPLOAD_UNKNOWN"}]}`))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Location", r.URL.Path)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", max(len(data)-1, 0)))
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodPatch:
+		if s.noChunk {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		s.patches++
+		chunk, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.t.Errorf("fail to read: %v", err)
+		}
+		if want := fmt.Sprintf("%d-%d", len(data), len(data)+len(chunk)-1); r.Header.Get("Content-Range") != want {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if s.maxAccept > 0 && len(chunk) > s.maxAccept {
+			chunk = chunk[:s.maxAccept]
+		}
+		data = append(data, chunk...)
+		s.sessions[id] = data
+		w.Header().Set("Location", r.URL.Path)
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(data)-1))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.t.Errorf("fail to read: %v", err)
+		}
+		data = append(data, body...)
+		dgst := digest.Digest(r.URL.Query().Get("digest"))
+		if digest.FromBytes(data) != dgst {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":[{"code":"DIGEST_INVALID"}]}`))
+			return
+		}
+		delete(s.sessions, id)
+		s.blobs[dgst] = data
+		w.Header().Set(headerDockerContentDigest, dgst.String())
+		w.WriteHeader(http.StatusCreated)
+	default:
+		s.t.Errorf("unexpected access: %s %s", r.Method, r.URL)
+		w.WriteHeader(http.StatusForbidden)
+	}
+}
+
+func newChunkedUploadTest(t *testing.T) (*chunkedUploadServer, *Repository, []byte, ocispec.Descriptor) {
+	server := newChunkedUploadServer(t)
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	repo, err := NewRepository(uri.Host + "/test")
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+	repo.PlainHTTP = true
+
+	blob := make([]byte, 100)
+	for i := range blob {
+		blob[i] = byte(i)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: "test",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	return server, repo, blob, desc
+}
+
+func TestRepository_PushChunked(t *testing.T) {
+	server, repo, blob, desc := newChunkedUploadTest(t)
+	ctx := context.Background()
+
+	var offsets []int64
+	opts := PushChunkedOptions{
+		ChunkSize: 30,
+		OnProgress: func(upload BlobUpload) {
+			offsets = append(offsets, upload.Offset)
+		},
+	}
+	if err := repo.PushChunked(ctx, desc, bytes.NewReader(blob), opts); err != nil {
+		t.Fatalf("Repository.PushChunked() error = %v", err)
+	}
+	if got := server.blobs[desc.Digest]; !bytes.Equal(got, blob) {
+		t.Errorf("Repository.PushChunked() = %v, want %v", got, blob)
+	}
+	if want := []int64{0, 30, 60, 90, 100}; !slices.Equal(offsets, want) {
+		t.Errorf("Repository.PushChunked() progress = %v, want %v", offsets, want)
+	}
+}
+
+func TestRepository_PushChunked_PartialAcknowledgement(t *testing.T) {
+	server, repo, blob, desc := newChunkedUploadTest(t)
+	server.maxAccept = 20
+	ctx := context.Background()
+
+	var offsets []int64
+	opts := PushChunkedOptions{
+		ChunkSize: 30,
+		OnProgress: func(upload BlobUpload) {
+			offsets = append(offsets, upload.Offset)
+		},
+	}
+	if err := repo.PushChunked(ctx, desc, bytes.NewReader(blob), opts); err != nil {
+		t.Fatalf("Repository.PushChunked() error = %v", err)
+	}
+	if got := server.blobs[desc.Digest]; !bytes.Equal(got, blob) {
+		t.Errorf("Repository.PushChunked() = %v, want %v", got, blob)
+	}
+	if want := []int64{0, 20, 30, 50, 60, 80, 90, 100}; !slices.Equal(offsets, want) {
+		t.Errorf("Repository.PushChunked() progress = %v, want %v", offsets, want)
+	}
+}
+
+func TestRepository_PushChunked_Resume(t *testing.T) {
+	server, repo, blob, desc := newChunkedUploadTest(t)
+	ctx := context.Background()
+
+	// fail the upload after the second chunk
+	var saved BlobUpload
+	errInterrupted := errors.New("interrupted")
+	content := io.MultiReader(bytes.NewReader(blob[:60]), iotest.ErrReader(errInterrupted))
+	opts := PushChunkedOptions{
+		ChunkSize: 30,
+		OnProgress: func(upload BlobUpload) {
+			saved = upload
+		},
+	}
+	if err := repo.PushChunked(ctx, desc, content, opts); !errors.Is(err, errInterrupted) {
+		t.Fatalf("Repository.PushChunked() error = %v, want %v", err, errInterrupted)
+	}
+	if saved.Offset != 60 {
+		t.Fatalf("Repository.PushChunked() progress = %d, want %d", saved.Offset, 60)
+	}
+
+	// resume the upload with non-seekable content
+	server.patches = 0
+	opts.Resume = &saved
+	if err := repo.PushChunked(ctx, desc, struct{ io.Reader }{bytes.NewReader(blob)}, opts); err != nil {
+		t.Fatalf("Repository.PushChunked() error = %v", err)
+	}
+	if got := server.blobs[desc.Digest]; !bytes.Equal(got, blob) {
+		t.Errorf("Repository.PushChunked() = %v, want %v", got, blob)
+	}
+	if server.posts != 1 {
+		t.Errorf("Repository.PushChunked() sessions = %d, want 1", server.posts)
+	}
+	if server.patches != 2 {
+		t.Errorf("Repository.PushChunked() resumed chunks = %d, want 2", server.patches)
+	}
+	if saved.Offset != 100 {
+		t.Errorf("Repository.PushChunked() progress = %d, want %d", saved.Offset, 100)
+	}
+
+	// resume an unknown upload
+	opts.Resume = &BlobUpload{Location: saved.Location, Offset: 60}
+	if err := repo.PushChunked(ctx, desc, bytes.NewReader(blob), opts); err != nil {
+		t.Fatalf("Repository.PushChunked() error = %v", err)
+	}
+	if server.posts != 2 {
+		t.Errorf("Repository.PushChunked() sessions = %d, want 2", server.posts)
+	}
+}
+
+func TestRepository_PushChunked_Fallback(t *testing.T) {
+	server, repo, blob, desc := newChunkedUploadTest(t)
+	server.noChunk = true
+	ctx := context.Background()
+
+	opts := PushChunkedOptions{
+		ChunkSize: 30,
+	}
+	if err := repo.PushChunked(ctx, desc, bytes.NewReader(blob), opts); err != nil {
+		t.Fatalf("Repository.PushChunked() error = %v", err)
+	}
+	if got := server.blobs[desc.Digest]; !bytes.Equal(got, blob) {
+		t.Errorf("Repository.PushChunked() = %v, want %v", got, blob)
+	}
+	if server.patches != 0 {
+		t.Errorf("Repository.PushChunked() accepted chunks = %d, want 0", server.patches)
+	}
+}
+
+func Test_parseUploadRange(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int64
+		wantOk bool
+	}{
+		{"0-99", 99, true},
+		{"bytes=0-99", 99, true},
+		{"0-0", 0, true},
+		{"", 0, false},
+		{"10-99", 0, false},
+		{"0-", 0, false},
+		{"0--1", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseUploadRange(tt.value)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("parseUploadRange() = (%v, %v), want (%v, %v)", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_replayReader(t *testing.T) {
+	content := []byte("hello world")
+
+	t.Run("replay within limit", func(t *testing.T) {
+		rr := newReplayReader(bytes.NewReader(content), 5)
+		buf := make([]byte, 5)
+		if _, err := io.ReadFull(rr, buf); err != nil {
+			t.Fatalf("replayReader.Read() error = %v", err)
+		}
+		replay, ok := rr.replay()
+		if !ok {
+			t.Fatalf("replayReader.replay() = %v, want %v", ok, true)
+		}
+		got, err := io.ReadAll(replay)
+		if err != nil {
+			t.Fatalf("failed to read replay: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Errorf("replay = %q, want %q", got, content)
+		}
+		// the detached reader is no longer readable
+		if _, err := rr.Read(buf); !errors.Is(err, errReplayReaderDetached) {
+			t.Errorf("replayReader.Read() error = %v, want %v", err, errReplayReaderDetached)
+		}
+		if _, ok := rr.replay(); ok {
+			t.Errorf("replayReader.replay() = %v, want %v", ok, false)
+		}
+	})
+
+	t.Run("read beyond limit", func(t *testing.T) {
+		rr := newReplayReader(iotest.OneByteReader(bytes.NewReader(content)), 5)
+		buf := make([]byte, 6)
+		if _, err := io.ReadFull(rr, buf); err != nil {
+			t.Fatalf("replayReader.Read() error = %v", err)
+		}
+		if _, ok := rr.replay(); ok {
+			t.Errorf("replayReader.replay() = %v, want %v", ok, false)
+		}
+	})
+}
@@ -18,6 +18,7 @@ package remote
 import (
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/opencontainers/go-digest"
@@ -99,6 +100,35 @@ func buildRepositoryBlobMountURL(plainHTTP bool, ref registry.Reference, d diges
 	)
 }
 
+// buildReferrersFilterURL builds the URL for querying the Referrers API with
+// the given filter.
+// Format: <scheme>://<registry>/v2/<repository>/referrers/<digest>?artifactType=<artifactType>&annotation=<key>=<value>
+func buildReferrersFilterURL(plainHTTP bool, ref registry.Reference, filter ReferrersFilter) string {
+	artifactType := filter.queryArtifactType()
+	if len(filter.Annotations) == 0 {
+		return buildReferrersURL(plainHTTP, ref, artifactType)
+	}
+
+	v := url.Values{}
+	if artifactType != "" {
+		v.Set("artifactType", artifactType)
+	}
+	keys := make([]string, 0, len(filter.Annotations))
+	for key := range filter.Annotations {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		v.Add(filterTypeAnnotation, key+"="+filter.Annotations[key])
+	}
+	return fmt.Sprintf(
+		"%s/referrers/%s?%s",
+		buildRepositoryBaseURL(plainHTTP, ref),
+		ref.Reference,
+		v.Encode(),
+	)
+}
+
 // buildReferrersURL builds the URL for querying the Referrers API.
 // Format: <scheme>://<registry>/v2/<repository>/referrers/<digest>?artifactType=<artifactType>
 // Reference: https://github.com/opencontainers/distribution-spec/blob/v1.1.0/spec.md#listing-referrers
@@ -58,13 +58,48 @@ func parseLink(resp *http.Response) (string, error) {
 	return linkURL.String(), nil
 }
 
-// limitReader returns a Reader that reads from r but stops with EOF after n
-// bytes. If n is less than or equal to zero, defaultMaxMetadataBytes is used.
+// limitReader returns a Reader that reads from r but fails with
+// ErrSizeExceedsLimit if r has more than n bytes.
+// If n is less than or equal to zero, defaultMaxMetadataBytes is used.
 func limitReader(r io.Reader, n int64) io.Reader {
 	if n <= 0 {
 		n = defaultMaxMetadataBytes
 	}
-	return io.LimitReader(r, n)
+	return &limitedReader{
+		r:     io.LimitReader(r, n+1),
+		limit: n,
+	}
+}
+
+// limitReadCloser is the io.ReadCloser variant of limitReader.
+func limitReadCloser(rc io.ReadCloser, n int64) io.ReadCloser {
+	return struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: limitReader(rc, n),
+		Closer: rc,
+	}
+}
+
+// limitedReader reads at most limit bytes, and fails with ErrSizeExceedsLimit
+// on reading more.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+// Read reads up to len(p) bytes into p.
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.limit {
+		n -= int(lr.read - lr.limit)
+		lr.read = lr.limit
+		return n, fmt.Errorf("response body exceeds MaxMetadataBytes %v: %w", lr.limit, errdef.ErrSizeExceedsLimit)
+	}
+	return n, err
 }
 
 // limitSize returns ErrSizeExceedsLimit if the size of desc exceeds the limit n.
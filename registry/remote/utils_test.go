@@ -16,7 +16,9 @@ limitations under the License.
 package remote
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
 	"testing"
@@ -154,3 +156,46 @@ func Test_limitSize(t *testing.T) {
 		})
 	}
 }
+
+func Test_limitReader(t *testing.T) {
+	content := []byte("hello world")
+	tests := []struct {
+		name    string
+		n       int64
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "size within specified limit",
+			n:    int64(len(content)) + 1,
+			want: content,
+		},
+		{
+			name: "size equals specified limit",
+			n:    int64(len(content)),
+			want: content,
+		},
+		{
+			name:    "size exceeds specified limit",
+			n:       int64(len(content)) - 1,
+			want:    content[:len(content)-1],
+			wantErr: errdef.ErrSizeExceedsLimit,
+		},
+		{
+			name: "size within default limit",
+			n:    0,
+			want: content,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(limitReader(bytes.NewReader(content), tt.n))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("limitReader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("limitReader() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
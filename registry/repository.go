@@ -18,10 +18,13 @@ package registry
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
 	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/internal/descriptor"
@@ -128,6 +131,28 @@ type Mounter interface {
 	) error
 }
 
+// PushChecker checks if content can be pushed to a repository without
+// transferring any content, so that a lack of permission can be detected
+// before a long transfer.
+// For backward compatibility reasons, this is not implemented by
+// BlobStore: use a type assertion to check availability.
+type PushChecker interface {
+	// CheckPush returns nil if content can be pushed to the repository.
+	CheckPush(ctx context.Context) error
+}
+
+// Locator provides the location of a repository, so that the repositories in
+// the same registry can be identified, such as for cross-repository mounting.
+// Wrappers of a repository should forward Locate to the wrapped repository.
+// For backward compatibility reasons, this is not implemented by
+// BlobStore: use a type assertion to check availability.
+type Locator interface {
+	// Locate returns the host of the registry, including the port if any,
+	// and the name of the repository. Empty strings are returned if the
+	// location is unknown.
+	Locate() (host, repository string)
+}
+
 // Tags lists the tags available in the repository.
 func Tags(ctx context.Context, repo TagLister) ([]string, error) {
 	var res []string
@@ -140,6 +165,99 @@ func Tags(ctx context.Context, repo TagLister) ([]string, error) {
 	return res, nil
 }
 
+// defaultTagsOfConcurrency is the default value of TagsOfOptions.Concurrency.
+const defaultTagsOfConcurrency = 3
+
+// ResolvableTagLister lists tags and resolves them to descriptors.
+type ResolvableTagLister interface {
+	TagLister
+	content.Resolver
+}
+
+// TagsOfOptions contains parameters for [registry.TagsOf].
+type TagsOfOptions struct {
+	// Concurrency limits the maximum number of concurrent tag resolutions.
+	// If less than or equal to 0, a default (currently 3) is used.
+	Concurrency int
+}
+
+// TagsOf lists the tags in the repository pointing to the content identified
+// by dgst, in the order of the tag list. Tags removed while being listed are
+// ignored.
+//
+// TagsOf lists all the tags and resolves each of them, which is costly for
+// repositories with many tags: for a remote repository, it costs the tag list
+// requests plus a HEAD request per tag.
+func TagsOf(ctx context.Context, repo ResolvableTagLister, dgst digest.Digest, opts TagsOfOptions) ([]string, error) {
+	tags, err := Tags(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultTagsOfConcurrency
+	}
+
+	matched := make([]bool, len(tags))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(opts.Concurrency)
+	for i, tag := range tags {
+		eg.Go(func() error {
+			desc, err := repo.Resolve(egCtx, tag)
+			if err != nil {
+				if errors.Is(err, errdef.ErrNotFound) {
+					// the tag is removed after being listed
+					return nil
+				}
+				return fmt.Errorf("failed to resolve %s: %w", tag, err)
+			}
+			matched[i] = desc.Digest == dgst
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for i, tag := range tags {
+		if matched[i] {
+			res = append(res, tag)
+		}
+	}
+	return res, nil
+}
+
+// FindRepository locates the content identified by dgst among the candidate
+// repositories, and returns the first repository where the content exists,
+// along with the resolved descriptor of the content.
+// In each repository, the manifest store is looked up before the blob store.
+// If the content exists in none of the repositories, ErrNotFound is returned.
+func FindRepository(ctx context.Context, dgst digest.Digest, repos []Repository) (Repository, ocispec.Descriptor, error) {
+	if err := dgst.Validate(); err != nil {
+		return nil, ocispec.Descriptor{}, fmt.Errorf("invalid digest %q: %w", dgst, err)
+	}
+	for _, repo := range repos {
+		desc, err := resolveDigest(ctx, repo, dgst)
+		if err == nil {
+			return repo, desc, nil
+		}
+		if !errors.Is(err, errdef.ErrNotFound) {
+			return nil, ocispec.Descriptor{}, err
+		}
+	}
+	return nil, ocispec.Descriptor{}, fmt.Errorf("%s: %w", dgst, errdef.ErrNotFound)
+}
+
+// resolveDigest resolves dgst from the manifest store and then the blob store
+// of repo.
+func resolveDigest(ctx context.Context, repo Repository, dgst digest.Digest) (ocispec.Descriptor, error) {
+	desc, err := repo.Manifests().Resolve(ctx, dgst.String())
+	if err == nil || !errors.Is(err, errdef.ErrNotFound) {
+		return desc, err
+	}
+	return repo.Blobs().Resolve(ctx, dgst.String())
+}
+
 // Referrers lists the descriptors of image or artifact manifests directly
 // referencing the given manifest descriptor.
 //
@@ -403,3 +403,192 @@ func equalDescriptorSet(actual []ocispec.Descriptor, expected []ocispec.Descript
 	}
 	return true
 }
+
+// testResolveStore implements the Resolve method of BlobStore and
+// ManifestStore.
+type testResolveStore struct {
+	ManifestStore
+	contents map[digest.Digest]ocispec.Descriptor
+	resolved int
+}
+
+func (s *testResolveStore) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	s.resolved++
+	desc, ok := s.contents[digest.Digest(reference)]
+	if !ok {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+	}
+	return desc, nil
+}
+
+// testResolveRepository implements the Blobs and the Manifests methods of
+// Repository.
+type testResolveRepository struct {
+	Repository
+	blobs     *testResolveStore
+	manifests *testResolveStore
+}
+
+func newTestResolveRepository(blobs, manifests []ocispec.Descriptor) *testResolveRepository {
+	repo := &testResolveRepository{
+		blobs:     &testResolveStore{contents: make(map[digest.Digest]ocispec.Descriptor)},
+		manifests: &testResolveStore{contents: make(map[digest.Digest]ocispec.Descriptor)},
+	}
+	for _, desc := range blobs {
+		repo.blobs.contents[desc.Digest] = desc
+	}
+	for _, desc := range manifests {
+		repo.manifests.contents[desc.Digest] = desc
+	}
+	return repo
+}
+
+func (r *testResolveRepository) Blobs() BlobStore {
+	return r.blobs
+}
+
+func (r *testResolveRepository) Manifests() ManifestStore {
+	return r.manifests
+}
+
+func TestFindRepository(t *testing.T) {
+	blob := []byte("hello world")
+	blobDesc := ocispec.Descriptor{
+		MediaType: "application/octet-stream",
+		Digest:    digest.FromBytes(blob),
+		Size:      int64(len(blob)),
+	}
+	manifest := []byte(`{"layers":[]}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	ctx := context.Background()
+
+	repos := []*testResolveRepository{
+		newTestResolveRepository(nil, nil),
+		newTestResolveRepository([]ocispec.Descriptor{blobDesc}, []ocispec.Descriptor{manifestDesc}),
+		newTestResolveRepository([]ocispec.Descriptor{blobDesc}, []ocispec.Descriptor{manifestDesc}),
+	}
+	candidates := make([]Repository, len(repos))
+	for i, repo := range repos {
+		candidates[i] = repo
+	}
+
+	// find blob
+	got, gotDesc, err := FindRepository(ctx, blobDesc.Digest, candidates)
+	if err != nil {
+		t.Fatalf("FindRepository() error = %v", err)
+	}
+	if got != candidates[1] {
+		t.Errorf("FindRepository() = %v, want %v", got, candidates[1])
+	}
+	if !reflect.DeepEqual(gotDesc, blobDesc) {
+		t.Errorf("FindRepository() descriptor = %v, want %v", gotDesc, blobDesc)
+	}
+	if repos[2].blobs.resolved != 0 || repos[2].manifests.resolved != 0 {
+		t.Errorf("FindRepository() looked up repository after the found one")
+	}
+
+	// find manifest
+	got, gotDesc, err = FindRepository(ctx, manifestDesc.Digest, candidates)
+	if err != nil {
+		t.Fatalf("FindRepository() error = %v", err)
+	}
+	if got != candidates[1] {
+		t.Errorf("FindRepository() = %v, want %v", got, candidates[1])
+	}
+	if !reflect.DeepEqual(gotDesc, manifestDesc) {
+		t.Errorf("FindRepository() descriptor = %v, want %v", gotDesc, manifestDesc)
+	}
+
+	// not found
+	_, _, err = FindRepository(ctx, digest.FromString("foo"), candidates)
+	if !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("FindRepository() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+
+	// invalid digest
+	_, _, err = FindRepository(ctx, "sha256:invalid", candidates)
+	if err == nil {
+		t.Errorf("FindRepository() error = %v, wantErr %v", err, true)
+	}
+}
+
+// testTagStore implements ResolvableTagLister.
+type testTagStore struct {
+	tags   []string
+	descs  map[string]ocispec.Descriptor
+	errTag string
+}
+
+func (s *testTagStore) Tags(ctx context.Context, last string, fn func(tags []string) error) error {
+	// paginate the tags one by one
+	for _, tag := range s.tags {
+		if err := fn([]string{tag}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *testTagStore) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if reference == s.errTag {
+		return ocispec.Descriptor{}, ErrBadFetch
+	}
+	desc, ok := s.descs[reference]
+	if !ok {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %w", reference, errdef.ErrNotFound)
+	}
+	return desc, nil
+}
+
+func TestTagsOf(t *testing.T) {
+	generateDesc := func(content string) ocispec.Descriptor {
+		blob := []byte(content)
+		return ocispec.Descriptor{
+			MediaType: ocispec.MediaTypeImageManifest,
+			Digest:    digest.FromBytes(blob),
+			Size:      int64(len(blob)),
+		}
+	}
+	foo := generateDesc("foo")
+	bar := generateDesc("bar")
+	store := &testTagStore{
+		tags: []string{"v1", "latest", "v2", "stable", "removed", "v1.0"},
+		descs: map[string]ocispec.Descriptor{
+			"v1":     foo,
+			"latest": bar,
+			"v2":     bar,
+			"stable": foo,
+			"v1.0":   generateDesc("baz"),
+		},
+	}
+	ctx := context.Background()
+
+	for _, concurrency := range []int{0, 1, 10} {
+		got, err := TagsOf(ctx, store, foo.Digest, TagsOfOptions{Concurrency: concurrency})
+		if err != nil {
+			t.Fatalf("TagsOf() error = %v", err)
+		}
+		if want := []string{"v1", "stable"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("TagsOf(Concurrency: %d) = %v, want %v", concurrency, got, want)
+		}
+	}
+
+	// no matching tags
+	got, err := TagsOf(ctx, store, generateDesc("qux").Digest, TagsOfOptions{})
+	if err != nil {
+		t.Fatalf("TagsOf() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("TagsOf() = %v, want empty", got)
+	}
+
+	// resolution failure
+	store.errTag = "v2"
+	if _, err := TagsOf(ctx, store, foo.Digest, TagsOfOptions{}); !errors.Is(err, ErrBadFetch) {
+		t.Errorf("TagsOf() error = %v, want %v", err, ErrBadFetch)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/docker"
+)
+
+// schema1Guidance is the guidance attached to the errors on fetching Docker
+// schema 1 manifests.
+const schema1Guidance = "docker schema 1 manifests are deprecated and not supported, " +
+	"convert the image to docker schema 2 or OCI format, for example by pulling and pushing it again with a recent docker client"
+
+// Schema1RejectingTarget represents a ReadOnlyTarget that rejects fetching
+// deprecated Docker schema 1 manifests with an explicit error, instead of
+// returning content unusable by OCI tooling.
+//
+// Docker schema 1 manifests are not converted, as the conversion requires the
+// diff IDs of the layers, which are only known by decompressing the layers.
+type Schema1RejectingTarget struct {
+	ReadOnlyTarget // underlying target
+}
+
+// RejectSchema1 returns a target that rejects fetching Docker schema 1
+// manifests from the given target.
+func RejectSchema1(target ReadOnlyTarget) *Schema1RejectingTarget {
+	return &Schema1RejectingTarget{
+		ReadOnlyTarget: target,
+	}
+}
+
+// Resolve resolves a reference to a descriptor.
+// An error wrapping errdef.ErrUnsupported is returned if the reference is
+// resolved to a Docker schema 1 manifest.
+func (t *Schema1RejectingTarget) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	desc, err := t.ReadOnlyTarget.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if isSchema1MediaType(desc.MediaType) {
+		return ocispec.Descriptor{}, fmt.Errorf("%s: %s: %s: %w", reference, desc.MediaType, schema1Guidance, errdef.ErrUnsupported)
+	}
+	return desc, nil
+}
+
+// Fetch fetches the content identified by the descriptor.
+// An error wrapping errdef.ErrUnsupported is returned if the content is a
+// Docker schema 1 manifest, including the ones served under other manifest
+// media types.
+func (t *Schema1RejectingTarget) Fetch(ctx context.Context, target ocispec.Descriptor) (io.ReadCloser, error) {
+	if isSchema1MediaType(target.MediaType) {
+		return nil, fmt.Errorf("%s: %s: %s: %w", target.Digest, target.MediaType, schema1Guidance, errdef.ErrUnsupported)
+	}
+	rc, err := t.ReadOnlyTarget.Fetch(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	if !isManifestMediaType(target.MediaType) || target.Size > defaultAllowlistMaxManifestBytes {
+		return rc, nil
+	}
+
+	// inspect the manifest content for mislabeled schema 1 manifests
+	defer rc.Close()
+	manifestJSON, err := content.ReadAll(rc, target)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, err := parseManifestMediaType(manifestJSON)
+	if err == nil && isSchema1MediaType(mediaType) {
+		return nil, fmt.Errorf("%s: %s: manifest content: %s: %w", target.Digest, target.MediaType, schema1Guidance, errdef.ErrUnsupported)
+	}
+	return io.NopCloser(bytes.NewReader(manifestJSON)), nil
+}
+
+// isSchema1MediaType checks if mediaType is one of the deprecated Docker
+// schema 1 manifest media types.
+func isSchema1MediaType(mediaType string) bool {
+	return mediaType == docker.MediaTypeManifestSchema1 || mediaType == docker.MediaTypeManifestSchema1Signed
+}
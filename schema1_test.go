@@ -0,0 +1,115 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/docker"
+)
+
+func TestSchema1RejectingTarget(t *testing.T) {
+	layer := []byte("foo")
+	layerDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageLayer, layer)
+	configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, []byte("{}"))
+	manifestJSON, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema1JSON := []byte(`{"schemaVersion":1,"name":"test","tag":"latest","fsLayers":[],"history":[]}`)
+	signedSchema1JSON := []byte(`{"schemaVersion":1,"name":"test","tag":"latest","fsLayers":[],"history":[],"signatures":[]}`)
+
+	tests := []struct {
+		name           string
+		desc           ocispec.Descriptor
+		content        []byte
+		wantResolveErr error
+		wantFetchErr   error
+	}{
+		{
+			name:    "layer",
+			desc:    layerDesc,
+			content: layer,
+		},
+		{
+			name:    "OCI manifest",
+			desc:    content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON),
+			content: manifestJSON,
+		},
+		{
+			name:           "schema 1 manifest",
+			desc:           content.NewDescriptorFromBytes(docker.MediaTypeManifestSchema1, schema1JSON),
+			content:        schema1JSON,
+			wantResolveErr: errdef.ErrUnsupported,
+			wantFetchErr:   errdef.ErrUnsupported,
+		},
+		{
+			name:           "signed schema 1 manifest",
+			desc:           content.NewDescriptorFromBytes(docker.MediaTypeManifestSchema1Signed, signedSchema1JSON),
+			content:        signedSchema1JSON,
+			wantResolveErr: errdef.ErrUnsupported,
+			wantFetchErr:   errdef.ErrUnsupported,
+		},
+		{
+			name:         "schema 1 manifest disguised as docker manifest",
+			desc:         content.NewDescriptorFromBytes(docker.MediaTypeManifest, schema1JSON),
+			content:      schema1JSON,
+			wantFetchErr: errdef.ErrUnsupported,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.New()
+			if err := store.Push(ctx, tt.desc, bytes.NewReader(tt.content)); err != nil {
+				t.Fatalf("Store.Push() error = %v", err)
+			}
+			ref := "latest"
+			if err := store.Tag(ctx, tt.desc, ref); err != nil {
+				t.Fatalf("Store.Tag() error = %v", err)
+			}
+			target := RejectSchema1(store)
+
+			desc, err := target.Resolve(ctx, ref)
+			if !errors.Is(err, tt.wantResolveErr) {
+				t.Fatalf("Schema1RejectingTarget.Resolve() error = %v, wantErr %v", err, tt.wantResolveErr)
+			}
+			if err == nil && !content.Equal(desc, tt.desc) {
+				t.Errorf("Schema1RejectingTarget.Resolve() = %v, want %v", desc, tt.desc)
+			}
+
+			got, err := content.FetchAll(ctx, target, tt.desc)
+			if !errors.Is(err, tt.wantFetchErr) {
+				t.Fatalf("Schema1RejectingTarget.Fetch() error = %v, wantErr %v", err, tt.wantFetchErr)
+			}
+			if err == nil && !bytes.Equal(got, tt.content) {
+				t.Errorf("Schema1RejectingTarget.Fetch() = %s, want %s", got, tt.content)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/internal/docker"
+	"oras.land/oras-go/v2/registry"
+)
+
+// ErrMissingSignature is returned by SignatureRequiredTarget when an image
+// is tagged without a required signature.
+var ErrMissingSignature = errors.New("missing signature")
+
+// errSignatureFound signals that a signature is found while listing the
+// referrers.
+var errSignatureFound = errors.New("signature found")
+
+// SignatureRequiredTarget represents a Target that only accepts tagging images
+// having a referrer of the required artifact type, such as a signature.
+//
+// The referrers are listed by the Referrers method of the underlying target if
+// it implements registry.ReferrerLister, or by its Predecessors method if it
+// implements content.ReadOnlyGraphStorage. Tagging fails with ErrUnsupported
+// if the underlying target supports neither.
+//
+// The presence of the signature is checked before tagging, which is not atomic
+// with the tagging itself:
+//   - The signature can be deleted after the check, leaving the image tagged
+//     without a signature.
+//   - The signature has to be pushed before the image is tagged. Pushing the
+//     image and its signature concurrently may fail the tagging.
+//   - Registries updating the referrers list asynchronously may not list a
+//     newly pushed signature in time.
+//   - Clients tagging the image with the underlying target directly are not
+//     checked.
+//
+// Therefore, SignatureRequiredTarget is a guard against mistakes rather than
+// an enforcement of a security policy on its own.
+type SignatureRequiredTarget struct {
+	Target              // underlying target
+	ArtifactType string // artifact type of the required referrer
+}
+
+// RequireSignature returns a target that only accepts tagging images having a
+// referrer of the given artifact type.
+func RequireSignature(target Target, artifactType string) *SignatureRequiredTarget {
+	return &SignatureRequiredTarget{
+		Target:       target,
+		ArtifactType: artifactType,
+	}
+}
+
+// Tag tags a descriptor with a reference string.
+// If desc describes an image manifest or an image index, it must have a
+// referrer of the required artifact type in the underlying target.
+// Returns ErrMissingSignature otherwise.
+func (t *SignatureRequiredTarget) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	if isImageMediaType(desc.MediaType) {
+		signed, err := t.hasSignature(ctx, desc)
+		if err != nil {
+			return fmt.Errorf("%s: %s: failed to find signature: %w", desc.Digest, desc.MediaType, err)
+		}
+		if !signed {
+			return fmt.Errorf("%s: %s: no referrer of artifact type %q: %w", desc.Digest, desc.MediaType, t.ArtifactType, ErrMissingSignature)
+		}
+	}
+	return t.Target.Tag(ctx, desc, reference)
+}
+
+// hasSignature returns true if desc has a referrer of the required artifact
+// type.
+func (t *SignatureRequiredTarget) hasSignature(ctx context.Context, desc ocispec.Descriptor) (bool, error) {
+	switch target := t.Target.(type) {
+	case registry.ReferrerLister:
+		err := target.Referrers(ctx, desc, t.ArtifactType, func(referrers []ocispec.Descriptor) error {
+			for _, referrer := range referrers {
+				if referrer.ArtifactType == t.ArtifactType {
+					return errSignatureFound
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, errSignatureFound) {
+			return true, nil
+		}
+		return false, err
+	case content.ReadOnlyGraphStorage:
+		referrers, err := registry.Referrers(ctx, target, desc, t.ArtifactType)
+		if err != nil {
+			return false, err
+		}
+		return len(referrers) > 0, nil
+	default:
+		return false, fmt.Errorf("listing referrers: %w", errdef.ErrUnsupported)
+	}
+}
+
+// isImageMediaType checks if mediaType is the media type of an image manifest
+// or an image index.
+func isImageMediaType(mediaType string) bool {
+	switch mediaType {
+	case ocispec.MediaTypeImageManifest, ocispec.MediaTypeImageIndex,
+		docker.MediaTypeManifest, docker.MediaTypeManifestList:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
+)
+
+func TestSignatureRequiredTarget_Tag(t *testing.T) {
+	const signatureType = "application/vnd.test.signature"
+	ctx := context.Background()
+	store := memory.New()
+	push := func(desc ocispec.Descriptor, blob []byte) {
+		if err := store.Push(ctx, desc, bytes.NewReader(blob)); err != nil {
+			t.Fatalf("Store.Push() error = %v", err)
+		}
+	}
+	pushManifest := func(manifest ocispec.Manifest) ocispec.Descriptor {
+		manifest.MediaType = ocispec.MediaTypeImageManifest
+		manifestJSON, err := json.Marshal(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+		push(desc, manifestJSON)
+		return desc
+	}
+	config := []byte("{}")
+	configDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageConfig, config)
+	push(configDesc, config)
+	image := pushManifest(ocispec.Manifest{
+		Config: configDesc,
+		Layers: []ocispec.Descriptor{},
+	})
+
+	target := RequireSignature(store, signatureType)
+
+	// tagging an unsigned image is rejected
+	if err := target.Tag(ctx, image, "unsigned"); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("SignatureRequiredTarget.Tag() error = %v, wantErr %v", err, ErrMissingSignature)
+	}
+	if _, err := store.Resolve(ctx, "unsigned"); !errors.Is(err, errdef.ErrNotFound) {
+		t.Errorf("Store.Resolve() error = %v, wantErr %v", err, errdef.ErrNotFound)
+	}
+
+	// referrers of other artifact types do not count
+	pushManifest(ocispec.Manifest{
+		ArtifactType: "application/vnd.test.sbom",
+		Config:       ocispec.DescriptorEmptyJSON,
+		Layers:       []ocispec.Descriptor{},
+		Subject:      &image,
+	})
+	if err := target.Tag(ctx, image, "unsigned"); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("SignatureRequiredTarget.Tag() error = %v, wantErr %v", err, ErrMissingSignature)
+	}
+
+	// tagging a signed image is allowed
+	pushManifest(ocispec.Manifest{
+		ArtifactType: signatureType,
+		Config:       ocispec.DescriptorEmptyJSON,
+		Layers:       []ocispec.Descriptor{},
+		Subject:      &image,
+	})
+	if err := target.Tag(ctx, image, "signed"); err != nil {
+		t.Fatalf("SignatureRequiredTarget.Tag() error = %v", err)
+	}
+	got, err := store.Resolve(ctx, "signed")
+	if err != nil {
+		t.Fatalf("Store.Resolve() error = %v", err)
+	}
+	if !content.Equal(got, image) {
+		t.Errorf("Store.Resolve() = %v, want %v", got, image)
+	}
+
+	// tagging non-image content is not checked
+	if err := target.Tag(ctx, configDesc, "config"); err != nil {
+		t.Errorf("SignatureRequiredTarget.Tag() error = %v", err)
+	}
+}
+
+func TestSignatureRequiredTarget_Tag_Unsupported(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	manifestJSON := []byte(`{"layers":[]}`)
+	desc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestJSON)
+	if err := store.Push(ctx, desc, bytes.NewReader(manifestJSON)); err != nil {
+		t.Fatalf("Store.Push() error = %v", err)
+	}
+
+	// hide the Predecessors method of the store
+	target := RequireSignature(struct{ Target }{store}, "application/vnd.test.signature")
+	if err := target.Tag(ctx, desc, "latest"); !errors.Is(err, errdef.ErrUnsupported) {
+		t.Errorf("SignatureRequiredTarget.Tag() error = %v, wantErr %v", err, errdef.ErrUnsupported)
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package trace defines the tracing interfaces, which can be bridged to
+// tracing frameworks such as OpenTelemetry.
+package trace
+
+import "context"
+
+// Span names of the traced operations.
+const (
+	// SpanFetch is the name of the spans fetching content, or listing tags
+	// and referrers.
+	SpanFetch = "oras.fetch"
+	// SpanPush is the name of the spans pushing content.
+	SpanPush = "oras.push"
+	// SpanResolve is the name of the spans resolving references.
+	SpanResolve = "oras.resolve"
+	// SpanDelete is the name of the spans deleting content.
+	SpanDelete = "oras.delete"
+	// SpanCopyNode is the name of the spans copying a single node of a graph.
+	SpanCopyNode = "oras.copy_node"
+)
+
+// Attribute keys of the spans.
+const (
+	// AttributeRegistry is the key of the registry host attribute.
+	AttributeRegistry = "oras.registry"
+	// AttributeRepository is the key of the repository name attribute.
+	AttributeRepository = "oras.repository"
+	// AttributeReference is the key of the tag or digest reference attribute.
+	AttributeReference = "oras.reference"
+	// AttributeDigest is the key of the content digest attribute.
+	AttributeDigest = "oras.digest"
+	// AttributeMediaType is the key of the content media type attribute.
+	AttributeMediaType = "oras.media_type"
+	// AttributeMethod is the key of the HTTP method attribute.
+	AttributeMethod = "http.method"
+	// AttributeStatus is the key of the HTTP status code attribute.
+	AttributeStatus = "http.status_code"
+)
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Tracer starts spans for traced operations.
+type Tracer interface {
+	// StartSpan starts a span with the given name and attributes, and returns
+	// a context containing the span.
+	StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span represents a traced operation.
+type Span interface {
+	// SetAttributes sets attributes on the span.
+	SetAttributes(attrs ...Attribute)
+	// End completes the span. err is the error of the operation, if any.
+	End(err error)
+}
@@ -0,0 +1,202 @@
+/*
+Copyright The ORAS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package oras
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/internal/descriptor"
+	"oras.land/oras-go/v2/registry"
+)
+
+// transformStorage transforms the manifests pushed to the underlying storage,
+// and rewrites the subjects of the referrers and the manifests listed by the
+// indexes pointing to the transformed manifests.
+type transformStorage struct {
+	content.Storage
+	transform func(ctx context.Context, desc ocispec.Descriptor, manifestJSON []byte) ([]byte, error)
+
+	lock        sync.RWMutex
+	transformed map[digest.Digest]ocispec.Descriptor
+}
+
+// newTransformStorage creates a transformStorage on top of the given storage.
+func newTransformStorage(s content.Storage, transform func(ctx context.Context, desc ocispec.Descriptor, manifestJSON []byte) ([]byte, error)) *transformStorage {
+	return &transformStorage{
+		Storage:     s,
+		transform:   transform,
+		transformed: make(map[digest.Digest]ocispec.Descriptor),
+	}
+}
+
+// Exists returns true if the described content, or its transformed content,
+// exists.
+func (s *transformStorage) Exists(ctx context.Context, target ocispec.Descriptor) (bool, error) {
+	return s.Storage.Exists(ctx, s.mapped(target))
+}
+
+// Push transforms the manifest read from r and pushes the transformed
+// manifest. Non-manifest content is pushed as is.
+func (s *transformStorage) Push(ctx context.Context, expected ocispec.Descriptor, r io.Reader) error {
+	if !descriptor.IsManifest(expected) {
+		return s.Storage.Push(ctx, expected, r)
+	}
+	manifestJSON, err := content.ReadAll(r, expected)
+	if err != nil {
+		return err
+	}
+	manifestJSON, err = s.transform(ctx, expected, manifestJSON)
+	if err != nil {
+		return fmt.Errorf("%s: %s: failed to transform manifest: %w", expected.Digest, expected.MediaType, err)
+	}
+	manifestJSON, err = s.rewriteReferences(manifestJSON)
+	if err != nil {
+		return fmt.Errorf("%s: %s: failed to rewrite references: %w", expected.Digest, expected.MediaType, err)
+	}
+
+	desc := expected
+	if dgst := expected.Digest.Algorithm().FromBytes(manifestJSON); dgst != expected.Digest {
+		desc.Digest = dgst
+		desc.Size = int64(len(manifestJSON))
+		s.lock.Lock()
+		s.transformed[expected.Digest] = desc
+		s.lock.Unlock()
+	}
+	return s.Storage.Push(ctx, desc, bytes.NewReader(manifestJSON))
+}
+
+// mapped returns the descriptor of the transformed content of desc, or desc
+// itself if it is not transformed.
+func (s *transformStorage) mapped(desc ocispec.Descriptor) ocispec.Descriptor {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if transformed, ok := s.transformed[desc.Digest]; ok {
+		desc.Digest = transformed.Digest
+		desc.Size = transformed.Size
+	}
+	return desc
+}
+
+// rewriteReferences rewrites the subject of the manifest, and the manifests
+// listed by the index, to their transformed descriptors. The manifest is
+// returned unchanged if none of its references is transformed.
+func (s *transformStorage) rewriteReferences(manifestJSON []byte) ([]byte, error) {
+	var manifest map[string]json.RawMessage
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, err
+	}
+	rewritten := false
+	if subjectJSON, ok := manifest["subject"]; ok {
+		var subject ocispec.Descriptor
+		if err := json.Unmarshal(subjectJSON, &subject); err != nil {
+			return nil, err
+		}
+		if mapped := s.mapped(subject); mapped.Digest != subject.Digest {
+			subjectJSON, err := marshalJSON(mapped)
+			if err != nil {
+				return nil, err
+			}
+			manifest["subject"] = subjectJSON
+			rewritten = true
+		}
+	}
+	if manifestsJSON, ok := manifest["manifests"]; ok {
+		var manifests []ocispec.Descriptor
+		if err := json.Unmarshal(manifestsJSON, &manifests); err != nil {
+			return nil, err
+		}
+		changed := false
+		for i, desc := range manifests {
+			if mapped := s.mapped(desc); mapped.Digest != desc.Digest {
+				manifests[i] = mapped
+				changed = true
+			}
+		}
+		if changed {
+			manifestsJSON, err := marshalJSON(manifests)
+			if err != nil {
+				return nil, err
+			}
+			manifest["manifests"] = manifestsJSON
+			rewritten = true
+		}
+	}
+	if !rewritten {
+		return manifestJSON, nil
+	}
+	return marshalJSON(manifest)
+}
+
+// addAnnotations adds the annotations to the manifest, overwriting the
+// annotations with the same keys. The other fields of the manifest, including
+// the unknown ones, are preserved.
+func addAnnotations(manifestJSON []byte, annotations map[string]string) ([]byte, error) {
+	// decode the fields generically so that all the other fields are preserved
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(manifestJSON, &fields); err != nil {
+		return nil, err
+	}
+	merged := make(map[string]string)
+	if raw, ok := fields["annotations"]; ok {
+		if err := json.Unmarshal(raw, &merged); err != nil {
+			return nil, fmt.Errorf("failed to decode annotations: %w", err)
+		}
+		if merged == nil {
+			merged = make(map[string]string)
+		}
+	}
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	annotationsJSON, err := marshalJSON(merged)
+	if err != nil {
+		return nil, err
+	}
+	fields["annotations"] = annotationsJSON
+	return marshalJSON(fields)
+}
+
+// marshalJSON returns the JSON encoding of v without escaping HTML characters,
+// so that the raw JSON values in v are preserved as is.
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// transformMounter is a transformStorage on top of a destination supporting
+// cross-repository mounting.
+type transformMounter struct {
+	*transformStorage
+	registry.Mounter
+}
+
+// Locate returns the location of the underlying destination, if known.
+func (tm *transformMounter) Locate() (string, string) {
+	return locate(tm.Mounter)
+}